@@ -2,32 +2,56 @@ package auth
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/baralga/shared"
+	"github.com/baralga/user"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
 	"schneider.vip/problem"
 )
 
+// loginModel signs in to the organization with the id or to the default organization of the user
 type loginModel struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username       string `json:"username"`
+	Password       string `json:"password"`
+	OrganizationID string `json:"organizationId"`
 }
 
+// loginResponseModel contains the short lived access token and the refresh token of the session,
+// the refresh token is omitted if it was rotated by another request just before
 type loginResponseModel struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// twoFactorRequiredModel is the response to a login with a second factor, the login
+// is completed with the two factor token and a code of the authenticator app
+type twoFactorRequiredModel struct {
+	TwoFactorToken string `json:"two_factor_token"`
+}
+
+type twoFactorLoginModel struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+}
+
+type refreshModel struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type AuthRestHandlers struct {
 	config      *shared.Config
 	authService *AuthService
-	tokenAuth   *jwtauth.JWTAuth
+	tokenAuth   *TokenAuth
 }
 
-func NewAuthRestHandlers(config *shared.Config, authService *AuthService, tokenAuth *jwtauth.JWTAuth) *AuthRestHandlers {
+func NewAuthRestHandlers(config *shared.Config, authService *AuthService, tokenAuth *TokenAuth) *AuthRestHandlers {
 	return &AuthRestHandlers{
 		config:      config,
 		authService: authService,
@@ -36,16 +60,19 @@ func NewAuthRestHandlers(config *shared.Config, authService *AuthService, tokenA
 }
 
 func (a *AuthRestHandlers) RegisterProtected(r chi.Router) {
+	r.Post("/auth/logout", a.HandleLogout())
 }
 
 func (a *AuthRestHandlers) RegisterOpen(r chi.Router) {
 	r.Post("/auth/login", a.HandleLogin())
+	r.Post("/auth/login/two-factor", a.HandleTwoFactorLogin())
+	r.Post("/auth/refresh", a.HandleRefresh())
 }
 
-// HandleLogin handles the authentication request of a user
+// HandleLogin handles the authentication request of a user, users with a second factor
+// get a two factor token instead of the access token
 func (a *AuthRestHandlers) HandleLogin() http.HandlerFunc {
-	tokenAuth := a.tokenAuth
-	expiryDuration := a.config.ExpiryDuration()
+	isProduction := a.config.IsProduction()
 	authService := a.authService
 	return func(w http.ResponseWriter, r *http.Request) {
 		var loginModel loginModel
@@ -55,35 +82,199 @@ func (a *AuthRestHandlers) HandleLogin() http.HandlerFunc {
 			return
 		}
 
-		principal, err := authService.Authenticate(r.Context(), loginModel.Username, loginModel.Password)
+		organizationID := uuid.Nil
+		if loginModel.OrganizationID != "" {
+			organizationID, err = uuid.Parse(loginModel.OrganizationID)
+			if err != nil {
+				http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+				return
+			}
+		}
+
+		principal, err := authService.AuthenticateThrottled(r.Context(), loginModel.Username, loginModel.Password, organizationID, clientIP(r))
+		if errors.Is(err, user.ErrAccountLocked) || errors.Is(err, user.ErrLoginThrottled) {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusTooManyRequests)
+			return
+		}
 		if err != nil {
 			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusForbidden)
 			return
 		}
 
+		step, err := authService.RequiredTwoFactorStep(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		switch step {
+		case TwoFactorStepEnroll:
+			http.Error(w, problem.New(problem.Title("two factor enrollment required")).JSONString(), http.StatusForbidden)
+		case TwoFactorStepVerify:
+			twoFactorToken, err := authService.CreateTwoFactorToken(principal, step)
+			if err != nil {
+				shared.RenderProblemJSON(w, isProduction, err)
+				return
+			}
+
+			shared.RenderJSON(w, &twoFactorRequiredModel{TwoFactorToken: twoFactorToken})
+		default:
+			a.startSession(w, r, principal)
+		}
+	}
+}
+
+// HandleTwoFactorLogin completes the login of a user with a second factor
+func (a *AuthRestHandlers) HandleTwoFactorLogin() http.HandlerFunc {
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		var twoFactorLoginModel twoFactorLoginModel
+		err := json.NewDecoder(r.Body).Decode(&twoFactorLoginModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		principal, err := authService.AuthenticateTwoFactor(r.Context(), twoFactorLoginModel.TwoFactorToken, twoFactorLoginModel.Code, clientIP(r))
+		if errors.Is(err, user.ErrAccountLocked) || errors.Is(err, user.ErrLoginThrottled) {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusTooManyRequests)
+			return
+		}
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("two factor code not valid")).JSONString(), http.StatusUnauthorized)
+			return
+		}
+
+		a.startSession(w, r, principal)
+	}
+}
+
+// startSession starts a new session of the signed in principal and renders its access and refresh token
+func (a *AuthRestHandlers) startSession(w http.ResponseWriter, r *http.Request, principal *shared.Principal) {
+	refreshToken, err := a.authService.StartSession(r.Context(), principal, r.UserAgent())
+	if err != nil {
+		shared.RenderProblemJSON(w, a.config.IsProduction(), err)
+		return
+	}
+
+	cookie := a.authService.CreateCookie(a.tokenAuth, a.config.ExpiryDuration(), principal)
+	http.SetCookie(w, &cookie)
+
+	loginResponseModel := &loginResponseModel{
+		AccessToken:  cookie.Value,
+		RefreshToken: refreshToken,
+	}
+	shared.RenderJSON(w, loginResponseModel)
+}
+
+// HandleRefresh issues a new access token for the refresh token and rotates the refresh token
+func (a *AuthRestHandlers) HandleRefresh() http.HandlerFunc {
+	tokenAuth := a.tokenAuth
+	expiryDuration := a.config.ExpiryDuration()
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		var refreshModel refreshModel
+		err := json.NewDecoder(r.Body).Decode(&refreshModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		principal, refreshToken, err := authService.RefreshSession(r.Context(), refreshModel.RefreshToken)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("refresh token not valid")).JSONString(), http.StatusUnauthorized)
+			return
+		}
+
 		cookie := authService.CreateCookie(tokenAuth, expiryDuration, principal)
-		http.SetCookie(w, &cookie)
 
-		loginResponseModel := &loginResponseModel{AccessToken: cookie.Value}
+		loginResponseModel := &loginResponseModel{
+			AccessToken:  cookie.Value,
+			RefreshToken: refreshToken,
+		}
 		shared.RenderJSON(w, loginResponseModel)
 	}
 }
 
+// HandleLogout ends the session of the access token, so its refresh token can't be used any longer
+func (a *AuthRestHandlers) HandleLogout() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := authService.EndSession(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		cookie := authService.CreateExpiredCookie()
+		http.SetCookie(w, &cookie)
+	}
+}
+
+// HandleJWKS publishes the public keys of the access tokens as JSON Web Key Set,
+// so that other services can verify the access tokens
+func (a *AuthRestHandlers) HandleJWKS() http.HandlerFunc {
+	tokenAuth := a.tokenAuth
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		shared.RenderJSON(w, tokenAuth.PublicKeys())
+	}
+}
+
 func (a *AuthRestHandlers) JWTVerifier() func(next http.Handler) http.Handler {
-	return jwtauth.Verifier(a.tokenAuth)
+	return a.tokenAuth.Verifier()
+}
+
+// APITokenVerifier sets up the user principal from a personal api token sent as bearer token,
+// requests without api token are left to the JWT verification
+func (a *AuthRestHandlers) APITokenVerifier() func(next http.Handler) http.Handler {
+	authService := a.authService
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := jwtauth.TokenFromHeader(r)
+			if !user.IsAPIToken(token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authService.AuthenticateAPIToken(r.Context(), token)
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			ctx := shared.ToContextWithPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
-// JWTPrincipalMiddleware sets up the user principal from the JWT
+// JWTPrincipalMiddleware sets up the user principal from the verified JWT, unless
+// the principal was already set up from a personal api token. Expired JWTs or JWTs
+// with an invalid signature are rejected.
 func (a *AuthRestHandlers) JWTPrincipalMiddleware() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token, claims, _ := jwtauth.FromContext(r.Context())
-			if token == nil {
+			if _, ok := shared.PrincipalFromContext(r.Context()); ok && user.IsAPIToken(jwtauth.TokenFromHeader(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil || token == nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 
 			principal := mapPrincipalFromClaims(claims)
+			if principal.SessionID != uuid.Nil && a.authService.IsSessionRevoked(principal.SessionID) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
 			ctx := shared.ToContextWithPrincipal(r.Context(), principal)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -91,20 +282,54 @@ func (a *AuthRestHandlers) JWTPrincipalMiddleware() func(next http.Handler) http
 	}
 }
 
+// sessionIDFromToken reads the session of the token, tokens without session return uuid.Nil
+func sessionIDFromToken(token jwt.Token) uuid.UUID {
+	sessionID, ok := token.Get("sid")
+	if !ok {
+		return uuid.Nil
+	}
+
+	id, _ := uuid.Parse(fmt.Sprintf("%v", sessionID))
+	return id
+}
+
 func mapPrincipalToClaims(principal *shared.Principal) map[string]interface{} {
-	return map[string]interface{}{
+	claims := map[string]interface{}{
 		"name":           principal.Name,
 		"username":       principal.Username,
 		"organizationId": principal.OrganizationID.String(),
 		"roles":          strings.Join(principal.Roles, ","),
+		"permissions":    strings.Join(principal.Permissions, ","),
+		"timeZone":       principal.TimeZone,
+	}
+	if principal.SessionID != uuid.Nil {
+		claims["sid"] = principal.SessionID.String()
 	}
+	return claims
 }
 
 func mapPrincipalFromClaims(claims map[string]interface{}) *shared.Principal {
-	return &shared.Principal{
+	principal := &shared.Principal{
 		Name:           claims["name"].(string),
 		Username:       claims["username"].(string),
 		OrganizationID: uuid.MustParse(claims["organizationId"].(string)),
 		Roles:          strings.Split(claims["roles"].(string), ","),
 	}
+
+	// time zone is optional for tokens issued before time zones were supported
+	if timeZone, ok := claims["timeZone"].(string); ok {
+		principal.TimeZone = timeZone
+	}
+
+	// permissions are optional for tokens issued before custom roles were supported
+	if permissions, ok := claims["permissions"].(string); ok && permissions != "" {
+		principal.Permissions = strings.Split(permissions, ",")
+	}
+
+	// session is optional for tokens issued before sessions were supported
+	if sessionID, ok := claims["sid"].(string); ok {
+		principal.SessionID, _ = uuid.Parse(sessionID)
+	}
+
+	return principal
 }
@@ -1,23 +1,29 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/baralga/user"
-	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/matryer/is"
+	"github.com/pkg/errors"
 )
 
 func TestHandleLogin(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	config := &shared.Config{
 		JWTExpiry: "1h",
 	}
@@ -26,8 +32,13 @@ func TestHandleLogin(t *testing.T) {
 		config:    config,
 		tokenAuth: tokenAuth,
 		authService: &AuthService{
-			config:         config,
-			userRepository: user.NewInMemUserRepository(),
+			config:                 config,
+			userRepository:         user.NewInMemUserRepository(),
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewInMemUserService(),
 		},
 	}
 
@@ -47,18 +58,210 @@ func TestHandleLogin(t *testing.T) {
 	err := json.NewDecoder(httpRec.Body).Decode(&loginResponse)
 	is.NoErr(err)
 	is.True(len(loginResponse["access_token"]) > 10)
+	is.True(len(loginResponse["refresh_token"]) > 10)
+}
+
+func TestHandleLoginWithTwoFactor(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{
+		JWTSecret: "secret",
+	}
+
+	twoFactorRepository := user.NewInMemTwoFactorRepository()
+	recoveryCodes := addTwoFactorSample(twoFactorRepository)
+
+	a := &AuthRestHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:                 config,
+			tokenAuth:              NewSecretTokenAuth("secret"),
+			userRepository:         user.NewInMemUserRepository(),
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    twoFactorRepository,
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewInMemUserService(),
+		},
+	}
+
+	body := `{"username": "admin@baralga.com", "password": "adm1n"}`
+
+	httpRec := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+
+	a.HandleLogin()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(httpRec.Result().Cookies()), 0)
+
+	loginResponse := make(map[string]string)
+	err := json.NewDecoder(httpRec.Body).Decode(&loginResponse)
+	is.NoErr(err)
+	is.Equal(loginResponse["access_token"], "")
+	is.True(len(loginResponse["two_factor_token"]) > 10)
+
+	t.Run("invalid code", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"two_factor_token": "%v", "code": "123456"}`, loginResponse["two_factor_token"])
+		r, _ := http.NewRequest("POST", "/api/auth/login/two-factor", strings.NewReader(body))
+
+		a.HandleTwoFactorLogin()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"two_factor_token": "%v", "code": "%v"}`, loginResponse["two_factor_token"], recoveryCodes[0])
+		r, _ := http.NewRequest("POST", "/api/auth/login/two-factor", strings.NewReader(body))
+
+		a.HandleTwoFactorLogin()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+		twoFactorLoginResponse := make(map[string]string)
+		err := json.NewDecoder(httpRec.Body).Decode(&twoFactorLoginResponse)
+		is.NoErr(err)
+		is.True(len(twoFactorLoginResponse["access_token"]) > 10)
+		is.True(len(twoFactorLoginResponse["refresh_token"]) > 10)
+	})
+	t.Run("too many invalid codes", func(t *testing.T) {
+		body := fmt.Sprintf(`{"two_factor_token": "%v", "code": "123456"}`, loginResponse["two_factor_token"])
+		for i := 0; i < user.LoginFailuresBeforeBackoff; i++ {
+			httpRec := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/auth/login/two-factor", strings.NewReader(body))
+
+			a.HandleTwoFactorLogin()(httpRec, r)
+			is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+		}
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/auth/login/two-factor", strings.NewReader(body))
+
+		a.HandleTwoFactorLogin()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusTooManyRequests)
+	})
+}
+
+func TestHandleLoginWithRequiredTwoFactorEnrollment(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	config := &shared.Config{}
+	organizationRepository := user.NewInMemOrganizationRepository()
+	requireAdminTwoFactor(organizationRepository)
+
+	a := &AuthRestHandlers{
+		config:    config,
+		tokenAuth: NewSecretTokenAuth("secret"),
+		authService: &AuthService{
+			config:                 config,
+			userRepository:         user.NewInMemUserRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: organizationRepository,
+			userService:            user.NewInMemUserService(),
+		},
+	}
+
+	body := `{"username": "admin@baralga.com", "password": "adm1n"}`
+	r, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+
+	a.HandleLogin()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+	is.True(strings.Contains(httpRec.Body.String(), "two factor enrollment required"))
+}
+
+func TestHandleRefresh(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{}
+
+	a := &AuthRestHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:            config,
+			userRepository:    user.NewInMemUserRepository(),
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+		},
+	}
+
+	principal, err := a.authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	refreshToken, err := a.authService.StartSession(context.Background(), principal, "curl")
+	is.NoErr(err)
+
+	t.Run("valid refresh token", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"refresh_token": "%v"}`, refreshToken)
+		r, _ := http.NewRequest("POST", "/api/auth/refresh", strings.NewReader(body))
+
+		a.HandleRefresh()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+		loginResponse := make(map[string]string)
+		err := json.NewDecoder(httpRec.Body).Decode(&loginResponse)
+		is.NoErr(err)
+		is.True(len(loginResponse["access_token"]) > 10)
+		is.True(loginResponse["refresh_token"] != refreshToken)
+
+		token, err := tokenAuth.Decode(loginResponse["access_token"])
+		is.NoErr(err)
+		is.Equal(sessionIDFromToken(token), principal.SessionID)
+	})
+
+	t.Run("unknown refresh token", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/auth/refresh", strings.NewReader(`{"refresh_token": "unknown"}`))
+
+		a.HandleRefresh()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
+
+func TestHandleLogout(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	config := &shared.Config{}
+	a := &AuthRestHandlers{
+		config: config,
+		authService: &AuthService{
+			config:            config,
+			userRepository:    user.NewInMemUserRepository(),
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+		},
+	}
+
+	principal, err := a.authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	refreshToken, err := a.authService.StartSession(context.Background(), principal, "curl")
+	is.NoErr(err)
+
+	r, _ := http.NewRequest("POST", "/api/auth/logout", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+	a.HandleLogout()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(a.authService.IsSessionRevoked(principal.SessionID))
+
+	_, _, err = a.authService.RefreshSession(context.Background(), refreshToken)
+	is.True(errors.Is(err, user.ErrSessionNotFound))
 }
 
 func TestHandleInvalidLogin(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	a := &AuthRestHandlers{
 		config:    &shared.Config{},
 		tokenAuth: tokenAuth,
 		authService: &AuthService{
 			userRepository: user.NewInMemUserRepository(),
+			userService:    user.NewInMemUserService(),
 		},
 	}
 
@@ -74,8 +277,89 @@ func TestHandleInvalidLogin(t *testing.T) {
 	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
 }
 
+func TestHandleLoginWithLockedAccount(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userService := user.NewInMemUserService()
+	for i := 0; i < user.LoginFailuresBeforeLockout; i++ {
+		err := userService.RecordLoginFailure(context.Background(), "someone@baralga.com", "")
+		is.NoErr(err)
+	}
+
+	a := &AuthRestHandlers{
+		config:    &shared.Config{},
+		tokenAuth: NewSecretTokenAuth("secret"),
+		authService: &AuthService{
+			userRepository: user.NewInMemUserRepository(),
+			userService:    userService,
+		},
+	}
+
+	body := `
+	{
+		"username": "someone@baralga.com",
+		"password": "adm1n"
+	 }
+	`
+
+	r, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+	a.HandleLogin()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusTooManyRequests)
+}
+
+func TestHandleLoginWithOrganization(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{
+		JWTExpiry: "1h",
+	}
+
+	a := &AuthRestHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:                 config,
+			userRepository:         user.NewInMemUserRepository(),
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewInMemUserService(),
+		},
+	}
+
+	t.Run("login to organization of user", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"username": "admin@baralga.com", "password": "adm1n", "organizationId": "%v"}`, shared.OrganizationIDSample)
+
+		r, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+		a.HandleLogin()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("login to other organization", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		body := fmt.Sprintf(`{"username": "admin@baralga.com", "password": "adm1n", "organizationId": "%v"}`, uuid.New())
+
+		r, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+		a.HandleLogin()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("login with invalid organization", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		body := `{"username": "admin@baralga.com", "password": "adm1n", "organizationId": "-invalid-"}`
+
+		r, _ := http.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+		a.HandleLogin()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusNotAcceptable)
+	})
+}
+
 func TestHandleLoginWithInvalidDuration(t *testing.T) {
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	a := &AuthRestHandlers{
 		config: &shared.Config{
 			JWTExpiry: "invalid",
@@ -89,6 +373,33 @@ func TestHandleLoginWithInvalidDuration(t *testing.T) {
 	a.HandleLogin()
 }
 
+func TestHandleJWKS(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	tokenAuth, err := NewPrivateKeyTokenAuth(newRSAKey(t))
+	is.NoErr(err)
+
+	a := &AuthRestHandlers{
+		config:    &shared.Config{},
+		tokenAuth: tokenAuth,
+	}
+
+	r, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	a.HandleJWKS()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("Content-Type"), "application/json")
+
+	keySet, err := jwk.Parse(httpRec.Body.Bytes())
+	is.NoErr(err)
+	is.Equal(keySet.Len(), 1)
+
+	publicKey, _ := keySet.Key(0)
+	_, isPrivate := publicKey.(jwk.RSAPrivateKey)
+	is.True(!isPrivate)
+}
+
 func TestMapPrincipalFromClaims(t *testing.T) {
 	is := is.New(t)
 
@@ -108,6 +419,40 @@ func TestMapPrincipalFromClaims(t *testing.T) {
 	is.Equal(shared.OrganizationIDSample, p.OrganizationID)
 	is.Equal(1, len(p.Roles))
 	is.Equal("ROLE_ADMIN", p.Roles[0])
+	is.Equal("", p.TimeZone)
+	is.Equal(0, len(p.Permissions))
+}
+
+func TestMapPrincipalFromClaimsWithTimeZone(t *testing.T) {
+	is := is.New(t)
+
+	principal := &shared.Principal{
+		Name:           "Ado Admin",
+		Username:       "admin",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+		TimeZone:       "America/New_York",
+	}
+
+	p := mapPrincipalFromClaims(mapPrincipalToClaims(principal))
+
+	is.Equal("America/New_York", p.TimeZone)
+}
+
+func TestMapPrincipalFromClaimsWithPermissions(t *testing.T) {
+	is := is.New(t)
+
+	principal := &shared.Principal{
+		Name:           "Carl Controller",
+		Username:       "controller",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_CONTROLLER"},
+		Permissions:    []string{shared.PermissionActivitiesReadAll, shared.PermissionProjectsWrite},
+	}
+
+	p := mapPrincipalFromClaims(mapPrincipalToClaims(principal))
+
+	is.Equal([]string{shared.PermissionActivitiesReadAll, shared.PermissionProjectsWrite}, p.Permissions)
 }
 
 func TestJWTPrincipalHandlerWithoutJWT(t *testing.T) {
@@ -127,3 +472,174 @@ func TestJWTPrincipalHandlerWithoutJWT(t *testing.T) {
 
 	is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
 }
+
+func TestJWTPrincipalHandlerWithInvalidJWT(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	a := &AuthRestHandlers{
+		config:      &shared.Config{},
+		authService: &AuthService{},
+		tokenAuth:   tokenAuth,
+	}
+
+	principal := &shared.Principal{
+		Name:           "Admin",
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}
+
+	handler := a.JWTVerifier()(
+		a.JWTPrincipalMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusIMUsed)
+		})),
+	)
+
+	t.Run("expired jwt", func(t *testing.T) {
+		claims := mapPrincipalToClaims(principal)
+		claims[jwt.ExpirationKey] = time.Now().Add(-time.Hour)
+		_, tokenString, err := tokenAuth.Encode(claims)
+		is.NoErr(err)
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+
+	t.Run("jwt with invalid signature", func(t *testing.T) {
+		_, tokenString, err := NewSecretTokenAuth("other-secret").Encode(mapPrincipalToClaims(principal))
+		is.NoErr(err)
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
+
+func TestJWTPrincipalHandlerWithRevokedSession(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	a := &AuthRestHandlers{
+		config:      &shared.Config{},
+		authService: &AuthService{},
+		tokenAuth:   tokenAuth,
+	}
+
+	principal := &shared.Principal{
+		Name:           "Admin",
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+		SessionID:      uuid.New(),
+	}
+	_, tokenString, err := tokenAuth.Encode(mapPrincipalToClaims(principal))
+	is.NoErr(err)
+
+	handler := a.JWTVerifier()(
+		a.JWTPrincipalMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusIMUsed)
+		})),
+	)
+
+	t.Run("active session", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusIMUsed)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		a.authService.revokedSessions.add(principal.SessionID)
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
+
+func TestAPITokenVerifier(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := user.NewInMemUserRepository()
+	apiTokenRepository := user.NewInMemAPITokenRepository()
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	_, err = apiTokenRepository.InsertAPIToken(context.Background(), &user.APIToken{
+		ID:             uuid.New(),
+		OrganizationID: admin.OrganizationID,
+		UserID:         admin.ID,
+		Name:           "CI",
+		TokenHash:      user.HashAPIToken("bpat_secret"),
+		CreatedAt:      time.Now(),
+	})
+	is.NoErr(err)
+
+	a := &AuthRestHandlers{
+		config: &shared.Config{},
+		authService: &AuthService{
+			config:             &shared.Config{},
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     userRepository,
+			roleRepository:     user.NewInMemRoleRepository(),
+			apiTokenRepository: apiTokenRepository,
+		},
+		tokenAuth: NewSecretTokenAuth("secret"),
+	}
+
+	handler := a.APITokenVerifier()(
+		a.JWTVerifier()(
+			a.JWTPrincipalMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal := shared.MustPrincipalFromContext(r.Context())
+				_, _ = w.Write([]byte(principal.Username))
+			})),
+		),
+	)
+
+	t.Run("valid api token", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+		r.Header.Set("Authorization", "Bearer bpat_secret")
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(httpRec.Body.String(), "admin@baralga.com")
+	})
+
+	t.Run("unknown api token", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+		r.Header.Set("Authorization", "Bearer bpat_unknown")
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+
+	t.Run("without token", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/projects", nil)
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
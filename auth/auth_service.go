@@ -2,58 +2,422 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/baralga/shared"
 	"github.com/baralga/user"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// TwoFactorStep is the step a user has to complete after the password before the session starts
+type TwoFactorStep string
+
+const (
+	// TwoFactorStepNone starts the session right away
+	TwoFactorStepNone TwoFactorStep = ""
+	// TwoFactorStepVerify asks for a one time password or a recovery code
+	TwoFactorStepVerify TwoFactorStep = "two_factor"
+	// TwoFactorStepEnroll sets up a second factor, which the organization requires for admins
+	TwoFactorStepEnroll TwoFactorStep = "two_factor_enrollment"
+)
+
+// twoFactorTokenExpiry is how long the user has to complete the second step
+const twoFactorTokenExpiry = 5 * time.Minute
+
+const (
+	// twoFactorTokenAudience is the audience of the tokens for the second step of the sign in
+	twoFactorTokenAudience = "baralga/two-factor"
+	// loginLinkTokenAudience is the audience of the tokens of login links
+	loginLinkTokenAudience = "baralga/login-link"
+)
+
 type AuthService struct {
-	config         *shared.Config
-	userRepository user.UserRepository
+	config                 *shared.Config
+	tokenAuth              *TokenAuth
+	repositoryTxer         shared.RepositoryTxer
+	userRepository         user.UserRepository
+	roleRepository         user.RoleRepository
+	apiTokenRepository     user.APITokenRepository
+	sessionRepository      user.SessionRepository
+	twoFactorRepository    user.TwoFactorRepository
+	organizationRepository user.OrganizationRepository
+	userService            *user.UserService
+	ldapDirectory          *LDAPDirectory
+	revokedSessions        revocationList
 }
 
-func NewAuthService(config *shared.Config, UserRepository user.UserRepository) *AuthService {
+func NewAuthService(config *shared.Config, tokenAuth *TokenAuth, repositoryTxer shared.RepositoryTxer, UserRepository user.UserRepository, roleRepository user.RoleRepository, apiTokenRepository user.APITokenRepository, sessionRepository user.SessionRepository, twoFactorRepository user.TwoFactorRepository, organizationRepository user.OrganizationRepository, userService *user.UserService) *AuthService {
 	return &AuthService{
-		config:         config,
-		userRepository: UserRepository,
+		config:                 config,
+		tokenAuth:              tokenAuth,
+		repositoryTxer:         repositoryTxer,
+		userRepository:         UserRepository,
+		roleRepository:         roleRepository,
+		apiTokenRepository:     apiTokenRepository,
+		sessionRepository:      sessionRepository,
+		twoFactorRepository:    twoFactorRepository,
+		organizationRepository: organizationRepository,
+		userService:            userService,
+		ldapDirectory:          NewLDAPDirectory(config),
 	}
 }
 
-func (a *AuthService) Authenticate(ctx context.Context, username, password string) (*shared.Principal, error) {
+// Authenticate checks the password of the user and signs the user in to the organization,
+// or to the default organization of the user if the organization is uuid.Nil.
+// The password is checked against the LDAP directory first if configured, then against the local password
+// of users who have one.
+func (a *AuthService) Authenticate(ctx context.Context, username, password string, organizationID uuid.UUID) (*shared.Principal, error) {
+	if a.ldapDirectory != nil {
+		principal, err := a.authenticateLDAP(ctx, username, password, organizationID)
+		if err == nil {
+			return principal, nil
+		}
+		if !errors.Is(err, ErrLDAPUserNotFound) && !errors.Is(err, ErrLDAPInvalidCredentials) && !errors.Is(err, ErrLDAPUserDisabled) {
+			log.Printf("ldap authentication failed: %s", err)
+		}
+	}
+
 	u, err := a.userRepository.FindUserByUsername(ctx, username)
 	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, a.unconfirmedUserError(ctx, username, password, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// users of GitHub, Google, OpenID Connect, LDAP or SCIM have no local password to sign in with
+	if !u.HasLocalPassword() {
+		return nil, user.ErrPasswordInvalid
+	}
+
+	passwdErr := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
+	if passwdErr != nil {
+		return nil, user.ErrPasswordInvalid
+	}
+
+	return a.principalInOrganization(ctx, u, organizationID)
+}
+
+// AuthenticateThrottled authenticates the user like Authenticate, but delays sign ins after recent failed sign ins
+// of the account or from the ip address and rejects sign ins to accounts locked after too many failures
+func (a *AuthService) AuthenticateThrottled(ctx context.Context, username, password string, organizationID uuid.UUID, ip string) (*shared.Principal, error) {
+	err := a.userService.CheckLoginThrottle(ctx, username, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := a.Authenticate(ctx, username, password, organizationID)
+	if errors.Is(err, user.ErrPasswordInvalid) || errors.Is(err, user.ErrUserNotFound) {
+		recordErr := a.userService.RecordLoginFailure(ctx, username, ip)
+		if recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// failures are forgotten once the second factor is verified too, so that signing in with
+	// the password between guesses of the second factor doesn't reset the count
+	twoFactorConfirmed, err := a.hasConfirmedTwoFactor(ctx, principal.Username)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactorConfirmed {
+		return principal, nil
+	}
+
+	err = a.userService.ResetLoginFailures(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return principal, nil
+}
+
+// authenticateLDAP signs in the user of the LDAP directory, users signing in for the first time join the
+// configured organization. The role of the user is kept in sync with the groups of the user if mapped.
+// Only users set up from the directory are signed in, other users with the same username are never linked.
+func (a *AuthService) authenticateLDAP(ctx context.Context, username, password string, organizationID uuid.UUID) (*shared.Principal, error) {
+	identity, err := a.ldapDirectory.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+	role := a.ldapDirectory.Role(identity)
+
+	u, err := a.userRepository.FindUserByUsername(ctx, identity.Username)
+	if errors.Is(err, user.ErrUserNotFound) {
+		u, err = a.findOrSetUpLDAPUser(ctx, identity, role)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Origin != LDAPOrigin {
+		return nil, ErrLDAPUserConflict
+	}
+
+	principal, err := a.principalInOrganization(ctx, u, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == "" {
+		return principal, nil
+	}
+
+	err = a.userService.UpdateMappedRole(ctx, principal, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.principalInOrganization(ctx, u, principal.OrganizationID)
+}
+
+// findOrSetUpLDAPUser sets up the user of the LDAP directory unless a disabled or unconfirmed user has the username,
+// which is not signed in
+func (a *AuthService) findOrSetUpLDAPUser(ctx context.Context, identity *LDAPIdentity, role string) (*user.User, error) {
+	u, err := a.userRepository.FindDisabledUserByUsername(ctx, identity.Username)
+	if err == nil {
+		if u.Origin != LDAPOrigin {
+			return nil, ErrLDAPUserConflict
+		}
+		return nil, ErrLDAPUserDisabled
+	}
+	if !errors.Is(err, user.ErrUserNotFound) {
 		return nil, err
 	}
+
+	err = a.setUpLDAPUser(ctx, identity, role)
 	if err != nil {
 		return nil, err
 	}
 
+	return a.userRepository.FindUserByUsername(ctx, identity.Username)
+}
+
+func (a *AuthService) setUpLDAPUser(ctx context.Context, identity *LDAPIdentity, role string) error {
+	newUser := &user.User{
+		Username: identity.Username,
+		Name:     identity.Name,
+		EMail:    identity.EMail,
+		Origin:   LDAPOrigin,
+	}
+
+	if a.config.LDAPOrganizationID == "" {
+		return a.userService.SetUpNewUser(ctx, newUser, uuid.Nil)
+	}
+
+	organizationID, err := uuid.Parse(a.config.LDAPOrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		role = user.RoleUser
+	}
+
+	return a.userService.SetUpMember(ctx, newUser, organizationID, role)
+}
+
+// unconfirmedUserError tells users who signed up but didn't confirm their email yet that they are not confirmed,
+// but only if the password matches so that no accounts can be probed
+func (a *AuthService) unconfirmedUserError(ctx context.Context, username, password string, err error) error {
+	u, findErr := a.userRepository.FindUnconfirmedUserByUsername(ctx, username)
+	if findErr != nil {
+		return err
+	}
+
 	passwdErr := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	if passwdErr != nil {
-		return nil, errors.New("password invalid")
+		return err
+	}
+
+	return user.ErrUserNotConfirmed
+}
+
+// AuthenticateTrusted signs in an already authenticated user to the organization,
+// or to the default organization of the user if the organization is uuid.Nil
+func (a *AuthService) AuthenticateTrusted(ctx context.Context, username string, organizationID uuid.UUID) (*shared.Principal, error) {
+	u, err := a.userRepository.FindUserByUsername(ctx, username)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	roles, err := a.userRepository.FindRolesByUserID(ctx, u.OrganizationID, u.ID)
+	return a.principalInOrganization(ctx, u, organizationID)
+}
+
+// AuthenticateOIDC signs in the user set up by the OpenID Connect provider with the subject like AuthenticateTrusted.
+// Users with the same username not set up by the provider are never signed in, ErrUserNotFound
+// is only returned if no user has the username so that the user can be set up.
+func (a *AuthService) AuthenticateOIDC(ctx context.Context, subject string, organizationID uuid.UUID) (*shared.Principal, error) {
+	u, err := a.userRepository.FindUserByUsername(ctx, subject)
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, a.disabledOIDCUserError(ctx, subject, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Origin != OIDCOrigin {
+		return nil, ErrOIDCUserConflict
+	}
+
+	return a.principalInOrganization(ctx, u, organizationID)
+}
+
+// disabledOIDCUserError tells if a disabled or unconfirmed user has the subject as username, so that it's not set up again
+func (a *AuthService) disabledOIDCUserError(ctx context.Context, subject string, err error) error {
+	u, findErr := a.userRepository.FindDisabledUserByUsername(ctx, subject)
+	if errors.Is(findErr, user.ErrUserNotFound) {
+		return err
+	}
+	if findErr != nil {
+		return findErr
+	}
+
+	if u.Origin != OIDCOrigin {
+		return ErrOIDCUserConflict
+	}
+	return ErrOIDCUserDisabled
+}
+
+// AuthenticateAPIToken signs in the owner of the personal api token to the organization of the token,
+// restricted to the scopes of the token
+func (a *AuthService) AuthenticateAPIToken(ctx context.Context, token string) (*shared.Principal, error) {
+	apiToken, err := a.apiTokenRepository.FindAPITokenByHash(ctx, user.HashAPIToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if apiToken.IsExpired(now) {
+		return nil, user.ErrAPITokenNotFound
+	}
+
+	u, err := a.userRepository.FindUserByID(ctx, apiToken.OrganizationID, apiToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := a.principalInOrganization(ctx, u, apiToken.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	principal.Scopes = apiToken.Scopes
+
+	// record the last use only once in a while to avoid a write on every request
+	if now.Sub(apiToken.LastUsedAt) >= user.APITokenLastUsedInterval {
+		err = a.repositoryTxer.InTx(
+			ctx,
+			func(ctx context.Context) error {
+				return a.apiTokenRepository.UpdateAPITokenLastUsed(ctx, apiToken.ID, now)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return principal, nil
+}
+
+// principalInOrganization sets up the principal of the user as member of the organization. Without an
+// organization the default organization of the user is used, or the first other one the user is enabled in.
+func (a *AuthService) principalInOrganization(ctx context.Context, u *user.User, organizationID uuid.UUID) (*shared.Principal, error) {
+	memberships, err := a.userRepository.FindMembershipsByUserID(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationID, err = selectOrganization(u, memberships, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := a.userRepository.FindUserByID(ctx, organizationID, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := mapUserToPrincipal(member, member.Roles)
+	principal.Permissions, err = a.customRolePermissions(ctx, organizationID, member.Roles)
 	if err != nil {
 		return nil, err
 	}
 
-	principal := mapUserToPrincipal(u, roles)
 	return principal, nil
 }
 
+// customRolePermissions reads the permissions of the custom roles, the built-in roles
+// grant their permissions without being listed in the principal
+func (a *AuthService) customRolePermissions(ctx context.Context, organizationID uuid.UUID, roles []string) ([]string, error) {
+	var permissions []string
+	for _, role := range roles {
+		if user.IsBuiltInRole(role) {
+			continue
+		}
+
+		customRole, err := a.roleRepository.FindRoleByName(ctx, organizationID, role)
+		if errors.Is(err, user.ErrRoleNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, customRole.Permissions...)
+	}
+
+	return permissions, nil
+}
+
+func selectOrganization(u *user.User, memberships []*user.Membership, organizationID uuid.UUID) (uuid.UUID, error) {
+	var enabledOrganizationIDs []uuid.UUID
+	for _, membership := range memberships {
+		if membership.Enabled {
+			enabledOrganizationIDs = append(enabledOrganizationIDs, membership.OrganizationID)
+		}
+	}
+
+	if organizationID != uuid.Nil {
+		if !slices.Contains(enabledOrganizationIDs, organizationID) {
+			return uuid.Nil, user.ErrMembershipNotFound
+		}
+		return organizationID, nil
+	}
+
+	if slices.Contains(enabledOrganizationIDs, u.OrganizationID) {
+		return u.OrganizationID, nil
+	}
+
+	if len(enabledOrganizationIDs) == 0 {
+		return uuid.Nil, user.ErrMembershipNotFound
+	}
+
+	return enabledOrganizationIDs[0], nil
+}
+
 func mapUserToPrincipal(user *user.User, roles []string) *shared.Principal {
 	principal := &shared.Principal{
 		Name:           user.Name,
 		Username:       user.Username,
 		OrganizationID: user.OrganizationID,
 		Roles:          roles,
+		TimeZone:       user.TimeZone,
 	}
 	if principal.Name == "" {
 		principal.Name = user.Username
@@ -61,25 +425,500 @@ func mapUserToPrincipal(user *user.User, roles []string) *shared.Principal {
 	return principal
 }
 
-func (a *AuthService) AuthenticateTrusted(ctx context.Context, username string) (*shared.Principal, error) {
+// RequiredTwoFactorStep checks whether the principal signed in with a password has to complete a second step,
+// the second step is required for users with a confirmed second factor and for admins of organizations requiring it
+func (a *AuthService) RequiredTwoFactorStep(ctx context.Context, principal *shared.Principal) (TwoFactorStep, error) {
+	twoFactorConfirmed, err := a.hasConfirmedTwoFactor(ctx, principal.Username)
+	if err != nil {
+		return TwoFactorStepNone, err
+	}
+	if twoFactorConfirmed {
+		return TwoFactorStepVerify, nil
+	}
+
+	if !principal.HasRole(user.RoleAdmin) {
+		return TwoFactorStepNone, nil
+	}
+
+	organization, err := a.organizationRepository.FindOrganizationByID(ctx, principal.OrganizationID)
+	if err != nil {
+		return TwoFactorStepNone, err
+	}
+	if organization.AdminTwoFactorRequired {
+		return TwoFactorStepEnroll, nil
+	}
+
+	return TwoFactorStepNone, nil
+}
+
+// hasConfirmedTwoFactor checks if the user has set up a second factor
+func (a *AuthService) hasConfirmedTwoFactor(ctx context.Context, username string) (bool, error) {
 	u, err := a.userRepository.FindUserByUsername(ctx, username)
+	if err != nil {
+		return false, err
+	}
+
+	twoFactor, err := a.twoFactorRepository.FindTwoFactorByUserID(ctx, u.ID)
+	if errors.Is(err, user.ErrTwoFactorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return twoFactor.IsConfirmed(), nil
+}
+
+// CreateTwoFactorToken creates the short lived token which proves that the principal passed the
+// password check. It is issued for its own audience so that it is never accepted as access token.
+func (a *AuthService) CreateTwoFactorToken(principal *shared.Principal, step TwoFactorStep) (string, error) {
+	claims := map[string]interface{}{
+		jwt.SubjectKey:   principal.Username,
+		"organizationId": principal.OrganizationID.String(),
+		"purpose":        string(step),
+	}
+	claims[jwt.ExpirationKey] = jwtauth.ExpireIn(twoFactorTokenExpiry)
+
+	_, tokenString, err := a.tokenAuth.EncodeFor(twoFactorTokenAudience, claims)
+	return tokenString, err
+}
+
+// AuthenticateTwoFactor completes the sign in of the two factor token with a one time password or a recovery code.
+// Wrong codes count as failed sign ins of the account from the ip address, so that the code can't be guessed
+// while the two factor token is valid.
+func (a *AuthService) AuthenticateTwoFactor(ctx context.Context, twoFactorToken, code, ip string) (*shared.Principal, error) {
+	u, organizationID, err := a.verifyTwoFactorToken(ctx, twoFactorToken, TwoFactorStepVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.userService.CheckLoginThrottle(ctx, u.Username, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := a.twoFactorRepository.FindTwoFactorByUserID(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !twoFactor.IsConfirmed() || !twoFactor.Verify(code, time.Now()) {
+		err = a.userService.RecordLoginFailure(ctx, u.Username, ip)
+		if err != nil {
+			return nil, err
+		}
+		return nil, user.ErrInvalidTwoFactorCode
+	}
+
+	// the used one time password or recovery code can't be used again
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.twoFactorRepository.UpdateTwoFactor(ctx, twoFactor)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.userService.ResetLoginFailures(ctx, u.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.principalInOrganization(ctx, u, organizationID)
+}
+
+// AuthenticateTwoFactorEnrollment signs in the user of the two factor token only to set up the required
+// second factor, the session must not start before the second factor is confirmed
+func (a *AuthService) AuthenticateTwoFactorEnrollment(ctx context.Context, twoFactorToken string) (*shared.Principal, error) {
+	u, organizationID, err := a.verifyTwoFactorToken(ctx, twoFactorToken, TwoFactorStepEnroll)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.principalInOrganization(ctx, u, organizationID)
+}
+
+func (a *AuthService) verifyTwoFactorToken(ctx context.Context, twoFactorToken string, step TwoFactorStep) (*user.User, uuid.UUID, error) {
+	token, err := a.tokenAuth.VerifyTokenFor(twoFactorTokenAudience, twoFactorToken)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	purpose, _ := token.Get("purpose")
+	if purpose != string(step) {
+		return nil, uuid.Nil, errors.New("two factor token not valid")
+	}
+
+	organizationIDClaim, _ := token.Get("organizationId")
+	organizationID, err := uuid.Parse(fmt.Sprintf("%v", organizationIDClaim))
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	u, err := a.userRepository.FindUserByUsername(ctx, token.Subject())
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	return u, organizationID, nil
+}
+
+// RequestLoginLink sends a link to sign in once without a password to the user with the email. Requests are
+// throttled per email and ip address. Unknown emails, locked or throttled accounts and users signing in with
+// GitHub, Google, OpenID Connect or LDAP are ignored so that no accounts can be probed.
+func (a *AuthService) RequestLoginLink(ctx context.Context, email, ip string) error {
+	email = strings.TrimSpace(email)
+	err := a.userService.ThrottleLoginLinkRequest(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+
+	u, err := a.userRepository.FindUserByUsername(ctx, email)
 	if errors.Is(err, user.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if u.EMail == "" || !u.HasLocalPassword() {
+		return nil
+	}
+
+	err = a.userService.CheckLoginThrottle(ctx, u.Username, ip)
+	if errors.Is(err, user.ErrAccountLocked) || errors.Is(err, user.ErrLoginThrottled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	loginLink := &user.LoginLink{
+		ID:        uuid.New(),
+		UserID:    u.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(user.LoginLinkValidity),
+	}
+
+	claims := map[string]interface{}{
+		jwt.SubjectKey: u.Username,
+		jwt.JwtIDKey:   loginLink.ID.String(),
+	}
+	claims[jwt.ExpirationKey] = loginLink.ExpiresAt
+
+	_, tokenString, err := a.tokenAuth.EncodeFor(loginLinkTokenAudience, claims)
+	if err != nil {
+		return err
+	}
+
+	return a.userService.SendLoginLink(ctx, u, loginLink, tokenString)
+}
+
+// AuthenticateLoginLink signs in the user of the login link token to the default organization, the token
+// proves that it was sent by us and the login link it refers to can be used only once by the user it was sent to.
+// Locked or throttled accounts can't sign in with a login link either, the link stays valid until they may sign in again.
+func (a *AuthService) AuthenticateLoginLink(ctx context.Context, loginLinkToken, ip string) (*shared.Principal, error) {
+	token, err := a.tokenAuth.VerifyTokenFor(loginLinkTokenAudience, loginLinkToken)
+	if err != nil {
+		return nil, user.ErrLoginLinkNotFound
+	}
+
+	loginLinkID, err := uuid.Parse(token.JwtID())
+	if err != nil {
+		return nil, user.ErrLoginLinkNotFound
+	}
+
+	err = a.userService.CheckLoginThrottle(ctx, token.Subject(), ip)
+	if err != nil {
 		return nil, err
 	}
+
+	u, err := a.userRepository.FindUserByUsername(ctx, token.Subject())
+	if errors.Is(err, user.ErrUserNotFound) {
+		return nil, user.ErrLoginLinkNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	roles, err := a.userRepository.FindRolesByUserID(ctx, u.OrganizationID, u.ID)
+	loginLink, err := a.userService.UseLoginLink(ctx, loginLinkID)
 	if err != nil {
 		return nil, err
 	}
 
-	principal := mapUserToPrincipal(u, roles)
-	return principal, nil
+	if loginLink.UserID != u.ID {
+		return nil, user.ErrLoginLinkNotFound
+	}
+
+	return a.principalInOrganization(ctx, u, uuid.Nil)
+}
+
+// StartSession starts a new session of the signed in principal, the returned
+// refresh token keeps the session alive and is not stored
+func (a *AuthService) StartSession(ctx context.Context, principal *shared.Principal, userAgent string) (string, error) {
+	u, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken, err := user.NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	session := &user.Session{
+		ID:               uuid.New(),
+		UserID:           u.ID,
+		OrganizationID:   principal.OrganizationID,
+		RefreshTokenHash: user.HashRefreshToken(refreshToken),
+		UserAgent:        truncateUserAgent(userAgent),
+		CreatedAt:        now,
+		RefreshedAt:      now,
+		ExpiresAt:        now.Add(a.config.RefreshExpiryDuration()),
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.sessionRepository.InsertSession(ctx, session)
+			return err
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	principal.SessionID = session.ID
+	return refreshToken, nil
+}
+
+// RefreshSession signs in the user of the session again and rotates the refresh token. The previous refresh
+// token is accepted for a short time without another rotation, in that case the returned refresh token is empty.
+// A later use of the previous refresh token indicates a stolen token, so the session is revoked.
+func (a *AuthService) RefreshSession(ctx context.Context, refreshToken string) (*shared.Principal, string, error) {
+	refreshTokenHash := user.HashRefreshToken(refreshToken)
+	session, err := a.sessionRepository.FindSessionByRefreshTokenHash(ctx, refreshTokenHash)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) {
+		return nil, "", user.ErrSessionNotFound
+	}
+
+	isPreviousRefreshToken := session.RefreshTokenHash != refreshTokenHash
+	if isPreviousRefreshToken && now.Sub(session.RefreshedAt) > user.RefreshTokenReuseInterval {
+		err = a.revokeSession(ctx, session.UserID, session.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		return nil, "", user.ErrSessionNotFound
+	}
+
+	u, err := a.userRepository.FindUserByID(ctx, session.OrganizationID, session.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	principal, err := a.principalInOrganization(ctx, u, session.OrganizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	principal.SessionID = session.ID
+
+	if isPreviousRefreshToken {
+		return principal, "", nil
+	}
+
+	rotatedRefreshToken, err := user.NewRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	session.PreviousRefreshTokenHash = session.RefreshTokenHash
+	session.RefreshTokenHash = user.HashRefreshToken(rotatedRefreshToken)
+	session.RefreshedAt = now
+	session.ExpiresAt = now.Add(a.config.RefreshExpiryDuration())
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.sessionRepository.UpdateSession(ctx, session)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return principal, rotatedRefreshToken, nil
+}
+
+// SwitchSessionOrganization keeps the session of the principal in the organization the principal switched to
+func (a *AuthService) SwitchSessionOrganization(ctx context.Context, principal *shared.Principal) error {
+	if principal.SessionID == uuid.Nil {
+		return nil
+	}
+
+	u, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.sessionRepository.UpdateSessionOrganization(ctx, u.ID, principal.SessionID, principal.OrganizationID)
+		},
+	)
+}
+
+// ReadSessions reads the active sessions of the principal
+func (a *AuthService) ReadSessions(ctx context.Context, principal *shared.Principal) ([]*user.Session, error) {
+	u, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.sessionRepository.FindSessionsByUserID(ctx, u.ID, time.Now())
+}
+
+// EndSession revokes the session the principal signed in with
+func (a *AuthService) EndSession(ctx context.Context, principal *shared.Principal) error {
+	if principal.SessionID == uuid.Nil {
+		return nil
+	}
+
+	err := a.RevokeSession(ctx, principal, principal.SessionID)
+	if errors.Is(err, user.ErrSessionNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// RevokeSession revokes a session of the principal, the access tokens of the session are rejected right away
+func (a *AuthService) RevokeSession(ctx context.Context, principal *shared.Principal, sessionID uuid.UUID) error {
+	u, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return err
+	}
+
+	return a.revokeSession(ctx, u.ID, sessionID)
+}
+
+func (a *AuthService) revokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.sessionRepository.RevokeSession(ctx, userID, sessionID, time.Now())
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	a.revokedSessions.add(sessionID)
+	return nil
+}
+
+// RevokeAllSessions revokes all sessions of the principal on all devices
+func (a *AuthService) RevokeAllSessions(ctx context.Context, principal *shared.Principal) error {
+	u, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return err
+	}
+
+	return a.revokeAllSessions(ctx, u.ID)
+}
+
+// SessionsRevoker revokes all sessions of a user on all devices, e.g. for the user service after the password changed
+func (a *AuthService) SessionsRevoker() func(ctx context.Context, userID uuid.UUID) error {
+	return a.revokeAllSessions
+}
+
+func (a *AuthService) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	sessions, err := a.sessionRepository.FindSessionsByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.sessionRepository.RevokeSessionsByUserID(ctx, userID, time.Now())
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		a.revokedSessions.add(session.ID)
+	}
+	return nil
+}
+
+// IsSessionRevoked checks the session against the revocation list
+func (a *AuthService) IsSessionRevoked(sessionID uuid.UUID) bool {
+	if sessionID == uuid.Nil {
+		return false
+	}
+	return a.revokedSessions.contains(sessionID)
 }
 
-func (a *AuthService) CreateCookie(tokenAuth *jwtauth.JWTAuth, expiryDuration time.Duration, principal *shared.Principal) http.Cookie {
+// RefreshRevocationList loads the revoked sessions, e.g. revoked by other instances, and deletes expired sessions
+func (a *AuthService) RefreshRevocationList(ctx context.Context) error {
+	now := time.Now()
+	sessionIDs, err := a.sessionRepository.FindRevokedSessionIDs(ctx, now)
+	if err != nil {
+		return err
+	}
+	a.revokedSessions.replace(sessionIDs)
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.sessionRepository.DeleteExpiredSessions(ctx, now)
+			return err
+		},
+	)
+}
+
+// RunRevocationListRefresh refreshes the revocation list in the given interval until the context is done
+func (a *AuthService) RunRevocationListRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := a.RefreshRevocationList(ctx)
+		if err != nil {
+			log.Printf("refreshing revoked sessions failed: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// truncateUserAgent keeps the user agent within the length of the database column
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= 200 {
+		return userAgent
+	}
+	return userAgent[:200]
+}
+
+func (a *AuthService) CreateCookie(tokenAuth *TokenAuth, expiryDuration time.Duration, principal *shared.Principal) http.Cookie {
 	claims := mapPrincipalToClaims(principal)
 	claims[jwt.ExpirationKey] = jwtauth.ExpireIn(expiryDuration)
 
@@ -95,6 +934,32 @@ func (a *AuthService) CreateCookie(tokenAuth *jwtauth.JWTAuth, expiryDuration ti
 	}
 }
 
+// CreateRefreshCookie creates the cookie with the refresh token of the session, the
+// cookie is not readable by scripts and expires with the session
+func (a *AuthService) CreateRefreshCookie(refreshToken string) http.Cookie {
+	return http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Expires:  time.Now().Add(a.config.RefreshExpiryDuration()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   a.config.IsProduction(),
+		Path:     "/",
+	}
+}
+
+func (a *AuthService) CreateExpiredRefreshCookie() http.Cookie {
+	return http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Expires:  time.Now(),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   a.config.IsProduction(),
+		Path:     "/",
+	}
+}
+
 func (a *AuthService) CreateExpiredCookie() http.Cookie {
 	return http.Cookie{
 		Name:     "jwt",
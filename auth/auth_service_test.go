@@ -2,15 +2,186 @@ package auth
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/baralga/shared"
 	"github.com/baralga/user"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/matryer/is"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func TestAuthenticateWithLDAP(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	mockServer := newMockLDAPServer(t, false)
+	config := mockServer.config("ldap")
+	config.LDAPOrganizationID = shared.OrganizationIDSample.String()
+
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	userRepository := user.NewInMemUserRepository()
+	organizationRepository := user.NewInMemOrganizationRepository()
+
+	a := &AuthService{
+		config:         config,
+		repositoryTxer: repositoryTxer,
+		userRepository: userRepository,
+		userService:    user.NewUserService(config, repositoryTxer, nil, userRepository, organizationRepository, nil, nil, nil, nil, nil, nil, nil, nil, nil),
+		ldapDirectory:  NewLDAPDirectory(config),
+	}
+
+	t.Run("first sign in of ldap user", func(t *testing.T) {
+		// Act
+		principal, err := a.Authenticate(context.Background(), "jdoe", "jd0e", uuid.Nil)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(principal.Username, "jane@example.com")
+		is.Equal(principal.Name, "Jane Doe")
+		is.Equal(principal.OrganizationID, shared.OrganizationIDSample)
+		is.Equal(principal.Roles, []string{user.RoleAdmin})
+
+		u, err := userRepository.FindUserByUsername(context.Background(), "jane@example.com")
+		is.NoErr(err)
+		is.Equal(u.Origin, LDAPOrigin)
+		is.Equal(u.Password, "")
+	})
+
+	t.Run("sign in of ldap user with changed groups", func(t *testing.T) {
+		mockServer.entries[1].attributes["memberOf"] = []string{"cn=baralga-users,ou=groups,dc=example,dc=org"}
+
+		// Act
+		principal, err := a.Authenticate(context.Background(), "jdoe", "jd0e", uuid.Nil)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(principal.Username, "jane@example.com")
+		is.Equal(principal.Roles, []string{user.RoleUser})
+	})
+
+	t.Run("sign in of ldap user with invalid password", func(t *testing.T) {
+		// Act
+		_, err := a.Authenticate(context.Background(), "jdoe", "wrong", uuid.Nil)
+
+		// Assert
+		is.True(err != nil)
+	})
+
+	t.Run("sign in of local user", func(t *testing.T) {
+		// Act
+		principal, err := a.Authenticate(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(principal.Username, "admin@baralga.com")
+	})
+
+	t.Run("sign in of local user with ldap unavailable", func(t *testing.T) {
+		ldapConfig := *config
+		ldapConfig.LDAPURL = "ldap://127.0.0.1:1"
+		a.ldapDirectory = NewLDAPDirectory(&ldapConfig)
+
+		// Act
+		principal, err := a.Authenticate(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(principal.Username, "admin@baralga.com")
+	})
+}
+
+func TestAuthenticateWithLDAPAndExistingUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	mockServer := newMockLDAPServer(t, false)
+	config := mockServer.config("ldap")
+	config.LDAPOrganizationID = shared.OrganizationIDSample.String()
+
+	newAuthService := func(userRepository user.UserRepository) *AuthService {
+		repositoryTxer := shared.NewInMemRepositoryTxer()
+		organizationRepository := user.NewInMemOrganizationRepository()
+		return &AuthService{
+			config:         config,
+			repositoryTxer: repositoryTxer,
+			userRepository: userRepository,
+			userService:    user.NewUserService(config, repositoryTxer, nil, userRepository, organizationRepository, nil, nil, nil, nil, nil, nil, nil, nil, nil),
+			ldapDirectory:  NewLDAPDirectory(config),
+		}
+	}
+
+	newLocalUser := func(userRepository user.UserRepository) *user.User {
+		localUser := &user.User{
+			ID:             uuid.New(),
+			Username:       "jane@example.com",
+			EMail:          "jane@example.com",
+			OrganizationID: shared.OrganizationIDSample,
+		}
+		_, err := userRepository.InsertUserWithRole(context.Background(), localUser, user.RoleUser)
+		is.NoErr(err)
+		return localUser
+	}
+
+	t.Run("local user with username of ldap user", func(t *testing.T) {
+		userRepository := user.NewInMemUserRepository()
+		a := newAuthService(userRepository)
+		localUser := newLocalUser(userRepository)
+
+		// Act
+		_, err := a.authenticateLDAP(context.Background(), "jdoe", "jd0e", uuid.Nil)
+
+		// Assert
+		is.True(errors.Is(err, ErrLDAPUserConflict))
+
+		u, err := userRepository.FindUserByID(context.Background(), shared.OrganizationIDSample, localUser.ID)
+		is.NoErr(err)
+		is.Equal(u.Roles, []string{user.RoleUser})
+	})
+
+	t.Run("disabled local user with username of ldap user", func(t *testing.T) {
+		userRepository := user.NewInMemUserRepository()
+		a := newAuthService(userRepository)
+		localUser := newLocalUser(userRepository)
+		err := userRepository.UpdateUserEnabled(context.Background(), shared.OrganizationIDSample, localUser.ID, false)
+		is.NoErr(err)
+
+		// Act
+		_, err = a.authenticateLDAP(context.Background(), "jdoe", "jd0e", uuid.Nil)
+
+		// Assert
+		is.True(errors.Is(err, ErrLDAPUserConflict))
+	})
+
+	t.Run("disabled ldap user", func(t *testing.T) {
+		userRepository := user.NewInMemUserRepository()
+		a := newAuthService(userRepository)
+
+		principal, err := a.Authenticate(context.Background(), "jdoe", "jd0e", uuid.Nil)
+		is.NoErr(err)
+		u, err := userRepository.FindUserByUsername(context.Background(), principal.Username)
+		is.NoErr(err)
+		err = userRepository.UpdateUserEnabled(context.Background(), shared.OrganizationIDSample, u.ID, false)
+		is.NoErr(err)
+
+		// Act
+		_, err = a.Authenticate(context.Background(), "jdoe", "jd0e", uuid.Nil)
+
+		// Assert
+		is.True(errors.Is(err, user.ErrUserNotFound))
+
+		users, err := userRepository.FindUsersByOrganizationID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.Equal(len(users), 2)
+	})
+}
+
 func TestAuthenticateTrustedWithExistingUser(t *testing.T) {
 	// Arrange
 	is := is.New(t)
@@ -22,7 +193,7 @@ func TestAuthenticateTrustedWithExistingUser(t *testing.T) {
 	username := "admin@baralga.com"
 
 	// Act
-	principal, err := a.AuthenticateTrusted(context.Background(), username)
+	principal, err := a.AuthenticateTrusted(context.Background(), username, uuid.Nil)
 
 	// Assert
 	is.NoErr(err)
@@ -39,12 +210,355 @@ func TestAuthenticateTrustedWithMissingUser(t *testing.T) {
 	username := "not.found@baralga.com"
 
 	// Act
-	_, err := a.AuthenticateTrusted(context.Background(), username)
+	_, err := a.AuthenticateTrusted(context.Background(), username, uuid.Nil)
 
 	// Assert
 	is.True(errors.Is(err, user.ErrUserNotFound))
 }
 
+func TestAuthenticateTrustedInOtherOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := user.NewInMemUserRepository()
+	a := &AuthService{
+		config:         &shared.Config{},
+		userRepository: userRepository,
+	}
+
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	organizationID := uuid.New()
+	err = userRepository.InsertMembership(context.Background(), organizationID, admin.ID, user.RoleUser)
+	is.NoErr(err)
+
+	// Act
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", organizationID)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(principal.OrganizationID, organizationID)
+	is.Equal(principal.Roles, []string{user.RoleUser})
+}
+
+func TestAuthenticateTrustedWithoutMembership(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	a := &AuthService{
+		config:         &shared.Config{},
+		userRepository: user.NewInMemUserRepository(),
+	}
+
+	// Act
+	_, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.New())
+
+	// Assert
+	is.True(errors.Is(err, user.ErrMembershipNotFound))
+}
+
+func TestAuthenticateInDefaultOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	a := &AuthService{
+		config:         &shared.Config{},
+		userRepository: user.NewInMemUserRepository(),
+	}
+
+	// Act
+	principal, err := a.Authenticate(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(principal.OrganizationID, shared.OrganizationIDSample)
+	is.Equal(principal.Roles, []string{user.RoleAdmin})
+}
+
+func TestAuthenticateWithoutLocalPassword(t *testing.T) {
+	is := is.New(t)
+
+	for _, origin := range []string{"github", OIDCOrigin, LDAPOrigin, user.SCIMOrigin} {
+		t.Run(origin, func(t *testing.T) {
+			// Arrange
+			userRepository := user.NewInMemUserRepository()
+			password, err := bcrypt.GenerateFromPassword([]byte("jd0e"), bcrypt.DefaultCost)
+			is.NoErr(err)
+
+			_, err = userRepository.InsertUserWithRole(context.Background(), &user.User{
+				ID:             uuid.New(),
+				Username:       "jane@example.com",
+				EMail:          "jane@example.com",
+				Password:       string(password),
+				Origin:         origin,
+				OrganizationID: shared.OrganizationIDSample,
+			}, user.RoleUser)
+			is.NoErr(err)
+
+			a := &AuthService{
+				config:         &shared.Config{},
+				userRepository: userRepository,
+			}
+
+			// Act
+			_, err = a.Authenticate(context.Background(), "jane@example.com", "jd0e", uuid.Nil)
+
+			// Assert
+			is.True(errors.Is(err, user.ErrPasswordInvalid))
+		})
+	}
+}
+
+func TestAuthenticateUnconfirmedUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := user.NewInMemUserRepository()
+	a := &AuthService{
+		config:         &shared.Config{},
+		userRepository: userRepository,
+	}
+
+	unconfirmedUser := &user.User{
+		ID:             uuid.New(),
+		Username:       "newbie@baralga.com",
+		EMail:          "newbie@baralga.com",
+		Password:       "$2a$10$NuzYobDOSTCx/EKBClGwGe0A9c8/yC7D4IP75hwz1jn.RCBfdEtb2",
+		OrganizationID: uuid.New(),
+	}
+	_, err := userRepository.InsertUserWithConfirmationID(context.Background(), unconfirmedUser, uuid.New())
+	is.NoErr(err)
+
+	// Act
+	_, err = a.Authenticate(context.Background(), "newbie@baralga.com", "adm1n", uuid.Nil)
+	_, errWithWrongPassword := a.Authenticate(context.Background(), "newbie@baralga.com", "-just-wrong-", uuid.Nil)
+
+	// Assert
+	is.True(errors.Is(err, user.ErrUserNotConfirmed))
+	is.True(errors.Is(errWithWrongPassword, user.ErrUserNotFound))
+}
+
+func TestAuthenticateThrottled(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	config := &shared.Config{}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	mailResource := shared.NewInMemMailResource()
+	userRepository := user.NewInMemUserRepository()
+	loginThrottleRepository := user.NewInMemLoginThrottleRepository()
+
+	a := &AuthService{
+		config:              config,
+		userRepository:      userRepository,
+		twoFactorRepository: user.NewInMemTwoFactorRepository(),
+		userService:         user.NewUserService(config, repositoryTxer, mailResource, userRepository, nil, nil, nil, nil, nil, nil, loginThrottleRepository, nil, nil, nil),
+	}
+
+	t.Run("successful sign in resets failures", func(t *testing.T) {
+		_, err := a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "-just-wrong-", uuid.Nil, "10.0.0.1")
+		is.True(errors.Is(err, user.ErrPasswordInvalid))
+
+		principal, err := a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil, "10.0.0.1")
+		is.NoErr(err)
+		is.Equal(principal.Username, "admin@baralga.com")
+
+		loginThrottles, err := loginThrottleRepository.FindLoginThrottles(context.Background(), []string{user.AccountLoginThrottleKey("admin@baralga.com")})
+		is.NoErr(err)
+		is.Equal(len(loginThrottles), 0)
+	})
+
+	t.Run("sign in delayed after failures", func(t *testing.T) {
+		for i := 0; i < user.LoginFailuresBeforeBackoff; i++ {
+			_, err := a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "-just-wrong-", uuid.Nil, "10.0.0.1")
+			is.True(errors.Is(err, user.ErrPasswordInvalid))
+		}
+
+		_, err := a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil, "10.0.0.1")
+		is.True(errors.Is(err, user.ErrLoginThrottled))
+	})
+
+	t.Run("account locked after too many failures", func(t *testing.T) {
+		// let the failures of the account pass the backoff
+		loginThrottles, err := loginThrottleRepository.FindLoginThrottles(context.Background(), []string{user.AccountLoginThrottleKey("admin@baralga.com")})
+		is.NoErr(err)
+		is.Equal(len(loginThrottles), 1)
+
+		err = repositoryTxer.InTx(context.Background(), func(ctx context.Context) error {
+			for i := loginThrottles[0].Failures; i < user.LoginFailuresBeforeLockout; i++ {
+				_, err := loginThrottleRepository.IncrementLoginFailures(ctx, user.AccountLoginThrottleKey("admin@baralga.com"), time.Now().Add(-time.Hour), time.Now().Add(-user.LoginFailureRetention))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		is.NoErr(err)
+
+		_, err = a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "-just-wrong-", uuid.Nil, "10.0.0.2")
+		is.True(errors.Is(err, user.ErrPasswordInvalid))
+
+		_, err = a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil, "10.0.0.2")
+		is.True(errors.Is(err, user.ErrAccountLocked))
+		is.Equal(len(mailResource.Mails), 1)
+	})
+}
+
+func TestAuthenticateLoginLink(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	config := &shared.Config{Webroot: "http://localhost:8080"}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	mailResource := shared.NewInMemMailResource()
+	userRepository := user.NewInMemUserRepository()
+
+	a := &AuthService{
+		config:         config,
+		tokenAuth:      NewSecretTokenAuth("secret"),
+		userRepository: userRepository,
+		userService:    user.NewUserService(config, repositoryTxer, mailResource, userRepository, nil, nil, nil, nil, nil, nil, user.NewInMemLoginThrottleRepository(), nil, nil, nil),
+	}
+
+	err := a.RequestLoginLink(context.Background(), "admin@baralga.com", "10.0.0.1")
+	is.NoErr(err)
+	is.Equal(len(mailResource.Mails), 1)
+
+	loginLinkToken := regexp.MustCompile(`http://localhost:8080/login/link/([^ ]+)`).FindStringSubmatch(mailResource.Mails[0])
+	is.Equal(len(loginLinkToken), 2)
+
+	t.Run("sign in with login link", func(t *testing.T) {
+		principal, err := a.AuthenticateLoginLink(context.Background(), loginLinkToken[1], "10.0.0.1")
+		is.NoErr(err)
+		is.Equal(principal.Username, "admin@baralga.com")
+		is.Equal(principal.OrganizationID, shared.OrganizationIDSample)
+	})
+
+	t.Run("login link used only once", func(t *testing.T) {
+		_, err := a.AuthenticateLoginLink(context.Background(), loginLinkToken[1], "10.0.0.1")
+		is.True(errors.Is(err, user.ErrLoginLinkNotFound))
+	})
+
+	t.Run("login link token with wrong signature", func(t *testing.T) {
+		_, forgedToken, err := jwtauth.New("HS256", []byte("other-secret"), nil).Encode(map[string]interface{}{
+			jwt.SubjectKey: "admin@baralga.com",
+			jwt.JwtIDKey:   uuid.New().String(),
+		})
+		is.NoErr(err)
+
+		_, err = a.AuthenticateLoginLink(context.Background(), forgedToken, "10.0.0.1")
+		is.True(errors.Is(err, user.ErrLoginLinkNotFound))
+	})
+
+	t.Run("no login link for unknown email", func(t *testing.T) {
+		err := a.RequestLoginLink(context.Background(), "nobody@baralga.com", "10.0.0.1")
+		is.NoErr(err)
+		is.Equal(len(mailResource.Mails), 1)
+	})
+
+	t.Run("locked account can't sign in with login link", func(t *testing.T) {
+		err := a.RequestLoginLink(context.Background(), "admin@baralga.com", "10.0.0.1")
+		is.NoErr(err)
+		is.Equal(len(mailResource.Mails), 2)
+
+		loginLinkToken := regexp.MustCompile(`http://localhost:8080/login/link/([^ ]+)`).FindStringSubmatch(mailResource.Mails[1])
+		is.Equal(len(loginLinkToken), 2)
+
+		for i := 0; i < user.LoginFailuresBeforeLockout; i++ {
+			err := a.userService.RecordLoginFailure(context.Background(), "admin@baralga.com", "10.0.0.2")
+			is.NoErr(err)
+		}
+		is.Equal(len(mailResource.Mails), 3)
+
+		_, err = a.AuthenticateLoginLink(context.Background(), loginLinkToken[1], "10.0.0.1")
+		is.True(errors.Is(err, user.ErrAccountLocked))
+
+		err = a.RequestLoginLink(context.Background(), "admin@baralga.com", "10.0.0.1")
+		is.NoErr(err)
+		is.Equal(len(mailResource.Mails), 3)
+	})
+}
+
+func TestAuthenticateLoginLinkOfOtherUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	config := &shared.Config{Webroot: "http://localhost:8080"}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	mailResource := shared.NewInMemMailResource()
+	userRepository := user.NewInMemUserRepository()
+
+	_, err := userRepository.InsertUserWithRole(context.Background(), &user.User{
+		ID:             uuid.New(),
+		Username:       "user1@baralga.com",
+		EMail:          "user1@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}, user.RoleUser)
+	is.NoErr(err)
+
+	a := &AuthService{
+		config:         config,
+		tokenAuth:      NewSecretTokenAuth("secret"),
+		userRepository: userRepository,
+		userService:    user.NewUserService(config, repositoryTxer, mailResource, userRepository, nil, nil, nil, nil, nil, nil, user.NewInMemLoginThrottleRepository(), nil, nil, nil),
+	}
+
+	err = a.RequestLoginLink(context.Background(), "admin@baralga.com", "10.0.0.1")
+	is.NoErr(err)
+
+	loginLinkToken := regexp.MustCompile(`http://localhost:8080/login/link/([^ ]+)`).FindStringSubmatch(mailResource.Mails[0])
+	is.Equal(len(loginLinkToken), 2)
+
+	token, err := a.tokenAuth.VerifyTokenFor(loginLinkTokenAudience, loginLinkToken[1])
+	is.NoErr(err)
+
+	// the login link of the admin with the user as subject
+	_, otherUserToken, err := a.tokenAuth.EncodeFor(loginLinkTokenAudience, map[string]interface{}{
+		jwt.SubjectKey:    "user1@baralga.com",
+		jwt.JwtIDKey:      token.JwtID(),
+		jwt.ExpirationKey: token.Expiration(),
+	})
+	is.NoErr(err)
+
+	// Act
+	_, err = a.AuthenticateLoginLink(context.Background(), otherUserToken, "10.0.0.1")
+
+	// Assert
+	is.True(errors.Is(err, user.ErrLoginLinkNotFound))
+}
+
+func TestRequestLoginLinkThrottled(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	config := &shared.Config{Webroot: "http://localhost:8080"}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	mailResource := shared.NewInMemMailResource()
+	userRepository := user.NewInMemUserRepository()
+
+	a := &AuthService{
+		config:         config,
+		tokenAuth:      NewSecretTokenAuth("secret"),
+		userRepository: userRepository,
+		userService:    user.NewUserService(config, repositoryTxer, mailResource, userRepository, nil, nil, nil, nil, nil, nil, user.NewInMemLoginThrottleRepository(), nil, nil, nil),
+	}
+
+	t.Run("throttled per email", func(t *testing.T) {
+		for i := 0; i < user.LoginLinksBeforeBackoff; i++ {
+			err := a.RequestLoginLink(context.Background(), "admin@baralga.com", fmt.Sprintf("10.0.1.%v", i))
+			is.NoErr(err)
+		}
+		is.Equal(len(mailResource.Mails), user.LoginLinksBeforeBackoff)
+
+		err := a.RequestLoginLink(context.Background(), "Admin@baralga.com", "10.0.1.100")
+		is.True(errors.Is(err, user.ErrLoginLinkThrottled))
+		is.Equal(len(mailResource.Mails), user.LoginLinksBeforeBackoff)
+	})
+
+	t.Run("throttled per ip address", func(t *testing.T) {
+		for i := 0; i < user.LoginLinkIPRequestsBeforeBackoff; i++ {
+			err := a.RequestLoginLink(context.Background(), fmt.Sprintf("nobody%v@baralga.com", i), "10.0.0.1")
+			is.NoErr(err)
+		}
+
+		err := a.RequestLoginLink(context.Background(), "somebody@baralga.com", "10.0.0.1")
+		is.True(errors.Is(err, user.ErrLoginLinkThrottled))
+	})
+}
+
 func TestCreateExpiredCookie(t *testing.T) {
 	// Arrange
 	is := is.New(t)
@@ -60,3 +574,477 @@ func TestCreateExpiredCookie(t *testing.T) {
 	is.Equal("jwt", cookie.Name)
 	is.Equal("/", cookie.Path)
 }
+
+func TestAuthenticateTrustedWithCustomRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := user.NewInMemUserRepository()
+	roleRepository := user.NewInMemRoleRepository()
+	a := &AuthService{
+		config:         &shared.Config{},
+		userRepository: userRepository,
+		roleRepository: roleRepository,
+	}
+
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	organizationID := uuid.New()
+	_, err = roleRepository.InsertRole(context.Background(), &user.OrganizationRole{
+		OrganizationID: organizationID,
+		Name:           "ROLE_CONTROLLER",
+		Title:          "Controller",
+		Permissions:    []string{shared.PermissionActivitiesReadAll},
+	})
+	is.NoErr(err)
+
+	err = userRepository.InsertMembership(context.Background(), organizationID, admin.ID, "ROLE_CONTROLLER")
+	is.NoErr(err)
+
+	// Act
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", organizationID)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(principal.Roles, []string{"ROLE_CONTROLLER"})
+	is.Equal(principal.Permissions, []string{shared.PermissionActivitiesReadAll})
+	is.True(principal.HasPermission(shared.PermissionActivitiesReadAll))
+	is.True(!principal.HasPermission(shared.PermissionUsersWrite))
+}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := user.NewInMemUserRepository()
+	apiTokenRepository := user.NewInMemAPITokenRepository()
+	a := &AuthService{
+		config:             &shared.Config{},
+		repositoryTxer:     shared.NewInMemRepositoryTxer(),
+		userRepository:     userRepository,
+		roleRepository:     user.NewInMemRoleRepository(),
+		apiTokenRepository: apiTokenRepository,
+	}
+
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	apiToken, err := apiTokenRepository.InsertAPIToken(context.Background(), &user.APIToken{
+		ID:             uuid.New(),
+		OrganizationID: admin.OrganizationID,
+		UserID:         admin.ID,
+		Name:           "CI",
+		TokenHash:      user.HashAPIToken("bpat_secret"),
+		Scopes:         []string{shared.ScopeActivitiesRead},
+		CreatedAt:      time.Now(),
+	})
+	is.NoErr(err)
+
+	// Act
+	principal, err := a.AuthenticateAPIToken(context.Background(), "bpat_secret")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(principal.Username, "admin@baralga.com")
+	is.Equal(principal.OrganizationID, admin.OrganizationID)
+	is.Equal(principal.Scopes, []string{shared.ScopeActivitiesRead})
+	is.True(!apiToken.LastUsedAt.IsZero())
+}
+
+func TestAuthenticateExpiredAPIToken(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := user.NewInMemUserRepository()
+	apiTokenRepository := user.NewInMemAPITokenRepository()
+	a := &AuthService{
+		config:             &shared.Config{},
+		repositoryTxer:     shared.NewInMemRepositoryTxer(),
+		userRepository:     userRepository,
+		roleRepository:     user.NewInMemRoleRepository(),
+		apiTokenRepository: apiTokenRepository,
+	}
+
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	_, err = apiTokenRepository.InsertAPIToken(context.Background(), &user.APIToken{
+		ID:             uuid.New(),
+		OrganizationID: admin.OrganizationID,
+		UserID:         admin.ID,
+		Name:           "CI",
+		TokenHash:      user.HashAPIToken("bpat_secret"),
+		CreatedAt:      time.Now().Add(-48 * time.Hour),
+		ExpiresAt:      time.Now().Add(-24 * time.Hour),
+	})
+	is.NoErr(err)
+
+	// Act
+	_, err = a.AuthenticateAPIToken(context.Background(), "bpat_secret")
+
+	// Assert
+	is.True(errors.Is(err, user.ErrAPITokenNotFound))
+}
+
+func TestRefreshSession(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	sessionRepository := user.NewInMemSessionRepository()
+	a := &AuthService{
+		config:            &shared.Config{},
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		userRepository:    user.NewInMemUserRepository(),
+		sessionRepository: sessionRepository,
+	}
+
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+
+	refreshToken, err := a.StartSession(context.Background(), principal, "Firefox")
+	is.NoErr(err)
+	is.True(principal.SessionID != uuid.Nil)
+
+	// Act
+	refreshedPrincipal, rotatedRefreshToken, err := a.RefreshSession(context.Background(), refreshToken)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(refreshedPrincipal.Username, "admin@baralga.com")
+	is.Equal(refreshedPrincipal.SessionID, principal.SessionID)
+	is.True(rotatedRefreshToken != "")
+	is.True(rotatedRefreshToken != refreshToken)
+
+	t.Run("previous refresh token shortly after rotation", func(t *testing.T) {
+		reusedPrincipal, reusedRefreshToken, err := a.RefreshSession(context.Background(), refreshToken)
+
+		is.NoErr(err)
+		is.Equal(reusedPrincipal.SessionID, principal.SessionID)
+		is.Equal(reusedRefreshToken, "")
+	})
+
+	t.Run("previous refresh token after reuse interval", func(t *testing.T) {
+		session, err := sessionRepository.FindSessionByRefreshTokenHash(context.Background(), user.HashRefreshToken(rotatedRefreshToken))
+		is.NoErr(err)
+		session.RefreshedAt = time.Now().Add(-time.Minute)
+		_, err = sessionRepository.UpdateSession(context.Background(), session)
+		is.NoErr(err)
+
+		_, _, err = a.RefreshSession(context.Background(), refreshToken)
+		is.True(errors.Is(err, user.ErrSessionNotFound))
+		is.True(a.IsSessionRevoked(principal.SessionID))
+
+		// the stolen session can't be used with the current refresh token either
+		_, _, err = a.RefreshSession(context.Background(), rotatedRefreshToken)
+		is.True(errors.Is(err, user.ErrSessionNotFound))
+	})
+}
+
+func TestRefreshSessionWithUnknownRefreshToken(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	a := &AuthService{
+		config:            &shared.Config{},
+		sessionRepository: user.NewInMemSessionRepository(),
+	}
+
+	// Act
+	_, _, err := a.RefreshSession(context.Background(), "unknown")
+
+	// Assert
+	is.True(errors.Is(err, user.ErrSessionNotFound))
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	a := &AuthService{
+		config:            &shared.Config{},
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		userRepository:    user.NewInMemUserRepository(),
+		sessionRepository: user.NewInMemSessionRepository(),
+	}
+
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+
+	refreshToken, err := a.StartSession(context.Background(), principal, "Firefox")
+	is.NoErr(err)
+
+	otherPrincipal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+
+	_, err = a.StartSession(context.Background(), otherPrincipal, "Chrome")
+	is.NoErr(err)
+
+	sessions, err := a.ReadSessions(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(len(sessions), 2)
+
+	// Act
+	err = a.RevokeAllSessions(context.Background(), principal)
+
+	// Assert
+	is.NoErr(err)
+	is.True(a.IsSessionRevoked(principal.SessionID))
+	is.True(a.IsSessionRevoked(otherPrincipal.SessionID))
+
+	sessions, err = a.ReadSessions(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(len(sessions), 0)
+
+	_, _, err = a.RefreshSession(context.Background(), refreshToken)
+	is.True(errors.Is(err, user.ErrSessionNotFound))
+}
+
+func TestSessionsRevokerAfterPasswordChange(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	config := &shared.Config{}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	userRepository := user.NewInMemUserRepository()
+	userService := user.NewUserService(config, repositoryTxer, nil, userRepository, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	a := &AuthService{
+		config:            config,
+		repositoryTxer:    repositoryTxer,
+		userRepository:    userRepository,
+		sessionRepository: user.NewInMemSessionRepository(),
+		userService:       userService,
+	}
+	userService.SetSessionsRevoker(a.SessionsRevoker())
+
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+
+	refreshToken, err := a.StartSession(context.Background(), principal, "Firefox")
+	is.NoErr(err)
+
+	// Act
+	err = userService.ChangePassword(context.Background(), principal, "adm1n", "myNewPassword?!")
+
+	// Assert
+	is.NoErr(err)
+	is.True(a.IsSessionRevoked(principal.SessionID))
+
+	_, _, err = a.RefreshSession(context.Background(), refreshToken)
+	is.True(errors.Is(err, user.ErrSessionNotFound))
+}
+
+func TestRefreshRevocationList(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	sessionRepository := user.NewInMemSessionRepository()
+	a := &AuthService{
+		config:            &shared.Config{},
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		sessionRepository: sessionRepository,
+	}
+
+	revokedSessionID := uuid.New()
+	_, err := sessionRepository.InsertSession(context.Background(), &user.Session{
+		ID:        revokedSessionID,
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: time.Now(),
+	})
+	is.NoErr(err)
+
+	_, err = sessionRepository.InsertSession(context.Background(), &user.Session{
+		ID:        uuid.New(),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+	is.NoErr(err)
+
+	// Act
+	err = a.RefreshRevocationList(context.Background())
+
+	// Assert
+	is.NoErr(err)
+	is.True(a.IsSessionRevoked(revokedSessionID))
+	is.True(!a.IsSessionRevoked(uuid.New()))
+
+	sessionIDs, err := sessionRepository.FindRevokedSessionIDs(context.Background(), time.Now().Add(-2*time.Hour))
+	is.NoErr(err)
+	is.Equal(sessionIDs, []uuid.UUID{revokedSessionID})
+}
+
+func TestSwitchSessionOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := user.NewInMemUserRepository()
+	a := &AuthService{
+		config:            &shared.Config{},
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		userRepository:    userRepository,
+		sessionRepository: user.NewInMemSessionRepository(),
+	}
+
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	organizationID := uuid.New()
+	err = userRepository.InsertMembership(context.Background(), organizationID, admin.ID, user.RoleUser)
+	is.NoErr(err)
+
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	refreshToken, err := a.StartSession(context.Background(), principal, "Firefox")
+	is.NoErr(err)
+
+	switchedPrincipal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", organizationID)
+	is.NoErr(err)
+	switchedPrincipal.SessionID = principal.SessionID
+
+	// Act
+	err = a.SwitchSessionOrganization(context.Background(), switchedPrincipal)
+
+	// Assert
+	is.NoErr(err)
+
+	refreshedPrincipal, _, err := a.RefreshSession(context.Background(), refreshToken)
+	is.NoErr(err)
+	is.Equal(refreshedPrincipal.OrganizationID, organizationID)
+}
+
+func TestAuthenticateTwoFactor(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	twoFactorRepository := user.NewInMemTwoFactorRepository()
+	recoveryCodes := addTwoFactorSample(twoFactorRepository)
+
+	a := &AuthService{
+		config:                 &shared.Config{JWTSecret: "secret"},
+		tokenAuth:              NewSecretTokenAuth("secret"),
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         user.NewInMemUserRepository(),
+		twoFactorRepository:    twoFactorRepository,
+		organizationRepository: user.NewInMemOrganizationRepository(),
+		userService:            user.NewInMemUserService(),
+	}
+
+	principal, err := a.Authenticate(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil)
+	is.NoErr(err)
+
+	step, err := a.RequiredTwoFactorStep(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(step, TwoFactorStepVerify)
+
+	twoFactorToken, err := a.CreateTwoFactorToken(principal, step)
+	is.NoErr(err)
+
+	t.Run("two factor token is no access token", func(t *testing.T) {
+		_, err := NewSecretTokenAuth("secret").VerifyToken(twoFactorToken)
+		is.True(err != nil)
+	})
+	t.Run("two factor token is only valid for its step", func(t *testing.T) {
+		_, err := a.AuthenticateTwoFactorEnrollment(context.Background(), twoFactorToken)
+		is.True(err != nil)
+	})
+	t.Run("invalid code", func(t *testing.T) {
+		_, err := a.AuthenticateTwoFactor(context.Background(), twoFactorToken, "123456", "10.0.0.1")
+		is.True(errors.Is(err, user.ErrInvalidTwoFactorCode))
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		twoFactorPrincipal, err := a.AuthenticateTwoFactor(context.Background(), twoFactorToken, recoveryCodes[0], "10.0.0.1")
+		is.NoErr(err)
+		is.Equal(twoFactorPrincipal.Username, "admin@baralga.com")
+		is.Equal(twoFactorPrincipal.OrganizationID, shared.OrganizationIDSample)
+	})
+	t.Run("used recovery code", func(t *testing.T) {
+		_, err := a.AuthenticateTwoFactor(context.Background(), twoFactorToken, recoveryCodes[0], "10.0.0.1")
+		is.True(errors.Is(err, user.ErrInvalidTwoFactorCode))
+	})
+}
+
+func TestAuthenticateTwoFactorThrottled(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	config := &shared.Config{JWTSecret: "secret"}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	userRepository := user.NewInMemUserRepository()
+	twoFactorRepository := user.NewInMemTwoFactorRepository()
+	loginThrottleRepository := user.NewInMemLoginThrottleRepository()
+	recoveryCodes := addTwoFactorSample(twoFactorRepository)
+
+	a := &AuthService{
+		config:                 config,
+		tokenAuth:              NewSecretTokenAuth("secret"),
+		repositoryTxer:         repositoryTxer,
+		userRepository:         userRepository,
+		twoFactorRepository:    twoFactorRepository,
+		organizationRepository: user.NewInMemOrganizationRepository(),
+		userService:            user.NewUserService(config, repositoryTxer, shared.NewInMemMailResource(), userRepository, nil, nil, nil, nil, nil, nil, loginThrottleRepository, nil, nil, nil),
+	}
+
+	principal, err := a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil, "10.0.0.1")
+	is.NoErr(err)
+
+	twoFactorToken, err := a.CreateTwoFactorToken(principal, TwoFactorStepVerify)
+	is.NoErr(err)
+
+	// Act
+	for i := 0; i < user.LoginFailuresBeforeBackoff; i++ {
+		_, err := a.AuthenticateTwoFactor(context.Background(), twoFactorToken, "000000", "10.0.0.1")
+		is.True(errors.Is(err, user.ErrInvalidTwoFactorCode))
+	}
+
+	// Assert
+	_, err = a.AuthenticateTwoFactor(context.Background(), twoFactorToken, recoveryCodes[0], "10.0.0.1")
+	is.True(errors.Is(err, user.ErrLoginThrottled))
+
+	t.Run("password sign in is throttled as well", func(t *testing.T) {
+		_, err := a.AuthenticateThrottled(context.Background(), "admin@baralga.com", "adm1n", uuid.Nil, "10.0.0.1")
+		is.True(errors.Is(err, user.ErrLoginThrottled))
+
+		loginThrottles, err := loginThrottleRepository.FindLoginThrottles(context.Background(), []string{user.AccountLoginThrottleKey("admin@baralga.com")})
+		is.NoErr(err)
+		is.Equal(len(loginThrottles), 1)
+		is.Equal(loginThrottles[0].Failures, user.LoginFailuresBeforeBackoff)
+	})
+}
+
+func TestRequiredTwoFactorStepOfAdmins(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	organizationRepository := user.NewInMemOrganizationRepository()
+
+	a := &AuthService{
+		config:                 &shared.Config{},
+		userRepository:         user.NewInMemUserRepository(),
+		twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+		organizationRepository: organizationRepository,
+	}
+
+	principal, err := a.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+
+	// Act
+	step, err := a.RequiredTwoFactorStep(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(step, TwoFactorStepNone)
+
+	requireAdminTwoFactor(organizationRepository)
+	step, err = a.RequiredTwoFactorStep(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(step, TwoFactorStepEnroll)
+
+	principal.Roles = []string{user.RoleUser}
+	step, err = a.RequiredTwoFactorStep(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(step, TwoFactorStepNone)
+}
+
+// addTwoFactorSample enables the second factor of the admin and returns its recovery codes
+func addTwoFactorSample(twoFactorRepository user.TwoFactorRepository) []string {
+	recoveryCodes, recoveryCodeHashes, _ := user.NewRecoveryCodes()
+	_, _ = twoFactorRepository.InsertTwoFactor(context.Background(), &user.TwoFactor{
+		UserID:             uuid.MustParse("00000000-0000-0000-1111-000000000001"),
+		Secret:             "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		RecoveryCodeHashes: recoveryCodeHashes,
+		CreatedAt:          time.Now(),
+		ConfirmedAt:        time.Now(),
+	})
+	return recoveryCodes
+}
+
+func requireAdminTwoFactor(organizationRepository user.OrganizationRepository) {
+	organization, _ := organizationRepository.FindOrganizationByID(context.Background(), shared.OrganizationIDSample)
+	organization.AdminTwoFactorRequired = true
+}
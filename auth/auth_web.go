@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -18,6 +20,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/schema"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 	githubOAuth2 "golang.org/x/oauth2/github"
@@ -34,48 +37,69 @@ type loginFormModel struct {
 	Redirect  string
 }
 
+type organizationSwitchFormModel struct {
+	CSRFToken      string
+	OrganizationID string
+}
+
 type loginParams struct {
 	errorMessage string
 	infoMessage  string
 	redirect     string
+	unconfirmed  bool
 }
 
 type AuthWebHandlers struct {
-	config      *shared.Config
-	authService *AuthService
-	userService *user.UserService
-	tokenAuth   *jwtauth.JWTAuth
+	config       *shared.Config
+	authService  *AuthService
+	userService  *user.UserService
+	tokenAuth    *TokenAuth
+	oidcProvider *OIDCProvider
 }
 
-func NewAuthWebHandlers(config *shared.Config, authService *AuthService, userService *user.UserService, tokenAuth *jwtauth.JWTAuth) *AuthWebHandlers {
+func NewAuthWebHandlers(config *shared.Config, authService *AuthService, userService *user.UserService, tokenAuth *TokenAuth) *AuthWebHandlers {
 	return &AuthWebHandlers{
-		config:      config,
-		authService: authService,
-		userService: userService,
-		tokenAuth:   tokenAuth,
+		config:       config,
+		authService:  authService,
+		userService:  userService,
+		tokenAuth:    tokenAuth,
+		oidcProvider: NewOIDCProvider(config, http.DefaultClient),
 	}
 
 }
 
 func (a *AuthWebHandlers) RegisterProtected(r chi.Router) {
 	r.Get("/logout", a.HandleLogoutPage())
+	r.Get("/session/refresh", a.HandleSessionRefresh())
+	r.Get("/organizations/switch", a.HandleOrganizationSwitchPage())
+	r.Post("/organizations/switch", a.HandleOrganizationSwitchForm())
 }
 
 func (a *AuthWebHandlers) RegisterOpen(r chi.Router) {
 	r.Get("/login", a.HandleLoginPage())
 	r.Post("/login", a.HandleLoginForm())
+	r.Post("/login/two-factor", a.HandleTwoFactorLoginForm())
+	r.Post("/login/two-factor/enroll", a.HandleTwoFactorEnrollmentForm())
+
+	if a.config.LoginLinks {
+		r.Get("/login/link", a.HandleLoginLinkPage())
+		r.Post("/login/link", a.HandleLoginLinkForm())
+		r.Get("/login/link/{login-link-token}", a.HandleLoginLinkConfirmPage())
+		r.Post("/login/link/{login-link-token}", a.HandleLoginLinkConfirmForm())
+	}
 
 	r.Handle("/github/login", a.GithubLoginHandler())
 	r.Handle("/github/callback", a.GithubCallbackHandler())
 
 	r.Handle("/google/login", a.GoogleLoginHandler())
 	r.Handle("/google/callback", a.GoogleCallbackHandler())
+
+	r.Handle("/oidc/login", a.OIDCLoginHandler())
+	r.Handle("/oidc/callback", a.OIDCCallbackHandler())
 }
 
 func (a *AuthWebHandlers) HandleLoginForm() http.HandlerFunc {
-	expiryDuration := a.config.ExpiryDuration()
 	authService := a.authService
-	tokenAuth := a.tokenAuth
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := r.ParseForm()
 		if err != nil {
@@ -94,7 +118,27 @@ func (a *AuthWebHandlers) HandleLoginForm() http.HandlerFunc {
 			return
 		}
 
-		principal, err := authService.Authenticate(r.Context(), formModel.EMail, formModel.Password)
+		principal, err := authService.AuthenticateThrottled(r.Context(), formModel.EMail, formModel.Password, uuid.Nil, clientIP(r))
+		if errors.Is(err, user.ErrAccountLocked) || errors.Is(err, user.ErrLoginThrottled) {
+			formModel.CSRFToken = csrf.Token(r)
+			loginParams := &loginParams{
+				errorMessage: "Too many failed sign ins. Please wait a moment and try again.",
+			}
+			if errors.Is(err, user.ErrAccountLocked) {
+				loginParams.errorMessage = "Your account is locked after too many failed sign ins. Please try again later or ask an admin to unlock it."
+			}
+			shared.RenderHTML(w, a.LoginPage(r.URL.Path, formModel, loginParams))
+			return
+		}
+		if errors.Is(err, user.ErrUserNotConfirmed) {
+			formModel.CSRFToken = csrf.Token(r)
+			loginParams := &loginParams{
+				errorMessage: "Please confirm your email first, we've sent you a link when you signed up.",
+				unconfirmed:  true,
+			}
+			shared.RenderHTML(w, a.LoginPage(r.URL.Path, formModel, loginParams))
+			return
+		}
 		if err != nil {
 			formModel.CSRFToken = csrf.Token(r)
 			loginParams := &loginParams{
@@ -104,16 +148,67 @@ func (a *AuthWebHandlers) HandleLoginForm() http.HandlerFunc {
 			return
 		}
 
-		cookie := authService.CreateCookie(tokenAuth, expiryDuration, principal)
-		http.SetCookie(w, &cookie)
-
-		if formModel.Redirect != "" {
-			http.Redirect(w, r, formModel.Redirect, http.StatusFound)
+		err = a.signIn(w, r, principal, formModel.Redirect)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			loginParams := &loginParams{
+				errorMessage: "Login failed. Please try again.",
+			}
+			shared.RenderHTML(w, a.LoginPage(r.URL.Path, formModel, loginParams))
 			return
 		}
+	}
+}
 
-		http.Redirect(w, r, "/", http.StatusFound)
+// clientIP is the ip address of the client, the address of the proxy
+// unless the real ip address is read from the proxy headers
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// signIn starts the session of the principal and redirects to the page the user came from, users with a
+// second factor and admins who have to set one up are asked for it before the session starts
+func (a *AuthWebHandlers) signIn(w http.ResponseWriter, r *http.Request, principal *shared.Principal, redirect string) error {
+	step, err := a.authService.RequiredTwoFactorStep(r.Context(), principal)
+	if err != nil {
+		return err
+	}
+
+	if step != TwoFactorStepNone {
+		return a.renderTwoFactorStep(w, r, principal, step, redirect)
+	}
+
+	err = a.startSession(w, r, principal)
+	if err != nil {
+		return err
+	}
+
+	if redirect == "" {
+		redirect = "/"
+	}
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+	return nil
+}
+
+// startSession starts a new session for the signed in principal and sets the JWT and refresh token cookies
+func (a *AuthWebHandlers) startSession(w http.ResponseWriter, r *http.Request, principal *shared.Principal) error {
+	refreshToken, err := a.authService.StartSession(r.Context(), principal, r.UserAgent())
+	if err != nil {
+		return err
 	}
+
+	cookie := a.authService.CreateCookie(a.tokenAuth, a.config.ExpiryDuration(), principal)
+	http.SetCookie(w, &cookie)
+
+	refreshCookie := a.authService.CreateRefreshCookie(refreshToken)
+	http.SetCookie(w, &refreshCookie)
+
+	return nil
 }
 
 func (a *AuthWebHandlers) HandleLoginPage() http.HandlerFunc {
@@ -133,22 +228,262 @@ func loginParamsFromQueryParams(params url.Values) *loginParams {
 	if len(params["info"]) == 1 && params["info"][0] == "confirm_successfull" {
 		loginParams.infoMessage = "You've been confirmed, so happy time tracking!"
 	}
+	if len(params["info"]) == 1 && params["info"][0] == "password_reset" {
+		loginParams.infoMessage = "Your password has been changed, please sign in with your new password."
+	}
+	if len(params["info"]) == 1 && params["info"][0] == "email_changed" {
+		loginParams.infoMessage = "Your new email has been confirmed."
+	}
 	if len(params["redirect"]) == 1 && strings.HasPrefix(params["redirect"][0], "/") {
 		loginParams.redirect = params["redirect"][0]
 	}
 	return loginParams
 }
 
+// HandleLogoutPage ends the session of the user, so neither the JWT nor the refresh token can be used any longer
 func (a *AuthWebHandlers) HandleLogoutPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
 	authService := a.authService
 	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := authService.EndSession(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
 		cookie := authService.CreateExpiredCookie()
 		http.SetCookie(w, &cookie)
 
+		refreshCookie := authService.CreateExpiredRefreshCookie()
+		http.SetCookie(w, &refreshCookie)
+
 		http.Redirect(w, r, "/", http.StatusFound)
 	}
 }
 
+// HandleSessionRefresh re-issues the cookie of the current user, e.g. after the user's settings changed
+func (a *AuthWebHandlers) HandleSessionRefresh() http.HandlerFunc {
+	expiryDuration := a.config.ExpiryDuration()
+	authService := a.authService
+	tokenAuth := a.tokenAuth
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		refreshedPrincipal, err := authService.AuthenticateTrusted(r.Context(), principal.Username, principal.OrganizationID)
+		if err != nil {
+			cookie := authService.CreateExpiredCookie()
+			http.SetCookie(w, &cookie)
+
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		refreshedPrincipal.SessionID = principal.SessionID
+
+		cookie := authService.CreateCookie(tokenAuth, expiryDuration, refreshedPrincipal)
+		http.SetCookie(w, &cookie)
+
+		redirect := r.URL.Query().Get("redirect")
+		if strings.HasPrefix(redirect, "/") && !strings.HasPrefix(redirect, "//") {
+			http.Redirect(w, r, redirect, http.StatusFound)
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+// HandleOrganizationSwitchPage shows the organizations the user can switch to
+func (a *AuthWebHandlers) HandleOrganizationSwitchPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		memberships, err := userService.ReadMemberships(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Organizations",
+			}
+			shared.RenderHTML(w, OrganizationSwitchPage(pageContext, csrf.Token(r), memberships))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, OrganizationSwitchView(principal, csrf.Token(r), memberships))
+	}
+}
+
+// HandleOrganizationSwitchForm re-issues the cookie of the user for the selected organization
+func (a *AuthWebHandlers) HandleOrganizationSwitchForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	expiryDuration := a.config.ExpiryDuration()
+	authService := a.authService
+	tokenAuth := a.tokenAuth
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var formModel organizationSwitchFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		organizationID, err := uuid.Parse(formModel.OrganizationID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switchedPrincipal, err := authService.AuthenticateTrusted(r.Context(), principal.Username, organizationID)
+		if errors.Is(err, user.ErrMembershipNotFound) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+		switchedPrincipal.SessionID = principal.SessionID
+
+		// admins without second factor can't switch to an organization which requires one
+		step, err := authService.RequiredTwoFactorStep(r.Context(), switchedPrincipal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+		if step == TwoFactorStepEnroll {
+			http.Error(w, "two-factor authentication required", http.StatusForbidden)
+			return
+		}
+
+		// the session stays in the switched organization when the JWT is refreshed
+		err = authService.SwitchSessionOrganization(r.Context(), switchedPrincipal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		cookie := authService.CreateCookie(tokenAuth, expiryDuration, switchedPrincipal)
+		http.SetCookie(w, &cookie)
+
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Redirect", "/")
+	}
+}
+
+func OrganizationSwitchPage(pageContext *shared.PageContext, csrfToken string, memberships []*user.Membership) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					OrganizationSwitchView(pageContext.Principal, csrfToken, memberships),
+				),
+			),
+		},
+	)
+}
+
+func OrganizationSwitchView(principal *shared.Principal, csrfToken string, memberships []*user.Membership) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Organizations"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Ul(
+				Class("list-group"),
+				g.Group(g.Map(memberships, func(membership *user.Membership) g.Node {
+					return OrganizationSwitchItem(principal, csrfToken, membership)
+				})),
+			),
+		),
+	)
+}
+
+func OrganizationSwitchItem(principal *shared.Principal, csrfToken string, membership *user.Membership) g.Node {
+	title := membership.OrganizationTitle
+	if title == "" {
+		title = membership.OrganizationID.String()
+	}
+
+	current := membership.OrganizationID == principal.OrganizationID
+	return Li(
+		Class("list-group-item d-flex justify-content-between align-items-center"),
+		Span(
+			I(Class("bi-building me-2")),
+			g.Text(title),
+		),
+		g.If(current,
+			Span(
+				Class("badge text-bg-primary"),
+				g.Text("current"),
+			),
+		),
+		g.If(!current,
+			FormEl(
+				ghx.Post("/organizations/switch"),
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Input(
+					Type("hidden"),
+					Name("OrganizationID"),
+					Value(membership.OrganizationID.String()),
+				),
+				Button(
+					Type("submit"),
+					Class("btn btn-outline-primary btn-sm"),
+					TitleAttr(fmt.Sprintf("Switch to %v", title)),
+					I(Class("bi-arrow-left-right me-2")),
+					g.Text("Switch"),
+				),
+			),
+		),
+	)
+}
+
 func (a *AuthWebHandlers) GithubLoginHandler() http.Handler {
 	stateConfig, oauth2Config := a.githubAuthConfig()
 	return github.StateHandler(stateConfig, github.LoginHandler(oauth2Config, nil))
@@ -169,6 +504,38 @@ func (a *AuthWebHandlers) GoogleCallbackHandler() http.Handler {
 	return google.StateHandler(stateConfig, google.CallbackHandler(oauth2Config, a.IssueCookieForGoogle(), HandleTokenFailure()))
 }
 
+func (a *AuthWebHandlers) OIDCLoginHandler() http.Handler {
+	return a.oidcHandler(func(stateConfig gologin.CookieConfig, oauth2Config *oauth2.Config) http.Handler {
+		return gologinOauth2.StateHandler(stateConfig, gologinOauth2.LoginHandler(oauth2Config, nil))
+	})
+}
+
+func (a *AuthWebHandlers) OIDCCallbackHandler() http.Handler {
+	return a.oidcHandler(func(stateConfig gologin.CookieConfig, oauth2Config *oauth2.Config) http.Handler {
+		return gologinOauth2.StateHandler(stateConfig, gologinOauth2.CallbackHandler(oauth2Config, a.IssueCookieForOIDC(), HandleTokenFailure()))
+	})
+}
+
+// oidcHandler creates the handler with the endpoints of the OpenID Connect provider,
+// which are discovered on the first sign in
+func (a *AuthWebHandlers) oidcHandler(handler func(gologin.CookieConfig, *oauth2.Config) http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if a.oidcProvider == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		oauth2Config, err := a.oidcProvider.OAuth2Config(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		handler(a.stateConfig(), oauth2Config).ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
 func HandleTokenFailure() http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		_, err := gologinOauth2.TokenFromContext(r.Context())
@@ -178,8 +545,6 @@ func HandleTokenFailure() http.Handler {
 }
 
 func (a *AuthWebHandlers) IssueCookieForGithub() http.Handler {
-	tokenAuth := a.tokenAuth
-	expiryDuration := a.config.ExpiryDuration()
 	authService := a.authService
 	userService := a.userService
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -190,7 +555,7 @@ func (a *AuthWebHandlers) IssueCookieForGithub() http.Handler {
 			return
 		}
 
-		principal, err := authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", *githubUser.ID))
+		principal, err := authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", *githubUser.ID), uuid.Nil)
 		if errors.Is(err, user.ErrUserNotFound) {
 			user := &user.User{
 				Username: fmt.Sprintf("%v", *githubUser.ID),
@@ -203,24 +568,23 @@ func (a *AuthWebHandlers) IssueCookieForGithub() http.Handler {
 				return
 			}
 
-			principal, err = authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", user.Username))
+			principal, err = authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", user.Username), uuid.Nil)
 			if err != nil {
 				http.Redirect(w, r, "/", http.StatusFound)
 				return
 			}
 		}
 
-		cookie := authService.CreateCookie(tokenAuth, expiryDuration, principal)
-		http.SetCookie(w, &cookie)
-
-		http.Redirect(w, r, "/", http.StatusFound)
+		err = a.signIn(w, r, principal, "")
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
 	}
 	return http.HandlerFunc(fn)
 }
 
 func (a *AuthWebHandlers) IssueCookieForGoogle() http.Handler {
-	tokenAuth := a.tokenAuth
-	expiryDuration := a.config.ExpiryDuration()
 	authService := a.authService
 	userService := a.userService
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -231,7 +595,7 @@ func (a *AuthWebHandlers) IssueCookieForGoogle() http.Handler {
 			return
 		}
 
-		principal, err := authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", googleUser.Id))
+		principal, err := authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", googleUser.Id), uuid.Nil)
 		if errors.Is(err, user.ErrUserNotFound) {
 			user := &user.User{
 				Username: fmt.Sprintf("%v", googleUser.Id),
@@ -245,21 +609,106 @@ func (a *AuthWebHandlers) IssueCookieForGoogle() http.Handler {
 				return
 			}
 
-			principal, err = authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", user.Username))
+			principal, err = authService.AuthenticateTrusted(ctx, fmt.Sprintf("%v", user.Username), uuid.Nil)
 			if err != nil {
 				http.Redirect(w, r, "/", http.StatusFound)
 				return
 			}
 		}
 
-		cookie := authService.CreateCookie(tokenAuth, expiryDuration, principal)
-		http.SetCookie(w, &cookie)
+		err = a.signIn(w, r, principal, "")
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+	}
+	return http.HandlerFunc(fn)
+}
 
-		http.Redirect(w, r, "/", http.StatusFound)
+// IssueCookieForOIDC signs in the user of the verified id token. New users join the configured organization
+// or set up their own, the role of the user is kept in sync with the groups of the user if mapped.
+// Only users set up by the provider are signed in, never other users with the subject as username.
+func (a *AuthWebHandlers) IssueCookieForOIDC() http.Handler {
+	authService := a.authService
+	userService := a.userService
+	oidcProvider := a.oidcProvider
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		token, err := gologinOauth2.TokenFromContext(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		identity, err := oidcProvider.VerifyIDToken(ctx, token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		role := oidcProvider.Role(identity)
+
+		principal, err := authService.AuthenticateOIDC(ctx, identity.Subject, uuid.Nil)
+		if errors.Is(err, user.ErrUserNotFound) {
+			err = a.setUpOIDCUser(ctx, identity, role)
+			if err != nil {
+				http.Redirect(w, r, "/", http.StatusFound)
+				return
+			}
+
+			principal, err = authService.AuthenticateOIDC(ctx, identity.Subject, uuid.Nil)
+		}
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		if role != "" {
+			err = userService.UpdateMappedRole(ctx, principal, role)
+			if err != nil {
+				http.Redirect(w, r, "/", http.StatusFound)
+				return
+			}
+
+			principal, err = authService.AuthenticateOIDC(ctx, identity.Subject, principal.OrganizationID)
+			if err != nil {
+				http.Redirect(w, r, "/", http.StatusFound)
+				return
+			}
+		}
+
+		err = a.signIn(w, r, principal, "")
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
 	}
 	return http.HandlerFunc(fn)
 }
 
+func (a *AuthWebHandlers) setUpOIDCUser(ctx context.Context, identity *OIDCIdentity, role string) error {
+	newUser := &user.User{
+		Username: identity.Subject,
+		Name:     identity.Name,
+		EMail:    identity.EMail,
+		Origin:   OIDCOrigin,
+	}
+
+	if a.config.OIDCOrganizationID == "" {
+		return a.userService.SetUpNewUser(ctx, newUser, uuid.Nil)
+	}
+
+	organizationID, err := uuid.Parse(a.config.OIDCOrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		role = user.RoleUser
+	}
+
+	return a.userService.SetUpMember(ctx, newUser, organizationID, role)
+}
+
 func (a *AuthWebHandlers) LoginPage(currentPath string, formModel loginFormModel, loginParams *loginParams) g.Node {
 	return shared.Page(
 		"Sign In",
@@ -269,26 +718,20 @@ func (a *AuthWebHandlers) LoginPage(currentPath string, formModel loginFormModel
 				Class("full-center"),
 				Div(
 					Class("container"),
-					Div(
-						Class("d-flex justify-content-center align-items-center mt-2 mb-3"),
-						Img(
-							Alt("Baralga"),
-							Class("img-responsive"),
-							Src("/assets/baralga_192.png"),
-						),
+					LoginBrand(),
+					LoginForm(formModel, loginParams),
+					g.If(
+						a.config.LoginLinks,
 						Div(
-							Class("ms-4"),
-							H2(
-								g.Text("Baralga"),
-								Small(
-									Class("text-muted"),
-									StyleAttr("display: block; font-size: 70%;"),
-									g.Text("project time tracking"),
-								),
+							Class("text-center mt-3"),
+							A(
+								Href("/login/link"),
+								Class("link-secondary"),
+								I(Class("bi-envelope")),
+								g.Text(" Email me a login link"),
 							),
 						),
 					),
-					LoginForm(formModel, loginParams),
 					Div(
 						Class("d-flex justify-content-center align-items-center mt-4 mb-3"),
 						g.If(
@@ -309,6 +752,15 @@ func (a *AuthWebHandlers) LoginPage(currentPath string, formModel loginFormModel
 								g.Text(" Sign in with Google"),
 							),
 						),
+						g.If(
+							a.oidcProvider != nil,
+							A(
+								Class("btn btn-secondary ms-2"),
+								Href("/oidc/login"),
+								I(Class("bi-box-arrow-in-right")),
+								g.Textf(" Sign in with %v", a.config.OIDCName),
+							),
+						),
 					),
 				),
 			),
@@ -316,6 +768,28 @@ func (a *AuthWebHandlers) LoginPage(currentPath string, formModel loginFormModel
 	)
 }
 
+func LoginBrand() g.Node {
+	return Div(
+		Class("d-flex justify-content-center align-items-center mt-2 mb-3"),
+		Img(
+			Alt("Baralga"),
+			Class("img-responsive"),
+			Src("/assets/baralga_192.png"),
+		),
+		Div(
+			Class("ms-4"),
+			H2(
+				g.Text("Baralga"),
+				Small(
+					Class("text-muted"),
+					StyleAttr("display: block; font-size: 70%;"),
+					g.Text("project time tracking"),
+				),
+			),
+		),
+	)
+}
+
 func LoginForm(formModel loginFormModel, loginParams *loginParams) g.Node {
 	return FormEl(
 		ID("login_form"),
@@ -327,6 +801,14 @@ func LoginForm(formModel loginFormModel, loginParams *loginParams) g.Node {
 				Class("alert alert-warning text-center"),
 				Role("alert"),
 				Span(g.Text(loginParams.errorMessage)),
+				g.If(
+					loginParams.unconfirmed,
+					A(
+						Href("/signup/resend"),
+						Class("alert-link ms-1"),
+						g.Text("Send the link again."),
+					),
+				),
 			),
 		),
 		g.If(
@@ -391,10 +873,12 @@ func LoginForm(formModel loginFormModel, loginParams *loginParams) g.Node {
 			Class("row justify-content-around mt-2"),
 			Div(
 				Class("col-4 text-center"),
-				//A(
-				//	Class("link-secondary"),
-				//	g.Text("Forgot Password?"),
-				//),
+				A(
+					Href("/password/forgot"),
+					ghx.Boost(""),
+					Class("link-secondary"),
+					g.Text("Forgot Password?"),
+				),
 			),
 			Div(
 				Class("col-4 text-center"),
@@ -409,11 +893,20 @@ func LoginForm(formModel loginFormModel, loginParams *loginParams) g.Node {
 	)
 }
 
+// WebVerifier verifies the JWT cookie of the request, an expired or revoked JWT
+// is renewed with the refresh token of the session if the session is still active
 func (a *AuthWebHandlers) WebVerifier() func(http.Handler) http.Handler {
 	tokenAuth := a.tokenAuth
+	authService := a.authService
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token, err := jwtauth.VerifyRequest(tokenAuth, r, jwtauth.TokenFromCookie)
+			token, err := tokenAuth.VerifyRequest(r, jwtauth.TokenFromCookie)
+			if err == nil && authService.IsSessionRevoked(sessionIDFromToken(token)) {
+				err = user.ErrSessionNotFound
+			}
+			if err != nil {
+				token, err = a.refreshSession(w, r)
+			}
 			if err != nil {
 				loginUri := "/login"
 
@@ -434,11 +927,41 @@ func (a *AuthWebHandlers) WebVerifier() func(http.Handler) http.Handler {
 	}
 }
 
-func (a *AuthWebHandlers) githubAuthConfig() (gologin.CookieConfig, *oauth2.Config) {
-	stateConfig := gologin.DefaultCookieConfig
+// refreshSession issues a new JWT cookie from the refresh token cookie of the request
+func (a *AuthWebHandlers) refreshSession(w http.ResponseWriter, r *http.Request) (jwt.Token, error) {
+	refreshCookie, err := r.Cookie("refresh_token")
+	if err != nil {
+		return nil, err
+	}
+
+	principal, refreshToken, err := a.authService.RefreshSession(r.Context(), refreshCookie.Value)
+	if err != nil {
+		expiredRefreshCookie := a.authService.CreateExpiredRefreshCookie()
+		http.SetCookie(w, &expiredRefreshCookie)
+		return nil, err
+	}
+
+	cookie := a.authService.CreateCookie(a.tokenAuth, a.config.ExpiryDuration(), principal)
+	http.SetCookie(w, &cookie)
+
+	// the previous refresh token was used shortly after the rotation, e.g. by concurrent requests
+	if refreshToken != "" {
+		refreshCookie := a.authService.CreateRefreshCookie(refreshToken)
+		http.SetCookie(w, &refreshCookie)
+	}
+
+	return a.tokenAuth.Decode(cookie.Value)
+}
+
+func (a *AuthWebHandlers) stateConfig() gologin.CookieConfig {
 	if !a.config.IsProduction() {
-		stateConfig = gologin.DebugOnlyCookieConfig
+		return gologin.DebugOnlyCookieConfig
 	}
+	return gologin.DefaultCookieConfig
+}
+
+func (a *AuthWebHandlers) githubAuthConfig() (gologin.CookieConfig, *oauth2.Config) {
+	stateConfig := a.stateConfig()
 	oauth2Config := &oauth2.Config{
 		ClientID:     a.config.GithubClientId,
 		ClientSecret: a.config.GithubClientSecret,
@@ -449,10 +972,7 @@ func (a *AuthWebHandlers) githubAuthConfig() (gologin.CookieConfig, *oauth2.Conf
 }
 
 func (a *AuthWebHandlers) googleAuthConfig() (gologin.CookieConfig, *oauth2.Config) {
-	stateConfig := gologin.DefaultCookieConfig
-	if !a.config.IsProduction() {
-		stateConfig = gologin.DebugOnlyCookieConfig
-	}
+	stateConfig := a.stateConfig()
 	oauth2Config := &oauth2.Config{
 		ClientID:     a.config.GoogleClientId,
 		ClientSecret: a.config.GoogleClientSecret,
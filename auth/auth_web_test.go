@@ -1,15 +1,17 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/baralga/shared"
 	"github.com/baralga/user"
-	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
 	"github.com/matryer/is"
 )
 
@@ -34,7 +36,7 @@ func TestHandleLoginFormWithSuccessfullLogin(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	config := &shared.Config{}
 
 	userRepository := user.NewInMemUserRepository()
@@ -42,8 +44,13 @@ func TestHandleLoginFormWithSuccessfullLogin(t *testing.T) {
 		config:    config,
 		tokenAuth: tokenAuth,
 		authService: &AuthService{
-			config:         config,
-			userRepository: userRepository,
+			config:                 config,
+			userRepository:         userRepository,
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewInMemUserService(),
 		},
 	}
 
@@ -57,21 +64,447 @@ func TestHandleLoginFormWithSuccessfullLogin(t *testing.T) {
 	a.HandleLoginForm()(httpRec, r)
 	is.Equal(httpRec.Result().StatusCode, http.StatusFound)
 	is.Equal(httpRec.Header()["Location"][0], "/")
+
+	cookies := httpRec.Result().Cookies()
+	is.Equal(len(cookies), 2)
+	is.Equal(cookies[0].Name, "jwt")
+	is.Equal(cookies[1].Name, "refresh_token")
+	is.True(cookies[1].HttpOnly)
+}
+
+func TestHandleLoginFormWithTwoFactor(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{}
+
+	twoFactorRepository := user.NewInMemTwoFactorRepository()
+	recoveryCodes := addTwoFactorSample(twoFactorRepository)
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:                 config,
+			tokenAuth:              NewSecretTokenAuth("secret"),
+			userRepository:         user.NewInMemUserRepository(),
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    twoFactorRepository,
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewInMemUserService(),
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"admin@baralga.com"}
+	data["Password"] = []string{"adm1n"}
+	data["Redirect"] = []string{"/reports"}
+
+	httpRec := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/login", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandleLoginForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(httpRec.Result().Cookies()), 0)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "/login/two-factor"))
+
+	twoFactorToken := regexp.MustCompile(`name="TwoFactorToken" value="([^"]+)"`).FindStringSubmatch(htmlBody)
+	is.Equal(len(twoFactorToken), 2)
+
+	t.Run("invalid code", func(t *testing.T) {
+		data := url.Values{}
+		data["TwoFactorToken"] = []string{twoFactorToken[1]}
+		data["Code"] = []string{"123456"}
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/login/two-factor", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		a.HandleTwoFactorLoginForm()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(len(httpRec.Result().Cookies()), 0)
+		is.True(strings.Contains(httpRec.Body.String(), "The code is not valid, please try again."))
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		data := url.Values{}
+		data["TwoFactorToken"] = []string{twoFactorToken[1]}
+		data["Code"] = []string{recoveryCodes[0]}
+		data["Redirect"] = []string{"/reports"}
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/login/two-factor", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		a.HandleTwoFactorLoginForm()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header()["Location"][0], "/reports")
+		is.Equal(len(httpRec.Result().Cookies()), 2)
+	})
+}
+
+func TestHandleLoginFormWithRequiredTwoFactorEnrollment(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	config := &shared.Config{}
+	userRepository := user.NewInMemUserRepository()
+	organizationRepository := user.NewInMemOrganizationRepository()
+	requireAdminTwoFactor(organizationRepository)
+	twoFactorRepository := user.NewInMemTwoFactorRepository()
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: NewSecretTokenAuth("secret"),
+		authService: &AuthService{
+			config:                 config,
+			tokenAuth:              NewSecretTokenAuth("secret"),
+			userRepository:         userRepository,
+			twoFactorRepository:    twoFactorRepository,
+			organizationRepository: organizationRepository,
+			userService:            user.NewInMemUserService(),
+		},
+		userService: user.NewUserService(config, shared.NewInMemRepositoryTxer(), nil, userRepository, organizationRepository, nil, nil, nil, nil, twoFactorRepository, user.NewInMemLoginThrottleRepository(), nil, nil, nil),
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"admin@baralga.com"}
+	data["Password"] = []string{"adm1n"}
+
+	r, _ := http.NewRequest("POST", "/login", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandleLoginForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(httpRec.Result().Cookies()), 0)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "/login/two-factor/enroll"))
+	is.True(strings.Contains(htmlBody, "<svg"))
+
+	twoFactor, err := twoFactorRepository.FindTwoFactorByUserID(context.Background(), uuid.MustParse("00000000-0000-0000-1111-000000000001"))
+	is.NoErr(err)
+	is.True(!twoFactor.IsConfirmed())
+}
+
+func TestOIDCLogin(t *testing.T) {
+	is := is.New(t)
+
+	mockServer := newMockOIDCServer(t, map[string]interface{}{
+		"sub":    "jane",
+		"email":  "jane@example.com",
+		"name":   "Jane Doe",
+		"groups": []string{"baralga-admins"},
+	})
+
+	config := mockServer.config()
+	config.OIDCGroupRoles = "baralga-admins:ROLE_ADMIN,baralga-users:ROLE_USER"
+	config.OIDCOrganizationID = shared.OrganizationIDSample.String()
+
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	userRepository := user.NewInMemUserRepository()
+	organizationRepository := user.NewInMemOrganizationRepository()
+	twoFactorRepository := user.NewInMemTwoFactorRepository()
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: NewSecretTokenAuth("secret"),
+		authService: &AuthService{
+			config:                 config,
+			repositoryTxer:         repositoryTxer,
+			userRepository:         userRepository,
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    twoFactorRepository,
+			organizationRepository: organizationRepository,
+		},
+		userService:  user.NewUserService(config, repositoryTxer, nil, userRepository, organizationRepository, nil, nil, nil, nil, twoFactorRepository, nil, nil, nil, nil),
+		oidcProvider: NewOIDCProvider(config, mockServer.server.Client()),
+	}
+
+	signIn := func() *http.Response {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oidc/login", nil)
+
+		a.OIDCLoginHandler().ServeHTTP(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+
+		location, err := url.Parse(httpRec.Header().Get("Location"))
+		is.NoErr(err)
+		is.Equal(location.Path, "/auth")
+		is.Equal(location.Query().Get("client_id"), mockClientID)
+
+		httpRec = httptest.NewRecorder()
+		r, _ = http.NewRequest("GET", "/oidc/callback?code=code&state="+location.Query().Get("state"), nil)
+		r.AddCookie(&http.Cookie{Name: "gologin-temporary-cookie", Value: location.Query().Get("state")})
+
+		a.OIDCCallbackHandler().ServeHTTP(httpRec, r)
+		return httpRec.Result()
+	}
+
+	t.Run("sign in new user", func(t *testing.T) {
+		res := signIn()
+		is.Equal(res.StatusCode, http.StatusFound)
+		is.Equal(res.Header.Get("Location"), "/")
+		is.Equal(len(res.Cookies()), 2)
+
+		u, err := userRepository.FindUserByUsername(context.Background(), "jane")
+		is.NoErr(err)
+		is.Equal(u.Name, "Jane Doe")
+		is.Equal(u.EMail, "jane@example.com")
+		is.Equal(u.Origin, OIDCOrigin)
+		is.Equal(u.OrganizationID, shared.OrganizationIDSample)
+		is.Equal(u.Roles, []string{user.RoleAdmin})
+	})
+
+	t.Run("sign in with changed groups", func(t *testing.T) {
+		mockServer.claims["groups"] = []string{"baralga-users"}
+
+		res := signIn()
+		is.Equal(res.StatusCode, http.StatusFound)
+		is.Equal(len(res.Cookies()), 2)
+
+		u, err := userRepository.FindUserByUsername(context.Background(), "jane")
+		is.NoErr(err)
+		is.Equal(u.Roles, []string{user.RoleUser})
+	})
+
+	t.Run("user not from the provider with subject as username", func(t *testing.T) {
+		mockServer.claims["sub"] = "admin@baralga.com"
+		defer func() { mockServer.claims["sub"] = "jane" }()
+
+		res := signIn()
+		is.Equal(res.StatusCode, http.StatusFound)
+		is.Equal(len(res.Cookies()), 0)
+
+		u, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+		is.NoErr(err)
+		is.Equal(u.Origin, "")
+		is.Equal(u.Roles, []string{user.RoleAdmin})
+	})
+
+	t.Run("disabled user of the provider", func(t *testing.T) {
+		u, err := userRepository.FindUserByUsername(context.Background(), "jane")
+		is.NoErr(err)
+		err = userRepository.UpdateUserEnabled(context.Background(), shared.OrganizationIDSample, u.ID, false)
+		is.NoErr(err)
+
+		res := signIn()
+		is.Equal(res.StatusCode, http.StatusFound)
+		is.Equal(len(res.Cookies()), 0)
+
+		users, err := userRepository.FindUsersByOrganizationID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.Equal(len(users), 2)
+	})
+
+	t.Run("callback with invalid state", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oidc/callback?code=code&state=invalid", nil)
+		r.AddCookie(&http.Cookie{Name: "gologin-temporary-cookie", Value: "state"})
+
+		a.OIDCCallbackHandler().ServeHTTP(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusInternalServerError)
+		is.Equal(len(httpRec.Result().Cookies()), 0)
+	})
+}
+
+func TestOIDCLoginNotConfigured(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &AuthWebHandlers{
+		config: &shared.Config{},
+	}
+
+	r, _ := http.NewRequest("GET", "/oidc/login", nil)
+
+	a.OIDCLoginHandler().ServeHTTP(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
+
+func TestHandleLogoutPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	config := &shared.Config{}
+	a := &AuthWebHandlers{
+		config: config,
+		authService: &AuthService{
+			config:            config,
+			userRepository:    user.NewInMemUserRepository(),
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+		},
+	}
+
+	principal, err := a.authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	_, err = a.authService.StartSession(context.Background(), principal, "Firefox")
+	is.NoErr(err)
+
+	r, _ := http.NewRequest("GET", "/logout", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+	a.HandleLogoutPage()(httpRec, r)
+
+	is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+	is.Equal(len(httpRec.Result().Cookies()), 2)
+	is.True(a.authService.IsSessionRevoked(principal.SessionID))
+}
+
+func TestWebVerifier(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{}
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:            config,
+			userRepository:    user.NewInMemUserRepository(),
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+		},
+	}
+
+	principal, err := a.authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	refreshToken, err := a.authService.StartSession(context.Background(), principal, "Firefox")
+	is.NoErr(err)
+
+	handler := a.WebVerifier()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusIMUsed)
+	}))
+
+	t.Run("without cookies", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/reports", nil)
+		r.RequestURI = "/reports"
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header().Get("Location"), "/login?redirect=%2Freports")
+	})
+
+	t.Run("with refresh token only", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusIMUsed)
+		cookies := httpRec.Result().Cookies()
+		is.Equal(len(cookies), 2)
+		is.Equal(cookies[0].Name, "jwt")
+		is.Equal(cookies[1].Name, "refresh_token")
+		is.True(cookies[1].Value != refreshToken)
+	})
+
+	t.Run("with jwt of revoked session", func(t *testing.T) {
+		jwtCookie := a.authService.CreateCookie(tokenAuth, config.ExpiryDuration(), principal)
+
+		err := a.authService.RevokeSession(context.Background(), principal, principal.SessionID)
+		is.NoErr(err)
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RequestURI = "/"
+		r.AddCookie(&jwtCookie)
+
+		handler.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header().Get("Location"), "/login")
+	})
+}
+
+func TestHandleSessionRefresh(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{}
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:            config,
+			userRepository:    user.NewInMemUserRepository(),
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+		},
+	}
+
+	t.Run("refresh with redirect", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/session/refresh?redirect=/reports", nil)
+		principal := &shared.Principal{
+			Username: "admin@baralga.com",
+		}
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleSessionRefresh()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header()["Location"][0], "/reports")
+		is.Equal(len(httpRec.Result().Cookies()), 1)
+		is.True(httpRec.Result().Cookies()[0].Value != "")
+	})
+
+	t.Run("refresh with external redirect", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/session/refresh?redirect=//evil.com", nil)
+		principal := &shared.Principal{
+			Username: "admin@baralga.com",
+		}
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleSessionRefresh()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header()["Location"][0], "/")
+	})
+
+	t.Run("refresh for unknown user", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/session/refresh", nil)
+		principal := &shared.Principal{
+			Username: "unknown@baralga.com",
+		}
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleSessionRefresh()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header()["Location"][0], "/login")
+	})
 }
 
 func TestHandleLoginFormWithSuccessfullLoginAndRedirect(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	config := &shared.Config{}
 
 	a := &AuthWebHandlers{
 		config:    config,
 		tokenAuth: tokenAuth,
 		authService: &AuthService{
-			config:         config,
-			userRepository: user.NewInMemUserRepository(),
+			config:                 config,
+			userRepository:         user.NewInMemUserRepository(),
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewInMemUserService(),
 		},
 	}
 
@@ -92,7 +525,7 @@ func TestHandleLoginFormWithInvalidLogin(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	config := &shared.Config{}
 
 	userRepository := user.NewInMemUserRepository()
@@ -100,8 +533,11 @@ func TestHandleLoginFormWithInvalidLogin(t *testing.T) {
 		config:    config,
 		tokenAuth: tokenAuth,
 		authService: &AuthService{
-			config:         config,
-			userRepository: userRepository,
+			config:            config,
+			userRepository:    userRepository,
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+			userService:       user.NewInMemUserService(),
 		},
 	}
 
@@ -119,11 +555,89 @@ func TestHandleLoginFormWithInvalidLogin(t *testing.T) {
 	is.True(strings.Contains(htmlBody, "Sign In # Baralga"))
 }
 
+func TestHandleLoginFormWithLockedAccount(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userService := user.NewInMemUserService()
+	for i := 0; i < user.LoginFailuresBeforeLockout; i++ {
+		err := userService.RecordLoginFailure(context.Background(), "someone@baralga.com", "")
+		is.NoErr(err)
+	}
+
+	a := &AuthWebHandlers{
+		config:    &shared.Config{},
+		tokenAuth: NewSecretTokenAuth("secret"),
+		authService: &AuthService{
+			userRepository: user.NewInMemUserRepository(),
+			userService:    userService,
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"someone@baralga.com"}
+	data["Password"] = []string{"adm1n"}
+
+	r, _ := http.NewRequest("POST", "/login", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandleLoginForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Your account is locked"))
+}
+
+func TestHandleLoginFormWithUnconfirmedUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{}
+
+	userRepository := user.NewInMemUserRepository()
+	unconfirmedUser := &user.User{
+		ID:             uuid.New(),
+		Username:       "newbie@baralga.com",
+		EMail:          "newbie@baralga.com",
+		Password:       "$2a$10$NuzYobDOSTCx/EKBClGwGe0A9c8/yC7D4IP75hwz1jn.RCBfdEtb2",
+		OrganizationID: uuid.New(),
+	}
+	_, err := userRepository.InsertUserWithConfirmationID(context.Background(), unconfirmedUser, uuid.New())
+	is.NoErr(err)
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:            config,
+			userRepository:    userRepository,
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			sessionRepository: user.NewInMemSessionRepository(),
+			userService:       user.NewInMemUserService(),
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"newbie@baralga.com"}
+	data["Password"] = []string{"adm1n"}
+
+	r, _ := http.NewRequest("POST", "/login", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandleLoginForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Please confirm your email first"))
+	is.True(strings.Contains(htmlBody, "/signup/resend"))
+}
+
 func TestHandleLoginFormWithInvalidFormData(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	a := &AuthWebHandlers{
 		config:      &shared.Config{},
 		tokenAuth:   tokenAuth,
@@ -147,7 +661,7 @@ func TestHandleLoginFormWithInvalidBodyData(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
 
-	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	tokenAuth := NewSecretTokenAuth("secret")
 	a := &AuthWebHandlers{
 		config:      &shared.Config{},
 		tokenAuth:   tokenAuth,
@@ -189,6 +703,26 @@ func TestLoginParamsFromQueryParams(t *testing.T) {
 		is.Equal(filter.infoMessage, "You've been confirmed, so happy time tracking!")
 	})
 
+	t.Run("login params with info query param 'password_reset'", func(t *testing.T) {
+		params := make(url.Values)
+		params.Add("info", "password_reset")
+
+		filter := loginParamsFromQueryParams(params)
+
+		is.Equal(filter.errorMessage, "")
+		is.Equal(filter.infoMessage, "Your password has been changed, please sign in with your new password.")
+	})
+
+	t.Run("login params with info query param 'email_changed'", func(t *testing.T) {
+		params := make(url.Values)
+		params.Add("info", "email_changed")
+
+		filter := loginParamsFromQueryParams(params)
+
+		is.Equal(filter.errorMessage, "")
+		is.Equal(filter.infoMessage, "Your new email has been confirmed.")
+	})
+
 	t.Run("login params with invalid info query param '-not-valid-'", func(t *testing.T) {
 		params := make(url.Values)
 		params.Add("info", "-not-valid-")
@@ -221,3 +755,121 @@ func TestLoginParamsFromQueryParams(t *testing.T) {
 		is.Equal(filter.redirect, "/reports")
 	})
 }
+
+func TestHandleOrganizationSwitchPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &AuthWebHandlers{
+		config:      &shared.Config{},
+		userService: user.NewInMemUserService(),
+	}
+
+	r, _ := http.NewRequest("GET", "/organizations/switch", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleOrganizationSwitchPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, shared.OrganizationIDSample.String()))
+	is.True(strings.Contains(htmlBody, "current"))
+}
+
+func TestHandleOrganizationSwitchForm(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{}
+
+	userRepository := user.NewInMemUserRepository()
+	admin, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+	is.NoErr(err)
+
+	organizationID := uuid.New()
+	err = userRepository.InsertMembership(context.Background(), organizationID, admin.ID, user.RoleUser)
+	is.NoErr(err)
+
+	organizationRepository := user.NewInMemOrganizationRepository()
+	_, err = organizationRepository.InsertOrganization(context.Background(), &user.Organization{ID: organizationID})
+	is.NoErr(err)
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:                 config,
+			userRepository:         userRepository,
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: organizationRepository,
+		},
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	t.Run("switch to organization of user", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		data := url.Values{}
+		data["OrganizationID"] = []string{organizationID.String()}
+
+		r, _ := http.NewRequest("POST", "/organizations/switch", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Add("HX-Request", "true")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleOrganizationSwitchForm()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(httpRec.Header().Get("HX-Redirect"), "/")
+		is.Equal(len(httpRec.Result().Cookies()), 1)
+
+		token, err := tokenAuth.Decode(httpRec.Result().Cookies()[0].Value)
+		is.NoErr(err)
+		claimedOrganizationID, _ := token.Get("organizationId")
+		is.Equal(claimedOrganizationID, organizationID.String())
+	})
+
+	t.Run("switch to other organization", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		data := url.Values{}
+		data["OrganizationID"] = []string{uuid.New().String()}
+
+		r, _ := http.NewRequest("POST", "/organizations/switch", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleOrganizationSwitchForm()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+		is.Equal(len(httpRec.Result().Cookies()), 0)
+	})
+
+	t.Run("switch to organization requiring two factor of admins", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		requireAdminTwoFactor(organizationRepository)
+
+		data := url.Values{}
+		data["OrganizationID"] = []string{shared.OrganizationIDSample.String()}
+
+		r, _ := http.NewRequest("POST", "/organizations/switch", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleOrganizationSwitchForm()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+		is.Equal(len(httpRec.Result().Cookies()), 0)
+	})
+}
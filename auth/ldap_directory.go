@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+// LDAPOrigin is the origin of users signed in with the LDAP directory
+const LDAPOrigin = "ldap"
+
+// ldapTimeout is how long to wait for the LDAP directory
+const ldapTimeout = 5 * time.Second
+
+var (
+	ErrLDAPUserNotFound       = errors.New("user not found in ldap directory")
+	ErrLDAPInvalidCredentials = errors.New("invalid ldap credentials")
+	ErrLDAPUserConflict       = errors.New("username of ldap user taken by a user not from the ldap directory")
+	ErrLDAPUserDisabled       = errors.New("ldap user disabled")
+)
+
+// LDAPDirectory verifies the credentials of users against an LDAP directory like OpenLDAP or Active Directory.
+// The user is searched with the bind account, then the password is verified by a bind as the user found.
+type LDAPDirectory struct {
+	config    *shared.Config
+	tlsConfig *tls.Config
+}
+
+// LDAPIdentity is the user as read from the LDAP directory
+type LDAPIdentity struct {
+	DN       string
+	Username string
+	EMail    string
+	Name     string
+	Groups   []string
+}
+
+// NewLDAPDirectory creates the LDAP directory, or nil if no url is configured
+func NewLDAPDirectory(config *shared.Config) *LDAPDirectory {
+	if config.LDAPURL == "" {
+		return nil
+	}
+
+	ldapDirectory := &LDAPDirectory{
+		config: config,
+	}
+
+	u, err := url.Parse(config.LDAPURL)
+	if err == nil {
+		ldapDirectory.tlsConfig = &tls.Config{
+			ServerName: u.Hostname(),
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return ldapDirectory
+}
+
+// Authenticate verifies the password of the user in the directory and reads the user,
+// users are identified by their email or by the username if they have no email
+func (d *LDAPDirectory) Authenticate(username, password string) (*LDAPIdentity, error) {
+	if username == "" || password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if d.config.LDAPBindDN != "" {
+		err = conn.Bind(d.config.LDAPBindDN, d.config.LDAPBindPassword)
+		if err != nil {
+			return nil, errors.Wrap(err, "bind to ldap directory failed")
+		}
+	}
+
+	identity, err := d.searchUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Bind(identity.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return nil, ErrLDAPInvalidCredentials
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "bind to ldap directory failed")
+	}
+
+	if identity.Username == "" {
+		identity.Username = username
+	}
+
+	return identity, nil
+}
+
+// Role maps the groups of the identity to the role of the first matching group of the configured group roles
+func (d *LDAPDirectory) Role(identity *LDAPIdentity) string {
+	return groupRole(d.config.LDAPGroupRoles, identity.Groups)
+}
+
+func (d *LDAPDirectory) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(
+		d.config.LDAPURL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}),
+		ldap.DialWithTLSConfig(d.tlsConfig),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "connecting to ldap directory failed")
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if d.config.LDAPStartTLS {
+		err = conn.StartTLS(d.tlsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "start tls with ldap directory failed")
+		}
+	}
+
+	return conn, nil
+}
+
+func (d *LDAPDirectory) searchUser(conn *ldap.Conn, username string) (*LDAPIdentity, error) {
+	searchRequest := ldap.NewSearchRequest(
+		d.config.LDAPBaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(ldapTimeout.Seconds()),
+		false,
+		fmt.Sprintf(d.config.LDAPUserFilter, ldap.EscapeFilter(username)),
+		[]string{d.config.LDAPEmailAttribute, d.config.LDAPNameAttribute, d.config.LDAPGroupAttribute},
+		nil,
+	)
+
+	result, err := conn.Search(searchRequest)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return nil, ErrLDAPUserNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "search in ldap directory failed")
+	}
+
+	// ambiguous filters must not sign in an arbitrary user
+	if len(result.Entries) != 1 {
+		return nil, ErrLDAPUserNotFound
+	}
+
+	entry := result.Entries[0]
+	identity := &LDAPIdentity{
+		DN:       entry.DN,
+		Username: entry.GetAttributeValue(d.config.LDAPEmailAttribute),
+		EMail:    entry.GetAttributeValue(d.config.LDAPEmailAttribute),
+		Name:     entry.GetAttributeValue(d.config.LDAPNameAttribute),
+	}
+
+	for _, group := range entry.GetAttributeValues(d.config.LDAPGroupAttribute) {
+		identity.Groups = append(identity.Groups, groupName(group))
+	}
+
+	return identity, nil
+}
+
+// groupName reads the common name of the group, e.g. baralga-admins of cn=baralga-admins,ou=groups,dc=example,dc=org
+func groupName(group string) string {
+	dn, err := ldap.ParseDN(group)
+	if err != nil || len(dn.RDNs) == 0 {
+		return group
+	}
+
+	for _, attribute := range dn.RDNs[0].Attributes {
+		if strings.EqualFold(attribute.Type, "cn") {
+			return attribute.Value
+		}
+	}
+	return group
+}
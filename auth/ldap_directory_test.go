@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/baralga/shared"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/matryer/is"
+)
+
+func TestLDAPDirectoryAuthenticate(t *testing.T) {
+	is := is.New(t)
+
+	mockServer := newMockLDAPServer(t, false)
+	ldapDirectory := NewLDAPDirectory(mockServer.config("ldap"))
+
+	t.Run("valid credentials", func(t *testing.T) {
+		identity, err := ldapDirectory.Authenticate("jdoe", "jd0e")
+		is.NoErr(err)
+
+		is.Equal(identity.DN, "uid=jdoe,ou=people,dc=example,dc=org")
+		is.Equal(identity.Username, "jane@example.com")
+		is.Equal(identity.EMail, "jane@example.com")
+		is.Equal(identity.Name, "Jane Doe")
+		is.Equal(identity.Groups, []string{"staff", "baralga-admins"})
+		is.Equal(ldapDirectory.Role(identity), "ROLE_ADMIN")
+	})
+
+	t.Run("user without email", func(t *testing.T) {
+		identity, err := ldapDirectory.Authenticate("build", "bu1ld")
+		is.NoErr(err)
+
+		is.Equal(identity.Username, "build")
+		is.Equal(identity.EMail, "")
+		is.Equal(len(identity.Groups), 0)
+		is.Equal(ldapDirectory.Role(identity), "")
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		_, err := ldapDirectory.Authenticate("jdoe", "wrong")
+		is.Equal(err, ErrLDAPInvalidCredentials)
+	})
+
+	t.Run("empty password", func(t *testing.T) {
+		_, err := ldapDirectory.Authenticate("jdoe", "")
+		is.Equal(err, ErrLDAPInvalidCredentials)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := ldapDirectory.Authenticate("unknown", "jd0e")
+		is.Equal(err, ErrLDAPUserNotFound)
+	})
+
+	t.Run("filter injection", func(t *testing.T) {
+		_, err := ldapDirectory.Authenticate("*", "jd0e")
+		is.Equal(err, ErrLDAPUserNotFound)
+	})
+
+	t.Run("invalid bind account", func(t *testing.T) {
+		config := mockServer.config("ldap")
+		config.LDAPBindPassword = "wrong"
+
+		_, err := NewLDAPDirectory(config).Authenticate("jdoe", "jd0e")
+		is.True(err != nil)
+		is.True(err != ErrLDAPInvalidCredentials)
+	})
+}
+
+func TestLDAPDirectoryAuthenticateWithTLS(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("ldaps", func(t *testing.T) {
+		mockServer := newMockLDAPServer(t, true)
+		ldapDirectory := NewLDAPDirectory(mockServer.config("ldaps"))
+		ldapDirectory.tlsConfig.RootCAs = mockServer.rootCAs
+
+		identity, err := ldapDirectory.Authenticate("jdoe", "jd0e")
+		is.NoErr(err)
+		is.Equal(identity.EMail, "jane@example.com")
+	})
+
+	t.Run("start tls", func(t *testing.T) {
+		mockServer := newMockLDAPServer(t, false)
+		config := mockServer.config("ldap")
+		config.LDAPStartTLS = true
+		ldapDirectory := NewLDAPDirectory(config)
+		ldapDirectory.tlsConfig.RootCAs = mockServer.rootCAs
+
+		identity, err := ldapDirectory.Authenticate("jdoe", "jd0e")
+		is.NoErr(err)
+		is.Equal(identity.EMail, "jane@example.com")
+		is.True(mockServer.startedTLS.Load())
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		mockServer := newMockLDAPServer(t, true)
+		ldapDirectory := NewLDAPDirectory(mockServer.config("ldaps"))
+
+		_, err := ldapDirectory.Authenticate("jdoe", "jd0e")
+		is.True(err != nil)
+	})
+}
+
+func TestNewLDAPDirectoryWithoutURL(t *testing.T) {
+	is := is.New(t)
+
+	is.True(NewLDAPDirectory(mockLDAPConfig("")) == nil)
+}
+
+func mockLDAPConfig(url string) *shared.Config {
+	config := &shared.Config{
+		LDAPURL:            url,
+		LDAPBindDN:         "cn=baralga,dc=example,dc=org",
+		LDAPBindPassword:   "s3cret",
+		LDAPBaseDN:         "dc=example,dc=org",
+		LDAPUserFilter:     "(&(objectClass=person)(uid=%s))",
+		LDAPEmailAttribute: "mail",
+		LDAPNameAttribute:  "cn",
+		LDAPGroupAttribute: "memberOf",
+		LDAPGroupRoles:     "baralga-admins:ROLE_ADMIN,baralga-users:ROLE_USER",
+	}
+	return config
+}
+
+// mockLDAPServer is an LDAP directory for tests, which supports simple binds,
+// searches with equality filters and start tls
+type mockLDAPServer struct {
+	listener   net.Listener
+	tlsConfig  *tls.Config
+	rootCAs    *x509.CertPool
+	entries    []*mockLDAPEntry
+	startedTLS atomic.Bool
+}
+
+type mockLDAPEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+func newMockLDAPServer(t *testing.T, useTLS bool) *mockLDAPServer {
+	// borrow the certificate of a test server for 127.0.0.1
+	tlsServer := httptest.NewTLSServer(nil)
+	tlsServer.Close()
+
+	m := &mockLDAPServer{
+		tlsConfig: &tls.Config{Certificates: tlsServer.TLS.Certificates},
+		rootCAs:   x509.NewCertPool(),
+		entries: []*mockLDAPEntry{
+			{
+				dn:       "cn=baralga,dc=example,dc=org",
+				password: "s3cret",
+				attributes: map[string][]string{
+					"objectClass": {"organizationalRole"},
+					"cn":          {"baralga"},
+				},
+			},
+			{
+				dn:       "uid=jdoe,ou=people,dc=example,dc=org",
+				password: "jd0e",
+				attributes: map[string][]string{
+					"objectClass": {"person"},
+					"uid":         {"jdoe"},
+					"cn":          {"Jane Doe"},
+					"mail":        {"jane@example.com"},
+					"memberOf":    {"cn=staff,ou=groups,dc=example,dc=org", "cn=baralga-admins,ou=groups,dc=example,dc=org"},
+				},
+			},
+			{
+				dn:       "uid=build,ou=people,dc=example,dc=org",
+				password: "bu1ld",
+				attributes: map[string][]string{
+					"objectClass": {"person"},
+					"uid":         {"build"},
+				},
+			},
+		},
+	}
+	m.rootCAs.AddCert(tlsServer.Certificate())
+
+	var err error
+	if useTLS {
+		m.listener, err = tls.Listen("tcp", "127.0.0.1:0", m.tlsConfig)
+	} else {
+		m.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.listener.Close() })
+
+	go m.serve()
+
+	return m
+}
+
+func (m *mockLDAPServer) config(scheme string) *shared.Config {
+	return mockLDAPConfig(fmt.Sprintf("%v://%v", scheme, m.listener.Addr()))
+}
+
+func (m *mockLDAPServer) serve() {
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			return
+		}
+		go m.handle(conn)
+	}
+}
+
+func (m *mockLDAPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	bound := false
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		messageID := packet.Children[0].Value
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := request.Children[1].Value.(string)
+			password := request.Children[2].Data.String()
+
+			bound = m.bind(dn, password)
+			resultCode := uint16(ldap.LDAPResultSuccess)
+			if !bound {
+				resultCode = ldap.LDAPResultInvalidCredentials
+			}
+			m.write(conn, messageID, ldap.ApplicationBindResponse, resultCode)
+		case ldap.ApplicationSearchRequest:
+			if !bound {
+				m.write(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights)
+				continue
+			}
+
+			baseDN := request.Children[0].Value.(string)
+			for _, entry := range m.entries {
+				if strings.HasSuffix(entry.dn, baseDN) && entry.matches(request.Children[6]) {
+					m.writeEntry(conn, messageID, entry, request.Children[7])
+				}
+			}
+			m.write(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+		case ldap.ApplicationExtendedRequest:
+			m.write(conn, messageID, ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess)
+			conn = tls.Server(conn, m.tlsConfig)
+			m.startedTLS.Store(true)
+		default:
+			return
+		}
+	}
+}
+
+func (m *mockLDAPServer) bind(dn, password string) bool {
+	for _, entry := range m.entries {
+		if entry.dn == dn && entry.password == password && password != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockLDAPServer) write(w io.Writer, messageID interface{}, tag ber.Tag, resultCode uint16) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), ""))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+	_, _ = w.Write(m.message(messageID, response).Bytes())
+}
+
+func (m *mockLDAPServer) writeEntry(w io.Writer, messageID interface{}, entry *mockLDAPEntry, requestedAttributes *ber.Packet) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for _, requestedAttribute := range requestedAttributes.Children {
+		name := requestedAttribute.Value.(string)
+		values, ok := entry.attributes[name]
+		if !ok {
+			continue
+		}
+
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		attributeValues := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, value := range values {
+			attributeValues.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		}
+		attribute.AppendChild(attributeValues)
+		attributes.AppendChild(attribute)
+	}
+	response.AppendChild(attributes)
+
+	_, _ = w.Write(m.message(messageID, response).Bytes())
+}
+
+func (m *mockLDAPServer) message(messageID interface{}, response *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, ""))
+	message.AppendChild(response)
+	return message
+}
+
+// matches evaluates and, or, equality and present filters
+func (e *mockLDAPEntry) matches(filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !e.matches(child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if e.matches(child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterEqualityMatch:
+		name := filter.Children[0].Value.(string)
+		value := filter.Children[1].Value.(string)
+		for _, attributeValue := range e.attributes[name] {
+			if strings.EqualFold(attributeValue, value) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		_, ok := e.attributes[filter.Data.String()]
+		return ok
+	default:
+		return false
+	}
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/user"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type loginLinkFormModel struct {
+	CSRFToken string
+	EMail     string `validate:"required,email"`
+}
+
+// HandleLoginLinkPage asks for the email to send a login link to
+func (a *AuthWebHandlers) HandleLoginLinkPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formModel := loginLinkFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+		shared.RenderHTML(w, LoginLinkPage(r.URL.Path, LoginLinkForm(formModel, nil)))
+	}
+}
+
+// HandleLoginLinkForm sends a login link to the email
+func (a *AuthWebHandlers) HandleLoginLinkForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			formModel := loginLinkFormModel{}
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, LoginLinkForm(formModel, nil))
+			return
+		}
+
+		var formModel loginLinkFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, LoginLinkForm(formModel, nil))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			fieldErrors := map[string]string{
+				"EMail": "Invalid email.",
+			}
+			shared.RenderHTML(w, LoginLinkForm(formModel, fieldErrors))
+			return
+		}
+
+		err = authService.RequestLoginLink(r.Context(), formModel.EMail, clientIP(r))
+		if errors.Is(err, user.ErrLoginLinkThrottled) {
+			formModel.CSRFToken = csrf.Token(r)
+			fieldErrors := map[string]string{
+				"EMail": "Too many login links requested. Please wait a moment and try again.",
+			}
+			shared.RenderHTML(w, LoginLinkForm(formModel, fieldErrors))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, LoginLinkSuccess(formModel))
+	}
+}
+
+// HandleLoginLinkConfirmPage asks the user to confirm the sign in, so that the login link
+// isn't used up by mail scanners which open the links of an email in advance
+func (a *AuthWebHandlers) HandleLoginLinkConfirmPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loginLinkToken := chi.URLParam(r, "login-link-token")
+		shared.RenderHTML(w, LoginLinkPage(r.URL.Path, LoginLinkConfirmForm(loginLinkToken, csrf.Token(r))))
+	}
+}
+
+// HandleLoginLinkConfirmForm signs in the user of the login link
+func (a *AuthWebHandlers) HandleLoginLinkConfirmForm() http.HandlerFunc {
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authService.AuthenticateLoginLink(r.Context(), chi.URLParam(r, "login-link-token"), clientIP(r))
+		if errors.Is(err, user.ErrAccountLocked) || errors.Is(err, user.ErrLoginThrottled) {
+			shared.RenderHTML(w, LoginLinkPage(r.URL.Path, LoginLinkThrottled(errors.Is(err, user.ErrAccountLocked))))
+			return
+		}
+		if err != nil {
+			shared.RenderHTML(w, LoginLinkPage(r.URL.Path, LoginLinkInvalid()))
+			return
+		}
+
+		err = a.signIn(w, r, principal, "")
+		if err != nil {
+			shared.RenderHTML(w, LoginLinkPage(r.URL.Path, LoginLinkInvalid()))
+			return
+		}
+	}
+}
+
+func LoginLinkPage(currentPath string, content g.Node) g.Node {
+	return shared.Page(
+		"Sign In",
+		currentPath,
+		[]g.Node{
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					LoginBrand(),
+					content,
+				),
+			),
+		},
+	)
+}
+
+func LoginLinkSuccess(formModel loginLinkFormModel) g.Node {
+	return Div(
+		Class("alert alert-success"),
+		Role("alert"),
+		g.Textf("If there is an account for %s, we've sent a link to sign in. The link can be used once within the next %v minutes.", formModel.EMail, int(user.LoginLinkValidity.Minutes())),
+	)
+}
+
+func LoginLinkInvalid() g.Node {
+	return Div(
+		Class("alert alert-warning"),
+		Role("alert"),
+		g.Text("This link is invalid, has already been used or has expired. "),
+		A(
+			Href("/login/link"),
+			Class("alert-link"),
+			g.Text("Request a new link."),
+		),
+	)
+}
+
+func LoginLinkThrottled(locked bool) g.Node {
+	message := "Too many failed sign ins. Please wait a moment and try again."
+	if locked {
+		message = "Your account is locked after too many failed sign ins. Please try again later or ask an admin to unlock it."
+	}
+	return Div(
+		Class("alert alert-warning"),
+		Role("alert"),
+		g.Text(message),
+	)
+}
+
+func LoginLinkForm(formModel loginLinkFormModel, fieldErrors map[string]string) g.Node {
+	return FormEl(
+		ID("login_link_form"),
+		ghx.Post("/login/link"),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		P(
+			g.Text("Enter the email of your account and we'll send you a link to sign in without your password."),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("email"),
+				Required(),
+				Type("email"),
+				Name("EMail"),
+				g.If(
+					fieldErrors["EMail"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["EMail"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "john.doe@mail.com"),
+				Value(formModel.EMail),
+			),
+			Label(
+				g.Attr("for", "email"),
+				g.Text("E-Mail"),
+			),
+			g.If(
+				fieldErrors["EMail"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["EMail"]),
+				),
+			),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Send link"),
+			),
+		),
+		Div(
+			Class("row justify-content-around mt-2"),
+			Div(
+				Class("col-4 text-center"),
+				A(
+					Href("/login"),
+					Class("link-secondary"),
+					g.Text("Back to sign in"),
+				),
+			),
+		),
+	)
+}
+
+func LoginLinkConfirmForm(loginLinkToken, csrfToken string) g.Node {
+	return FormEl(
+		ID("login_link_confirm_form"),
+		Action(fmt.Sprintf("/login/link/%v", loginLinkToken)),
+		Method("POST"),
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(csrfToken),
+		),
+		P(
+			Class("text-center"),
+			g.Text("Sign in with the link we've sent to your email."),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Sign in"),
+			),
+		),
+	)
+}
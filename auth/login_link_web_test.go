@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/user"
+	"github.com/go-chi/chi/v5"
+	"github.com/matryer/is"
+)
+
+func TestHandleLoginPageWithLoginLinks(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &AuthWebHandlers{
+		config: &shared.Config{LoginLinks: true},
+	}
+
+	r, _ := http.NewRequest("GET", "/login", nil)
+
+	a.HandleLoginPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Email me a login link"))
+}
+
+func TestHandleLoginLinkForm(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth := NewSecretTokenAuth("secret")
+	config := &shared.Config{Webroot: "http://localhost:8080", LoginLinks: true}
+	repositoryTxer := shared.NewInMemRepositoryTxer()
+	mailResource := shared.NewInMemMailResource()
+	userRepository := user.NewInMemUserRepository()
+
+	a := &AuthWebHandlers{
+		config:    config,
+		tokenAuth: tokenAuth,
+		authService: &AuthService{
+			config:                 config,
+			tokenAuth:              NewSecretTokenAuth("secret"),
+			userRepository:         userRepository,
+			repositoryTxer:         repositoryTxer,
+			sessionRepository:      user.NewInMemSessionRepository(),
+			twoFactorRepository:    user.NewInMemTwoFactorRepository(),
+			organizationRepository: user.NewInMemOrganizationRepository(),
+			userService:            user.NewUserService(config, repositoryTxer, mailResource, userRepository, nil, nil, nil, nil, nil, nil, user.NewInMemLoginThrottleRepository(), nil, nil, nil),
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"admin@baralga.com"}
+
+	httpRec := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/login/link", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandleLoginLinkForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(strings.Contains(httpRec.Body.String(), "sent a link to sign in"))
+	is.Equal(len(mailResource.Mails), 1)
+
+	loginLinkToken := regexp.MustCompile(`http://localhost:8080/login/link/([^ ]+)`).FindStringSubmatch(mailResource.Mails[0])
+	is.Equal(len(loginLinkToken), 2)
+
+	newLoginLinkRequest := func(method string) *http.Request {
+		r, _ := http.NewRequest(method, fmt.Sprintf("/login/link/%v", loginLinkToken[1]), nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("login-link-token", loginLinkToken[1])
+		return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	t.Run("confirm page doesn't use the login link", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleLoginLinkConfirmPage()(httpRec, newLoginLinkRequest("GET"))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(strings.Contains(httpRec.Body.String(), "login_link_confirm_form"))
+		is.Equal(len(httpRec.Result().Cookies()), 0)
+	})
+
+	t.Run("sign in with login link", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleLoginLinkConfirmForm()(httpRec, newLoginLinkRequest("POST"))
+		is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+		is.Equal(httpRec.Header()["Location"][0], "/")
+
+		cookies := httpRec.Result().Cookies()
+		is.Equal(len(cookies), 2)
+		is.Equal(cookies[0].Name, "jwt")
+		is.Equal(cookies[1].Name, "refresh_token")
+	})
+
+	t.Run("login link used only once", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleLoginLinkConfirmForm()(httpRec, newLoginLinkRequest("POST"))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(len(httpRec.Result().Cookies()), 0)
+		is.True(strings.Contains(httpRec.Body.String(), "has already been used or has expired"))
+	})
+
+	t.Run("too many login links requested", func(t *testing.T) {
+		for i := 1; i < user.LoginLinksBeforeBackoff; i++ {
+			httpRec := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/login/link", strings.NewReader(data.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			a.HandleLoginLinkForm()(httpRec, r)
+			is.True(strings.Contains(httpRec.Body.String(), "sent a link to sign in"))
+		}
+
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/login/link", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		a.HandleLoginLinkForm()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(strings.Contains(httpRec.Body.String(), "Too many login links requested"))
+		is.Equal(len(mailResource.Mails), user.LoginLinksBeforeBackoff)
+	})
+}
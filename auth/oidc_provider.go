@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/baralga/shared"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// OIDCOrigin is the origin of users signed in with the OpenID Connect provider
+const OIDCOrigin = "oidc"
+
+var (
+	ErrOIDCIDTokenMissing = errors.New("no id token in token response")
+	ErrOIDCUserConflict   = errors.New("username of oidc user taken by a user not from the oidc provider")
+	ErrOIDCUserDisabled   = errors.New("oidc user disabled")
+)
+
+// OIDCProvider signs in users with a generic OpenID Connect provider like Keycloak,
+// the endpoints and keys are discovered on first use
+type OIDCProvider struct {
+	config     *shared.Config
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keySet    jwk.Set
+}
+
+// OIDCIdentity is the user as claimed by the id token of the OpenID Connect provider
+type OIDCIdentity struct {
+	Subject string
+	EMail   string
+	Name    string
+	Groups  []string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewOIDCProvider creates the OpenID Connect provider, or nil if no discovery url is configured
+func NewOIDCProvider(config *shared.Config, httpClient *http.Client) *OIDCProvider {
+	if config.OIDCDiscoveryURL == "" {
+		return nil
+	}
+
+	return &OIDCProvider{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+// OAuth2Config reads the OAuth2 config of the authorization code flow with the discovered endpoints
+func (p *OIDCProvider) OAuth2Config(ctx context.Context) (*oauth2.Config, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.config.OIDCClientId,
+		ClientSecret: p.config.OIDCClientSecret,
+		RedirectURL:  p.config.OIDCRedirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+		Scopes: strings.Fields(p.config.OIDCScopes),
+	}, nil
+}
+
+// VerifyIDToken verifies the id token of the token response and maps its claims to the identity
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, token *oauth2.Token) (*OIDCIdentity, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrOIDCIDTokenMissing
+	}
+
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keySet, err := p.readKeySet(ctx, discovery, false)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := p.parseIDToken(rawIDToken, discovery, keySet)
+	if err != nil {
+		// the provider may have rotated its keys since they were read
+		keySet, err = p.readKeySet(ctx, discovery, true)
+		if err != nil {
+			return nil, err
+		}
+
+		idToken, err = p.parseIDToken(rawIDToken, discovery, keySet)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.mapIdentity(idToken), nil
+}
+
+// Role maps the groups of the identity to the role of the first matching group of the
+// configured group roles, or to no role if no group matches
+func (p *OIDCProvider) Role(identity *OIDCIdentity) string {
+	return groupRole(p.config.OIDCGroupRoles, identity.Groups)
+}
+
+// groupRole maps the groups to the role of the first matching group of group roles
+// like baralga-admins:ROLE_ADMIN,baralga-managers:ROLE_MANAGER
+func groupRole(groupRoles string, groups []string) string {
+	for _, groupRole := range strings.Split(groupRoles, ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(groupRole), ":")
+		if !ok {
+			continue
+		}
+
+		if slices.Contains(groups, group) {
+			return role
+		}
+	}
+	return ""
+}
+
+func (p *OIDCProvider) parseIDToken(rawIDToken string, discovery *oidcDiscovery, keySet jwk.Set) (jwt.Token, error) {
+	return jwt.ParseString(
+		rawIDToken,
+		jwt.WithKeySet(keySet),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.OIDCClientId),
+	)
+}
+
+func (p *OIDCProvider) mapIdentity(idToken jwt.Token) *OIDCIdentity {
+	identity := &OIDCIdentity{
+		Subject: idToken.Subject(),
+		EMail:   stringClaim(idToken, p.config.OIDCEmailClaim),
+		Name:    stringClaim(idToken, p.config.OIDCNameClaim),
+	}
+
+	if identity.Name == "" {
+		identity.Name = identity.EMail
+	}
+
+	groups, _ := idToken.Get(p.config.OIDCGroupsClaim)
+	switch groups := groups.(type) {
+	case string:
+		identity.Groups = []string{groups}
+	case []interface{}:
+		for _, group := range groups {
+			if group, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, group)
+			}
+		}
+	}
+
+	return identity
+}
+
+func stringClaim(idToken jwt.Token, name string) string {
+	value, ok := idToken.Get(name)
+	if !ok {
+		return ""
+	}
+
+	claim, _ := value.(string)
+	return claim
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.OIDCDiscoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "discovery of openid connect provider failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery of openid connect provider failed with status %v", res.StatusCode)
+	}
+
+	var discovery oidcDiscovery
+	err = json.NewDecoder(res.Body).Decode(&discovery)
+	if err != nil {
+		return nil, errors.Wrap(err, "discovery of openid connect provider failed")
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) readKeySet(ctx context.Context, discovery *oidcDiscovery, refresh bool) (jwk.Set, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keySet != nil && !refresh {
+		return p.keySet, nil
+	}
+
+	keySet, err := jwk.Fetch(ctx, discovery.JWKSURI, jwk.WithHTTPClient(p.httpClient))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading keys of openid connect provider failed")
+	}
+
+	p.keySet = keySet
+	return p.keySet, nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/matryer/is"
+	"golang.org/x/oauth2"
+)
+
+func TestOIDCProviderVerifyIDToken(t *testing.T) {
+	is := is.New(t)
+
+	mockServer := newMockOIDCServer(t, map[string]interface{}{
+		"sub":    "c0ffee00-0000-0000-0000-000000000001",
+		"email":  "jane@example.com",
+		"name":   "Jane Doe",
+		"groups": []string{"staff", "baralga-admins"},
+	})
+	provider := NewOIDCProvider(mockServer.config(), mockServer.server.Client())
+
+	t.Run("claims of valid id token", func(t *testing.T) {
+		identity, err := provider.VerifyIDToken(context.Background(), mockServer.token(t))
+		is.NoErr(err)
+
+		is.Equal(identity.Subject, "c0ffee00-0000-0000-0000-000000000001")
+		is.Equal(identity.EMail, "jane@example.com")
+		is.Equal(identity.Name, "Jane Doe")
+		is.Equal(identity.Groups, []string{"staff", "baralga-admins"})
+	})
+
+	t.Run("id token for other client", func(t *testing.T) {
+		mockServer.claims["aud"] = "other-client"
+		defer func() { mockServer.claims["aud"] = mockClientID }()
+
+		_, err := provider.VerifyIDToken(context.Background(), mockServer.token(t))
+		is.True(err != nil)
+	})
+
+	t.Run("id token signed with rotated key", func(t *testing.T) {
+		previousToken := mockServer.token(t)
+		mockServer.rotateKey(t)
+
+		_, err := provider.VerifyIDToken(context.Background(), mockServer.token(t))
+		is.NoErr(err)
+
+		_, err = provider.VerifyIDToken(context.Background(), previousToken)
+		is.True(err != nil)
+	})
+
+	t.Run("token response without id token", func(t *testing.T) {
+		_, err := provider.VerifyIDToken(context.Background(), &oauth2.Token{AccessToken: "access"})
+		is.Equal(err, ErrOIDCIDTokenMissing)
+	})
+}
+
+func TestOIDCProviderClaimMapping(t *testing.T) {
+	is := is.New(t)
+
+	mockServer := newMockOIDCServer(t, map[string]interface{}{
+		"sub":                "jdoe",
+		"preferred_username": "jdoe",
+		"mail":               "jane@example.com",
+		"roles":              "baralga-managers",
+	})
+
+	config := mockServer.config()
+	config.OIDCEmailClaim = "mail"
+	config.OIDCNameClaim = "display_name"
+	config.OIDCGroupsClaim = "roles"
+	provider := NewOIDCProvider(config, mockServer.server.Client())
+
+	identity, err := provider.VerifyIDToken(context.Background(), mockServer.token(t))
+	is.NoErr(err)
+
+	is.Equal(identity.EMail, "jane@example.com")
+	is.Equal(identity.Name, "jane@example.com")
+	is.Equal(identity.Groups, []string{"baralga-managers"})
+}
+
+func TestOIDCProviderRole(t *testing.T) {
+	is := is.New(t)
+
+	provider := NewOIDCProvider(&shared.Config{
+		OIDCDiscoveryURL: "http://localhost/.well-known/openid-configuration",
+		OIDCGroupRoles:   "baralga-admins:ROLE_ADMIN, baralga-managers:ROLE_MANAGER",
+	}, http.DefaultClient)
+
+	is.Equal(provider.Role(&OIDCIdentity{Groups: []string{"staff", "baralga-managers", "baralga-admins"}}), "ROLE_ADMIN")
+	is.Equal(provider.Role(&OIDCIdentity{Groups: []string{"baralga-managers"}}), "ROLE_MANAGER")
+	is.Equal(provider.Role(&OIDCIdentity{Groups: []string{"staff"}}), "")
+	is.Equal(provider.Role(&OIDCIdentity{}), "")
+}
+
+func TestNewOIDCProviderWithoutDiscoveryURL(t *testing.T) {
+	is := is.New(t)
+
+	is.True(NewOIDCProvider(&shared.Config{}, http.DefaultClient) == nil)
+}
+
+const mockClientID = "baralga"
+
+// mockOIDCServer is an OpenID Connect provider for tests, which issues an id token with the claims for every code
+type mockOIDCServer struct {
+	server *httptest.Server
+	key    jwk.Key
+	claims map[string]interface{}
+}
+
+func newMockOIDCServer(t *testing.T, claims map[string]interface{}) *mockOIDCServer {
+	m := &mockOIDCServer{
+		claims: claims,
+	}
+	m.claims["aud"] = mockClientID
+	m.rotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/auth",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		publicKey, _ := m.key.PublicKey()
+		keySet := jwk.NewSet()
+		_ = keySet.AddKey(publicKey)
+		_ = json.NewEncoder(w).Encode(keySet)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     m.idToken(t),
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+func (m *mockOIDCServer) config() *shared.Config {
+	return &shared.Config{
+		OIDCDiscoveryURL: m.server.URL + "/.well-known/openid-configuration",
+		OIDCClientId:     mockClientID,
+		OIDCRedirectURL:  "http://localhost:8080/oidc/callback",
+		OIDCScopes:       "openid profile email",
+		OIDCEmailClaim:   "email",
+		OIDCNameClaim:    "name",
+		OIDCGroupsClaim:  "groups",
+	}
+}
+
+func (m *mockOIDCServer) rotateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := jwk.FromRaw(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = jwk.AssignKeyID(key)
+	_ = key.Set(jwk.AlgorithmKey, jwa.RS256)
+
+	m.key = key
+}
+
+func (m *mockOIDCServer) token(t *testing.T) *oauth2.Token {
+	token := &oauth2.Token{AccessToken: "access"}
+	return token.WithExtra(map[string]interface{}{
+		"id_token": m.idToken(t),
+	})
+}
+
+func (m *mockOIDCServer) idToken(t *testing.T) string {
+	idToken := jwt.New()
+	_ = idToken.Set(jwt.IssuerKey, m.server.URL)
+	_ = idToken.Set(jwt.IssuedAtKey, time.Now())
+	_ = idToken.Set(jwt.ExpirationKey, time.Now().Add(5*time.Minute))
+	for name, value := range m.claims {
+		_ = idToken.Set(name, value)
+	}
+
+	signed, err := jwt.Sign(idToken, jwt.WithKey(jwa.RS256, m.key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(signed)
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// revocationList keeps the revoked sessions in memory, so that access tokens
+// of revoked sessions are rejected without a database query on every request.
+// The zero value is an empty revocation list.
+type revocationList struct {
+	mu         sync.RWMutex
+	sessionIDs map[uuid.UUID]struct{}
+}
+
+func (l *revocationList) add(sessionID uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sessionIDs == nil {
+		l.sessionIDs = make(map[uuid.UUID]struct{})
+	}
+	l.sessionIDs[sessionID] = struct{}{}
+}
+
+func (l *revocationList) contains(sessionID uuid.UUID) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.sessionIDs[sessionID]
+	return ok
+}
+
+// replace sets the revoked sessions, sessions revoked meanwhile by this instance
+// are already in the database and so contained in the new sessions
+func (l *revocationList) replace(sessionIDs []uuid.UUID) {
+	revoked := make(map[uuid.UUID]struct{}, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		revoked[sessionID] = struct{}{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sessionIDs = revoked
+}
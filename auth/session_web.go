@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/baralga/user"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type SessionWebHandlers struct {
+	config      *shared.Config
+	authService *AuthService
+}
+
+func NewSessionWebHandlers(config *shared.Config, authService *AuthService) *SessionWebHandlers {
+	return &SessionWebHandlers{
+		config:      config,
+		authService: authService,
+	}
+}
+
+func (a *SessionWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/sessions", a.HandleSessionsPage())
+	r.Post("/sessions/revoke-all", a.HandleRevokeAllSessions())
+	r.Post("/sessions/{session-id}/revoke", a.HandleRevokeSession())
+}
+
+func (a *SessionWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleSessionsPage shows the active sessions of the signed in user
+func (a *SessionWebHandlers) HandleSessionsPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		sessions, err := authService.ReadSessions(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Sessions",
+			}
+			shared.RenderHTML(w, SessionsPage(pageContext, csrf.Token(r), sessions))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, SessionsView(principal, csrf.Token(r), sessions))
+	}
+}
+
+// HandleRevokeSession signs out a session of the signed in user, e.g. on a lost device
+func (a *SessionWebHandlers) HandleRevokeSession() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		sessionID, err := uuid.Parse(chi.URLParam(r, "session-id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = authService.RevokeSession(r.Context(), principal, sessionID)
+		if errors.Is(err, user.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if sessionID == principal.SessionID {
+			a.redirectToLogin(w, r)
+			return
+		}
+
+		sessions, err := authService.ReadSessions(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, SessionsView(principal, csrf.Token(r), sessions))
+	}
+}
+
+// HandleRevokeAllSessions signs out all sessions of the signed in user including the current one
+func (a *SessionWebHandlers) HandleRevokeAllSessions() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := authService.RevokeAllSessions(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		a.redirectToLogin(w, r)
+	}
+}
+
+// redirectToLogin expires the cookies of the revoked current session
+func (a *SessionWebHandlers) redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	cookie := a.authService.CreateExpiredCookie()
+	http.SetCookie(w, &cookie)
+
+	refreshCookie := a.authService.CreateExpiredRefreshCookie()
+	http.SetCookie(w, &refreshCookie)
+
+	if !hx.IsHXRequest(r) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/login")
+}
+
+func SessionsPage(pageContext *shared.PageContext, csrfToken string, sessions []*user.Session) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					SessionsView(pageContext.Principal, csrfToken, sessions),
+				),
+			),
+		},
+	)
+}
+
+func SessionsView(principal *shared.Principal, csrfToken string, sessions []*user.Session) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Sessions"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			g.If(len(sessions) == 0,
+				P(
+					Class("text-muted"),
+					g.Text("No active sessions."),
+				),
+			),
+			Table(
+				Class("table table-sm table-borderless align-middle"),
+				TBody(
+					g.Group(
+						g.Map(sessions, func(session *user.Session) g.Node {
+							return SessionRow(principal, csrfToken, session)
+						}),
+					),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			FormEl(
+				ghx.Post("/sessions/revoke-all"),
+				ghx.Confirm("Do you really want to log out all sessions, including this one?"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Type("submit"),
+					Class("btn btn-outline-danger btn-sm"),
+					I(Class("bi-box-arrow-right me-2")),
+					g.Text("Log out all sessions"),
+				),
+			),
+		),
+	)
+}
+
+func SessionRow(principal *shared.Principal, csrfToken string, session *user.Session) g.Node {
+	location := principal.Location()
+
+	userAgent := session.UserAgent
+	if userAgent == "" {
+		userAgent = "Unknown device"
+	}
+
+	current := session.ID == principal.SessionID
+	return Tr(
+		Td(
+			Class("w-100"),
+			Div(
+				g.Text(userAgent),
+				g.If(current,
+					Span(
+						Class("badge text-bg-primary ms-2"),
+						g.Text("current"),
+					),
+				),
+			),
+			Small(
+				Class("text-muted"),
+				g.Textf(
+					"signed in %v, last active %v",
+					session.CreatedAt.In(location).Format("02.01.2006 15:04"),
+					session.RefreshedAt.In(location).Format("02.01.2006 15:04"),
+				),
+			),
+		),
+		Td(
+			Class("text-nowrap"),
+			FormEl(
+				Class("d-inline"),
+				ghx.Post(fmt.Sprintf("/sessions/%v/revoke", session.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm("Do you really want to log out this session?"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr("Log out session"),
+					I(Class("bi-box-arrow-right")),
+				),
+			),
+		),
+	)
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/user"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleSessionsPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	config := &shared.Config{}
+	authService := &AuthService{
+		config:            config,
+		userRepository:    user.NewInMemUserRepository(),
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		sessionRepository: user.NewInMemSessionRepository(),
+	}
+	a := &SessionWebHandlers{
+		config:      config,
+		authService: authService,
+	}
+
+	principal, err := authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	_, err = authService.StartSession(context.Background(), principal, "Firefox on Linux")
+	is.NoErr(err)
+
+	r, _ := http.NewRequest("GET", "/sessions", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+	a.HandleSessionsPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Firefox on Linux"))
+	is.True(strings.Contains(htmlBody, "current"))
+	is.True(strings.Contains(htmlBody, "Log out all sessions"))
+}
+
+func TestHandleRevokeSession(t *testing.T) {
+	is := is.New(t)
+
+	config := &shared.Config{}
+	authService := &AuthService{
+		config:            config,
+		userRepository:    user.NewInMemUserRepository(),
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		sessionRepository: user.NewInMemSessionRepository(),
+	}
+	a := &SessionWebHandlers{
+		config:      config,
+		authService: authService,
+	}
+
+	principal, err := authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	_, err = authService.StartSession(context.Background(), principal, "Firefox on Linux")
+	is.NoErr(err)
+
+	otherPrincipal, err := authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	_, err = authService.StartSession(context.Background(), otherPrincipal, "Safari on iPhone")
+	is.NoErr(err)
+
+	t.Run("revoke other session", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", fmt.Sprintf("/sessions/%v/revoke", otherPrincipal.SessionID), nil)
+		r.Header.Add("HX-Request", "true")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("session-id", otherPrincipal.SessionID.String())
+
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleRevokeSession()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(authService.IsSessionRevoked(otherPrincipal.SessionID))
+		is.True(!authService.IsSessionRevoked(principal.SessionID))
+
+		htmlBody := httpRec.Body.String()
+		is.True(strings.Contains(htmlBody, "Firefox on Linux"))
+		is.True(!strings.Contains(htmlBody, "Safari on iPhone"))
+	})
+
+	t.Run("revoke unknown session", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		sessionID := uuid.New()
+		r, _ := http.NewRequest("POST", fmt.Sprintf("/sessions/%v/revoke", sessionID), nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("session-id", sessionID.String())
+
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleRevokeSession()(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+	})
+}
+
+func TestHandleRevokeAllSessions(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	config := &shared.Config{}
+	authService := &AuthService{
+		config:            config,
+		userRepository:    user.NewInMemUserRepository(),
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		sessionRepository: user.NewInMemSessionRepository(),
+	}
+	a := &SessionWebHandlers{
+		config:      config,
+		authService: authService,
+	}
+
+	principal, err := authService.AuthenticateTrusted(context.Background(), "admin@baralga.com", uuid.Nil)
+	is.NoErr(err)
+	_, err = authService.StartSession(context.Background(), principal, "Firefox on Linux")
+	is.NoErr(err)
+
+	r, _ := http.NewRequest("POST", "/sessions/revoke-all", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+	a.HandleRevokeAllSessions()(httpRec, r)
+
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Redirect"), "/login")
+	is.Equal(len(httpRec.Result().Cookies()), 2)
+	is.True(authService.IsSessionRevoked(principal.SessionID))
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+)
+
+// ErrJWTKeyType is returned for keys which are neither RSA nor Ed25519 private keys
+var ErrJWTKeyType = errors.New("jwt key must be a RSA or Ed25519 private key")
+
+// TokenAuth signs and verifies the JWT access tokens, either with the JWT secret (HS256)
+// or with private keys (RS256 or EdDSA) whose public keys are published as JWKS so that
+// other services can verify the tokens. The first key signs, all keys verify so that keys
+// can be rotated without signing out users.
+type TokenAuth struct {
+	alg        jwa.SignatureAlgorithm
+	signKey    interface{}
+	verifier   jwt.ParseOption
+	publicKeys jwk.Set
+}
+
+// NewTokenAuth creates the token auth from the configured key files, or from the JWT secret if no key files are configured
+func NewTokenAuth(config *shared.Config) (*TokenAuth, error) {
+	if config.JWTKeyFiles == "" {
+		return NewSecretTokenAuth(config.JWTSecret), nil
+	}
+
+	var privateKeys []jwk.Key
+	for _, keyFile := range strings.Split(config.JWTKeyFiles, ",") {
+		pem, err := os.ReadFile(strings.TrimSpace(keyFile))
+		if err != nil {
+			return nil, errors.Wrap(err, "reading jwt key failed")
+		}
+
+		privateKey, err := jwk.ParseKey(pem, jwk.WithPEM(true))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing jwt key %v failed", keyFile)
+		}
+
+		privateKeys = append(privateKeys, privateKey)
+	}
+
+	return NewPrivateKeyTokenAuth(privateKeys...)
+}
+
+// NewSecretTokenAuth creates the token auth which signs and verifies with the secret (HS256)
+func NewSecretTokenAuth(secret string) *TokenAuth {
+	return &TokenAuth{
+		alg:        jwa.HS256,
+		signKey:    []byte(secret),
+		verifier:   jwt.WithKey(jwa.HS256, []byte(secret)),
+		publicKeys: jwk.NewSet(),
+	}
+}
+
+// NewPrivateKeyTokenAuth creates the token auth which signs with the first private key and
+// verifies with the public keys of all private keys, RSA keys sign with RS256 and Ed25519 keys with EdDSA.
+// Keys are identified by their thumbprint as kid.
+func NewPrivateKeyTokenAuth(privateKeys ...jwk.Key) (*TokenAuth, error) {
+	if len(privateKeys) == 0 {
+		return nil, ErrJWTKeyType
+	}
+
+	publicKeys := jwk.NewSet()
+	for _, privateKey := range privateKeys {
+		alg, err := keyAlgorithm(privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		err = privateKey.Set(jwk.AlgorithmKey, alg)
+		if err != nil {
+			return nil, err
+		}
+
+		err = jwk.AssignKeyID(privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := privateKey.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		err = publicKey.Set(jwk.KeyUsageKey, jwk.ForSignature)
+		if err != nil {
+			return nil, err
+		}
+
+		err = publicKeys.AddKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signKey := privateKeys[0]
+	return &TokenAuth{
+		alg:        signKey.Algorithm().(jwa.SignatureAlgorithm),
+		signKey:    signKey,
+		verifier:   jwt.WithKeySet(publicKeys),
+		publicKeys: publicKeys,
+	}, nil
+}
+
+// keyAlgorithm is the signature algorithm of the private key
+func keyAlgorithm(privateKey jwk.Key) (jwa.SignatureAlgorithm, error) {
+	switch privateKey := privateKey.(type) {
+	case jwk.RSAPrivateKey:
+		return jwa.RS256, nil
+	case jwk.OKPPrivateKey:
+		if privateKey.Crv() == jwa.Ed25519 {
+			return jwa.EdDSA, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w, got %v", ErrJWTKeyType, privateKey.KeyType())
+}
+
+// PublicKeys are the public keys which verify the tokens, empty if the tokens are signed with the JWT secret
+func (t *TokenAuth) PublicKeys() jwk.Set {
+	return t.publicKeys
+}
+
+// Encode signs a new token with the claims
+func (t *TokenAuth) Encode(claims map[string]interface{}) (jwt.Token, string, error) {
+	token := jwt.New()
+	for name, value := range claims {
+		err := token.Set(name, value)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(t.alg, t.signKey))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return token, string(signed), nil
+}
+
+// Decode verifies the signature of the token without validating its claims
+func (t *TokenAuth) Decode(tokenString string) (jwt.Token, error) {
+	return jwt.ParseString(tokenString, t.verifier, jwt.WithValidate(false))
+}
+
+// EncodeFor signs a new token with the claims for the audience, e.g. for a step of the sign in.
+// Tokens for an audience are never accepted as access tokens.
+func (t *TokenAuth) EncodeFor(audience string, claims map[string]interface{}) (jwt.Token, string, error) {
+	audienceClaims := map[string]interface{}{jwt.AudienceKey: audience}
+	for name, value := range claims {
+		audienceClaims[name] = value
+	}
+
+	return t.Encode(audienceClaims)
+}
+
+// VerifyToken verifies the signature of the access token and validates its claims like the expiry,
+// errors are normalized like the errors of jwtauth
+func (t *TokenAuth) VerifyToken(tokenString string) (jwt.Token, error) {
+	token, err := t.Decode(tokenString)
+	if err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	err = jwt.Validate(token)
+	if err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	if len(token.Audience()) > 0 {
+		return token, jwtauth.ErrUnauthorized
+	}
+
+	return token, nil
+}
+
+// VerifyTokenFor verifies the signature of the token and validates its claims like VerifyToken,
+// but only accepts tokens for the audience
+func (t *TokenAuth) VerifyTokenFor(audience, tokenString string) (jwt.Token, error) {
+	token, err := t.Decode(tokenString)
+	if err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	err = jwt.Validate(token, jwt.WithAudience(audience))
+	if err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	return token, nil
+}
+
+// VerifyRequest verifies the first token found in the request
+func (t *TokenAuth) VerifyRequest(r *http.Request, findTokenFns ...func(r *http.Request) string) (jwt.Token, error) {
+	for _, findTokenFn := range findTokenFns {
+		tokenString := findTokenFn(r)
+		if tokenString != "" {
+			return t.VerifyToken(tokenString)
+		}
+	}
+
+	return nil, jwtauth.ErrNoTokenFound
+}
+
+// Verifier verifies the token of the authorization header or the jwt cookie and sets the token and the
+// verification error in the request context like jwtauth.Verifier
+func (t *TokenAuth) Verifier() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := t.VerifyRequest(r, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie)
+			ctx := jwtauth.NewContext(r.Context(), token, err)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
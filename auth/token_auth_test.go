@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestPrivateKeyTokenAuth(t *testing.T) {
+	is := is.New(t)
+
+	rsaKey := newRSAKey(t)
+	tokenAuth, err := NewPrivateKeyTokenAuth(rsaKey)
+	is.NoErr(err)
+
+	_, tokenString, err := tokenAuth.Encode(map[string]interface{}{
+		jwt.SubjectKey:    "admin@baralga.com",
+		jwt.ExpirationKey: jwtauth.ExpireIn(time.Minute),
+	})
+	is.NoErr(err)
+
+	t.Run("token signed with RS256 and kid", func(t *testing.T) {
+		message, err := jws.ParseString(tokenString)
+		is.NoErr(err)
+
+		header := message.Signatures()[0].ProtectedHeaders()
+		is.Equal(header.Algorithm(), jwa.RS256)
+		is.Equal(header.KeyID(), rsaKey.KeyID())
+		is.True(header.KeyID() != "")
+	})
+
+	t.Run("token verified", func(t *testing.T) {
+		token, err := tokenAuth.VerifyToken(tokenString)
+		is.NoErr(err)
+		is.Equal(token.Subject(), "admin@baralga.com")
+	})
+
+	t.Run("public keys without private parts", func(t *testing.T) {
+		is.Equal(tokenAuth.PublicKeys().Len(), 1)
+
+		publicKey, ok := tokenAuth.PublicKeys().Key(0)
+		is.True(ok)
+		is.Equal(publicKey.KeyID(), rsaKey.KeyID())
+		is.Equal(publicKey.KeyUsage(), string(jwk.ForSignature))
+
+		_, isPrivate := publicKey.(jwk.RSAPrivateKey)
+		is.True(!isPrivate)
+
+		token, err := jwt.ParseString(tokenString, jwt.WithKeySet(tokenAuth.PublicKeys()))
+		is.NoErr(err)
+		is.Equal(token.Subject(), "admin@baralga.com")
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		_, expiredTokenString, err := tokenAuth.Encode(map[string]interface{}{
+			jwt.SubjectKey:    "admin@baralga.com",
+			jwt.ExpirationKey: jwtauth.ExpireIn(-time.Minute),
+		})
+		is.NoErr(err)
+
+		_, err = tokenAuth.VerifyToken(expiredTokenString)
+		is.Equal(err, jwtauth.ErrExpired)
+	})
+
+	t.Run("token signed with secret", func(t *testing.T) {
+		_, secretTokenString, err := NewSecretTokenAuth("secret").Encode(map[string]interface{}{
+			jwt.SubjectKey: "admin@baralga.com",
+		})
+		is.NoErr(err)
+
+		_, err = tokenAuth.VerifyToken(secretTokenString)
+		is.Equal(err, jwtauth.ErrUnauthorized)
+	})
+}
+
+func TestPrivateKeyTokenAuthRotation(t *testing.T) {
+	is := is.New(t)
+
+	previousKey := newRSAKey(t)
+	nextKey, err := jwk.FromRaw(newEd25519Key(t))
+	is.NoErr(err)
+
+	previousTokenAuth, err := NewPrivateKeyTokenAuth(previousKey)
+	is.NoErr(err)
+
+	_, previousTokenString, err := previousTokenAuth.Encode(map[string]interface{}{
+		jwt.SubjectKey: "admin@baralga.com",
+	})
+	is.NoErr(err)
+
+	// the next key signs, the previous key still verifies
+	rotatedTokenAuth, err := NewPrivateKeyTokenAuth(nextKey, previousKey)
+	is.NoErr(err)
+	is.Equal(rotatedTokenAuth.PublicKeys().Len(), 2)
+
+	_, err = rotatedTokenAuth.VerifyToken(previousTokenString)
+	is.NoErr(err)
+
+	_, nextTokenString, err := rotatedTokenAuth.Encode(map[string]interface{}{
+		jwt.SubjectKey: "admin@baralga.com",
+	})
+	is.NoErr(err)
+
+	message, err := jws.ParseString(nextTokenString)
+	is.NoErr(err)
+	is.Equal(message.Signatures()[0].ProtectedHeaders().Algorithm(), jwa.EdDSA)
+
+	_, err = rotatedTokenAuth.VerifyToken(nextTokenString)
+	is.NoErr(err)
+
+	_, err = previousTokenAuth.VerifyToken(nextTokenString)
+	is.True(err != nil)
+
+	// the previous key is removed
+	nextTokenAuth, err := NewPrivateKeyTokenAuth(nextKey)
+	is.NoErr(err)
+
+	_, err = nextTokenAuth.VerifyToken(previousTokenString)
+	is.True(err != nil)
+}
+
+func TestPrivateKeyTokenAuthWithUnsupportedKey(t *testing.T) {
+	is := is.New(t)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	is.NoErr(err)
+
+	key, err := jwk.FromRaw(ecdsaKey)
+	is.NoErr(err)
+
+	_, err = NewPrivateKeyTokenAuth(key)
+	is.True(errors.Is(err, ErrJWTKeyType))
+
+	publicKey, err := newRSAKey(t).PublicKey()
+	is.NoErr(err)
+
+	_, err = NewPrivateKeyTokenAuth(publicKey)
+	is.True(errors.Is(err, ErrJWTKeyType))
+}
+
+func TestTokenAuthWithAudience(t *testing.T) {
+	is := is.New(t)
+
+	tokenAuth, err := NewPrivateKeyTokenAuth(newRSAKey(t))
+	is.NoErr(err)
+
+	_, twoFactorTokenString, err := tokenAuth.EncodeFor(twoFactorTokenAudience, map[string]interface{}{
+		jwt.SubjectKey:    "admin@baralga.com",
+		jwt.ExpirationKey: jwtauth.ExpireIn(time.Minute),
+	})
+	is.NoErr(err)
+
+	_, accessTokenString, err := tokenAuth.Encode(map[string]interface{}{
+		jwt.SubjectKey:    "admin@baralga.com",
+		jwt.ExpirationKey: jwtauth.ExpireIn(time.Minute),
+	})
+	is.NoErr(err)
+
+	t.Run("token verified for its audience", func(t *testing.T) {
+		token, err := tokenAuth.VerifyTokenFor(twoFactorTokenAudience, twoFactorTokenString)
+		is.NoErr(err)
+		is.Equal(token.Subject(), "admin@baralga.com")
+		is.Equal(token.Audience(), []string{twoFactorTokenAudience})
+	})
+
+	t.Run("token not accepted as access token", func(t *testing.T) {
+		_, err := tokenAuth.VerifyToken(twoFactorTokenString)
+		is.Equal(err, jwtauth.ErrUnauthorized)
+	})
+
+	t.Run("token not accepted for other audience", func(t *testing.T) {
+		_, err := tokenAuth.VerifyTokenFor(loginLinkTokenAudience, twoFactorTokenString)
+		is.True(err != nil)
+	})
+
+	t.Run("access token not accepted for audience", func(t *testing.T) {
+		_, err := tokenAuth.VerifyTokenFor(twoFactorTokenAudience, accessTokenString)
+		is.True(err != nil)
+	})
+
+	t.Run("token signed with secret", func(t *testing.T) {
+		_, secretTokenString, err := NewSecretTokenAuth("secret").EncodeFor(twoFactorTokenAudience, map[string]interface{}{
+			jwt.SubjectKey: "admin@baralga.com",
+		})
+		is.NoErr(err)
+
+		_, err = tokenAuth.VerifyTokenFor(twoFactorTokenAudience, secretTokenString)
+		is.Equal(err, jwtauth.ErrUnauthorized)
+	})
+}
+
+func TestNewTokenAuth(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("with secret", func(t *testing.T) {
+		tokenAuth, err := NewTokenAuth(&shared.Config{JWTSecret: "secret"})
+		is.NoErr(err)
+		is.Equal(tokenAuth.PublicKeys().Len(), 0)
+
+		_, tokenString, err := tokenAuth.Encode(map[string]interface{}{jwt.SubjectKey: "admin@baralga.com"})
+		is.NoErr(err)
+
+		_, err = NewSecretTokenAuth("secret").VerifyToken(tokenString)
+		is.NoErr(err)
+	})
+
+	t.Run("with key files", func(t *testing.T) {
+		keyDir := t.TempDir()
+
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		is.NoErr(err)
+		rsaKeyFile := writeKeyFile(t, keyDir, "rsa.pem", rsaKey)
+		ed25519KeyFile := writeKeyFile(t, keyDir, "ed25519.pem", newEd25519Key(t))
+
+		tokenAuth, err := NewTokenAuth(&shared.Config{JWTKeyFiles: ed25519KeyFile + ", " + rsaKeyFile})
+		is.NoErr(err)
+		is.Equal(tokenAuth.PublicKeys().Len(), 2)
+		is.Equal(tokenAuth.alg, jwa.EdDSA)
+	})
+
+	t.Run("with missing key file", func(t *testing.T) {
+		_, err := NewTokenAuth(&shared.Config{JWTKeyFiles: filepath.Join(t.TempDir(), "missing.pem")})
+		is.True(err != nil)
+	})
+}
+
+func newRSAKey(t *testing.T) jwk.Key {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := jwk.FromRaw(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey
+}
+
+func writeKeyFile(t *testing.T, dir, name string, privateKey interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(dir, name)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyFile
+}
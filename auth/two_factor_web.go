@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/user"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type twoFactorLoginFormModel struct {
+	CSRFToken      string
+	TwoFactorToken string
+	Code           string
+	Redirect       string
+}
+
+// renderTwoFactorStep asks for the second factor, or sets up the second factor required by the organization
+func (a *AuthWebHandlers) renderTwoFactorStep(w http.ResponseWriter, r *http.Request, principal *shared.Principal, step TwoFactorStep, redirect string) error {
+	twoFactorToken, err := a.authService.CreateTwoFactorToken(principal, step)
+	if err != nil {
+		return err
+	}
+
+	formModel := twoFactorLoginFormModel{
+		CSRFToken:      csrf.Token(r),
+		TwoFactorToken: twoFactorToken,
+		Redirect:       redirect,
+	}
+
+	if step == TwoFactorStepVerify {
+		shared.RenderHTML(w, TwoFactorLoginPage(r.URL.Path, formModel, ""))
+		return nil
+	}
+
+	twoFactor, err := a.userService.StartTwoFactorEnrollment(r.Context(), principal)
+	if err != nil {
+		return err
+	}
+
+	return a.renderTwoFactorEnrollment(w, r, principal, twoFactor, formModel, "")
+}
+
+func (a *AuthWebHandlers) renderTwoFactorEnrollment(w http.ResponseWriter, r *http.Request, principal *shared.Principal, twoFactor *user.TwoFactor, formModel twoFactorLoginFormModel, errorMessage string) error {
+	profile, err := a.userService.ReadProfile(r.Context(), principal)
+	if err != nil {
+		return err
+	}
+
+	shared.RenderHTML(w, TwoFactorEnrollmentLoginPage(r.URL.Path, formModel, twoFactor, profile.TwoFactorAccount(), errorMessage))
+	return nil
+}
+
+// HandleTwoFactorLoginForm completes the sign in with a one time password or a recovery code
+func (a *AuthWebHandlers) HandleTwoFactorLoginForm() http.HandlerFunc {
+	authService := a.authService
+	return func(w http.ResponseWriter, r *http.Request) {
+		formModel, err := decodeTwoFactorLoginForm(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		principal, err := authService.AuthenticateTwoFactor(r.Context(), formModel.TwoFactorToken, formModel.Code, clientIP(r))
+		if errors.Is(err, user.ErrInvalidTwoFactorCode) {
+			shared.RenderHTML(w, TwoFactorLoginPage(r.URL.Path, formModel, "The code is not valid, please try again."))
+			return
+		}
+		if errors.Is(err, user.ErrAccountLocked) || errors.Is(err, user.ErrLoginThrottled) {
+			errorMessage := "Too many invalid codes. Please wait a moment and try again."
+			if errors.Is(err, user.ErrAccountLocked) {
+				errorMessage = "Your account is locked after too many failed sign ins. Please try again later or ask an admin to unlock it."
+			}
+			shared.RenderHTML(w, TwoFactorLoginPage(r.URL.Path, formModel, errorMessage))
+			return
+		}
+		if err != nil {
+			a.renderLoginExpired(w, r, formModel)
+			return
+		}
+
+		err = a.startSession(w, r, principal)
+		if err != nil {
+			a.renderLoginExpired(w, r, formModel)
+			return
+		}
+
+		http.Redirect(w, r, redirectOrHome(formModel.Redirect), http.StatusFound)
+	}
+}
+
+// HandleTwoFactorEnrollmentForm confirms the second factor required by the organization with a first
+// one time password, starts the session and shows the recovery codes once
+func (a *AuthWebHandlers) HandleTwoFactorEnrollmentForm() http.HandlerFunc {
+	authService := a.authService
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		formModel, err := decodeTwoFactorLoginForm(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		principal, err := authService.AuthenticateTwoFactorEnrollment(r.Context(), formModel.TwoFactorToken)
+		if err != nil {
+			a.renderLoginExpired(w, r, formModel)
+			return
+		}
+
+		recoveryCodes, err := userService.ConfirmTwoFactor(r.Context(), principal, formModel.Code)
+		if errors.Is(err, user.ErrInvalidTwoFactorCode) {
+			twoFactor, err := userService.ReadTwoFactor(r.Context(), principal)
+			if err == nil {
+				err = a.renderTwoFactorEnrollment(w, r, principal, twoFactor, formModel, "The code is not valid, please try again.")
+			}
+			if err != nil {
+				a.renderLoginExpired(w, r, formModel)
+			}
+			return
+		}
+		if err != nil {
+			a.renderLoginExpired(w, r, formModel)
+			return
+		}
+
+		err = a.startSession(w, r, principal)
+		if err != nil {
+			a.renderLoginExpired(w, r, formModel)
+			return
+		}
+
+		shared.RenderHTML(w, RecoveryCodesLoginPage(r.URL.Path, recoveryCodes, redirectOrHome(formModel.Redirect)))
+	}
+}
+
+// renderLoginExpired starts the sign in again, e.g. if the two factor token expired
+func (a *AuthWebHandlers) renderLoginExpired(w http.ResponseWriter, r *http.Request, formModel twoFactorLoginFormModel) {
+	loginFormModel := loginFormModel{
+		CSRFToken: csrf.Token(r),
+		Redirect:  formModel.Redirect,
+	}
+	loginParams := &loginParams{
+		errorMessage: "Login failed. Please sign in again.",
+	}
+	shared.RenderHTML(w, a.LoginPage("/login", loginFormModel, loginParams))
+}
+
+func decodeTwoFactorLoginForm(r *http.Request) (twoFactorLoginFormModel, error) {
+	var formModel twoFactorLoginFormModel
+
+	err := r.ParseForm()
+	if err != nil {
+		return formModel, err
+	}
+
+	err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+	if err != nil {
+		return formModel, err
+	}
+	formModel.CSRFToken = csrf.Token(r)
+
+	return formModel, nil
+}
+
+func redirectOrHome(redirect string) string {
+	if redirect == "" {
+		return "/"
+	}
+	return redirect
+}
+
+func TwoFactorLoginPage(currentPath string, formModel twoFactorLoginFormModel, errorMessage string) g.Node {
+	return twoFactorLoginLayout(
+		currentPath,
+		FormEl(
+			ID("two_factor_form"),
+			Action("/login/two-factor"),
+			Method("POST"),
+			twoFactorLoginError(errorMessage),
+			P(
+				Class("text-center"),
+				g.Text("Enter the code of your authenticator app or one of your recovery codes."),
+			),
+			twoFactorLoginHiddenInputs(formModel),
+			twoFactorLoginCodeInput(),
+			Div(
+				Class("container-fluid text-center"),
+				Button(
+					Type("submit"),
+					Class("btn btn-primary w-100"),
+					g.Text("Verify"),
+				),
+			),
+		),
+	)
+}
+
+func TwoFactorEnrollmentLoginPage(currentPath string, formModel twoFactorLoginFormModel, twoFactor *user.TwoFactor, account, errorMessage string) g.Node {
+	return twoFactorLoginLayout(
+		currentPath,
+		FormEl(
+			ID("two_factor_form"),
+			Action("/login/two-factor/enroll"),
+			Method("POST"),
+			twoFactorLoginError(errorMessage),
+			Div(
+				Class("alert alert-info text-center"),
+				Role("alert"),
+				g.Text("Your organization requires two-factor authentication for admins."),
+			),
+			user.TwoFactorSecret(twoFactor, account),
+			twoFactorLoginHiddenInputs(formModel),
+			twoFactorLoginCodeInput(),
+			Div(
+				Class("container-fluid text-center"),
+				Button(
+					Type("submit"),
+					Class("btn btn-primary w-100"),
+					g.Text("Enable and sign in"),
+				),
+			),
+		),
+	)
+}
+
+func RecoveryCodesLoginPage(currentPath string, recoveryCodes []string, redirect string) g.Node {
+	return twoFactorLoginLayout(
+		currentPath,
+		Div(
+			user.RecoveryCodeList(recoveryCodes),
+			A(
+				Href(redirect),
+				Class("btn btn-primary w-100"),
+				g.Text("Continue"),
+			),
+		),
+	)
+}
+
+func twoFactorLoginLayout(currentPath string, content g.Node) g.Node {
+	return shared.Page(
+		"Two-Factor Authentication",
+		currentPath,
+		[]g.Node{
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					LoginBrand(),
+					content,
+				),
+			),
+		},
+	)
+}
+
+func twoFactorLoginError(errorMessage string) g.Node {
+	return g.If(
+		errorMessage != "",
+		Div(
+			Class("alert alert-warning text-center"),
+			Role("alert"),
+			Span(g.Text(errorMessage)),
+		),
+	)
+}
+
+func twoFactorLoginHiddenInputs(formModel twoFactorLoginFormModel) g.Node {
+	return g.Group([]g.Node{
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		Input(
+			Type("hidden"),
+			Name("TwoFactorToken"),
+			Value(formModel.TwoFactorToken),
+		),
+		g.If(
+			formModel.Redirect != "",
+			Input(
+				Type("hidden"),
+				Name("Redirect"),
+				Value(formModel.Redirect),
+			),
+		),
+	})
+}
+
+func twoFactorLoginCodeInput() g.Node {
+	return Div(
+		Class("form-floating mb-3"),
+		Input(
+			ID("code"),
+			Type("text"),
+			Name("Code"),
+			Class("form-control"),
+			g.Attr("autocomplete", "one-time-code"),
+			g.Attr("placeholder", "123456"),
+			AutoFocus(),
+		),
+		Label(
+			g.Attr("for", "code"),
+			g.Text("Code"),
+		),
+	)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"fmt"
 	"io/fs"
@@ -9,13 +10,15 @@ import (
 	"os"
 	"time"
 
+	// embed time zone database for per user time zones
+	_ "time/tzdata"
+
 	"github.com/baralga/auth"
 	"github.com/baralga/shared"
 	"github.com/baralga/tracking"
 	"github.com/baralga/user"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/jwtauth/v5"
 	"github.com/go-http-utils/etag"
 	"github.com/gorilla/csrf"
 	"github.com/hellofresh/health-go/v5"
@@ -73,42 +76,131 @@ func newApp() (*shared.Config, *pgxpool.Pool, *chi.Mux, error) {
 	projectRestHandlers := tracking.NewProjectController(&config, projectRepository, projectService)
 	projectWebHandlers := tracking.NewProjectWebHandlers(&config, projectService, projectRepository)
 
+	holidayRepository := tracking.NewDbHolidayRepository(connPool)
+	holidayService := tracking.NewHolidayService(repositoryTxer, holidayRepository)
+	holidayRestHandlers := tracking.NewHolidayRestHandlers(&config, holidayService)
+	holidayWebHandlers := tracking.NewHolidayWebHandlers(&config, holidayService)
+
 	tagRepository := tracking.NewDbTagRepository(connPool)
 	tagService := tracking.NewTagService(tagRepository)
 	activityRepository := tracking.NewDbActivityRepository(connPool)
-	activityService := tracking.NewActitivityService(repositoryTxer, activityRepository, tagRepository, tagService)
+	activityService := tracking.NewActitivityService(repositoryTxer, activityRepository, tagRepository, tagService, holidayRepository)
 	activityRestHandlers := tracking.NewActivityRestHandlers(&config, activityService, activityRepository)
-	activityWebHandlers := tracking.NewActivityWebHandlers(&config, activityService, activityRepository, projectRepository)
 
-	reportWebHandlers := tracking.NewReportWebHandlers(&config, activityService)
+	absenceRepository := tracking.NewDbAbsenceRepository(connPool)
+	absenceService := tracking.NewAbsenceService(repositoryTxer, absenceRepository)
+	absenceRestHandlers := tracking.NewAbsenceRestHandlers(&config, absenceService)
+	absenceWebHandlers := tracking.NewAbsenceWebHandlers(&config, absenceService)
+
+	activityWebHandlers := tracking.NewActivityWebHandlers(&config, activityService, activityRepository, projectRepository, absenceService)
+
+	workingTimeRepository := tracking.NewDbWorkingTimeRepository(connPool)
+	workingTimeService := tracking.NewWorkingTimeService(repositoryTxer, workingTimeRepository, activityRepository, holidayRepository, absenceRepository)
+	workingTimeRestHandlers := tracking.NewWorkingTimeRestHandlers(&config, workingTimeService)
+	workingTimeWebHandlers := tracking.NewWorkingTimeWebHandlers(&config, workingTimeService)
+
+	reportWebHandlers := tracking.NewReportWebHandlers(&config, activityService, workingTimeService)
+
+	userDataService := tracking.NewUserDataService(activityService, activityRepository, tagRepository, absenceRepository, workingTimeRepository)
 
 	// User
 	userRepository := user.NewDbUserRepository(connPool)
 	organizationRepository := user.NewDbOrganizationRepository(connPool)
-	userService := user.NewUserService(&config, repositoryTxer, mailResource, userRepository, organizationRepository, projectService.OrganizationInitializer())
+	invitationRepository := user.NewDbInvitationRepository(connPool)
+	teamRepository := user.NewDbTeamRepository(connPool)
+	roleRepository := user.NewDbRoleRepository(connPool)
+	apiTokenRepository := user.NewDbAPITokenRepository(connPool)
+	sessionRepository := user.NewDbSessionRepository(connPool)
+	twoFactorRepository := user.NewDbTwoFactorRepository(connPool)
+	loginThrottleRepository := user.NewDbLoginThrottleRepository(connPool)
+	userService := user.NewUserService(&config, repositoryTxer, mailResource, userRepository, organizationRepository, invitationRepository, teamRepository, roleRepository, apiTokenRepository, twoFactorRepository, loginThrottleRepository, projectService.OrganizationInitializer(), userDataService.UserDataExporter(), userDataService.UserDataRemover())
 	userWeb := user.NewUserWeb(&config, userService, userRepository)
+	invitationWeb := user.NewInvitationWebHandlers(&config, userService)
+	userAdminWeb := user.NewUserAdminWebHandlers(&config, userService)
+	passwordResetWeb := user.NewPasswordResetWebHandlers(&config, userService)
+	userRestHandlers := user.NewUserRestHandlers(&config, userService)
+	profileWeb := user.NewProfileWebHandlers(&config, userService)
+	profileRestHandlers := user.NewProfileRestHandlers(&config, userService)
+
+	// delete users who never confirmed their email
+	go userService.RunUnconfirmedUserCleanup(context.Background(), time.Hour)
+
+	// delete failed sign ins which are no longer counted
+	go userService.RunLoginThrottleCleanup(context.Background(), time.Hour)
+	organizationWeb := user.NewOrganizationWebHandlers(&config, userService)
+	organizationRestHandlers := user.NewOrganizationRestHandlers(&config, userService)
+	teamWeb := user.NewTeamWebHandlers(&config, userService)
+	teamRestHandlers := user.NewTeamRestHandlers(&config, userService)
+	roleWeb := user.NewRoleWebHandlers(&config, userService)
+	roleRestHandlers := user.NewRoleRestHandlers(&config, userService)
+	apiTokenWeb := user.NewAPITokenWebHandlers(&config, userService)
+	twoFactorWeb := user.NewTwoFactorWebHandlers(&config, userService)
+	apiTokenRestHandlers := user.NewAPITokenRestHandlers(&config, userService)
+	scimRestHandlers := user.NewSCIMRestHandlers(&config, userService)
+
+	// team leads see the activities of their team members
+	activityService.SetTeamsReader(userService.TeamsReader())
+
+	// week start, working days and date format are set up per organization
+	activityService.SetOrganizationSettingsReader(userService.OrganizationSettingsReader())
+	workingTimeService.SetOrganizationSettingsReader(userService.OrganizationSettingsReader())
 
 	// Auth
-	tokenAuth := jwtauth.New("HS256", []byte(config.JWTSecret), nil)
-	authService := auth.NewAuthService(&config, userRepository)
+	tokenAuth, err := auth.NewTokenAuth(&config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	authService := auth.NewAuthService(&config, tokenAuth, repositoryTxer, userRepository, roleRepository, apiTokenRepository, sessionRepository, twoFactorRepository, organizationRepository, userService)
 	authController := auth.NewAuthRestHandlers(&config, authService, tokenAuth)
 	authWeb := auth.NewAuthWebHandlers(&config, authService, userService, tokenAuth)
+	sessionWeb := auth.NewSessionWebHandlers(&config, authService)
+
+	// changed passwords and disabled members sign out on all devices
+	userService.SetSessionsRevoker(authService.SessionsRevoker())
+
+	// revoked sessions are shared between instances through the database
+	go authService.RunRevocationListRefresh(context.Background(), 15*time.Second)
 
 	apiHandlers := []shared.DomainHandler{
 		authController,
 		activityRestHandlers,
 		projectRestHandlers,
+		workingTimeRestHandlers,
+		holidayRestHandlers,
+		absenceRestHandlers,
+		userRestHandlers,
+		profileRestHandlers,
+		organizationRestHandlers,
+		teamRestHandlers,
+		roleRestHandlers,
+		apiTokenRestHandlers,
+	}
+	scimHandlers := []shared.DomainHandler{
+		scimRestHandlers,
 	}
 	webHandlers := []shared.DomainHandler{
 		userWeb,
+		invitationWeb,
+		userAdminWeb,
+		passwordResetWeb,
+		profileWeb,
+		organizationWeb,
+		teamWeb,
+		roleWeb,
+		apiTokenWeb,
+		twoFactorWeb,
 		activityWebHandlers,
 		authWeb,
+		sessionWeb,
 		projectWebHandlers,
 		reportWebHandlers,
+		workingTimeWebHandlers,
+		holidayWebHandlers,
+		absenceWebHandlers,
 	}
 
 	router := chi.NewRouter()
-	registerRoutes(&config, router, authController, authWeb, apiHandlers, webHandlers)
+	registerRoutes(&config, router, authController, authWeb, apiHandlers, scimHandlers, webHandlers)
 	registerHealthcheck(&config, router)
 
 	return &config, connPool, router, nil
@@ -128,12 +220,16 @@ func registerHealthcheck(config *shared.Config, router *chi.Mux) {
 	router.Get("/health", h.HandlerFunc)
 }
 
-func registerRoutes(config *shared.Config, router *chi.Mux, authController *auth.AuthRestHandlers, authWeb *auth.AuthWebHandlers, apiHandlers []shared.DomainHandler, webHandlers []shared.DomainHandler) {
+func registerRoutes(config *shared.Config, router *chi.Mux, authController *auth.AuthRestHandlers, authWeb *auth.AuthWebHandlers, apiHandlers []shared.DomainHandler, scimHandlers []shared.DomainHandler, webHandlers []shared.DomainHandler) {
+	if config.BehindProxy {
+		router.Use(shared.ClientIP(config.ClientIPHeader, config.ProxyCount))
+	}
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.Compress(5))
 
 	router.Mount("/api", apiRouteHandler(authController, apiHandlers))
+	router.Mount("/scim/v2", apiRouteHandler(authController, scimHandlers))
 	registerWebRoutes(config, router, authController, authWeb, webHandlers)
 }
 
@@ -145,12 +241,11 @@ func apiRouteHandler(authController *auth.AuthRestHandlers, apiHandlers []shared
 	}
 
 	r.Group(func(r chi.Router) {
+		r.Use(authController.APITokenVerifier())
 		r.Use(authController.JWTVerifier())
 		r.Use(authController.JWTPrincipalMiddleware())
 
-		for _, apiHandler := range apiHandlers {
-			apiHandler.RegisterProtected(r)
-		}
+		shared.RegisterProtectedRoutes(r, apiHandlers)
 	})
 
 	return r
@@ -160,6 +255,7 @@ func registerWebRoutes(config *shared.Config, router *chi.Mux, authController *a
 	assetsDir, _ := fs.Sub(assets, "shared")
 	router.Mount("/assets/", etag.Handler(http.FileServer(http.FS(assetsDir)), true))
 	router.Get("/manifest.webmanifest", shared.HandleWebManifest())
+	router.Get("/.well-known/jwks.json", authController.HandleJWKS())
 
 	secureMiddleware := secure.New(secure.Options{
 		HostsProxyHeaders:     []string{"X-Forwarded-Host"},
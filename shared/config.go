@@ -13,9 +13,17 @@ type Config struct {
 	DbMaxConns int32  `default:"3"`
 	Env        string `default:"dev"`
 
-	JWTSecret  string `default:"secret"`
-	JWTExpiry  string `default:"24h"`
-	CSRFSecret string `default:"CSRFsecret"`
+	BehindProxy    bool   `default:"false"`
+	ClientIPHeader string `default:"X-Forwarded-For"`
+	ProxyCount     int    `default:"1"`
+
+	JWTSecret          string `default:"secret"`
+	JWTExpiry          string `default:"15m"`
+	RefreshTokenExpiry string `default:"24h"`
+	JWTKeyFiles        string `default:""`
+	CSRFSecret         string `default:"CSRFsecret"`
+
+	LoginLinks bool `default:"false"`
 
 	SMTPServername string `default:"smtp.server:465"`
 	SMTPFrom       string `default:"smtp.from@baralga.com"`
@@ -31,12 +39,49 @@ type Config struct {
 	GoogleClientId     string `default:""`
 	GoogleClientSecret string `default:""`
 	GoogleRedirectURL  string `default:"http://localhost:8080/google/callback"`
+
+	OIDCName           string `default:"OpenID Connect"`
+	OIDCDiscoveryURL   string `default:""`
+	OIDCClientId       string `default:""`
+	OIDCClientSecret   string `default:""`
+	OIDCRedirectURL    string `default:"http://localhost:8080/oidc/callback"`
+	OIDCScopes         string `default:"openid profile email"`
+	OIDCEmailClaim     string `default:"email"`
+	OIDCNameClaim      string `default:"name"`
+	OIDCGroupsClaim    string `default:"groups"`
+	OIDCGroupRoles     string `default:""`
+	OIDCOrganizationID string `default:""`
+
+	LDAPURL            string `default:""`
+	LDAPStartTLS       bool   `default:"false"`
+	LDAPBindDN         string `default:""`
+	LDAPBindPassword   string `default:""`
+	LDAPBaseDN         string `default:""`
+	LDAPUserFilter     string `default:"(uid=%s)"`
+	LDAPEmailAttribute string `default:"mail"`
+	LDAPNameAttribute  string `default:"cn"`
+	LDAPGroupAttribute string `default:"memberOf"`
+	LDAPGroupRoles     string `default:""`
+	LDAPOrganizationID string `default:""`
+
+	SCIMGroupRoles string `default:""`
 }
 
+// ExpiryDuration is how long the JWT access tokens are valid
 func (c *Config) ExpiryDuration() time.Duration {
 	expiryDuration, err := time.ParseDuration(c.JWTExpiry)
 	if err != nil {
 		log.Printf("could not parse jwt expiry %s", c.JWTExpiry)
+		expiryDuration = time.Duration(15 * time.Minute)
+	}
+	return expiryDuration
+}
+
+// RefreshExpiryDuration is how long a session is kept alive without being refreshed
+func (c *Config) RefreshExpiryDuration() time.Duration {
+	expiryDuration, err := time.ParseDuration(c.RefreshTokenExpiry)
+	if err != nil {
+		log.Printf("could not parse refresh token expiry %s", c.RefreshTokenExpiry)
 		expiryDuration = time.Duration(24 * time.Hour)
 	}
 	return expiryDuration
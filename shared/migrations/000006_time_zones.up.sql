@@ -0,0 +1,59 @@
+-- Organization default time zone
+ALTER TABLE organizations ADD time_zone VARCHAR(100) NOT NULL DEFAULT 'Europe/Berlin';
+
+-- User time zone (falls back to organization time zone)
+ALTER TABLE users ADD time_zone VARCHAR(100);
+
+-- Store activity times with time zone
+DROP VIEW IF EXISTS activities_agg;
+
+ALTER TABLE activities
+ALTER COLUMN start_time TYPE timestamptz USING start_time AT TIME ZONE 'Europe/Berlin';
+
+ALTER TABLE activities
+ALTER COLUMN end_time TYPE timestamptz USING end_time AT TIME ZONE 'Europe/Berlin';
+
+-- Aggregate activities in the time zone of their user
+CREATE VIEW activities_agg as
+SELECT
+  activities.activity_id,
+  activities.project_id,
+  activities.org_id,
+  activities.username,
+  activities.start_time,
+  activities.end_time,
+  activities.description,
+  EXTRACT(day from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as day, 
+  EXTRACT(week from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as week, 
+  EXTRACT(month from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as month, 
+  EXTRACT(quarter from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as quarter, 
+  EXTRACT(year from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as year, 
+  EXTRACT(minute from end_time - start_time) as duration_minutes, 
+  EXTRACT(hour from end_time - start_time) as duration_hours,
+  EXTRACT(hour from end_time - start_time) * 60 + EXTRACT(minute from end_time - start_time) as duration_minutes_total,
+  -- Tag information as JSON array
+  COALESCE(
+    JSON_AGG(
+      JSON_BUILD_OBJECT(
+        'name', t.name,
+        'color', t.color
+      ) ORDER BY t.name
+    ) FILTER (WHERE t.tag_id IS NOT NULL),
+    '[]'::json
+  ) as tags_info
+FROM 
+  activities
+JOIN organizations o ON activities.org_id = o.org_id
+LEFT JOIN users u ON activities.username = u.username
+LEFT JOIN activity_tags at ON activities.activity_id = at.activity_id
+LEFT JOIN tags t ON at.tag_id = t.tag_id
+GROUP BY 
+  activities.activity_id,
+  activities.project_id,
+  activities.org_id,
+  activities.username,
+  activities.start_time,
+  activities.end_time,
+  activities.description,
+  u.time_zone,
+  o.time_zone
@@ -0,0 +1,80 @@
+-- Activities split into one row per day in the time zone of their user
+CREATE VIEW activities_by_day as
+SELECT
+  a.activity_id,
+  a.project_id,
+  a.org_id,
+  a.username,
+  GREATEST(a.start_local, d.day_start) AT TIME ZONE a.time_zone as start_time,
+  LEAST(a.end_local, d.day_start + interval '1 day') AT TIME ZONE a.time_zone as end_time,
+  EXTRACT(day from d.day_start) as day, 
+  EXTRACT(week from d.day_start) as week, 
+  EXTRACT(month from d.day_start) as month, 
+  EXTRACT(quarter from d.day_start) as quarter, 
+  EXTRACT(year from d.day_start) as year, 
+  FLOOR(EXTRACT(epoch from LEAST(a.end_local, d.day_start + interval '1 day') - GREATEST(a.start_local, d.day_start)) / 60) as duration_minutes_total
+FROM (
+  SELECT
+    activities.activity_id,
+    activities.project_id,
+    activities.org_id,
+    activities.username,
+    COALESCE(u.time_zone, o.time_zone, 'UTC') as time_zone,
+    activities.start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC') as start_local,
+    activities.end_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC') as end_local
+  FROM activities
+  JOIN organizations o ON activities.org_id = o.org_id
+  LEFT JOIN users u ON activities.username = u.username
+) a
+CROSS JOIN LATERAL generate_series(
+  date_trunc('day', a.start_local),
+  GREATEST(a.end_local - interval '1 microsecond', date_trunc('day', a.start_local)),
+  interval '1 day'
+) as d(day_start);
+
+-- Durations of activities spanning midnight include the whole days, the hours of an interval don't
+DROP VIEW IF EXISTS activities_agg;
+
+CREATE VIEW activities_agg as
+SELECT
+  activities.activity_id,
+  activities.project_id,
+  activities.org_id,
+  activities.username,
+  activities.start_time,
+  activities.end_time,
+  activities.description,
+  EXTRACT(day from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as day, 
+  EXTRACT(week from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as week, 
+  EXTRACT(month from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as month, 
+  EXTRACT(quarter from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as quarter, 
+  EXTRACT(year from start_time AT TIME ZONE COALESCE(u.time_zone, o.time_zone, 'UTC')) as year, 
+  MOD(FLOOR(EXTRACT(epoch from end_time - start_time) / 60), 60) as duration_minutes, 
+  FLOOR(EXTRACT(epoch from end_time - start_time) / 3600) as duration_hours,
+  FLOOR(EXTRACT(epoch from end_time - start_time) / 60) as duration_minutes_total,
+  -- Tag information as JSON array
+  COALESCE(
+    JSON_AGG(
+      JSON_BUILD_OBJECT(
+        'name', t.name,
+        'color', t.color
+      ) ORDER BY t.name
+    ) FILTER (WHERE t.tag_id IS NOT NULL),
+    '[]'::json
+  ) as tags_info
+FROM 
+  activities
+JOIN organizations o ON activities.org_id = o.org_id
+LEFT JOIN users u ON activities.username = u.username
+LEFT JOIN activity_tags at ON activities.activity_id = at.activity_id
+LEFT JOIN tags t ON at.tag_id = t.tag_id
+GROUP BY 
+  activities.activity_id,
+  activities.project_id,
+  activities.org_id,
+  activities.username,
+  activities.start_time,
+  activities.end_time,
+  activities.description,
+  u.time_zone,
+  o.time_zone;
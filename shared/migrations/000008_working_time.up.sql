@@ -0,0 +1,20 @@
+-- Table working_time_targets
+CREATE TABLE working_time_targets (
+    org_id            uuid not null,
+    username          varchar(50) not null,
+    valid_from        date not null,
+    monday_minutes    integer not null default 0,
+    tuesday_minutes   integer not null default 0,
+    wednesday_minutes integer not null default 0,
+    thursday_minutes  integer not null default 0,
+    friday_minutes    integer not null default 0,
+    saturday_minutes  integer not null default 0,
+    sunday_minutes    integer not null default 0
+);
+
+ALTER TABLE working_time_targets
+ADD CONSTRAINT pk_working_time_targets PRIMARY KEY (org_id, username);
+
+ALTER TABLE working_time_targets
+ADD CONSTRAINT fk_working_time_targets_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
@@ -0,0 +1,44 @@
+-- Table holidays
+CREATE TABLE holidays (
+    holiday_id   uuid not null,
+    org_id       uuid not null,
+    day          date not null,
+    title        varchar(100) not null
+);
+
+ALTER TABLE holidays
+ADD CONSTRAINT pk_holidays PRIMARY KEY (holiday_id);
+
+ALTER TABLE holidays
+ADD CONSTRAINT fk_holidays_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE holidays
+ADD CONSTRAINT uk_holidays_org_day UNIQUE (org_id, day);
+
+-- Table absences
+CREATE TABLE absences (
+    absence_id   uuid not null,
+    org_id       uuid not null,
+    username     varchar(50) not null,
+    start_date   date not null,
+    end_date     date not null,
+    type         varchar(20) not null,
+    description  varchar(500)
+);
+
+ALTER TABLE absences
+ADD CONSTRAINT pk_absences PRIMARY KEY (absence_id);
+
+ALTER TABLE absences
+ADD CONSTRAINT fk_absences_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE absences
+ADD CONSTRAINT ck_absences_type CHECK (type IN ('vacation', 'sick', 'other'));
+
+ALTER TABLE absences
+ADD CONSTRAINT ck_absences_dates CHECK (start_date <= end_date);
+
+CREATE INDEX absences_idx_org_user_start
+ON absences (org_id, username, start_date);
@@ -0,0 +1,19 @@
+-- Table user_invitations
+CREATE TABLE user_invitations (
+    user_invitation_id  uuid not null,
+    org_id              uuid not null,
+    email               varchar(100) not null,
+    created_by          varchar(50) not null,
+    created_at          timestamptz not null DEFAULT CURRENT_TIMESTAMP,
+    expires_at          timestamptz not null
+);
+
+ALTER TABLE user_invitations
+ADD CONSTRAINT pk_user_invitations PRIMARY KEY (user_invitation_id);
+
+ALTER TABLE user_invitations
+ADD CONSTRAINT fk_user_invitations_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+CREATE INDEX user_invitations_idx_org_email
+ON user_invitations (org_id, email);
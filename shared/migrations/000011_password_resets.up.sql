@@ -0,0 +1,14 @@
+-- Table user_password_resets
+CREATE TABLE user_password_resets (
+    user_password_reset_id  uuid not null,
+    user_id                 uuid not null,
+    created_at              timestamptz not null DEFAULT CURRENT_TIMESTAMP,
+    expires_at              timestamptz not null
+);
+
+ALTER TABLE user_password_resets
+ADD CONSTRAINT pk_user_password_resets PRIMARY KEY (user_password_reset_id);
+
+ALTER TABLE user_password_resets
+ADD CONSTRAINT fk_user_password_resets_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
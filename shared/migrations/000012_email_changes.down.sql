@@ -0,0 +1,2 @@
+DELETE FROM user_confirmations WHERE email IS NOT NULL;
+ALTER TABLE user_confirmations DROP COLUMN IF EXISTS email;
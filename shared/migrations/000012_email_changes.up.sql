@@ -0,0 +1,2 @@
+-- User EMail Change, the new email of the user until it's confirmed
+ALTER TABLE user_confirmations ADD email VARCHAR(100);
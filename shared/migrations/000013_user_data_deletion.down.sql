@@ -0,0 +1,17 @@
+ALTER TABLE activity_tags DROP CONSTRAINT fk_activity_tags_orgs;
+ALTER TABLE activity_tags
+ADD CONSTRAINT fk_activity_tags_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id);
+
+ALTER TABLE activities DROP CONSTRAINT fk_activities_orgs;
+ALTER TABLE activities
+ADD CONSTRAINT fk_activities_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id);
+
+ALTER TABLE projects DROP CONSTRAINT fk_projects_orgs;
+ALTER TABLE projects
+ADD CONSTRAINT fk_projects_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id);
+
+ALTER TABLE organizations DROP CONSTRAINT IF EXISTS ck_organizations_user_deletion_policy;
+ALTER TABLE organizations DROP COLUMN IF EXISTS user_deletion_policy;
@@ -0,0 +1,21 @@
+-- User Deletion Policy, whether activities of deleted users are anonymized or deleted
+ALTER TABLE organizations ADD user_deletion_policy VARCHAR(20) NOT NULL DEFAULT 'anonymize';
+
+ALTER TABLE organizations
+ADD CONSTRAINT ck_organizations_user_deletion_policy CHECK (user_deletion_policy IN ('anonymize', 'delete'));
+
+-- Delete all data of an organization together with the organization
+ALTER TABLE projects DROP CONSTRAINT fk_projects_orgs;
+ALTER TABLE projects
+ADD CONSTRAINT fk_projects_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE activities DROP CONSTRAINT fk_activities_orgs;
+ALTER TABLE activities
+ADD CONSTRAINT fk_activities_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE activity_tags DROP CONSTRAINT fk_activity_tags_orgs;
+ALTER TABLE activity_tags
+ADD CONSTRAINT fk_activity_tags_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+UPDATE users
+SET enabled = 0
+WHERE EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = users.user_id AND m.org_id = users.org_id AND m.enabled = 0);
+
+DELETE FROM roles
+WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.user_id = roles.user_id AND u.org_id = roles.org_id);
+
+DROP TABLE IF EXISTS organization_members;
@@ -0,0 +1,32 @@
+-- Table organization_members
+CREATE TABLE organization_members (
+    user_id     uuid not null,
+    org_id      uuid not null,
+    enabled     INTEGER NOT NULL DEFAULT 1,
+    created_at  timestamptz not null DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE organization_members
+ADD CONSTRAINT pk_organization_members PRIMARY KEY (user_id, org_id);
+
+ALTER TABLE organization_members
+ADD CONSTRAINT fk_organization_members_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+
+ALTER TABLE organization_members
+ADD CONSTRAINT fk_organization_members_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+CREATE INDEX organization_members_idx_org_id
+ON organization_members (org_id);
+
+-- Every user is member of the organization of the user, users disabled by an admin become disabled members
+INSERT INTO organization_members (user_id, org_id, enabled)
+SELECT u.user_id, u.org_id,
+       CASE WHEN u.enabled = 0 AND NOT EXISTS (SELECT 1 FROM user_confirmations c WHERE c.user_id = u.user_id AND c.email IS NULL) THEN 0 ELSE 1 END
+FROM users u;
+
+-- Users are enabled once confirmed, members are enabled or disabled by the admins of each organization
+UPDATE users
+SET enabled = 1
+WHERE enabled = 0 AND NOT EXISTS (SELECT 1 FROM user_confirmations c WHERE c.user_id = users.user_id AND c.email IS NULL);
@@ -0,0 +1,20 @@
+-- Table organization_settings, settings of an organization that apply to all its members
+CREATE TABLE organization_settings (
+     org_id        uuid not null,
+     week_start    INTEGER NOT NULL DEFAULT 1,
+     working_days  VARCHAR(20) NOT NULL DEFAULT '1,2,3,4,5',
+     date_format   VARCHAR(20) NOT NULL DEFAULT '02.01.2006'
+);
+
+ALTER TABLE organization_settings
+    ADD CONSTRAINT pk_organization_settings PRIMARY KEY (org_id);
+
+ALTER TABLE organization_settings
+ADD CONSTRAINT fk_organization_settings_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE organization_settings
+ADD CONSTRAINT ck_organization_settings_week_start CHECK (week_start BETWEEN 0 AND 6);
+
+INSERT INTO organization_settings (org_id)
+SELECT org_id FROM organizations;
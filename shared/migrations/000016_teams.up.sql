@@ -0,0 +1,38 @@
+-- Table teams, groups of members below the organization with an optional team lead
+CREATE TABLE teams (
+     team_id       uuid not null,
+     org_id        uuid not null,
+     name          VARCHAR(100) NOT NULL,
+     lead_user_id  uuid
+);
+
+ALTER TABLE teams
+    ADD CONSTRAINT pk_teams PRIMARY KEY (team_id);
+
+ALTER TABLE teams
+ADD CONSTRAINT fk_teams_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE teams
+ADD CONSTRAINT fk_teams_lead_users
+FOREIGN KEY (lead_user_id) REFERENCES users (user_id) ON DELETE SET NULL;
+
+CREATE INDEX teams_idx_org_id
+ON teams (org_id);
+
+-- Table team_members
+CREATE TABLE team_members (
+     team_id  uuid not null,
+     user_id  uuid not null
+);
+
+ALTER TABLE team_members
+    ADD CONSTRAINT pk_team_members PRIMARY KEY (team_id, user_id);
+
+ALTER TABLE team_members
+ADD CONSTRAINT fk_team_members_teams
+FOREIGN KEY (team_id) REFERENCES teams (team_id) ON DELETE CASCADE;
+
+ALTER TABLE team_members
+ADD CONSTRAINT fk_team_members_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
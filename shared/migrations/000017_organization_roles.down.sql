@@ -0,0 +1,6 @@
+-- Members with the manager role or a custom role become users
+UPDATE roles
+SET role = 'ROLE_USER'
+WHERE role NOT IN ('ROLE_USER', 'ROLE_ADMIN');
+
+DROP TABLE IF EXISTS organization_roles;
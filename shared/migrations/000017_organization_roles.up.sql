@@ -0,0 +1,14 @@
+-- Table organization_roles, custom roles of an organization in addition to the built-in roles
+CREATE TABLE organization_roles (
+     org_id       uuid not null,
+     role         VARCHAR(50) NOT NULL,
+     title        VARCHAR(50) NOT NULL,
+     permissions  VARCHAR(500) NOT NULL DEFAULT ''
+);
+
+ALTER TABLE organization_roles
+    ADD CONSTRAINT pk_organization_roles PRIMARY KEY (org_id, role);
+
+ALTER TABLE organization_roles
+ADD CONSTRAINT fk_organization_roles_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
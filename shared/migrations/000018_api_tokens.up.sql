@@ -0,0 +1,28 @@
+-- Table api_tokens, personal access tokens of members for the REST API, only the hash of a token is stored
+CREATE TABLE api_tokens (
+     api_token_id  uuid not null,
+     org_id        uuid not null,
+     user_id       uuid not null,
+     name          VARCHAR(100) NOT NULL,
+     token_hash    VARCHAR(64) NOT NULL,
+     created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     expires_at    timestamptz,
+     last_used_at  timestamptz
+);
+
+ALTER TABLE api_tokens
+    ADD CONSTRAINT pk_api_tokens PRIMARY KEY (api_token_id);
+
+ALTER TABLE api_tokens
+ADD CONSTRAINT fk_api_tokens_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+ALTER TABLE api_tokens
+ADD CONSTRAINT fk_api_tokens_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX api_tokens_idx_token_hash
+ON api_tokens (token_hash);
+
+CREATE INDEX api_tokens_idx_org_user
+ON api_tokens (org_id, user_id);
@@ -0,0 +1,4 @@
+-- Scoped tokens would gain full access without their scopes
+DELETE FROM api_tokens WHERE scopes <> '';
+
+ALTER TABLE api_tokens DROP COLUMN scopes;
@@ -0,0 +1,2 @@
+-- Scopes restrict api tokens, tokens without scopes have the full access of their owner
+ALTER TABLE api_tokens ADD scopes VARCHAR(200) NOT NULL DEFAULT '';
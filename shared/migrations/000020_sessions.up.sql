@@ -0,0 +1,33 @@
+-- Table sessions, sign ins of users kept alive by rotating refresh tokens, only the hashes of the tokens are stored
+CREATE TABLE sessions (
+     session_id                   uuid not null,
+     user_id                      uuid not null,
+     org_id                       uuid not null,
+     refresh_token_hash           VARCHAR(64) NOT NULL,
+     previous_refresh_token_hash  VARCHAR(64),
+     user_agent                   VARCHAR(200) NOT NULL DEFAULT '',
+     created_at                   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     refreshed_at                 timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     expires_at                   timestamptz NOT NULL,
+     revoked_at                   timestamptz
+);
+
+ALTER TABLE sessions
+    ADD CONSTRAINT pk_sessions PRIMARY KEY (session_id);
+
+ALTER TABLE sessions
+ADD CONSTRAINT fk_sessions_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+
+ALTER TABLE sessions
+ADD CONSTRAINT fk_sessions_orgs
+FOREIGN KEY (org_id) REFERENCES organizations (org_id) ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX sessions_idx_refresh_token_hash
+ON sessions (refresh_token_hash);
+
+CREATE INDEX sessions_idx_previous_refresh_token_hash
+ON sessions (previous_refresh_token_hash);
+
+CREATE INDEX sessions_idx_user_id
+ON sessions (user_id);
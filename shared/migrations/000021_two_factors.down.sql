@@ -0,0 +1,3 @@
+ALTER TABLE organization_settings DROP COLUMN IF EXISTS admin_two_factor_required;
+
+DROP TABLE IF EXISTS two_factors;
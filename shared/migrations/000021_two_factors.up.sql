@@ -0,0 +1,18 @@
+-- Table two_factors, the second factor of a user with time based one time passwords and hashed recovery codes
+CREATE TABLE two_factors (
+     user_id         uuid not null,
+     secret          VARCHAR(64) NOT NULL,
+     recovery_codes  VARCHAR(1000) NOT NULL DEFAULT '',
+     last_used_step  BIGINT NOT NULL DEFAULT 0,
+     created_at      timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     confirmed_at    timestamptz
+);
+
+ALTER TABLE two_factors
+    ADD CONSTRAINT pk_two_factors PRIMARY KEY (user_id);
+
+ALTER TABLE two_factors
+ADD CONSTRAINT fk_two_factors_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+
+ALTER TABLE organization_settings ADD admin_two_factor_required BOOLEAN NOT NULL DEFAULT FALSE;
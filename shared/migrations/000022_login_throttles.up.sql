@@ -0,0 +1,12 @@
+-- Table login_throttles, recent failed sign ins of accounts and from ip addresses to delay brute force attacks
+CREATE TABLE login_throttles (
+     throttle_key     VARCHAR(300) NOT NULL,
+     failures         INTEGER NOT NULL DEFAULT 0,
+     last_failure_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+     locked_until     timestamptz
+);
+
+ALTER TABLE login_throttles
+    ADD CONSTRAINT pk_login_throttles PRIMARY KEY (throttle_key);
+
+CREATE INDEX idx_login_throttles_last_failure_at ON login_throttles (last_failure_at);
@@ -0,0 +1,14 @@
+-- Table user_login_links, links sent by email to sign in once without a password
+CREATE TABLE user_login_links (
+    user_login_link_id  uuid not null,
+    user_id             uuid not null,
+    created_at          timestamptz not null DEFAULT CURRENT_TIMESTAMP,
+    expires_at          timestamptz not null
+);
+
+ALTER TABLE user_login_links
+ADD CONSTRAINT pk_user_login_links PRIMARY KEY (user_login_link_id);
+
+ALTER TABLE user_login_links
+ADD CONSTRAINT fk_user_login_links_users
+FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
@@ -0,0 +1,538 @@
+// Package qr encodes short texts like otpauth URIs as QR codes in byte mode
+// with error correction level M, which is sufficient for up to 213 bytes.
+package qr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTooLong is returned for contents that exceed the capacity of the supported versions
+var ErrTooLong = errors.New("content too long for qr code")
+
+// blockStructure describes the error correction blocks of a version at error correction level M
+type blockStructure struct {
+	ecCodewordsPerBlock int
+	group1Blocks        int
+	group1DataCodewords int
+	group2Blocks        int
+	group2DataCodewords int
+}
+
+func (b blockStructure) dataCodewords() int {
+	return b.group1Blocks*b.group1DataCodewords + b.group2Blocks*b.group2DataCodewords
+}
+
+// blockStructures of versions 1 to 10 at error correction level M
+var blockStructures = []blockStructure{
+	{10, 1, 16, 0, 0},
+	{16, 1, 28, 0, 0},
+	{26, 1, 44, 0, 0},
+	{18, 2, 32, 0, 0},
+	{24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0},
+	{18, 4, 31, 0, 0},
+	{22, 2, 38, 2, 39},
+	{22, 3, 36, 2, 37},
+	{26, 4, 43, 1, 44},
+}
+
+// alignmentPatternPositions of versions 1 to 10
+var alignmentPatternPositions = [][]int{
+	{},
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// Code is a QR code, modules are addressed by row and column
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// IsDark checks if the module at row and column is dark
+func (c *Code) IsDark(row, col int) bool {
+	return c.modules[row][col]
+}
+
+// Encode encodes the content in the smallest version that fits
+func Encode(content string) (*Code, error) {
+	data := []byte(content)
+	for i, blocks := range blockStructures {
+		version := i + 1
+		if len(data) <= (blocks.dataCodewords()*8-4-charCountBits(version))/8 {
+			return encode(data, version, -1), nil
+		}
+	}
+
+	return nil, ErrTooLong
+}
+
+// SVG renders the QR code as SVG image with a quiet zone of 4 modules
+func (c *Code) SVG() string {
+	size := c.Size + 8
+
+	var path strings.Builder
+	for row := 0; row < c.Size; row++ {
+		for col := 0; col < c.Size; col++ {
+			if c.modules[row][col] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", col+4, row+4)
+			}
+		}
+	}
+
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		size, size, path.String(),
+	)
+}
+
+// encode builds the QR code of the version, a negative mask selects the mask with the lowest penalty
+func encode(data []byte, version, mask int) *Code {
+	codewords := interleave(dataCodewords(data, version), version)
+
+	size := 17 + 4*version
+	c := &builder{
+		size:       size,
+		modules:    newGrid(size),
+		isFunction: newGrid(size),
+	}
+	c.drawFunctionPatterns(version)
+	c.drawCodewords(codewords)
+
+	if mask < 0 {
+		minPenalty := 0
+		for m := 0; m < 8; m++ {
+			c.applyMask(m)
+			c.drawFormatBits(m)
+			penalty := c.penalty()
+			if mask < 0 || penalty < minPenalty {
+				mask = m
+				minPenalty = penalty
+			}
+			c.applyMask(m)
+		}
+	}
+
+	c.applyMask(mask)
+	c.drawFormatBits(mask)
+
+	return &Code{Size: size, modules: c.modules}
+}
+
+func charCountBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// dataCodewords encodes the data in byte mode and pads it to the capacity of the version
+func dataCodewords(data []byte, version int) []byte {
+	capacity := blockStructures[version-1].dataCodewords() * 8
+
+	bits := &bitBuffer{}
+	bits.append(0b0100, 4)
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	bits.append(0, min(4, capacity-bits.len()))
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	return bits.bytes()
+}
+
+// interleave splits the data codewords into blocks, adds the error correction
+// codewords of each block and interleaves the blocks
+func interleave(data []byte, version int) []byte {
+	blocks := blockStructures[version-1]
+
+	var dataBlocks, ecBlocks [][]byte
+	offset := 0
+	for i := 0; i < blocks.group1Blocks+blocks.group2Blocks; i++ {
+		length := blocks.group1DataCodewords
+		if i >= blocks.group1Blocks {
+			length = blocks.group2DataCodewords
+		}
+
+		block := data[offset : offset+length]
+		offset += length
+
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, reedSolomon(block, blocks.ecCodewordsPerBlock))
+	}
+
+	result := make([]byte, 0, len(data)+len(ecBlocks)*blocks.ecCodewordsPerBlock)
+	for i := 0; i < max(blocks.group1DataCodewords, blocks.group2DataCodewords); i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < blocks.ecCodewordsPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+
+	return result
+}
+
+type builder struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	return grid
+}
+
+func (c *builder) setFunction(row, col int, dark bool) {
+	c.modules[row][col] = dark
+	c.isFunction[row][col] = true
+}
+
+func (c *builder) drawFunctionPatterns(version int) {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(3, c.size-4)
+	c.drawFinderPattern(c.size-4, 3)
+
+	positions := alignmentPatternPositions[version-1]
+	last := len(positions) - 1
+	for i, row := range positions {
+		for j, col := range positions {
+			// skip the corners of the finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(row, col)
+		}
+	}
+
+	// reserve the format areas, the format bits are drawn after masking
+	c.drawFormatBits(0)
+	c.drawVersion(version)
+}
+
+// drawFinderPattern draws a finder pattern with its separator around the center
+func (c *builder) drawFinderPattern(centerRow, centerCol int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			row, col := centerRow+dy, centerCol+dx
+			if row < 0 || row >= c.size || col < 0 || col >= c.size {
+				continue
+			}
+
+			distance := max(abs(dx), abs(dy))
+			c.setFunction(row, col, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (c *builder) drawAlignmentPattern(centerRow, centerCol int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(centerRow+dy, centerCol+dx, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the error correction level M and the mask
+func (c *builder) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(i, 8, bit(bits, i))
+	}
+	c.setFunction(7, 8, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(8, 7, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(8, 14-i, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(8, c.size-1-i, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(c.size-15+i, 8, bit(bits, i))
+	}
+
+	// the dark module
+	c.setFunction(c.size-8, 8, true)
+}
+
+// formatBits are the error correction level M and the mask protected by a BCH code
+func formatBits(mask int) int {
+	data := mask // error correction level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawVersion draws both copies of the version information of versions 7 and above
+func (c *builder) drawVersion(version int) {
+	if version < 7 {
+		return
+	}
+
+	bits := versionBits(version)
+	for i := 0; i < 18; i++ {
+		a := c.size - 11 + i%3
+		b := i / 3
+		c.setFunction(b, a, bit(bits, i))
+		c.setFunction(a, b, bit(bits, i))
+	}
+}
+
+// versionBits are the version protected by a BCH code
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawCodewords places the codewords in the zigzag order from the bottom right corner
+func (c *builder) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		// skip the vertical timing pattern
+		if right == 6 {
+			right = 5
+		}
+
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			row := vert
+			if upward {
+				row = c.size - 1 - vert
+			}
+
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if c.isFunction[row][col] || i >= len(codewords)*8 {
+					continue
+				}
+
+				c.modules[row][col] = bit(int(codewords[i>>3]), 7-(i&7))
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by the mask, applying a mask twice undoes it
+func (c *builder) applyMask(mask int) {
+	for row := 0; row < c.size; row++ {
+		for col := 0; col < c.size; col++ {
+			if c.isFunction[row][col] {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (row+col)%2 == 0
+			case 1:
+				invert = row%2 == 0
+			case 2:
+				invert = col%3 == 0
+			case 3:
+				invert = (row+col)%3 == 0
+			case 4:
+				invert = (row/2+col/3)%2 == 0
+			case 5:
+				invert = row*col%2+row*col%3 == 0
+			case 6:
+				invert = (row*col%2+row*col%3)%2 == 0
+			case 7:
+				invert = ((row+col)%2+row*col%3)%2 == 0
+			}
+
+			if invert {
+				c.modules[row][col] = !c.modules[row][col]
+			}
+		}
+	}
+}
+
+// penalty rates how hard the code is to scan, following the rules of the QR code specification
+func (c *builder) penalty() int {
+	penalty := 0
+
+	// runs of five or more modules of the same color in rows and columns
+	for i := 0; i < c.size; i++ {
+		rowRun, colRun := 1, 1
+		for j := 1; j < c.size; j++ {
+			rowRun, penalty = run(c.modules[i][j] == c.modules[i][j-1], rowRun, penalty)
+			colRun, penalty = run(c.modules[j][i] == c.modules[j-1][i], colRun, penalty)
+		}
+		penalty += runPenalty(rowRun) + runPenalty(colRun)
+	}
+
+	// blocks of 2x2 modules of the same color
+	for row := 0; row < c.size-1; row++ {
+		for col := 0; col < c.size-1; col++ {
+			dark := c.modules[row][col]
+			if dark == c.modules[row][col+1] && dark == c.modules[row+1][col] && dark == c.modules[row+1][col+1] {
+				penalty += 3
+			}
+		}
+	}
+
+	// patterns similar to finder patterns in rows and columns
+	patterns := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for i := 0; i < c.size; i++ {
+		for j := 0; j+len(patterns[0]) <= c.size; j++ {
+			for _, pattern := range patterns {
+				rowMatch, colMatch := true, true
+				for k, dark := range pattern {
+					rowMatch = rowMatch && c.modules[i][j+k] == dark
+					colMatch = colMatch && c.modules[j+k][i] == dark
+				}
+				if rowMatch {
+					penalty += 40
+				}
+				if colMatch {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	// deviation of the share of dark modules from 50%
+	dark := 0
+	for row := 0; row < c.size; row++ {
+		for col := 0; col < c.size; col++ {
+			if c.modules[row][col] {
+				dark++
+			}
+		}
+	}
+	total := c.size * c.size
+	penalty += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+
+	return penalty
+}
+
+// run extends a run of modules of the same color or adds the penalty of the finished run
+func run(same bool, length, penalty int) (int, int) {
+	if same {
+		return length + 1, penalty
+	}
+	return 1, penalty + runPenalty(length)
+}
+
+func runPenalty(length int) int {
+	if length < 5 {
+		return 0
+	}
+	return 3 + length - 5
+}
+
+func bit(value, i int) bool {
+	return (value>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// reedSolomon computes the error correction codewords of the data over GF(256) with the polynomial 0x11D
+func reedSolomon(data []byte, degree int) []byte {
+	// generator polynomial (x - a^0)(x - a^1)...(x - a^(degree-1)), without the leading coefficient
+	generator := make([]byte, degree)
+	generator[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			generator[j] = gfMultiply(generator[j], root)
+			if j+1 < degree {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	remainder := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[degree-1] = 0
+		for i := range remainder {
+			remainder[i] ^= gfMultiply(generator[i], factor)
+		}
+	}
+
+	return remainder
+}
+
+// gfMultiply multiplies in GF(256) with the polynomial 0x11D
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.bits = append(b.bits, bit(value, i))
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+func (b *bitBuffer) bytes() []byte {
+	result := make([]byte, len(b.bits)/8)
+	for i, dark := range b.bits {
+		if dark {
+			result[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return result
+}
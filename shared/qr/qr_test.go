@@ -0,0 +1,82 @@
+package qr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestReedSolomon(t *testing.T) {
+	is := is.New(t)
+
+	// data codewords of HELLO WORLD in version 1 with error correction level M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+
+	ec := reedSolomon(data, 10)
+
+	is.Equal(ec, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23})
+}
+
+func TestFormatBits(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(formatBits(0), 0b101010000010010)
+	is.Equal(formatBits(1), 0b101000100100101)
+	is.Equal(formatBits(4), 0b100010111111001)
+	is.Equal(formatBits(7), 0b100101010100000)
+}
+
+func TestVersionBits(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(versionBits(7), 0x07C94)
+	is.Equal(versionBits(10), 0x0A4D3)
+}
+
+func TestEncode(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("smallest version", func(t *testing.T) {
+		code, err := Encode("hello")
+		is.NoErr(err)
+		is.Equal(code.Size, 21)
+
+		// finder pattern in the top left corner
+		is.True(code.IsDark(0, 0))
+		is.True(code.IsDark(6, 6))
+		is.True(!code.IsDark(1, 1))
+		is.True(!code.IsDark(7, 7))
+	})
+
+	t.Run("otpauth uri", func(t *testing.T) {
+		code, err := Encode("otpauth://totp/Baralga:admin%40baralga.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=Baralga")
+		is.NoErr(err)
+		is.Equal(code.Size, 41)
+	})
+
+	t.Run("largest version", func(t *testing.T) {
+		code, err := Encode(strings.Repeat("x", 213))
+		is.NoErr(err)
+		is.Equal(code.Size, 57)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		_, err := Encode(strings.Repeat("x", 214))
+		is.True(errors.Is(err, ErrTooLong))
+	})
+}
+
+func TestSVG(t *testing.T) {
+	is := is.New(t)
+
+	code, err := Encode("hello")
+	is.NoErr(err)
+
+	svg := code.SVG()
+
+	is.True(strings.HasPrefix(svg, "<svg"))
+	is.True(strings.Contains(svg, `viewBox="0 0 29 29"`))
+	is.True(strings.Contains(svg, "M4,4h1v1h-1z"))
+}
@@ -2,6 +2,8 @@ package shared
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -18,6 +20,10 @@ type Principal struct {
 	Username       string
 	OrganizationID uuid.UUID
 	Roles          []string
+	Permissions    []string  // permissions of custom roles, built-in roles grant their permissions without being listed
+	Scopes         []string  // scopes of the api token the principal signed in with, no scopes restrict nothing
+	SessionID      uuid.UUID // session the principal signed in with, uuid.Nil for api tokens
+	TimeZone       string
 }
 
 // MustPrincipalFromContext reads the current principal from the context or panics if not present
@@ -30,6 +36,12 @@ func MustPrincipalFromContext(ctx context.Context) *Principal {
 	return principal
 }
 
+// PrincipalFromContext reads the current principal from the context if present
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKeyPrincipal).(*Principal)
+	return principal, ok
+}
+
 // ToContextWithPrincipal creates a new context with the principal as value
 func ToContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
 	return context.WithValue(ctx, contextKeyPrincipal, principal)
@@ -44,6 +56,167 @@ func (p *Principal) HasRole(role string) bool {
 	return false
 }
 
+// HasPermission checks if the principal is granted the permission by one of its roles
+func (p *Principal) HasPermission(permission string) bool {
+	if slices.Contains(p.Permissions, permission) {
+		return true
+	}
+
+	for _, role := range p.Roles {
+		if slices.Contains(RolePermissions[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissions members of an organization are granted by their roles
+const (
+	// PermissionActivitiesReadAll reads the activities and reports of all members
+	PermissionActivitiesReadAll = "activities:read:all"
+	// PermissionActivitiesWriteAll changes and deletes the activities of all members
+	PermissionActivitiesWriteAll = "activities:write:all"
+	// PermissionReportsReadTeam reads the activities and reports of the teams the member leads
+	PermissionReportsReadTeam = "reports:read:team"
+	// PermissionProjectsWrite creates, changes and deletes projects
+	PermissionProjectsWrite = "projects:write"
+	// PermissionHolidaysWrite imports, generates and deletes holidays
+	PermissionHolidaysWrite = "holidays:write"
+	// PermissionUsersWrite invites members and changes their roles
+	PermissionUsersWrite = "users:write"
+	// PermissionTeamsWrite creates, changes and deletes teams
+	PermissionTeamsWrite = "teams:write"
+	// PermissionOrganizationWrite changes the settings and the custom roles of the organization
+	PermissionOrganizationWrite = "organization:write"
+)
+
+// Permissions are all permissions that can be granted to a role
+var Permissions = []string{
+	PermissionActivitiesReadAll,
+	PermissionActivitiesWriteAll,
+	PermissionReportsReadTeam,
+	PermissionProjectsWrite,
+	PermissionHolidaysWrite,
+	PermissionUsersWrite,
+	PermissionTeamsWrite,
+	PermissionOrganizationWrite,
+}
+
+// RolePermissions are the permissions of the built-in roles
+var RolePermissions = map[string][]string{
+	"ROLE_USER": {
+		PermissionReportsReadTeam,
+	},
+	"ROLE_MANAGER": {
+		PermissionActivitiesReadAll,
+		PermissionReportsReadTeam,
+		PermissionProjectsWrite,
+		PermissionTeamsWrite,
+	},
+	"ROLE_ADMIN": Permissions,
+}
+
+// IsValidPermission checks if the permission can be granted to a role
+func IsValidPermission(permission string) bool {
+	return slices.Contains(Permissions, permission)
+}
+
+// scopes restrict what an api token can do on top of the permissions of its owner
+const (
+	// ScopeActivitiesRead reads activities
+	ScopeActivitiesRead = "activities:read"
+	// ScopeActivitiesWrite creates, changes and deletes activities
+	ScopeActivitiesWrite = "activities:write"
+	// ScopeProjectsRead reads projects
+	ScopeProjectsRead = "projects:read"
+	// ScopeProjectsWrite creates, changes and deletes projects
+	ScopeProjectsWrite = "projects:write"
+	// ScopeReportsRead reads reports like compliance violations
+	ScopeReportsRead = "reports:read"
+	// ScopeSCIM provisions members and groups of the organization from an identity provider with SCIM
+	ScopeSCIM = "scim"
+)
+
+// Scopes are all scopes an api token can be restricted to
+var Scopes = []string{
+	ScopeActivitiesRead,
+	ScopeActivitiesWrite,
+	ScopeProjectsRead,
+	ScopeProjectsWrite,
+	ScopeReportsRead,
+	ScopeSCIM,
+}
+
+// IsValidScope checks if an api token can be restricted to the scope
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// IsScoped checks if the principal is restricted to the scopes of an api token
+func (p *Principal) IsScoped() bool {
+	return len(p.Scopes) > 0
+}
+
+// HasScope checks if the principal may act within the scope, principals without scopes are not restricted
+func (p *Principal) HasScope(scope string) bool {
+	return !p.IsScoped() || slices.Contains(p.Scopes, scope)
+}
+
+// Location returns the time zone of the principal or UTC if not set or invalid
+func (p *Principal) Location() *time.Location {
+	if p.TimeZone == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// date formats of organizations as layouts of package time
+const (
+	DateFormatGerman = "02.01.2006"
+	DateFormatISO    = "2006-01-02"
+	DateFormatUS     = "01/02/2006"
+)
+
+// DateFormats are the date formats an organization can choose from
+var DateFormats = []string{DateFormatGerman, DateFormatISO, DateFormatUS}
+
+// OrganizationSettings are the settings of an organization that apply to all its members
+type OrganizationSettings struct {
+	WeekStart   time.Weekday
+	WorkingDays []time.Weekday
+	TimeZone    string // default time zone of members without an own time zone
+	DateFormat  string
+}
+
+// IsValidDateFormat checks if the date format is one of the date formats of organizations
+func IsValidDateFormat(dateFormat string) bool {
+	return slices.Contains(DateFormats, dateFormat)
+}
+
+// IsWorkingDay checks if the weekday is a working day of the organization
+func (s *OrganizationSettings) IsWorkingDay(weekday time.Weekday) bool {
+	return slices.Contains(s.WorkingDays, weekday)
+}
+
+// StartOfWeek returns the first day of the week of the given time
+func (s *OrganizationSettings) StartOfWeek(t time.Time) time.Time {
+	daysSinceWeekStart := (int(t.Weekday()) - int(s.WeekStart) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceWeekStart, 0, 0, 0, 0, t.Location())
+}
+
+// TeamMembers are the members of a team whose activities are visible to the admins and the team lead
+type TeamMembers struct {
+	TeamID    uuid.UUID
+	Name      string
+	Usernames []string
+}
+
 type RepositoryTxer interface {
 	InTx(ctx context.Context, txFuncs ...func(ctxWithTx context.Context) error) error
 }
@@ -3,6 +3,7 @@ package shared
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
 )
@@ -59,3 +60,101 @@ func TestHasRole(t *testing.T) {
 		is.True(!hasClaim)
 	})
 }
+
+func TestHasPermission(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("permission of built-in role", func(t *testing.T) {
+		p := &Principal{Roles: []string{"ROLE_MANAGER"}}
+
+		is.True(p.HasPermission(PermissionProjectsWrite))
+		is.True(!p.HasPermission(PermissionUsersWrite))
+	})
+
+	t.Run("all permissions of admin", func(t *testing.T) {
+		p := &Principal{Roles: []string{"ROLE_ADMIN"}}
+
+		for _, permission := range Permissions {
+			is.True(p.HasPermission(permission))
+		}
+	})
+
+	t.Run("permission of custom role", func(t *testing.T) {
+		p := &Principal{
+			Roles:       []string{"ROLE_CONTROLLER"},
+			Permissions: []string{PermissionActivitiesReadAll},
+		}
+
+		is.True(p.HasPermission(PermissionActivitiesReadAll))
+		is.True(!p.HasPermission(PermissionActivitiesWriteAll))
+	})
+}
+
+func TestPrincipalLocation(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("principal with time zone", func(t *testing.T) {
+		p := &Principal{TimeZone: "Europe/Berlin"}
+
+		is.Equal(p.Location().String(), "Europe/Berlin")
+	})
+
+	t.Run("principal without time zone", func(t *testing.T) {
+		p := &Principal{}
+
+		is.Equal(p.Location(), time.UTC)
+	})
+
+	t.Run("principal with invalid time zone", func(t *testing.T) {
+		p := &Principal{TimeZone: "Mars/Olympus_Mons"}
+
+		is.Equal(p.Location(), time.UTC)
+	})
+}
+
+func TestOrganizationSettings(t *testing.T) {
+	is := is.New(t)
+
+	settings := &OrganizationSettings{
+		WeekStart:   time.Monday,
+		WorkingDays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+
+	t.Run("working day", func(t *testing.T) {
+		is.True(settings.IsWorkingDay(time.Friday))
+		is.True(!settings.IsWorkingDay(time.Sunday))
+	})
+
+	t.Run("start of week", func(t *testing.T) {
+		sunday := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+
+		is.Equal(settings.StartOfWeek(sunday), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC))
+
+		sundaySettings := &OrganizationSettings{WeekStart: time.Sunday}
+		is.Equal(sundaySettings.StartOfWeek(sunday), time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("date format", func(t *testing.T) {
+		is.True(IsValidDateFormat(DateFormatISO))
+		is.True(!IsValidDateFormat("2006"))
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("principal without scopes", func(t *testing.T) {
+		p := &Principal{}
+
+		is.True(!p.IsScoped())
+		is.True(p.HasScope(ScopeActivitiesWrite))
+	})
+
+	t.Run("principal with scopes", func(t *testing.T) {
+		p := &Principal{Scopes: []string{ScopeActivitiesRead}}
+
+		is.True(p.IsScoped())
+		is.True(p.HasScope(ScopeActivitiesRead))
+		is.True(!p.HasScope(ScopeActivitiesWrite))
+	})
+}
@@ -27,6 +27,7 @@ var (
 	UserIDAdminSample    uuid.UUID
 	ConfirmationIDError  uuid.UUID
 	ConfirmationIdSample uuid.UUID
+	InvitationIDSample   uuid.UUID
 )
 
 func init() {
@@ -34,6 +35,7 @@ func init() {
 	ProjectIDSample = uuid.MustParse("f4b1087c-8fbb-4c8d-bbb7-ab4d46da16ea")
 	UserIDAdminSample = uuid.MustParse("eeeeeb80-33f3-4d3f-befe-58694d2ac841")
 	ConfirmationIDError = uuid.MustParse("4303e5b2-8124-4d9c-aea1-91aae2be562f")
+	InvitationIDSample = uuid.MustParse("b4a3f2d0-7d5e-4c1b-9e2a-6f0c8d1e3a57")
 }
 
 // DbUserRepository is a SQL database repository for users
@@ -2,8 +2,11 @@ package shared
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"schneider.vip/problem"
@@ -14,6 +17,30 @@ type DomainHandler interface {
 	RegisterOpen(router chi.Router)
 }
 
+// ScopedDomainHandler is a domain handler with routes api tokens restricted to scopes may use
+type ScopedDomainHandler interface {
+	// RegisterScoped registers the routes api tokens restricted to scopes may use, each route requires its scope with RequireScope
+	RegisterScoped(router chi.Router)
+}
+
+// RegisterProtectedRoutes registers the protected routes of the domain handlers. Api tokens restricted to scopes
+// are rejected on all routes except the scoped routes, which require their scope.
+func RegisterProtectedRoutes(r chi.Router, domainHandlers []DomainHandler) {
+	for _, domainHandler := range domainHandlers {
+		if scopedDomainHandler, ok := domainHandler.(ScopedDomainHandler); ok {
+			scopedDomainHandler.RegisterScoped(r)
+		}
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(RequireUnscoped())
+
+		for _, domainHandler := range domainHandlers {
+			domainHandler.RegisterProtected(r)
+		}
+	})
+}
+
 func RenderJSON(w http.ResponseWriter, jsonModel interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	err := json.NewEncoder(w).Encode(jsonModel)
@@ -32,3 +59,85 @@ func RenderProblemJSON(w http.ResponseWriter, isProduction bool, err error) {
 
 	http.Error(w, problem.New(problem.Title("internal server error")).JSONString(), http.StatusInternalServerError)
 }
+
+// RequireScope rejects requests of principals restricted to scopes without the scope
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := MustPrincipalFromContext(r.Context())
+			if !principal.HasScope(scope) {
+				http.Error(w, problem.New(
+					problem.Title("insufficient scope"),
+					problem.Detail(fmt.Sprintf("the api token lacks the scope %v", scope)),
+					problem.Status(http.StatusForbidden),
+				).JSONString(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUnscoped rejects requests of principals restricted to scopes, so that a
+// restricted api token can't be used to gain more access like a new unrestricted token
+func RequireUnscoped() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := MustPrincipalFromContext(r.Context())
+			if principal.IsScoped() {
+				http.Error(w, problem.New(
+					problem.Title("insufficient scope"),
+					problem.Detail("the api token is restricted to scopes"),
+					problem.Status(http.StatusForbidden),
+				).JSONString(), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP replaces the remote address of the request with the client ip address reported by the trusted
+// reverse proxies in the client ip header. X-Forwarded-For is a list every proxy appends the address it
+// received the request from to, so the client is the hop appended by the outermost of the trusted proxies
+// and hops in front of it are ignored as the client can send any. Other headers like X-Real-IP must be
+// set by the proxy. Requests without a valid address keep the remote address.
+func ClientIP(clientIPHeader string, proxyCount int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIPFromHeader(r, clientIPHeader, proxyCount)
+			if ip != "" {
+				r.RemoteAddr = ip
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIPFromHeader(r *http.Request, clientIPHeader string, proxyCount int) string {
+	values := r.Header.Values(clientIPHeader)
+	if len(values) == 0 {
+		return ""
+	}
+
+	ip := strings.TrimSpace(values[len(values)-1])
+	if http.CanonicalHeaderKey(clientIPHeader) == "X-Forwarded-For" {
+		var hops []string
+		for _, value := range values {
+			hops = append(hops, strings.Split(value, ",")...)
+		}
+
+		if proxyCount < 1 || len(hops) < proxyCount {
+			return ""
+		}
+		ip = strings.TrimSpace(hops[len(hops)-proxyCount])
+	}
+
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
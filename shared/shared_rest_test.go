@@ -2,10 +2,12 @@ package shared
 
 import (
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/matryer/is"
 )
 
@@ -50,3 +52,152 @@ func TestRenderProblemJSON(t *testing.T) {
 
 	is.True(strings.Contains(w.Body.String(), "my error"))
 }
+
+func TestRequireScope(t *testing.T) {
+	is := is.New(t)
+
+	handler := RequireScope(ScopeProjectsWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Run("principal with scope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/api/projects", nil)
+		r = r.WithContext(ToContextWithPrincipal(r.Context(), &Principal{Scopes: []string{ScopeProjectsWrite}}))
+
+		handler.ServeHTTP(w, r)
+
+		is.Equal(w.Code, http.StatusNoContent)
+	})
+
+	t.Run("principal without scope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/api/projects", nil)
+		r = r.WithContext(ToContextWithPrincipal(r.Context(), &Principal{Scopes: []string{ScopeProjectsRead}}))
+
+		handler.ServeHTTP(w, r)
+
+		is.Equal(w.Code, http.StatusForbidden)
+		is.True(strings.Contains(w.Body.String(), "insufficient scope"))
+	})
+}
+
+type scopedDomainHandlerSample struct{}
+
+func (a *scopedDomainHandlerSample) RegisterProtected(r chi.Router) {
+	r.Get("/protected", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+}
+
+func (a *scopedDomainHandlerSample) RegisterOpen(r chi.Router) {
+}
+
+func (a *scopedDomainHandlerSample) RegisterScoped(r chi.Router) {
+	r.With(RequireScope(ScopeProjectsRead)).Get("/scoped", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+}
+
+func TestRegisterProtectedRoutes(t *testing.T) {
+	is := is.New(t)
+
+	newRouter := func(scopes []string) *chi.Mux {
+		router := chi.NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(ToContextWithPrincipal(r.Context(), &Principal{Scopes: scopes})))
+			})
+		})
+		RegisterProtectedRoutes(router, []DomainHandler{&scopedDomainHandlerSample{}})
+		return router
+	}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		target string
+		status int
+	}{
+		{"scoped route with scope", []string{ScopeProjectsRead}, "/scoped", http.StatusNoContent},
+		{"scoped route without scope", []string{ScopeActivitiesRead}, "/scoped", http.StatusForbidden},
+		{"protected route with scope", []string{ScopeProjectsRead}, "/protected", http.StatusForbidden},
+		{"scoped route without restriction", nil, "/scoped", http.StatusNoContent},
+		{"protected route without restriction", nil, "/protected", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", tt.target, nil)
+
+			newRouter(tt.scopes).ServeHTTP(w, r)
+
+			is.Equal(w.Code, tt.status)
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	is := is.New(t)
+
+	clientIPOf := func(clientIPHeader string, proxyCount int, header http.Header) string {
+		var remoteAddr string
+		handler := ClientIP(clientIPHeader, proxyCount)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remoteAddr = r.RemoteAddr
+		}))
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:4711"
+		for name, values := range header {
+			for _, value := range values {
+				r.Header.Add(name, value)
+			}
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return remoteAddr
+	}
+
+	t.Run("client appended by the proxy", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 1, http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+		is.Equal(ip, "203.0.113.7")
+	})
+
+	t.Run("spoofed x-forwarded-for", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 1, http.Header{
+			"X-Forwarded-For": {"198.51.100.1, 203.0.113.7"},
+			"X-Real-Ip":       {"198.51.100.2"},
+			"True-Client-Ip":  {"198.51.100.3"},
+		})
+		is.Equal(ip, "203.0.113.7")
+	})
+
+	t.Run("spoofed x-forwarded-for in separate headers", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 1, http.Header{"X-Forwarded-For": {"198.51.100.1", "203.0.113.7"}})
+		is.Equal(ip, "203.0.113.7")
+	})
+
+	t.Run("client behind two proxies", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 2, http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7, 10.0.0.2"}})
+		is.Equal(ip, "203.0.113.7")
+	})
+
+	t.Run("fewer hops than proxies", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 2, http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+		is.Equal(ip, "10.0.0.1:4711")
+	})
+
+	t.Run("invalid ip address", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 1, http.Header{"X-Forwarded-For": {"203.0.113.7, unknown"}})
+		is.Equal(ip, "10.0.0.1:4711")
+	})
+
+	t.Run("header set by the proxy", func(t *testing.T) {
+		ip := clientIPOf("X-Real-IP", 1, http.Header{
+			"X-Forwarded-For": {"198.51.100.1"},
+			"X-Real-Ip":       {"203.0.113.7"},
+		})
+		is.Equal(ip, "203.0.113.7")
+	})
+
+	t.Run("without header", func(t *testing.T) {
+		ip := clientIPOf("X-Forwarded-For", 1, http.Header{})
+		is.Equal(ip, "10.0.0.1:4711")
+	})
+}
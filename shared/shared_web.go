@@ -188,6 +188,138 @@ func Navbar(pageContext *PageContext) g.Node {
 					),
 					Ul(
 						Class("dropdown-menu dropdown-menu-end"),
+						Li(
+							A(
+								Href("/profile"),
+								ghx.Get("/profile"),
+								ghx.Target("#baralga__main_content_modal_content"),
+								ghx.Swap("outerHTML"),
+								Class("dropdown-item"),
+								I(Class("bi-person me-2")),
+								g.Text("Profile"),
+							),
+						),
+						Li(
+							A(
+								Href("/tokens"),
+								ghx.Get("/tokens"),
+								ghx.Target("#baralga__main_content_modal_content"),
+								ghx.Swap("outerHTML"),
+								Class("dropdown-item"),
+								I(Class("bi-key me-2")),
+								g.Text("API Tokens"),
+							),
+						),
+						Li(
+							A(
+								Href("/organizations/switch"),
+								ghx.Get("/organizations/switch"),
+								ghx.Target("#baralga__main_content_modal_content"),
+								ghx.Swap("outerHTML"),
+								Class("dropdown-item"),
+								I(Class("bi-building me-2")),
+								g.Text("Organizations"),
+							),
+						),
+						Li(
+							A(
+								Href("/settings/time-zone"),
+								ghx.Get("/settings/time-zone"),
+								ghx.Target("#baralga__main_content_modal_content"),
+								ghx.Swap("outerHTML"),
+								Class("dropdown-item"),
+								I(Class("bi-globe me-2")),
+								TitleAttr(fmt.Sprintf("Time zone %v", pageContext.Principal.TimeZone)),
+								g.Text("Time Zone"),
+							),
+						),
+						Li(
+							A(
+								Href("/absences"),
+								ghx.Get("/absences"),
+								ghx.Target("#baralga__main_content_modal_content"),
+								ghx.Swap("outerHTML"),
+								Class("dropdown-item"),
+								I(Class("bi-calendar-x me-2")),
+								g.Text("Absences"),
+							),
+						),
+						Li(
+							A(
+								Href("/holidays"),
+								ghx.Get("/holidays"),
+								ghx.Target("#baralga__main_content_modal_content"),
+								ghx.Swap("outerHTML"),
+								Class("dropdown-item"),
+								I(Class("bi-calendar-event me-2")),
+								g.Text("Holidays"),
+							),
+						),
+						g.If(pageContext.Principal.HasPermission(PermissionOrganizationWrite),
+							Li(
+								A(
+									Href("/organization"),
+									ghx.Get("/organization"),
+									ghx.Target("#baralga__main_content_modal_content"),
+									ghx.Swap("outerHTML"),
+									Class("dropdown-item"),
+									I(Class("bi-gear me-2")),
+									g.Text("Organization"),
+								),
+							),
+						),
+						g.If(pageContext.Principal.HasPermission(PermissionOrganizationWrite),
+							Li(
+								A(
+									Href("/roles"),
+									ghx.Get("/roles"),
+									ghx.Target("#baralga__main_content_modal_content"),
+									ghx.Swap("outerHTML"),
+									Class("dropdown-item"),
+									I(Class("bi-shield-lock me-2")),
+									g.Text("Roles"),
+								),
+							),
+						),
+						g.If(pageContext.Principal.HasPermission(PermissionUsersWrite),
+							Li(
+								A(
+									Href("/users"),
+									ghx.Get("/users"),
+									ghx.Target("#baralga__main_content_modal_content"),
+									ghx.Swap("outerHTML"),
+									Class("dropdown-item"),
+									I(Class("bi-people me-2")),
+									g.Text("Users"),
+								),
+							),
+						),
+						g.If(pageContext.Principal.HasPermission(PermissionTeamsWrite),
+							Li(
+								A(
+									Href("/teams"),
+									ghx.Get("/teams"),
+									ghx.Target("#baralga__main_content_modal_content"),
+									ghx.Swap("outerHTML"),
+									Class("dropdown-item"),
+									I(Class("bi-diagram-3 me-2")),
+									g.Text("Teams"),
+								),
+							),
+						),
+						g.If(pageContext.Principal.HasPermission(PermissionUsersWrite),
+							Li(
+								A(
+									Href("/invitations"),
+									ghx.Get("/invitations"),
+									ghx.Target("#baralga__main_content_modal_content"),
+									ghx.Swap("outerHTML"),
+									Class("dropdown-item"),
+									I(Class("bi-envelope-plus me-2")),
+									g.Text("Invitations"),
+								),
+							),
+						),
 						Li(
 							A(
 								Href("/logout"),
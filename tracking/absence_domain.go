@@ -0,0 +1,70 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var ErrAbsenceNotFound = errors.New("absence not found")
+
+// Types of absences
+const (
+	AbsenceTypeVacation string = "vacation"
+	AbsenceTypeSick     string = "sick"
+	AbsenceTypeOther    string = "other"
+)
+
+// Absence represents a user being absent for whole days from start to end (inclusive)
+type Absence struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Username       string
+	Start          time.Time
+	End            time.Time
+	Type           string
+	Description    string
+}
+
+type AbsenceRepository interface {
+	FindAbsences(ctx context.Context, organizationID uuid.UUID, username string, start, end time.Time) ([]*Absence, error)
+	FindAbsenceByID(ctx context.Context, organizationID uuid.UUID, username string, absenceID uuid.UUID) (*Absence, error)
+	InsertAbsence(ctx context.Context, absence *Absence) (*Absence, error)
+	UpdateAbsence(ctx context.Context, absence *Absence) (*Absence, error)
+	DeleteAbsenceByID(ctx context.Context, organizationID uuid.UUID, username string, absenceID uuid.UUID) error
+	DeleteAbsencesByUsername(ctx context.Context, organizationID uuid.UUID, username string) error
+}
+
+func IsValidAbsenceType(t string) bool {
+	switch t {
+	case AbsenceTypeVacation, AbsenceTypeSick, AbsenceTypeOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// Covers returns true if the user is absent on the given day
+func (a *Absence) Covers(day time.Time) bool {
+	d := dateOf(day)
+	return !d.Before(dateOf(a.Start)) && !d.After(dateOf(a.End))
+}
+
+// Days returns the number of days of the absence
+func (a *Absence) Days() int {
+	return int(dateOf(a.End).Sub(dateOf(a.Start)).Hours()/24) + 1
+}
+
+// TypeFormatted is the type of the absence for display
+func (a *Absence) TypeFormatted() string {
+	switch a.Type {
+	case AbsenceTypeVacation:
+		return "Vacation"
+	case AbsenceTypeSick:
+		return "Sick"
+	default:
+		return "Other"
+	}
+}
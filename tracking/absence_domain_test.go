@@ -0,0 +1,44 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestAbsenceCovers(t *testing.T) {
+	is := is.New(t)
+
+	absence := &Absence{
+		Start: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC),
+	}
+
+	is.True(absence.Covers(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+	is.True(absence.Covers(time.Date(2024, 7, 5, 18, 0, 0, 0, time.UTC)))
+	is.True(!absence.Covers(time.Date(2024, 6, 30, 23, 0, 0, 0, time.UTC)))
+	is.True(!absence.Covers(time.Date(2024, 7, 6, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestAbsenceDays(t *testing.T) {
+	is := is.New(t)
+
+	absence := &Absence{
+		Start: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC),
+	}
+	is.Equal(absence.Days(), 5)
+
+	absence.End = absence.Start
+	is.Equal(absence.Days(), 1)
+}
+
+func TestIsValidAbsenceType(t *testing.T) {
+	is := is.New(t)
+
+	is.True(IsValidAbsenceType(AbsenceTypeVacation))
+	is.True(IsValidAbsenceType(AbsenceTypeSick))
+	is.True(IsValidAbsenceType(AbsenceTypeOther))
+	is.True(!IsValidAbsenceType("holiday"))
+}
@@ -0,0 +1,192 @@
+package tracking
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbAbsenceRepository is a SQL database repository for absences
+type DbAbsenceRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ AbsenceRepository = (*DbAbsenceRepository)(nil)
+
+// NewDbAbsenceRepository creates a new SQL database repository for absences
+func NewDbAbsenceRepository(connPool *pgxpool.Pool) *DbAbsenceRepository {
+	return &DbAbsenceRepository{
+		connPool: connPool,
+	}
+}
+
+// FindAbsences finds all absences of the user overlapping the days from start (inclusive) to end (exclusive)
+func (r *DbAbsenceRepository) FindAbsences(ctx context.Context, organizationID uuid.UUID, username string, start, end time.Time) ([]*Absence, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT absence_id as id, start_date, end_date, type, description 
+		 FROM absences 
+		 WHERE org_id = $1 AND username = $2 AND start_date < $4 AND $3 <= end_date
+		 ORDER BY start_date ASC`,
+		organizationID, username, dateOf(start), dateOf(end),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var absences []*Absence
+	for rows.Next() {
+		var (
+			id          string
+			startDate   time.Time
+			endDate     time.Time
+			absenceType string
+			description sql.NullString
+		)
+
+		err = rows.Scan(&id, &startDate, &endDate, &absenceType, &description)
+		if err != nil {
+			return nil, err
+		}
+
+		absence := &Absence{
+			ID:             uuid.MustParse(id),
+			OrganizationID: organizationID,
+			Username:       username,
+			Start:          startDate,
+			End:            endDate,
+			Type:           absenceType,
+			Description:    description.String,
+		}
+		absences = append(absences, absence)
+	}
+
+	return absences, nil
+}
+
+func (r *DbAbsenceRepository) FindAbsenceByID(ctx context.Context, organizationID uuid.UUID, username string, absenceID uuid.UUID) (*Absence, error) {
+	row := r.connPool.QueryRow(ctx,
+		`SELECT absence_id as id, start_date, end_date, type, description 
+         FROM absences 
+	     WHERE absence_id = $1 AND org_id = $2 AND username = $3`,
+		absenceID, organizationID, username)
+
+	var (
+		id          string
+		startDate   time.Time
+		endDate     time.Time
+		absenceType string
+		description sql.NullString
+	)
+
+	err := row.Scan(&id, &startDate, &endDate, &absenceType, &description)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAbsenceNotFound
+		}
+
+		return nil, err
+	}
+
+	absence := &Absence{
+		ID:             uuid.MustParse(id),
+		OrganizationID: organizationID,
+		Username:       username,
+		Start:          startDate,
+		End:            endDate,
+		Type:           absenceType,
+		Description:    description.String,
+	}
+
+	return absence, nil
+}
+
+func (r *DbAbsenceRepository) InsertAbsence(ctx context.Context, absence *Absence) (*Absence, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO absences 
+		   (absence_id, org_id, username, start_date, end_date, type, description) 
+		 VALUES 
+		   ($1, $2, $3, $4, $5, $6, $7)`,
+		absence.ID,
+		absence.OrganizationID,
+		absence.Username,
+		dateOf(absence.Start),
+		dateOf(absence.End),
+		absence.Type,
+		absence.Description,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return absence, nil
+}
+
+func (r *DbAbsenceRepository) UpdateAbsence(ctx context.Context, absence *Absence) (*Absence, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`UPDATE absences 
+		 SET start_date = $4, end_date = $5, type = $6, description = $7 
+		 WHERE absence_id = $1 AND org_id = $2 AND username = $3
+		 RETURNING absence_id`,
+		absence.ID, absence.OrganizationID, absence.Username,
+		dateOf(absence.Start), dateOf(absence.End), absence.Type, absence.Description,
+	)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAbsenceNotFound
+		}
+
+		return nil, err
+	}
+
+	return absence, nil
+}
+
+func (r *DbAbsenceRepository) DeleteAbsenceByID(ctx context.Context, organizationID uuid.UUID, username string, absenceID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`DELETE 
+         FROM absences 
+	     WHERE absence_id = $1 AND org_id = $2 AND username = $3
+		 RETURNING absence_id`,
+		absenceID, organizationID, username)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAbsenceNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *DbAbsenceRepository) DeleteAbsencesByUsername(ctx context.Context, organizationID uuid.UUID, username string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(ctx,
+		`DELETE 
+         FROM absences 
+	     WHERE org_id = $1 AND username = $2`,
+		organizationID, username)
+	return err
+}
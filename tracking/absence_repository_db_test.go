@@ -0,0 +1,112 @@
+package tracking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestAbsenceRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	absenceRepository := NewDbAbsenceRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	absence := &Absence{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		Start:          time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2021, 11, 19, 0, 0, 0, 0, time.UTC),
+		Type:           AbsenceTypeVacation,
+		Description:    "Autumn Vacation",
+	}
+
+	t.Run("InsertAndFindAbsence", func(t *testing.T) {
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := absenceRepository.InsertAbsence(ctx, absence)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		absenceRead, err := absenceRepository.FindAbsenceByID(context.Background(), shared.OrganizationIDSample, "user1", absence.ID)
+		is.NoErr(err)
+		is.Equal(absenceRead.Description, "Autumn Vacation")
+		is.Equal(absenceRead.Days(), 5)
+
+		_, err = absenceRepository.FindAbsenceByID(context.Background(), shared.OrganizationIDSample, "user2", absence.ID)
+		is.True(errors.Is(err, ErrAbsenceNotFound))
+	})
+
+	t.Run("FindOverlappingAbsences", func(t *testing.T) {
+		absences, err := absenceRepository.FindAbsences(
+			context.Background(),
+			shared.OrganizationIDSample,
+			"user1",
+			time.Date(2021, 11, 19, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC),
+		)
+		is.NoErr(err)
+		is.Equal(len(absences), 1)
+
+		absences, err = absenceRepository.FindAbsences(
+			context.Background(),
+			shared.OrganizationIDSample,
+			"user1",
+			time.Date(2021, 11, 20, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 11, 27, 0, 0, 0, 0, time.UTC),
+		)
+		is.NoErr(err)
+		is.Equal(len(absences), 0)
+	})
+
+	t.Run("UpdateAndDeleteAbsence", func(t *testing.T) {
+		absence.Type = AbsenceTypeSick
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := absenceRepository.UpdateAbsence(ctx, absence)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return absenceRepository.DeleteAbsenceByID(ctx, shared.OrganizationIDSample, "user1", absence.ID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = absenceRepository.FindAbsenceByID(context.Background(), shared.OrganizationIDSample, "user1", absence.ID)
+		is.True(errors.Is(err, ErrAbsenceNotFound))
+	})
+}
@@ -0,0 +1,76 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InMemAbsenceRepository is an in-memory repository for absences
+type InMemAbsenceRepository struct {
+	absences []*Absence
+}
+
+var _ AbsenceRepository = (*InMemAbsenceRepository)(nil)
+
+// NewInMemAbsenceRepository creates a new in-memory repository for absences
+func NewInMemAbsenceRepository() *InMemAbsenceRepository {
+	return &InMemAbsenceRepository{}
+}
+
+func (r *InMemAbsenceRepository) FindAbsences(ctx context.Context, organizationID uuid.UUID, username string, start, end time.Time) ([]*Absence, error) {
+	var absences []*Absence
+	for _, a := range r.absences {
+		if a.OrganizationID == organizationID && a.Username == username &&
+			dateOf(a.Start).Before(dateOf(end)) && !dateOf(a.End).Before(dateOf(start)) {
+			absences = append(absences, a)
+		}
+	}
+	return absences, nil
+}
+
+func (r *InMemAbsenceRepository) FindAbsenceByID(ctx context.Context, organizationID uuid.UUID, username string, absenceID uuid.UUID) (*Absence, error) {
+	for _, a := range r.absences {
+		if a.ID == absenceID && a.OrganizationID == organizationID && a.Username == username {
+			return a, nil
+		}
+	}
+	return nil, ErrAbsenceNotFound
+}
+
+func (r *InMemAbsenceRepository) InsertAbsence(ctx context.Context, absence *Absence) (*Absence, error) {
+	r.absences = append(r.absences, absence)
+	return absence, nil
+}
+
+func (r *InMemAbsenceRepository) UpdateAbsence(ctx context.Context, absence *Absence) (*Absence, error) {
+	for i, a := range r.absences {
+		if a.ID == absence.ID && a.OrganizationID == absence.OrganizationID && a.Username == absence.Username {
+			r.absences[i] = absence
+			return absence, nil
+		}
+	}
+	return nil, ErrAbsenceNotFound
+}
+
+func (r *InMemAbsenceRepository) DeleteAbsenceByID(ctx context.Context, organizationID uuid.UUID, username string, absenceID uuid.UUID) error {
+	for i, a := range r.absences {
+		if a.ID == absenceID && a.OrganizationID == organizationID && a.Username == username {
+			r.absences = append(r.absences[:i], r.absences[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAbsenceNotFound
+}
+
+func (r *InMemAbsenceRepository) DeleteAbsencesByUsername(ctx context.Context, organizationID uuid.UUID, username string) error {
+	var absences []*Absence
+	for _, a := range r.absences {
+		if a.OrganizationID != organizationID || a.Username != username {
+			absences = append(absences, a)
+		}
+	}
+	r.absences = absences
+	return nil
+}
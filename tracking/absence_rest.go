@@ -0,0 +1,285 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type absenceModel struct {
+	ID          string     `json:"id"`
+	Start       string     `json:"start" validate:"required"`
+	End         string     `json:"end" validate:"required"`
+	Type        string     `json:"type" validate:"required,oneof=vacation sick other"`
+	Description string     `json:"description" validate:"max=500"`
+	Days        int        `json:"days"`
+	Links       *hal.Links `json:"_links"`
+}
+
+type absencesModel struct {
+	*EmbeddedAbsences `json:"_embedded"`
+	Links             *hal.Links `json:"_links"`
+}
+
+// EmbeddedAbsences contains embedded absences
+type EmbeddedAbsences struct {
+	AbsenceModels []*absenceModel `json:"absences"`
+}
+
+type AbsenceRestHandlers struct {
+	config         *shared.Config
+	absenceService *AbsenceService
+}
+
+func NewAbsenceRestHandlers(config *shared.Config, absenceService *AbsenceService) *AbsenceRestHandlers {
+	return &AbsenceRestHandlers{
+		config:         config,
+		absenceService: absenceService,
+	}
+}
+
+func (a *AbsenceRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/absences", a.HandleGetAbsences())
+	r.Post("/absences", a.HandleCreateAbsence())
+	r.Get("/absences/{absence-id}", a.HandleGetAbsence())
+	r.Patch("/absences/{absence-id}", a.HandleUpdateAbsence())
+	r.Delete("/absences/{absence-id}", a.HandleDeleteAbsence())
+}
+
+func (a *AbsenceRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetAbsences reads the absences of the principal
+func (a *AbsenceRestHandlers) HandleGetAbsences() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		filter, err := filterFromQueryParams(r.URL.Query(), principal.Location())
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, errors.New("invalid query params"))
+			return
+		}
+
+		absences, err := absenceService.ReadAbsences(r.Context(), principal, filter)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		absenceModels := make([]*absenceModel, 0, len(absences))
+		for _, absence := range absences {
+			absenceModels = append(absenceModels, mapToAbsenceModel(absence))
+		}
+
+		absencesModel := &absencesModel{
+			EmbeddedAbsences: &EmbeddedAbsences{
+				AbsenceModels: absenceModels,
+			},
+			Links: hal.NewLinks(
+				hal.NewSelfLink(r.RequestURI),
+				hal.NewLink("create", "/api/absences"),
+			),
+		}
+
+		shared.RenderJSON(w, absencesModel)
+	}
+}
+
+// HandleCreateAbsence creates an absence
+func (a *AbsenceRestHandlers) HandleCreateAbsence() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		var absenceModel absenceModel
+		err := json.NewDecoder(r.Body).Decode(&absenceModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(absenceModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("absence not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		absenceToCreate, err := mapToAbsence(&absenceModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		absence, err := absenceService.CreateAbsence(r.Context(), principal, absenceToCreate)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		shared.RenderJSON(w, mapToAbsenceModel(absence))
+	}
+}
+
+// HandleGetAbsence reads an absence
+func (a *AbsenceRestHandlers) HandleGetAbsence() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		absenceIDParam := chi.URLParam(r, "absence-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		absenceID, err := uuid.Parse(absenceIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		absence, err := absenceService.ReadAbsence(r.Context(), principal, absenceID)
+		if errors.Is(err, ErrAbsenceNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToAbsenceModel(absence))
+	}
+}
+
+// HandleUpdateAbsence updates an absence
+func (a *AbsenceRestHandlers) HandleUpdateAbsence() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		absenceIDParam := chi.URLParam(r, "absence-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		absenceID, err := uuid.Parse(absenceIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		var absenceModel absenceModel
+		err = json.NewDecoder(r.Body).Decode(&absenceModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(absenceModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("absence not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		absence, err := mapToAbsence(&absenceModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		absence.ID = absenceID
+
+		absenceUpdated, err := absenceService.UpdateAbsence(r.Context(), principal, absence)
+		if errors.Is(err, ErrAbsenceNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToAbsenceModel(absenceUpdated))
+	}
+}
+
+// HandleDeleteAbsence deletes an absence
+func (a *AbsenceRestHandlers) HandleDeleteAbsence() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		absenceIDParam := chi.URLParam(r, "absence-id")
+		absenceID, err := uuid.Parse(absenceIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err = absenceService.DeleteAbsence(r.Context(), principal, absenceID)
+		if errors.Is(err, ErrAbsenceNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__activities-changed")
+	}
+}
+
+func mapToAbsence(absenceModel *absenceModel) (*Absence, error) {
+	start, err := time_utils.ParseDate(absenceModel.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := time_utils.ParseDate(absenceModel.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if end.Before(*start) {
+		return nil, errors.New("absence must end on or after its start")
+	}
+
+	if !IsValidAbsenceType(absenceModel.Type) {
+		return nil, fmt.Errorf("invalid absence type '%s'", absenceModel.Type)
+	}
+
+	return &Absence{
+		Start:       *start,
+		End:         *end,
+		Type:        absenceModel.Type,
+		Description: absenceModel.Description,
+	}, nil
+}
+
+func mapToAbsenceModel(absence *Absence) *absenceModel {
+	selfLink := hal.NewSelfLink(fmt.Sprintf("/api/absences/%s", absence.ID))
+	return &absenceModel{
+		ID:          absence.ID.String(),
+		Start:       time_utils.FormatDate(absence.Start),
+		End:         time_utils.FormatDate(absence.End),
+		Type:        absence.Type,
+		Description: absence.Description,
+		Days:        absence.Days(),
+		Links: hal.NewLinks(
+			selfLink,
+			hal.NewLink("edit", selfLink.Href()),
+			hal.NewLink("delete", selfLink.Href()),
+		),
+	}
+}
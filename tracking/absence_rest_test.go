@@ -0,0 +1,178 @@
+package tracking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetAbsences(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		Start:          time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2021, 11, 19, 0, 0, 0, 0, time.UTC),
+		Type:           AbsenceTypeVacation,
+	})
+
+	a := &AbsenceRestHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	r, _ := http.NewRequest("GET", "/api/absences?t=month&v=2021-11", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleGetAbsences()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	absencesModel := &absencesModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(absencesModel)
+	is.NoErr(err)
+	is.Equal(len(absencesModel.AbsenceModels), 1)
+	is.Equal(absencesModel.AbsenceModels[0].Start, "2021-11-15")
+	is.Equal(absencesModel.AbsenceModels[0].Days, 5)
+}
+
+func TestHandleCreateAbsence(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceRepository := NewInMemAbsenceRepository()
+	a := &AbsenceRestHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	body := `{
+		"start": "2021-11-15",
+		"end": "2021-11-16",
+		"type": "sick"
+	}`
+
+	r, _ := http.NewRequest("POST", "/api/absences", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleCreateAbsence()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+	is.Equal(len(absenceRepository.absences), 1)
+	is.Equal(absenceRepository.absences[0].Username, "user1")
+	is.Equal(absenceRepository.absences[0].Type, AbsenceTypeSick)
+}
+
+func TestHandleCreateInvalidAbsence(t *testing.T) {
+	is := is.New(t)
+
+	a := &AbsenceRestHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), NewInMemAbsenceRepository()),
+	}
+
+	bodies := []string{
+		`{"start": "2021-11-16", "end": "2021-11-15", "type": "sick"}`,
+		`{"start": "2021-11-15", "end": "2021-11-15", "type": "holiday"}`,
+		`{"start": "15.11.2021", "end": "2021-11-15", "type": "sick"}`,
+	}
+
+	for _, body := range bodies {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/absences", strings.NewReader(body))
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+		a.HandleCreateAbsence()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestHandleUpdateAbsence(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceID := uuid.New()
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:             absenceID,
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		Start:          time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		Type:           AbsenceTypeSick,
+	})
+
+	a := &AbsenceRestHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	body := `{"start": "2021-11-15", "end": "2021-11-17", "type": "sick", "description": "Flu"}`
+
+	r, _ := http.NewRequest("PATCH", "/api/absences/"+absenceID.String(), strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("absence-id", absenceID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUpdateAbsence()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(absenceRepository.absences[0].Days(), 3)
+	is.Equal(absenceRepository.absences[0].Description, "Flu")
+}
+
+func TestHandleDeleteAbsenceOfOtherUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceID := uuid.New()
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:             absenceID,
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		Start:          time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		Type:           AbsenceTypeSick,
+	})
+
+	a := &AbsenceRestHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	r, _ := http.NewRequest("DELETE", "/api/absences/"+absenceID.String(), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user2",
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("absence-id", absenceID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleDeleteAbsence()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+	is.Equal(len(absenceRepository.absences), 1)
+}
@@ -0,0 +1,87 @@
+package tracking
+
+import (
+	"context"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+)
+
+type AbsenceService struct {
+	repositoryTxer    shared.RepositoryTxer
+	absenceRepository AbsenceRepository
+}
+
+func NewAbsenceService(repositoryTxer shared.RepositoryTxer, absenceRepository AbsenceRepository) *AbsenceService {
+	return &AbsenceService{
+		repositoryTxer:    repositoryTxer,
+		absenceRepository: absenceRepository,
+	}
+}
+
+// ReadAbsences reads the absences of the principal overlapping the filter's timespan
+func (a *AbsenceService) ReadAbsences(ctx context.Context, principal *shared.Principal, filter *ActivityFilter) ([]*Absence, error) {
+	return a.absenceRepository.FindAbsences(ctx, principal.OrganizationID, principal.Username, filter.Start(), filter.End())
+}
+
+// ReadAbsence reads an absence of the principal
+func (a *AbsenceService) ReadAbsence(ctx context.Context, principal *shared.Principal, absenceID uuid.UUID) (*Absence, error) {
+	return a.absenceRepository.FindAbsenceByID(ctx, principal.OrganizationID, principal.Username, absenceID)
+}
+
+// CreateAbsence creates a new absence of the principal
+func (a *AbsenceService) CreateAbsence(ctx context.Context, principal *shared.Principal, absence *Absence) (*Absence, error) {
+	absence.ID = uuid.New()
+	absence.OrganizationID = principal.OrganizationID
+	absence.Username = principal.Username
+
+	var absenceCreated *Absence
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			ab, err := a.absenceRepository.InsertAbsence(ctx, absence)
+			if err != nil {
+				return err
+			}
+			absenceCreated = ab
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return absenceCreated, nil
+}
+
+// UpdateAbsence updates an absence of the principal
+func (a *AbsenceService) UpdateAbsence(ctx context.Context, principal *shared.Principal, absence *Absence) (*Absence, error) {
+	absence.OrganizationID = principal.OrganizationID
+	absence.Username = principal.Username
+
+	var absenceUpdated *Absence
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			ab, err := a.absenceRepository.UpdateAbsence(ctx, absence)
+			if err != nil {
+				return err
+			}
+			absenceUpdated = ab
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return absenceUpdated, nil
+}
+
+// DeleteAbsence deletes an absence of the principal
+func (a *AbsenceService) DeleteAbsence(ctx context.Context, principal *shared.Principal, absenceID uuid.UUID) error {
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.absenceRepository.DeleteAbsenceByID(ctx, principal.OrganizationID, principal.Username, absenceID)
+		},
+	)
+}
@@ -0,0 +1,114 @@
+package tracking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestCreateAndReadAbsences(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &AbsenceService{
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		absenceRepository: NewInMemAbsenceRepository(),
+	}
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+
+	// Act
+	absence, err := a.CreateAbsence(context.Background(), principal, &Absence{
+		Start: time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2021, 11, 19, 0, 0, 0, 0, time.UTC),
+		Type:  AbsenceTypeVacation,
+	})
+	is.NoErr(err)
+
+	// Assert
+	is.True(absence.ID != uuid.Nil)
+	is.Equal(absence.Username, "user1")
+
+	filter := &ActivityFilter{
+		Timespan: TimespanWeek,
+		start:    time.Date(2021, 11, 8, 0, 0, 0, 0, time.UTC),
+		end:      time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+	}
+	absences, err := a.ReadAbsences(context.Background(), principal, filter)
+	is.NoErr(err)
+	is.Equal(len(absences), 0)
+
+	filter = filter.Next()
+	absences, err = a.ReadAbsences(context.Background(), principal, filter)
+	is.NoErr(err)
+	is.Equal(len(absences), 1)
+
+	otherPrincipal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user2",
+	}
+	absences, err = a.ReadAbsences(context.Background(), otherPrincipal, filter)
+	is.NoErr(err)
+	is.Equal(len(absences), 0)
+}
+
+func TestUpdateAbsenceOfOtherUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	absenceRepository := NewInMemAbsenceRepository()
+	a := &AbsenceService{
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		absenceRepository: absenceRepository,
+	}
+	absence, err := a.CreateAbsence(context.Background(), &shared.Principal{Username: "user1"}, &Absence{
+		Start: time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		Type:  AbsenceTypeSick,
+	})
+	is.NoErr(err)
+
+	// Act
+	_, err = a.UpdateAbsence(context.Background(), &shared.Principal{Username: "user2"}, &Absence{
+		ID:    absence.ID,
+		Start: time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2021, 11, 16, 0, 0, 0, 0, time.UTC),
+		Type:  AbsenceTypeSick,
+	})
+
+	// Assert
+	is.True(errors.Is(err, ErrAbsenceNotFound))
+	is.Equal(absenceRepository.absences[0].Username, "user1")
+	is.Equal(absenceRepository.absences[0].Days(), 1)
+}
+
+func TestDeleteAbsence(t *testing.T) {
+	is := is.New(t)
+
+	absenceRepository := NewInMemAbsenceRepository()
+	a := &AbsenceService{
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		absenceRepository: absenceRepository,
+	}
+	principal := &shared.Principal{Username: "user1"}
+	absence, err := a.CreateAbsence(context.Background(), principal, &Absence{
+		Start: time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		Type:  AbsenceTypeOther,
+	})
+	is.NoErr(err)
+
+	err = a.DeleteAbsence(context.Background(), principal, absence.ID)
+	is.NoErr(err)
+	is.Equal(len(absenceRepository.absences), 0)
+
+	err = a.DeleteAbsence(context.Background(), principal, absence.ID)
+	is.True(errors.Is(err, ErrAbsenceNotFound))
+}
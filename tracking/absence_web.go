@@ -0,0 +1,545 @@
+package tracking
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type absenceFormModel struct {
+	CSRFToken   string
+	ID          string
+	Start       string `validate:"required"`
+	End         string `validate:"required"`
+	Type        string `validate:"required,oneof=vacation sick other"`
+	Description string `validate:"max=500"`
+}
+
+type AbsenceWebHandlers struct {
+	config         *shared.Config
+	absenceService *AbsenceService
+}
+
+func NewAbsenceWebHandlers(config *shared.Config, absenceService *AbsenceService) *AbsenceWebHandlers {
+	return &AbsenceWebHandlers{
+		config:         config,
+		absenceService: absenceService,
+	}
+}
+
+func (a *AbsenceWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/absences", a.HandleAbsencesPage())
+	r.Post("/absences/new", a.HandleAbsenceForm())
+	r.Get("/absences/{absence-id}/edit", a.HandleAbsenceEdit())
+	r.Post("/absences/{absence-id}/edit", a.HandleAbsenceForm())
+}
+
+func (a *AbsenceWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+func (a *AbsenceWebHandlers) HandleAbsencesPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		formModel := newAbsenceFormModel(principal.Location())
+		formModel.CSRFToken = csrf.Token(r)
+
+		absences, err := a.readAbsencesOfView(r, principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Absences",
+			}
+			shared.RenderHTML(w, AbsencesPage(pageContext, formModel, absences))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, AbsencesView(formModel, absences, ""))
+	}
+}
+
+func (a *AbsenceWebHandlers) HandleAbsenceEdit() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		absenceIDParam := chi.URLParam(r, "absence-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		absenceID, err := uuid.Parse(absenceIDParam)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		absence, err := absenceService.ReadAbsence(r.Context(), principal, absenceID)
+		if errors.Is(err, ErrAbsenceNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		absences, err := a.readAbsencesOfView(r, principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := mapAbsenceToForm(absence)
+		formModel.CSRFToken = csrf.Token(r)
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, AbsencesView(formModel, absences, ""))
+	}
+}
+
+// HandleAbsenceForm creates a new or updates an existing absence
+func (a *AbsenceWebHandlers) HandleAbsenceForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	absenceService := a.absenceService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			a.renderAbsencesView(w, r, principal, isProduction, newAbsenceFormModel(principal.Location()), "")
+			return
+		}
+
+		var formModel absenceFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			a.renderAbsencesView(w, r, principal, isProduction, newAbsenceFormModel(principal.Location()), "")
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			a.renderAbsencesView(w, r, principal, isProduction, formModel, "Please enter the dates and type of your absence.")
+			return
+		}
+
+		absence, err := mapFormToAbsence(formModel)
+		if err != nil {
+			a.renderAbsencesView(w, r, principal, isProduction, formModel, "The absence must end on or after its start.")
+			return
+		}
+
+		if formModel.ID == "" {
+			_, err = absenceService.CreateAbsence(r.Context(), principal, absence)
+		} else {
+			_, err = absenceService.UpdateAbsence(r.Context(), principal, absence)
+		}
+		if errors.Is(err, ErrAbsenceNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__activities-changed")
+		a.renderAbsencesView(w, r, principal, isProduction, newAbsenceFormModel(principal.Location()), "")
+	}
+}
+
+func (a *AbsenceWebHandlers) renderAbsencesView(w http.ResponseWriter, r *http.Request, principal *shared.Principal, isProduction bool, formModel absenceFormModel, errorMessage string) {
+	absences, err := a.readAbsencesOfView(r, principal)
+	if err != nil {
+		shared.RenderProblemHTML(w, isProduction, err)
+		return
+	}
+
+	formModel.CSRFToken = csrf.Token(r)
+
+	shared.RenderHTML(w, AbsencesView(formModel, absences, errorMessage))
+}
+
+// readAbsencesOfView reads the absences of the principal from the start of the last year until the end of the next year
+func (a *AbsenceWebHandlers) readAbsencesOfView(r *http.Request, principal *shared.Principal) ([]*Absence, error) {
+	location := principal.Location()
+	now := time.Now().In(location)
+
+	filter := &ActivityFilter{
+		Timespan: TimespanCustom,
+		start:    time.Date(now.Year()-1, time.January, 1, 0, 0, 0, 0, location),
+		end:      time.Date(now.Year()+2, time.January, 1, 0, 0, 0, 0, location),
+		location: location,
+	}
+
+	return a.absenceService.ReadAbsences(r.Context(), principal, filter)
+}
+
+func AbsencesPage(pageContext *shared.PageContext, formModel absenceFormModel, absences []*Absence) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					AbsencesView(formModel, absences, ""),
+				),
+			),
+		},
+	)
+}
+
+func AbsencesView(formModel absenceFormModel, absences []*Absence, errorMessage string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Absences"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			AbsenceForm(formModel, errorMessage),
+			g.If(
+				len(absences) == 0,
+				Div(
+					Class("alert alert-info"),
+					Role("alert"),
+					g.Text("No absences yet."),
+				),
+			),
+			g.Group(
+				g.Map(absences, func(absence *Absence) g.Node {
+					return AbsenceRow(absence)
+				}),
+			),
+		),
+	)
+}
+
+func AbsenceRow(absence *Absence) g.Node {
+	return Div(
+		Class("card mt-2"),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("card-body p-2"),
+			Div(
+				Class("d-flex justify-content-between align-items-center"),
+				Span(
+					Class("flex-grow-1"),
+					AbsenceBadge(absence),
+					Span(
+						Class("ms-2"),
+						g.Text(absenceDatesFormatted(absence)),
+					),
+					Small(
+						Class("text-muted ms-2"),
+						g.Textf("%v day(s)", absence.Days()),
+					),
+				),
+				A(
+					ghx.Get(fmt.Sprintf("/absences/%v/edit", absence.ID)),
+					ghx.Target("#baralga__main_content_modal_content"),
+					Class("btn btn-outline-secondary btn-sm ms-1"),
+					I(Class("bi-pen")),
+				),
+				A(
+					ghx.Confirm(fmt.Sprintf("Do you really want to delete the absence %v?", absenceDatesFormatted(absence))),
+					ghx.Delete(fmt.Sprintf("/api/absences/%v", absence.ID)),
+					Class("btn btn-outline-secondary btn-sm ms-1"),
+					I(Class("bi-trash2")),
+				),
+			),
+			g.If(absence.Description != "",
+				Small(
+					Class("text-muted"),
+					g.Text(absence.Description),
+				),
+			),
+		),
+	)
+}
+
+// AbsencesInWeekView shows the absences of the week
+func AbsencesInWeekView(absences []*Absence) g.Node {
+	if len(absences) == 0 {
+		return nil
+	}
+
+	return Div(
+		Class("mb-3 d-flex flex-wrap gap-2"),
+		g.Group(
+			g.Map(absences, func(absence *Absence) g.Node {
+				return A(
+					Href("#"),
+					Class("text-decoration-none"),
+					TitleAttr(absence.Description),
+					ghx.Get(fmt.Sprintf("/absences/%v/edit", absence.ID)),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					AbsenceBadge(absence),
+					Small(
+						Class("text-muted ms-1"),
+						g.Text(absenceDatesFormatted(absence)),
+					),
+				)
+			}),
+		),
+	)
+}
+
+func AbsenceBadge(absence *Absence) g.Node {
+	var badgeClass, icon string
+	switch absence.Type {
+	case AbsenceTypeVacation:
+		badgeClass, icon = "bg-success", "bi-sun"
+	case AbsenceTypeSick:
+		badgeClass, icon = "bg-danger", "bi-bandaid"
+	default:
+		badgeClass, icon = "bg-secondary", "bi-calendar-x"
+	}
+
+	return Span(
+		Class(fmt.Sprintf("badge rounded-pill fw-normal %v", badgeClass)),
+		I(Class(fmt.Sprintf("%v me-1", icon))),
+		g.Text(absence.TypeFormatted()),
+	)
+}
+
+func AbsenceForm(formModel absenceFormModel, errorMessage string) g.Node {
+	isEditMode := formModel.ID != ""
+	return FormEl(
+		Class("mb-4 mt-2"),
+		g.If(!isEditMode,
+			ghx.Post("/absences/new"),
+		),
+		g.If(isEditMode,
+			ghx.Post(fmt.Sprintf("/absences/%v/edit", formModel.ID)),
+		),
+		ghx.Target("#baralga__main_content_modal_content"),
+		ghx.Swap("outerHTML"),
+
+		g.If(
+			errorMessage != "",
+			Div(
+				Class("alert alert-danger text-center"),
+				Role("alert"),
+				Span(g.Text(errorMessage)),
+			),
+		),
+		g.If(isEditMode,
+			Input(
+				Type("hidden"),
+				Name("ID"),
+				Value(formModel.ID),
+			),
+		),
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		Div(
+			Class("row g-2 mb-2"),
+			Div(
+				Class("col"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "AbsenceStart"),
+					g.Text("From"),
+				),
+				Input(
+					ID("AbsenceStart"),
+					Type("text"),
+					Name("Start"),
+					Value(formModel.Start),
+					Pattern("[0-3][0-9]\\.[0-1][0-9]\\.20[0-9]{2}"),
+					MinLength("10"),
+					MaxLength("10"),
+					g.Attr("required", "required"),
+					Class("form-control"),
+					g.Attr("placeholder", "16.11.2021"),
+				),
+			),
+			Div(
+				Class("col"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "AbsenceEnd"),
+					g.Text("Until"),
+				),
+				Input(
+					ID("AbsenceEnd"),
+					Type("text"),
+					Name("End"),
+					Value(formModel.End),
+					Pattern("[0-3][0-9]\\.[0-1][0-9]\\.20[0-9]{2}"),
+					MinLength("10"),
+					MaxLength("10"),
+					g.Attr("required", "required"),
+					Class("form-control"),
+					g.Attr("placeholder", "20.11.2021"),
+				),
+			),
+			Div(
+				Class("col"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "AbsenceType"),
+					g.Text("Type"),
+				),
+				Select(
+					ID("AbsenceType"),
+					Name("Type"),
+					Class("form-select"),
+					Option(
+						Value(AbsenceTypeVacation),
+						g.Text("Vacation"),
+						g.If(formModel.Type == AbsenceTypeVacation, Selected()),
+					),
+					Option(
+						Value(AbsenceTypeSick),
+						g.Text("Sick"),
+						g.If(formModel.Type == AbsenceTypeSick, Selected()),
+					),
+					Option(
+						Value(AbsenceTypeOther),
+						g.Text("Other"),
+						g.If(formModel.Type == AbsenceTypeOther, Selected()),
+					),
+				),
+			),
+		),
+		Div(
+			Class("input-group"),
+			Input(
+				ID("AbsenceDescription"),
+				Type("text"),
+				Name("Description"),
+				MaxLength("500"),
+				Value(formModel.Description),
+				Class("form-control"),
+				g.Attr("placeholder", "Description"),
+			),
+			g.If(isEditMode,
+				g.Group([]g.Node{
+					Button(
+						Class("btn btn-outline-primary"),
+						TitleAttr("Update Absence"),
+						I(Class("bi-save")),
+					),
+					A(
+						Class("btn btn-outline-secondary"),
+						TitleAttr("Cancel Edit"),
+						ghx.Get("/absences"),
+						ghx.Target("#baralga__main_content_modal_content"),
+						I(Class("bi-x")),
+					),
+				}),
+			),
+			g.If(!isEditMode,
+				Button(
+					Class("btn btn-outline-primary"),
+					TitleAttr("Add Absence"),
+					I(Class("bi-plus")),
+				),
+			),
+		),
+	)
+}
+
+func absenceDatesFormatted(absence *Absence) string {
+	if dateOf(absence.Start).Equal(dateOf(absence.End)) {
+		return time_utils.FormatDateDE(absence.Start)
+	}
+	return fmt.Sprintf("%v - %v", time_utils.FormatDateDE(absence.Start), time_utils.FormatDateDE(absence.End))
+}
+
+func newAbsenceFormModel(location *time.Location) absenceFormModel {
+	today := time_utils.FormatDateDE(time.Now().In(location))
+	return absenceFormModel{
+		Start: today,
+		End:   today,
+		Type:  AbsenceTypeVacation,
+	}
+}
+
+func mapAbsenceToForm(absence *Absence) absenceFormModel {
+	return absenceFormModel{
+		ID:          absence.ID.String(),
+		Start:       time_utils.FormatDateDE(absence.Start),
+		End:         time_utils.FormatDateDE(absence.End),
+		Type:        absence.Type,
+		Description: absence.Description,
+	}
+}
+
+func mapFormToAbsence(formModel absenceFormModel) (*Absence, error) {
+	var absenceID uuid.UUID
+	if formModel.ID != "" {
+		id, err := uuid.Parse(formModel.ID)
+		if err != nil {
+			return nil, err
+		}
+		absenceID = id
+	}
+
+	start, err := time_utils.ParseDateDE(formModel.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := time_utils.ParseDateDE(formModel.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if end.Before(*start) {
+		return nil, errors.New("absence must end on or after its start")
+	}
+
+	return &Absence{
+		ID:          absenceID,
+		Start:       *start,
+		End:         *end,
+		Type:        formModel.Type,
+		Description: formModel.Description,
+	}, nil
+}
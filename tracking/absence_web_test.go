@@ -0,0 +1,144 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleAbsencesPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:    uuid.New(),
+		Start: time.Now(),
+		End:   time.Now(),
+		Type:  AbsenceTypeSick,
+	})
+
+	a := &AbsenceWebHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	r, _ := http.NewRequest("GET", "/absences", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleAbsencesPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Absences"))
+	is.True(strings.Contains(htmlBody, "Sick"))
+	is.True(strings.Contains(htmlBody, "1 day(s)"))
+}
+
+func TestHandleAbsenceEdit(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceID := uuid.New()
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:          absenceID,
+		Start:       time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2021, 11, 19, 0, 0, 0, 0, time.UTC),
+		Type:        AbsenceTypeVacation,
+		Description: "Autumn Vacation",
+	})
+
+	a := &AbsenceWebHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	r, _ := http.NewRequest("GET", "/absences/"+absenceID.String()+"/edit", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("absence-id", absenceID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleAbsenceEdit()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "15.11.2021"))
+	is.True(strings.Contains(htmlBody, "Autumn Vacation"))
+	is.True(strings.Contains(htmlBody, "Update Absence"))
+}
+
+func TestHandleAbsenceFormWithValidAbsence(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceRepository := NewInMemAbsenceRepository()
+	a := &AbsenceWebHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	data := url.Values{}
+	data["Start"] = []string{"15.11.2021"}
+	data["End"] = []string{"19.11.2021"}
+	data["Type"] = []string{"vacation"}
+	data["Description"] = []string{"Autumn Vacation"}
+
+	r, _ := http.NewRequest("POST", "/absences/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleAbsenceForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__activities-changed")
+
+	is.Equal(len(absenceRepository.absences), 1)
+	is.Equal(absenceRepository.absences[0].Username, "user1")
+	is.Equal(absenceRepository.absences[0].Days(), 5)
+}
+
+func TestHandleAbsenceFormWithEndBeforeStart(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	absenceRepository := NewInMemAbsenceRepository()
+	a := &AbsenceWebHandlers{
+		config:         &shared.Config{},
+		absenceService: NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	data := url.Values{}
+	data["Start"] = []string{"19.11.2021"}
+	data["End"] = []string{"15.11.2021"}
+	data["Type"] = []string{"vacation"}
+
+	r, _ := http.NewRequest("POST", "/absences/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleAbsenceForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(absenceRepository.absences), 0)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "The absence must end on or after its start."))
+}
@@ -17,6 +17,9 @@ const (
 	SortOrderDesc string = "desc"
 )
 
+// MaxActivityDuration is the longest an activity may last, long enough for a night shift across midnight
+const MaxActivityDuration = 24 * time.Hour
+
 var ErrActivityNotFound = errors.New("activity not found")
 
 // Activity represents a tracked time for a project
@@ -65,7 +68,9 @@ type ActivityFilter struct {
 	sortOrder string
 	start     time.Time
 	end       time.Time
-	tags      []string // tag names to filter by
+	tags      []string  // tag names to filter by
+	team      uuid.UUID // team to filter by, uuid.Nil for no team
+	location  *time.Location
 }
 
 type ActivityTimeReportItem struct {
@@ -88,6 +93,7 @@ type ActivitiesFilter struct {
 	SortBy         string
 	SortOrder      string
 	Username       string
+	Usernames      []string // restricts to the activities of these users if not nil, e.g. of a team
 	OrganizationID uuid.UUID
 }
 
@@ -126,6 +132,8 @@ type ActivityRepository interface {
 	DeleteActivityByIDAndUsername(ctx context.Context, organizationID, activityID uuid.UUID, username string) error
 	UpdateActivity(ctx context.Context, organizationID uuid.UUID, activity *Activity) (*Activity, error)
 	UpdateActivityByUsername(ctx context.Context, organizationID uuid.UUID, activity *Activity, username string) (*Activity, error)
+	AnonymizeActivitiesByUsername(ctx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error
+	DeleteActivitiesByUsername(ctx context.Context, organizationID uuid.UUID, username string) error
 }
 
 // TagRepository manages tag CRUD operations
@@ -213,14 +221,38 @@ func (f *ActivityFilter) WithTags(tags []string) *ActivityFilter {
 		start:     f.start,
 		end:       f.end,
 		tags:      tags,
+		team:      f.team,
+		location:  f.location,
 	}
 }
 
+// Team returns the team to filter by, uuid.Nil for no team
+func (f *ActivityFilter) Team() uuid.UUID {
+	return f.team
+}
+
+// WithTeam returns a new filter for the activities of the team
+func (f *ActivityFilter) WithTeam(teamID uuid.UUID) *ActivityFilter {
+	filterWithTeam := *f
+	filterWithTeam.team = teamID
+	return &filterWithTeam
+}
+
+// Location returns the filter's time zone, defaults to UTC
+func (f *ActivityFilter) Location() *time.Location {
+	if f.location == nil {
+		return time.UTC
+	}
+	return f.location
+}
+
 func (f *ActivityFilter) Home() *ActivityFilter {
 	return &ActivityFilter{
 		Timespan: f.Timespan,
-		start:    time.Now(),
+		start:    time.Now().In(f.Location()),
 		tags:     f.tags,
+		team:     f.team,
+		location: f.location,
 	}
 }
 
@@ -230,6 +262,8 @@ func (f *ActivityFilter) Next() *ActivityFilter {
 		start:    f.start,
 		end:      f.end,
 		tags:     f.tags,
+		team:     f.team,
+		location: f.location,
 	}
 
 	switch nextFilter.Timespan {
@@ -259,6 +293,8 @@ func (f *ActivityFilter) Previous() *ActivityFilter {
 		start:    f.start,
 		end:      f.end,
 		tags:     f.tags,
+		team:     f.team,
+		location: f.location,
 	}
 
 	switch previousFilter.Timespan {
@@ -289,6 +325,8 @@ func (f *ActivityFilter) WithSortToggle(sortBy string) *ActivityFilter {
 		start:    f.start,
 		end:      f.end,
 		tags:     f.tags,
+		team:     f.team,
+		location: f.location,
 	}
 
 	if f.sortOrder == "desc" {
@@ -342,7 +380,7 @@ func (f *ActivityFilter) StringFormatted() string {
 }
 
 func (f *ActivityFilter) NewValue() string {
-	now := time.Now()
+	now := time.Now().In(f.Location())
 	switch f.Timespan {
 	case TimespanDay:
 		return now.Format("2006-01-02")
@@ -387,6 +425,41 @@ func (a *Activity) DurationFormatted() string {
 	return time_utils.FormatMinutesAsDuration(float64(a.DurationMinutesTotal()))
 }
 
+// IsTooLong checks if the activity lasts longer than the maximum duration of an activity
+func (a *Activity) IsTooLong() bool {
+	return a.duration() > MaxActivityDuration
+}
+
+// SplitByDay splits an activity spanning midnight into one part per day in the time zone of its start
+func (a *Activity) SplitByDay() []*Activity {
+	location := a.Start.Location()
+	end := a.End.In(location)
+
+	var parts []*Activity
+	start := a.Start
+	for {
+		y, m, d := start.Date()
+		nextDay := time.Date(y, m, d+1, 0, 0, 0, 0, location)
+
+		part := *a
+		part.Start = start
+		if !end.After(nextDay) {
+			part.End = end
+			parts = append(parts, &part)
+			return parts
+		}
+
+		part.End = nextDay
+		parts = append(parts, &part)
+		start = nextDay
+	}
+}
+
+// IsSpanningMidnight checks if the activity ends on a later day than it starts
+func (a *Activity) IsSpanningMidnight() bool {
+	return len(a.SplitByDay()) > 1
+}
+
 func (a *Activity) duration() time.Duration {
 	return a.End.Sub(a.Start)
 }
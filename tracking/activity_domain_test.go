@@ -87,6 +87,65 @@ func TestActivityDurationFormatted(t *testing.T) {
 	is.Equal(formatted, "1:09 h")
 }
 
+func TestActivitySplitByDay(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	t.Run("activity within a day", func(t *testing.T) {
+		a := &Activity{
+			Start: time.Date(2021, time.November, 12, 10, 0, 0, 0, berlin),
+			End:   time.Date(2021, time.November, 12, 11, 0, 0, 0, berlin),
+		}
+
+		parts := a.SplitByDay()
+
+		is.Equal(len(parts), 1)
+		is.Equal(parts[0].DurationMinutesTotal(), 60)
+		is.True(!a.IsSpanningMidnight())
+	})
+
+	t.Run("activity spanning midnight", func(t *testing.T) {
+		a := &Activity{
+			Start: time.Date(2021, time.November, 12, 22, 0, 0, 0, berlin),
+			End:   time.Date(2021, time.November, 13, 1, 30, 0, 0, berlin),
+		}
+
+		parts := a.SplitByDay()
+
+		is.Equal(len(parts), 2)
+		is.Equal(parts[0].Start.Day(), 12)
+		is.Equal(parts[0].DurationMinutesTotal(), 120)
+		is.Equal(parts[1].Start.Day(), 13)
+		is.Equal(parts[1].Start.Hour(), 0)
+		is.Equal(parts[1].DurationMinutesTotal(), 90)
+		is.True(a.IsSpanningMidnight())
+	})
+
+	t.Run("activity ending at midnight", func(t *testing.T) {
+		a := &Activity{
+			Start: time.Date(2021, time.November, 12, 22, 0, 0, 0, berlin),
+			End:   time.Date(2021, time.November, 13, 0, 0, 0, 0, berlin),
+		}
+
+		parts := a.SplitByDay()
+
+		is.Equal(len(parts), 1)
+		is.Equal(parts[0].DurationMinutesTotal(), 120)
+	})
+
+	t.Run("activity spanning several days", func(t *testing.T) {
+		a := &Activity{
+			Start: time.Date(2021, time.November, 12, 22, 0, 0, 0, berlin),
+			End:   time.Date(2021, time.November, 14, 2, 0, 0, 0, berlin),
+		}
+
+		parts := a.SplitByDay()
+
+		is.Equal(len(parts), 3)
+		is.Equal(parts[1].DurationMinutesTotal(), 24*60)
+	})
+}
+
 func TestActivityFilterEnd(t *testing.T) {
 	is := is.New(t)
 
@@ -224,6 +283,30 @@ func TestActivityFilterHome(t *testing.T) {
 
 }
 
+func TestActivityFilterLocation(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	t.Run("filter without location", func(t *testing.T) {
+		f := &ActivityFilter{}
+		is.Equal(f.Location(), time.UTC)
+	})
+
+	t.Run("location is kept when navigating", func(t *testing.T) {
+		f := &ActivityFilter{
+			start:    time.Date(2021, time.November, 1, 0, 0, 0, 0, berlin),
+			Timespan: TimespanMonth,
+			location: berlin,
+		}
+
+		is.Equal(f.Next().Location(), berlin)
+		is.Equal(f.Previous().Location(), berlin)
+		is.Equal(f.Home().Location(), berlin)
+		is.Equal(f.WithSortToggle("start").Location(), berlin)
+		is.Equal(f.WithTags([]string{"meeting"}).Location(), berlin)
+	})
+}
+
 func TestIsValidSortOrder(t *testing.T) {
 	is := is.New(t)
 
@@ -37,11 +37,15 @@ func (r *DbActivityRepository) TimeReportByDay(ctx context.Context, filter *Acti
 	if filter.Username != "" {
 		params = append(params, filter.Username)
 		filterSql = " AND username = $4"
+	} else if filter.Usernames != nil {
+		params = append(params, filter.Usernames)
+		filterSql = " AND username = ANY($4)"
 	}
 
+	// activities spanning midnight are split into one row per day
 	sql := fmt.Sprintf(
 		`SELECT year, quarter, month, week, day, sum(duration_minutes_total) as duration_minutes_total  
-		 FROM activities_agg
+		 FROM activities_by_day
 	     WHERE org_id = $1 AND $2 <= start_time AND start_time < $3 %s
 		 GROUP BY year, quarter, month, week, day
          ORDER BY (year, quarter, month, week, day) desc`,
@@ -91,11 +95,15 @@ func (r *DbActivityRepository) TimeReportByWeek(ctx context.Context, filter *Act
 	if filter.Username != "" {
 		params = append(params, filter.Username)
 		filterSql = " AND username = $4"
+	} else if filter.Usernames != nil {
+		params = append(params, filter.Usernames)
+		filterSql = " AND username = ANY($4)"
 	}
 
+	// activities spanning midnight are split into one row per day
 	sql := fmt.Sprintf(
 		`SELECT year, week, sum(duration_minutes_total) as duration_minutes_total  
-		 FROM activities_agg
+		 FROM activities_by_day
 	     WHERE org_id = $1 AND $2 <= start_time AND start_time < $3 %s
 		 GROUP BY year, week
          ORDER BY (year, week) desc`,
@@ -139,11 +147,15 @@ func (r *DbActivityRepository) TimeReportByMonth(ctx context.Context, filter *Ac
 	if filter.Username != "" {
 		params = append(params, filter.Username)
 		filterSql = " AND username = $4"
+	} else if filter.Usernames != nil {
+		params = append(params, filter.Usernames)
+		filterSql = " AND username = ANY($4)"
 	}
 
+	// activities spanning midnight are split into one row per day
 	sql := fmt.Sprintf(
 		`SELECT year, month, sum(duration_minutes_total) as duration_minutes_total  
-		 FROM activities_agg
+		 FROM activities_by_day
 	     WHERE org_id = $1 AND $2 <= start_time AND start_time < $3 %s
 		 GROUP BY year, month
          ORDER BY (year, month) desc`,
@@ -188,11 +200,15 @@ func (r *DbActivityRepository) TimeReportByQuarter(ctx context.Context, filter *
 	if filter.Username != "" {
 		params = append(params, filter.Username)
 		filterSql = " AND username = $4"
+	} else if filter.Usernames != nil {
+		params = append(params, filter.Usernames)
+		filterSql = " AND username = ANY($4)"
 	}
 
+	// activities spanning midnight are split into one row per day
 	sql := fmt.Sprintf(
 		`SELECT year, quarter, sum(duration_minutes_total) as duration_minutes_total  
-		 FROM activities_agg
+		 FROM activities_by_day
 	     WHERE org_id = $1 AND $2 <= start_time AND start_time < $3 %s
 		 GROUP BY year, quarter
          ORDER BY (year, quarter) desc`,
@@ -237,6 +253,9 @@ func (r *DbActivityRepository) ProjectReport(ctx context.Context, filter *Activi
 	if filter.Username != "" {
 		params = append(params, filter.Username)
 		filterSql = " AND username = $4"
+	} else if filter.Usernames != nil {
+		params = append(params, filter.Usernames)
+		filterSql = " AND username = ANY($4)"
 	}
 
 	sql := fmt.Sprintf(
@@ -282,6 +301,7 @@ func (r *DbActivityRepository) ProjectReport(ctx context.Context, filter *Activi
 	return activities, nil
 }
 
+// FindActivities finds all activities overlapping the filter's timespan
 func (r *DbActivityRepository) FindActivities(ctx context.Context, filter *ActivitiesFilter, pageParams *paged.PageParams) (*ActivitiesPaged, []*Project, error) {
 	params := []interface{}{filter.OrganizationID, filter.Start, filter.End, pageParams.Size, pageParams.Offset()}
 	filterSql := ""
@@ -290,6 +310,9 @@ func (r *DbActivityRepository) FindActivities(ctx context.Context, filter *Activ
 	if filter.Username != "" {
 		params = append(params, filter.Username)
 		filterSql += fmt.Sprintf(" AND username = $%d", paramIndex)
+	} else if filter.Usernames != nil {
+		params = append(params, filter.Usernames)
+		filterSql += fmt.Sprintf(" AND username = ANY($%d)", paramIndex)
 	}
 
 	sortBy := "start"
@@ -319,7 +342,7 @@ func (r *DbActivityRepository) FindActivities(ctx context.Context, filter *Activ
 		FROM (
 			SELECT activity_id as id, description, start_time as start, end_time as end, username, org_id, project_id
 			FROM activities 
-			WHERE org_id = $1 %s AND $2 <= start_time AND start_time < $3
+			WHERE org_id = $1 %s AND start_time < $3 AND ($2 <= start_time OR $2 < end_time)
 		) a
 		INNER JOIN projects ON projects.project_id = a.project_id
 		LEFT JOIN activity_tags at ON at.activity_id = a.id
@@ -429,12 +452,15 @@ func (r *DbActivityRepository) FindActivities(ctx context.Context, filter *Activ
 	if filter.Username != "" {
 		countParams = append(countParams, filter.Username)
 		countFilter += fmt.Sprintf(" AND username = $%d", countParamIndex)
+	} else if filter.Usernames != nil {
+		countParams = append(countParams, filter.Usernames)
+		countFilter += fmt.Sprintf(" AND username = ANY($%d)", countParamIndex)
 	}
 
 	countSql := fmt.Sprintf(`
      	SELECT count(activities.activity_id) as total 
 	    FROM activities
-	    WHERE org_id = $1 %s AND $2 <= start_time AND start_time < $3`,
+	    WHERE org_id = $1 %s AND start_time < $3 AND ($2 <= start_time OR $2 < end_time)`,
 		countFilter)
 	row := r.connPool.QueryRow(ctx, countSql, countParams...)
 	var total int
@@ -667,3 +693,27 @@ func (r *DbActivityRepository) InsertActivity(ctx context.Context, activity *Act
 
 	return activity, nil
 }
+
+// AnonymizeActivitiesByUsername replaces the username of all activities of the user
+func (r *DbActivityRepository) AnonymizeActivitiesByUsername(ctx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(ctx,
+		`UPDATE activities 
+		 SET username = $3 
+		 WHERE org_id = $1 AND username = $2`,
+		organizationID, username, anonymizedUsername)
+	return err
+}
+
+// DeleteActivitiesByUsername deletes all activities of the user with their tag relationships
+func (r *DbActivityRepository) DeleteActivitiesByUsername(ctx context.Context, organizationID uuid.UUID, username string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(ctx,
+		`DELETE 
+         FROM activities 
+	     WHERE org_id = $1 AND username = $2`,
+		organizationID, username)
+	return err
+}
@@ -452,6 +452,122 @@ func TestActivityRepositoryReports(t *testing.T) {
 		is.Equal(len(reportItems), 1)
 		is.Equal(300, reportItems[0].DurationInMinutesTotal)
 	})
+
+	t.Run("TimeReportByDay with activity spanning midnight", func(t *testing.T) {
+		// Arrange
+		berlin, _ := time.LoadLocation("Europe/Berlin")
+		repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+		activity := &Activity{
+			ID:             uuid.New(),
+			Start:          time.Date(2024, time.March, 5, 22, 0, 0, 0, berlin),
+			End:            time.Date(2024, time.March, 6, 2, 0, 0, 0, berlin),
+			ProjectID:      shared.ProjectIDSample,
+			OrganizationID: shared.OrganizationIDSample,
+			Username:       "admin",
+		}
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := activityRepository.InsertActivity(ctx, activity)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		// Act
+		reportItems, err := activityRepository.TimeReportByDay(
+			context.Background(),
+			&ActivitiesFilter{
+				Start:          time.Date(2024, time.March, 1, 0, 0, 0, 0, berlin),
+				End:            time.Date(2024, time.April, 1, 0, 0, 0, 0, berlin),
+				OrganizationID: shared.OrganizationIDSample,
+			},
+		)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(len(reportItems), 2)
+		is.Equal(6, reportItems[0].Day)
+		is.Equal(120, reportItems[0].DurationInMinutesTotal)
+		is.Equal(5, reportItems[1].Day)
+		is.Equal(120, reportItems[1].DurationInMinutesTotal)
+	})
+
+	t.Run("TimeReportByMonth with activity spanning the end of the month", func(t *testing.T) {
+		// Arrange
+		berlin, _ := time.LoadLocation("Europe/Berlin")
+		repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+		activity := &Activity{
+			ID:             uuid.New(),
+			Start:          time.Date(2024, time.March, 31, 22, 0, 0, 0, berlin),
+			End:            time.Date(2024, time.April, 1, 2, 0, 0, 0, berlin),
+			ProjectID:      shared.ProjectIDSample,
+			OrganizationID: shared.OrganizationIDSample,
+			Username:       "admin",
+		}
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := activityRepository.InsertActivity(ctx, activity)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		// Act
+		reportItems, err := activityRepository.TimeReportByMonth(
+			context.Background(),
+			&ActivitiesFilter{
+				Start:          time.Date(2024, time.March, 1, 0, 0, 0, 0, berlin),
+				End:            time.Date(2024, time.May, 1, 0, 0, 0, 0, berlin),
+				OrganizationID: shared.OrganizationIDSample,
+			},
+		)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(len(reportItems), 2)
+		is.Equal(4, reportItems[0].Month)
+		is.Equal(120, reportItems[0].DurationInMinutesTotal)
+		is.Equal(3, reportItems[1].Month)
+		is.Equal(240+120, reportItems[1].DurationInMinutesTotal)
+	})
+
+	t.Run("ProjectReport with activity of a whole day", func(t *testing.T) {
+		// Arrange
+		repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+		activity := &Activity{
+			ID:             uuid.New(),
+			Start:          time.Date(2025, time.January, 6, 8, 0, 0, 0, time.UTC),
+			End:            time.Date(2025, time.January, 7, 8, 0, 0, 0, time.UTC),
+			ProjectID:      shared.ProjectIDSample,
+			OrganizationID: shared.OrganizationIDSample,
+			Username:       "admin",
+		}
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := activityRepository.InsertActivity(ctx, activity)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		// Act
+		reportItems, err := activityRepository.ProjectReport(
+			context.Background(),
+			&ActivitiesFilter{
+				Start:          time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+				End:            time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+				OrganizationID: shared.OrganizationIDSample,
+			},
+		)
+
+		// Assert
+		is.NoErr(err)
+		is.Equal(len(reportItems), 1)
+		is.Equal(1440, reportItems[0].DurationInMinutesTotal)
+	})
 }
 
 func insertSampleActivitiesForReports(ctx context.Context, connPool *pgxpool.Pool) error {
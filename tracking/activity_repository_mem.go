@@ -172,3 +172,23 @@ func (r *InMemActivityRepository) UpdateActivityByUsername(ctx context.Context,
 	}
 	return nil, ErrActivityNotFound
 }
+
+func (r *InMemActivityRepository) AnonymizeActivitiesByUsername(ctx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error {
+	for _, a := range r.activities {
+		if a.OrganizationID == organizationID && a.Username == username {
+			a.Username = anonymizedUsername
+		}
+	}
+	return nil
+}
+
+func (r *InMemActivityRepository) DeleteActivitiesByUsername(ctx context.Context, organizationID uuid.UUID, username string) error {
+	var activities []*Activity
+	for _, a := range r.activities {
+		if a.OrganizationID != organizationID || a.Username != username {
+			activities = append(activities, a)
+		}
+	}
+	r.activities = activities
+	return nil
+}
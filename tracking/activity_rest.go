@@ -33,12 +33,29 @@ type EmbeddedActivities struct {
 }
 
 type activityModel struct {
-	ID          string         `json:"id"`
-	Start       string         `json:"start" validate:"required"`
-	End         string         `json:"end" validate:"required"`
-	Description string         `json:"description" validate:"max=500"`
-	Duration    *durationModel `json:"duration"`
-	Links       *hal.Links     `json:"_links"`
+	ID          string                      `json:"id"`
+	Start       string                      `json:"start" validate:"required"`
+	End         string                      `json:"end" validate:"required"`
+	Description string                      `json:"description" validate:"max=500"`
+	Duration    *durationModel              `json:"duration"`
+	Warnings    []*complianceViolationModel `json:"warnings,omitempty"`
+	Links       *hal.Links                  `json:"_links"`
+}
+
+type complianceViolationModel struct {
+	Day     string `json:"day"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type complianceViolationsModel struct {
+	*EmbeddedComplianceViolations `json:"_embedded"`
+	Links                         *hal.Links `json:"_links"`
+}
+
+// EmbeddedComplianceViolations contains embedded violations of the german working time law
+type EmbeddedComplianceViolations struct {
+	ComplianceViolationModels []*complianceViolationModel `json:"violations"`
 }
 
 type durationModel struct {
@@ -66,11 +83,18 @@ func (a *ActivityRestHandlers) RegisterOpen(r chi.Router) {
 }
 
 func (a *ActivityRestHandlers) RegisterProtected(r chi.Router) {
-	r.Get("/activities", a.HandleGetActivities())
-	r.Post("/activities", a.HandleCreateActivity())
-	r.Get("/activities/{activity-id}", a.HandleGetActivity())
-	r.Delete("/activities/{activity-id}", a.HandleDeleteActivity())
-	r.Patch("/activities/{activity-id}", a.HandleUpdateActivity())
+}
+
+func (a *ActivityRestHandlers) RegisterScoped(r chi.Router) {
+	read := shared.RequireScope(shared.ScopeActivitiesRead)
+	write := shared.RequireScope(shared.ScopeActivitiesWrite)
+
+	r.With(read).Get("/activities", a.HandleGetActivities())
+	r.With(write).Post("/activities", a.HandleCreateActivity())
+	r.With(read).Get("/activities/{activity-id}", a.HandleGetActivity())
+	r.With(write).Delete("/activities/{activity-id}", a.HandleDeleteActivity())
+	r.With(write).Patch("/activities/{activity-id}", a.HandleUpdateActivity())
+	r.With(shared.RequireScope(shared.ScopeReportsRead)).Get("/compliance", a.HandleGetComplianceViolations())
 }
 
 // HandleGetActivities reads activities
@@ -83,7 +107,7 @@ func (a *ActivityRestHandlers) HandleGetActivities() http.HandlerFunc {
 		principal := shared.MustPrincipalFromContext(r.Context())
 		pageParams := paged.PageParamsOf(r)
 
-		filter, err := filterFromQueryParams(r.URL.Query())
+		filter, err := filterFromQueryParams(r.URL.Query(), principal.Location())
 		if err != nil {
 			shared.RenderProblemJSON(w, isProduction, errors.New("invalid query params"))
 			return
@@ -115,7 +139,7 @@ func (a *ActivityRestHandlers) HandleGetActivities() http.HandlerFunc {
 			return
 		}
 
-		activityModels := mapToActivityModels(activitiesPage.Activities)
+		activityModels := mapToActivityModels(activitiesPage.Activities, principal.Location())
 		projectModels := mapToProjectModels(principal, projects)
 
 		activitiesModel := &activitiesModel{
@@ -152,21 +176,28 @@ func (a *ActivityRestHandlers) HandleCreateActivity() http.HandlerFunc {
 			return
 		}
 
-		activityToCreate, err := mapToActivity(&activityModel)
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		activityToCreate, err := mapToActivity(&activityModel, principal.Location())
 		if err != nil {
 			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
 			return
 		}
 
-		principal := shared.MustPrincipalFromContext(r.Context())
-
 		activity, err := actitivityService.CreateActivity(r.Context(), principal, activityToCreate)
 		if err != nil {
 			shared.RenderProblemJSON(w, isProduction, err)
 			return
 		}
 
-		activityModelCreated := mapToActivityModel(activity)
+		violations, err := actitivityService.ComplianceViolationsOfActivity(r.Context(), principal, activity)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		activityModelCreated := mapToActivityModel(activity, principal.Location())
+		activityModelCreated.Warnings = mapToComplianceViolationModels(violations)
 
 		w.WriteHeader(http.StatusCreated)
 		shared.RenderJSON(w, activityModelCreated)
@@ -197,7 +228,7 @@ func (a *ActivityRestHandlers) HandleGetActivity() http.HandlerFunc {
 			return
 		}
 
-		activityModel := mapToActivityModel(activity)
+		activityModel := mapToActivityModel(activity, principal.Location())
 		shared.RenderJSON(w, activityModel)
 	}
 }
@@ -252,7 +283,7 @@ func (a *ActivityRestHandlers) HandleUpdateActivity() http.HandlerFunc {
 			return
 		}
 
-		activity, err := mapToActivity(&activityModel)
+		activity, err := mapToActivity(&activityModel, principal.Location())
 		if err != nil {
 			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
 			return
@@ -275,12 +306,63 @@ func (a *ActivityRestHandlers) HandleUpdateActivity() http.HandlerFunc {
 			return
 		}
 
-		activityModelUpdate := mapToActivityModel(activityUpdate)
+		violations, err := actitivityService.ComplianceViolationsOfActivity(r.Context(), principal, activityUpdate)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		activityModelUpdate := mapToActivityModel(activityUpdate, principal.Location())
+		activityModelUpdate.Warnings = mapToComplianceViolationModels(violations)
 		shared.RenderJSON(w, activityModelUpdate)
 	}
 }
 
-func mapToActivity(activityModel *activityModel) (*Activity, error) {
+// HandleGetComplianceViolations checks the activities of the principal against the german working time law
+func (a *ActivityRestHandlers) HandleGetComplianceViolations() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	actitivityService := a.actitivityService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		filter, err := filterFromQueryParams(r.URL.Query(), principal.Location())
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, errors.New("invalid query params"))
+			return
+		}
+
+		violations, err := actitivityService.ComplianceViolations(r.Context(), principal, filter)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		violationsModel := &complianceViolationsModel{
+			EmbeddedComplianceViolations: &EmbeddedComplianceViolations{
+				ComplianceViolationModels: mapToComplianceViolationModels(violations),
+			},
+			Links: hal.NewLinks(
+				hal.NewSelfLink(r.RequestURI),
+			),
+		}
+
+		shared.RenderJSON(w, violationsModel)
+	}
+}
+
+func mapToComplianceViolationModels(violations []*ComplianceViolation) []*complianceViolationModel {
+	violationModels := make([]*complianceViolationModel, 0, len(violations))
+	for _, violation := range violations {
+		violationModels = append(violationModels, &complianceViolationModel{
+			Day:     time_utils.FormatDate(violation.Day),
+			Rule:    violation.Rule,
+			Message: violation.Message,
+		})
+	}
+	return violationModels
+}
+
+func mapToActivity(activityModel *activityModel, location *time.Location) (*Activity, error) {
 	var activityID uuid.UUID
 
 	if activityModel.ID != "" {
@@ -291,16 +373,20 @@ func mapToActivity(activityModel *activityModel) (*Activity, error) {
 		activityID = aID
 	}
 
-	start, err := time_utils.ParseDateTime(activityModel.Start)
+	start, err := time_utils.ParseDateTimeInLocation(activityModel.Start, location)
 	if err != nil {
 		return nil, err
 	}
 
-	end, err := time_utils.ParseDateTime(activityModel.End)
+	end, err := time_utils.ParseDateTimeInLocation(activityModel.End, location)
 	if err != nil {
 		return nil, err
 	}
 
+	if end.Before(*start) {
+		return nil, errors.New("activity must end after it starts")
+	}
+
 	projectHref := activityModel.Links.HrefOf("project")
 	projectID, err := uuid.Parse(projectHref[strings.LastIndex(projectHref, "/")+1:])
 	if err != nil {
@@ -315,15 +401,19 @@ func mapToActivity(activityModel *activityModel) (*Activity, error) {
 		Description: activityModel.Description,
 	}
 
+	if activity.IsTooLong() {
+		return nil, fmt.Errorf("activity must not last longer than %v hours", MaxActivityDuration.Hours())
+	}
+
 	return activity, nil
 }
 
-func mapToActivityModel(activity *Activity) *activityModel {
+func mapToActivityModel(activity *Activity, location *time.Location) *activityModel {
 	return &activityModel{
 		ID:          activity.ID.String(),
 		Description: activity.Description,
-		Start:       time_utils.FormatDateTime(activity.Start),
-		End:         time_utils.FormatDateTime(activity.End),
+		Start:       time_utils.FormatDateTime(activity.Start.In(location)),
+		End:         time_utils.FormatDateTime(activity.End.In(location)),
 		Links: hal.NewLinks(
 			hal.NewSelfLink(fmt.Sprintf("/api/activities/%s", activity.ID)),
 			hal.NewLink("delete", fmt.Sprintf("/api/activities/%s", activity.ID)),
@@ -339,11 +429,11 @@ func mapToActivityModel(activity *Activity) *activityModel {
 	}
 }
 
-func mapToActivityModels(activities []*Activity) []*activityModel {
+func mapToActivityModels(activities []*Activity, location *time.Location) []*activityModel {
 	activityModels := make([]*activityModel, len(activities))
 
 	for i, activity := range activities {
-		activityModel := mapToActivityModel(activity)
+		activityModel := mapToActivityModel(activity, location)
 		activityModels[i] = activityModel
 	}
 
@@ -361,7 +451,7 @@ func mapToProjectModels(principal *shared.Principal, projects []*Project) []*pro
 	return activityModels
 }
 
-func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
+func filterFromQueryParams(params url.Values, location *time.Location) (*ActivityFilter, error) {
 	if len(params["t"]) == 0 {
 		params["t"] = []string{"week"}
 	}
@@ -388,6 +478,15 @@ func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
 		Timespan:  timespan,
 		sortBy:    sortBy,
 		sortOrder: sortOrder,
+		location:  location,
+	}
+
+	if params.Get("team") != "" {
+		teamID, err := uuid.Parse(params.Get("team"))
+		if err != nil {
+			return nil, err
+		}
+		filter.team = teamID
 	}
 
 	if timespan == TimespanCustom && len(params["start"]) == 0 && len(params["end"]) == 0 {
@@ -402,7 +501,7 @@ func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
 
 	switch timespan {
 	case TimespanYear:
-		start, err := time.Parse("2006", value)
+		start, err := time.ParseInLocation("2006", value, location)
 		if err != nil {
 			return nil, err
 		}
@@ -412,21 +511,18 @@ func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
 			return nil, errors.New("invalid quarter")
 		}
 		valueParts := strings.Split(value, "-")
-		start, err := time.Parse("2006", valueParts[0])
+		start, err := time.ParseInLocation("2006", valueParts[0], location)
 		if err != nil {
 			return nil, err
 		}
 
-		d := 24 * time.Hour
-		start = start.Truncate(d)
-
 		startQuarterOfYear, err := strconv.Atoi(valueParts[1])
 		if err != nil {
 			return nil, errors.New("invalid quarter")
 		}
 		filter.start = start.AddDate(0, 3*(startQuarterOfYear-1), 0)
 	case TimespanMonth:
-		start, err := time.Parse("2006-01", value)
+		start, err := time.ParseInLocation("2006-01", value, location)
 		if err != nil {
 			return nil, err
 		}
@@ -446,9 +542,9 @@ func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
 			return nil, err
 		}
 
-		filter.start = isoweek.StartTime(startYear, startWeekOfYear, time.UTC)
+		filter.start = isoweek.StartTime(startYear, startWeekOfYear, location)
 	case TimespanDay:
-		start, err := time.Parse("2006-01-02", value)
+		start, err := time.ParseInLocation("2006-01-02", value, location)
 		if err != nil {
 			return nil, err
 		}
@@ -456,7 +552,7 @@ func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
 	case TimespanCustom:
 		startParamValue := params.Get("start")
 		if startParamValue != "" {
-			startParam, err := time_utils.ParseDate(startParamValue)
+			startParam, err := time_utils.ParseDateInLocation(startParamValue, location)
 			if err != nil {
 				return nil, err
 			}
@@ -465,7 +561,7 @@ func filterFromQueryParams(params url.Values) (*ActivityFilter, error) {
 
 		endParamValue := params.Get("end")
 		if endParamValue != "" {
-			endParam, err := time_utils.ParseDate(endParamValue)
+			endParam, err := time_utils.ParseDateInLocation(endParamValue, location)
 			if err != nil {
 				return nil, err
 			}
@@ -26,6 +26,7 @@ func createTestActivityServiceForRest(repo ActivityRepository) *ActitivityServic
 		activityRepository: repo,
 		tagRepository:      tagRepo,
 		tagService:         tagService,
+		holidayRepository:  NewInMemHolidayRepository(),
 	}
 }
 
@@ -41,7 +42,7 @@ func TestMapToActivity(t *testing.T) {
 		),
 	}
 
-	activity, err := mapToActivity(activityModel)
+	activity, err := mapToActivity(activityModel, time.UTC)
 
 	is.NoErr(err)
 	is.Equal(activityModel.ID, activity.ID.String())
@@ -50,6 +51,74 @@ func TestMapToActivity(t *testing.T) {
 	is.Equal(2021, activity.End.Year())
 }
 
+func TestMapToActivityInLocation(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	activityModel := &activityModel{
+		Start: "2021-11-06T21:37:00",
+		End:   "2021-11-06T22:37:00+01:00",
+		Links: hal.NewLinks(
+			hal.NewLink("project", "/api/projects/efa45cae-5dc7-412a-887f-945ddbb0a23f"),
+		),
+	}
+
+	activity, err := mapToActivity(activityModel, berlin)
+
+	is.NoErr(err)
+	is.Equal(20, activity.Start.UTC().Hour())
+	is.Equal(21, activity.End.UTC().Hour())
+}
+
+func TestMapToActivitySpanningMidnight(t *testing.T) {
+	is := is.New(t)
+
+	activityModel := &activityModel{
+		Start: "2021-11-06T22:00:00",
+		End:   "2021-11-07T02:00:00",
+		Links: hal.NewLinks(
+			hal.NewLink("project", "/api/projects/efa45cae-5dc7-412a-887f-945ddbb0a23f"),
+		),
+	}
+
+	activity, err := mapToActivity(activityModel, time.UTC)
+
+	is.NoErr(err)
+	is.Equal(240, activity.DurationMinutesTotal())
+}
+
+func TestMapToActivityWithEndBeforeStart(t *testing.T) {
+	is := is.New(t)
+
+	activityModel := &activityModel{
+		Start: "2021-11-06T22:00:00",
+		End:   "2021-11-06T02:00:00",
+		Links: hal.NewLinks(
+			hal.NewLink("project", "/api/projects/efa45cae-5dc7-412a-887f-945ddbb0a23f"),
+		),
+	}
+
+	_, err := mapToActivity(activityModel, time.UTC)
+
+	is.True(err != nil)
+}
+
+func TestMapToActivityTooLong(t *testing.T) {
+	is := is.New(t)
+
+	activityModel := &activityModel{
+		Start: "2021-11-06T22:00:00",
+		End:   "2021-11-07T22:01:00",
+		Links: hal.NewLinks(
+			hal.NewLink("project", "/api/projects/efa45cae-5dc7-412a-887f-945ddbb0a23f"),
+		),
+	}
+
+	_, err := mapToActivity(activityModel, time.UTC)
+
+	is.True(err != nil)
+}
+
 func TestMapToActivityIdNotValid(t *testing.T) {
 	is := is.New(t)
 
@@ -62,7 +131,7 @@ func TestMapToActivityIdNotValid(t *testing.T) {
 		),
 	}
 
-	_, err := mapToActivity(activityModel)
+	_, err := mapToActivity(activityModel, time.UTC)
 
 	is.True(err != nil)
 }
@@ -80,7 +149,7 @@ func TestMapToActivityModel(t *testing.T) {
 		ProjectID: uuid.New(),
 	}
 
-	activityModel := mapToActivityModel(activity)
+	activityModel := mapToActivityModel(activity, time.UTC)
 
 	is.Equal(activity.ID.String(), activityModel.ID)
 	is.True(strings.Contains(activityModel.Links.HrefOf("project"), activity.ProjectID.String()))
@@ -88,6 +157,26 @@ func TestMapToActivityModel(t *testing.T) {
 	is.True(strings.Contains(activityModel.End, "2021"))
 }
 
+func TestMapToActivityModelInLocation(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	start, _ := time.Parse(time.RFC3339, "2021-11-12T11:00:00.000Z")
+	end, _ := time.Parse(time.RFC3339, "2021-11-12T11:30:00.000Z")
+
+	activity := &Activity{
+		ID:        uuid.New(),
+		Start:     start,
+		End:       end,
+		ProjectID: uuid.New(),
+	}
+
+	activityModel := mapToActivityModel(activity, berlin)
+
+	is.Equal("2021-11-12T12:00:00", activityModel.Start)
+	is.Equal("2021-11-12T12:30:00", activityModel.End)
+}
+
 func TestHandleGetActivity(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -280,6 +369,75 @@ func TestHandleCreateActivity(t *testing.T) {
 	is.Equal(countBefore+1, len(repo.activities))
 }
 
+func TestHandleCreateActivityWithComplianceWarnings(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemActivityRepository()
+
+	c := &ActivityRestHandlers{
+		config:             &shared.Config{},
+		activityRepository: repo,
+		actitivityService:  createTestActivityServiceForRest(repo),
+	}
+
+	// 2021-11-07 is a Sunday
+	body := `
+	{
+		"start":"2021-11-07T10:00:00",
+		"end":"2021-11-07T12:00:00",
+		"description":"",
+		"_links":{
+		   "project":{
+			  "href":"http://localhost:8080/api/projects/f4b1087c-8fbb-4c8d-bbb7-ab4d46da16ea"
+		   }
+		}
+	 }
+	`
+
+	r, _ := http.NewRequest("POST", "/api/activities", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	c.HandleCreateActivity()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+
+	activityModel := &activityModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(activityModel)
+	is.NoErr(err)
+	is.Equal(len(activityModel.Warnings), 1)
+	is.Equal(activityModel.Warnings[0].Day, "2021-11-07")
+	is.Equal(activityModel.Warnings[0].Rule, ComplianceRuleSundayWork)
+}
+
+func TestHandleGetComplianceViolations(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemActivityRepository()
+	repo.activities = []*Activity{
+		activityOf(2021, 11, 2, 7, 0, 18, 0),
+	}
+
+	c := &ActivityRestHandlers{
+		config:             &shared.Config{},
+		activityRepository: repo,
+		actitivityService:  createTestActivityServiceForRest(repo),
+	}
+
+	r, _ := http.NewRequest("GET", "/api/compliance?t=week&v=2021-44", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	c.HandleGetComplianceViolations()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	violationsModel := &complianceViolationsModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(violationsModel)
+	is.NoErr(err)
+	is.Equal(len(violationsModel.ComplianceViolationModels), 2)
+	is.Equal(violationsModel.ComplianceViolationModels[0].Rule, ComplianceRuleMaxWorkingTime)
+	is.Equal(violationsModel.ComplianceViolationModels[1].Rule, ComplianceRuleBreak)
+}
+
 func TestHandleCreateInvalidActivity(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -665,7 +823,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params := make(url.Values)
 		params.Add("t", "year")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(time.Now().Year(), filter.Start().Year())
@@ -676,7 +834,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "year")
 		params.Add("sort", "project:asc")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(time.Now().Year(), filter.Start().Year())
@@ -689,7 +847,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "year")
 		params.Add("v", "2021")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2021, filter.Start().Year())
@@ -703,7 +861,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "year")
 		params.Add("v", "XXXX")
 
-		_, err := filterFromQueryParams(params)
+		_, err := filterFromQueryParams(params, time.UTC)
 
 		is.True(err != nil)
 	})
@@ -713,7 +871,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "quarter")
 		params.Add("v", "2021-2")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2021, filter.Start().Year())
@@ -726,7 +884,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "quarter")
 		params.Add("v", "XXXX-9")
 
-		_, err := filterFromQueryParams(params)
+		_, err := filterFromQueryParams(params, time.UTC)
 
 		is.True(err != nil)
 	})
@@ -736,7 +894,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "month")
 		params.Add("v", "2021-11")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2021, filter.Start().Year())
@@ -750,7 +908,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "month")
 		params.Add("v", "2020-99")
 
-		_, err := filterFromQueryParams(params)
+		_, err := filterFromQueryParams(params, time.UTC)
 
 		is.True(err != nil)
 	})
@@ -760,7 +918,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "week")
 		params.Add("v", "2021-1")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2021, filter.Start().Year())
@@ -774,7 +932,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "week")
 		params.Add("v", "2023-10")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2023, filter.Start().Year())
@@ -788,7 +946,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "week")
 		params.Add("v", "2020-ccc")
 
-		_, err := filterFromQueryParams(params)
+		_, err := filterFromQueryParams(params, time.UTC)
 
 		is.True(err != nil)
 	})
@@ -798,7 +956,7 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "month")
 		params.Add("v", "2021-03")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2021, filter.Start().Year())
@@ -810,11 +968,89 @@ func TestFilterFromQueryParams(t *testing.T) {
 		params.Add("t", "day")
 		params.Add("v", "2021-11-10")
 
-		filter, err := filterFromQueryParams(params)
+		filter, err := filterFromQueryParams(params, time.UTC)
 
 		is.NoErr(err)
 		is.Equal(2021, filter.Start().Year())
 		is.Equal(time.November, filter.Start().Month())
 	})
 
+	t.Run("week filter from query params in time zone", func(t *testing.T) {
+		berlin, _ := time.LoadLocation("Europe/Berlin")
+		params := make(url.Values)
+		params.Add("t", "week")
+		params.Add("v", "2023-10")
+
+		filter, err := filterFromQueryParams(params, berlin)
+
+		is.NoErr(err)
+		is.Equal(6, filter.Start().Day())
+		is.Equal(0, filter.Start().Hour())
+		is.Equal(5, filter.Start().UTC().Day())
+		is.Equal(23, filter.Start().UTC().Hour())
+		is.Equal(berlin, filter.Location())
+	})
+
+	t.Run("day filter from query params in time zone", func(t *testing.T) {
+		newYork, _ := time.LoadLocation("America/New_York")
+		params := make(url.Values)
+		params.Add("t", "day")
+		params.Add("v", "2021-11-10")
+
+		filter, err := filterFromQueryParams(params, newYork)
+
+		is.NoErr(err)
+		is.Equal(5, filter.Start().UTC().Hour())
+		is.Equal(5, filter.End().UTC().Hour())
+		is.Equal(11, filter.End().Day())
+	})
+}
+
+func TestActivityRoutesWithScopes(t *testing.T) {
+	is := is.New(t)
+
+	a := &ActivityRestHandlers{
+		config:             &shared.Config{},
+		activityRepository: NewInMemActivityRepository(),
+	}
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+				Roles:  []string{"ROLE_ADMIN"},
+				Scopes: []string{shared.ScopeActivitiesRead},
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	a.RegisterScoped(router)
+
+	t.Run("read with read scope", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/activities/00000000-0000-0000-2222-000000000001", nil)
+
+		router.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("delete without write scope", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/activities/00000000-0000-0000-2222-000000000001", nil)
+
+		router.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+		is.True(strings.Contains(httpRec.Body.String(), shared.ScopeActivitiesWrite))
+	})
+
+	t.Run("compliance without reports scope", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/compliance", nil)
+
+		router.ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+	})
 }
@@ -5,8 +5,10 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/baralga/shared/paged"
@@ -19,31 +21,85 @@ type ActitivityService struct {
 	activityRepository ActivityRepository
 	tagRepository      TagRepository
 	tagService         *TagService
+	holidayRepository  HolidayRepository
+	teamsReader        func(ctx context.Context, principal *shared.Principal) ([]*shared.TeamMembers, error)
+	settingsReader     func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error)
 }
 
-func NewActitivityService(repositoryTxer shared.RepositoryTxer, activityRepository ActivityRepository, tagRepository TagRepository, tagService *TagService) *ActitivityService {
+func NewActitivityService(repositoryTxer shared.RepositoryTxer, activityRepository ActivityRepository, tagRepository TagRepository, tagService *TagService, holidayRepository HolidayRepository) *ActitivityService {
 	return &ActitivityService{
 		repositoryTxer:     repositoryTxer,
 		activityRepository: activityRepository,
 		tagRepository:      tagRepository,
 		tagService:         tagService,
+		holidayRepository:  holidayRepository,
 	}
 }
 
-// ReadActivitiesWithProjects reads activities with their associated projects
+// SetTeamsReader sets the reader for the teams whose activities a principal may see
+func (a *ActitivityService) SetTeamsReader(teamsReader func(ctx context.Context, principal *shared.Principal) ([]*shared.TeamMembers, error)) {
+	a.teamsReader = teamsReader
+}
+
+// ReadTeams reads the teams whose activities the principal may see, none if there are no teams
+func (a *ActitivityService) ReadTeams(ctx context.Context, principal *shared.Principal) ([]*shared.TeamMembers, error) {
+	if a.teamsReader == nil {
+		return nil, nil
+	}
+
+	return a.teamsReader(ctx, principal)
+}
+
+// SetOrganizationSettingsReader sets the reader for the settings of an organization like its date format
+func (a *ActitivityService) SetOrganizationSettingsReader(settingsReader func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error)) {
+	a.settingsReader = settingsReader
+}
+
+// ReadOrganizationSettings reads the settings of the organization of the principal
+func (a *ActitivityService) ReadOrganizationSettings(ctx context.Context, principal *shared.Principal) (*shared.OrganizationSettings, error) {
+	return readOrganizationSettings(ctx, a.settingsReader, principal)
+}
+
+// readOrganizationSettings reads the settings of the organization of the principal with the reader, without
+// a reader weeks start on Monday and every day is a working day
+func readOrganizationSettings(ctx context.Context, settingsReader func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error), principal *shared.Principal) (*shared.OrganizationSettings, error) {
+	if settingsReader == nil {
+		return &shared.OrganizationSettings{
+			WeekStart:   time.Monday,
+			WorkingDays: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+			DateFormat:  shared.DateFormatGerman,
+		}, nil
+	}
+
+	return settingsReader(ctx, principal.OrganizationID)
+}
+
+// ReadActivitiesWithProjects reads activities with their associated projects in the time zone of the filter
 func (a *ActitivityService) ReadActivitiesWithProjects(ctx context.Context, principal *shared.Principal, filter *ActivityFilter, pageParams *paged.PageParams) (*ActivitiesPaged, []*Project, error) {
-	activitiesFilter := toFilter(principal, filter)
+	activitiesFilter, err := a.toFilter(ctx, principal, filter)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	activitiesPage, projects, err := a.activityRepository.FindActivities(ctx, activitiesFilter, pageParams)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	location := filter.Location()
+	for _, activity := range activitiesPage.Activities {
+		activity.Start = activity.Start.In(location)
+		activity.End = activity.End.In(location)
+	}
+
 	return activitiesPage, projects, err
 }
 
 func (a *ActitivityService) TimeReports(ctx context.Context, principal *shared.Principal, filter *ActivityFilter, aggregateBy string) ([]*ActivityTimeReportItem, error) {
-	activitiesFilter := toFilter(principal, filter)
+	activitiesFilter, err := a.toFilter(ctx, principal, filter)
+	if err != nil {
+		return nil, err
+	}
 
 	switch aggregateBy {
 	case "week":
@@ -60,7 +116,11 @@ func (a *ActitivityService) TimeReports(ctx context.Context, principal *shared.P
 }
 
 func (a *ActitivityService) ProjectReports(ctx context.Context, principal *shared.Principal, filter *ActivityFilter) ([]*ActivityProjectReportItem, error) {
-	activitiesFilter := toFilter(principal, filter)
+	activitiesFilter, err := a.toFilter(ctx, principal, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	return a.activityRepository.ProjectReport(ctx, activitiesFilter)
 }
 
@@ -110,7 +170,7 @@ func (a *ActitivityService) CreateActivity(ctx context.Context, principal *share
 
 // DeleteActivityByID deletes an activity
 func (a *ActitivityService) DeleteActivityByID(ctx context.Context, principal *shared.Principal, activityID uuid.UUID) error {
-	if principal.HasRole("ROLE_ADMIN") {
+	if principal.HasPermission(shared.PermissionActivitiesWriteAll) {
 		return a.repositoryTxer.InTx(
 			ctx,
 			func(ctx context.Context) error {
@@ -143,7 +203,7 @@ func (a *ActitivityService) UpdateActivity(ctx context.Context, principal *share
 	activity.Tags = tagsWithColors
 
 	var activityUpdate *Activity
-	if principal.HasRole("ROLE_ADMIN") {
+	if principal.HasPermission(shared.PermissionActivitiesWriteAll) {
 		err := a.repositoryTxer.InTx(
 			ctx,
 			func(ctx context.Context) error {
@@ -190,6 +250,78 @@ func (a *ActitivityService) UpdateActivity(ctx context.Context, principal *share
 	return activityUpdate, nil
 }
 
+// ComplianceViolations checks the activities of the principal in the filter's timespan
+// against the german working time law, the compliance check is personal also for admins
+func (a *ActitivityService) ComplianceViolations(ctx context.Context, principal *shared.Principal, filter *ActivityFilter) ([]*ComplianceViolation, error) {
+	location := filter.Location()
+	start := startOfDay(filter.Start().In(location))
+	end := filter.End().In(location)
+
+	violations, err := a.complianceViolations(ctx, principal.OrganizationID, principal.Username, start, end, location)
+	if err != nil {
+		return nil, err
+	}
+
+	// latest days first like in the time reports
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Day.After(violations[j].Day) })
+	return violations, nil
+}
+
+// ComplianceViolationsOfActivity checks the days of a saved activity and the rest period until
+// the following day against the german working time law, the warnings are for the owner of the activity
+func (a *ActitivityService) ComplianceViolationsOfActivity(ctx context.Context, principal *shared.Principal, activity *Activity) ([]*ComplianceViolation, error) {
+	username := principal.Username
+	if principal.HasPermission(shared.PermissionActivitiesWriteAll) {
+		savedActivity, err := a.activityRepository.FindActivityByID(ctx, activity.ID, principal.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		username = savedActivity.Username
+	}
+
+	location := principal.Location()
+	start := startOfDay(activity.Start.In(location))
+	end := startOfDay(activity.End.In(location)).AddDate(0, 0, 2)
+
+	return a.complianceViolations(ctx, principal.OrganizationID, username, start, end, location)
+}
+
+// complianceViolations checks the activities of a user from start to end, the day before the
+// start is read as well to check the rest period of the first day
+func (a *ActitivityService) complianceViolations(ctx context.Context, organizationID uuid.UUID, username string, start, end time.Time, location *time.Location) ([]*ComplianceViolation, error) {
+	activitiesFilter := &ActivitiesFilter{
+		Start:          start.AddDate(0, 0, -1),
+		End:            end,
+		OrganizationID: organizationID,
+		Username:       username,
+	}
+
+	pageParams := &paged.PageParams{
+		Page: 0,
+		Size: 1000,
+	}
+
+	activitiesPage, _, err := a.activityRepository.FindActivities(ctx, activitiesFilter, pageParams)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := a.holidayRepository.FindHolidays(ctx, organizationID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []*ComplianceViolation
+	for _, violation := range CheckCompliance(activitiesPage.Activities, holidays, location) {
+		day := time.Date(violation.Day.Year(), violation.Day.Month(), violation.Day.Day(), 0, 0, 0, 0, location)
+		if day.Before(start) || !day.Before(end) {
+			continue
+		}
+		violations = append(violations, violation)
+	}
+	return violations, nil
+}
+
 func (a *ActitivityService) WriteAsCSV(activities []*Activity, projects []*Project, w io.Writer) error {
 	csvWriter := csv.NewWriter(w)
 	csvWriter.Comma = ';'
@@ -315,17 +447,28 @@ func (a *ActitivityService) GetTagsForAutocomplete(ctx context.Context, principa
 
 // GenerateTagReports generates comprehensive tag-based reports with time breakdowns
 func (a *ActitivityService) GenerateTagReports(ctx context.Context, principal *shared.Principal, filter *ActivityFilter, aggregateBy string, selectedTags []string) (*TagReportData, error) {
-	activitiesFilter := toFilter(principal, filter)
+	activitiesFilter, err := a.toFilter(ctx, principal, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	return a.tagService.GenerateTagReports(ctx, principal.OrganizationID, activitiesFilter, aggregateBy, selectedTags)
 }
 
 // GetTagReportData retrieves filtered tag report data for specific date ranges and tag selections
 func (a *ActitivityService) GetTagReportData(ctx context.Context, principal *shared.Principal, filter *ActivityFilter, aggregateBy string) ([]*TagReportItem, error) {
-	activitiesFilter := toFilter(principal, filter)
+	activitiesFilter, err := a.toFilter(ctx, principal, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	return a.tagService.GetTagReportData(ctx, activitiesFilter, aggregateBy)
 }
 
-func toFilter(principal *shared.Principal, filter *ActivityFilter) *ActivitiesFilter {
+// toFilter restricts the filter to the activities the principal may see. Admins see all activities
+// of the organization, team leads the activities of the members of their teams and everybody else
+// only the own activities. A team filter for a team the principal may not see is ignored.
+func (a *ActitivityService) toFilter(ctx context.Context, principal *shared.Principal, filter *ActivityFilter) (*ActivitiesFilter, error) {
 	activitiesFilter := &ActivitiesFilter{
 		Start:          filter.Start(),
 		End:            filter.End(),
@@ -334,9 +477,23 @@ func toFilter(principal *shared.Principal, filter *ActivityFilter) *ActivitiesFi
 		OrganizationID: principal.OrganizationID,
 	}
 
-	if !principal.HasRole("ROLE_ADMIN") {
+	if filter.Team() != uuid.Nil {
+		teams, err := a.ReadTeams(ctx, principal)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, team := range teams {
+			if team.TeamID == filter.Team() {
+				activitiesFilter.Usernames = append([]string{}, team.Usernames...)
+				return activitiesFilter, nil
+			}
+		}
+	}
+
+	if !principal.HasPermission(shared.PermissionActivitiesReadAll) {
 		activitiesFilter.Username = principal.Username
 	}
 
-	return activitiesFilter
+	return activitiesFilter, nil
 }
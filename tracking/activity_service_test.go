@@ -515,3 +515,159 @@ func TestActivityFilter_WithTags(t *testing.T) {
 	is.Equal(filterWithTags.Start(), start)
 	is.Equal(filterWithTags.End(), end)
 }
+
+func TestComplianceViolations(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	activityRepository := NewInMemActivityRepository()
+	activityRepository.activities = []*Activity{
+		// Sunday before the filter is not reported
+		activityOf(2021, 10, 31, 10, 0, 12, 0),
+		// short rest between Sunday and Monday is reported on Monday
+		activityOf(2021, 10, 31, 20, 0, 23, 0),
+		activityOf(2021, 11, 1, 6, 0, 12, 0),
+		activityOf(2021, 11, 14, 10, 0, 12, 0),
+	}
+
+	a := &ActitivityService{
+		activityRepository: activityRepository,
+		holidayRepository:  NewInMemHolidayRepository(),
+	}
+
+	principal := &shared.Principal{}
+	filter := &ActivityFilter{
+		Timespan: TimespanMonth,
+		start:    time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+		end:      time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	violations, err := a.ComplianceViolations(context.Background(), principal, filter)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(violations), 2)
+
+	// latest violation first
+	is.Equal(violations[0].Day, time.Date(2021, 11, 14, 0, 0, 0, 0, time.UTC))
+	is.Equal(violations[0].Rule, ComplianceRuleSundayWork)
+	is.Equal(violations[1].Day, time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC))
+	is.Equal(violations[1].Rule, ComplianceRuleRestPeriod)
+}
+
+func TestComplianceViolationsOfActivity(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	activity := activityOf(2021, 11, 2, 20, 0, 23, 0)
+	activityRepository := NewInMemActivityRepository()
+	activityRepository.activities = []*Activity{
+		activityOf(2021, 11, 1, 7, 0, 19, 0),
+		activity,
+		activityOf(2021, 11, 3, 6, 0, 8, 0),
+		activityOf(2021, 11, 7, 10, 0, 12, 0),
+	}
+
+	a := &ActitivityService{
+		activityRepository: activityRepository,
+		holidayRepository:  NewInMemHolidayRepository(),
+	}
+
+	// Act
+	violations, err := a.ComplianceViolationsOfActivity(context.Background(), &shared.Principal{}, activity)
+
+	// Assert
+	is.NoErr(err)
+
+	// violations of the day before and the sunday after are not part of the warnings
+	is.Equal(len(violations), 1)
+	is.Equal(violations[0].Rule, ComplianceRuleRestPeriod)
+	is.Equal(violations[0].Day, time.Date(2021, 11, 3, 0, 0, 0, 0, time.UTC))
+}
+
+func TestActivityService_FilterByTeam(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	teamID := uuid.New()
+	a := &ActitivityService{}
+	a.SetTeamsReader(func(ctx context.Context, principal *shared.Principal) ([]*shared.TeamMembers, error) {
+		if principal.Username != "lead@baralga.com" {
+			return nil, nil
+		}
+
+		return []*shared.TeamMembers{
+			{
+				TeamID:    teamID,
+				Name:      "Backend",
+				Usernames: []string{"lead@baralga.com", "user1@baralga.com"},
+			},
+		}, nil
+	})
+
+	lead := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "lead@baralga.com",
+		Roles:          []string{"ROLE_USER"},
+	}
+	user := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1@baralga.com",
+		Roles:          []string{"ROLE_USER"},
+	}
+	start, _ := time.Parse(time.RFC3339, "2021-01-01T10:00:00.000Z")
+	end, _ := time.Parse(time.RFC3339, "2021-01-01T11:00:00.000Z")
+
+	filter := (&ActivityFilter{
+		Timespan: TimespanCustom,
+		start:    start,
+		end:      end,
+	}).WithTeam(teamID)
+
+	// Act
+	leadFilter, err := a.toFilter(context.Background(), lead, filter)
+	is.NoErr(err)
+
+	userFilter, err := a.toFilter(context.Background(), user, filter)
+	is.NoErr(err)
+
+	// Assert
+	is.Equal(leadFilter.Username, "")
+	is.Equal(leadFilter.Usernames, []string{"lead@baralga.com", "user1@baralga.com"})
+
+	is.Equal(userFilter.Username, "user1@baralga.com")
+	is.True(userFilter.Usernames == nil)
+}
+
+func TestActivityService_FilterWithPermissions(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	a := &ActitivityService{}
+
+	start, _ := time.Parse(time.RFC3339, "2021-01-01T10:00:00.000Z")
+	end, _ := time.Parse(time.RFC3339, "2021-01-01T11:00:00.000Z")
+
+	filter := &ActivityFilter{
+		Timespan: TimespanCustom,
+		start:    start,
+		end:      end,
+	}
+
+	// Act
+	managerFilter, err := a.toFilter(context.Background(), &shared.Principal{
+		Username: "manager@baralga.com",
+		Roles:    []string{"ROLE_MANAGER"},
+	}, filter)
+	is.NoErr(err)
+
+	userFilter, err := a.toFilter(context.Background(), &shared.Principal{
+		Username: "user1@baralga.com",
+		Roles:    []string{"ROLE_USER"},
+	}, filter)
+	is.NoErr(err)
+
+	// Assert
+	is.Equal(managerFilter.Username, "")
+	is.Equal(userFilter.Username, "user1@baralga.com")
+}
@@ -29,6 +29,7 @@ type activityFormModel struct {
 	ProjectID   string `validate:"required"`
 	Date        string `validate:"required"`
 	StartTime   string `validate:"required,min=5,max=5"`
+	EndDate     string // optional, defaults to Date
 	EndTime     string `validate:"required,min=5,max=5"`
 	Description string `validate:"min=0,max=500"`
 	Tags        string `validate:"max=1000"` // comma-separated tag string
@@ -46,6 +47,8 @@ type activityTrackFormModel struct {
 	StartTime    string
 	Description  string
 	Tags         string `validate:"max=1000"` // comma-separated tag string
+
+	Warnings []*ComplianceViolation `schema:"-"`
 }
 
 type ActivityWebHandlers struct {
@@ -53,14 +56,16 @@ type ActivityWebHandlers struct {
 	activityService    *ActitivityService
 	activityRepository ActivityRepository
 	projectRepository  ProjectRepository
+	absenceService     *AbsenceService
 }
 
-func NewActivityWebHandlers(config *shared.Config, activityService *ActitivityService, activityRepository ActivityRepository, projectRepository ProjectRepository) *ActivityWebHandlers {
+func NewActivityWebHandlers(config *shared.Config, activityService *ActitivityService, activityRepository ActivityRepository, projectRepository ProjectRepository, absenceService *AbsenceService) *ActivityWebHandlers {
 	return &ActivityWebHandlers{
 		config:             config,
 		activityService:    activityService,
 		activityRepository: activityRepository,
 		projectRepository:  projectRepository,
+		absenceService:     absenceService,
 	}
 }
 
@@ -78,11 +83,12 @@ func (a *ActivityWebHandlers) RegisterProtected(r chi.Router) {
 func (a *ActivityWebHandlers) RegisterOpen(r chi.Router) {
 }
 
-func newActivityFormModel() activityFormModel {
-	now := time.Now()
+func newActivityFormModel(location *time.Location) activityFormModel {
+	now := time.Now().In(location)
 	return activityFormModel{
 		Date:      time_utils.FormatDateDE(now),
 		StartTime: time_utils.FormatTime(now),
+		EndDate:   time_utils.FormatDateDE(now),
 		EndTime:   time_utils.FormatTime(now),
 	}
 }
@@ -91,12 +97,26 @@ func (a *ActivityWebHandlers) HandleTrackingPage() http.HandlerFunc {
 	isProduction := a.config.IsProduction()
 	activityService := a.activityService
 	projectRepository := a.projectRepository
+	absenceService := a.absenceService
 	return func(w http.ResponseWriter, r *http.Request) {
-		now := time.Now()
+		principal := shared.MustPrincipalFromContext(r.Context())
+		location := principal.Location()
+
+		now := time.Now().In(location)
 		wyear, week := isoweek.FromDate(now.Year(), now.Month(), now.Day())
 		filter := &ActivityFilter{
 			Timespan: TimespanWeek,
-			start:    isoweek.StartTime(wyear, week, time.UTC),
+			start:    isoweek.StartTime(wyear, week, location),
+			location: location,
+		}
+
+		if teamParam := r.URL.Query().Get("team"); teamParam != "" {
+			teamID, err := uuid.Parse(teamParam)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter = filter.WithTeam(teamID)
 		}
 
 		pageParams := &paged.PageParams{
@@ -104,7 +124,6 @@ func (a *ActivityWebHandlers) HandleTrackingPage() http.HandlerFunc {
 			Size: 100,
 		}
 
-		principal := shared.MustPrincipalFromContext(r.Context())
 		activitiesPage, projectsOfActivities, err := activityService.ReadActivitiesWithProjects(
 			r.Context(),
 			principal,
@@ -122,8 +141,20 @@ func (a *ActivityWebHandlers) HandleTrackingPage() http.HandlerFunc {
 			return
 		}
 
+		absences, err := absenceService.ReadAbsences(r.Context(), principal, filter)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		teams, err := activityService.ReadTeams(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
 		if hx.IsHXTargetRequest(r, "baralga__main_content") {
-			shared.RenderHTML(w, Div(ActivitiesInWeekView(filter, activitiesPage, projectsOfActivities)))
+			shared.RenderHTML(w, Div(ActivitiesInWeekView(principal, filter, activitiesPage, projectsOfActivities, absences, teams)))
 			return
 		}
 
@@ -135,7 +166,7 @@ func (a *ActivityWebHandlers) HandleTrackingPage() http.HandlerFunc {
 		formModel := activityTrackFormModel{Action: "start"}
 		formModel.CSRFToken = csrf.Token(r)
 
-		shared.RenderHTML(w, TrackingPage(pageContext, formModel, filter, activitiesPage, projectsOfActivities, projects))
+		shared.RenderHTML(w, TrackingPage(pageContext, formModel, filter, activitiesPage, projectsOfActivities, projects, absences, teams))
 	}
 }
 
@@ -161,7 +192,7 @@ func (a *ActivityWebHandlers) HandleActivityAddPage() http.HandlerFunc {
 			CurrentPath: r.URL.Path,
 			Title:       "Add Activity",
 		}
-		activityFormModel := newActivityFormModel()
+		activityFormModel := newActivityFormModel(principal.Location())
 		activityFormModel.CSRFToken = csrf.Token(r)
 
 		if !hx.IsHXRequest(r) {
@@ -213,7 +244,7 @@ func (a *ActivityWebHandlers) HandleActivityEditPage() http.HandlerFunc {
 			CurrentPath: r.URL.Path,
 			Title:       "Edit Activity",
 		}
-		formModel := mapActivityToForm(*activity)
+		formModel := mapActivityToForm(*activity, principal.Location())
 
 		if !hx.IsHXRequest(r) {
 			formModel.CSRFToken = csrf.Token(r)
@@ -270,7 +301,7 @@ func (a *ActivityWebHandlers) HandleActivityTrackForm() http.HandlerFunc {
 				return
 			}
 
-			now := time.Now()
+			now := time.Now().In(principal.Location())
 			formModel.Action = "running"
 			formModel.Date = time_utils.FormatDateDE(now)
 			formModel.StartTime = time_utils.FormatTime(now)
@@ -294,15 +325,17 @@ func (a *ActivityWebHandlers) HandleActivityTrackForm() http.HandlerFunc {
 				},
 			}
 
+			now := time.Now().In(principal.Location())
 			activityFormModel := activityFormModel{
 				Date:        formModel.Date,
 				StartTime:   formModel.StartTime,
-				EndTime:     time_utils.FormatTime(time.Now()),
+				EndDate:     time_utils.FormatDateDE(now),
+				EndTime:     time_utils.FormatTime(now),
 				ProjectID:   formModel.ProjectID,
 				Description: formModel.Description,
 				Tags:        formModel.Tags,
 			}
-			activityToCreate, _ := mapFormToActivity(activityFormModel)
+			activityToCreate, _ := mapFormToActivity(activityFormModel, principal.Location())
 			formModel.Duration = activityToCreate.DurationFormatted()
 
 			if actionParam == "reload" {
@@ -310,7 +343,13 @@ func (a *ActivityWebHandlers) HandleActivityTrackForm() http.HandlerFunc {
 				return
 			}
 
-			_, err := activityService.CreateActivity(r.Context(), principal, activityToCreate)
+			activityCreated, err := activityService.CreateActivity(r.Context(), principal, activityToCreate)
+			if err != nil {
+				shared.RenderProblemHTML(w, isProduction, err)
+				return
+			}
+
+			violations, err := activityService.ComplianceViolationsOfActivity(r.Context(), principal, activityCreated)
 			if err != nil {
 				shared.RenderProblemHTML(w, isProduction, err)
 				return
@@ -325,7 +364,7 @@ func (a *ActivityWebHandlers) HandleActivityTrackForm() http.HandlerFunc {
 			}
 			projects = projectsPage.Projects
 
-			formModel = activityTrackFormModel{Action: "start"}
+			formModel = activityTrackFormModel{Action: "start", Warnings: violations}
 			formModel.CSRFToken = csrf.Token(r)
 
 			shared.RenderHTML(w, TrackPanel(projects, formModel))
@@ -348,6 +387,7 @@ func (a *ActivityWebHandlers) HandleActivityForm() http.HandlerFunc {
 				principal,
 				isProduction,
 				activityFormModel{},
+				"",
 			)
 			return
 		}
@@ -361,6 +401,7 @@ func (a *ActivityWebHandlers) HandleActivityForm() http.HandlerFunc {
 				principal,
 				isProduction,
 				activityFormModel{},
+				"",
 			)
 			return
 		}
@@ -373,11 +414,12 @@ func (a *ActivityWebHandlers) HandleActivityForm() http.HandlerFunc {
 				principal,
 				isProduction,
 				formModel,
+				"",
 			)
 			return
 		}
 
-		activityNew, err := mapFormToActivity(formModel)
+		activityNew, err := mapFormToActivity(formModel, principal.Location())
 		if err != nil {
 			a.renderActivityAddView(
 				w,
@@ -385,20 +427,58 @@ func (a *ActivityWebHandlers) HandleActivityForm() http.HandlerFunc {
 				principal,
 				isProduction,
 				formModel,
+				"",
 			)
 			return
 		}
 
+		if activityNew.End.Before(activityNew.Start) {
+			a.renderActivityAddView(
+				w,
+				r,
+				principal,
+				isProduction,
+				formModel,
+				"The activity must end after it starts.",
+			)
+			return
+		}
+
+		if activityNew.IsTooLong() {
+			a.renderActivityAddView(
+				w,
+				r,
+				principal,
+				isProduction,
+				formModel,
+				fmt.Sprintf("The activity must not last longer than %v hours.", MaxActivityDuration.Hours()),
+			)
+			return
+		}
+
+		var activitySaved *Activity
 		if uuid.Nil == activityNew.ID {
-			_, err = activityService.CreateActivity(r.Context(), principal, activityNew)
+			activitySaved, err = activityService.CreateActivity(r.Context(), principal, activityNew)
 		} else {
-			_, err = activityService.UpdateActivity(r.Context(), principal, activityNew)
+			activitySaved, err = activityService.UpdateActivity(r.Context(), principal, activityNew)
 		}
 		if err != nil {
 			shared.RenderProblemHTML(w, isProduction, err)
 			return
 		}
 
+		violations, err := activityService.ComplianceViolationsOfActivity(r.Context(), principal, activitySaved)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if len(violations) > 0 {
+			w.Header().Set("HX-Trigger", "baralga__activities-changed")
+			shared.RenderHTML(w, ActivityComplianceWarningsView(violations))
+			return
+		}
+
 		w.Header().Set("HX-Trigger", "{ \"baralga__activities-changed\": true, \"baralga__main_content_modal-hide\": true } ")
 	}
 }
@@ -436,12 +516,25 @@ func (a *ActivityWebHandlers) HandleEndTimeValidation() http.HandlerFunc {
 		}
 
 		formModel.EndTime = time_utils.CompleteTimeValue(formModel.EndTime)
+		formModel.StartTime = time_utils.CompleteTimeValue(formModel.StartTime)
+
+		if formModel.EndDate == "" {
+			formModel.EndDate = formModel.Date
+		}
+
+		// an end before the start on the same day ends on the next day, e.g. a night shift
+		if formModel.EndDate == formModel.Date && formModel.EndTime < formModel.StartTime {
+			date, err := time_utils.ParseDateDE(formModel.Date)
+			if err == nil {
+				formModel.EndDate = time_utils.FormatDateDE(date.AddDate(0, 0, 1))
+			}
+		}
 
 		shared.RenderHTML(w, EndTimeInputView(formModel))
 	}
 }
 
-func TrackingPage(pageContext *shared.PageContext, formModel activityTrackFormModel, filter *ActivityFilter, activitiesPage *ActivitiesPaged, projectsOfActivities []*Project, projects *ProjectsPaged) g.Node {
+func TrackingPage(pageContext *shared.PageContext, formModel activityTrackFormModel, filter *ActivityFilter, activitiesPage *ActivitiesPaged, projectsOfActivities []*Project, projects *ProjectsPaged, absences []*Absence, teams []*shared.TeamMembers) g.Node {
 	return shared.Page(
 		"Track Activities",
 		pageContext.CurrentPath,
@@ -460,11 +553,20 @@ func TrackingPage(pageContext *shared.PageContext, formModel activityTrackFormMo
 
 						ghx.Trigger("baralga__activities-changed from:body"),
 						ghx.Get("/"),
+						// keep the selected team when reloading the activities
+						ghx.Include("#baralga__team_filter"),
 
-						ActivitiesInWeekView(filter, activitiesPage, projectsOfActivities),
+						ActivitiesInWeekView(pageContext.Principal, filter, activitiesPage, projectsOfActivities, absences, teams),
 					),
 					Div(Class("col-lg-4 col-sm-12 order-1 order-lg-2 mt-lg-4 mt-2"),
 						TrackPanel(projects.Projects, formModel),
+						Div(
+							ID("baralga__working_time_balance"),
+							ghx.Target("this"),
+							ghx.Swap("innerHTML"),
+							ghx.Trigger("load, baralga__activities-changed from:body"),
+							ghx.Get("/working-time/balance"),
+						),
 					),
 				),
 			),
@@ -473,7 +575,7 @@ func TrackingPage(pageContext *shared.PageContext, formModel activityTrackFormMo
 	)
 }
 
-func ActivitiesInWeekView(filter *ActivityFilter, activitiesPage *ActivitiesPaged, projects []*Project) g.Node {
+func ActivitiesInWeekView(principal *shared.Principal, filter *ActivityFilter, activitiesPage *ActivitiesPaged, projects []*Project, absences []*Absence, teams []*shared.TeamMembers) g.Node {
 	// prepare projects
 	projectsById := make(map[uuid.UUID]*Project)
 	for _, project := range projects {
@@ -481,10 +583,17 @@ func ActivitiesInWeekView(filter *ActivityFilter, activitiesPage *ActivitiesPage
 	}
 
 	var durationWeekTotal float64
-	for _, activity := range activitiesPage.Activities {
+	for _, activity := range activityPartsInTimespan(filter, activitiesPage.Activities) {
 		durationWeekTotal = durationWeekTotal + float64(activity.DurationMinutesTotal())
 	}
 
+	weekTitle := "My Week "
+	for _, team := range teams {
+		if team.TeamID == filter.Team() {
+			weekTitle = fmt.Sprintf("Week of %v ", team.Name)
+		}
+	}
+
 	nodes := []g.Node{
 		Div(
 			Class("mb-2 d-flex"),
@@ -507,7 +616,7 @@ func ActivitiesInWeekView(filter *ActivityFilter, activitiesPage *ActivitiesPage
 					Small(
 						StyleAttr("white-space: nowrap;"),
 						Class("text-muted"),
-						g.Text("My Week "),
+						g.Text(weekTitle),
 						g.If(len(activitiesPage.Activities) > 0,
 							Span(
 								Class("badge rounded-pill bg-secondary fw-normal"),
@@ -517,6 +626,21 @@ func ActivitiesInWeekView(filter *ActivityFilter, activitiesPage *ActivitiesPage
 					),
 				),
 			),
+			g.If(len(teams) > 0,
+				Div(
+					TeamFilterView(principal, filter, teams),
+				),
+			),
+			Div(
+				A(
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					ghx.Get("/absences"),
+					Class("btn btn-outline-primary btn-sm ms-1"),
+					I(Class("bi-calendar-x")),
+					TitleAttr("Manage Absences"),
+				),
+			),
 			Div(
 				A(
 					ghx.Target("#baralga__main_content_modal_content"),
@@ -540,7 +664,8 @@ func ActivitiesInWeekView(filter *ActivityFilter, activitiesPage *ActivitiesPage
 				),
 			),
 		),
-		ActivitiesSumByDayView(activitiesPage, projects),
+		AbsencesInWeekView(absences),
+		ActivitiesSumByDayView(principal, filter, activitiesPage, projects),
 		g.If(
 			len(activitiesPage.Activities) == 0,
 			Div(
@@ -562,7 +687,39 @@ func ActivitiesInWeekView(filter *ActivityFilter, activitiesPage *ActivitiesPage
 	return g.Group(nodes)
 }
 
-func ActivitiesSumByDayView(activitiesPage *ActivitiesPaged, projects []*Project) g.Node {
+// TeamFilterView selects the team whose activities are shown
+func TeamFilterView(principal *shared.Principal, filter *ActivityFilter, teams []*shared.TeamMembers) g.Node {
+	noTeamTitle := "My Activities"
+	if principal.HasPermission(shared.PermissionActivitiesReadAll) {
+		noTeamTitle = "All Activities"
+	}
+
+	return Select(
+		ID("baralga__team_filter"),
+		ghx.Get("/"),
+		ghx.PushURL("true"),
+		ghx.Target("#baralga__main_content"),
+		ghx.Swap("innerHTML"),
+
+		Name("team"),
+		Class("form-select form-select-sm"),
+		TitleAttr("Team"),
+		Option(
+			Value(""),
+			g.Text(noTeamTitle),
+			g.If(filter.Team() == uuid.Nil, Selected()),
+		),
+		g.Group(g.Map(teams, func(team *shared.TeamMembers) g.Node {
+			return Option(
+				Value(team.TeamID.String()),
+				g.Text(fmt.Sprintf("Team %v", team.Name)),
+				g.If(filter.Team() == team.TeamID, Selected()),
+			)
+		})),
+	)
+}
+
+func ActivitiesSumByDayView(principal *shared.Principal, filter *ActivityFilter, activitiesPage *ActivitiesPaged, projects []*Project) g.Node {
 	// prepare projects
 	projectsById := make(map[uuid.UUID]*Project)
 	for _, project := range projects {
@@ -573,7 +730,7 @@ func ActivitiesSumByDayView(activitiesPage *ActivitiesPaged, projects []*Project
 	activitySumByDay := make(map[int]float64)
 	activitiesByDay := make(map[int][]*Activity)
 	dayFormattedByDay := make(map[int][]string)
-	for _, activity := range activitiesPage.Activities {
+	for _, activity := range activityPartsInTimespan(filter, activitiesPage.Activities) {
 		day := activity.Start.Day()
 		dayFormattedByDay[day] = []string{
 			activity.Start.Format("Monday"),
@@ -591,7 +748,7 @@ func ActivitiesSumByDayView(activitiesPage *ActivitiesPaged, projects []*Project
 
 	sort.Slice(dayNodes, func(i, j int) bool { return dayNodes[i] > dayNodes[j] })
 
-	today := time.Now().Day()
+	today := time.Now().In(filter.Location()).Day()
 
 	return g.Group(g.Map(dayNodes, func(i int) g.Node {
 		activities := activitiesByDay[i]
@@ -653,7 +810,8 @@ func ActivitiesSumByDayView(activitiesPage *ActivitiesPaged, projects []*Project
 								Class("flex-fill text-end pe-3"),
 								g.Text(activity.DurationFormatted()),
 							),
-							Div(
+							// team leads see the activities of their members but may not edit them
+							g.If(principal.HasPermission(shared.PermissionActivitiesWriteAll) || activity.Username == principal.Username, Div(
 								A(
 									ghx.Get(fmt.Sprintf("/activities/%v/edit", activity.ID)),
 									ghx.Target("#baralga__main_content_modal_content"),
@@ -674,6 +832,17 @@ func ActivitiesSumByDayView(activitiesPage *ActivitiesPaged, projects []*Project
 									Class("btn btn-outline-secondary btn-sm ms-1"),
 									I(Class("bi-trash2")),
 								),
+							)),
+						),
+						// User row (if activity of somebody else)
+						g.If(activity.Username != principal.Username,
+							Div(
+								Class("mb-1"),
+								Small(
+									Class("text-muted"),
+									I(Class("bi-person me-1")),
+									g.Text(activity.Username),
+								),
 							),
 						),
 						// Description row (if exists)
@@ -706,6 +875,20 @@ func ActivitiesSumByDayView(activitiesPage *ActivitiesPaged, projects []*Project
 	}))
 }
 
+// activityPartsInTimespan splits activities spanning midnight into parts per day within the filter's timespan
+func activityPartsInTimespan(filter *ActivityFilter, activities []*Activity) []*Activity {
+	var parts []*Activity
+	for _, activity := range activities {
+		for _, part := range activity.SplitByDay() {
+			if part.Start.Before(filter.Start()) || !part.Start.Before(filter.End()) {
+				continue
+			}
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
 func ActivityAddPage(pageContext *shared.PageContext, activityFormModel activityFormModel, projects *ProjectsPaged) g.Node {
 	return shared.Page(
 		pageContext.Title,
@@ -755,25 +938,50 @@ func StartTimeInputView(formModel activityFormModel) g.Node {
 func EndTimeInputView(formModel activityFormModel) g.Node {
 	return Div(
 		ID("activity_end_time"),
-		Class("mb-3"),
-		Label(
-			Class("form-label"),
-			g.Attr("for", "EndTime"),
-			g.Text("End Time"),
+		Class("row mb-3"),
+		Div(
+			Class("col-6"),
+			Label(
+				Class("form-label"),
+				g.Attr("for", "EndTime"),
+				g.Text("End Time"),
+			),
+			Input(
+				ID("EndTime"),
+				Type("text"),
+				Name("EndTime"),
+				ghx.Target("#activity_end_time"),
+				ghx.Post("/activities/validate-end-time"),
+				Value(formModel.EndTime),
+				Pattern("[0-9]{2}:[0-5][0-9]"),
+				MinLength("5"),
+				MaxLength("5"),
+				g.Attr("required", "required"),
+				Class("form-control"),
+				g.Attr("placeholder", "10:00"),
+			),
 		),
-		Input(
-			ID("EndTime"),
-			Type("text"),
-			Name("EndTime"),
-			ghx.Target("#activity_end_time"),
-			ghx.Post("/activities/validate-end-time"),
-			Value(formModel.EndTime),
-			Pattern("[0-9]{2}:[0-5][0-9]"),
-			MinLength("5"),
-			MaxLength("5"),
-			g.Attr("required", "required"),
-			Class("form-control"),
-			g.Attr("placeholder", "10:00"),
+		Div(
+			Class("col-6"),
+			Label(
+				Class("form-label"),
+				g.Attr("for", "EndDate"),
+				g.Text("End Date"),
+			),
+			Input(
+				ID("EndDate"),
+				Type("text"),
+				Name("EndDate"),
+				ghx.Target("#activity_end_time"),
+				ghx.Post("/activities/validate-end-time"),
+				Value(formModel.EndDate),
+				Pattern("[0-3][0-9]\\.[0-1][0-9]\\.20[0-9]{2}"),
+				MinLength("10"),
+				MaxLength("10"),
+				Class("form-control"),
+				g.Attr("placeholder", "16.11.2021"),
+				TitleAttr("Leave empty or set to the next day for activities spanning midnight."),
+			),
 		),
 	)
 }
@@ -1094,10 +1302,71 @@ func TrackPanel(projects []*Project, formModel activityTrackFormModel) g.Node {
 				),
 			),
 		),
+		g.If(len(formModel.Warnings) > 0,
+			Div(
+				Class("alert alert-warning mt-2 mb-0 p-2"),
+				Role("alert"),
+				ComplianceViolationsList(formModel.Warnings),
+			),
+		),
+	)
+}
+
+// ActivityComplianceWarningsView shows the violations of the german working time law after saving an activity
+func ActivityComplianceWarningsView(violations []*ComplianceViolation) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Activity Saved"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Div(
+				Class("alert alert-warning"),
+				Role("alert"),
+				P(
+					I(Class("bi-exclamation-triangle me-2")),
+					g.Text("The activity violates the German working time law (ArbZG):"),
+				),
+				ComplianceViolationsList(violations),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("button"),
+				Class("btn btn-primary"),
+				g.Attr("data-bs-dismiss", "modal"),
+				g.Text("OK"),
+			),
+		),
+	)
+}
+
+func ComplianceViolationsList(violations []*ComplianceViolation) g.Node {
+	return Ul(
+		Class("mb-0"),
+		g.Group(
+			g.Map(violations, func(violation *ComplianceViolation) g.Node {
+				return Li(
+					Strong(g.Textf("%v %v: ", violation.DayFormatted(), violation.RuleFormatted())),
+					g.Text(violation.Message),
+				)
+			}),
+		),
 	)
 }
 
-func (a *ActivityWebHandlers) renderActivityAddView(w http.ResponseWriter, r *http.Request, principal *shared.Principal, isProduction bool, formModel activityFormModel) {
+func (a *ActivityWebHandlers) renderActivityAddView(w http.ResponseWriter, r *http.Request, principal *shared.Principal, isProduction bool, formModel activityFormModel, errorMessage string) {
 	pageParams := &paged.PageParams{
 		Page: 0,
 		Size: 50,
@@ -1111,7 +1380,7 @@ func (a *ActivityWebHandlers) renderActivityAddView(w http.ResponseWriter, r *ht
 
 	if hx.IsHXRequest(r) {
 		formModel.CSRFToken = csrf.Token(r)
-		shared.RenderHTML(w, ActivityForm(formModel, projects, ""))
+		shared.RenderHTML(w, ActivityForm(formModel, projects, errorMessage))
 		return
 	}
 
@@ -1121,13 +1390,13 @@ func (a *ActivityWebHandlers) renderActivityAddView(w http.ResponseWriter, r *ht
 		Title:       "Add Activity",
 	}
 
-	activityFormModel := newActivityFormModel()
+	activityFormModel := newActivityFormModel(principal.Location())
 	activityFormModel.CSRFToken = csrf.Token(r)
 
 	shared.RenderHTML(w, ActivityAddPage(pageContext, activityFormModel, projects))
 }
 
-func mapFormToActivity(formModel activityFormModel) (*Activity, error) {
+func mapFormToActivity(formModel activityFormModel, location *time.Location) (*Activity, error) {
 	var activityID uuid.UUID
 
 	if formModel.ID != "" {
@@ -1138,12 +1407,17 @@ func mapFormToActivity(formModel activityFormModel) (*Activity, error) {
 		activityID = aID
 	}
 
-	start, err := time_utils.ParseDateTimeForm(fmt.Sprintf("%v %v", formModel.Date, formModel.StartTime))
+	start, err := time_utils.ParseDateTimeFormInLocation(fmt.Sprintf("%v %v", formModel.Date, formModel.StartTime), location)
 	if err != nil {
 		return nil, err
 	}
 
-	end, err := time_utils.ParseDateTimeForm(fmt.Sprintf("%v %v", formModel.Date, formModel.EndTime))
+	endDate := formModel.EndDate
+	if endDate == "" {
+		endDate = formModel.Date
+	}
+
+	end, err := time_utils.ParseDateTimeFormInLocation(fmt.Sprintf("%v %v", endDate, formModel.EndTime), location)
 	if err != nil {
 		return nil, err
 	}
@@ -1192,7 +1466,7 @@ func mapFormToActivity(formModel activityFormModel) (*Activity, error) {
 	return activity, nil
 }
 
-func mapActivityToForm(activity Activity) activityFormModel {
+func mapActivityToForm(activity Activity, location *time.Location) activityFormModel {
 	// Convert tags slice to comma-separated string
 	tagNames := make([]string, len(activity.Tags))
 	for i, tag := range activity.Tags {
@@ -1200,11 +1474,15 @@ func mapActivityToForm(activity Activity) activityFormModel {
 	}
 	tagsString := strings.Join(tagNames, ", ")
 
+	start := activity.Start.In(location)
+	end := activity.End.In(location)
+
 	return activityFormModel{
 		ID:          activity.ID.String(),
-		Date:        time_utils.FormatDateDE(activity.Start),
-		StartTime:   time_utils.FormatTime(activity.Start),
-		EndTime:     time_utils.FormatTime(activity.End),
+		Date:        time_utils.FormatDateDE(start),
+		StartTime:   time_utils.FormatTime(start),
+		EndDate:     time_utils.FormatDateDE(end),
+		EndTime:     time_utils.FormatTime(end),
 		ProjectID:   activity.ProjectID.String(),
 		Description: activity.Description,
 		Tags:        tagsString,
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/go-chi/chi/v5"
@@ -23,6 +24,7 @@ func createTestActivityServiceForWeb(repo ActivityRepository) *ActitivityService
 		activityRepository: repo,
 		tagRepository:      tagRepo,
 		tagService:         tagService,
+		holidayRepository:  NewInMemHolidayRepository(),
 	}
 }
 
@@ -36,6 +38,7 @@ func TestHandleTrackingPage(t *testing.T) {
 		activityRepository: activityRepository,
 		projectRepository:  NewInMemProjectRepository(),
 		activityService:    createTestActivityServiceForWeb(activityRepository),
+		absenceService:     NewAbsenceService(shared.NewInMemRepositoryTxer(), NewInMemAbsenceRepository()),
 	}
 
 	r, _ := http.NewRequest("GET", "/", nil)
@@ -48,6 +51,39 @@ func TestHandleTrackingPage(t *testing.T) {
 	is.True(strings.Contains(htmlBody, "Track Activities # Baralga"))
 }
 
+func TestHandleTrackingPageWithAbsence(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	activityRepository := NewInMemActivityRepository()
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:          uuid.New(),
+		Start:       time.Now().AddDate(0, 0, -7),
+		End:         time.Now().AddDate(0, 0, 7),
+		Type:        AbsenceTypeVacation,
+		Description: "Summer Vacation",
+	})
+
+	a := &ActivityWebHandlers{
+		config:             &shared.Config{},
+		activityRepository: activityRepository,
+		projectRepository:  NewInMemProjectRepository(),
+		activityService:    createTestActivityServiceForWeb(activityRepository),
+		absenceService:     NewAbsenceService(shared.NewInMemRepositoryTxer(), absenceRepository),
+	}
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleTrackingPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Summer Vacation"))
+	is.True(strings.Contains(htmlBody, "Vacation"))
+}
+
 func TestHandleActivityAddPage(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -133,6 +169,41 @@ func TestHandleCreateActivtiyWithValidActivtiy(t *testing.T) {
 	is.Equal(countBefore+1, len(repo.activities))
 }
 
+func TestHandleActivityFormWithComplianceWarnings(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemActivityRepository()
+
+	w := &ActivityWebHandlers{
+		config:             &shared.Config{},
+		activityRepository: repo,
+		projectRepository:  NewInMemProjectRepository(),
+		activityService:    createTestActivityServiceForWeb(repo),
+	}
+
+	// 19.12.2021 is a Sunday
+	data := url.Values{}
+	data["ProjectID"] = []string{shared.ProjectIDSample.String()}
+	data["Date"] = []string{"19.12.2021"}
+	data["StartTime"] = []string{"10:00"}
+	data["EndTime"] = []string{"11:00"}
+	data["Description"] = []string{"My description"}
+
+	r, _ := http.NewRequest("POST", "/activities/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	w.HandleActivityForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__activities-changed")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Activity Saved"))
+	is.True(strings.Contains(htmlBody, "Worked on a Sunday."))
+}
+
 func TestHandleCreateActivityWithTags(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -188,7 +259,7 @@ func TestMapFormToActivityWithTags(t *testing.T) {
 		Tags:        "meeting, development, bug-fix",
 	}
 
-	activity, err := mapFormToActivity(formModel)
+	activity, err := mapFormToActivity(formModel, time.UTC)
 	is.NoErr(err)
 	is.Equal(len(activity.Tags), 3)
 	is.True(containsTag(activity.Tags, "meeting"))
@@ -208,7 +279,7 @@ func TestMapFormToActivityWithSpaceSeparatedTags(t *testing.T) {
 		Tags:        "meeting development bug-fix",
 	}
 
-	activity, err := mapFormToActivity(formModel)
+	activity, err := mapFormToActivity(formModel, time.UTC)
 	is.NoErr(err)
 	is.Equal(len(activity.Tags), 3)
 	is.True(containsTag(activity.Tags, "meeting"))
@@ -228,13 +299,47 @@ func TestMapFormToActivityWithDuplicateTags(t *testing.T) {
 		Tags:        "meeting, Meeting, MEETING, development",
 	}
 
-	activity, err := mapFormToActivity(formModel)
+	activity, err := mapFormToActivity(formModel, time.UTC)
 	is.NoErr(err)
 	is.Equal(len(activity.Tags), 2) // Should deduplicate case-insensitive
 	is.True(containsTag(activity.Tags, "meeting"))
 	is.True(containsTag(activity.Tags, "development"))
 }
 
+func TestMapFormToActivityInLocation(t *testing.T) {
+	is := is.New(t)
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	formModel := activityFormModel{
+		ProjectID: shared.ProjectIDSample.String(),
+		Date:      "21.12.2021",
+		StartTime: "10:00",
+		EndTime:   "11:00",
+	}
+
+	activity, err := mapFormToActivity(formModel, newYork)
+	is.NoErr(err)
+	is.Equal(activity.Start.UTC().Hour(), 15)
+	is.Equal(activity.End.UTC().Hour(), 16)
+}
+
+func TestMapActivityToFormInLocation(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	activity := Activity{
+		ID:        uuid.MustParse("00000000-0000-0000-2222-000000000001"),
+		ProjectID: shared.ProjectIDSample,
+		Start:     time.Date(2021, time.December, 21, 23, 30, 0, 0, time.UTC),
+		End:       time.Date(2021, time.December, 21, 23, 45, 0, 0, time.UTC),
+	}
+
+	formModel := mapActivityToForm(activity, berlin)
+	is.Equal(formModel.Date, "22.12.2021")
+	is.Equal(formModel.StartTime, "00:30")
+	is.Equal(formModel.EndTime, "00:45")
+}
+
 func TestMapActivityToFormWithTags(t *testing.T) {
 	is := is.New(t)
 
@@ -250,7 +355,7 @@ func TestMapActivityToFormWithTags(t *testing.T) {
 		},
 	}
 
-	formModel := mapActivityToForm(activity)
+	formModel := mapActivityToForm(activity, time.UTC)
 	is.Equal(formModel.Tags, "meeting, development, bug-fix")
 }
 
@@ -265,9 +370,10 @@ func TestHandleTrackingPageDisplaysTagsWithoutFiltering(t *testing.T) {
 	tagService := NewTagService(tagRepository)
 	repositoryTxer := shared.NewInMemRepositoryTxer()
 
-	activityService := NewActitivityService(repositoryTxer, activityRepository, tagRepository, tagService)
+	activityService := NewActitivityService(repositoryTxer, activityRepository, tagRepository, tagService, NewInMemHolidayRepository())
+	absenceService := NewAbsenceService(repositoryTxer, NewInMemAbsenceRepository())
 
-	handlers := NewActivityWebHandlers(config, activityService, activityRepository, projectRepository)
+	handlers := NewActivityWebHandlers(config, activityService, activityRepository, projectRepository, absenceService)
 
 	// Create a simple request (no tag filtering on web page)
 	req := httptest.NewRequest("GET", "/", nil)
@@ -442,6 +548,189 @@ func TestHandleEndTimeValidation(t *testing.T) {
 	is.True(strings.Contains(htmlBody, "10:00"))
 }
 
+func TestHandleEndTimeValidationWithEndBeforeStart(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ActivityWebHandlers{
+		config: &shared.Config{},
+	}
+
+	data := url.Values{}
+	data["Date"] = []string{"21.12.2021"}
+	data["StartTime"] = []string{"22:00"}
+	data["EndTime"] = []string{"02"}
+
+	r, _ := http.NewRequest("POST", "/activities/validation-end-time", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleEndTimeValidation()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "02:00"))
+	is.True(strings.Contains(htmlBody, "22.12.2021"))
+}
+
+func TestHandleCreateActivtiySpanningMidnight(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemActivityRepository()
+
+	w := &ActivityWebHandlers{
+		config:             &shared.Config{},
+		activityRepository: repo,
+		projectRepository:  NewInMemProjectRepository(),
+		activityService:    createTestActivityServiceForWeb(repo),
+	}
+
+	countBefore := len(repo.activities)
+
+	data := url.Values{}
+	data["ProjectID"] = []string{shared.ProjectIDSample.String()}
+	data["Date"] = []string{"21.12.2021"}
+	data["StartTime"] = []string{"22:00"}
+	data["EndDate"] = []string{"22.12.2021"}
+	data["EndTime"] = []string{"02:00"}
+
+	r, _ := http.NewRequest("POST", "/activities/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_ADMIN"},
+	}))
+
+	w.HandleActivityForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(countBefore+1, len(repo.activities))
+	is.Equal(repo.activities[countBefore].DurationMinutesTotal(), 240)
+}
+
+func TestHandleCreateActivtiyWithEndBeforeStart(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemActivityRepository()
+
+	w := &ActivityWebHandlers{
+		config:             &shared.Config{},
+		activityRepository: repo,
+		projectRepository:  NewInMemProjectRepository(),
+		activityService:    createTestActivityServiceForWeb(repo),
+	}
+
+	countBefore := len(repo.activities)
+
+	data := url.Values{}
+	data["ProjectID"] = []string{shared.ProjectIDSample.String()}
+	data["Date"] = []string{"21.12.2021"}
+	data["StartTime"] = []string{"22:00"}
+	data["EndTime"] = []string{"02:00"}
+
+	r, _ := http.NewRequest("POST", "/activities/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("HX-Request", "true")
+
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_ADMIN"},
+	}))
+
+	w.HandleActivityForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(countBefore, len(repo.activities))
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "The activity must end after it starts."))
+}
+
+func TestHandleCreateActivtiyTooLong(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemActivityRepository()
+
+	w := &ActivityWebHandlers{
+		config:             &shared.Config{},
+		activityRepository: repo,
+		projectRepository:  NewInMemProjectRepository(),
+		activityService:    createTestActivityServiceForWeb(repo),
+	}
+
+	countBefore := len(repo.activities)
+
+	data := url.Values{}
+	data["ProjectID"] = []string{shared.ProjectIDSample.String()}
+	data["Date"] = []string{"21.12.2021"}
+	data["EndDate"] = []string{"23.12.2021"}
+	data["StartTime"] = []string{"22:00"}
+	data["EndTime"] = []string{"02:00"}
+
+	r, _ := http.NewRequest("POST", "/activities/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("HX-Request", "true")
+
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_ADMIN"},
+	}))
+
+	w.HandleActivityForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(countBefore, len(repo.activities))
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "The activity must not last longer than 24 hours."))
+}
+
+func TestActivityPartsInTimespan(t *testing.T) {
+	is := is.New(t)
+
+	filter := &ActivityFilter{
+		Timespan: TimespanWeek,
+		start:    time.Date(2021, time.December, 20, 0, 0, 0, 0, time.UTC),
+	}
+
+	activities := []*Activity{
+		{
+			Start: time.Date(2021, time.December, 21, 22, 0, 0, 0, time.UTC),
+			End:   time.Date(2021, time.December, 22, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			Start: time.Date(2021, time.December, 26, 23, 0, 0, 0, time.UTC),
+			End:   time.Date(2021, time.December, 27, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	parts := activityPartsInTimespan(filter, activities)
+
+	is.Equal(len(parts), 3)
+	is.Equal(parts[0].DurationMinutesTotal(), 120)
+	is.Equal(parts[1].DurationMinutesTotal(), 120)
+	is.Equal(parts[2].DurationMinutesTotal(), 60)
+}
+
+func TestMapFormToActivityWithEndDate(t *testing.T) {
+	is := is.New(t)
+
+	formModel := activityFormModel{
+		ProjectID: shared.ProjectIDSample.String(),
+		Date:      "21.12.2021",
+		StartTime: "22:00",
+		EndDate:   "22.12.2021",
+		EndTime:   "01:00",
+	}
+
+	activity, err := mapFormToActivity(formModel, time.UTC)
+	is.NoErr(err)
+	is.Equal(activity.End.Day(), 22)
+	is.Equal(activity.DurationMinutesTotal(), 180)
+
+	formModel = mapActivityToForm(*activity, time.UTC)
+	is.Equal(formModel.EndDate, "22.12.2021")
+}
+
 // Helper function to check if a tag name exists in a slice of Tag objects
 func containsTag(tags []*Tag, tagName string) bool {
 	for _, tag := range tags {
@@ -0,0 +1,207 @@
+package tracking
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	time_utils "github.com/baralga/tracking/time"
+)
+
+// Rules of the german working time law (Arbeitszeitgesetz, ArbZG)
+const (
+	ComplianceRuleMaxWorkingTime string = "max-working-time"
+	ComplianceRuleRestPeriod     string = "rest-period"
+	ComplianceRuleBreak          string = "break"
+	ComplianceRuleSundayWork     string = "sunday-work"
+)
+
+const (
+	// maxWorkingMinutesPerDay is the maximum working time per day (§ 3 ArbZG)
+	maxWorkingMinutesPerDay = 10 * 60
+	// minRestMinutes is the minimum uninterrupted rest after the end of the daily working time (§ 5 ArbZG)
+	minRestMinutes = 11 * 60
+	// minBreakMinutes is the minimum length of a break counting as rest break (§ 4 ArbZG)
+	minBreakMinutes = 15
+	// maxMinutesWithoutBreak is the maximum working time without a rest break (§ 4 ArbZG)
+	maxMinutesWithoutBreak = 6 * 60
+)
+
+// ComplianceViolation is a violation of a rule of the german working time law on a day
+type ComplianceViolation struct {
+	Day     time.Time
+	Rule    string
+	Message string
+}
+
+// DayFormatted is the day of the violation for display
+func (v *ComplianceViolation) DayFormatted() string {
+	return time_utils.FormatDateDE(v.Day)
+}
+
+// RuleFormatted is the rule of the violation for display
+func (v *ComplianceViolation) RuleFormatted() string {
+	switch v.Rule {
+	case ComplianceRuleMaxWorkingTime:
+		return "Maximum Working Time"
+	case ComplianceRuleRestPeriod:
+		return "Rest Period"
+	case ComplianceRuleBreak:
+		return "Rest Break"
+	case ComplianceRuleSundayWork:
+		return "Sunday and Holiday Work"
+	default:
+		return v.Rule
+	}
+}
+
+// CheckCompliance checks the activities of a single user against the german working time law.
+// The activities are evaluated by day in the given location, the violations are sorted by day.
+func CheckCompliance(activities []*Activity, holidays []*Holiday, location *time.Location) []*ComplianceViolation {
+	sorted := make([]*Activity, 0, len(activities))
+	for _, activity := range activities {
+		a := *activity
+		a.Start = a.Start.In(location)
+		a.End = a.End.In(location)
+		sorted = append(sorted, &a)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	holidayTitles := make(map[time.Time]string)
+	for _, holiday := range holidays {
+		holidayTitles[dateOf(holiday.Day)] = holiday.Title
+	}
+
+	partsByDay := make(map[time.Time][]*Activity)
+	var days []time.Time
+	for _, activity := range sorted {
+		for _, part := range activity.SplitByDay() {
+			day := dateOf(part.Start)
+			if _, ok := partsByDay[day]; !ok {
+				days = append(days, day)
+			}
+			partsByDay[day] = append(partsByDay[day], part)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	violationsByDay := make(map[time.Time][]*ComplianceViolation)
+	for _, day := range days {
+		violationsByDay[day] = checkDayCompliance(day, partsByDay[day], holidayTitles)
+	}
+
+	for day, violation := range checkRestPeriods(sorted) {
+		if _, ok := violationsByDay[day]; !ok {
+			days = append(days, day)
+		}
+		violationsByDay[day] = append(violationsByDay[day], violation)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var violations []*ComplianceViolation
+	for _, day := range days {
+		violations = append(violations, violationsByDay[day]...)
+	}
+	return violations
+}
+
+// checkDayCompliance checks the working time, breaks and the weekday of a day with the parts of the activities on that day sorted by start
+func checkDayCompliance(day time.Time, parts []*Activity, holidayTitles map[time.Time]string) []*ComplianceViolation {
+	var violations []*ComplianceViolation
+
+	workingMinutes := 0
+	breakMinutes := 0
+	stretchMinutes := 0
+	longestStretchMinutes := 0
+	var end time.Time
+	for i, part := range parts {
+		if i > 0 && part.Start.Sub(end) >= minBreakMinutes*time.Minute {
+			breakMinutes += int(part.Start.Sub(end).Minutes())
+			stretchMinutes = 0
+		}
+
+		workingMinutes += part.DurationMinutesTotal()
+		stretchMinutes += part.DurationMinutesTotal()
+		if stretchMinutes > longestStretchMinutes {
+			longestStretchMinutes = stretchMinutes
+		}
+
+		if part.End.After(end) {
+			end = part.End
+		}
+	}
+
+	if workingMinutes > maxWorkingMinutesPerDay {
+		violations = append(violations, &ComplianceViolation{
+			Day:     day,
+			Rule:    ComplianceRuleMaxWorkingTime,
+			Message: fmt.Sprintf("Worked %v, more than the maximum of 10 hours per day.", time_utils.FormatMinutesAsDuration(float64(workingMinutes))),
+		})
+	}
+
+	requiredBreakMinutes := 0
+	switch {
+	case workingMinutes > 9*60:
+		requiredBreakMinutes = 45
+	case workingMinutes > 6*60:
+		requiredBreakMinutes = 30
+	}
+
+	if breakMinutes < requiredBreakMinutes {
+		violations = append(violations, &ComplianceViolation{
+			Day:     day,
+			Rule:    ComplianceRuleBreak,
+			Message: fmt.Sprintf("Took %v minutes of breaks while working %v, at least %v minutes are required.", breakMinutes, time_utils.FormatMinutesAsDuration(float64(workingMinutes)), requiredBreakMinutes),
+		})
+	} else if longestStretchMinutes > maxMinutesWithoutBreak {
+		violations = append(violations, &ComplianceViolation{
+			Day:     day,
+			Rule:    ComplianceRuleBreak,
+			Message: fmt.Sprintf("Worked %v without a break, more than the maximum of 6 hours.", time_utils.FormatMinutesAsDuration(float64(longestStretchMinutes))),
+		})
+	}
+
+	if title, ok := holidayTitles[day]; ok {
+		violations = append(violations, &ComplianceViolation{
+			Day:     day,
+			Rule:    ComplianceRuleSundayWork,
+			Message: fmt.Sprintf("Worked on the public holiday %v.", title),
+		})
+	} else if day.Weekday() == time.Sunday {
+		violations = append(violations, &ComplianceViolation{
+			Day:     day,
+			Rule:    ComplianceRuleSundayWork,
+			Message: "Worked on a Sunday.",
+		})
+	}
+
+	return violations
+}
+
+// checkRestPeriods checks the rest between the end of work and the first activity of a later day,
+// the activities need to be sorted by start
+func checkRestPeriods(activities []*Activity) map[time.Time]*ComplianceViolation {
+	violations := make(map[time.Time]*ComplianceViolation)
+
+	var previous *Activity
+	var end time.Time
+	for _, activity := range activities {
+		if previous != nil && dateOf(activity.Start).After(dateOf(previous.Start)) {
+			restMinutes := int(activity.Start.Sub(end).Minutes())
+			if restMinutes < minRestMinutes {
+				violations[dateOf(activity.Start)] = &ComplianceViolation{
+					Day:     dateOf(activity.Start),
+					Rule:    ComplianceRuleRestPeriod,
+					Message: fmt.Sprintf("Rested only %v after the end of work, at least 11 hours are required.", time_utils.FormatMinutesAsDuration(float64(max(restMinutes, 0)))),
+				}
+			}
+		}
+
+		previous = activity
+		if activity.End.After(end) {
+			end = activity.End
+		}
+	}
+
+	return violations
+}
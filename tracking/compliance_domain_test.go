@@ -0,0 +1,166 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestCheckComplianceWithoutViolations(t *testing.T) {
+	is := is.New(t)
+
+	// Tuesday and Wednesday with lunch break
+	activities := []*Activity{
+		activityOf(2024, 7, 2, 8, 0, 12, 0),
+		activityOf(2024, 7, 2, 12, 30, 17, 0),
+		activityOf(2024, 7, 3, 8, 0, 12, 0),
+		activityOf(2024, 7, 3, 12, 30, 17, 0),
+	}
+
+	violations := CheckCompliance(activities, nil, time.UTC)
+
+	is.Equal(len(violations), 0)
+}
+
+func TestCheckComplianceMaxWorkingTime(t *testing.T) {
+	is := is.New(t)
+
+	activities := []*Activity{
+		activityOf(2024, 7, 2, 7, 0, 12, 0),
+		activityOf(2024, 7, 2, 13, 0, 18, 30),
+	}
+
+	violations := CheckCompliance(activities, nil, time.UTC)
+
+	is.Equal(len(violations), 1)
+	is.Equal(violations[0].Rule, ComplianceRuleMaxWorkingTime)
+	is.Equal(violations[0].Day, time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC))
+	is.Equal(violations[0].Message, "Worked 10:30 h, more than the maximum of 10 hours per day.")
+}
+
+func TestCheckComplianceBreaks(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("MissingBreakAfterSixHours", func(t *testing.T) {
+		activities := []*Activity{
+			activityOf(2024, 7, 2, 8, 0, 12, 0),
+			activityOf(2024, 7, 2, 12, 10, 15, 0),
+		}
+
+		violations := CheckCompliance(activities, nil, time.UTC)
+
+		is.Equal(len(violations), 1)
+		is.Equal(violations[0].Rule, ComplianceRuleBreak)
+		is.Equal(violations[0].Message, "Took 0 minutes of breaks while working 6:50 h, at least 30 minutes are required.")
+	})
+
+	t.Run("ShortBreakAfterNineHours", func(t *testing.T) {
+		activities := []*Activity{
+			activityOf(2024, 7, 2, 7, 0, 11, 0),
+			activityOf(2024, 7, 2, 11, 30, 16, 0),
+			activityOf(2024, 7, 2, 16, 5, 17, 0),
+		}
+
+		violations := CheckCompliance(activities, nil, time.UTC)
+
+		is.Equal(len(violations), 1)
+		is.Equal(violations[0].Rule, ComplianceRuleBreak)
+	})
+
+	t.Run("MoreThanSixHoursWithoutBreak", func(t *testing.T) {
+		activities := []*Activity{
+			activityOf(2024, 7, 2, 6, 0, 6, 30),
+			activityOf(2024, 7, 2, 7, 0, 13, 30),
+		}
+
+		violations := CheckCompliance(activities, nil, time.UTC)
+
+		is.Equal(len(violations), 1)
+		is.Equal(violations[0].Rule, ComplianceRuleBreak)
+		is.Equal(violations[0].Message, "Worked 6:30 h without a break, more than the maximum of 6 hours.")
+	})
+}
+
+func TestCheckComplianceRestPeriod(t *testing.T) {
+	is := is.New(t)
+
+	activities := []*Activity{
+		activityOf(2024, 7, 2, 14, 0, 17, 0),
+		activityOf(2024, 7, 2, 18, 0, 22, 0),
+		activityOf(2024, 7, 3, 7, 0, 12, 0),
+	}
+
+	violations := CheckCompliance(activities, nil, time.UTC)
+
+	is.Equal(len(violations), 1)
+	is.Equal(violations[0].Rule, ComplianceRuleRestPeriod)
+	is.Equal(violations[0].Day, time.Date(2024, 7, 3, 0, 0, 0, 0, time.UTC))
+	is.Equal(violations[0].Message, "Rested only 9:00 h after the end of work, at least 11 hours are required.")
+}
+
+func TestCheckComplianceRestPeriodAfterMidnight(t *testing.T) {
+	is := is.New(t)
+
+	activities := []*Activity{
+		{
+			Start: time.Date(2024, 7, 2, 22, 0, 0, 0, time.UTC),
+			End:   time.Date(2024, 7, 3, 2, 0, 0, 0, time.UTC),
+		},
+		activityOf(2024, 7, 3, 9, 0, 12, 0),
+	}
+
+	violations := CheckCompliance(activities, nil, time.UTC)
+
+	is.Equal(len(violations), 1)
+	is.Equal(violations[0].Rule, ComplianceRuleRestPeriod)
+	is.Equal(violations[0].Message, "Rested only 7:00 h after the end of work, at least 11 hours are required.")
+}
+
+func TestCheckComplianceSundayAndHolidayWork(t *testing.T) {
+	is := is.New(t)
+
+	activities := []*Activity{
+		activityOf(2024, 6, 30, 10, 0, 12, 0),
+		activityOf(2024, 10, 3, 10, 0, 12, 0),
+	}
+	holidays := []*Holiday{
+		{
+			Day:   time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
+			Title: "German Unity Day",
+		},
+	}
+
+	violations := CheckCompliance(activities, holidays, time.UTC)
+
+	is.Equal(len(violations), 2)
+	is.Equal(violations[0].Rule, ComplianceRuleSundayWork)
+	is.Equal(violations[0].Message, "Worked on a Sunday.")
+	is.Equal(violations[1].Rule, ComplianceRuleSundayWork)
+	is.Equal(violations[1].Message, "Worked on the public holiday German Unity Day.")
+}
+
+func TestCheckComplianceInLocation(t *testing.T) {
+	is := is.New(t)
+
+	location, err := time.LoadLocation("Europe/Berlin")
+	is.NoErr(err)
+
+	// Saturday evening in UTC is already Sunday in Berlin
+	activities := []*Activity{
+		activityOf(2024, 6, 29, 22, 30, 23, 30),
+	}
+
+	is.Equal(len(CheckCompliance(activities, nil, time.UTC)), 0)
+
+	violations := CheckCompliance(activities, nil, location)
+	is.Equal(len(violations), 1)
+	is.Equal(violations[0].Rule, ComplianceRuleSundayWork)
+}
+
+func activityOf(year int, month time.Month, day, startHour, startMinute, endHour, endMinute int) *Activity {
+	return &Activity{
+		Start: time.Date(year, month, day, startHour, startMinute, 0, 0, time.UTC),
+		End:   time.Date(year, month, day, endHour, endMinute, 0, 0, time.UTC),
+	}
+}
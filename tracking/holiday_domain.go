@@ -0,0 +1,142 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrHolidayNotFound = errors.New("holiday not found")
+	// ErrUnknownFederalState is returned when holidays are generated for an unknown german federal state
+	ErrUnknownFederalState = errors.New("unknown federal state")
+)
+
+// Holiday represents a public holiday in the calendar of an organization
+type Holiday struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Day            time.Time
+	Title          string
+}
+
+type HolidayRepository interface {
+	FindHolidays(ctx context.Context, organizationID uuid.UUID, start, end time.Time) ([]*Holiday, error)
+	// InsertHolidays inserts the holidays and skips days which are already in the calendar
+	InsertHolidays(ctx context.Context, holidays []*Holiday) (int, error)
+	DeleteHolidayByID(ctx context.Context, organizationID, holidayID uuid.UUID) error
+}
+
+// FederalStates are the german federal states with holidays by their abbreviation
+var FederalStates = map[string]string{
+	"BW": "Baden-Württemberg",
+	"BY": "Bayern",
+	"BE": "Berlin",
+	"BB": "Brandenburg",
+	"HB": "Bremen",
+	"HH": "Hamburg",
+	"HE": "Hessen",
+	"MV": "Mecklenburg-Vorpommern",
+	"NI": "Niedersachsen",
+	"NW": "Nordrhein-Westfalen",
+	"RP": "Rheinland-Pfalz",
+	"SL": "Saarland",
+	"SN": "Sachsen",
+	"ST": "Sachsen-Anhalt",
+	"SH": "Schleswig-Holstein",
+	"TH": "Thüringen",
+}
+
+// GermanHolidays returns the public holidays of the given year in a german federal state
+func GermanHolidays(state string, year int) ([]*Holiday, error) {
+	if _, ok := FederalStates[state]; !ok {
+		return nil, ErrUnknownFederalState
+	}
+
+	easter := easterSunday(year)
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	in := func(states ...string) bool {
+		for _, s := range states {
+			if s == state {
+				return true
+			}
+		}
+		return false
+	}
+
+	var holidays []*Holiday
+	add := func(day time.Time, title string) {
+		holidays = append(holidays, &Holiday{Day: day, Title: title})
+	}
+
+	add(date(time.January, 1), "New Year's Day")
+	if in("BW", "BY", "ST") {
+		add(date(time.January, 6), "Epiphany")
+	}
+	if (in("BE") && year >= 2019) || (in("MV") && year >= 2023) {
+		add(date(time.March, 8), "International Women's Day")
+	}
+	add(easter.AddDate(0, 0, -2), "Good Friday")
+	if in("BB") {
+		add(easter, "Easter Sunday")
+	}
+	add(easter.AddDate(0, 0, 1), "Easter Monday")
+	add(date(time.May, 1), "Labour Day")
+	add(easter.AddDate(0, 0, 39), "Ascension Day")
+	if in("BB") {
+		add(easter.AddDate(0, 0, 49), "Whit Sunday")
+	}
+	add(easter.AddDate(0, 0, 50), "Whit Monday")
+	if in("BW", "BY", "HE", "NW", "RP", "SL") {
+		add(easter.AddDate(0, 0, 60), "Corpus Christi")
+	}
+	if in("SL") {
+		add(date(time.August, 15), "Assumption Day")
+	}
+	if in("TH") && year >= 2019 {
+		add(date(time.September, 20), "World Children's Day")
+	}
+	add(date(time.October, 3), "German Unity Day")
+	if in("BB", "MV", "SN", "ST", "TH") || (in("HB", "HH", "NI", "SH") && year >= 2018) || year == 2017 {
+		add(date(time.October, 31), "Reformation Day")
+	}
+	if in("BW", "BY", "NW", "RP", "SL") {
+		add(date(time.November, 1), "All Saints' Day")
+	}
+	if in("SN") {
+		add(repentanceDay(year), "Day of Repentance and Prayer")
+	}
+	add(date(time.December, 25), "Christmas Day")
+	add(date(time.December, 26), "Second Day of Christmas")
+
+	return holidays, nil
+}
+
+// easterSunday calculates easter sunday of the gregorian calendar
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := ((h + l - 7*m + 114) % 31) + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// repentanceDay is the wednesday before the 23rd of november
+func repentanceDay(year int) time.Time {
+	day := time.Date(year, time.November, 22, 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(time.Wednesday) + 7) % 7))
+}
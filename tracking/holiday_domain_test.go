@@ -0,0 +1,66 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestEasterSunday(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(easterSunday(2021), time.Date(2021, 4, 4, 0, 0, 0, 0, time.UTC))
+	is.Equal(easterSunday(2024), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+	is.Equal(easterSunday(2025), time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC))
+}
+
+func TestRepentanceDay(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(repentanceDay(2023), time.Date(2023, 11, 22, 0, 0, 0, 0, time.UTC))
+	is.Equal(repentanceDay(2024), time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC))
+}
+
+func TestGermanHolidays(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("Bavaria", func(t *testing.T) {
+		holidays, err := GermanHolidays("BY", 2024)
+		is.NoErr(err)
+		is.Equal(len(holidays), 12)
+		is.Equal(holidays[0].Title, "New Year's Day")
+		is.True(containsHoliday(holidays, time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)))
+		is.True(containsHoliday(holidays, time.Date(2024, 5, 30, 0, 0, 0, 0, time.UTC)))
+		is.True(!containsHoliday(holidays, time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Saxony", func(t *testing.T) {
+		holidays, err := GermanHolidays("SN", 2024)
+		is.NoErr(err)
+		is.Equal(len(holidays), 11)
+		is.True(containsHoliday(holidays, time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC)))
+		is.True(containsHoliday(holidays, time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("ReformationDay2017", func(t *testing.T) {
+		holidays, err := GermanHolidays("BY", 2017)
+		is.NoErr(err)
+		is.True(containsHoliday(holidays, time.Date(2017, 10, 31, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("UnknownState", func(t *testing.T) {
+		_, err := GermanHolidays("XX", 2024)
+		is.True(errors.Is(err, ErrUnknownFederalState))
+	})
+}
+
+func containsHoliday(holidays []*Holiday, day time.Time) bool {
+	for _, holiday := range holidays {
+		if holiday.Day.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
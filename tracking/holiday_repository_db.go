@@ -0,0 +1,115 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbHolidayRepository is a SQL database repository for holidays
+type DbHolidayRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ HolidayRepository = (*DbHolidayRepository)(nil)
+
+// NewDbHolidayRepository creates a new SQL database repository for holidays
+func NewDbHolidayRepository(connPool *pgxpool.Pool) *DbHolidayRepository {
+	return &DbHolidayRepository{
+		connPool: connPool,
+	}
+}
+
+// FindHolidays finds all holidays from start (inclusive) to end (exclusive)
+func (r *DbHolidayRepository) FindHolidays(ctx context.Context, organizationID uuid.UUID, start, end time.Time) ([]*Holiday, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT holiday_id as id, day, title 
+		 FROM holidays 
+		 WHERE org_id = $1 AND $2 <= day AND day < $3
+		 ORDER BY day ASC`,
+		organizationID, dateOf(start), dateOf(end),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holidays []*Holiday
+	for rows.Next() {
+		var (
+			id    string
+			day   time.Time
+			title string
+		)
+
+		err = rows.Scan(&id, &day, &title)
+		if err != nil {
+			return nil, err
+		}
+
+		holiday := &Holiday{
+			ID:             uuid.MustParse(id),
+			OrganizationID: organizationID,
+			Day:            day,
+			Title:          title,
+		}
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, nil
+}
+
+func (r *DbHolidayRepository) InsertHolidays(ctx context.Context, holidays []*Holiday) (int, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	inserted := 0
+	for _, holiday := range holidays {
+		tag, err := tx.Exec(
+			ctx,
+			`INSERT INTO holidays 
+			   (holiday_id, org_id, day, title) 
+			 VALUES 
+			   ($1, $2, $3, $4)
+			 ON CONFLICT (org_id, day) DO NOTHING`,
+			holiday.ID,
+			holiday.OrganizationID,
+			dateOf(holiday.Day),
+			holiday.Title,
+		)
+		if err != nil {
+			return 0, err
+		}
+		inserted += int(tag.RowsAffected())
+	}
+
+	return inserted, nil
+}
+
+func (r *DbHolidayRepository) DeleteHolidayByID(ctx context.Context, organizationID, holidayID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`DELETE 
+         FROM holidays 
+	     WHERE holiday_id = $1 AND org_id = $2
+		 RETURNING holiday_id`,
+		holidayID, organizationID)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrHolidayNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package tracking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestHolidayRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	holidayRepository := NewDbHolidayRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	holidayID := uuid.New()
+
+	t.Run("InsertHolidaysSkipsExistingDays", func(t *testing.T) {
+		holidays := []*Holiday{
+			{
+				ID:             holidayID,
+				OrganizationID: shared.OrganizationIDSample,
+				Day:            time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC),
+				Title:          "Christmas Eve",
+			},
+		}
+
+		var inserted int
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				i, err := holidayRepository.InsertHolidays(ctx, holidays)
+				inserted = i
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(inserted, 1)
+
+		holidays[0].ID = uuid.New()
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				i, err := holidayRepository.InsertHolidays(ctx, holidays)
+				inserted = i
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(inserted, 0)
+	})
+
+	t.Run("FindHolidays", func(t *testing.T) {
+		holidays, err := holidayRepository.FindHolidays(
+			context.Background(),
+			shared.OrganizationIDSample,
+			time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		)
+		is.NoErr(err)
+		is.Equal(len(holidays), 1)
+		is.Equal(holidays[0].ID, holidayID)
+		is.Equal(holidays[0].Title, "Christmas Eve")
+	})
+
+	t.Run("DeleteHoliday", func(t *testing.T) {
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return holidayRepository.DeleteHolidayByID(ctx, shared.OrganizationIDSample, holidayID)
+			},
+		)
+		is.NoErr(err)
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return holidayRepository.DeleteHolidayByID(ctx, shared.OrganizationIDSample, holidayID)
+			},
+		)
+		is.True(errors.Is(err, ErrHolidayNotFound))
+	})
+}
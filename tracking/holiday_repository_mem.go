@@ -0,0 +1,63 @@
+package tracking
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InMemHolidayRepository is an in-memory repository for holidays
+type InMemHolidayRepository struct {
+	holidays []*Holiday
+}
+
+var _ HolidayRepository = (*InMemHolidayRepository)(nil)
+
+// NewInMemHolidayRepository creates a new in-memory repository for holidays
+func NewInMemHolidayRepository() *InMemHolidayRepository {
+	return &InMemHolidayRepository{}
+}
+
+func (r *InMemHolidayRepository) FindHolidays(ctx context.Context, organizationID uuid.UUID, start, end time.Time) ([]*Holiday, error) {
+	var holidays []*Holiday
+	for _, h := range r.holidays {
+		if h.OrganizationID == organizationID && !h.Day.Before(dateOf(start)) && h.Day.Before(dateOf(end)) {
+			holidays = append(holidays, h)
+		}
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Day.Before(holidays[j].Day) })
+	return holidays, nil
+}
+
+func (r *InMemHolidayRepository) InsertHolidays(ctx context.Context, holidays []*Holiday) (int, error) {
+	inserted := 0
+	for _, holiday := range holidays {
+		exists := false
+		for _, h := range r.holidays {
+			if h.OrganizationID == holiday.OrganizationID && h.Day.Equal(dateOf(holiday.Day)) {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		holiday.Day = dateOf(holiday.Day)
+		r.holidays = append(r.holidays, holiday)
+		inserted++
+	}
+	return inserted, nil
+}
+
+func (r *InMemHolidayRepository) DeleteHolidayByID(ctx context.Context, organizationID, holidayID uuid.UUID) error {
+	for i, h := range r.holidays {
+		if h.OrganizationID == organizationID && h.ID == holidayID {
+			r.holidays = append(r.holidays[:i], r.holidays[i+1:]...)
+			return nil
+		}
+	}
+	return ErrHolidayNotFound
+}
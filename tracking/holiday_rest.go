@@ -0,0 +1,245 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+// maxCalendarSize limits the size of imported iCalendar files
+const maxCalendarSize = 1 << 20
+
+type holidayModel struct {
+	ID    string     `json:"id"`
+	Day   string     `json:"day"`
+	Title string     `json:"title"`
+	Links *hal.Links `json:"_links,omitempty"`
+}
+
+type holidaysModel struct {
+	*EmbeddedHolidays `json:"_embedded"`
+	Links             *hal.Links `json:"_links"`
+}
+
+// EmbeddedHolidays contains embedded holidays
+type EmbeddedHolidays struct {
+	HolidayModels []*holidayModel `json:"holidays"`
+}
+
+type holidayGenerationModel struct {
+	State string `json:"state" validate:"required,len=2"`
+	Year  int    `json:"year" validate:"required,min=1900,max=2200"`
+}
+
+type holidayImportResultModel struct {
+	Imported int `json:"imported"`
+}
+
+type HolidayRestHandlers struct {
+	config         *shared.Config
+	holidayService *HolidayService
+}
+
+func NewHolidayRestHandlers(config *shared.Config, holidayService *HolidayService) *HolidayRestHandlers {
+	return &HolidayRestHandlers{
+		config:         config,
+		holidayService: holidayService,
+	}
+}
+
+func (a *HolidayRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/holidays", a.HandleGetHolidays())
+	r.Post("/holidays/import", a.HandleImportHolidays())
+	r.Post("/holidays/generate", a.HandleGenerateHolidays())
+	r.Delete("/holidays/{holiday-id}", a.HandleDeleteHoliday())
+}
+
+func (a *HolidayRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetHolidays reads the holidays of a year
+func (a *HolidayRestHandlers) HandleGetHolidays() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		year, err := yearFromQueryParams(r, principal)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		holidays, err := holidayService.ReadHolidaysOfYear(r.Context(), principal, year)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		holidayModels := make([]*holidayModel, 0, len(holidays))
+		for _, holiday := range holidays {
+			holidayModels = append(holidayModels, mapToHolidayModel(principal, holiday))
+		}
+
+		holidaysModel := &holidaysModel{
+			EmbeddedHolidays: &EmbeddedHolidays{
+				HolidayModels: holidayModels,
+			},
+		}
+
+		selfLink := hal.NewSelfLink(r.RequestURI)
+		if principal.HasPermission(shared.PermissionHolidaysWrite) {
+			holidaysModel.Links = hal.NewLinks(
+				selfLink,
+				hal.NewLink("import", "/api/holidays/import"),
+				hal.NewLink("generate", "/api/holidays/generate"),
+			)
+		} else {
+			holidaysModel.Links = hal.NewLinks(
+				selfLink,
+			)
+		}
+
+		shared.RenderJSON(w, holidaysModel)
+	}
+}
+
+// HandleImportHolidays imports holidays from an iCalendar (.ics) file in the request body
+func (a *HolidayRestHandlers) HandleImportHolidays() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionHolidaysWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		imported, err := holidayService.ImportHolidays(r.Context(), principal, http.MaxBytesReader(w, r.Body, maxCalendarSize))
+		if errors.Is(err, ErrInvalidCalendar) {
+			http.Error(w, problem.New(problem.Title("calendar not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, &holidayImportResultModel{Imported: imported})
+	}
+}
+
+// HandleGenerateHolidays adds the public holidays of a german federal state
+func (a *HolidayRestHandlers) HandleGenerateHolidays() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionHolidaysWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var generationModel holidayGenerationModel
+		err := json.NewDecoder(r.Body).Decode(&generationModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(generationModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("holiday generation not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		imported, err := holidayService.GenerateHolidays(r.Context(), principal, generationModel.State, generationModel.Year)
+		if errors.Is(err, ErrUnknownFederalState) {
+			http.Error(w, problem.New(problem.Title("unknown federal state")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, &holidayImportResultModel{Imported: imported})
+	}
+}
+
+// HandleDeleteHoliday deletes a holiday
+func (a *HolidayRestHandlers) HandleDeleteHoliday() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		holidayIDParam := chi.URLParam(r, "holiday-id")
+		holidayID, err := uuid.Parse(holidayIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionHolidaysWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		err = holidayService.DeleteHoliday(r.Context(), principal, holidayID)
+		if errors.Is(err, ErrHolidayNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "{ \"baralga__activities-changed\": true, \"baralga__holidays-changed\": true } ")
+	}
+}
+
+// yearFromQueryParams reads the year from the query params which defaults to the current year
+func yearFromQueryParams(r *http.Request, principal *shared.Principal) (int, error) {
+	yearParam := r.URL.Query().Get("year")
+	if yearParam == "" {
+		return time.Now().In(principal.Location()).Year(), nil
+	}
+
+	year, err := strconv.Atoi(yearParam)
+	if err != nil || year < 1900 || year > 2200 {
+		return 0, fmt.Errorf("invalid year '%s'", yearParam)
+	}
+	return year, nil
+}
+
+func mapToHolidayModel(principal *shared.Principal, holiday *Holiday) *holidayModel {
+	holidayModel := &holidayModel{
+		ID:    holiday.ID.String(),
+		Day:   time_utils.FormatDate(holiday.Day),
+		Title: holiday.Title,
+	}
+
+	if principal.HasPermission(shared.PermissionHolidaysWrite) {
+		holidayModel.Links = hal.NewLinks(
+			hal.NewLink("delete", fmt.Sprintf("/api/holidays/%s", holidayModel.ID)),
+		)
+	}
+
+	return holidayModel
+}
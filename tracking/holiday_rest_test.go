@@ -0,0 +1,182 @@
+package tracking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetHolidays(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayRepository := NewInMemHolidayRepository()
+	holidayRepository.holidays = append(holidayRepository.holidays, &Holiday{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Day:            time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC),
+		Title:          "Christmas Eve",
+	})
+
+	a := &HolidayRestHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	r, _ := http.NewRequest("GET", "/api/holidays?year=2024", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_USER"},
+	}))
+
+	a.HandleGetHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	holidaysModel := &holidaysModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(holidaysModel)
+	is.NoErr(err)
+	is.Equal(len(holidaysModel.HolidayModels), 1)
+	is.Equal(holidaysModel.HolidayModels[0].Day, "2024-12-24")
+	is.Equal(holidaysModel.HolidayModels[0].Title, "Christmas Eve")
+	is.True(holidaysModel.HolidayModels[0].Links == nil)
+}
+
+func TestHandleGetHolidaysWithInvalidYear(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &HolidayRestHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), NewInMemHolidayRepository()),
+	}
+
+	r, _ := http.NewRequest("GET", "/api/holidays?year=abc", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleGetHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
+
+func TestHandleImportHolidays(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayRepository := NewInMemHolidayRepository()
+	a := &HolidayRestHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	r, _ := http.NewRequest("POST", "/api/holidays/import", strings.NewReader(holidayCalendarSample))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}))
+
+	a.HandleImportHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	importResultModel := &holidayImportResultModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(importResultModel)
+	is.NoErr(err)
+	is.Equal(importResultModel.Imported, 3)
+	is.Equal(len(holidayRepository.holidays), 3)
+}
+
+func TestHandleImportHolidaysAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayRepository := NewInMemHolidayRepository()
+	a := &HolidayRestHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	r, _ := http.NewRequest("POST", "/api/holidays/import", strings.NewReader(holidayCalendarSample))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_USER"},
+	}))
+
+	a.HandleImportHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+	is.Equal(len(holidayRepository.holidays), 0)
+}
+
+func TestHandleGenerateHolidays(t *testing.T) {
+	is := is.New(t)
+
+	a := &HolidayRestHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), NewInMemHolidayRepository()),
+	}
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}
+
+	t.Run("KnownState", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/holidays/generate", strings.NewReader(`{"state": "BY", "year": 2024}`))
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleGenerateHolidays()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+		importResultModel := &holidayImportResultModel{}
+		err := json.NewDecoder(httpRec.Body).Decode(importResultModel)
+		is.NoErr(err)
+		is.Equal(importResultModel.Imported, 12)
+	})
+
+	t.Run("UnknownState", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/holidays/generate", strings.NewReader(`{"state": "XX", "year": 2024}`))
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleGenerateHolidays()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+	})
+}
+
+func TestHandleDeleteHoliday(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayID := uuid.New()
+	holidayRepository := NewInMemHolidayRepository()
+	holidayRepository.holidays = append(holidayRepository.holidays, &Holiday{
+		ID:             holidayID,
+		OrganizationID: shared.OrganizationIDSample,
+		Day:            time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC),
+		Title:          "Christmas Eve",
+	})
+
+	a := &HolidayRestHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	r, _ := http.NewRequest("DELETE", "/api/holidays/"+holidayID.String(), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("holiday-id", holidayID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleDeleteHoliday()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(holidayRepository.holidays), 0)
+}
@@ -0,0 +1,186 @@
+package tracking
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// maxHolidayDays limits the days of a single calendar event
+const maxHolidayDays = 31
+
+// ErrInvalidCalendar is returned when an iCalendar file contains no holidays
+var ErrInvalidCalendar = errors.New("no holidays found in calendar")
+
+type HolidayService struct {
+	repositoryTxer    shared.RepositoryTxer
+	holidayRepository HolidayRepository
+}
+
+func NewHolidayService(repositoryTxer shared.RepositoryTxer, holidayRepository HolidayRepository) *HolidayService {
+	return &HolidayService{
+		repositoryTxer:    repositoryTxer,
+		holidayRepository: holidayRepository,
+	}
+}
+
+// ReadHolidaysOfYear reads the holidays of the principal's organization in the given year
+func (a *HolidayService) ReadHolidaysOfYear(ctx context.Context, principal *shared.Principal, year int) ([]*Holiday, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return a.holidayRepository.FindHolidays(ctx, principal.OrganizationID, start, start.AddDate(1, 0, 0))
+}
+
+// ImportHolidays imports the events of an iCalendar (.ics) file as holidays,
+// days already in the calendar are skipped
+func (a *HolidayService) ImportHolidays(ctx context.Context, principal *shared.Principal, calendar io.Reader) (int, error) {
+	holidays, err := parseICalendar(calendar)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.insertHolidays(ctx, principal, holidays)
+}
+
+// GenerateHolidays adds the public holidays of a german federal state in the given year,
+// days already in the calendar are skipped
+func (a *HolidayService) GenerateHolidays(ctx context.Context, principal *shared.Principal, state string, year int) (int, error) {
+	holidays, err := GermanHolidays(state, year)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.insertHolidays(ctx, principal, holidays)
+}
+
+func (a *HolidayService) DeleteHoliday(ctx context.Context, principal *shared.Principal, holidayID uuid.UUID) error {
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.holidayRepository.DeleteHolidayByID(ctx, principal.OrganizationID, holidayID)
+		},
+	)
+}
+
+func (a *HolidayService) insertHolidays(ctx context.Context, principal *shared.Principal, holidays []*Holiday) (int, error) {
+	for _, holiday := range holidays {
+		holiday.ID = uuid.New()
+		holiday.OrganizationID = principal.OrganizationID
+	}
+
+	var inserted int
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			i, err := a.holidayRepository.InsertHolidays(ctx, holidays)
+			if err != nil {
+				return err
+			}
+			inserted = i
+			return nil
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// parseICalendar reads all day events of an iCalendar as holidays, recurrence rules are not supported
+func parseICalendar(calendar io.Reader) ([]*Holiday, error) {
+	var lines []string
+	scanner := bufio.NewScanner(calendar)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		holidays []*Holiday
+		inEvent  bool
+		start    *time.Time
+		end      *time.Time
+		title    string
+	)
+	for _, line := range lines {
+		nameAndParams, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		name, _, _ := strings.Cut(nameAndParams, ";")
+		name = strings.ToUpper(name)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, start, end, title = true, nil, nil, ""
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			if start == nil {
+				continue
+			}
+
+			// the end of an all day event is exclusive
+			days := 1
+			if end != nil && end.After(*start) {
+				days = int(end.Sub(*start).Hours() / 24)
+			}
+			if days > maxHolidayDays {
+				days = maxHolidayDays
+			}
+			for i := 0; i < days; i++ {
+				holidays = append(holidays, &Holiday{
+					Day:   start.AddDate(0, 0, i),
+					Title: title,
+				})
+			}
+		case inEvent && name == "DTSTART":
+			start = parseICalendarDate(value)
+		case inEvent && name == "DTEND":
+			end = parseICalendarDate(value)
+		case inEvent && name == "SUMMARY":
+			title = unescapeICalendarText(value)
+		}
+	}
+
+	if len(holidays) == 0 {
+		return nil, ErrInvalidCalendar
+	}
+
+	return holidays, nil
+}
+
+// parseICalendarDate parses the date of a DATE or DATE-TIME value
+func parseICalendarDate(value string) *time.Time {
+	if len(value) < 8 {
+		return nil
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func unescapeICalendarText(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	title := strings.TrimSpace(replacer.Replace(value))
+	if runes := []rune(title); len(runes) > 100 {
+		title = string(runes[:100])
+	}
+	if title == "" {
+		title = "Holiday"
+	}
+	return title
+}
@@ -0,0 +1,120 @@
+package tracking
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+const holidayCalendarSample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20241224\r\n" +
+	"DTEND;VALUE=DATE:20241225\r\n" +
+	"SUMMARY:Christmas\r\n" +
+	"  Eve\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20241230\r\n" +
+	"DTEND;VALUE=DATE:20250101\r\n" +
+	"SUMMARY:Company Holidays\\, Team\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICalendar(t *testing.T) {
+	is := is.New(t)
+
+	holidays, err := parseICalendar(strings.NewReader(holidayCalendarSample))
+
+	is.NoErr(err)
+	is.Equal(len(holidays), 3)
+	is.Equal(holidays[0].Day, time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC))
+	is.Equal(holidays[0].Title, "Christmas Eve")
+	is.Equal(holidays[1].Day, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC))
+	is.Equal(holidays[2].Day, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	is.Equal(holidays[2].Title, "Company Holidays, Team")
+}
+
+func TestParseInvalidICalendar(t *testing.T) {
+	is := is.New(t)
+
+	_, err := parseICalendar(strings.NewReader("no calendar"))
+
+	is.True(errors.Is(err, ErrInvalidCalendar))
+}
+
+func TestImportHolidaysSkipsExistingDays(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	holidayRepository := NewInMemHolidayRepository()
+	a := &HolidayService{
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		holidayRepository: holidayRepository,
+	}
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	imported, err := a.ImportHolidays(context.Background(), principal, strings.NewReader(holidayCalendarSample))
+	is.NoErr(err)
+	importedAgain, err := a.ImportHolidays(context.Background(), principal, strings.NewReader(holidayCalendarSample))
+	is.NoErr(err)
+
+	// Assert
+	is.Equal(imported, 3)
+	is.Equal(importedAgain, 0)
+
+	holidays, err := a.ReadHolidaysOfYear(context.Background(), principal, 2024)
+	is.NoErr(err)
+	is.Equal(len(holidays), 3)
+	is.Equal(holidays[0].OrganizationID, shared.OrganizationIDSample)
+}
+
+func TestGenerateHolidays(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &HolidayService{
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		holidayRepository: NewInMemHolidayRepository(),
+	}
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	imported, err := a.GenerateHolidays(context.Background(), principal, "BE", 2024)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(imported, 10)
+
+	holidays, err := a.ReadHolidaysOfYear(context.Background(), principal, 2024)
+	is.NoErr(err)
+	is.Equal(len(holidays), 10)
+
+	otherHolidays, err := a.ReadHolidaysOfYear(context.Background(), &shared.Principal{OrganizationID: uuid.New()}, 2024)
+	is.NoErr(err)
+	is.Equal(len(otherHolidays), 0)
+}
+
+func TestDeleteNotExistingHoliday(t *testing.T) {
+	is := is.New(t)
+
+	a := &HolidayService{
+		repositoryTxer:    shared.NewInMemRepositoryTxer(),
+		holidayRepository: NewInMemHolidayRepository(),
+	}
+
+	err := a.DeleteHoliday(context.Background(), &shared.Principal{}, uuid.New())
+
+	is.True(errors.Is(err, ErrHolidayNotFound))
+}
@@ -0,0 +1,381 @@
+package tracking
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type HolidayWebHandlers struct {
+	config         *shared.Config
+	holidayService *HolidayService
+}
+
+func NewHolidayWebHandlers(config *shared.Config, holidayService *HolidayService) *HolidayWebHandlers {
+	return &HolidayWebHandlers{
+		config:         config,
+		holidayService: holidayService,
+	}
+}
+
+func (a *HolidayWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/holidays", a.HandleHolidaysPage())
+	r.Post("/holidays/generate", a.HandleGenerateHolidays())
+	r.Post("/holidays/import", a.HandleImportHolidays())
+}
+
+func (a *HolidayWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+func (a *HolidayWebHandlers) HandleHolidaysPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		year, err := yearFromQueryParams(r, principal)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		holidays, err := holidayService.ReadHolidaysOfYear(r.Context(), principal, year)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Holidays",
+			}
+			shared.RenderHTML(w, HolidaysPage(pageContext, principal, csrf.Token(r), year, holidays))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, HolidaysView(principal, csrf.Token(r), year, holidays, ""))
+	}
+}
+
+// HandleGenerateHolidays adds the public holidays of a german federal state
+func (a *HolidayWebHandlers) HandleGenerateHolidays() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionHolidaysWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		year, err := strconv.Atoi(r.PostForm.Get("Year"))
+		if err != nil || year < 1900 || year > 2200 {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+
+		_, err = holidayService.GenerateHolidays(r.Context(), principal, r.PostForm.Get("State"), year)
+		if errors.Is(err, ErrUnknownFederalState) {
+			a.renderHolidaysView(w, r, principal, isProduction, year, "Please select a federal state.")
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__activities-changed")
+		a.renderHolidaysView(w, r, principal, isProduction, year, "")
+	}
+}
+
+// HandleImportHolidays imports holidays from an uploaded iCalendar (.ics) file
+func (a *HolidayWebHandlers) HandleImportHolidays() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	holidayService := a.holidayService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionHolidaysWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		year, err := yearFromQueryParams(r, principal)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxCalendarSize)
+		err = r.ParseMultipartForm(maxCalendarSize)
+		if err != nil {
+			a.renderHolidaysView(w, r, principal, isProduction, year, "Please select a calendar file of at most 1 MB.")
+			return
+		}
+
+		calendar, _, err := r.FormFile("Calendar")
+		if err != nil {
+			a.renderHolidaysView(w, r, principal, isProduction, year, "Please select a calendar file of at most 1 MB.")
+			return
+		}
+		defer calendar.Close()
+
+		_, err = holidayService.ImportHolidays(r.Context(), principal, calendar)
+		if errors.Is(err, ErrInvalidCalendar) {
+			a.renderHolidaysView(w, r, principal, isProduction, year, "The file contains no holidays in iCalendar format.")
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__activities-changed")
+		a.renderHolidaysView(w, r, principal, isProduction, year, "")
+	}
+}
+
+func (a *HolidayWebHandlers) renderHolidaysView(w http.ResponseWriter, r *http.Request, principal *shared.Principal, isProduction bool, year int, errorMessage string) {
+	holidays, err := a.holidayService.ReadHolidaysOfYear(r.Context(), principal, year)
+	if err != nil {
+		shared.RenderProblemHTML(w, isProduction, err)
+		return
+	}
+
+	shared.RenderHTML(w, HolidaysView(principal, csrf.Token(r), year, holidays, errorMessage))
+}
+
+func HolidaysPage(pageContext *shared.PageContext, principal *shared.Principal, csrfToken string, year int, holidays []*Holiday) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					HolidaysView(principal, csrfToken, year, holidays, ""),
+				),
+			),
+		},
+	)
+}
+
+func HolidaysView(principal *shared.Principal, csrfToken string, year int, holidays []*Holiday, errorMessage string) g.Node {
+	canWrite := principal.HasPermission(shared.PermissionHolidaysWrite)
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		ghx.Get(fmt.Sprintf("/holidays?year=%v", year)),
+		ghx.Trigger("baralga__holidays-changed from:body"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Textf("Holidays %v", year),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Div(
+				Class("d-flex justify-content-between mb-3"),
+				A(
+					ghx.Get(fmt.Sprintf("/holidays?year=%v", year-1)),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr(fmt.Sprintf("Holidays %v", year-1)),
+					I(Class("bi-arrow-left")),
+				),
+				A(
+					ghx.Get(fmt.Sprintf("/holidays?year=%v", year+1)),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr(fmt.Sprintf("Holidays %v", year+1)),
+					I(Class("bi-arrow-right")),
+				),
+			),
+			g.If(
+				errorMessage != "",
+				Div(
+					Class("alert alert-danger text-center"),
+					Role("alert"),
+					Span(g.Text(errorMessage)),
+				),
+			),
+			g.If(canWrite,
+				HolidayGenerationForm(csrfToken, year),
+			),
+			g.If(canWrite,
+				HolidayImportForm(csrfToken, year),
+			),
+			g.If(
+				len(holidays) == 0,
+				Div(
+					Class("alert alert-info"),
+					Role("alert"),
+					g.Textf("No holidays in %v yet.", year),
+				),
+			),
+			g.If(
+				len(holidays) > 0,
+				Table(
+					Class("table table-sm table-borderless"),
+					TBody(
+						g.Group(
+							g.Map(holidays, func(holiday *Holiday) g.Node {
+								return HolidayRow(canWrite, holiday)
+							}),
+						),
+					),
+				),
+			),
+		),
+	)
+}
+
+func HolidayRow(canWrite bool, holiday *Holiday) g.Node {
+	return Tr(
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Td(
+			Class("text-nowrap"),
+			g.Text(time_utils.FormatDateDE(holiday.Day)),
+		),
+		Td(
+			Class("w-100"),
+			g.Text(holiday.Title),
+		),
+		Td(
+			g.If(canWrite,
+				A(
+					ghx.Confirm(fmt.Sprintf("Do you really want to delete the holiday %v?", holiday.Title)),
+					ghx.Delete(fmt.Sprintf("/api/holidays/%v", holiday.ID)),
+					Class("btn btn-outline-secondary btn-sm"),
+					I(Class("bi-trash2")),
+				),
+			),
+		),
+	)
+}
+
+func HolidayGenerationForm(csrfToken string, year int) g.Node {
+	states := make([]string, 0, len(FederalStates))
+	for state := range FederalStates {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return FederalStates[states[i]] < FederalStates[states[j]]
+	})
+
+	return FormEl(
+		Class("mb-2"),
+		ghx.Post("/holidays/generate"),
+		ghx.Target("#baralga__main_content_modal_content"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(csrfToken),
+		),
+		Input(
+			Type("hidden"),
+			Name("Year"),
+			Value(strconv.Itoa(year)),
+		),
+		Div(
+			Class("input-group"),
+			Select(
+				Name("State"),
+				Class("form-select"),
+				TitleAttr("Federal State"),
+				Option(
+					Value(""),
+					g.Text("Public holidays of federal state ..."),
+				),
+				g.Group(
+					g.Map(states, func(state string) g.Node {
+						return Option(
+							Value(state),
+							g.Text(FederalStates[state]),
+						)
+					}),
+				),
+			),
+			Button(
+				Class("btn btn-outline-primary"),
+				TitleAttr(fmt.Sprintf("Add Public Holidays %v", year)),
+				I(Class("bi-plus")),
+			),
+		),
+	)
+}
+
+func HolidayImportForm(csrfToken string, year int) g.Node {
+	return FormEl(
+		Class("mb-4"),
+		ghx.Post(fmt.Sprintf("/holidays/import?year=%v", year)),
+		ghx.Encoding("multipart/form-data"),
+		ghx.Target("#baralga__main_content_modal_content"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(csrfToken),
+		),
+		Div(
+			Class("input-group"),
+			Input(
+				Type("file"),
+				Name("Calendar"),
+				Accept(".ics,text/calendar"),
+				g.Attr("required", "required"),
+				Class("form-control"),
+				TitleAttr("iCalendar File"),
+			),
+			Button(
+				Class("btn btn-outline-primary"),
+				TitleAttr("Import Holidays"),
+				I(Class("bi-upload")),
+			),
+		),
+	)
+}
@@ -0,0 +1,150 @@
+package tracking
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleHolidaysPage(t *testing.T) {
+	is := is.New(t)
+
+	holidayRepository := NewInMemHolidayRepository()
+	holidayRepository.holidays = append(holidayRepository.holidays, &Holiday{
+		ID:    uuid.New(),
+		Day:   time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC),
+		Title: "Christmas Eve",
+	})
+
+	a := &HolidayWebHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	t.Run("AsUser", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/holidays?year=2024", nil)
+		r.Header.Add("HX-Request", "true")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+			Roles: []string{"ROLE_USER"},
+		}))
+
+		a.HandleHolidaysPage()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+		htmlBody := httpRec.Body.String()
+		is.True(strings.Contains(htmlBody, "Holidays 2024"))
+		is.True(strings.Contains(htmlBody, "Christmas Eve"))
+		is.True(!strings.Contains(htmlBody, "/holidays/import"))
+	})
+
+	t.Run("AsAdmin", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/holidays?year=2024", nil)
+		r.Header.Add("HX-Request", "true")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+			Roles: []string{"ROLE_ADMIN"},
+		}))
+
+		a.HandleHolidaysPage()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+		htmlBody := httpRec.Body.String()
+		is.True(strings.Contains(htmlBody, "/holidays/import"))
+		is.True(strings.Contains(htmlBody, "Baden-Württemberg"))
+	})
+}
+
+func TestHandleGenerateHolidaysForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayRepository := NewInMemHolidayRepository()
+	a := &HolidayWebHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	data := url.Values{}
+	data["State"] = []string{"SN"}
+	data["Year"] = []string{"2024"}
+
+	r, _ := http.NewRequest("POST", "/holidays/generate", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_ADMIN"},
+	}))
+
+	a.HandleGenerateHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__activities-changed")
+	is.Equal(len(holidayRepository.holidays), 11)
+	is.True(strings.Contains(httpRec.Body.String(), "Day of Repentance and Prayer"))
+}
+
+func TestHandleImportHolidaysForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayRepository := NewInMemHolidayRepository()
+	a := &HolidayWebHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("Calendar", "holidays.ics")
+	is.NoErr(err)
+	_, err = part.Write([]byte(holidayCalendarSample))
+	is.NoErr(err)
+	is.NoErr(writer.Close())
+
+	r, _ := http.NewRequest("POST", "/holidays/import?year=2024", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_ADMIN"},
+	}))
+
+	a.HandleImportHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(holidayRepository.holidays), 3)
+	is.True(strings.Contains(httpRec.Body.String(), "Company Holidays, Team"))
+}
+
+func TestHandleGenerateHolidaysFormAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	holidayRepository := NewInMemHolidayRepository()
+	a := &HolidayWebHandlers{
+		config:         &shared.Config{},
+		holidayService: NewHolidayService(shared.NewInMemRepositoryTxer(), holidayRepository),
+	}
+
+	data := url.Values{}
+	data["State"] = []string{"SN"}
+	data["Year"] = []string{"2024"}
+
+	r, _ := http.NewRequest("POST", "/holidays/generate", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles: []string{"ROLE_USER"},
+	}))
+
+	a.HandleGenerateHolidays()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+	is.Equal(len(holidayRepository.holidays), 0)
+}
@@ -48,11 +48,17 @@ func NewProjectController(config *shared.Config, projectRepository ProjectReposi
 }
 
 func (a *ProjectRestHandlers) RegisterProtected(r chi.Router) {
-	r.Get("/projects", a.HandleGetProjects())
-	r.Post("/projects", a.HandleCreateProject())
-	r.Get("/projects/{project-id}", a.HandleGetProject())
-	r.Delete("/projects/{project-id}", a.HandleDeleteProject())
-	r.Patch("/projects/{project-id}", a.HandleUpdateProject())
+}
+
+func (a *ProjectRestHandlers) RegisterScoped(r chi.Router) {
+	read := shared.RequireScope(shared.ScopeProjectsRead)
+	write := shared.RequireScope(shared.ScopeProjectsWrite)
+
+	r.With(read).Get("/projects", a.HandleGetProjects())
+	r.With(write).Post("/projects", a.HandleCreateProject())
+	r.With(read).Get("/projects/{project-id}", a.HandleGetProject())
+	r.With(write).Delete("/projects/{project-id}", a.HandleDeleteProject())
+	r.With(write).Patch("/projects/{project-id}", a.HandleUpdateProject())
 }
 
 func (a *ProjectRestHandlers) RegisterOpen(r chi.Router) {
@@ -86,7 +92,7 @@ func (a *ProjectRestHandlers) HandleGetProjects() http.HandlerFunc {
 		}
 
 		selfLink := hal.NewSelfLink(r.RequestURI)
-		if principal.HasRole("ROLE_ADMIN") {
+		if principal.HasPermission(shared.PermissionProjectsWrite) {
 			projectsModel.Links = hal.NewLinks(
 				selfLink,
 				hal.NewLink("create", "/api/projects"),
@@ -146,7 +152,7 @@ func (a *ProjectRestHandlers) HandleCreateProject() http.HandlerFunc {
 			return
 		}
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
@@ -198,7 +204,7 @@ func (a *ProjectRestHandlers) HandleUpdateProject() http.HandlerFunc {
 			return
 		}
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
@@ -246,7 +252,7 @@ func (a *ProjectRestHandlers) HandleDeleteProject() http.HandlerFunc {
 
 		principal := shared.MustPrincipalFromContext(r.Context())
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
@@ -292,7 +298,7 @@ func mapToProjectModel(principal *shared.Principal, project *Project) *projectMo
 		Active:      project.Active,
 	}
 	selfLink := hal.NewSelfLink(fmt.Sprintf("/api/projects/%s", projectModel.ID))
-	if principal.HasRole("ROLE_ADMIN") {
+	if principal.HasPermission(shared.PermissionProjectsWrite) && principal.HasScope(shared.ScopeProjectsWrite) {
 		projectModel.Links = hal.NewLinks(
 			selfLink,
 			hal.NewLink("create", selfLink.Href()),
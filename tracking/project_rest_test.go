@@ -394,6 +394,40 @@ func TestHandleCreateProject(t *testing.T) {
 	is.Equal(countBefore+1, len(repo.projects))
 }
 
+func TestHandleCreateProjectWithCustomRole(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	repo := NewInMemProjectRepository()
+
+	c := &ProjectRestHandlers{
+		config: &shared.Config{},
+		projectService: &ProjectService{
+			repositoryTxer:    shared.NewInMemRepositoryTxer(),
+			projectRepository: repo,
+		},
+		projectRepository: repo,
+	}
+
+	countBefore := len(repo.projects)
+	body := `
+	{
+		"title": "My new Title",
+		"description": "My new Description"
+	}
+	`
+
+	r, _ := http.NewRequest("POST", "/api/projects", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Roles:       []string{"ROLE_PROJECT_LEAD"},
+		Permissions: []string{shared.PermissionProjectsWrite},
+	}))
+
+	c.HandleCreateProject()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+	is.Equal(countBefore+1, len(repo.projects))
+}
+
 func TestHandleInvalidCreateProject(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -545,3 +579,21 @@ func TestHandleDeleteProjectIdNotValid(t *testing.T) {
 	c.HandleDeleteProject()(httpRec, r)
 	is.Equal(httpRec.Result().StatusCode, http.StatusNotAcceptable)
 }
+
+func TestMapToProjectModelWithReadOnlyScope(t *testing.T) {
+	is := is.New(t)
+
+	principal := &shared.Principal{
+		Roles:  []string{"ROLE_ADMIN"},
+		Scopes: []string{shared.ScopeProjectsRead},
+	}
+
+	project := &Project{
+		ID:    uuid.New(),
+		Title: "My Title",
+	}
+
+	projectModel := mapToProjectModel(principal, project)
+
+	is.Equal(1, projectModel.Links.Size())
+}
@@ -131,7 +131,7 @@ func (a *ProjectWeb) HandleProjectEdit() http.HandlerFunc {
 			return
 		}
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			http.Error(w, "No permission.", http.StatusForbidden)
 			return
 		}
@@ -168,7 +168,7 @@ func (a *ProjectWeb) HandleProjectEditForm() http.HandlerFunc {
 			return
 		}
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			http.Error(w, "No permission.", http.StatusForbidden)
 			return
 		}
@@ -216,7 +216,7 @@ func (a *ProjectWeb) HandleProjectForm() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		principal := shared.MustPrincipalFromContext(r.Context())
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			http.Error(w, "No permission.", http.StatusForbidden)
 			return
 		}
@@ -294,7 +294,7 @@ func (a *ProjectWeb) HandleArchiveProject() http.HandlerFunc {
 			return
 		}
 
-		if !principal.HasRole("ROLE_ADMIN") {
+		if !principal.HasPermission(shared.PermissionProjectsWrite) {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
@@ -370,7 +370,7 @@ func ProjectsView(principal *shared.Principal, formModel projectFormModel, proje
 		Div(
 			Class("modal-body"),
 			g.If(
-				principal.HasRole("ROLE_ADMIN"),
+				principal.HasPermission(shared.PermissionProjectsWrite),
 				ProjectNewForm(formModel, ""),
 			),
 			g.Group(
@@ -400,7 +400,7 @@ func ProjectRow(principal *shared.Principal, project *Project) g.Node {
 						g.Text(project.Title),
 					),
 					g.If(
-						principal.HasRole("ROLE_ADMIN"),
+						principal.HasPermission(shared.PermissionProjectsWrite),
 						A(
 							ghx.Get(fmt.Sprintf("/projects/%v/edit", project.ID)),
 							Class("btn btn-outline-secondary btn-sm ms-1"),
@@ -408,7 +408,7 @@ func ProjectRow(principal *shared.Principal, project *Project) g.Node {
 						),
 					),
 					g.If(
-						principal.HasRole("ROLE_ADMIN"),
+						principal.HasPermission(shared.PermissionProjectsWrite),
 						A(
 							ghx.Confirm(fmt.Sprintf("Do you really want to delete project %v?", project.Title)),
 							ghx.Delete(fmt.Sprintf("/api/projects/%v", project.ID)),
@@ -417,7 +417,7 @@ func ProjectRow(principal *shared.Principal, project *Project) g.Node {
 						),
 					),
 					g.If(
-						principal.HasRole("ROLE_ADMIN"),
+						principal.HasPermission(shared.PermissionProjectsWrite),
 						A(
 							ghx.Confirm(fmt.Sprintf("Do you really want to archive project %v?", project.Title)),
 							ghx.Get(fmt.Sprintf("/projects/%v/archive", project.ID)),
@@ -19,14 +19,16 @@ import (
 )
 
 type ReportWeb struct {
-	config          *shared.Config
-	activityService *ActitivityService
+	config             *shared.Config
+	activityService    *ActitivityService
+	workingTimeService *WorkingTimeService
 }
 
-func NewReportWebHandlers(config *shared.Config, activityService *ActitivityService) *ReportWeb {
+func NewReportWebHandlers(config *shared.Config, activityService *ActitivityService, workingTimeService *WorkingTimeService) *ReportWeb {
 	return &ReportWeb{
-		config:          config,
-		activityService: activityService,
+		config:             config,
+		activityService:    activityService,
+		workingTimeService: workingTimeService,
 	}
 }
 
@@ -50,7 +52,7 @@ func (a *ReportWeb) HandleReportPage() http.HandlerFunc {
 		}
 
 		queryParams := r.URL.Query()
-		filter, err := filterFromQueryParams(queryParams)
+		filter, err := filterFromQueryParams(queryParams, principal.Location())
 		if err != nil {
 			shared.RenderProblemHTML(w, isProduction, errors.New("invalid query params"))
 			return
@@ -58,7 +60,13 @@ func (a *ReportWeb) HandleReportPage() http.HandlerFunc {
 
 		view := reportViewFromQueryParams(queryParams, filter.Timespan)
 
-		reportView, err := a.ReportView(pageContext, view, filter)
+		teams, err := a.activityService.ReadTeams(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		reportView, err := a.ReportView(pageContext, view, filter, teams)
 		if err != nil {
 			shared.RenderProblemHTML(w, isProduction, errors.New("invalid reports"))
 			return
@@ -73,12 +81,12 @@ func (a *ReportWeb) HandleReportPage() http.HandlerFunc {
 	}
 }
 
-func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView, filter *ActivityFilter) (g.Node, error) {
+func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView, filter *ActivityFilter, teams []*shared.TeamMembers) (g.Node, error) {
 	previousFilter := filter.Previous()
 	homeFilter := filter.Home()
 	nextFilter := filter.Next()
 
-	var reportGeneralView, reportTimeView, reportProjectView, reportTagView g.Node
+	var reportGeneralView, reportTimeView, reportProjectView, reportTagView, reportWorkingTimeView, reportComplianceView g.Node
 	var err error
 	if view.main == "general" {
 		reportGeneralView, err = a.reportGeneralView(pageContext, filter, view)
@@ -104,6 +112,18 @@ func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView
 			return nil, err
 		}
 	}
+	if view.main == "working" {
+		reportWorkingTimeView, err = a.reportWorkingTimeView(pageContext, view, filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if view.main == "compliance" {
+		reportComplianceView, err = a.reportComplianceView(pageContext, filter)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return Div(
 		ID("baralga__report_content"),
@@ -119,7 +139,7 @@ func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView
 			Div(
 				Class("col-md-4 col-12 mt-2"),
 				Select(
-					ghx.Get(fmt.Sprintf("/reports?c=%v", view.asParam())),
+					ghx.Get(reportHrefForTimespan(filter, view)),
 					ghx.PushURL("true"),
 					ghx.Target("#baralga__report_content"),
 					ghx.Swap("outerHTML"),
@@ -212,7 +232,7 @@ func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView
 				Class("col-1 text-end mt-2"),
 				A(
 					Href(
-						fmt.Sprintf("/api/activities?contentType=application/vnd.ms-excel&t=%v&v=%v", filter.Timespan, filter.String()),
+						exportHref(filter),
 					),
 					Class("btn btn-outline-primary"),
 					I(Class("bi-file-excel")),
@@ -221,6 +241,9 @@ func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView
 			),
 		),
 
+		g.If(len(teams) > 0,
+			reportTeamFilterView(pageContext.Principal, view, filter, teams),
+		),
 		Div(
 			Class("row mb-lg-4 mb-2"),
 			Div(
@@ -294,6 +317,40 @@ func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView
 						g.Text("Tag"),
 						Class("nav-link"),
 					),
+					A(
+						g.If(view.main == "working",
+							Class("nav-link active"),
+						),
+						g.If(view.main != "working",
+							g.Group([]g.Node{
+								Class("btn nav-link"),
+								ghx.Get(reportHrefForView(filter, "working", "d")),
+								ghx.PushURL("true"),
+								ghx.Target("#baralga__report_content"),
+								ghx.Swap("outerHTML"),
+							}),
+						),
+						I(Class("bi-hourglass-split me-2")),
+						g.Text("Working Time"),
+						Class("nav-link"),
+					),
+					A(
+						g.If(view.main == "compliance",
+							Class("nav-link active"),
+						),
+						g.If(view.main != "compliance",
+							g.Group([]g.Node{
+								Class("btn nav-link"),
+								ghx.Get(reportHrefForView(filter, "compliance", "")),
+								ghx.PushURL("true"),
+								ghx.Target("#baralga__report_content"),
+								ghx.Swap("outerHTML"),
+							}),
+						),
+						I(Class("bi-shield-check me-2")),
+						g.Text("Compliance"),
+						Class("nav-link"),
+					),
 				),
 			),
 		),
@@ -309,6 +366,12 @@ func (a *ReportWeb) ReportView(pageContext *shared.PageContext, view *reportView
 		g.If(view.main == "tag",
 			reportTagView,
 		),
+		g.If(view.main == "working",
+			reportWorkingTimeView,
+		),
+		g.If(view.main == "compliance",
+			reportComplianceView,
+		),
 	), nil
 }
 
@@ -332,6 +395,11 @@ func (a *ReportWeb) reportTimeView(pageContext *shared.PageContext, view *report
 		return nil, err
 	}
 
+	settings, err := a.activityService.ReadOrganizationSettings(pageContext.Ctx, pageContext.Principal)
+	if err != nil {
+		return nil, err
+	}
+
 	var reportView g.Node
 	var showWeekView, showMonthView, showQuarterView bool
 
@@ -347,9 +415,9 @@ func (a *ReportWeb) reportTimeView(pageContext *shared.PageContext, view *report
 	case "q":
 		reportView = reportByQuarterView(timeReports)
 	case "d":
-		reportView = reportByDayView(timeReports)
+		reportView = reportByDayView(timeReports, settings.DateFormat)
 	default:
-		reportView = reportByDayView(timeReports)
+		reportView = reportByDayView(timeReports, settings.DateFormat)
 	}
 	if err != nil {
 		return nil, err
@@ -495,7 +563,281 @@ func (a *ReportWeb) reportProjectView(pageContext *shared.PageContext, view *rep
 	}), nil
 }
 
-func reportByDayView(timeReports []*ActivityTimeReportItem) g.Node {
+func (a *ReportWeb) reportWorkingTimeView(pageContext *shared.PageContext, view *reportView, filter *ActivityFilter) (g.Node, error) {
+	var aggregateBy string
+	switch view.sub {
+	case "w":
+		aggregateBy = WorkingTimeByWeek
+	case "m":
+		aggregateBy = WorkingTimeByMonth
+	default:
+		aggregateBy = WorkingTimeByDay
+	}
+
+	account, err := a.workingTimeService.ReadWorkingTimeAccount(pageContext.Ctx, pageContext.Principal, filter, aggregateBy)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := a.activityService.ReadOrganizationSettings(pageContext.Ctx, pageContext.Principal)
+	if err != nil {
+		return nil, err
+	}
+
+	if !account.HasTarget() {
+		return Div(
+			Class("alert alert-info"),
+			Role("alert"),
+			g.Text("No target working hours set up yet. Set them up "),
+			A(
+				Href("#"),
+				Class("info-link"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Get("/working-time/target"),
+				g.Text("here"),
+			),
+			g.Text("!"),
+		), nil
+	}
+
+	showWeekView := filter.Timespan != TimespanDay
+	showMonthView := filter.Timespan != TimespanDay && filter.Timespan != TimespanWeek
+
+	var reportView g.Node
+	if len(account.Items) == 0 {
+		reportView = Div(
+			Class("alert alert-info mt-2"),
+			Role("alert"),
+			g.Text(fmt.Sprintf("No working time in %v.", filter.String())),
+		)
+	} else {
+		reportView = reportWorkingTimeItemsView(account, aggregateBy, settings.DateFormat)
+	}
+
+	balanceClass := "text-success"
+	if account.BalanceMinutes < 0 {
+		balanceClass = "text-danger"
+	}
+
+	return g.Group([]g.Node{
+		Div(
+			Class("row mb-3"),
+			Div(
+				Class("col"),
+				Small(Class("text-muted d-block"), g.Text("Overtime Balance")),
+				Span(
+					Class(fmt.Sprintf("fs-4 %v", balanceClass)),
+					g.Text(account.BalanceFormatted()),
+				),
+			),
+			Div(
+				Class("col text-end"),
+				Small(
+					Class("text-muted d-block"),
+					g.Textf("Target %v per week since %v", account.Target.WeeklyFormatted(), time_utils.FormatDateDE(account.Target.ValidFrom)),
+				),
+				A(
+					Href("#"),
+					Class("btn btn-outline-secondary btn-sm mt-1"),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					ghx.Get("/working-time/target"),
+					I(Class("bi-pencil me-2")),
+					g.Text("Edit Target"),
+				),
+			),
+		),
+		Nav(
+			Div(
+				Class("nav nav-tabs"),
+				A(
+					g.If(view.sub == "d",
+						Class("nav-link active"),
+					),
+					g.If(view.sub != "d",
+						g.Group([]g.Node{
+							Class("nav-link"),
+							ghx.Get(reportHrefForView(filter, "working", "d")),
+							ghx.PushURL("true"),
+							ghx.Target("#baralga__report_content"),
+							ghx.Swap("outerHTML"),
+						}),
+					),
+					Type("button"),
+					g.Text("By Day"),
+				),
+				g.If(showWeekView,
+					A(
+						g.If(view.sub == "w",
+							Class("nav-link active"),
+						),
+						g.If(view.sub != "w",
+							g.Group([]g.Node{
+								Class("nav-link"),
+								ghx.Get(reportHrefForView(filter, "working", "w")),
+								ghx.PushURL("true"),
+								ghx.Target("#baralga__report_content"),
+								ghx.Swap("outerHTML"),
+							}),
+						),
+						Type("button"),
+						g.Text("By Week"),
+					),
+				),
+				g.If(showMonthView,
+					A(
+						g.If(view.sub == "m",
+							Class("nav-link active"),
+						),
+						g.If(view.sub != "m",
+							g.Group([]g.Node{
+								Class("nav-link"),
+								ghx.Get(reportHrefForView(filter, "working", "m")),
+								ghx.PushURL("true"),
+								ghx.Target("#baralga__report_content"),
+								ghx.Swap("outerHTML"),
+							}),
+						),
+						Type("button"),
+						g.Text("By Month"),
+					),
+				),
+			),
+		),
+		Div(
+			Class("tab-content"),
+			reportView,
+		),
+	}), nil
+}
+
+func (a *ReportWeb) reportComplianceView(pageContext *shared.PageContext, filter *ActivityFilter) (g.Node, error) {
+	violations, err := a.activityService.ComplianceViolations(pageContext.Ctx, pageContext.Principal, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(violations) == 0 {
+		return Div(
+			Class("alert alert-success"),
+			Role("alert"),
+			I(Class("bi-check-circle me-2")),
+			g.Text(fmt.Sprintf("No violations of the German working time law (ArbZG) in %v.", filter.String())),
+		), nil
+	}
+
+	return Table(
+		ID("compliance-report"),
+		Class("table table-borderless table-striped"),
+		THead(
+			Tr(
+				Th(g.Text("Day")),
+				Th(g.Text("Rule")),
+				Th(g.Text("Violation")),
+			),
+		),
+		TBody(
+			g.Group(g.Map(violations, func(violation *ComplianceViolation) g.Node {
+				return Tr(
+					Td(
+						Class("text-nowrap"),
+						g.Text(violation.DayFormatted()),
+					),
+					Td(
+						Class("text-nowrap"),
+						g.Text(violation.RuleFormatted()),
+					),
+					Td(g.Text(violation.Message)),
+				)
+			})),
+		),
+	), nil
+}
+
+func reportWorkingTimeItemsView(account *WorkingTimeAccount, aggregateBy, dateFormat string) g.Node {
+	formatItem := func(item *WorkingTimeItem) string {
+		switch aggregateBy {
+		case WorkingTimeByWeek:
+			// weeks not starting on Monday are named by the ISO week most of their days belong to
+			year, week := item.Start.AddDate(0, 0, 3).ISOWeek()
+			return fmt.Sprintf("Week %v/%v", week, year)
+		case WorkingTimeByMonth:
+			return item.Start.Format("January 2006")
+		default:
+			return item.Start.Format(dateFormat + " Monday")
+		}
+	}
+
+	balanceTotal := &WorkingTimeItem{
+		ActualMinutes: account.ActualMinutes,
+		TargetMinutes: account.TargetMinutes,
+	}
+
+	return Table(
+		ID("working-time-report"),
+		Class("table table-striped"),
+		THead(
+			Tr(
+				Th(g.Text("Period")),
+				Th(
+					Class("text-end"),
+					g.Text("Actual"),
+				),
+				Th(
+					Class("text-end"),
+					g.Text("Target"),
+				),
+				Th(
+					Class("text-end"),
+					g.Text("Balance"),
+				),
+			),
+		),
+		TBody(
+			g.Group(g.Map(account.Items, func(item *WorkingTimeItem) g.Node {
+				return Tr(
+					Td(
+						g.Text(formatItem(item)),
+					),
+					Td(
+						Class("text-end"),
+						g.Text(item.ActualFormatted()),
+					),
+					Td(
+						Class("text-end"),
+						g.Text(item.TargetFormatted()),
+					),
+					Td(
+						g.If(item.BalanceMinutes() < 0, Class("text-end text-danger")),
+						g.If(item.BalanceMinutes() >= 0, Class("text-end")),
+						g.Text(item.BalanceFormatted()),
+					),
+				)
+			}),
+			),
+		),
+		TFoot(
+			Tr(
+				Th(g.Text("Total")),
+				Th(
+					Class("text-end"),
+					g.Text(balanceTotal.ActualFormatted()),
+				),
+				Th(
+					Class("text-end"),
+					g.Text(balanceTotal.TargetFormatted()),
+				),
+				Th(
+					Class("text-end"),
+					g.Text(balanceTotal.BalanceFormatted()),
+				),
+			),
+		),
+	)
+}
+
+func reportByDayView(timeReports []*ActivityTimeReportItem, dateFormat string) g.Node {
 	return Table(
 		ID("time-report-by-day"),
 		Class("table table-striped"),
@@ -512,7 +854,7 @@ func reportByDayView(timeReports []*ActivityTimeReportItem) g.Node {
 			g.Group(g.Map(timeReports, func(reportItem *ActivityTimeReportItem) g.Node {
 				return Tr(
 					Td(
-						g.Text(reportItem.AsTime().Format("02.01.2006 Monday")),
+						g.Text(reportItem.AsTime().Format(dateFormat+" Monday")),
 					),
 					Td(
 						Class("text-end"),
@@ -1028,6 +1370,28 @@ func reportHrefForPage(filter *ActivityFilter, view *reportView, page int) strin
 	return fmt.Sprintf("%v&p=%v", reportHref(filter, view), page)
 }
 
+// reportHrefForTimespan links to the report of another timespan which is passed as query param t
+func reportHrefForTimespan(filter *ActivityFilter, view *reportView) string {
+	reportHref := fmt.Sprintf("/reports?c=%v", view.asParam())
+
+	if filter.Team() != uuid.Nil {
+		reportHref += fmt.Sprintf("&team=%v", filter.Team())
+	}
+
+	return reportHref
+}
+
+// exportHref links to the export of the activities as Excel file
+func exportHref(filter *ActivityFilter) string {
+	exportHref := fmt.Sprintf("/api/activities?contentType=application/vnd.ms-excel&t=%v&v=%v", filter.Timespan, filter.String())
+
+	if filter.Team() != uuid.Nil {
+		exportHref += fmt.Sprintf("&team=%v", filter.Team())
+	}
+
+	return exportHref
+}
+
 func reportHref(filter *ActivityFilter, view *reportView) string {
 	reportHref := fmt.Sprintf("/reports?t=%v&v=%v&c=%v", filter.Timespan, filter.String(), view.asParam())
 
@@ -1040,9 +1404,50 @@ func reportHref(filter *ActivityFilter, view *reportView) string {
 		reportHref += "&tags=" + strings.Join(filter.Tags(), ",")
 	}
 
+	if filter.Team() != uuid.Nil {
+		reportHref += fmt.Sprintf("&team=%v", filter.Team())
+	}
+
 	return reportHref
 }
 
+// reportTeamFilterView selects the team whose activities are reported
+func reportTeamFilterView(principal *shared.Principal, view *reportView, filter *ActivityFilter, teams []*shared.TeamMembers) g.Node {
+	noTeamTitle := "My Activities"
+	if principal.HasPermission(shared.PermissionActivitiesReadAll) {
+		noTeamTitle = "All Activities"
+	}
+
+	return Div(
+		Class("row mb-2"),
+		Div(
+			Class("col-md-4 col-12"),
+			Select(
+				ghx.Get(reportHref(filter.WithTeam(uuid.Nil), view)),
+				ghx.PushURL("true"),
+				ghx.Target("#baralga__report_content"),
+				ghx.Swap("outerHTML"),
+
+				Name("team"),
+				Class("form-select"),
+				TitleAttr("Team"),
+				Option(
+					Value(""),
+					g.Text(noTeamTitle),
+					g.If(filter.Team() == uuid.Nil, Selected()),
+				),
+				g.Group(g.Map(teams, func(team *shared.TeamMembers) g.Node {
+					return Option(
+						Value(team.TeamID.String()),
+						g.Text(fmt.Sprintf("Team %v", team.Name)),
+						g.If(filter.Team() == team.TeamID, Selected()),
+					)
+				})),
+			),
+		),
+	)
+}
+
 func (a *ReportWeb) ReportPage(pageContext *shared.PageContext, reportView g.Node) g.Node {
 	return shared.Page(
 		pageContext.Title,
@@ -1080,8 +1485,24 @@ func reportViewFromQueryParams(params url.Values, timespan string) *reportView {
 		}
 	}
 
-	// Tag view doesn't need sub-views for now
-	if reportView.main == "tag" {
+	if reportView.main == "working" {
+		if len(cParts) > 1 {
+			reportView.sub = cParts[1]
+			if reportView.sub != "d" && reportView.sub != "w" && reportView.sub != "m" {
+				reportView.sub = "d"
+			}
+			if timespan == "week" && reportView.sub == "m" {
+				reportView.sub = "w"
+			} else if timespan == "day" {
+				reportView.sub = "d"
+			}
+		} else {
+			reportView.sub = "d"
+		}
+	}
+
+	// Tag and compliance view don't need sub-views for now
+	if reportView.main == "tag" || reportView.main == "compliance" {
 		reportView.sub = ""
 	}
 
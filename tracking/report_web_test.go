@@ -58,6 +58,51 @@ func TestHandleReportPageWithTimeByDay(t *testing.T) {
 	is.True(strings.Contains(htmlBody, "id=\"time-report-by-day\""))
 }
 
+func TestHandleReportPageWithTimeByDayInDateFormat(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	activityService := &ActitivityService{
+		activityRepository: &InMemActivityRepository{
+			activities: []*Activity{
+				{
+					Start:          time.Date(2021, 11, 16, 9, 0, 0, 0, time.UTC),
+					End:            time.Date(2021, 11, 16, 10, 0, 0, 0, time.UTC),
+					OrganizationID: shared.OrganizationIDSample,
+					Username:       "user1",
+				},
+			},
+		},
+	}
+	activityService.SetOrganizationSettingsReader(func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error) {
+		return &shared.OrganizationSettings{
+			WeekStart:   time.Monday,
+			WorkingDays: []time.Weekday{time.Monday},
+			DateFormat:  shared.DateFormatUS,
+		}, nil
+	})
+
+	a := &ReportWeb{
+		config:          &shared.Config{},
+		activityService: activityService,
+	}
+
+	r, _ := http.NewRequest("GET", "/reports?t=month&v=2021-11&c=time:d", nil)
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Target", "baralga__report_content")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleReportPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "11/16/2021 Tuesday"))
+	is.True(!strings.Contains(htmlBody, "16.11.2021 Tuesday"))
+}
+
 func TestHandleReportPageWithTimeByWeek(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -150,6 +195,127 @@ func TestHandleReportPageWithProject(t *testing.T) {
 	is.True(strings.Contains(htmlBody, "id=\"project-report\""))
 }
 
+func TestHandleReportPageWithWorkingTime(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	_, _ = workingTimeRepository.UpsertWorkingTimeTarget(context.Background(), &WorkingTimeTarget{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		ValidFrom:      time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+	})
+
+	a := &ReportWeb{
+		config:          &shared.Config{},
+		activityService: &ActitivityService{},
+		workingTimeService: &WorkingTimeService{
+			workingTimeRepository: workingTimeRepository,
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+			activityRepository:    NewInMemActivityRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/reports?t=month&v=2021-11&c=working:w", nil)
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Target", "baralga__report_content")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleReportPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "id=\"working-time-report\""))
+	is.True(strings.Contains(htmlBody, "Week 45/2021"))
+	is.True(strings.Contains(htmlBody, "Overtime Balance"))
+}
+
+func TestHandleReportPageWithWorkingTimeWithoutTarget(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ReportWeb{
+		config:          &shared.Config{},
+		activityService: &ActitivityService{},
+		workingTimeService: &WorkingTimeService{
+			workingTimeRepository: NewInMemWorkingTimeRepository(),
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+			activityRepository:    NewInMemActivityRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/reports?c=working:d", nil)
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Target", "baralga__report_content")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleReportPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "No target working hours set up yet."))
+}
+
+func TestHandleReportPageWithCompliance(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	activityRepository := NewInMemActivityRepository()
+	activityRepository.activities = []*Activity{
+		activityOf(2021, 11, 14, 10, 0, 12, 0),
+	}
+
+	a := &ReportWeb{
+		config: &shared.Config{},
+		activityService: &ActitivityService{
+			activityRepository: activityRepository,
+			holidayRepository:  NewInMemHolidayRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/reports?t=month&v=2021-11&c=compliance", nil)
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Target", "baralga__report_content")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleReportPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "compliance-report"))
+	is.True(strings.Contains(htmlBody, "Worked on a Sunday."))
+}
+
+func TestHandleReportPageWithComplianceWithoutViolations(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ReportWeb{
+		config: &shared.Config{},
+		activityService: &ActitivityService{
+			activityRepository: NewInMemActivityRepository(),
+			holidayRepository:  NewInMemHolidayRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/reports?t=month&v=2021-11&c=compliance", nil)
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Target", "baralga__report_content")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleReportPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "No violations of the German working time law (ArbZG) in 2021-11."))
+}
+
 func TestHandleReportPageWithTag(t *testing.T) {
 	is := is.New(t)
 	httpRec := httptest.NewRecorder()
@@ -481,4 +647,17 @@ func TestReportViewFromQueryParams(t *testing.T) {
 		is.Equal(view.main, "tag")
 		is.Equal(view.sub, "")
 	})
+	t.Run("view with week and working time by month", func(t *testing.T) {
+		// Arrange
+		params := make(url.Values)
+		params["t"] = []string{"week"}
+		params["c"] = []string{"working:m"}
+
+		// Act
+		view := reportViewFromQueryParams(params, "week")
+
+		// Assert
+		is.Equal(view.main, "working")
+		is.Equal(view.sub, "w")
+	})
 }
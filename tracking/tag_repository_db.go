@@ -252,6 +252,9 @@ func (r *DbTagRepository) GetTagReportData(ctx context.Context, filter *Activiti
 	if filter.Username != "" {
 		baseQuery += ` AND username = $` + strconv.Itoa(argIndex)
 		args = append(args, filter.Username)
+	} else if filter.Usernames != nil {
+		baseQuery += ` AND username = ANY($` + strconv.Itoa(argIndex) + `)`
+		args = append(args, filter.Usernames)
 	}
 
 	// For tag reports, we want to aggregate all activities for each tag across the time period
@@ -223,6 +223,7 @@ func (s *TagService) GenerateTagReports(ctx context.Context, organizationID uuid
 		SortBy:         filter.SortBy,
 		SortOrder:      filter.SortOrder,
 		Username:       filter.Username,
+		Usernames:      filter.Usernames,
 		OrganizationID: organizationID,
 	}
 
@@ -25,8 +25,27 @@ func ParseDateTime(dateTime string) (*time.Time, error) {
 	return &t, nil
 }
 
+// ParseDateTimeInLocation parses a date time with offset (RFC 3339) or a date time without offset in the given location
+func ParseDateTimeInLocation(dateTime string, location *time.Location) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, dateTime)
+	if err == nil {
+		return &t, nil
+	}
+
+	t, err = time.ParseInLocation(dateTimeFormat, dateTime, location)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse date time from '%s'", dateTime)
+	}
+	return &t, nil
+}
+
 func ParseDateTimeForm(dateTime string) (*time.Time, error) {
-	t, err := time.Parse(dateTimeFormatForm, dateTime)
+	return ParseDateTimeFormInLocation(dateTime, time.UTC)
+}
+
+// ParseDateTimeFormInLocation parses a date time as entered in a form in the given location
+func ParseDateTimeFormInLocation(dateTime string, location *time.Location) (*time.Time, error) {
+	t, err := time.ParseInLocation(dateTimeFormatForm, dateTime, location)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse date time from '%s'", dateTime)
 	}
@@ -38,6 +57,16 @@ func FormatMinutesAsDuration(minutes float64) string {
 	return fmt.Sprintf("%v:%02d h", math.Floor(minutes/60), int(minutes)%60)
 }
 
+// FormatMinutesAsBalance formates the balance in minutes as signed formatted string (e.g. +1:15 h or -0:30 h)
+func FormatMinutesAsBalance(minutes int) string {
+	sign := "+"
+	if minutes < 0 {
+		sign = "-"
+		minutes = -minutes
+	}
+	return fmt.Sprintf("%v%v:%02d h", sign, minutes/60, minutes%60)
+}
+
 func FormatTime(dateTime time.Time) string {
 	return dateTime.Format(timeFormat)
 }
@@ -59,7 +88,21 @@ func FormatDateDEShort(dateTime time.Time) string {
 }
 
 func ParseDate(date string) (*time.Time, error) {
-	t, err := time.Parse(dateFormat, date)
+	return ParseDateInLocation(date, time.UTC)
+}
+
+// ParseDateDE parses a date in german format (e.g. 21.11.2020)
+func ParseDateDE(date string) (*time.Time, error) {
+	t, err := time.Parse(dateFormatDE, date)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse date from '%s'", date)
+	}
+	return &t, nil
+}
+
+// ParseDateInLocation parses a date as start of the day in the given location
+func ParseDateInLocation(date string, location *time.Location) (*time.Time, error) {
+	t, err := time.ParseInLocation(dateFormat, date, location)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse date from '%s'", date)
 	}
@@ -70,6 +113,30 @@ func Quarter(time time.Time) int {
 	return int(math.Ceil(float64(time.Month()) / 3))
 }
 
+// ParseDurationAsMinutes parses a duration like 7:30, 7,5 or 8 as minutes
+func ParseDurationAsMinutes(duration string) (int, error) {
+	if strings.TrimSpace(duration) == "" {
+		return 0, nil
+	}
+
+	parts := strings.Split(CompleteTimeValue(strings.TrimSpace(duration)), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("could not parse duration from '%s'", duration)
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("could not parse duration from '%s'", duration)
+	}
+
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("could not parse duration from '%s'", duration)
+	}
+
+	return hours*60 + minutes, nil
+}
+
 func CompleteTimeValue(time string) string {
 	completedTime := time
 
@@ -2,6 +2,7 @@ package time
 
 import (
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
 )
@@ -48,6 +49,69 @@ func TestParseDateTimeForm(t *testing.T) {
 	})
 }
 
+func TestParseDateTimeInLocation(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	t.Run("dateTime without offset", func(t *testing.T) {
+		dateTime, err := ParseDateTimeInLocation("2020-11-21T16:46:28", berlin)
+		is.NoErr(err)
+		is.Equal(dateTime.Hour(), 16)
+		is.Equal(dateTime.UTC().Hour(), 15)
+	})
+
+	t.Run("dateTime with offset", func(t *testing.T) {
+		dateTime, err := ParseDateTimeInLocation("2020-11-21T16:46:28+02:00", berlin)
+		is.NoErr(err)
+		is.Equal(dateTime.UTC().Hour(), 14)
+	})
+
+	t.Run("invalid dateTime", func(t *testing.T) {
+		_, err := ParseDateTimeInLocation("2020-11-21safasdf", berlin)
+
+		is.True(err != nil)
+	})
+}
+
+func TestParseDateTimeFormInLocation(t *testing.T) {
+	is := is.New(t)
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	dateTime, err := ParseDateTimeFormInLocation("21.11.2020 16:46", newYork)
+	is.NoErr(err)
+	is.Equal(dateTime.Hour(), 16)
+	is.Equal(dateTime.UTC().Hour(), 21)
+}
+
+func TestParseDateDE(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("valid date", func(t *testing.T) {
+		date, err := ParseDateDE("21.11.2020")
+		is.NoErr(err)
+		is.Equal(date.Year(), 2020)
+		is.Equal(date.Month(), time.November)
+		is.Equal(date.Day(), 21)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		_, err := ParseDateDE("2020-11-21")
+		is.True(err != nil)
+	})
+}
+
+func TestParseDateInLocation(t *testing.T) {
+	is := is.New(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	date, err := ParseDateInLocation("2020-11-21", berlin)
+	is.NoErr(err)
+	is.Equal(date.Day(), 21)
+	is.Equal(date.Hour(), 0)
+	is.Equal(date.UTC().Day(), 20)
+	is.Equal(date.UTC().Hour(), 23)
+}
+
 func TestParseDate(t *testing.T) {
 	is := is.New(t)
 
@@ -114,6 +178,45 @@ func TestFormatDateDEShort(t *testing.T) {
 	is.Equal(formattedTime, "1.11.")
 }
 
+func TestFormatMinutesAsBalance(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(FormatMinutesAsBalance(90), "+1:30 h")
+	is.Equal(FormatMinutesAsBalance(0), "+0:00 h")
+	is.Equal(FormatMinutesAsBalance(-150), "-2:30 h")
+	is.Equal(FormatMinutesAsBalance(-5), "-0:05 h")
+}
+
+func TestParseDurationAsMinutes(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("valid durations", func(t *testing.T) {
+		minutes, err := ParseDurationAsMinutes("7:30")
+		is.NoErr(err)
+		is.Equal(minutes, 450)
+
+		minutes, err = ParseDurationAsMinutes("7,5")
+		is.NoErr(err)
+		is.Equal(minutes, 450)
+
+		minutes, err = ParseDurationAsMinutes("8")
+		is.NoErr(err)
+		is.Equal(minutes, 480)
+
+		minutes, err = ParseDurationAsMinutes("")
+		is.NoErr(err)
+		is.Equal(minutes, 0)
+	})
+
+	t.Run("invalid durations", func(t *testing.T) {
+		_, err := ParseDurationAsMinutes("abc")
+		is.True(err != nil)
+
+		_, err = ParseDurationAsMinutes("7:75")
+		is.True(err != nil)
+	})
+}
+
 func TestCompleteTimeValue(t *testing.T) {
 	is := is.New(t)
 
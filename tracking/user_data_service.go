@@ -0,0 +1,195 @@
+package tracking
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/baralga/shared/paged"
+	"github.com/google/uuid"
+)
+
+// exportPageSize is the number of activities read at once for the data export
+const exportPageSize = 1000
+
+// UserDataService exports and removes the tracking data of a user
+type UserDataService struct {
+	activityService       *ActitivityService
+	activityRepository    ActivityRepository
+	tagRepository         TagRepository
+	absenceRepository     AbsenceRepository
+	workingTimeRepository WorkingTimeRepository
+}
+
+type activityDataModel struct {
+	ID          string    `json:"id"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Description string    `json:"description"`
+	ProjectID   string    `json:"projectId"`
+	Project     string    `json:"project"`
+	Tags        []string  `json:"tags"`
+}
+
+type tagDataModel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func NewUserDataService(activityService *ActitivityService, activityRepository ActivityRepository, tagRepository TagRepository, absenceRepository AbsenceRepository, workingTimeRepository WorkingTimeRepository) *UserDataService {
+	return &UserDataService{
+		activityService:       activityService,
+		activityRepository:    activityRepository,
+		tagRepository:         tagRepository,
+		absenceRepository:     absenceRepository,
+		workingTimeRepository: workingTimeRepository,
+	}
+}
+
+// UserDataExporter writes the activities of the user as json and csv and the tags used by the user to the zip archive
+func (a *UserDataService) UserDataExporter() func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error {
+	return func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error {
+		activities, projects, err := a.readAllActivities(ctx, organizationID, username)
+		if err != nil {
+			return err
+		}
+
+		projectsByID := make(map[uuid.UUID]*Project)
+		for _, project := range projects {
+			projectsByID[project.ID] = project
+		}
+
+		activityModels := make([]*activityDataModel, 0, len(activities))
+		tagsByName := make(map[string]*tagDataModel)
+		for _, activity := range activities {
+			tagNames := make([]string, 0, len(activity.Tags))
+			for _, tag := range activity.Tags {
+				tagNames = append(tagNames, tag.Name)
+				tagsByName[tag.Name] = &tagDataModel{
+					Name:  tag.Name,
+					Color: tag.Color,
+				}
+			}
+
+			activityModel := &activityDataModel{
+				ID:          activity.ID.String(),
+				Start:       activity.Start.UTC(),
+				End:         activity.End.UTC(),
+				Description: activity.Description,
+				ProjectID:   activity.ProjectID.String(),
+				Tags:        tagNames,
+			}
+			if project, ok := projectsByID[activity.ProjectID]; ok {
+				activityModel.Project = project.Title
+			}
+			activityModels = append(activityModels, activityModel)
+		}
+
+		tagModels := make([]*tagDataModel, 0, len(tagsByName))
+		for _, tagModel := range tagsByName {
+			tagModels = append(tagModels, tagModel)
+		}
+		sort.Slice(tagModels, func(i, j int) bool {
+			return tagModels[i].Name < tagModels[j].Name
+		})
+
+		err = writeJSONToZip(zipWriter, "activities.json", activityModels)
+		if err != nil {
+			return err
+		}
+
+		csvWriter, err := zipWriter.Create("activities.csv")
+		if err != nil {
+			return err
+		}
+
+		err = a.activityService.WriteAsCSV(activities, projects, csvWriter)
+		if err != nil {
+			return err
+		}
+
+		return writeJSONToZip(zipWriter, "tags.json", tagModels)
+	}
+}
+
+// UserDataRemover deletes the absences and the working time target of the user. The activities of the user
+// are anonymized with the anonymized username or deleted if the anonymized username is empty.
+func (a *UserDataService) UserDataRemover() func(ctxWithTx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error {
+	return func(ctx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error {
+		err := a.absenceRepository.DeleteAbsencesByUsername(ctx, organizationID, username)
+		if err != nil {
+			return err
+		}
+
+		err = a.workingTimeRepository.DeleteWorkingTimeTarget(ctx, organizationID, username)
+		if err != nil {
+			return err
+		}
+
+		if anonymizedUsername != "" {
+			return a.activityRepository.AnonymizeActivitiesByUsername(ctx, organizationID, username, anonymizedUsername)
+		}
+
+		err = a.activityRepository.DeleteActivitiesByUsername(ctx, organizationID, username)
+		if err != nil {
+			return err
+		}
+
+		return a.tagRepository.DeleteUnusedTags(ctx, organizationID)
+	}
+}
+
+// readAllActivities reads all activities of the user page by page
+func (a *UserDataService) readAllActivities(ctx context.Context, organizationID uuid.UUID, username string) ([]*Activity, []*Project, error) {
+	activitiesFilter := &ActivitiesFilter{
+		Start:          time.Time{},
+		End:            time.Now().AddDate(100, 0, 0),
+		SortBy:         "start",
+		SortOrder:      SortOrderAsc,
+		OrganizationID: organizationID,
+		Username:       username,
+	}
+
+	var activities []*Activity
+	projectsByID := make(map[uuid.UUID]*Project)
+	for page := 0; ; page++ {
+		pageParams := &paged.PageParams{
+			Page: page,
+			Size: exportPageSize,
+		}
+
+		activitiesPage, projects, err := a.activityRepository.FindActivities(ctx, activitiesFilter, pageParams)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		activities = append(activities, activitiesPage.Activities...)
+		for _, project := range projects {
+			projectsByID[project.ID] = project
+		}
+
+		if page+1 >= activitiesPage.Page.TotalPages {
+			break
+		}
+	}
+
+	projects := make([]*Project, 0, len(projectsByID))
+	for _, project := range projectsByID {
+		projects = append(projects, project)
+	}
+
+	return activities, projects, nil
+}
+
+func writeJSONToZip(zipWriter *zip.Writer, name string, v any) error {
+	w, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,111 @@
+package tracking
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestUserDataExporter(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	activityRepository := NewInMemActivityRepository()
+	activityRepository.activities[0].Start = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	activityRepository.activities[0].End = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	activityRepository.activities[0].Tags = []*Tag{
+		{Name: "meeting", Color: "#ff0000"},
+	}
+
+	userDataService := NewUserDataService(
+		&ActitivityService{},
+		activityRepository,
+		NewInMemTagRepository(),
+		NewInMemAbsenceRepository(),
+		NewInMemWorkingTimeRepository(),
+	)
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+
+	// Act
+	err := userDataService.UserDataExporter()(context.Background(), shared.OrganizationIDSample, "user1", zipWriter)
+
+	// Assert
+	is.NoErr(err)
+	is.NoErr(zipWriter.Close())
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	is.NoErr(err)
+	is.Equal(len(zipReader.File), 3)
+	is.Equal(zipReader.File[0].Name, "activities.json")
+	is.Equal(zipReader.File[1].Name, "activities.csv")
+	is.Equal(zipReader.File[2].Name, "tags.json")
+
+	activitiesReader, err := zipReader.File[0].Open()
+	is.NoErr(err)
+	defer activitiesReader.Close()
+
+	var activityModels []*activityDataModel
+	err = json.NewDecoder(activitiesReader).Decode(&activityModels)
+	is.NoErr(err)
+	is.Equal(len(activityModels), 1)
+	is.Equal(activityModels[0].Project, "My Project")
+	is.Equal(activityModels[0].Tags, []string{"meeting"})
+}
+
+func TestUserDataRemoverAnonymizesActivities(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	activityRepository := NewInMemActivityRepository()
+	absenceRepository := NewInMemAbsenceRepository()
+	absenceRepository.absences = append(absenceRepository.absences, &Absence{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	})
+
+	userDataService := NewUserDataService(
+		&ActitivityService{},
+		activityRepository,
+		NewInMemTagRepository(),
+		absenceRepository,
+		NewInMemWorkingTimeRepository(),
+	)
+
+	// Act
+	err := userDataService.UserDataRemover()(context.Background(), shared.OrganizationIDSample, "user1", "deleted-12345678")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(activityRepository.activities), 1)
+	is.Equal(activityRepository.activities[0].Username, "deleted-12345678")
+	is.Equal(len(absenceRepository.absences), 0)
+}
+
+func TestUserDataRemoverDeletesActivities(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	activityRepository := NewInMemActivityRepository()
+
+	userDataService := NewUserDataService(
+		&ActitivityService{},
+		activityRepository,
+		NewInMemTagRepository(),
+		NewInMemAbsenceRepository(),
+		NewInMemWorkingTimeRepository(),
+	)
+
+	// Act
+	err := userDataService.UserDataRemover()(context.Background(), shared.OrganizationIDSample, "user1", "")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(activityRepository.activities), 0)
+}
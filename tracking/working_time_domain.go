@@ -0,0 +1,107 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var ErrWorkingTimeTargetNotFound = errors.New("working time target not found")
+
+// Aggregations for the working time account
+const (
+	WorkingTimeByDay   string = "day"
+	WorkingTimeByWeek  string = "week"
+	WorkingTimeByMonth string = "month"
+)
+
+// WorkingTimeTarget represents the target working time of a user per weekday
+type WorkingTimeTarget struct {
+	OrganizationID uuid.UUID
+	Username       string
+	ValidFrom      time.Time
+	WeekdayMinutes [7]int // indexed by time.Weekday, Sunday is 0
+}
+
+// WorkingTimeItem represents actual and target working time within a timespan
+type WorkingTimeItem struct {
+	Start         time.Time
+	End           time.Time
+	ActualMinutes int
+	TargetMinutes int
+}
+
+// WorkingTimeAccount represents the working time account of a user
+type WorkingTimeAccount struct {
+	Target        *WorkingTimeTarget
+	Items         []*WorkingTimeItem
+	ActualMinutes int
+	TargetMinutes int
+	// BalanceMinutes is the running balance from the start of the target until today
+	BalanceMinutes int
+}
+
+type WorkingTimeRepository interface {
+	FindWorkingTimeTarget(ctx context.Context, organizationID uuid.UUID, username string) (*WorkingTimeTarget, error)
+	UpsertWorkingTimeTarget(ctx context.Context, target *WorkingTimeTarget) (*WorkingTimeTarget, error)
+	DeleteWorkingTimeTarget(ctx context.Context, organizationID uuid.UUID, username string) error
+}
+
+// TargetMinutesOn returns the target minutes on the given day, which is zero before the target is valid
+func (t *WorkingTimeTarget) TargetMinutesOn(day time.Time) int {
+	if dateOf(day).Before(dateOf(t.ValidFrom)) {
+		return 0
+	}
+	return t.WeekdayMinutes[day.Weekday()]
+}
+
+// WeeklyMinutes returns the target minutes of a whole week
+func (t *WorkingTimeTarget) WeeklyMinutes() int {
+	total := 0
+	for _, minutes := range t.WeekdayMinutes {
+		total += minutes
+	}
+	return total
+}
+
+// WeeklyFormatted is the weekly target as formatted string (e.g. 40:00 h)
+func (t *WorkingTimeTarget) WeeklyFormatted() string {
+	return time_utils.FormatMinutesAsDuration(float64(t.WeeklyMinutes()))
+}
+
+// BalanceMinutes is the difference between actual and target minutes
+func (i *WorkingTimeItem) BalanceMinutes() int {
+	return i.ActualMinutes - i.TargetMinutes
+}
+
+// ActualFormatted is the actual working time as formatted string (e.g. 1:15 h)
+func (i *WorkingTimeItem) ActualFormatted() string {
+	return time_utils.FormatMinutesAsDuration(float64(i.ActualMinutes))
+}
+
+// TargetFormatted is the target working time as formatted string (e.g. 8:00 h)
+func (i *WorkingTimeItem) TargetFormatted() string {
+	return time_utils.FormatMinutesAsDuration(float64(i.TargetMinutes))
+}
+
+// BalanceFormatted is the balance as formatted string (e.g. -0:45 h)
+func (i *WorkingTimeItem) BalanceFormatted() string {
+	return time_utils.FormatMinutesAsBalance(i.BalanceMinutes())
+}
+
+// HasTarget returns true if a target working time is set up
+func (a *WorkingTimeAccount) HasTarget() bool {
+	return a.Target != nil
+}
+
+// BalanceFormatted is the running balance as formatted string (e.g. +12:30 h)
+func (a *WorkingTimeAccount) BalanceFormatted() string {
+	return time_utils.FormatMinutesAsBalance(a.BalanceMinutes)
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
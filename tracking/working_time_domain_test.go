@@ -0,0 +1,37 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWorkingTimeTargetMinutesOn(t *testing.T) {
+	is := is.New(t)
+
+	target := &WorkingTimeTarget{
+		ValidFrom:      time.Date(2021, 11, 16, 0, 0, 0, 0, time.UTC),
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 240, 0},
+	}
+
+	is.Equal(target.TargetMinutesOn(time.Date(2021, 11, 15, 12, 0, 0, 0, time.UTC)), 0)   // Monday before valid from
+	is.Equal(target.TargetMinutesOn(time.Date(2021, 11, 16, 0, 0, 0, 0, time.UTC)), 480)  // Tuesday
+	is.Equal(target.TargetMinutesOn(time.Date(2021, 11, 19, 23, 0, 0, 0, time.UTC)), 240) // Friday
+	is.Equal(target.TargetMinutesOn(time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)), 0)   // Saturday
+	is.Equal(target.WeeklyMinutes(), 2160)
+	is.Equal(target.WeeklyFormatted(), "36:00 h")
+}
+
+func TestWorkingTimeItemBalance(t *testing.T) {
+	is := is.New(t)
+
+	item := &WorkingTimeItem{
+		ActualMinutes: 450,
+		TargetMinutes: 480,
+	}
+
+	is.Equal(item.BalanceMinutes(), -30)
+	is.Equal(item.BalanceFormatted(), "-0:30 h")
+	is.Equal(item.ActualFormatted(), "7:30 h")
+}
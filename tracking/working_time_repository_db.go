@@ -0,0 +1,107 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbWorkingTimeRepository is a SQL database repository for working time targets
+type DbWorkingTimeRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ WorkingTimeRepository = (*DbWorkingTimeRepository)(nil)
+
+// NewDbWorkingTimeRepository creates a new SQL database repository for working time targets
+func NewDbWorkingTimeRepository(connPool *pgxpool.Pool) *DbWorkingTimeRepository {
+	return &DbWorkingTimeRepository{
+		connPool: connPool,
+	}
+}
+
+func (r *DbWorkingTimeRepository) FindWorkingTimeTarget(ctx context.Context, organizationID uuid.UUID, username string) (*WorkingTimeTarget, error) {
+	row := r.connPool.QueryRow(ctx,
+		`SELECT valid_from, 
+		        sunday_minutes, monday_minutes, tuesday_minutes, wednesday_minutes, 
+		        thursday_minutes, friday_minutes, saturday_minutes
+         FROM working_time_targets 
+	     WHERE org_id = $1 AND username = $2`,
+		organizationID, username)
+
+	var (
+		validFrom      time.Time
+		weekdayMinutes [7]int
+	)
+
+	err := row.Scan(
+		&validFrom,
+		&weekdayMinutes[time.Sunday], &weekdayMinutes[time.Monday], &weekdayMinutes[time.Tuesday], &weekdayMinutes[time.Wednesday],
+		&weekdayMinutes[time.Thursday], &weekdayMinutes[time.Friday], &weekdayMinutes[time.Saturday],
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWorkingTimeTargetNotFound
+		}
+
+		return nil, err
+	}
+
+	target := &WorkingTimeTarget{
+		OrganizationID: organizationID,
+		Username:       username,
+		ValidFrom:      validFrom,
+		WeekdayMinutes: weekdayMinutes,
+	}
+
+	return target, nil
+}
+
+func (r *DbWorkingTimeRepository) UpsertWorkingTimeTarget(ctx context.Context, target *WorkingTimeTarget) (*WorkingTimeTarget, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO working_time_targets 
+		   (org_id, username, valid_from, 
+		    sunday_minutes, monday_minutes, tuesday_minutes, wednesday_minutes, 
+		    thursday_minutes, friday_minutes, saturday_minutes) 
+		 VALUES 
+		   ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 ON CONFLICT (org_id, username) DO UPDATE 
+		 SET valid_from = $3, 
+		     sunday_minutes = $4, monday_minutes = $5, tuesday_minutes = $6, wednesday_minutes = $7, 
+		     thursday_minutes = $8, friday_minutes = $9, saturday_minutes = $10`,
+		target.OrganizationID,
+		target.Username,
+		dateOf(target.ValidFrom),
+		target.WeekdayMinutes[time.Sunday],
+		target.WeekdayMinutes[time.Monday],
+		target.WeekdayMinutes[time.Tuesday],
+		target.WeekdayMinutes[time.Wednesday],
+		target.WeekdayMinutes[time.Thursday],
+		target.WeekdayMinutes[time.Friday],
+		target.WeekdayMinutes[time.Saturday],
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+func (r *DbWorkingTimeRepository) DeleteWorkingTimeTarget(ctx context.Context, organizationID uuid.UUID, username string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(ctx,
+		`DELETE 
+         FROM working_time_targets 
+	     WHERE org_id = $1 AND username = $2`,
+		organizationID, username)
+	return err
+}
@@ -0,0 +1,85 @@
+package tracking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestWorkingTimeRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	workingTimeRepository := NewDbWorkingTimeRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	t.Run("FindNotExistingWorkingTimeTarget", func(t *testing.T) {
+		_, err := workingTimeRepository.FindWorkingTimeTarget(
+			context.Background(),
+			shared.OrganizationIDSample,
+			"user1",
+		)
+
+		is.True(errors.Is(err, ErrWorkingTimeTargetNotFound))
+	})
+
+	t.Run("UpsertAndFindWorkingTimeTarget", func(t *testing.T) {
+		target := &WorkingTimeTarget{
+			OrganizationID: shared.OrganizationIDSample,
+			Username:       "user1",
+			ValidFrom:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+		}
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := workingTimeRepository.UpsertWorkingTimeTarget(ctx, target)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		target.WeekdayMinutes[time.Friday] = 240
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := workingTimeRepository.UpsertWorkingTimeTarget(ctx, target)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		targetRead, err := workingTimeRepository.FindWorkingTimeTarget(
+			context.Background(),
+			shared.OrganizationIDSample,
+			"user1",
+		)
+		is.NoErr(err)
+		is.Equal(targetRead.WeekdayMinutes, target.WeekdayMinutes)
+		is.Equal(targetRead.WeeklyMinutes(), 2160)
+		is.Equal(targetRead.ValidFrom.Format("2006-01-02"), "2024-01-01")
+	})
+}
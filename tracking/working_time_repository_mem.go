@@ -0,0 +1,50 @@
+package tracking
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// InMemWorkingTimeRepository is an in-memory repository for working time targets
+type InMemWorkingTimeRepository struct {
+	targets []*WorkingTimeTarget
+}
+
+var _ WorkingTimeRepository = (*InMemWorkingTimeRepository)(nil)
+
+// NewInMemWorkingTimeRepository creates a new in-memory repository for working time targets
+func NewInMemWorkingTimeRepository() *InMemWorkingTimeRepository {
+	return &InMemWorkingTimeRepository{}
+}
+
+func (r *InMemWorkingTimeRepository) FindWorkingTimeTarget(ctx context.Context, organizationID uuid.UUID, username string) (*WorkingTimeTarget, error) {
+	for _, t := range r.targets {
+		if t.OrganizationID == organizationID && t.Username == username {
+			return t, nil
+		}
+	}
+	return nil, ErrWorkingTimeTargetNotFound
+}
+
+func (r *InMemWorkingTimeRepository) UpsertWorkingTimeTarget(ctx context.Context, target *WorkingTimeTarget) (*WorkingTimeTarget, error) {
+	for i, t := range r.targets {
+		if t.OrganizationID == target.OrganizationID && t.Username == target.Username {
+			r.targets[i] = target
+			return target, nil
+		}
+	}
+	r.targets = append(r.targets, target)
+	return target, nil
+}
+
+func (r *InMemWorkingTimeRepository) DeleteWorkingTimeTarget(ctx context.Context, organizationID uuid.UUID, username string) error {
+	var targets []*WorkingTimeTarget
+	for _, t := range r.targets {
+		if t.OrganizationID != organizationID || t.Username != username {
+			targets = append(targets, t)
+		}
+	}
+	r.targets = targets
+	return nil
+}
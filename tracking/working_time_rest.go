@@ -0,0 +1,238 @@
+package tracking
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type workingTimeTargetModel struct {
+	ValidFrom      string               `json:"validFrom" validate:"required"`
+	WeekdayMinutes *weekdayMinutesModel `json:"weekdayMinutes" validate:"required"`
+	WeeklyMinutes  int                  `json:"weeklyMinutes"`
+	Links          *hal.Links           `json:"_links"`
+}
+
+type weekdayMinutesModel struct {
+	Monday    int `json:"monday" validate:"min=0,max=1440"`
+	Tuesday   int `json:"tuesday" validate:"min=0,max=1440"`
+	Wednesday int `json:"wednesday" validate:"min=0,max=1440"`
+	Thursday  int `json:"thursday" validate:"min=0,max=1440"`
+	Friday    int `json:"friday" validate:"min=0,max=1440"`
+	Saturday  int `json:"saturday" validate:"min=0,max=1440"`
+	Sunday    int `json:"sunday" validate:"min=0,max=1440"`
+}
+
+type workingTimeAccountModel struct {
+	ActualMinutes  int                     `json:"actualMinutes"`
+	TargetMinutes  int                     `json:"targetMinutes"`
+	BalanceMinutes int                     `json:"balanceMinutes"`
+	Balance        string                  `json:"balance"`
+	Items          []*workingTimeItemModel `json:"items"`
+	Target         *workingTimeTargetModel `json:"target,omitempty"`
+	Links          *hal.Links              `json:"_links"`
+}
+
+type workingTimeItemModel struct {
+	Start          string `json:"start"`
+	End            string `json:"end"`
+	ActualMinutes  int    `json:"actualMinutes"`
+	TargetMinutes  int    `json:"targetMinutes"`
+	BalanceMinutes int    `json:"balanceMinutes"`
+}
+
+type WorkingTimeRestHandlers struct {
+	config             *shared.Config
+	workingTimeService *WorkingTimeService
+}
+
+func NewWorkingTimeRestHandlers(config *shared.Config, workingTimeService *WorkingTimeService) *WorkingTimeRestHandlers {
+	return &WorkingTimeRestHandlers{
+		config:             config,
+		workingTimeService: workingTimeService,
+	}
+}
+
+func (a *WorkingTimeRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+func (a *WorkingTimeRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/working-time", a.HandleGetWorkingTimeAccount())
+	r.Get("/working-time/target", a.HandleGetWorkingTimeTarget())
+	r.Put("/working-time/target", a.HandleUpdateWorkingTimeTarget())
+}
+
+// HandleGetWorkingTimeAccount reads the working time account of the principal
+func (a *WorkingTimeRestHandlers) HandleGetWorkingTimeAccount() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	workingTimeService := a.workingTimeService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		filter, err := filterFromQueryParams(r.URL.Query(), principal.Location())
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, errors.New("invalid query params"))
+			return
+		}
+
+		aggregateBy := r.URL.Query().Get("aggregateBy")
+		if !isValidWorkingTimeAggregation(aggregateBy) {
+			aggregateBy = WorkingTimeByDay
+		}
+
+		account, err := workingTimeService.ReadWorkingTimeAccount(r.Context(), principal, filter, aggregateBy)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		accountModel := mapToWorkingTimeAccountModel(account)
+		accountModel.Links = hal.NewLinks(
+			hal.NewSelfLink(r.RequestURI),
+			hal.NewLink("target", "/api/working-time/target"),
+		)
+
+		shared.RenderJSON(w, accountModel)
+	}
+}
+
+// HandleGetWorkingTimeTarget reads the working time target of the principal
+func (a *WorkingTimeRestHandlers) HandleGetWorkingTimeTarget() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	workingTimeService := a.workingTimeService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		target, err := workingTimeService.ReadWorkingTimeTarget(r.Context(), principal)
+		if errors.Is(err, ErrWorkingTimeTargetNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToWorkingTimeTargetModel(target))
+	}
+}
+
+// HandleUpdateWorkingTimeTarget sets up or updates the working time target of the principal
+func (a *WorkingTimeRestHandlers) HandleUpdateWorkingTimeTarget() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	workingTimeService := a.workingTimeService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var targetModel workingTimeTargetModel
+		err := json.NewDecoder(r.Body).Decode(&targetModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		err = validator.Struct(targetModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("working time target not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		target, err := mapToWorkingTimeTarget(&targetModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		targetUpdated, err := workingTimeService.UpdateWorkingTimeTarget(r.Context(), principal, target)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToWorkingTimeTargetModel(targetUpdated))
+	}
+}
+
+func isValidWorkingTimeAggregation(aggregateBy string) bool {
+	switch aggregateBy {
+	case WorkingTimeByDay, WorkingTimeByWeek, WorkingTimeByMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+func mapToWorkingTimeTarget(targetModel *workingTimeTargetModel) (*WorkingTimeTarget, error) {
+	validFrom, err := time_utils.ParseDate(targetModel.ValidFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &WorkingTimeTarget{
+		ValidFrom: *validFrom,
+	}
+	target.WeekdayMinutes[time.Monday] = targetModel.WeekdayMinutes.Monday
+	target.WeekdayMinutes[time.Tuesday] = targetModel.WeekdayMinutes.Tuesday
+	target.WeekdayMinutes[time.Wednesday] = targetModel.WeekdayMinutes.Wednesday
+	target.WeekdayMinutes[time.Thursday] = targetModel.WeekdayMinutes.Thursday
+	target.WeekdayMinutes[time.Friday] = targetModel.WeekdayMinutes.Friday
+	target.WeekdayMinutes[time.Saturday] = targetModel.WeekdayMinutes.Saturday
+	target.WeekdayMinutes[time.Sunday] = targetModel.WeekdayMinutes.Sunday
+
+	return target, nil
+}
+
+func mapToWorkingTimeTargetModel(target *WorkingTimeTarget) *workingTimeTargetModel {
+	return &workingTimeTargetModel{
+		ValidFrom: time_utils.FormatDate(target.ValidFrom),
+		WeekdayMinutes: &weekdayMinutesModel{
+			Monday:    target.WeekdayMinutes[time.Monday],
+			Tuesday:   target.WeekdayMinutes[time.Tuesday],
+			Wednesday: target.WeekdayMinutes[time.Wednesday],
+			Thursday:  target.WeekdayMinutes[time.Thursday],
+			Friday:    target.WeekdayMinutes[time.Friday],
+			Saturday:  target.WeekdayMinutes[time.Saturday],
+			Sunday:    target.WeekdayMinutes[time.Sunday],
+		},
+		WeeklyMinutes: target.WeeklyMinutes(),
+		Links: hal.NewLinks(
+			hal.NewSelfLink("/api/working-time/target"),
+		),
+	}
+}
+
+func mapToWorkingTimeAccountModel(account *WorkingTimeAccount) *workingTimeAccountModel {
+	itemModels := make([]*workingTimeItemModel, 0, len(account.Items))
+	for _, item := range account.Items {
+		itemModels = append(itemModels, &workingTimeItemModel{
+			Start:          time_utils.FormatDate(item.Start),
+			End:            time_utils.FormatDate(item.End),
+			ActualMinutes:  item.ActualMinutes,
+			TargetMinutes:  item.TargetMinutes,
+			BalanceMinutes: item.BalanceMinutes(),
+		})
+	}
+
+	accountModel := &workingTimeAccountModel{
+		ActualMinutes:  account.ActualMinutes,
+		TargetMinutes:  account.TargetMinutes,
+		BalanceMinutes: account.BalanceMinutes,
+		Balance:        account.BalanceFormatted(),
+		Items:          itemModels,
+	}
+
+	if account.HasTarget() {
+		accountModel.Target = mapToWorkingTimeTargetModel(account.Target)
+	}
+
+	return accountModel
+}
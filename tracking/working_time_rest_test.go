@@ -0,0 +1,137 @@
+package tracking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetWorkingTimeAccount(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &WorkingTimeRestHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			repositoryTxer:        shared.NewInMemRepositoryTxer(),
+			workingTimeRepository: NewInMemWorkingTimeRepository(),
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+			activityRepository:    NewInMemActivityRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/working-time?t=month&v=2021-11&aggregateBy=week", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleGetWorkingTimeAccount()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	accountModel := &workingTimeAccountModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(accountModel)
+	is.NoErr(err)
+	is.Equal(accountModel.Balance, "+0:00 h")
+	is.True(accountModel.Target == nil)
+}
+
+func TestHandleGetNonExistingWorkingTimeTarget(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &WorkingTimeRestHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			workingTimeRepository: NewInMemWorkingTimeRepository(),
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/working-time/target", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleGetWorkingTimeTarget()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
+
+func TestHandleUpdateWorkingTimeTarget(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	a := &WorkingTimeRestHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			repositoryTxer:        shared.NewInMemRepositoryTxer(),
+			workingTimeRepository: workingTimeRepository,
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+		},
+	}
+
+	body := `
+	{
+		"validFrom": "2021-11-01",
+		"weekdayMinutes": {
+			"monday": 480,
+			"tuesday": 480,
+			"wednesday": 480,
+			"thursday": 480,
+			"friday": 240
+		}
+	}
+	`
+
+	r, _ := http.NewRequest("PUT", "/api/working-time/target", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleUpdateWorkingTimeTarget()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	targetModel := &workingTimeTargetModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(targetModel)
+	is.NoErr(err)
+	is.Equal(targetModel.WeeklyMinutes, 2160)
+	is.Equal(len(workingTimeRepository.targets), 1)
+	is.Equal(workingTimeRepository.targets[0].Username, "user1")
+}
+
+func TestHandleUpdateWorkingTimeTargetWithInvalidMinutes(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &WorkingTimeRestHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			repositoryTxer:        shared.NewInMemRepositoryTxer(),
+			workingTimeRepository: NewInMemWorkingTimeRepository(),
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+		},
+	}
+
+	body := `{ "validFrom": "2021-11-01", "weekdayMinutes": { "monday": 1500 } }`
+
+	r, _ := http.NewRequest("PUT", "/api/working-time/target", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleUpdateWorkingTimeTarget()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
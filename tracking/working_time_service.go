@@ -0,0 +1,236 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type WorkingTimeService struct {
+	repositoryTxer        shared.RepositoryTxer
+	workingTimeRepository WorkingTimeRepository
+	activityRepository    ActivityRepository
+	holidayRepository     HolidayRepository
+	absenceRepository     AbsenceRepository
+	settingsReader        func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error)
+}
+
+func NewWorkingTimeService(repositoryTxer shared.RepositoryTxer, workingTimeRepository WorkingTimeRepository, activityRepository ActivityRepository, holidayRepository HolidayRepository, absenceRepository AbsenceRepository) *WorkingTimeService {
+	return &WorkingTimeService{
+		repositoryTxer:        repositoryTxer,
+		workingTimeRepository: workingTimeRepository,
+		activityRepository:    activityRepository,
+		holidayRepository:     holidayRepository,
+		absenceRepository:     absenceRepository,
+	}
+}
+
+// SetOrganizationSettingsReader sets the reader for the settings of an organization like its working days
+func (a *WorkingTimeService) SetOrganizationSettingsReader(settingsReader func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error)) {
+	a.settingsReader = settingsReader
+}
+
+// ReadWorkingTimeTarget reads the working time target of the principal
+func (a *WorkingTimeService) ReadWorkingTimeTarget(ctx context.Context, principal *shared.Principal) (*WorkingTimeTarget, error) {
+	return a.workingTimeRepository.FindWorkingTimeTarget(ctx, principal.OrganizationID, principal.Username)
+}
+
+// UpdateWorkingTimeTarget sets up or updates the working time target of the principal
+func (a *WorkingTimeService) UpdateWorkingTimeTarget(ctx context.Context, principal *shared.Principal, target *WorkingTimeTarget) (*WorkingTimeTarget, error) {
+	target.OrganizationID = principal.OrganizationID
+	target.Username = principal.Username
+
+	var targetUpdated *WorkingTimeTarget
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			t, err := a.workingTimeRepository.UpsertWorkingTimeTarget(ctx, target)
+			if err != nil {
+				return err
+			}
+			targetUpdated = t
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return targetUpdated, nil
+}
+
+// ReadWorkingTimeAccount reads the working time account of the principal with actual and target
+// working time aggregated by day, week or month. Days after today are not part of the account,
+// holidays, absences and days which are no working days of the organization have no target.
+// Weeks start on the week start of the organization.
+func (a *WorkingTimeService) ReadWorkingTimeAccount(ctx context.Context, principal *shared.Principal, filter *ActivityFilter, aggregateBy string) (*WorkingTimeAccount, error) {
+	target, err := a.workingTimeRepository.FindWorkingTimeTarget(ctx, principal.OrganizationID, principal.Username)
+	if err != nil && !errors.Is(err, ErrWorkingTimeTargetNotFound) {
+		return nil, err
+	}
+
+	settings, err := readOrganizationSettings(ctx, a.settingsReader, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	location := filter.Location()
+	tomorrow := startOfDay(time.Now().In(location)).AddDate(0, 0, 1)
+
+	end := filter.End()
+	if end.After(tomorrow) {
+		end = tomorrow
+	}
+
+	actualMinutesByDay, err := a.actualMinutesByDay(ctx, principal, filter.Start(), end)
+	if err != nil {
+		return nil, err
+	}
+
+	daysOff, err := a.daysOff(ctx, principal, filter.Start(), end)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &WorkingTimeAccount{
+		Target: target,
+	}
+
+	var items []*WorkingTimeItem
+	for day := startOfDay(filter.Start().In(location)); day.Before(end); day = day.AddDate(0, 0, 1) {
+		actualMinutes := actualMinutesByDay[dateOf(day)]
+		targetMinutes := 0
+		if target != nil && !daysOff[dateOf(day)] && settings.IsWorkingDay(day.Weekday()) {
+			targetMinutes = target.TargetMinutesOn(day)
+		}
+
+		if actualMinutes == 0 && targetMinutes == 0 {
+			continue
+		}
+
+		itemStart := workingTimeItemStart(day, aggregateBy, settings)
+		if len(items) == 0 || !items[len(items)-1].Start.Equal(itemStart) {
+			items = append(items, &WorkingTimeItem{
+				Start: itemStart,
+				End:   workingTimeItemEnd(itemStart, aggregateBy),
+			})
+		}
+
+		item := items[len(items)-1]
+		item.ActualMinutes += actualMinutes
+		item.TargetMinutes += targetMinutes
+
+		account.ActualMinutes += actualMinutes
+		account.TargetMinutes += targetMinutes
+	}
+
+	// latest items first like in the time reports
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	account.Items = items
+
+	if target == nil {
+		return account, nil
+	}
+
+	validFrom := time.Date(target.ValidFrom.Year(), target.ValidFrom.Month(), target.ValidFrom.Day(), 0, 0, 0, 0, location)
+	if !validFrom.Before(tomorrow) {
+		return account, nil
+	}
+
+	actualMinutesSinceValidFrom, err := a.actualMinutesByDay(ctx, principal, validFrom, tomorrow)
+	if err != nil {
+		return nil, err
+	}
+
+	daysOffSinceValidFrom, err := a.daysOff(ctx, principal, validFrom, tomorrow)
+	if err != nil {
+		return nil, err
+	}
+
+	for day := validFrom; day.Before(tomorrow); day = day.AddDate(0, 0, 1) {
+		account.BalanceMinutes += actualMinutesSinceValidFrom[dateOf(day)]
+		if !daysOffSinceValidFrom[dateOf(day)] && settings.IsWorkingDay(day.Weekday()) {
+			account.BalanceMinutes -= target.TargetMinutesOn(day)
+		}
+	}
+
+	return account, nil
+}
+
+// actualMinutesByDay reads the tracked minutes of the principal per day,
+// the working time account is personal also for admins
+func (a *WorkingTimeService) actualMinutesByDay(ctx context.Context, principal *shared.Principal, start, end time.Time) (map[time.Time]int, error) {
+	activitiesFilter := &ActivitiesFilter{
+		Start:          start,
+		End:            end,
+		OrganizationID: principal.OrganizationID,
+		Username:       principal.Username,
+	}
+
+	reportItems, err := a.activityRepository.TimeReportByDay(ctx, activitiesFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	actualMinutesByDay := make(map[time.Time]int)
+	for _, reportItem := range reportItems {
+		actualMinutesByDay[dateOf(reportItem.AsTime())] += reportItem.DurationInMinutesTotal
+	}
+
+	return actualMinutesByDay, nil
+}
+
+// daysOff reads the holidays of the organization and the absences of the principal as days without target
+func (a *WorkingTimeService) daysOff(ctx context.Context, principal *shared.Principal, start, end time.Time) (map[time.Time]bool, error) {
+	daysOff := make(map[time.Time]bool)
+
+	holidays, err := a.holidayRepository.FindHolidays(ctx, principal.OrganizationID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, holiday := range holidays {
+		daysOff[dateOf(holiday.Day)] = true
+	}
+
+	absences, err := a.absenceRepository.FindAbsences(ctx, principal.OrganizationID, principal.Username, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, absence := range absences {
+		for day := dateOf(absence.Start); !day.After(dateOf(absence.End)); day = day.AddDate(0, 0, 1) {
+			daysOff[day] = true
+		}
+	}
+
+	return daysOff, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func workingTimeItemStart(day time.Time, aggregateBy string, settings *shared.OrganizationSettings) time.Time {
+	switch aggregateBy {
+	case WorkingTimeByWeek:
+		return settings.StartOfWeek(day)
+	case WorkingTimeByMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day
+	}
+}
+
+func workingTimeItemEnd(start time.Time, aggregateBy string) time.Time {
+	switch aggregateBy {
+	case WorkingTimeByWeek:
+		return start.AddDate(0, 0, 7)
+	case WorkingTimeByMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,308 @@
+package tracking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestReadWorkingTimeAccountWithoutTarget(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &WorkingTimeService{
+		repositoryTxer:        shared.NewInMemRepositoryTxer(),
+		workingTimeRepository: NewInMemWorkingTimeRepository(),
+		holidayRepository:     NewInMemHolidayRepository(),
+		absenceRepository:     NewInMemAbsenceRepository(),
+		activityRepository: &InMemActivityRepository{
+			activities: []*Activity{
+				{
+					Start:          time.Date(2021, 11, 15, 9, 0, 0, 0, time.UTC),
+					End:            time.Date(2021, 11, 15, 10, 0, 0, 0, time.UTC),
+					OrganizationID: shared.OrganizationIDSample,
+					Username:       "user1",
+				},
+			},
+		},
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+	filter := &ActivityFilter{
+		Timespan: TimespanWeek,
+		start:    time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		end:      time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	account, err := a.ReadWorkingTimeAccount(context.Background(), principal, filter, WorkingTimeByDay)
+
+	// Assert
+	is.NoErr(err)
+	is.True(!account.HasTarget())
+	is.Equal(len(account.Items), 1)
+	is.Equal(account.Items[0].ActualMinutes, 60)
+	is.Equal(account.Items[0].TargetMinutes, 0)
+	is.Equal(account.BalanceMinutes, 0)
+}
+
+func TestReadWorkingTimeAccountByWeek(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	_, _ = workingTimeRepository.UpsertWorkingTimeTarget(context.Background(), &WorkingTimeTarget{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		ValidFrom:      time.Date(2021, 11, 16, 0, 0, 0, 0, time.UTC),
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+	})
+
+	a := &WorkingTimeService{
+		repositoryTxer:        shared.NewInMemRepositoryTxer(),
+		workingTimeRepository: workingTimeRepository,
+		holidayRepository:     NewInMemHolidayRepository(),
+		absenceRepository:     NewInMemAbsenceRepository(),
+		activityRepository: &InMemActivityRepository{
+			activities: []*Activity{
+				{
+					Start:          time.Date(2021, 11, 16, 9, 0, 0, 0, time.UTC),
+					End:            time.Date(2021, 11, 16, 10, 0, 0, 0, time.UTC),
+					OrganizationID: shared.OrganizationIDSample,
+					Username:       "user1",
+				},
+			},
+		},
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+	filter := &ActivityFilter{
+		Timespan: TimespanMonth,
+		start:    time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+		end:      time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	account, err := a.ReadWorkingTimeAccount(context.Background(), principal, filter, WorkingTimeByWeek)
+
+	// Assert
+	is.NoErr(err)
+	is.True(account.HasTarget())
+	is.Equal(len(account.Items), 3)
+
+	// latest week first
+	is.Equal(account.Items[0].Start, time.Date(2021, 11, 29, 0, 0, 0, 0, time.UTC))
+	is.Equal(account.Items[0].TargetMinutes, 960)
+
+	// week of target start with target from Tuesday on
+	is.Equal(account.Items[2].Start, time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC))
+	is.Equal(account.Items[2].End, time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC))
+	is.Equal(account.Items[2].ActualMinutes, 60)
+	is.Equal(account.Items[2].TargetMinutes, 1920)
+	is.Equal(account.Items[2].BalanceMinutes(), -1860)
+
+	is.Equal(account.ActualMinutes, 60)
+	is.Equal(account.TargetMinutes, 1920+2400+960)
+	is.True(account.BalanceMinutes < account.ActualMinutes-account.TargetMinutes)
+}
+
+func TestReadWorkingTimeAccountWithOrganizationSettings(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	_, _ = workingTimeRepository.UpsertWorkingTimeTarget(context.Background(), &WorkingTimeTarget{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		ValidFrom:      time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+	})
+
+	a := &WorkingTimeService{
+		repositoryTxer:        shared.NewInMemRepositoryTxer(),
+		workingTimeRepository: workingTimeRepository,
+		holidayRepository:     NewInMemHolidayRepository(),
+		absenceRepository:     NewInMemAbsenceRepository(),
+		activityRepository:    &InMemActivityRepository{},
+	}
+
+	// weeks start on Sunday and Friday is no working day
+	a.SetOrganizationSettingsReader(func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error) {
+		return &shared.OrganizationSettings{
+			WeekStart:   time.Sunday,
+			WorkingDays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday},
+			DateFormat:  shared.DateFormatISO,
+		}, nil
+	})
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+	filter := &ActivityFilter{
+		Timespan: TimespanWeek,
+		start:    time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		end:      time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	account, err := a.ReadWorkingTimeAccount(context.Background(), principal, filter, WorkingTimeByWeek)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(account.Items), 1)
+	is.Equal(account.Items[0].Start, time.Date(2021, 11, 14, 0, 0, 0, 0, time.UTC))
+	is.Equal(account.Items[0].End, time.Date(2021, 11, 21, 0, 0, 0, 0, time.UTC))
+	is.Equal(account.Items[0].TargetMinutes, 4*480)
+}
+
+func TestReadWorkingTimeAccountWithDaysOff(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	_, _ = workingTimeRepository.UpsertWorkingTimeTarget(context.Background(), &WorkingTimeTarget{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		ValidFrom:      time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+	})
+
+	holidayRepository := NewInMemHolidayRepository()
+	_, _ = holidayRepository.InsertHolidays(context.Background(), []*Holiday{
+		{
+			ID:             uuid.New(),
+			OrganizationID: shared.OrganizationIDSample,
+			Day:            time.Date(2021, 11, 17, 0, 0, 0, 0, time.UTC),
+			Title:          "Day of Repentance and Prayer",
+		},
+	})
+
+	absenceRepository := NewInMemAbsenceRepository()
+	_, _ = absenceRepository.InsertAbsence(context.Background(), &Absence{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		Start:          time.Date(2021, 11, 18, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2021, 11, 19, 0, 0, 0, 0, time.UTC),
+		Type:           AbsenceTypeVacation,
+	})
+
+	a := &WorkingTimeService{
+		repositoryTxer:        shared.NewInMemRepositoryTxer(),
+		workingTimeRepository: workingTimeRepository,
+		holidayRepository:     holidayRepository,
+		absenceRepository:     absenceRepository,
+		activityRepository:    NewInMemActivityRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+	filter := &ActivityFilter{
+		Timespan: TimespanWeek,
+		start:    time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		end:      time.Date(2021, 11, 22, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	account, err := a.ReadWorkingTimeAccount(context.Background(), principal, filter, WorkingTimeByWeek)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(account.Items), 1)
+	is.Equal(account.TargetMinutes, 960)
+}
+
+func TestReadWorkingTimeAccountBalanceUntilToday(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	now := time.Now().In(time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	target := &WorkingTimeTarget{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		ValidFrom:      today,
+		WeekdayMinutes: [7]int{480, 480, 480, 480, 480, 480, 480},
+	}
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	_, _ = workingTimeRepository.UpsertWorkingTimeTarget(context.Background(), target)
+
+	a := &WorkingTimeService{
+		repositoryTxer:        shared.NewInMemRepositoryTxer(),
+		workingTimeRepository: workingTimeRepository,
+		holidayRepository:     NewInMemHolidayRepository(),
+		absenceRepository:     NewInMemAbsenceRepository(),
+		activityRepository: &InMemActivityRepository{
+			activities: []*Activity{
+				{
+					Start:          today,
+					End:            today.Add(time.Hour),
+					OrganizationID: shared.OrganizationIDSample,
+					Username:       "user1",
+				},
+			},
+		},
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+	filter := &ActivityFilter{
+		Timespan: TimespanDay,
+		start:    today,
+		end:      today.AddDate(0, 0, 1),
+	}
+
+	// Act
+	account, err := a.ReadWorkingTimeAccount(context.Background(), principal, filter, WorkingTimeByDay)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(account.BalanceMinutes, 60-480)
+	is.Equal(account.BalanceFormatted(), "-7:00 h")
+}
+
+func TestUpdateWorkingTimeTarget(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	a := &WorkingTimeService{
+		repositoryTxer:        shared.NewInMemRepositoryTxer(),
+		workingTimeRepository: workingTimeRepository,
+		holidayRepository:     NewInMemHolidayRepository(),
+		absenceRepository:     NewInMemAbsenceRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}
+
+	// Act
+	_, err := a.UpdateWorkingTimeTarget(context.Background(), principal, &WorkingTimeTarget{
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+	})
+
+	// Assert
+	is.NoErr(err)
+	target, err := a.ReadWorkingTimeTarget(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(target.Username, "user1")
+	is.Equal(target.WeeklyMinutes(), 2400)
+}
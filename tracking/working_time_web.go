@@ -0,0 +1,423 @@
+package tracking
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	time_utils "github.com/baralga/tracking/time"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	"github.com/snabb/isoweek"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type workingTimeTargetFormModel struct {
+	CSRFToken string
+	ValidFrom string `validate:"required"`
+	Monday    string `validate:"max=5"`
+	Tuesday   string `validate:"max=5"`
+	Wednesday string `validate:"max=5"`
+	Thursday  string `validate:"max=5"`
+	Friday    string `validate:"max=5"`
+	Saturday  string `validate:"max=5"`
+	Sunday    string `validate:"max=5"`
+}
+
+// weekdaysOfForm are the weekdays in the order of the form starting with monday
+var weekdaysOfForm = []time.Weekday{
+	time.Monday,
+	time.Tuesday,
+	time.Wednesday,
+	time.Thursday,
+	time.Friday,
+	time.Saturday,
+	time.Sunday,
+}
+
+type WorkingTimeWebHandlers struct {
+	config             *shared.Config
+	workingTimeService *WorkingTimeService
+}
+
+func NewWorkingTimeWebHandlers(config *shared.Config, workingTimeService *WorkingTimeService) *WorkingTimeWebHandlers {
+	return &WorkingTimeWebHandlers{
+		config:             config,
+		workingTimeService: workingTimeService,
+	}
+}
+
+func (a *WorkingTimeWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/working-time/balance", a.HandleWorkingTimeBalance())
+	r.Get("/working-time/target", a.HandleWorkingTimeTargetPage())
+	r.Post("/working-time/target", a.HandleWorkingTimeTargetForm())
+}
+
+func (a *WorkingTimeWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleWorkingTimeBalance renders the running balance of the principal together with the current week
+func (a *WorkingTimeWebHandlers) HandleWorkingTimeBalance() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	workingTimeService := a.workingTimeService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+		location := principal.Location()
+
+		now := time.Now().In(location)
+		wyear, week := isoweek.FromDate(now.Year(), now.Month(), now.Day())
+		filter := &ActivityFilter{
+			Timespan: TimespanWeek,
+			start:    isoweek.StartTime(wyear, week, location),
+			location: location,
+		}
+
+		account, err := workingTimeService.ReadWorkingTimeAccount(r.Context(), principal, filter, WorkingTimeByWeek)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, WorkingTimeBalanceView(account))
+	}
+}
+
+func (a *WorkingTimeWebHandlers) HandleWorkingTimeTargetPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	workingTimeService := a.workingTimeService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		target, err := workingTimeService.ReadWorkingTimeTarget(r.Context(), principal)
+		if err != nil && !errors.Is(err, ErrWorkingTimeTargetNotFound) {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := newWorkingTimeTargetFormModel(principal.Location())
+		if target != nil {
+			formModel = mapWorkingTimeTargetToForm(target)
+		}
+		formModel.CSRFToken = csrf.Token(r)
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Target Working Hours",
+			}
+			shared.RenderHTML(w, WorkingTimeTargetPage(pageContext, formModel))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, WorkingTimeTargetForm(formModel, ""))
+	}
+}
+
+func (a *WorkingTimeWebHandlers) HandleWorkingTimeTargetForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	workingTimeService := a.workingTimeService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			formModel := newWorkingTimeTargetFormModel(principal.Location())
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, WorkingTimeTargetForm(formModel, ""))
+			return
+		}
+
+		var formModel workingTimeTargetFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, WorkingTimeTargetForm(formModel, ""))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, WorkingTimeTargetForm(formModel, "Please enter a valid date and the hours per weekday."))
+			return
+		}
+
+		target, err := mapFormToWorkingTimeTarget(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, WorkingTimeTargetForm(formModel, "Please enter a valid date and the hours per weekday."))
+			return
+		}
+
+		_, err = workingTimeService.UpdateWorkingTimeTarget(r.Context(), principal, target)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "{ \"baralga__activities-changed\": true, \"baralga__main_content_modal-hide\": true } ")
+	}
+}
+
+func WorkingTimeBalanceView(account *WorkingTimeAccount) g.Node {
+	if !account.HasTarget() {
+		return Div(
+			Class("mt-3 text-center"),
+			A(
+				Href("#"),
+				Class("text-muted small"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Get("/working-time/target"),
+				I(Class("bi-hourglass-split me-1")),
+				g.Text("Set up your target working hours"),
+			),
+		)
+	}
+
+	balanceClass := "text-success"
+	if account.BalanceMinutes < 0 {
+		balanceClass = "text-danger"
+	}
+
+	return Div(
+		Class("mt-3 p-3 rounded-3 border d-flex align-items-start"),
+		Div(
+			Class("flex-fill"),
+			Small(
+				Class("text-muted d-block"),
+				g.Text("Overtime Balance"),
+			),
+			Span(
+				Class(fmt.Sprintf("fs-4 %v", balanceClass)),
+				g.Text(account.BalanceFormatted()),
+			),
+			Small(
+				Class("text-muted d-block"),
+				g.Textf(
+					"This week %v of %v",
+					time_utils.FormatMinutesAsDuration(float64(account.ActualMinutes)),
+					time_utils.FormatMinutesAsDuration(float64(account.TargetMinutes)),
+				),
+			),
+		),
+		A(
+			ghx.Target("#baralga__main_content_modal_content"),
+			ghx.Swap("outerHTML"),
+			ghx.Get("/working-time/target"),
+			Class("btn btn-outline-secondary btn-sm"),
+			I(Class("bi-pencil")),
+			TitleAttr("Edit Target Working Hours"),
+		),
+	)
+}
+
+func WorkingTimeTargetPage(pageContext *shared.PageContext, formModel workingTimeTargetFormModel) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					WorkingTimeTargetForm(formModel, ""),
+				),
+			),
+		},
+	)
+}
+
+func WorkingTimeTargetForm(formModel workingTimeTargetFormModel, errorMessage string) g.Node {
+	weekdayValues := map[time.Weekday]string{
+		time.Monday:    formModel.Monday,
+		time.Tuesday:   formModel.Tuesday,
+		time.Wednesday: formModel.Wednesday,
+		time.Thursday:  formModel.Thursday,
+		time.Friday:    formModel.Friday,
+		time.Saturday:  formModel.Saturday,
+		time.Sunday:    formModel.Sunday,
+	}
+
+	return FormEl(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		ghx.Post("/working-time/target"),
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Target Working Hours"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			g.If(
+				errorMessage != "",
+				Div(
+					Class("alert alert-danger text-center"),
+					Role("alert"),
+					Span(g.Text(errorMessage)),
+				),
+			),
+			Input(
+				Type("hidden"),
+				Name("CSRFToken"),
+				Value(formModel.CSRFToken),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "ValidFrom"),
+					g.Text("Valid from"),
+				),
+				Input(
+					ID("ValidFrom"),
+					Type("text"),
+					Name("ValidFrom"),
+					Value(formModel.ValidFrom),
+					Pattern("[0-3][0-9]\\.[0-1][0-9]\\.20[0-9]{2}"),
+					MinLength("10"),
+					MaxLength("10"),
+					g.Attr("required", "required"),
+					Class("form-control"),
+					g.Attr("placeholder", "01.11.2021"),
+				),
+				Div(
+					Class("form-text"),
+					g.Text("Your overtime balance is calculated from this day on."),
+				),
+			),
+			Div(
+				Class("row g-2"),
+				g.Group(
+					g.Map(weekdaysOfForm, func(weekday time.Weekday) g.Node {
+						return Div(
+							Class("col"),
+							Label(
+								Class("form-label"),
+								g.Attr("for", weekday.String()),
+								g.Text(weekday.String()[:3]),
+							),
+							Input(
+								ID(weekday.String()),
+								Type("text"),
+								Name(weekday.String()),
+								Value(weekdayValues[weekday]),
+								MaxLength("5"),
+								Class("form-control"),
+								g.Attr("placeholder", "0:00"),
+							),
+						)
+					}),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("submit"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-save me-2")),
+				g.Text("Save"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("text-center btn btn-secondary"),
+				I(Class("bi-x me-2")),
+				g.Text("Cancel"),
+			),
+		),
+	)
+}
+
+func newWorkingTimeTargetFormModel(location *time.Location) workingTimeTargetFormModel {
+	now := time.Now().In(location)
+	return workingTimeTargetFormModel{
+		ValidFrom: time_utils.FormatDateDE(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)),
+		Monday:    "8:00",
+		Tuesday:   "8:00",
+		Wednesday: "8:00",
+		Thursday:  "8:00",
+		Friday:    "8:00",
+		Saturday:  "0:00",
+		Sunday:    "0:00",
+	}
+}
+
+func mapWorkingTimeTargetToForm(target *WorkingTimeTarget) workingTimeTargetFormModel {
+	formatMinutes := func(minutes int) string {
+		return fmt.Sprintf("%v:%02d", minutes/60, minutes%60)
+	}
+
+	return workingTimeTargetFormModel{
+		ValidFrom: time_utils.FormatDateDE(target.ValidFrom),
+		Monday:    formatMinutes(target.WeekdayMinutes[time.Monday]),
+		Tuesday:   formatMinutes(target.WeekdayMinutes[time.Tuesday]),
+		Wednesday: formatMinutes(target.WeekdayMinutes[time.Wednesday]),
+		Thursday:  formatMinutes(target.WeekdayMinutes[time.Thursday]),
+		Friday:    formatMinutes(target.WeekdayMinutes[time.Friday]),
+		Saturday:  formatMinutes(target.WeekdayMinutes[time.Saturday]),
+		Sunday:    formatMinutes(target.WeekdayMinutes[time.Sunday]),
+	}
+}
+
+func mapFormToWorkingTimeTarget(formModel workingTimeTargetFormModel) (*WorkingTimeTarget, error) {
+	validFrom, err := time_utils.ParseDateDE(formModel.ValidFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &WorkingTimeTarget{
+		ValidFrom: *validFrom,
+	}
+
+	weekdayValues := map[time.Weekday]string{
+		time.Monday:    formModel.Monday,
+		time.Tuesday:   formModel.Tuesday,
+		time.Wednesday: formModel.Wednesday,
+		time.Thursday:  formModel.Thursday,
+		time.Friday:    formModel.Friday,
+		time.Saturday:  formModel.Saturday,
+		time.Sunday:    formModel.Sunday,
+	}
+	for weekday, value := range weekdayValues {
+		minutes, err := time_utils.ParseDurationAsMinutes(value)
+		if err != nil {
+			return nil, err
+		}
+		if minutes > 24*60 {
+			return nil, errors.Errorf("target of %v exceeds 24 hours", weekday)
+		}
+		target.WeekdayMinutes[weekday] = minutes
+	}
+
+	return target, nil
+}
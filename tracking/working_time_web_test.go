@@ -0,0 +1,177 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestHandleWorkingTimeBalanceWithoutTarget(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &WorkingTimeWebHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			workingTimeRepository: NewInMemWorkingTimeRepository(),
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+			activityRepository:    NewInMemActivityRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/working-time/balance", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleWorkingTimeBalance()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Set up your target working hours"))
+}
+
+func TestHandleWorkingTimeBalance(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	_, _ = workingTimeRepository.UpsertWorkingTimeTarget(context.Background(), &WorkingTimeTarget{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+		ValidFrom:      time.Now().AddDate(0, 0, 1),
+		WeekdayMinutes: [7]int{0, 480, 480, 480, 480, 480, 0},
+	})
+
+	a := &WorkingTimeWebHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			workingTimeRepository: workingTimeRepository,
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+			activityRepository:    NewInMemActivityRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/working-time/balance", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleWorkingTimeBalance()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Overtime Balance"))
+	is.True(strings.Contains(htmlBody, "+0:00 h"))
+}
+
+func TestHandleWorkingTimeTargetPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &WorkingTimeWebHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			workingTimeRepository: NewInMemWorkingTimeRepository(),
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/working-time/target", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{}))
+
+	a.HandleWorkingTimeTargetPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Target Working Hours"))
+	is.True(strings.Contains(htmlBody, "name=\"Monday\""))
+}
+
+func TestHandleWorkingTimeTargetFormWithValidTarget(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	a := &WorkingTimeWebHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			repositoryTxer:        shared.NewInMemRepositoryTxer(),
+			workingTimeRepository: workingTimeRepository,
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["ValidFrom"] = []string{"01.11.2021"}
+	data["Monday"] = []string{"8"}
+	data["Tuesday"] = []string{"8:00"}
+	data["Wednesday"] = []string{"7,5"}
+	data["Thursday"] = []string{"8"}
+	data["Friday"] = []string{"4"}
+
+	r, _ := http.NewRequest("POST", "/working-time/target", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleWorkingTimeTargetForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(strings.Contains(httpRec.Header().Get("HX-Trigger"), "baralga__activities-changed"))
+
+	is.Equal(len(workingTimeRepository.targets), 1)
+	is.Equal(workingTimeRepository.targets[0].WeeklyMinutes(), 2130)
+	is.Equal(workingTimeRepository.targets[0].WeekdayMinutes[time.Wednesday], 450)
+}
+
+func TestHandleWorkingTimeTargetFormWithInvalidTarget(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	workingTimeRepository := NewInMemWorkingTimeRepository()
+	a := &WorkingTimeWebHandlers{
+		config: &shared.Config{},
+		workingTimeService: &WorkingTimeService{
+			repositoryTxer:        shared.NewInMemRepositoryTxer(),
+			workingTimeRepository: workingTimeRepository,
+			holidayRepository:     NewInMemHolidayRepository(),
+			absenceRepository:     NewInMemAbsenceRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["ValidFrom"] = []string{"01.11.2021"}
+	data["Monday"] = []string{"25"}
+
+	r, _ := http.NewRequest("POST", "/working-time/target", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "user1",
+	}))
+
+	a.HandleWorkingTimeTargetForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(workingTimeRepository.targets), 0)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Please enter a valid date and the hours per weekday."))
+}
@@ -0,0 +1,208 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbAPITokenRepository is a SQL database repository for personal api tokens
+type DbAPITokenRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ APITokenRepository = (*DbAPITokenRepository)(nil)
+
+// NewDbAPITokenRepository creates a new SQL database repository for personal api tokens
+func NewDbAPITokenRepository(connPool *pgxpool.Pool) *DbAPITokenRepository {
+	return &DbAPITokenRepository{
+		connPool: connPool,
+	}
+}
+
+// FindAPITokensByUserID finds the api tokens of the member ordered by creation, newest first
+func (r *DbAPITokenRepository) FindAPITokensByUserID(ctx context.Context, organizationID, userID uuid.UUID) ([]*APIToken, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT api_token_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+		 FROM api_tokens
+		 WHERE org_id = $1 AND user_id = $2
+		 ORDER BY created_at DESC`, organizationID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apiTokens []*APIToken
+	for rows.Next() {
+		var (
+			id         string
+			name       string
+			tokenHash  string
+			scopes     string
+			createdAt  time.Time
+			expiresAt  sql.NullTime
+			lastUsedAt sql.NullTime
+		)
+
+		err = rows.Scan(&id, &name, &tokenHash, &scopes, &createdAt, &expiresAt, &lastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		apiTokens = append(apiTokens, &APIToken{
+			ID:             uuid.MustParse(id),
+			OrganizationID: organizationID,
+			UserID:         userID,
+			Name:           name,
+			TokenHash:      tokenHash,
+			Scopes:         parseScopes(scopes),
+			CreatedAt:      createdAt,
+			ExpiresAt:      expiresAt.Time,
+			LastUsedAt:     lastUsedAt.Time,
+		})
+	}
+
+	return apiTokens, nil
+}
+
+func (r *DbAPITokenRepository) FindAPITokenByHash(ctx context.Context, tokenHash string) (*APIToken, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT api_token_id, org_id, user_id, name, scopes, created_at, expires_at, last_used_at
+		 FROM api_tokens
+		 WHERE token_hash = $1`, tokenHash,
+	)
+
+	var (
+		id             string
+		organizationID string
+		userID         string
+		name           string
+		scopes         string
+		createdAt      time.Time
+		expiresAt      sql.NullTime
+		lastUsedAt     sql.NullTime
+	)
+
+	err := row.Scan(&id, &organizationID, &userID, &name, &scopes, &createdAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPITokenNotFound
+		}
+
+		return nil, err
+	}
+
+	return &APIToken{
+		ID:             uuid.MustParse(id),
+		OrganizationID: uuid.MustParse(organizationID),
+		UserID:         uuid.MustParse(userID),
+		Name:           name,
+		TokenHash:      tokenHash,
+		Scopes:         parseScopes(scopes),
+		CreatedAt:      createdAt,
+		ExpiresAt:      expiresAt.Time,
+		LastUsedAt:     lastUsedAt.Time,
+	}, nil
+}
+
+func (r *DbAPITokenRepository) InsertAPIToken(ctx context.Context, apiToken *APIToken) (*APIToken, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO api_tokens
+		   (api_token_id, org_id, user_id, name, token_hash, scopes, created_at, expires_at)
+		 VALUES
+		   ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		apiToken.ID,
+		apiToken.OrganizationID,
+		apiToken.UserID,
+		apiToken.Name,
+		apiToken.TokenHash,
+		strings.Join(apiToken.Scopes, ","),
+		apiToken.CreatedAt,
+		sql.NullTime{Time: apiToken.ExpiresAt, Valid: !apiToken.ExpiresAt.IsZero()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiToken, nil
+}
+
+func (r *DbAPITokenRepository) UpdateAPITokenLastUsed(ctx context.Context, apiTokenID uuid.UUID, lastUsedAt time.Time) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE api_tokens
+		 SET last_used_at = $2
+		 WHERE api_token_id = $1`,
+		apiTokenID,
+		lastUsedAt,
+	)
+
+	return err
+}
+
+func (r *DbAPITokenRepository) DeleteAPITokenByID(ctx context.Context, organizationID, userID, apiTokenID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`DELETE
+		 FROM api_tokens
+		 WHERE org_id = $1 AND user_id = $2 AND api_token_id = $3
+		 RETURNING api_token_id`,
+		organizationID, userID, apiTokenID)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAPITokenNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *DbAPITokenRepository) DeleteAPITokensByUserID(ctx context.Context, organizationID, userID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE
+		 FROM api_tokens
+		 WHERE org_id = $1 AND user_id = $2`,
+		organizationID, userID,
+	)
+
+	return err
+}
+
+// parseScopes parses comma separated scopes, unknown scopes are kept so
+// that a token never loses its restrictions and gains full access
+func parseScopes(scopes string) []string {
+	var parsed []string
+	for _, scope := range strings.Split(scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		parsed = append(parsed, scope)
+	}
+
+	return parsed
+}
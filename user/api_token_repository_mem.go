@@ -0,0 +1,72 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InMemAPITokenRepository struct {
+	apiTokens []*APIToken
+}
+
+var _ APITokenRepository = (*InMemAPITokenRepository)(nil)
+
+func NewInMemAPITokenRepository() *InMemAPITokenRepository {
+	return &InMemAPITokenRepository{}
+}
+
+func (r *InMemAPITokenRepository) FindAPITokensByUserID(ctx context.Context, organizationID, userID uuid.UUID) ([]*APIToken, error) {
+	var apiTokens []*APIToken
+	for _, t := range r.apiTokens {
+		if t.OrganizationID == organizationID && t.UserID == userID {
+			apiTokens = append(apiTokens, t)
+		}
+	}
+	return apiTokens, nil
+}
+
+func (r *InMemAPITokenRepository) FindAPITokenByHash(ctx context.Context, tokenHash string) (*APIToken, error) {
+	for _, t := range r.apiTokens {
+		if t.TokenHash == tokenHash {
+			return t, nil
+		}
+	}
+	return nil, ErrAPITokenNotFound
+}
+
+func (r *InMemAPITokenRepository) InsertAPIToken(ctx context.Context, apiToken *APIToken) (*APIToken, error) {
+	r.apiTokens = append(r.apiTokens, apiToken)
+	return apiToken, nil
+}
+
+func (r *InMemAPITokenRepository) UpdateAPITokenLastUsed(ctx context.Context, apiTokenID uuid.UUID, lastUsedAt time.Time) error {
+	for _, t := range r.apiTokens {
+		if t.ID == apiTokenID {
+			t.LastUsedAt = lastUsedAt
+		}
+	}
+	return nil
+}
+
+func (r *InMemAPITokenRepository) DeleteAPITokenByID(ctx context.Context, organizationID, userID, apiTokenID uuid.UUID) error {
+	for i, t := range r.apiTokens {
+		if t.ID == apiTokenID && t.OrganizationID == organizationID && t.UserID == userID {
+			r.apiTokens = append(r.apiTokens[:i], r.apiTokens[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAPITokenNotFound
+}
+
+func (r *InMemAPITokenRepository) DeleteAPITokensByUserID(ctx context.Context, organizationID, userID uuid.UUID) error {
+	var apiTokens []*APIToken
+	for _, t := range r.apiTokens {
+		if t.OrganizationID != organizationID || t.UserID != userID {
+			apiTokens = append(apiTokens, t)
+		}
+	}
+	r.apiTokens = apiTokens
+	return nil
+}
@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestAPITokenRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	apiTokenRepository := NewDbAPITokenRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	adminID := uuid.MustParse("00000000-0000-0000-1111-000000000001")
+	apiToken := &APIToken{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		UserID:         adminID,
+		Name:           "CI",
+		TokenHash:      HashAPIToken("bpat_secret"),
+		Scopes:         []string{shared.ScopeActivitiesRead, shared.ScopeProjectsRead},
+		CreatedAt:      time.Now(),
+	}
+
+	t.Run("InsertAPIToken", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := apiTokenRepository.InsertAPIToken(ctx, apiToken)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		apiTokens, err := apiTokenRepository.FindAPITokensByUserID(context.Background(), shared.OrganizationIDSample, adminID)
+		is.NoErr(err)
+		is.Equal(len(apiTokens), 1)
+		is.Equal(apiTokens[0].Name, "CI")
+		is.Equal(apiTokens[0].Scopes, []string{shared.ScopeActivitiesRead, shared.ScopeProjectsRead})
+		is.True(apiTokens[0].ExpiresAt.IsZero())
+		is.True(apiTokens[0].LastUsedAt.IsZero())
+	})
+	t.Run("UpdateAPITokenLastUsed", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return apiTokenRepository.UpdateAPITokenLastUsed(ctx, apiToken.ID, time.Now())
+			},
+		)
+		is.NoErr(err)
+
+		foundAPIToken, err := apiTokenRepository.FindAPITokenByHash(context.Background(), HashAPIToken("bpat_secret"))
+		is.NoErr(err)
+		is.Equal(foundAPIToken.ID, apiToken.ID)
+		is.Equal(foundAPIToken.UserID, adminID)
+		is.True(!foundAPIToken.LastUsedAt.IsZero())
+	})
+	t.Run("DeleteAPITokenByID", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return apiTokenRepository.DeleteAPITokenByID(ctx, shared.OrganizationIDSample, adminID, apiToken.ID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = apiTokenRepository.FindAPITokenByHash(context.Background(), HashAPIToken("bpat_secret"))
+		is.True(errors.Is(err, ErrAPITokenNotFound))
+	})
+}
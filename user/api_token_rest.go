@@ -0,0 +1,189 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+// apiTokenModel is a personal api token, the token itself is only contained in the response to the creation
+type apiTokenModel struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	Token      string     `json:"token,omitempty"`
+	Scopes     []string   `json:"scopes" validate:"dive,max=50"`
+	CreatedAt  string     `json:"createdAt"`
+	ExpiresAt  string     `json:"expiresAt,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	LastUsedAt string     `json:"lastUsedAt,omitempty"`
+	Links      *hal.Links `json:"_links"`
+}
+
+type apiTokensModel struct {
+	*EmbeddedAPITokens `json:"_embedded"`
+	Links              *hal.Links `json:"_links"`
+}
+
+// EmbeddedAPITokens contains embedded api tokens
+type EmbeddedAPITokens struct {
+	APITokenModels []*apiTokenModel `json:"tokens"`
+}
+
+type APITokenRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewAPITokenRestHandlers(config *shared.Config, userService *UserService) *APITokenRestHandlers {
+	return &APITokenRestHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *APITokenRestHandlers) RegisterProtected(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(shared.RequireUnscoped())
+
+		r.Get("/tokens", a.HandleGetAPITokens())
+		r.Post("/tokens", a.HandleCreateAPIToken())
+		r.Delete("/tokens/{token-id}", a.HandleDeleteAPIToken())
+	})
+}
+
+func (a *APITokenRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetAPITokens reads the personal api tokens of the signed in user
+func (a *APITokenRestHandlers) HandleGetAPITokens() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		apiTokens, err := userService.ReadAPITokens(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		apiTokenModels := make([]*apiTokenModel, 0, len(apiTokens))
+		for _, apiToken := range apiTokens {
+			apiTokenModels = append(apiTokenModels, mapToAPITokenModel(apiToken))
+		}
+
+		apiTokensModel := &apiTokensModel{
+			EmbeddedAPITokens: &EmbeddedAPITokens{
+				APITokenModels: apiTokenModels,
+			},
+			Links: hal.NewSelfLink(r.RequestURI),
+		}
+
+		shared.RenderJSON(w, apiTokensModel)
+	}
+}
+
+// HandleCreateAPIToken creates a personal api token restricted to the scopes if any,
+// the response contains the token which can't be read again
+func (a *APITokenRestHandlers) HandleCreateAPIToken() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var apiTokenModel apiTokenModel
+		err := json.NewDecoder(r.Body).Decode(&apiTokenModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(apiTokenModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("api token not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		var expiresAt time.Time
+		if apiTokenModel.ExpiresAt != "" {
+			expiresAt, _ = time.Parse(time.RFC3339, apiTokenModel.ExpiresAt)
+		}
+
+		apiToken, secret, err := userService.CreateAPIToken(r.Context(), principal, apiTokenModel.Name, expiresAt, apiTokenModel.Scopes)
+		if errors.Is(err, ErrInvalidAPIToken) {
+			http.Error(w, problem.New(problem.Title("api token not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		createdModel := mapToAPITokenModel(apiToken)
+		createdModel.Token = secret
+
+		w.WriteHeader(http.StatusCreated)
+		shared.RenderJSON(w, createdModel)
+	}
+}
+
+// HandleDeleteAPIToken revokes a personal api token of the signed in user
+func (a *APITokenRestHandlers) HandleDeleteAPIToken() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		apiTokenID, err := uuid.Parse(chi.URLParam(r, "token-id"))
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.DeleteAPIToken(r.Context(), principal, apiTokenID)
+		if errors.Is(err, ErrAPITokenNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+	}
+}
+
+func mapToAPITokenModel(apiToken *APIToken) *apiTokenModel {
+	scopes := apiToken.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	apiTokenModel := &apiTokenModel{
+		ID:        apiToken.ID.String(),
+		Name:      apiToken.Name,
+		Scopes:    scopes,
+		CreatedAt: apiToken.CreatedAt.Format(time.RFC3339),
+	}
+	if !apiToken.ExpiresAt.IsZero() {
+		apiTokenModel.ExpiresAt = apiToken.ExpiresAt.Format(time.RFC3339)
+	}
+	if !apiToken.LastUsedAt.IsZero() {
+		apiTokenModel.LastUsedAt = apiToken.LastUsedAt.Format(time.RFC3339)
+	}
+
+	apiTokenModel.Links = hal.NewLinks(
+		hal.NewSelfLink(fmt.Sprintf("/api/tokens/%s", apiTokenModel.ID)),
+		hal.NewLink("delete", fmt.Sprintf("/api/tokens/%s", apiTokenModel.ID)),
+	)
+
+	return apiTokenModel
+}
@@ -0,0 +1,207 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/matryer/is"
+)
+
+func TestHandleCreateAPIToken(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	apiTokenRepository := NewInMemAPITokenRepository()
+
+	a := &APITokenRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: apiTokenRepository,
+		},
+	}
+
+	body := `{"name": "Build Server", "expiresAt": "2099-12-31T00:00:00Z"}`
+	r, _ := http.NewRequest("POST", "/api/tokens", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleCreateAPIToken()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+
+	apiTokenModel := &apiTokenModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(apiTokenModel)
+	is.NoErr(err)
+	is.Equal(apiTokenModel.Name, "Build Server")
+	is.Equal(apiTokenModel.ExpiresAt, "2099-12-31T00:00:00Z")
+	is.True(IsAPIToken(apiTokenModel.Token))
+	is.Equal(len(apiTokenRepository.apiTokens), 1)
+}
+
+func TestHandleCreateAPITokenWithInvalidExpiry(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &APITokenRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+		},
+	}
+
+	body := `{"name": "Build Server", "expiresAt": "tomorrow"}`
+	r, _ := http.NewRequest("POST", "/api/tokens", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleCreateAPIToken()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
+
+func TestHandleGetAPITokens(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &APITokenRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/tokens", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleGetAPITokens()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	apiTokensModel := &apiTokensModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(apiTokensModel)
+	is.NoErr(err)
+	is.Equal(len(apiTokensModel.APITokenModels), 0)
+}
+
+func TestAPITokenRoutesWithScopedToken(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &APITokenRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+		},
+	}
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+				Username:       "admin@baralga.com",
+				OrganizationID: shared.OrganizationIDSample,
+				Scopes:         []string{shared.ScopeActivitiesRead},
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	a.RegisterProtected(router)
+
+	r, _ := http.NewRequest("POST", "/tokens", strings.NewReader(`{"name": "Escalation"}`))
+	router.ServeHTTP(httpRec, r)
+
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestProtectedRoutesWithScopedToken(t *testing.T) {
+	is := is.New(t)
+
+	config := &shared.Config{}
+	userService := &UserService{
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          NewInMemUserRepository(),
+		organizationRepository:  NewInMemOrganizationRepository(),
+		teamRepository:          NewInMemTeamRepository(),
+		roleRepository:          NewInMemRoleRepository(),
+		apiTokenRepository:      NewInMemAPITokenRepository(),
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
+	}
+
+	newRouter := func(scopes []string) *chi.Mux {
+		router := chi.NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+					Username:       "admin@baralga.com",
+					OrganizationID: shared.OrganizationIDSample,
+					Roles:          []string{RoleAdmin},
+					Scopes:         scopes,
+				})
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		})
+		shared.RegisterProtectedRoutes(router, []shared.DomainHandler{
+			NewUserRestHandlers(config, userService),
+			NewProfileRestHandlers(config, userService),
+			NewOrganizationRestHandlers(config, userService),
+			NewRoleRestHandlers(config, userService),
+			NewTeamRestHandlers(config, userService),
+		})
+		return router
+	}
+
+	router := newRouter([]string{shared.ScopeActivitiesRead})
+	requests := []struct {
+		method string
+		target string
+		body   string
+	}{
+		{"GET", "/users", ""},
+		{"DELETE", "/users/00000000-0000-0000-1111-000000000001", ""},
+		{"GET", "/me", ""},
+		{"POST", "/me/email", `{"email": "mallory@baralga.com"}`},
+		{"GET", "/organization", ""},
+		{"PATCH", "/organization", `{"name": "Taken Over"}`},
+		{"GET", "/roles", ""},
+		{"POST", "/roles", `{"title": "Escalation"}`},
+		{"GET", "/teams", ""},
+		{"POST", "/teams", `{"name": "Escalation"}`},
+	}
+	for _, request := range requests {
+		t.Run(fmt.Sprintf("%v %v", request.method, request.target), func(t *testing.T) {
+			httpRec := httptest.NewRecorder()
+			r, _ := http.NewRequest(request.method, request.target, strings.NewReader(request.body))
+
+			router.ServeHTTP(httpRec, r)
+
+			is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+			is.True(strings.Contains(httpRec.Body.String(), "insufficient scope"))
+		})
+	}
+
+	t.Run("unscoped token", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/users", nil)
+
+		newRouter(nil).ServeHTTP(httpRec, r)
+
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	})
+}
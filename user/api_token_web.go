@@ -0,0 +1,440 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+// scopeTitles describe the scopes in the api token form
+var scopeTitles = map[string]string{
+	shared.ScopeActivitiesRead:  "Read activities",
+	shared.ScopeActivitiesWrite: "Create, edit and delete activities",
+	shared.ScopeProjectsRead:    "Read projects",
+	shared.ScopeProjectsWrite:   "Create, edit and delete projects",
+	shared.ScopeReportsRead:     "Read reports",
+	shared.ScopeSCIM:            "Provision members and groups with SCIM",
+}
+
+type apiTokenFormModel struct {
+	CSRFToken string
+	Name      string `validate:"required,max=100"`
+	ExpiresAt string `validate:"omitempty,datetime=2006-01-02"`
+	Scopes    []string
+}
+
+type APITokenWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewAPITokenWebHandlers(config *shared.Config, userService *UserService) *APITokenWebHandlers {
+	return &APITokenWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *APITokenWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/tokens", a.HandleAPITokensPage())
+	r.Get("/tokens/new", a.HandleAPITokenAddPage())
+	r.Post("/tokens/new", a.HandleAPITokenForm())
+	r.Post("/tokens/{token-id}/delete", a.HandleDeleteAPIToken())
+}
+
+func (a *APITokenWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleAPITokensPage shows the personal api tokens of the signed in user
+func (a *APITokenWebHandlers) HandleAPITokensPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		apiTokens, err := userService.ReadAPITokens(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "API Tokens",
+			}
+			shared.RenderHTML(w, APITokensPage(pageContext, csrf.Token(r), apiTokens, ""))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, APITokensView(principal, csrf.Token(r), apiTokens, ""))
+	}
+}
+
+// HandleAPITokenAddPage shows the form for a new personal api token
+func (a *APITokenWebHandlers) HandleAPITokenAddPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formModel := apiTokenFormModel{CSRFToken: csrf.Token(r)}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, APITokenForm(formModel, nil))
+	}
+}
+
+// HandleAPITokenForm creates a new personal api token and shows its secret once
+func (a *APITokenWebHandlers) HandleAPITokenForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var formModel apiTokenFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		formModel.CSRFToken = csrf.Token(r)
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			shared.RenderHTML(w, APITokenForm(formModel, map[string]string{"Name": "Name must have 1 to 100 characters."}))
+			return
+		}
+
+		// the token expires at the end of the selected day
+		var expiresAt time.Time
+		if formModel.ExpiresAt != "" {
+			expiryDate, _ := time.ParseInLocation("2006-01-02", formModel.ExpiresAt, principal.Location())
+			expiresAt = expiryDate.AddDate(0, 0, 1)
+		}
+
+		_, secret, err := userService.CreateAPIToken(r.Context(), principal, formModel.Name, expiresAt, formModel.Scopes)
+		if errors.Is(err, ErrInvalidAPIToken) {
+			shared.RenderHTML(w, APITokenForm(formModel, map[string]string{"ExpiresAt": "Expiry date must not be in the past and scopes must be known."}))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		apiTokens, err := userService.ReadAPITokens(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, APITokensView(principal, csrf.Token(r), apiTokens, secret))
+	}
+}
+
+// HandleDeleteAPIToken revokes a personal api token and shows the remaining tokens
+func (a *APITokenWebHandlers) HandleDeleteAPIToken() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		apiTokenID, err := uuid.Parse(chi.URLParam(r, "token-id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.DeleteAPIToken(r.Context(), principal, apiTokenID)
+		if errors.Is(err, ErrAPITokenNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		apiTokens, err := userService.ReadAPITokens(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, APITokensView(principal, csrf.Token(r), apiTokens, ""))
+	}
+}
+
+func APITokensPage(pageContext *shared.PageContext, csrfToken string, apiTokens []*APIToken, secret string) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					APITokensView(pageContext.Principal, csrfToken, apiTokens, secret),
+				),
+			),
+		},
+	)
+}
+
+// APITokensView lists the api tokens, the secret of a newly created token is shown once
+func APITokensView(principal *shared.Principal, csrfToken string, apiTokens []*APIToken, secret string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("API Tokens"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			g.If(secret != "",
+				Div(
+					Class("alert alert-success"),
+					P(
+						g.Text("Copy the new token now, it will not be shown again."),
+					),
+					Input(
+						ID("api_token_Secret"),
+						Type("text"),
+						ReadOnly(),
+						Class("form-control font-monospace"),
+						Value(secret),
+					),
+				),
+			),
+			Div(
+				Class("d-flex justify-content-end mb-3"),
+				A(
+					ghx.Get("/tokens/new"),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					Class("btn btn-outline-primary btn-sm"),
+					I(Class("bi-plus me-2")),
+					g.Text("New Token"),
+				),
+			),
+			g.If(len(apiTokens) == 0,
+				P(
+					Class("text-muted"),
+					g.Text("No API tokens yet. Tokens are sent as bearer token to the REST API."),
+				),
+			),
+			Table(
+				Class("table table-sm table-borderless align-middle"),
+				TBody(
+					g.Group(
+						g.Map(apiTokens, func(apiToken *APIToken) g.Node {
+							return APITokenRow(principal, csrfToken, apiToken)
+						}),
+					),
+				),
+			),
+		),
+	)
+}
+
+func APITokenRow(principal *shared.Principal, csrfToken string, apiToken *APIToken) g.Node {
+	location := principal.Location()
+
+	expiry := "never expires"
+	if apiToken.IsExpired(time.Now()) {
+		expiry = "expired"
+	} else if !apiToken.ExpiresAt.IsZero() {
+		expiry = fmt.Sprintf("until %v", apiToken.ExpiresAt.Add(-time.Second).In(location).Format("02.01.2006"))
+	}
+
+	scopes := "full access"
+	if len(apiToken.Scopes) > 0 {
+		scopes = strings.Join(apiToken.Scopes, ", ")
+	}
+
+	lastUsed := "never used"
+	if !apiToken.LastUsedAt.IsZero() {
+		lastUsed = fmt.Sprintf("last used %v", apiToken.LastUsedAt.In(location).Format("02.01.2006 15:04"))
+	}
+
+	return Tr(
+		Td(
+			Class("w-100"),
+			Div(
+				g.Text(apiToken.Name),
+			),
+			Small(
+				Class("text-muted"),
+				g.Textf("%v, %v, %v", scopes, expiry, lastUsed),
+			),
+		),
+		Td(
+			Class("text-nowrap"),
+			FormEl(
+				Class("d-inline"),
+				ghx.Post(fmt.Sprintf("/tokens/%v/delete", apiToken.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm(fmt.Sprintf("Do you really want to revoke the token %v?", apiToken.Name)),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr(fmt.Sprintf("Revoke %v", apiToken.Name)),
+					I(Class("bi-trash2")),
+				),
+			),
+		),
+	)
+}
+
+func APITokenForm(formModel apiTokenFormModel, fieldErrors map[string]string) g.Node {
+	return FormEl(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		ghx.Post("/tokens/new"),
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("New API Token"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Input(
+				Type("hidden"),
+				Name("CSRFToken"),
+				Value(formModel.CSRFToken),
+			),
+			Div(
+				Class("form-floating mb-3"),
+				Input(
+					ID("api_token_Name"),
+					Required(),
+					Type("text"),
+					Name("Name"),
+					MaxLength("100"),
+					organizationControlClass("form-control", "Name", fieldErrors),
+					g.Attr("placeholder", "Name"),
+					Value(formModel.Name),
+				),
+				Label(
+					g.Attr("for", "api_token_Name"),
+					g.Text("Name"),
+				),
+				organizationFieldError("Name", fieldErrors),
+			),
+			Div(
+				Class("form-floating mb-3"),
+				Input(
+					ID("api_token_ExpiresAt"),
+					Type("date"),
+					Name("ExpiresAt"),
+					organizationControlClass("form-control", "ExpiresAt", fieldErrors),
+					g.Attr("placeholder", "Expires"),
+					Value(formModel.ExpiresAt),
+				),
+				Label(
+					g.Attr("for", "api_token_ExpiresAt"),
+					g.Text("Expires (optional)"),
+				),
+				organizationFieldError("ExpiresAt", fieldErrors),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label d-block"),
+					g.Text("Scopes"),
+				),
+				g.Group(
+					g.Map(shared.Scopes, func(scope string) g.Node {
+						checkboxID := fmt.Sprintf("api_token_Scopes_%v", scope)
+						return Div(
+							Class("form-check"),
+							Input(
+								ID(checkboxID),
+								Type("checkbox"),
+								Name("Scopes"),
+								Value(scope),
+								Class("form-check-input"),
+								g.If(slices.Contains(formModel.Scopes, scope), Checked()),
+							),
+							Label(
+								Class("form-check-label"),
+								g.Attr("for", checkboxID),
+								g.Text(scopeTitles[scope]),
+							),
+						)
+					}),
+				),
+				Div(
+					Class("form-text"),
+					g.Text("Without scopes the token has the full access of your account."),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("submit"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-key me-2")),
+				g.Text("Create"),
+			),
+			A(
+				ghx.Get("/tokens"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("text-center btn btn-secondary"),
+				I(Class("bi-x me-2")),
+				g.Text("Cancel"),
+			),
+		),
+	)
+}
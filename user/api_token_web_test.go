@@ -0,0 +1,166 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleAPITokensPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	apiTokenRepository := NewInMemAPITokenRepository()
+	apiTokenRepository.apiTokens = append(apiTokenRepository.apiTokens, &APIToken{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		UserID:         uuid.MustParse("00000000-0000-0000-1111-000000000001"),
+		Name:           "Build Server",
+		TokenHash:      HashAPIToken("bpat_secret"),
+		CreatedAt:      time.Now(),
+	})
+
+	a := &APITokenWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: apiTokenRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/tokens", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleAPITokensPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Build Server"))
+	is.True(strings.Contains(htmlBody, "never used"))
+	is.True(!strings.Contains(htmlBody, "bpat_secret"))
+}
+
+func TestHandleAPITokenForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	apiTokenRepository := NewInMemAPITokenRepository()
+
+	a := &APITokenWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: apiTokenRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Build Server"}
+	data["ExpiresAt"] = []string{time.Now().AddDate(1, 0, 0).Format("2006-01-02")}
+
+	r, _ := http.NewRequest("POST", "/tokens/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleAPITokenForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(apiTokenRepository.apiTokens), 1)
+	is.True(!apiTokenRepository.apiTokens[0].ExpiresAt.IsZero())
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Build Server"))
+	is.True(strings.Contains(htmlBody, APITokenPrefix))
+}
+
+func TestHandleAPITokenFormWithoutName(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	apiTokenRepository := NewInMemAPITokenRepository()
+
+	a := &APITokenWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: apiTokenRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{""}
+
+	r, _ := http.NewRequest("POST", "/tokens/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleAPITokenForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(apiTokenRepository.apiTokens), 0)
+	is.True(strings.Contains(httpRec.Body.String(), "Name must have 1 to 100 characters."))
+}
+
+func TestHandleDeleteAPIToken(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	apiTokenID := uuid.New()
+	apiTokenRepository := NewInMemAPITokenRepository()
+	apiTokenRepository.apiTokens = append(apiTokenRepository.apiTokens, &APIToken{
+		ID:             apiTokenID,
+		OrganizationID: shared.OrganizationIDSample,
+		UserID:         uuid.MustParse("00000000-0000-0000-1111-000000000001"),
+		Name:           "Build Server",
+		TokenHash:      HashAPIToken("bpat_secret"),
+		CreatedAt:      time.Now(),
+	})
+
+	a := &APITokenWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			apiTokenRepository: apiTokenRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/tokens/%v/delete", apiTokenID), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("token-id", apiTokenID.String())
+
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleDeleteAPIToken()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(apiTokenRepository.apiTokens), 0)
+}
@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbInvitationRepository is a SQL database repository for invitations
+type DbInvitationRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ InvitationRepository = (*DbInvitationRepository)(nil)
+
+// NewDbInvitationRepository creates a new SQL database repository for invitations
+func NewDbInvitationRepository(connPool *pgxpool.Pool) *DbInvitationRepository {
+	return &DbInvitationRepository{
+		connPool: connPool,
+	}
+}
+
+func (r *DbInvitationRepository) InsertInvitation(ctx context.Context, invitation *Invitation) (*Invitation, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO user_invitations 
+		   (user_invitation_id, org_id, email, created_by, created_at, expires_at) 
+		 VALUES 
+		   ($1, $2, $3, $4, $5, $6)`,
+		invitation.ID,
+		invitation.OrganizationID,
+		invitation.EMail,
+		invitation.CreatedBy,
+		invitation.CreatedAt,
+		invitation.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *DbInvitationRepository) FindInvitationByID(ctx context.Context, invitationID uuid.UUID) (*Invitation, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT org_id, email, created_by, created_at, expires_at 
+		 FROM user_invitations 
+		 WHERE user_invitation_id = $1`, invitationID,
+	)
+
+	var (
+		organizationID string
+		email          string
+		createdBy      string
+		createdAt      time.Time
+		expiresAt      time.Time
+	)
+
+	err := row.Scan(&organizationID, &email, &createdBy, &createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		ID:             invitationID,
+		OrganizationID: uuid.MustParse(organizationID),
+		EMail:          email,
+		CreatedBy:      createdBy,
+		CreatedAt:      createdAt,
+		ExpiresAt:      expiresAt,
+	}
+	return invitation, nil
+}
+
+// FindPendingInvitations finds the invitations of the organization which have not expired yet
+func (r *DbInvitationRepository) FindPendingInvitations(ctx context.Context, organizationID uuid.UUID, now time.Time) ([]*Invitation, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT user_invitation_id, email, created_by, created_at, expires_at 
+		 FROM user_invitations 
+		 WHERE org_id = $1 AND expires_at > $2
+		 ORDER BY created_at DESC`,
+		organizationID, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*Invitation
+	for rows.Next() {
+		var (
+			id        string
+			email     string
+			createdBy string
+			createdAt time.Time
+			expiresAt time.Time
+		)
+
+		err = rows.Scan(&id, &email, &createdBy, &createdAt, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		invitation := &Invitation{
+			ID:             uuid.MustParse(id),
+			OrganizationID: organizationID,
+			EMail:          email,
+			CreatedBy:      createdBy,
+			CreatedAt:      createdAt,
+			ExpiresAt:      expiresAt,
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+func (r *DbInvitationRepository) DeleteInvitationByID(ctx context.Context, organizationID, invitationID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`DELETE 
+         FROM user_invitations 
+	     WHERE user_invitation_id = $1 AND org_id = $2
+		 RETURNING user_invitation_id`,
+		invitationID, organizationID)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+)
+
+type InMemInvitationRepository struct {
+	invitations []*Invitation
+}
+
+var _ InvitationRepository = (*InMemInvitationRepository)(nil)
+
+func NewInMemInvitationRepository() *InMemInvitationRepository {
+	return &InMemInvitationRepository{
+		invitations: []*Invitation{
+			{
+				ID:             shared.InvitationIDSample,
+				OrganizationID: shared.OrganizationIDSample,
+				EMail:          "invited@baralga.com",
+				CreatedBy:      "admin@baralga.com",
+				CreatedAt:      time.Now(),
+				ExpiresAt:      time.Now().Add(InvitationValidity),
+			},
+		},
+	}
+}
+
+func (r *InMemInvitationRepository) InsertInvitation(ctx context.Context, invitation *Invitation) (*Invitation, error) {
+	r.invitations = append(r.invitations, invitation)
+	return invitation, nil
+}
+
+func (r *InMemInvitationRepository) FindInvitationByID(ctx context.Context, invitationID uuid.UUID) (*Invitation, error) {
+	for _, invitation := range r.invitations {
+		if invitation.ID == invitationID {
+			return invitation, nil
+		}
+	}
+	return nil, ErrInvitationNotFound
+}
+
+func (r *InMemInvitationRepository) FindPendingInvitations(ctx context.Context, organizationID uuid.UUID, now time.Time) ([]*Invitation, error) {
+	var invitations []*Invitation
+	for _, invitation := range r.invitations {
+		if invitation.OrganizationID == organizationID && !invitation.IsExpired(now) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (r *InMemInvitationRepository) DeleteInvitationByID(ctx context.Context, organizationID, invitationID uuid.UUID) error {
+	for i, invitation := range r.invitations {
+		if invitation.ID == invitationID && invitation.OrganizationID == organizationID {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			return nil
+		}
+	}
+	return ErrInvitationNotFound
+}
@@ -0,0 +1,97 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestInvitationRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	invitationRepository := NewDbInvitationRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	now := time.Now()
+	invitation := &Invitation{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		EMail:          "ivy.invited@baralga.com",
+		CreatedBy:      "admin@baralga.com",
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(InvitationValidity),
+	}
+
+	t.Run("InsertInvitation", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := invitationRepository.InsertInvitation(ctx, invitation)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		foundInvitation, err := invitationRepository.FindInvitationByID(context.Background(), invitation.ID)
+		is.NoErr(err)
+		is.Equal(foundInvitation.EMail, "ivy.invited@baralga.com")
+		is.Equal(foundInvitation.OrganizationID, shared.OrganizationIDSample)
+	})
+
+	t.Run("FindPendingInvitations", func(t *testing.T) {
+		invitations, err := invitationRepository.FindPendingInvitations(context.Background(), shared.OrganizationIDSample, now)
+		is.NoErr(err)
+		is.Equal(len(invitations), 1)
+
+		invitations, err = invitationRepository.FindPendingInvitations(context.Background(), shared.OrganizationIDSample, now.Add(InvitationValidity))
+		is.NoErr(err)
+		is.Equal(len(invitations), 0)
+	})
+
+	t.Run("DeleteInvitationByID", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return invitationRepository.DeleteInvitationByID(ctx, shared.OrganizationIDSample, invitation.ID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = invitationRepository.FindInvitationByID(context.Background(), invitation.ID)
+		is.True(errors.Is(err, ErrInvitationNotFound))
+	})
+
+	t.Run("DeleteMissingInvitation", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return invitationRepository.DeleteInvitationByID(ctx, shared.OrganizationIDSample, uuid.New())
+			},
+		)
+		is.True(errors.Is(err, ErrInvitationNotFound))
+	})
+}
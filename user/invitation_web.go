@@ -0,0 +1,723 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type invitationFormModel struct {
+	CSRFToken string
+	EMail     string `validate:"required,email,max=100"`
+}
+
+type invitationSignupFormModel struct {
+	CSRFToken        string
+	Name             string `validate:"required,min=5,max=50"`
+	Password         string `validate:"required,min=8,max=100"`
+	AcceptConditions bool
+}
+
+type InvitationWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewInvitationWebHandlers(config *shared.Config, userService *UserService) *InvitationWebHandlers {
+	return &InvitationWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *InvitationWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/invitations", a.HandleInvitationsPage())
+	r.Post("/invitations/new", a.HandleInvitationForm())
+	r.Post("/invitations/{invitation-id}/revoke", a.HandleRevokeInvitation())
+}
+
+func (a *InvitationWebHandlers) RegisterOpen(r chi.Router) {
+	r.Get("/signup/invitation/{invitation-id}", a.HandleInvitationSignUpPage())
+	r.Post("/signup/invitation/{invitation-id}", a.HandleInvitationSignUpForm())
+	r.Post("/signup/invitation/{invitation-id}/join", a.HandleInvitationJoinForm())
+}
+
+func (a *InvitationWebHandlers) HandleInvitationsPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		invitations, err := userService.ReadPendingInvitations(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := invitationFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Invitations",
+			}
+			shared.RenderHTML(w, InvitationsPage(pageContext, formModel, invitations))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, InvitationsView(formModel, invitations, principal.Location(), nil))
+	}
+}
+
+// HandleInvitationForm invites a user by email into the organization of the principal
+func (a *InvitationWebHandlers) HandleInvitationForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var formModel invitationFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		fieldErrors := make(map[string]string)
+		err = validator.Struct(formModel)
+		if err != nil {
+			fieldErrors["EMail"] = "Invalid email."
+		} else {
+			_, err = userService.InviteUser(r.Context(), principal, formModel.EMail)
+			if errors.Is(err, ErrUserExists) {
+				fieldErrors["EMail"] = "A user with this email is already a member."
+			} else if err != nil {
+				shared.RenderProblemHTML(w, isProduction, err)
+				return
+			}
+		}
+
+		invitations, err := userService.ReadPendingInvitations(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if len(fieldErrors) == 0 {
+			formModel = invitationFormModel{}
+		}
+		formModel.CSRFToken = csrf.Token(r)
+		shared.RenderHTML(w, InvitationsView(formModel, invitations, principal.Location(), fieldErrors))
+	}
+}
+
+func (a *InvitationWebHandlers) HandleRevokeInvitation() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitationIDParam := chi.URLParam(r, "invitation-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		invitationID, err := uuid.Parse(invitationIDParam)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.RevokeInvitation(r.Context(), principal, invitationID)
+		if errors.Is(err, ErrInvitationNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		invitations, err := userService.ReadPendingInvitations(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := invitationFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+		shared.RenderHTML(w, InvitationsView(formModel, invitations, principal.Location(), nil))
+	}
+}
+
+func (a *InvitationWebHandlers) HandleInvitationSignUpPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitation, err := a.readInvitation(r)
+		if err != nil && !errors.Is(err, ErrInvitationNotFound) {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := invitationSignupFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+
+		if invitation == nil {
+			shared.RenderHTML(w, a.InvitationSignUpPage(r.URL.Path, InvitationInvalid()))
+			return
+		}
+
+		registered, err := userService.IsInvitedUserRegistered(r.Context(), invitation)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if registered {
+			shared.RenderHTML(w, a.InvitationSignUpPage(r.URL.Path, InvitationJoinForm(invitation, formModel.CSRFToken)))
+			return
+		}
+
+		shared.RenderHTML(w, a.InvitationSignUpPage(r.URL.Path, a.InvitationSignUpForm(invitation, formModel, nil)))
+	}
+}
+
+// HandleInvitationJoinForm makes an existing user who accepts an invitation member of the organization
+func (a *InvitationWebHandlers) HandleInvitationJoinForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitation, err := a.readInvitation(r)
+		if errors.Is(err, ErrInvitationNotFound) {
+			shared.RenderHTML(w, InvitationInvalid())
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = userService.JoinOrganization(r.Context(), invitation.ID)
+		if errors.Is(err, ErrInvitationNotFound) || errors.Is(err, ErrUserNotFound) {
+			shared.RenderHTML(w, InvitationInvalid())
+			return
+		}
+		if err != nil && !errors.Is(err, ErrUserExists) {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, InvitationJoinSuccess())
+	}
+}
+
+// HandleInvitationSignUpForm signs up a user who accepts an invitation
+func (a *InvitationWebHandlers) HandleInvitationSignUpForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitation, err := a.readInvitation(r)
+		if errors.Is(err, ErrInvitationNotFound) {
+			shared.RenderHTML(w, InvitationInvalid())
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			formModel := invitationSignupFormModel{}
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, a.InvitationSignUpForm(invitation, formModel, nil))
+			return
+		}
+
+		var formModel invitationSignupFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, a.InvitationSignUpForm(invitation, formModel, nil))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, a.InvitationSignUpForm(invitation, formModel, invitationSignupFieldErrors(err)))
+			return
+		}
+
+		user := &User{
+			Name:     formModel.Name,
+			Password: userService.EncryptPassword(formModel.Password),
+			Origin:   "baralga",
+		}
+		err = userService.AcceptInvitation(r.Context(), invitation.ID, user)
+		if errors.Is(err, ErrInvitationNotFound) {
+			shared.RenderHTML(w, InvitationInvalid())
+			return
+		}
+		if errors.Is(err, ErrUserExists) {
+			formModel.CSRFToken = csrf.Token(r)
+			fieldErrors := map[string]string{
+				"EMail": "Email not available.",
+			}
+			shared.RenderHTML(w, a.InvitationSignUpForm(invitation, formModel, fieldErrors))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, InvitationSignupSuccess(formModel))
+	}
+}
+
+func (a *InvitationWebHandlers) readInvitation(r *http.Request) (*Invitation, error) {
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitation-id"))
+	if err != nil {
+		return nil, ErrInvitationNotFound
+	}
+
+	return a.userService.ReadInvitation(r.Context(), invitationID)
+}
+
+func invitationSignupFieldErrors(err error) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return fieldErrors
+	}
+
+	for _, fieldError := range validationErrors {
+		switch fieldError.Field() {
+		case "Name":
+			fieldErrors["Name"] = "Name must have 5 to 50 characters."
+		case "Password":
+			fieldErrors["Password"] = "Password must have 8 to 100 characters."
+		}
+	}
+
+	return fieldErrors
+}
+
+func InvitationsPage(pageContext *shared.PageContext, formModel invitationFormModel, invitations []*Invitation) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					InvitationsView(formModel, invitations, pageContext.Principal.Location(), nil),
+				),
+			),
+		},
+	)
+}
+
+func InvitationsView(formModel invitationFormModel, invitations []*Invitation, location *time.Location, fieldErrors map[string]string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Invitations"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			FormEl(
+				Class("mb-4"),
+				ghx.Post("/invitations/new"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(formModel.CSRFToken),
+				),
+				Div(
+					Class("input-group has-validation"),
+					Input(
+						ID("EMail"),
+						Type("email"),
+						Name("EMail"),
+						Required(),
+						MaxLength("100"),
+						g.If(
+							fieldErrors["EMail"] != "",
+							Class("form-control is-invalid"),
+						),
+						g.If(
+							fieldErrors["EMail"] == "",
+							Class("form-control"),
+						),
+						g.Attr("placeholder", "colleague@mail.com"),
+						Value(formModel.EMail),
+					),
+					Button(
+						Class("btn btn-outline-primary"),
+						TitleAttr("Invite"),
+						I(Class("bi-envelope-plus")),
+					),
+					g.If(
+						fieldErrors["EMail"] != "",
+						Div(
+							Class("invalid-feedback"),
+							g.Text(fieldErrors["EMail"]),
+						),
+					),
+				),
+				Div(
+					Class("form-text"),
+					g.Textf("Invited colleagues join your organization. Invitations expire after %v days.", int(InvitationValidity.Hours()/24)),
+				),
+			),
+			g.If(
+				len(invitations) == 0,
+				Div(
+					Class("alert alert-info"),
+					Role("alert"),
+					g.Text("No pending invitations."),
+				),
+			),
+			g.If(
+				len(invitations) > 0,
+				Table(
+					Class("table table-sm table-borderless"),
+					TBody(
+						g.Group(
+							g.Map(invitations, func(invitation *Invitation) g.Node {
+								return InvitationRow(formModel.CSRFToken, invitation, location)
+							}),
+						),
+					),
+				),
+			),
+		),
+	)
+}
+
+func InvitationRow(csrfToken string, invitation *Invitation, location *time.Location) g.Node {
+	return Tr(
+		Td(
+			Class("w-100"),
+			g.Text(invitation.EMail),
+		),
+		Td(
+			Class("text-nowrap"),
+			TitleAttr(fmt.Sprintf("Invited by %v", invitation.CreatedBy)),
+			g.Textf("until %v", invitation.ExpiresAt.In(location).Format("02.01.2006")),
+		),
+		Td(
+			FormEl(
+				ghx.Post(fmt.Sprintf("/invitations/%v/revoke", invitation.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm(fmt.Sprintf("Do you really want to revoke the invitation of %v?", invitation.EMail)),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr("Revoke Invitation"),
+					I(Class("bi-trash2")),
+				),
+			),
+		),
+	)
+}
+
+func (a *InvitationWebHandlers) InvitationSignUpPage(currentPath string, content g.Node) g.Node {
+	return shared.Page(
+		"Join",
+		currentPath,
+		[]g.Node{
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("d-flex justify-content-center align-items-center mt-2 mb-3"),
+						Img(
+							Alt("Baralga"),
+							Class("img-responsive"),
+							Src("/assets/baralga_192.png"),
+						),
+						Div(
+							Class("ms-4"),
+							H2(
+								g.Text("Baralga"),
+								Small(
+									Class("text-muted"),
+									StyleAttr("display: block; font-size: 70%;"),
+									g.Text("project time tracking"),
+								),
+							),
+						),
+					),
+					content,
+				),
+			),
+		},
+	)
+}
+
+func InvitationInvalid() g.Node {
+	return Div(
+		Class("alert alert-warning"),
+		Role("alert"),
+		g.Text("This invitation is invalid or has expired. Please ask for a new invitation or "),
+		A(
+			Href("/signup"),
+			Class("alert-link"),
+			g.Text("sign up here."),
+		),
+	)
+}
+
+func InvitationSignupSuccess(formModel invitationSignupFormModel) g.Node {
+	return Div(
+		Class("alert alert-success"),
+		Role("alert"),
+		g.Textf("Welcome %s, you have joined your team! ", formModel.Name),
+		A(
+			Href("/login"),
+			Class("alert-link"),
+			g.Text("Sign in here."),
+		),
+	)
+}
+
+func InvitationJoinSuccess() g.Node {
+	return Div(
+		Class("alert alert-success"),
+		Role("alert"),
+		g.Text("You have joined your team! Switch to the new organization after you "),
+		A(
+			Href("/login"),
+			Class("alert-link"),
+			g.Text("sign in here."),
+		),
+	)
+}
+
+// InvitationJoinForm lets a user who already has an account join the organization of the invitation
+func InvitationJoinForm(invitation *Invitation, csrfToken string) g.Node {
+	return FormEl(
+		ID("signup_form"),
+		ghx.Post(fmt.Sprintf("/signup/invitation/%v/join", invitation.ID)),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(csrfToken),
+		),
+		P(
+			g.Textf("You already have an account as %v. Join the team with your existing account.", invitation.EMail),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Join your team"),
+			),
+		),
+	)
+}
+
+func (a *InvitationWebHandlers) InvitationSignUpForm(invitation *Invitation, formModel invitationSignupFormModel, fieldErrors map[string]string) g.Node {
+	return FormEl(
+		ID("signup_form"),
+		ghx.Post(fmt.Sprintf("/signup/invitation/%v", invitation.ID)),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("email"),
+				Type("email"),
+				ReadOnly(),
+				g.If(
+					fieldErrors["EMail"] != "",
+					Class("form-control-plaintext is-invalid"),
+				),
+				g.If(
+					fieldErrors["EMail"] == "",
+					Class("form-control-plaintext"),
+				),
+				Value(invitation.EMail),
+			),
+			Label(
+				g.Attr("for", "email"),
+				g.Text("E-Mail"),
+			),
+			g.If(
+				fieldErrors["EMail"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["EMail"]),
+				),
+			),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("name"),
+				Required(),
+				MinLength("5"),
+				MaxLength("50"),
+				Type("text"),
+				Name("Name"),
+				g.If(
+					fieldErrors["Name"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["Name"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "John Doe"),
+				Value(formModel.Name),
+			),
+			Label(
+				g.Attr("for", "name"),
+				g.Text("Name"),
+			),
+			g.If(
+				fieldErrors["Name"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["Name"]),
+				),
+			),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("password"),
+				Required(),
+				Type("password"),
+				Name("Password"),
+				MinLength("8"),
+				MaxLength("100"),
+				g.If(
+					fieldErrors["Password"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["Password"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "***"),
+			),
+			Label(
+				g.Attr("for", "password"),
+				g.Text("Password"),
+			),
+			g.If(
+				fieldErrors["Password"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["Password"]),
+				),
+			),
+		),
+		Div(
+			Class("form-check mb-3"),
+			Input(
+				ID("acceptConditions"),
+				Required(),
+				Type("checkbox"),
+				Name("AcceptConditions"),
+				Class("form-check-input"),
+				g.If(formModel.AcceptConditions, Value("true")),
+			),
+			Label(
+				g.Attr("for", "acceptConditions"),
+				g.Raw(
+					fmt.Sprintf("Ich bin mit den <a href=\"%v\">Datenschutzbestimmungen</a> einverstanden. I accept the <a href=\"%v\">data protection rules</a>.",
+						a.config.DataProtectionURL,
+						a.config.DataProtectionURL,
+					),
+				),
+			),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Join your team"),
+			),
+		),
+	)
+}
@@ -0,0 +1,350 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleInvitationsPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			invitationRepository: NewInMemInvitationRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/invitations", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}))
+
+	a.HandleInvitationsPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "invited@baralga.com"))
+	is.True(strings.Contains(htmlBody, fmt.Sprintf("/invitations/%v/revoke", shared.InvitationIDSample)))
+}
+
+func TestHandleInvitationsPageAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			invitationRepository: NewInMemInvitationRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/invitations", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_USER"},
+	}))
+
+	a.HandleInvitationsPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleInvitationForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	mailResource := shared.NewInMemMailResource()
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			config:               &shared.Config{},
+			repositoryTxer:       shared.NewInMemRepositoryTxer(),
+			mailResource:         mailResource,
+			userRepository:       NewInMemUserRepository(),
+			invitationRepository: invitationRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"colleague@baralga.com"}
+
+	r, _ := http.NewRequest("POST", "/invitations/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}))
+
+	a.HandleInvitationForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(invitationRepository.invitations), 2)
+	is.Equal(len(mailResource.Mails), 1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "colleague@baralga.com"))
+}
+
+func TestHandleInvitationFormWithExistingUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			config:               &shared.Config{},
+			repositoryTxer:       shared.NewInMemRepositoryTxer(),
+			mailResource:         shared.NewInMemMailResource(),
+			userRepository:       NewInMemUserRepository(),
+			invitationRepository: invitationRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"admin@baralga.com"}
+
+	r, _ := http.NewRequest("POST", "/invitations/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}))
+
+	a.HandleInvitationForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(invitationRepository.invitations), 1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "A user with this email is already a member."))
+}
+
+func TestHandleRevokeInvitation(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:       shared.NewInMemRepositoryTxer(),
+			invitationRepository: invitationRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/invitations/%v/revoke", shared.InvitationIDSample), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", shared.InvitationIDSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleRevokeInvitation()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(invitationRepository.invitations), 0)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "No pending invitations."))
+}
+
+func TestHandleInvitationSignUpPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:       NewInMemUserRepository(),
+			invitationRepository: NewInMemInvitationRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/signup/invitation/%v", shared.InvitationIDSample), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", shared.InvitationIDSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleInvitationSignUpPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Join # Baralga"))
+	is.True(strings.Contains(htmlBody, "invited@baralga.com"))
+}
+
+func TestHandleInvitationSignUpPageWithUnknownInvitation(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			invitationRepository: NewInMemInvitationRepository(),
+		},
+	}
+
+	invitationID := uuid.New()
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/signup/invitation/%v", invitationID), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", invitationID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleInvitationSignUpPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "This invitation is invalid or has expired."))
+}
+
+func TestHandleInvitationSignUpForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:       shared.NewInMemRepositoryTxer(),
+			userRepository:       userRepository,
+			invitationRepository: NewInMemInvitationRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Ivy Invited"}
+	data["Password"] = []string{"myPassword?!§!"}
+	data["AcceptConditions"] = []string{"true"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/signup/invitation/%v", shared.InvitationIDSample), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", shared.InvitationIDSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleInvitationSignUpForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.users), userCount+1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Welcome Ivy Invited"))
+}
+
+func TestHandleInvitationSignUpFormWithInvalidData(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:       shared.NewInMemRepositoryTxer(),
+			userRepository:       userRepository,
+			invitationRepository: NewInMemInvitationRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Ivy"}
+	data["Password"] = []string{"short"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/signup/invitation/%v", shared.InvitationIDSample), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", shared.InvitationIDSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleInvitationSignUpForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.users), userCount)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Password must have 8 to 100 characters."))
+}
+
+func TestHandleInvitationSignUpPageForExistingUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	invitationRepository := NewInMemInvitationRepository()
+	invitationRepository.invitations[0].EMail = "admin@baralga.com"
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:       NewInMemUserRepository(),
+			invitationRepository: invitationRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/signup/invitation/%v", shared.InvitationIDSample), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", shared.InvitationIDSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleInvitationSignUpPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "You already have an account as admin@baralga.com."))
+	is.True(strings.Contains(htmlBody, fmt.Sprintf("/signup/invitation/%v/join", shared.InvitationIDSample)))
+}
+
+func TestHandleInvitationJoinForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+	invitationRepository := NewInMemInvitationRepository()
+	invitationRepository.invitations[0].OrganizationID = uuid.New()
+	invitationRepository.invitations[0].EMail = "admin@baralga.com"
+
+	a := &InvitationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:       shared.NewInMemRepositoryTxer(),
+			userRepository:       userRepository,
+			invitationRepository: invitationRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/signup/invitation/%v/join", shared.InvitationIDSample), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("invitation-id", shared.InvitationIDSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleInvitationJoinForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.users), userCount+1)
+	is.Equal(len(invitationRepository.invitations), 0)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "You have joined your team!"))
+}
@@ -0,0 +1,141 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DbLoginThrottleRepository is a SQL database repository for failed sign ins
+type DbLoginThrottleRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ LoginThrottleRepository = (*DbLoginThrottleRepository)(nil)
+
+// NewDbLoginThrottleRepository creates a new SQL database repository for failed sign ins
+func NewDbLoginThrottleRepository(connPool *pgxpool.Pool) *DbLoginThrottleRepository {
+	return &DbLoginThrottleRepository{
+		connPool: connPool,
+	}
+}
+
+func (r *DbLoginThrottleRepository) FindLoginThrottles(ctx context.Context, keys []string) ([]*LoginThrottle, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT throttle_key, failures, last_failure_at, locked_until
+		 FROM login_throttles
+		 WHERE throttle_key = ANY($1)`,
+		keys,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loginThrottles []*LoginThrottle
+	for rows.Next() {
+		loginThrottle, err := scanLoginThrottle(rows)
+		if err != nil {
+			return nil, err
+		}
+		loginThrottles = append(loginThrottles, loginThrottle)
+	}
+
+	return loginThrottles, rows.Err()
+}
+
+// IncrementLoginFailures counts the failed sign in, failures before countedSince and before an expired lock
+// are no longer counted
+func (r *DbLoginThrottleRepository) IncrementLoginFailures(ctx context.Context, key string, failedAt, countedSince time.Time) (*LoginThrottle, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(
+		ctx,
+		`INSERT INTO login_throttles
+		   (throttle_key, failures, last_failure_at)
+		 VALUES
+		   ($1, 1, $2)
+		 ON CONFLICT (throttle_key) DO UPDATE
+		 SET failures = CASE
+		       WHEN login_throttles.last_failure_at < $3 OR login_throttles.locked_until <= $2 THEN 1
+		       ELSE login_throttles.failures + 1
+		     END,
+		     locked_until = CASE
+		       WHEN login_throttles.locked_until <= $2 THEN NULL
+		       ELSE login_throttles.locked_until
+		     END,
+		     last_failure_at = $2
+		 RETURNING throttle_key, failures, last_failure_at, locked_until`,
+		key,
+		failedAt,
+		countedSince,
+	)
+
+	return scanLoginThrottle(row)
+}
+
+func (r *DbLoginThrottleRepository) LockLoginThrottle(ctx context.Context, key string, lockedUntil time.Time) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE login_throttles
+		 SET locked_until = $2
+		 WHERE throttle_key = $1`,
+		key,
+		lockedUntil,
+	)
+
+	return err
+}
+
+func (r *DbLoginThrottleRepository) DeleteLoginThrottle(ctx context.Context, key string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE
+		 FROM login_throttles
+		 WHERE throttle_key = $1`,
+		key,
+	)
+
+	return err
+}
+
+func (r *DbLoginThrottleRepository) DeleteStaleLoginThrottles(ctx context.Context, lastFailureBefore time.Time) (int, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`DELETE
+		 FROM login_throttles
+		 WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until <= $1)`,
+		lastFailureBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+func scanLoginThrottle(row pgx.Row) (*LoginThrottle, error) {
+	var (
+		loginThrottle LoginThrottle
+		lockedUntil   sql.NullTime
+	)
+
+	err := row.Scan(&loginThrottle.Key, &loginThrottle.Failures, &loginThrottle.LastFailureAt, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	loginThrottle.LockedUntil = lockedUntil.Time
+	return &loginThrottle, nil
+}
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+type InMemLoginThrottleRepository struct {
+	loginThrottles []*LoginThrottle
+}
+
+var _ LoginThrottleRepository = (*InMemLoginThrottleRepository)(nil)
+
+func NewInMemLoginThrottleRepository() *InMemLoginThrottleRepository {
+	return &InMemLoginThrottleRepository{}
+}
+
+func (r *InMemLoginThrottleRepository) FindLoginThrottles(ctx context.Context, keys []string) ([]*LoginThrottle, error) {
+	var loginThrottles []*LoginThrottle
+	for _, t := range r.loginThrottles {
+		if slices.Contains(keys, t.Key) {
+			loginThrottle := *t
+			loginThrottles = append(loginThrottles, &loginThrottle)
+		}
+	}
+	return loginThrottles, nil
+}
+
+func (r *InMemLoginThrottleRepository) IncrementLoginFailures(ctx context.Context, key string, failedAt, countedSince time.Time) (*LoginThrottle, error) {
+	for _, t := range r.loginThrottles {
+		if t.Key != key {
+			continue
+		}
+
+		if t.LastFailureAt.Before(countedSince) || (!t.LockedUntil.IsZero() && !t.IsLocked(failedAt)) {
+			t.Failures = 0
+			t.LockedUntil = time.Time{}
+		}
+		t.Failures++
+		t.LastFailureAt = failedAt
+
+		loginThrottle := *t
+		return &loginThrottle, nil
+	}
+
+	loginThrottle := &LoginThrottle{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: failedAt,
+	}
+	r.loginThrottles = append(r.loginThrottles, loginThrottle)
+
+	result := *loginThrottle
+	return &result, nil
+}
+
+func (r *InMemLoginThrottleRepository) LockLoginThrottle(ctx context.Context, key string, lockedUntil time.Time) error {
+	for _, t := range r.loginThrottles {
+		if t.Key == key {
+			t.LockedUntil = lockedUntil
+		}
+	}
+	return nil
+}
+
+func (r *InMemLoginThrottleRepository) DeleteLoginThrottle(ctx context.Context, key string) error {
+	r.loginThrottles = slices.DeleteFunc(r.loginThrottles, func(t *LoginThrottle) bool {
+		return t.Key == key
+	})
+	return nil
+}
+
+func (r *InMemLoginThrottleRepository) DeleteStaleLoginThrottles(ctx context.Context, lastFailureBefore time.Time) (int, error) {
+	count := len(r.loginThrottles)
+	r.loginThrottles = slices.DeleteFunc(r.loginThrottles, func(t *LoginThrottle) bool {
+		return t.LastFailureAt.Before(lastFailureBefore) && !t.IsLocked(lastFailureBefore)
+	})
+	return count - len(r.loginThrottles), nil
+}
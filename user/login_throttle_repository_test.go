@@ -0,0 +1,108 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestLoginThrottleRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	loginThrottleRepository := NewDbLoginThrottleRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	key := AccountLoginThrottleKey("admin@baralga.com")
+	now := time.Now()
+
+	t.Run("IncrementLoginFailures", func(t *testing.T) {
+		var loginThrottle *LoginThrottle
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := loginThrottleRepository.IncrementLoginFailures(ctx, key, now.Add(-time.Minute), now.Add(-LoginFailureRetention))
+				if err != nil {
+					return err
+				}
+
+				loginThrottle, err = loginThrottleRepository.IncrementLoginFailures(ctx, key, now, now.Add(-LoginFailureRetention))
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(loginThrottle.Failures, 2)
+		is.True(loginThrottle.LockedUntil.IsZero())
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				loginThrottle, err = loginThrottleRepository.IncrementLoginFailures(ctx, key, now, now.Add(time.Second))
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(loginThrottle.Failures, 1)
+	})
+	t.Run("LockLoginThrottle", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return loginThrottleRepository.LockLoginThrottle(ctx, key, now.Add(LoginLockoutDuration))
+			},
+		)
+		is.NoErr(err)
+
+		loginThrottles, err := loginThrottleRepository.FindLoginThrottles(context.Background(), []string{key, IPLoginThrottleKey("10.0.0.1")})
+		is.NoErr(err)
+		is.Equal(len(loginThrottles), 1)
+		is.True(loginThrottles[0].IsLocked(now))
+	})
+	t.Run("DeleteStaleLoginThrottles", func(t *testing.T) {
+		var deleted int
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				var err error
+				deleted, err = loginThrottleRepository.DeleteStaleLoginThrottles(ctx, now.Add(time.Minute))
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(deleted, 0)
+	})
+	t.Run("DeleteLoginThrottle", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return loginThrottleRepository.DeleteLoginThrottle(ctx, key)
+			},
+		)
+		is.NoErr(err)
+
+		loginThrottles, err := loginThrottleRepository.FindLoginThrottles(context.Background(), []string{key})
+		is.NoErr(err)
+		is.Equal(len(loginThrottles), 0)
+	})
+}
@@ -2,9 +2,15 @@ package user
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
 )
 
 // DbOrganizationRepository is a SQL database repository for users
@@ -27,11 +33,156 @@ func (r *DbOrganizationRepository) InsertOrganization(ctx context.Context, organ
 	_, err := tx.Exec(
 		ctx,
 		`INSERT INTO organizations 
-		   (org_id, title) 
+		   (org_id, title, time_zone) 
 		 VALUES 
-		   ($1, $2)`,
+		   ($1, $2, $3)`,
 		organization.ID,
 		organization.Title,
+		organization.TimeZone,
 	)
-	return organization, err
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO organization_settings 
+		   (org_id, week_start, working_days, date_format, admin_two_factor_required) 
+		 VALUES 
+		   ($1, $2, $3, $4, $5)`,
+		organization.ID,
+		int(organization.WeekStart),
+		formatWeekdays(organization.WorkingDays),
+		organization.DateFormat,
+		organization.AdminTwoFactorRequired,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (r *DbOrganizationRepository) FindOrganizationByID(ctx context.Context, organizationID uuid.UUID) (*Organization, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT COALESCE(o.title, ''), o.time_zone, o.user_deletion_policy, 
+		        COALESCE(s.week_start, 1), COALESCE(s.working_days, '1,2,3,4,5'), COALESCE(s.date_format, '02.01.2006'), 
+		        COALESCE(s.admin_two_factor_required, FALSE) 
+		 FROM organizations o 
+		 LEFT JOIN organization_settings s ON s.org_id = o.org_id 
+		 WHERE o.org_id = $1`, organizationID,
+	)
+
+	var (
+		title              string
+		timeZone           string
+		userDeletionPolicy string
+		weekStart          int
+		workingDays        string
+		dateFormat         string
+		adminTwoFactor     bool
+	)
+
+	err := row.Scan(&title, &timeZone, &userDeletionPolicy, &weekStart, &workingDays, &dateFormat, &adminTwoFactor)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrganizationNotFound
+		}
+
+		return nil, err
+	}
+
+	organization := &Organization{
+		ID:                 organizationID,
+		Title:              title,
+		TimeZone:           timeZone,
+		UserDeletionPolicy: userDeletionPolicy,
+		WeekStart:          time.Weekday(weekStart),
+		WorkingDays:        parseWeekdays(workingDays),
+		DateFormat:         dateFormat,
+
+		AdminTwoFactorRequired: adminTwoFactor,
+	}
+	return organization, nil
+}
+
+// UpdateOrganization updates title and settings of the organization
+func (r *DbOrganizationRepository) UpdateOrganization(ctx context.Context, organization *Organization) (*Organization, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`UPDATE organizations 
+		 SET title = $2, time_zone = $3, user_deletion_policy = $4 
+		 WHERE org_id = $1`,
+		organization.ID,
+		organization.Title,
+		organization.TimeZone,
+		organization.UserDeletionPolicy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO organization_settings 
+		   (org_id, week_start, working_days, date_format, admin_two_factor_required) 
+		 VALUES 
+		   ($1, $2, $3, $4, $5) 
+		 ON CONFLICT (org_id) DO UPDATE 
+		 SET week_start = $2, working_days = $3, date_format = $4, admin_two_factor_required = $5`,
+		organization.ID,
+		int(organization.WeekStart),
+		formatWeekdays(organization.WorkingDays),
+		organization.DateFormat,
+		organization.AdminTwoFactorRequired,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+// DeleteOrganizationByID deletes the organization with all its projects, activities, tags, holidays and absences
+func (r *DbOrganizationRepository) DeleteOrganizationByID(ctx context.Context, organizationID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM organizations 
+		 WHERE org_id = $1`,
+		organizationID,
+	)
+	return err
+}
+
+// formatWeekdays formats weekdays as comma separated numbers like 1,2,3,4,5
+func formatWeekdays(weekdays []time.Weekday) string {
+	numbers := make([]string, 0, len(weekdays))
+	for _, weekday := range weekdays {
+		numbers = append(numbers, strconv.Itoa(int(weekday)))
+	}
+
+	return strings.Join(numbers, ",")
+}
+
+// parseWeekdays parses weekdays from comma separated numbers, invalid numbers are skipped
+func parseWeekdays(numbers string) []time.Weekday {
+	weekdays := make([]time.Weekday, 0, 7)
+	for _, number := range strings.Split(numbers, ",") {
+		weekday, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || !isValidWeekday(time.Weekday(weekday)) {
+			continue
+		}
+		weekdays = append(weekdays, time.Weekday(weekday))
+	}
+
+	return weekdays
 }
@@ -2,8 +2,10 @@ package user
 
 import (
 	"context"
+	"slices"
 
 	"github.com/baralga/shared"
+	"github.com/google/uuid"
 )
 
 type InMemOrganizationRepository struct {
@@ -16,8 +18,13 @@ func NewInMemOrganizationRepository() *InMemOrganizationRepository {
 	return &InMemOrganizationRepository{
 		organizations: []*Organization{
 			{
-				ID:    shared.OrganizationIDSample,
-				Title: "Test Organization",
+				ID:                 shared.OrganizationIDSample,
+				Title:              "Test Organization",
+				TimeZone:           DefaultTimeZone,
+				UserDeletionPolicy: UserDeletionPolicyAnonymize,
+				WeekStart:          DefaultWeekStart,
+				WorkingDays:        slices.Clone(DefaultWorkingDays),
+				DateFormat:         DefaultDateFormat,
 			},
 		},
 	}
@@ -27,3 +34,32 @@ func (r *InMemOrganizationRepository) InsertOrganization(ctx context.Context, or
 	r.organizations = append(r.organizations, organization)
 	return organization, nil
 }
+
+func (r *InMemOrganizationRepository) FindOrganizationByID(ctx context.Context, organizationID uuid.UUID) (*Organization, error) {
+	for _, o := range r.organizations {
+		if o.ID == organizationID {
+			return o, nil
+		}
+	}
+	return nil, ErrOrganizationNotFound
+}
+
+func (r *InMemOrganizationRepository) UpdateOrganization(ctx context.Context, organization *Organization) (*Organization, error) {
+	for i, o := range r.organizations {
+		if o.ID == organization.ID {
+			r.organizations[i] = organization
+			return organization, nil
+		}
+	}
+	return nil, ErrOrganizationNotFound
+}
+
+func (r *InMemOrganizationRepository) DeleteOrganizationByID(ctx context.Context, organizationID uuid.UUID) error {
+	for i, o := range r.organizations {
+		if o.ID == organizationID {
+			r.organizations = append(r.organizations[:i], r.organizations[i+1:]...)
+			return nil
+		}
+	}
+	return ErrOrganizationNotFound
+}
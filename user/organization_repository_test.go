@@ -37,8 +37,12 @@ func TestOrganizationRepository(t *testing.T) {
 
 	t.Run("InsertOrganization", func(t *testing.T) {
 		organization := &Organization{
-			ID:    uuid.New(),
-			Title: "My Test Organization" + time.Now().String(),
+			ID:          uuid.New(),
+			Title:       "My Test Organization" + time.Now().String(),
+			TimeZone:    DefaultTimeZone,
+			WeekStart:   DefaultWeekStart,
+			WorkingDays: DefaultWorkingDays,
+			DateFormat:  DefaultDateFormat,
 		}
 
 		err := repositoryTxer.InTx(
@@ -53,4 +57,35 @@ func TestOrganizationRepository(t *testing.T) {
 		)
 		is.NoErr(err)
 	})
+	t.Run("UpdateOrganization", func(t *testing.T) {
+		organization, err := organizationRepository.FindOrganizationByID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.Equal(organization.WeekStart, time.Monday)
+		is.Equal(organization.WorkingDays, DefaultWorkingDays)
+		is.Equal(organization.DateFormat, shared.DateFormatGerman)
+
+		organization.Title = "My Updated Organization"
+		organization.WeekStart = time.Sunday
+		organization.WorkingDays = []time.Weekday{time.Sunday, time.Monday}
+		organization.DateFormat = shared.DateFormatUS
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := organizationRepository.UpdateOrganization(
+					ctx,
+					organization,
+				)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		updatedOrganization, err := organizationRepository.FindOrganizationByID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.Equal(updatedOrganization.Title, "My Updated Organization")
+		is.Equal(updatedOrganization.WeekStart, time.Sunday)
+		is.Equal(updatedOrganization.WorkingDays, []time.Weekday{time.Sunday, time.Monday})
+		is.Equal(updatedOrganization.DateFormat, shared.DateFormatUS)
+	})
 }
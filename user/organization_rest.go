@@ -0,0 +1,193 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type organizationSettingsModel struct {
+	ID                 string     `json:"id"`
+	Title              string     `json:"title"`
+	TimeZone           string     `json:"timeZone"`
+	WeekStart          string     `json:"weekStart"`
+	WorkingDays        []string   `json:"workingDays"`
+	DateFormat         string     `json:"dateFormat"`
+	UserDeletionPolicy string     `json:"userDeletionPolicy"`
+	AdminTwoFactor     bool       `json:"adminTwoFactorRequired"`
+	Links              *hal.Links `json:"_links"`
+}
+
+// organizationUpdateModel changes title or settings of the organization, unset fields are left unchanged
+type organizationUpdateModel struct {
+	Title              *string   `json:"title" validate:"omitempty,min=3,max=100"`
+	TimeZone           *string   `json:"timeZone" validate:"omitempty,max=100"`
+	WeekStart          *string   `json:"weekStart"`
+	WorkingDays        *[]string `json:"workingDays"`
+	DateFormat         *string   `json:"dateFormat"`
+	UserDeletionPolicy *string   `json:"userDeletionPolicy" validate:"omitempty,oneof=anonymize delete"`
+	AdminTwoFactor     *bool     `json:"adminTwoFactorRequired"`
+}
+
+type OrganizationRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewOrganizationRestHandlers(config *shared.Config, userService *UserService) *OrganizationRestHandlers {
+	return &OrganizationRestHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *OrganizationRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/organization", a.HandleGetOrganization())
+	r.Patch("/organization", a.HandleUpdateOrganization())
+}
+
+func (a *OrganizationRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetOrganization reads title and settings of the organization of the principal
+func (a *OrganizationRestHandlers) HandleGetOrganization() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		organization, err := userService.ReadOrganization(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToOrganizationSettingsModel(organization, principal.HasPermission(shared.PermissionOrganizationWrite)))
+	}
+}
+
+// HandleUpdateOrganization changes title or settings of the organization
+func (a *OrganizationRestHandlers) HandleUpdateOrganization() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var updateModel organizationUpdateModel
+		err := json.NewDecoder(r.Body).Decode(&updateModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(updateModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("organization not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		organization, err := userService.ReadOrganization(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		changedOrganization, ok := applyOrganizationUpdate(organization, &updateModel)
+		if !ok {
+			http.Error(w, problem.New(problem.Title("organization not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		organization, err = userService.UpdateOrganization(r.Context(), principal, changedOrganization)
+		if errors.Is(err, ErrInvalidOrganization) {
+			http.Error(w, problem.New(problem.Title("organization not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToOrganizationSettingsModel(organization, true))
+	}
+}
+
+// applyOrganizationUpdate applies the set fields to a copy of the organization, it fails for unknown weekdays
+func applyOrganizationUpdate(organization *Organization, updateModel *organizationUpdateModel) (*Organization, bool) {
+	changedOrganization := *organization
+
+	if updateModel.Title != nil {
+		changedOrganization.Title = *updateModel.Title
+	}
+	if updateModel.TimeZone != nil {
+		changedOrganization.TimeZone = *updateModel.TimeZone
+	}
+	if updateModel.WeekStart != nil {
+		weekStart, ok := ParseWeekday(*updateModel.WeekStart)
+		if !ok {
+			return nil, false
+		}
+		changedOrganization.WeekStart = weekStart
+	}
+	if updateModel.WorkingDays != nil {
+		workingDays := make([]time.Weekday, 0, len(*updateModel.WorkingDays))
+		for _, name := range *updateModel.WorkingDays {
+			workingDay, ok := ParseWeekday(name)
+			if !ok {
+				return nil, false
+			}
+			workingDays = append(workingDays, workingDay)
+		}
+		changedOrganization.WorkingDays = workingDays
+	}
+	if updateModel.DateFormat != nil {
+		changedOrganization.DateFormat = *updateModel.DateFormat
+	}
+	if updateModel.UserDeletionPolicy != nil {
+		changedOrganization.UserDeletionPolicy = *updateModel.UserDeletionPolicy
+	}
+	if updateModel.AdminTwoFactor != nil {
+		changedOrganization.AdminTwoFactorRequired = *updateModel.AdminTwoFactor
+	}
+
+	return &changedOrganization, true
+}
+
+func mapToOrganizationSettingsModel(organization *Organization, editable bool) *organizationSettingsModel {
+	workingDays := make([]string, 0, len(organization.WorkingDays))
+	for _, workingDay := range organization.WorkingDays {
+		workingDays = append(workingDays, workingDay.String())
+	}
+
+	organizationSettingsModel := &organizationSettingsModel{
+		ID:                 organization.ID.String(),
+		Title:              organization.Title,
+		TimeZone:           organization.TimeZone,
+		WeekStart:          organization.WeekStart.String(),
+		WorkingDays:        workingDays,
+		DateFormat:         organization.DateFormat,
+		UserDeletionPolicy: organization.UserDeletionPolicy,
+		AdminTwoFactor:     organization.AdminTwoFactorRequired,
+	}
+
+	links := []*hal.Links{hal.NewSelfLink("/api/organization")}
+	if editable {
+		links = append(links, hal.NewLink("edit", "/api/organization"))
+	}
+	organizationSettingsModel.Links = hal.NewLinks(links...)
+
+	return organizationSettingsModel
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetOrganization(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &OrganizationRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			organizationRepository: NewInMemOrganizationRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/organization", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleGetOrganization()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	organizationModel := &organizationSettingsModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(organizationModel)
+	is.NoErr(err)
+	is.Equal(organizationModel.Title, "Test Organization")
+	is.Equal(organizationModel.WeekStart, "Monday")
+	is.Equal(organizationModel.WorkingDays, []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"})
+	is.Equal(organizationModel.DateFormat, shared.DateFormatGerman)
+	is.Equal(organizationModel.Links.HrefOf("edit"), "")
+}
+
+func TestHandleUpdateOrganization(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	organizationRepository := NewInMemOrganizationRepository()
+
+	a := &OrganizationRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			organizationRepository: organizationRepository,
+		},
+	}
+
+	body := `{"weekStart": "Sunday", "workingDays": ["Sunday", "Monday"], "dateFormat": "2006-01-02", "adminTwoFactorRequired": true}`
+	r, _ := http.NewRequest("PATCH", "/api/organization", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleUpdateOrganization()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	organization := organizationRepository.organizations[0]
+	is.Equal(organization.Title, "Test Organization")
+	is.Equal(organization.WeekStart, time.Sunday)
+	is.Equal(organization.WorkingDays, []time.Weekday{time.Sunday, time.Monday})
+	is.Equal(organization.DateFormat, shared.DateFormatISO)
+	is.True(organization.AdminTwoFactorRequired)
+}
+
+func TestHandleUpdateOrganizationWithUnknownWeekday(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	organizationRepository := NewInMemOrganizationRepository()
+
+	a := &OrganizationRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			organizationRepository: organizationRepository,
+		},
+	}
+
+	body := `{"workingDays": ["Moonday"]}`
+	r, _ := http.NewRequest("PATCH", "/api/organization", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleUpdateOrganization()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+	is.Equal(len(organizationRepository.organizations[0].WorkingDays), 5)
+}
+
+func TestHandleUpdateOrganizationAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &OrganizationRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			organizationRepository: NewInMemOrganizationRepository(),
+		},
+	}
+
+	body := `{"title": "Taken Over"}`
+	r, _ := http.NewRequest("PATCH", "/api/organization", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleUpdateOrganization()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
@@ -0,0 +1,473 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type organizationFormModel struct {
+	CSRFToken          string
+	Title              string `validate:"required,min=3,max=100"`
+	TimeZone           string `validate:"required,max=100"`
+	WeekStart          int    `validate:"min=0,max=6"`
+	WorkingDays        []int  `validate:"required,min=1"`
+	DateFormat         string `validate:"required"`
+	UserDeletionPolicy string `validate:"oneof=anonymize delete"`
+	AdminTwoFactor     bool
+}
+
+// weekdays are the weekdays in the order offered for selection
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+type OrganizationWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewOrganizationWebHandlers(config *shared.Config, userService *UserService) *OrganizationWebHandlers {
+	return &OrganizationWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *OrganizationWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/organization", a.HandleOrganizationPage())
+	r.Post("/organization", a.HandleOrganizationForm())
+}
+
+func (a *OrganizationWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleOrganizationPage shows title and settings of the organization to members who manage the organization
+func (a *OrganizationWebHandlers) HandleOrganizationPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		organization, err := userService.ReadOrganization(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := mapOrganizationToForm(organization)
+		formModel.CSRFToken = csrf.Token(r)
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Organization",
+			}
+			shared.RenderHTML(w, OrganizationPage(pageContext, formModel))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, OrganizationForm(formModel, nil))
+	}
+}
+
+// HandleOrganizationForm saves title and settings of the organization
+func (a *OrganizationWebHandlers) HandleOrganizationForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var formModel organizationFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		formModel.CSRFToken = csrf.Token(r)
+
+		err = validator.Struct(formModel)
+		fieldErrors := organizationFieldErrors(err)
+		if !IsValidTimeZone(formModel.TimeZone) {
+			fieldErrors["TimeZone"] = "Unknown time zone."
+		}
+		for _, workingDay := range formModel.WorkingDays {
+			if !isValidWeekday(time.Weekday(workingDay)) {
+				fieldErrors["WorkingDays"] = "Unknown weekday."
+			}
+		}
+		if !shared.IsValidDateFormat(formModel.DateFormat) {
+			fieldErrors["DateFormat"] = "Unknown date format."
+		}
+		if len(fieldErrors) > 0 {
+			shared.RenderHTML(w, OrganizationForm(formModel, fieldErrors))
+			return
+		}
+
+		_, err = userService.UpdateOrganization(r.Context(), principal, mapFormToOrganization(formModel))
+		if errors.Is(err, ErrInvalidOrganization) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		// refresh session so that a new default time zone becomes part of the principal
+		refreshURI := fmt.Sprintf("/session/refresh?redirect=%v", url.QueryEscape(currentRequestURI(r)))
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, refreshURI, http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Redirect", refreshURI)
+	}
+}
+
+func organizationFieldErrors(err error) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return fieldErrors
+	}
+
+	for _, fieldError := range validationErrors {
+		switch fieldError.Field() {
+		case "Title":
+			fieldErrors["Title"] = "Title must have 3 to 100 characters."
+		case "WeekStart":
+			fieldErrors["WeekStart"] = "Unknown weekday."
+		case "WorkingDays":
+			fieldErrors["WorkingDays"] = "Choose at least one working day."
+		case "UserDeletionPolicy":
+			fieldErrors["UserDeletionPolicy"] = "Unknown policy."
+		}
+	}
+
+	return fieldErrors
+}
+
+func mapOrganizationToForm(organization *Organization) organizationFormModel {
+	workingDays := make([]int, 0, len(organization.WorkingDays))
+	for _, workingDay := range organization.WorkingDays {
+		workingDays = append(workingDays, int(workingDay))
+	}
+
+	return organizationFormModel{
+		Title:              organization.Title,
+		TimeZone:           organization.TimeZone,
+		WeekStart:          int(organization.WeekStart),
+		WorkingDays:        workingDays,
+		DateFormat:         organization.DateFormat,
+		UserDeletionPolicy: organization.UserDeletionPolicy,
+		AdminTwoFactor:     organization.AdminTwoFactorRequired,
+	}
+}
+
+func mapFormToOrganization(formModel organizationFormModel) *Organization {
+	workingDays := make([]time.Weekday, 0, len(formModel.WorkingDays))
+	for _, workingDay := range formModel.WorkingDays {
+		workingDays = append(workingDays, time.Weekday(workingDay))
+	}
+
+	return &Organization{
+		Title:              formModel.Title,
+		TimeZone:           formModel.TimeZone,
+		WeekStart:          time.Weekday(formModel.WeekStart),
+		WorkingDays:        workingDays,
+		DateFormat:         formModel.DateFormat,
+		UserDeletionPolicy: formModel.UserDeletionPolicy,
+
+		AdminTwoFactorRequired: formModel.AdminTwoFactor,
+	}
+}
+
+func OrganizationPage(pageContext *shared.PageContext, formModel organizationFormModel) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					OrganizationForm(formModel, nil),
+				),
+			),
+		},
+	)
+}
+
+func OrganizationForm(formModel organizationFormModel, fieldErrors map[string]string) g.Node {
+	timeZoneOptions := timeZones
+	if formModel.TimeZone != "" && !slices.Contains(timeZoneOptions, formModel.TimeZone) {
+		timeZoneOptions = append([]string{formModel.TimeZone}, timeZoneOptions...)
+	}
+
+	// sample date to show the date formats
+	sampleDate := time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	return FormEl(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		ghx.Post("/organization"),
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Organization"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Input(
+				Type("hidden"),
+				Name("CSRFToken"),
+				Value(formModel.CSRFToken),
+			),
+			Div(
+				Class("form-floating mb-3"),
+				Input(
+					ID("organization_Title"),
+					Required(),
+					Type("text"),
+					Name("Title"),
+					MaxLength("100"),
+					organizationControlClass("form-control", "Title", fieldErrors),
+					g.Attr("placeholder", "Title"),
+					Value(formModel.Title),
+				),
+				Label(
+					g.Attr("for", "organization_Title"),
+					g.Text("Title"),
+				),
+				organizationFieldError("Title", fieldErrors),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "organization_TimeZone"),
+					g.Text("Default Time Zone"),
+				),
+				Select(
+					ID("organization_TimeZone"),
+					Name("TimeZone"),
+					organizationControlClass("form-select", "TimeZone", fieldErrors),
+					g.Group(
+						g.Map(timeZoneOptions, func(timeZone string) g.Node {
+							return Option(
+								Value(timeZone),
+								g.Text(timeZone),
+								g.If(formModel.TimeZone == timeZone, Selected()),
+							)
+						}),
+					),
+				),
+				organizationFieldError("TimeZone", fieldErrors),
+				Div(
+					Class("form-text"),
+					g.Text("Members without an own time zone enter and see their activities in this time zone."),
+				),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "organization_WeekStart"),
+					g.Text("Week Starts On"),
+				),
+				Select(
+					ID("organization_WeekStart"),
+					Name("WeekStart"),
+					organizationControlClass("form-select", "WeekStart", fieldErrors),
+					g.Group(
+						g.Map(weekdays, func(weekday time.Weekday) g.Node {
+							return Option(
+								Value(strconv.Itoa(int(weekday))),
+								g.Text(weekday.String()),
+								g.If(formModel.WeekStart == int(weekday), Selected()),
+							)
+						}),
+					),
+				),
+				organizationFieldError("WeekStart", fieldErrors),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label d-block"),
+					g.Text("Working Days"),
+				),
+				g.Group(
+					g.Map(weekdays, func(weekday time.Weekday) g.Node {
+						checkboxID := fmt.Sprintf("organization_WorkingDays_%v", int(weekday))
+						return Div(
+							Class("form-check form-check-inline"),
+							Input(
+								ID(checkboxID),
+								Type("checkbox"),
+								Name("WorkingDays"),
+								Value(strconv.Itoa(int(weekday))),
+								organizationControlClass("form-check-input", "WorkingDays", fieldErrors),
+								g.If(slices.Contains(formModel.WorkingDays, int(weekday)), Checked()),
+							),
+							Label(
+								Class("form-check-label"),
+								g.Attr("for", checkboxID),
+								g.Text(weekday.String()[:3]),
+							),
+						)
+					}),
+				),
+				g.If(
+					fieldErrors["WorkingDays"] != "",
+					Div(
+						Class("invalid-feedback d-block"),
+						g.Text(fieldErrors["WorkingDays"]),
+					),
+				),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "organization_DateFormat"),
+					g.Text("Date Format"),
+				),
+				Select(
+					ID("organization_DateFormat"),
+					Name("DateFormat"),
+					organizationControlClass("form-select", "DateFormat", fieldErrors),
+					g.Group(
+						g.Map(shared.DateFormats, func(dateFormat string) g.Node {
+							return Option(
+								Value(dateFormat),
+								g.Text(sampleDate.Format(dateFormat)),
+								g.If(formModel.DateFormat == dateFormat, Selected()),
+							)
+						}),
+					),
+				),
+				organizationFieldError("DateFormat", fieldErrors),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "organization_UserDeletionPolicy"),
+					g.Text("Activities of Deleted Users"),
+				),
+				Select(
+					ID("organization_UserDeletionPolicy"),
+					Name("UserDeletionPolicy"),
+					organizationControlClass("form-select", "UserDeletionPolicy", fieldErrors),
+					Option(
+						Value(UserDeletionPolicyAnonymize),
+						g.Text("Keep anonymized"),
+						g.If(formModel.UserDeletionPolicy == UserDeletionPolicyAnonymize, Selected()),
+					),
+					Option(
+						Value(UserDeletionPolicyDelete),
+						g.Text("Delete"),
+						g.If(formModel.UserDeletionPolicy == UserDeletionPolicyDelete, Selected()),
+					),
+				),
+				organizationFieldError("UserDeletionPolicy", fieldErrors),
+			),
+			Div(
+				Class("form-check mb-3"),
+				Input(
+					ID("organization_AdminTwoFactor"),
+					Type("checkbox"),
+					Name("AdminTwoFactor"),
+					Value("true"),
+					Class("form-check-input"),
+					g.If(formModel.AdminTwoFactor, Checked()),
+				),
+				Label(
+					Class("form-check-label"),
+					g.Attr("for", "organization_AdminTwoFactor"),
+					g.Text("Require two-factor authentication for admins"),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("submit"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-save me-2")),
+				g.Text("Save"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("text-center btn btn-secondary"),
+				I(Class("bi-x me-2")),
+				g.Text("Cancel"),
+			),
+		),
+	)
+}
+
+func organizationControlClass(class, name string, fieldErrors map[string]string) g.Node {
+	if fieldErrors[name] != "" {
+		return Class(class + " is-invalid")
+	}
+
+	return Class(class)
+}
+
+func organizationFieldError(name string, fieldErrors map[string]string) g.Node {
+	return g.If(
+		fieldErrors[name] != "",
+		Div(
+			Class("invalid-feedback"),
+			g.Text(fieldErrors[name]),
+		),
+	)
+}
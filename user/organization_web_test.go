@@ -0,0 +1,144 @@
+package user
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestHandleOrganizationPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &OrganizationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			organizationRepository: NewInMemOrganizationRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/organization", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleOrganizationPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Test Organization"))
+	is.True(strings.Contains(htmlBody, "Working Days"))
+	is.True(strings.Contains(htmlBody, "31.12.2024"))
+}
+
+func TestHandleOrganizationPageAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &OrganizationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			organizationRepository: NewInMemOrganizationRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/organization", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleOrganizationPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleOrganizationForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	organizationRepository := NewInMemOrganizationRepository()
+
+	a := &OrganizationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			organizationRepository: organizationRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Title"] = []string{"Baralga Inc."}
+	data["TimeZone"] = []string{"America/New_York"}
+	data["WeekStart"] = []string{"0"}
+	data["WorkingDays"] = []string{"1", "2", "3", "4"}
+	data["DateFormat"] = []string{shared.DateFormatUS}
+	data["UserDeletionPolicy"] = []string{UserDeletionPolicyDelete}
+
+	r, _ := http.NewRequest("POST", "/organization", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Current-URL", "http://localhost:8080/reports")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleOrganizationForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Redirect"), "/session/refresh?redirect=%2Freports")
+
+	organization := organizationRepository.organizations[0]
+	is.Equal(organization.Title, "Baralga Inc.")
+	is.Equal(organization.TimeZone, "America/New_York")
+	is.Equal(organization.WeekStart, time.Sunday)
+	is.Equal(organization.WorkingDays, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday})
+	is.Equal(organization.DateFormat, shared.DateFormatUS)
+	is.Equal(organization.UserDeletionPolicy, UserDeletionPolicyDelete)
+}
+
+func TestHandleOrganizationFormWithoutWorkingDays(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	organizationRepository := NewInMemOrganizationRepository()
+
+	a := &OrganizationWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			organizationRepository: organizationRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Title"] = []string{"Baralga Inc."}
+	data["TimeZone"] = []string{"Mars/Olympus_Mons"}
+	data["WeekStart"] = []string{"1"}
+	data["DateFormat"] = []string{shared.DateFormatISO}
+	data["UserDeletionPolicy"] = []string{UserDeletionPolicyAnonymize}
+
+	r, _ := http.NewRequest("POST", "/organization", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleOrganizationForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Choose at least one working day."))
+	is.True(strings.Contains(htmlBody, "Unknown time zone."))
+	is.Equal(organizationRepository.organizations[0].Title, "Test Organization")
+}
@@ -0,0 +1,416 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type passwordForgotFormModel struct {
+	CSRFToken string
+	EMail     string `validate:"required,email"`
+}
+
+type passwordResetFormModel struct {
+	CSRFToken            string
+	Password             string `validate:"required,min=8,max=100"`
+	PasswordConfirmation string `validate:"required,eqfield=Password"`
+}
+
+type PasswordResetWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewPasswordResetWebHandlers(config *shared.Config, userService *UserService) *PasswordResetWebHandlers {
+	return &PasswordResetWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *PasswordResetWebHandlers) RegisterProtected(r chi.Router) {
+}
+
+func (a *PasswordResetWebHandlers) RegisterOpen(r chi.Router) {
+	r.Get("/password/forgot", a.HandlePasswordForgotPage())
+	r.Post("/password/forgot", a.HandlePasswordForgotForm())
+	r.Get("/password/reset/{password-reset-id}", a.HandlePasswordResetPage())
+	r.Post("/password/reset/{password-reset-id}", a.HandlePasswordResetForm())
+}
+
+func (a *PasswordResetWebHandlers) HandlePasswordForgotPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formModel := passwordForgotFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+		shared.RenderHTML(w, PasswordPage(r.URL.Path, PasswordForgotForm(formModel, nil)))
+	}
+}
+
+// HandlePasswordForgotForm sends a password reset link to the email
+func (a *PasswordResetWebHandlers) HandlePasswordForgotForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			formModel := passwordForgotFormModel{}
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, PasswordForgotForm(formModel, nil))
+			return
+		}
+
+		var formModel passwordForgotFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, PasswordForgotForm(formModel, nil))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			fieldErrors := map[string]string{
+				"EMail": "Invalid email.",
+			}
+			shared.RenderHTML(w, PasswordForgotForm(formModel, fieldErrors))
+			return
+		}
+
+		err = userService.RequestPasswordReset(r.Context(), formModel.EMail)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, PasswordForgotSuccess(formModel))
+	}
+}
+
+func (a *PasswordResetWebHandlers) HandlePasswordResetPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	return func(w http.ResponseWriter, r *http.Request) {
+		passwordReset, err := a.readPasswordReset(r)
+		if errors.Is(err, ErrPasswordResetNotFound) {
+			shared.RenderHTML(w, PasswordPage(r.URL.Path, PasswordResetInvalid()))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := passwordResetFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+		shared.RenderHTML(w, PasswordPage(r.URL.Path, PasswordResetForm(passwordReset, formModel, nil)))
+	}
+}
+
+// HandlePasswordResetForm sets the new password of a user
+func (a *PasswordResetWebHandlers) HandlePasswordResetForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		passwordReset, err := a.readPasswordReset(r)
+		if errors.Is(err, ErrPasswordResetNotFound) {
+			shared.RenderHTML(w, PasswordResetInvalid())
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			formModel := passwordResetFormModel{}
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, PasswordResetForm(passwordReset, formModel, nil))
+			return
+		}
+
+		var formModel passwordResetFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, PasswordResetForm(passwordReset, formModel, nil))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, PasswordResetForm(passwordReset, formModel, passwordResetFieldErrors(err)))
+			return
+		}
+
+		err = userService.ResetPassword(r.Context(), passwordReset.ID, formModel.Password)
+		if errors.Is(err, ErrPasswordResetNotFound) {
+			shared.RenderHTML(w, PasswordResetInvalid())
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, "/login?info=password_reset", http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Redirect", "/login?info=password_reset")
+	}
+}
+
+func (a *PasswordResetWebHandlers) readPasswordReset(r *http.Request) (*PasswordReset, error) {
+	passwordResetID, err := uuid.Parse(chi.URLParam(r, "password-reset-id"))
+	if err != nil {
+		return nil, ErrPasswordResetNotFound
+	}
+
+	return a.userService.ReadPasswordReset(r.Context(), passwordResetID)
+}
+
+func passwordResetFieldErrors(err error) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return fieldErrors
+	}
+
+	for _, fieldError := range validationErrors {
+		switch fieldError.Field() {
+		case "Password":
+			fieldErrors["Password"] = "Password must have 8 to 100 characters."
+		case "PasswordConfirmation":
+			fieldErrors["PasswordConfirmation"] = "Passwords don't match."
+		}
+	}
+
+	return fieldErrors
+}
+
+func PasswordPage(currentPath string, content g.Node) g.Node {
+	return shared.Page(
+		"Password",
+		currentPath,
+		[]g.Node{
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("d-flex justify-content-center align-items-center mt-2 mb-3"),
+						Img(
+							Alt("Baralga"),
+							Class("img-responsive"),
+							Src("/assets/baralga_192.png"),
+						),
+						Div(
+							Class("ms-4"),
+							H2(
+								g.Text("Baralga"),
+								Small(
+									Class("text-muted"),
+									StyleAttr("display: block; font-size: 70%;"),
+									g.Text("project time tracking"),
+								),
+							),
+						),
+					),
+					content,
+				),
+			),
+		},
+	)
+}
+
+func PasswordForgotSuccess(formModel passwordForgotFormModel) g.Node {
+	return Div(
+		Class("alert alert-success"),
+		Role("alert"),
+		g.Textf("If there is an account for %s, we've sent a link to set a new password. The link can be used once within the next hour.", formModel.EMail),
+	)
+}
+
+func PasswordResetInvalid() g.Node {
+	return Div(
+		Class("alert alert-warning"),
+		Role("alert"),
+		g.Text("This link is invalid, has already been used or has expired. "),
+		A(
+			Href("/password/forgot"),
+			Class("alert-link"),
+			g.Text("Request a new link."),
+		),
+	)
+}
+
+func PasswordForgotForm(formModel passwordForgotFormModel, fieldErrors map[string]string) g.Node {
+	return FormEl(
+		ID("password_forgot_form"),
+		ghx.Post("/password/forgot"),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		P(
+			g.Text("Enter the email of your account and we'll send you a link to set a new password."),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("email"),
+				Required(),
+				Type("email"),
+				Name("EMail"),
+				g.If(
+					fieldErrors["EMail"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["EMail"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "john.doe@mail.com"),
+				Value(formModel.EMail),
+			),
+			Label(
+				g.Attr("for", "email"),
+				g.Text("E-Mail"),
+			),
+			g.If(
+				fieldErrors["EMail"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["EMail"]),
+				),
+			),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Send link"),
+			),
+		),
+		Div(
+			Class("row justify-content-around mt-2"),
+			Div(
+				Class("col-4 text-center"),
+				A(
+					Href("/login"),
+					Class("link-secondary"),
+					g.Text("Back to sign in"),
+				),
+			),
+		),
+	)
+}
+
+func PasswordResetForm(passwordReset *PasswordReset, formModel passwordResetFormModel, fieldErrors map[string]string) g.Node {
+	return FormEl(
+		ID("password_reset_form"),
+		ghx.Post(fmt.Sprintf("/password/reset/%v", passwordReset.ID)),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("password"),
+				Required(),
+				Type("password"),
+				Name("Password"),
+				MinLength("8"),
+				MaxLength("100"),
+				g.If(
+					fieldErrors["Password"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["Password"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "***"),
+			),
+			Label(
+				g.Attr("for", "password"),
+				g.Text("New Password"),
+			),
+			g.If(
+				fieldErrors["Password"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["Password"]),
+				),
+			),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("passwordConfirmation"),
+				Required(),
+				Type("password"),
+				Name("PasswordConfirmation"),
+				MaxLength("100"),
+				g.If(
+					fieldErrors["PasswordConfirmation"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["PasswordConfirmation"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "***"),
+			),
+			Label(
+				g.Attr("for", "passwordConfirmation"),
+				g.Text("Repeat New Password"),
+			),
+			g.If(
+				fieldErrors["PasswordConfirmation"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["PasswordConfirmation"]),
+				),
+			),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Set new password"),
+			),
+		),
+	)
+}
@@ -0,0 +1,167 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandlePasswordForgotPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &PasswordResetWebHandlers{
+		config: &shared.Config{},
+	}
+
+	r, _ := http.NewRequest("GET", "/password/forgot", nil)
+
+	a.HandlePasswordForgotPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Password # Baralga"))
+}
+
+func TestHandlePasswordForgotForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	mailResource := shared.NewInMemMailResource()
+
+	a := &PasswordResetWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			config:         &shared.Config{},
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			mailResource:   mailResource,
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"admin@baralga.com"}
+
+	r, _ := http.NewRequest("POST", "/password/forgot", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandlePasswordForgotForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(mailResource.Mails), 1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "If there is an account for admin@baralga.com"))
+}
+
+func TestHandlePasswordResetPageWithUnknownPasswordReset(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &PasswordResetWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	passwordResetID := uuid.New()
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/password/reset/%v", passwordResetID), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("password-reset-id", passwordResetID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandlePasswordResetPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "This link is invalid"))
+}
+
+func TestHandlePasswordResetForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	passwordReset := addPasswordResetSample(userRepository)
+
+	a := &PasswordResetWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Password"] = []string{"myNewPassword?!"}
+	data["PasswordConfirmation"] = []string{"myNewPassword?!"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/password/reset/%v", passwordReset.ID), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("password-reset-id", passwordReset.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandlePasswordResetForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Redirect"), "/login?info=password_reset")
+	is.Equal(len(userRepository.passwordResets), 0)
+}
+
+func TestHandlePasswordResetFormWithDifferentPasswords(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	passwordReset := addPasswordResetSample(userRepository)
+
+	a := &PasswordResetWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Password"] = []string{"myNewPassword?!"}
+	data["PasswordConfirmation"] = []string{"myOtherPassword?!"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/password/reset/%v", passwordReset.ID), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("password-reset-id", passwordReset.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandlePasswordResetForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.passwordResets), 1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Passwords don&#39;t match."))
+}
+
+// addPasswordResetSample adds a valid password reset of the admin sample user
+func addPasswordResetSample(userRepository *InMemUserRepository) *PasswordReset {
+	passwordReset := &PasswordReset{
+		ID:        uuid.New(),
+		UserID:    userRepository.users[0].ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(PasswordResetValidity),
+	}
+	userRepository.passwordResets = append(userRepository.passwordResets, passwordReset)
+	return passwordReset
+}
@@ -0,0 +1,307 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type profileModel struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Username string     `json:"username"`
+	EMail    string     `json:"email"`
+	Origin   string     `json:"origin"`
+	Links    *hal.Links `json:"_links"`
+}
+
+// organizationModel is an organization the user is member of, API clients sign in to it with its id
+type organizationModel struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Current bool   `json:"current"`
+}
+
+type organizationsModel struct {
+	*EmbeddedOrganizations `json:"_embedded"`
+	Links                  *hal.Links `json:"_links"`
+}
+
+// EmbeddedOrganizations contains embedded organizations
+type EmbeddedOrganizations struct {
+	OrganizationModels []*organizationModel `json:"organizations"`
+}
+
+type profileUpdateModel struct {
+	Name string `json:"name" validate:"required,min=5,max=50"`
+}
+
+type passwordChangeModel struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=100"`
+	Password        string `json:"password" validate:"required,min=8,max=100"`
+}
+
+type emailChangeModel struct {
+	EMail string `json:"email" validate:"required,email,max=100"`
+}
+
+// accountDeletionModel confirms the deletion of the account with the password, which users of GitHub or Google leave empty
+type accountDeletionModel struct {
+	Password string `json:"password" validate:"max=100"`
+}
+
+type ProfileRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewProfileRestHandlers(config *shared.Config, userService *UserService) *ProfileRestHandlers {
+	return &ProfileRestHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *ProfileRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/me", a.HandleGetProfile())
+	r.Patch("/me", a.HandleUpdateProfile())
+	r.Post("/me/password", a.HandleChangePassword())
+	r.Post("/me/email", a.HandleChangeEMail())
+	r.Get("/me/organizations", a.HandleGetOrganizations())
+	r.Get("/me/export", a.HandleExportUserData())
+	r.Delete("/me", a.HandleDeleteAccount())
+}
+
+func (a *ProfileRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetProfile reads the signed in user
+func (a *ProfileRestHandlers) HandleGetProfile() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToProfileModel(profile))
+	}
+}
+
+// HandleUpdateProfile sets the display name of the signed in user
+func (a *ProfileRestHandlers) HandleUpdateProfile() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var updateModel profileUpdateModel
+		err := json.NewDecoder(r.Body).Decode(&updateModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(updateModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("profile not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		profile, err := userService.UpdateName(r.Context(), principal, updateModel.Name)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToProfileModel(profile))
+	}
+}
+
+// HandleChangePassword changes the password of the signed in user if the current password matches
+func (a *ProfileRestHandlers) HandleChangePassword() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var passwordModel passwordChangeModel
+		err := json.NewDecoder(r.Body).Decode(&passwordModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(passwordModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("password not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.ChangePassword(r.Context(), principal, passwordModel.CurrentPassword, passwordModel.Password)
+		if errors.Is(err, ErrPasswordInvalid) {
+			http.Error(w, problem.New(problem.Title("current password invalid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleChangeEMail sends a confirmation link to the new email of the signed in user
+func (a *ProfileRestHandlers) HandleChangeEMail() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var emailModel emailChangeModel
+		err := json.NewDecoder(r.Body).Decode(&emailModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(emailModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("email not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		_, err = userService.RequestEMailChange(r.Context(), principal, emailModel.EMail)
+		if errors.Is(err, ErrUserExists) {
+			http.Error(w, problem.New(problem.Title("email not available")).JSONString(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// HandleGetOrganizations reads the organizations the signed in user is an enabled member of
+func (a *ProfileRestHandlers) HandleGetOrganizations() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		memberships, err := userService.ReadMemberships(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		organizationModels := make([]*organizationModel, 0, len(memberships))
+		for _, membership := range memberships {
+			organizationModels = append(organizationModels, &organizationModel{
+				ID:      membership.OrganizationID.String(),
+				Title:   membership.OrganizationTitle,
+				Current: membership.OrganizationID == principal.OrganizationID,
+			})
+		}
+
+		organizationsModel := &organizationsModel{
+			EmbeddedOrganizations: &EmbeddedOrganizations{
+				OrganizationModels: organizationModels,
+			},
+			Links: hal.NewSelfLink(r.RequestURI),
+		}
+
+		shared.RenderJSON(w, organizationsModel)
+	}
+}
+
+// HandleExportUserData downloads all data of the signed in user as zip archive
+func (a *ProfileRestHandlers) HandleExportUserData() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var buffer bytes.Buffer
+		err := userService.ExportUserData(r.Context(), principal, &buffer)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"Baralga_Data.zip\"")
+		_, _ = buffer.WriteTo(w)
+	}
+}
+
+// HandleDeleteAccount deletes the account of the signed in user
+func (a *ProfileRestHandlers) HandleDeleteAccount() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var deletionModel accountDeletionModel
+		err := json.NewDecoder(r.Body).Decode(&deletionModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(deletionModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("password not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.DeleteAccount(r.Context(), principal, deletionModel.Password)
+		if errors.Is(err, ErrPasswordInvalid) {
+			http.Error(w, problem.New(problem.Title("password invalid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			http.Error(w, problem.New(problem.Title(ErrLastAdmin.Error())).JSONString(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func mapToProfileModel(user *User) *profileModel {
+	return &profileModel{
+		ID:       user.ID.String(),
+		Name:     user.Name,
+		Username: user.Username,
+		EMail:    user.EMail,
+		Origin:   user.Origin,
+		Links: hal.NewLinks(
+			hal.NewSelfLink("/api/me"),
+			hal.NewLink("edit", "/api/me"),
+		),
+	}
+}
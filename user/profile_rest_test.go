@@ -0,0 +1,283 @@
+package user
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetProfile(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/me", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleGetProfile()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	profileModel := &profileModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(profileModel)
+	is.NoErr(err)
+	is.Equal(profileModel.Username, "admin@baralga.com")
+	is.Equal(profileModel.EMail, "admin@baralga.com")
+}
+
+func TestHandleUpdateProfile(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+	}
+
+	body := `{"name": "Ed Admin"}`
+	r, _ := http.NewRequest("PATCH", "/api/me", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleUpdateProfile()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(userRepository.users[0].Name, "Ed Admin")
+}
+
+func TestHandleUpdateProfileWithInvalidName(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	body := `{"name": ""}`
+	r, _ := http.NewRequest("PATCH", "/api/me", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleUpdateProfile()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
+
+func TestHandleChangePassword(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	body := `{"currentPassword": "adm1n", "password": "myNewPassword?!"}`
+	r, _ := http.NewRequest("POST", "/api/me/password", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleChangePassword()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNoContent)
+}
+
+func TestHandleChangePasswordWithWrongCurrentPassword(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	body := `{"currentPassword": "wrong", "password": "myNewPassword?!"}`
+	r, _ := http.NewRequest("POST", "/api/me/password", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleChangePassword()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
+
+func TestHandleChangeEMail(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	mailResource := shared.NewInMemMailResource()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			config:         &shared.Config{},
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			mailResource:   mailResource,
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	body := `{"email": "ed@baralga.com"}`
+	r, _ := http.NewRequest("POST", "/api/me/email", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleChangeEMail()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusAccepted)
+	is.Equal(len(mailResource.Mails), 1)
+}
+
+func TestHandleExportUserData(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+			userDataExporter: func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error {
+				return nil
+			},
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/me/export", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleExportUserData()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("Content-Type"), "application/zip")
+}
+
+func TestHandleDeleteAccount(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			userRepository:         userRepository,
+			organizationRepository: NewInMemOrganizationRepository(),
+			teamRepository:         NewInMemTeamRepository(),
+			apiTokenRepository:     NewInMemAPITokenRepository(),
+			userDataRemover:        userDataRemoverSample(nil),
+		},
+	}
+
+	body := `{"password": "adm1n"}`
+	r, _ := http.NewRequest("DELETE", "/api/me", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleDeleteAccount()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNoContent)
+	is.Equal(len(userRepository.users), 0)
+}
+
+func TestHandleDeleteAccountOfLastAdmin(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			userRepository:         userRepository,
+			organizationRepository: NewInMemOrganizationRepository(),
+			teamRepository:         NewInMemTeamRepository(),
+			apiTokenRepository:     NewInMemAPITokenRepository(),
+			userDataRemover:        userDataRemoverSample(nil),
+		},
+	}
+
+	body := `{"password": "adm1n"}`
+	r, _ := http.NewRequest("DELETE", "/api/me", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleDeleteAccount()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusConflict)
+	is.Equal(len(userRepository.users), 2)
+}
+
+func TestHandleGetOrganizations(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	err := userRepository.InsertMembership(context.Background(), uuid.New(), userRepository.users[0].ID, RoleUser)
+	is.NoErr(err)
+
+	a := &ProfileRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: userRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/me/organizations", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleGetOrganizations()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	organizationsModel := &organizationsModel{}
+	err = json.NewDecoder(httpRec.Body).Decode(organizationsModel)
+	is.NoErr(err)
+	is.Equal(len(organizationsModel.OrganizationModels), 2)
+	is.Equal(organizationsModel.OrganizationModels[0].ID, shared.OrganizationIDSample.String())
+	is.True(organizationsModel.OrganizationModels[0].Current)
+	is.True(!organizationsModel.OrganizationModels[1].Current)
+}
@@ -0,0 +1,582 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type profileNameFormModel struct {
+	CSRFToken string
+	Name      string `validate:"required,min=5,max=50"`
+}
+
+type profilePasswordFormModel struct {
+	CSRFToken            string
+	CurrentPassword      string `validate:"required,max=100"`
+	Password             string `validate:"required,min=8,max=100"`
+	PasswordConfirmation string `validate:"required,eqfield=Password"`
+}
+
+type profileEMailFormModel struct {
+	CSRFToken string
+	EMail     string `validate:"required,email,max=100"`
+}
+
+type ProfileWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewProfileWebHandlers(config *shared.Config, userService *UserService) *ProfileWebHandlers {
+	return &ProfileWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *ProfileWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/profile", a.HandleProfilePage())
+	r.Post("/profile/name", a.HandleProfileNameForm())
+	r.Post("/profile/password", a.HandleProfilePasswordForm())
+	r.Post("/profile/email", a.HandleProfileEMailForm())
+	r.Get("/profile/export", a.HandleProfileExport())
+	r.Post("/profile/delete", a.HandleProfileDeleteForm())
+}
+
+func (a *ProfileWebHandlers) RegisterOpen(r chi.Router) {
+	r.Get("/profile/email/confirm/{confirmation-id}", a.HandleProfileEMailConfirm())
+}
+
+func (a *ProfileWebHandlers) HandleProfilePage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Profile",
+			}
+			shared.RenderHTML(w, a.ProfilePage(pageContext, csrf.Token(r), profile))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+	}
+}
+
+// HandleProfileNameForm sets the display name of the signed in user
+func (a *ProfileWebHandlers) HandleProfileNameForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		var formModel profileNameFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			invalidProfile := *profile
+			invalidProfile.Name = formModel.Name
+			fieldErrors := map[string]string{
+				"Name": "Name must have 5 to 50 characters.",
+			}
+			shared.RenderHTML(w, a.ProfileView(&invalidProfile, csrf.Token(r), fieldErrors, "", ""))
+			return
+		}
+
+		_, err = userService.UpdateName(r.Context(), principal, formModel.Name)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		// refresh session so that the new name becomes part of the principal
+		refreshURI := fmt.Sprintf("/session/refresh?redirect=%v", url.QueryEscape(currentRequestURI(r)))
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, refreshURI, http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Redirect", refreshURI)
+	}
+}
+
+// HandleProfilePasswordForm changes the password of the signed in user
+func (a *ProfileWebHandlers) HandleProfilePasswordForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		var formModel profilePasswordFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			fieldErrors := passwordResetFieldErrors(err)
+			if formModel.CurrentPassword == "" {
+				fieldErrors["CurrentPassword"] = "Current password is required."
+			}
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), fieldErrors, "", ""))
+			return
+		}
+
+		err = userService.ChangePassword(r.Context(), principal, formModel.CurrentPassword, formModel.Password)
+		if errors.Is(err, ErrPasswordInvalid) {
+			fieldErrors := map[string]string{
+				"CurrentPassword": "Current password is wrong.",
+			}
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), fieldErrors, "", ""))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "Your password has been changed, please sign in again with your new password.", ""))
+	}
+}
+
+// HandleProfileEMailForm sends a confirmation link to the new email of the signed in user
+func (a *ProfileWebHandlers) HandleProfileEMailForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		var formModel profileEMailFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			fieldErrors := map[string]string{
+				"EMail": "Invalid email.",
+			}
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), fieldErrors, "", ""))
+			return
+		}
+
+		_, err = userService.RequestEMailChange(r.Context(), principal, formModel.EMail)
+		if errors.Is(err, ErrUserExists) {
+			fieldErrors := map[string]string{
+				"EMail": "Email not available.",
+			}
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), fieldErrors, "", ""))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		infoMessage := fmt.Sprintf("We've sent a link to %s, your email is changed once you confirm it.", formModel.EMail)
+		shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, infoMessage, ""))
+	}
+}
+
+// HandleProfileExport downloads all data of the signed in user as zip archive
+func (a *ProfileWebHandlers) HandleProfileExport() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var buffer bytes.Buffer
+		err := userService.ExportUserData(r.Context(), principal, &buffer)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"Baralga_Data.zip\"")
+		_, _ = buffer.WriteTo(w)
+	}
+}
+
+// HandleProfileDeleteForm deletes the account of the signed in user and signs out
+func (a *ProfileWebHandlers) HandleProfileDeleteForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", ""))
+			return
+		}
+
+		err = userService.DeleteAccount(r.Context(), principal, r.PostForm.Get("DeletionPassword"))
+		if errors.Is(err, ErrPasswordInvalid) {
+			fieldErrors := map[string]string{
+				"DeletionPassword": "Password is wrong.",
+			}
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), fieldErrors, "", ""))
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			errorMessage := "You're the last admin of the organization, make another member admin first."
+			shared.RenderHTML(w, a.ProfileView(profile, csrf.Token(r), nil, "", errorMessage))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, "/logout", http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Redirect", "/logout")
+	}
+}
+
+func (a *ProfileWebHandlers) HandleProfileEMailConfirm() http.HandlerFunc {
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		confirmationID, err := uuid.Parse(chi.URLParam(r, "confirmation-id"))
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		err = userService.ConfirmEMailChange(r.Context(), confirmationID)
+		if err != nil {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		http.Redirect(w, r, "/login?info=email_changed", http.StatusFound)
+	}
+}
+
+func (a *ProfileWebHandlers) ProfilePage(pageContext *shared.PageContext, csrfToken string, profile *User) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					a.ProfileView(profile, csrfToken, nil, "", ""),
+				),
+			),
+		},
+	)
+}
+
+func (a *ProfileWebHandlers) ProfileView(profile *User, csrfToken string, fieldErrors map[string]string, infoMessage, errorMessage string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Profile"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			g.If(
+				infoMessage != "",
+				Div(
+					Class("alert alert-info text-center"),
+					Role("alert"),
+					Span(g.Text(infoMessage)),
+				),
+			),
+			g.If(
+				errorMessage != "",
+				Div(
+					Class("alert alert-danger text-center"),
+					Role("alert"),
+					Span(g.Text(errorMessage)),
+				),
+			),
+			FormEl(
+				ghx.Post("/profile/name"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("mb-4"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				profileInput("Name", "text", "Name", profile.Name, fieldErrors),
+				Div(
+					Class("form-text mb-2"),
+					g.Textf("You sign in as %v.", profile.Username),
+				),
+				Button(
+					Type("submit"),
+					Class("btn btn-primary btn-sm"),
+					I(Class("bi-save me-2")),
+					g.Text("Save name"),
+				),
+			),
+			FormEl(
+				ghx.Post("/profile/email"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("mb-4"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				profileInput("EMail", "email", "E-Mail", profile.EMail, fieldErrors),
+				Button(
+					Type("submit"),
+					Class("btn btn-primary btn-sm"),
+					I(Class("bi-envelope me-2")),
+					g.Text("Change email"),
+				),
+			),
+			g.If(profile.HasLocalPassword(),
+				FormEl(
+					ghx.Post("/profile/password"),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+
+					Input(
+						Type("hidden"),
+						Name("CSRFToken"),
+						Value(csrfToken),
+					),
+					profileInput("CurrentPassword", "password", "Current Password", "", fieldErrors),
+					profileInput("Password", "password", "New Password", "", fieldErrors),
+					profileInput("PasswordConfirmation", "password", "Repeat New Password", "", fieldErrors),
+					Button(
+						Type("submit"),
+						Class("btn btn-primary btn-sm"),
+						I(Class("bi-key me-2")),
+						g.Text("Change password"),
+					),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer d-block"),
+			H5(g.Text("Two-Factor Authentication")),
+			P(
+				Class("form-text"),
+				g.Text("Sign in with a one time password of an authenticator app in addition to your password."),
+			),
+			A(
+				ghx.Get("/profile/two-factor"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("btn btn-outline-primary btn-sm"),
+				I(Class("bi-shield-lock me-2")),
+				g.Text("Manage two-factor authentication"),
+			),
+		),
+		Div(
+			Class("modal-footer d-block"),
+			H5(g.Text("Sessions")),
+			P(
+				Class("form-text"),
+				g.Text("See where you are signed in and sign out of other devices."),
+			),
+			A(
+				ghx.Get("/sessions"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("btn btn-outline-primary btn-sm mb-3"),
+				I(Class("bi-laptop me-2")),
+				g.Text("Show sessions"),
+			),
+			FormEl(
+				ghx.Post("/sessions/revoke-all"),
+				ghx.Confirm("Do you really want to log out all sessions, including this one?"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Type("submit"),
+					Class("btn btn-outline-danger btn-sm"),
+					I(Class("bi-box-arrow-right me-2")),
+					g.Text("Log out all sessions"),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer d-block"),
+			H5(g.Text("Your Data")),
+			P(
+				Class("form-text"),
+				g.Text("Download all your data or delete your account. See our "),
+				A(
+					Href(a.config.DataProtectionURL),
+					g.Text("data protection rules"),
+				),
+				g.Text("."),
+			),
+			A(
+				Href("/profile/export"),
+				Class("btn btn-outline-primary btn-sm mb-3"),
+				I(Class("bi-download me-2")),
+				g.Text("Download my data"),
+			),
+			FormEl(
+				ghx.Post("/profile/delete"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm("Do you really want to delete your account? This cannot be undone."),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				g.If(profile.HasLocalPassword(),
+					profileInput("DeletionPassword", "password", "Password", "", fieldErrors),
+				),
+				Button(
+					Type("submit"),
+					Class("btn btn-outline-danger btn-sm"),
+					I(Class("bi-trash me-2")),
+					g.Text("Delete my account"),
+				),
+			),
+		),
+	)
+}
+
+func profileInput(name, inputType, label, value string, fieldErrors map[string]string) g.Node {
+	return Div(
+		Class("form-floating mb-2"),
+		Input(
+			ID(fmt.Sprintf("profile_%v", name)),
+			Required(),
+			Type(inputType),
+			Name(name),
+			MaxLength("100"),
+			g.If(
+				fieldErrors[name] != "",
+				Class("form-control is-invalid"),
+			),
+			g.If(
+				fieldErrors[name] == "",
+				Class("form-control"),
+			),
+			g.Attr("placeholder", label),
+			g.If(value != "", Value(value)),
+		),
+		Label(
+			g.Attr("for", fmt.Sprintf("profile_%v", name)),
+			g.Text(label),
+		),
+		g.If(
+			fieldErrors[name] != "",
+			Div(
+				Class("invalid-feedback"),
+				g.Text(fieldErrors[name]),
+			),
+		),
+	)
+}
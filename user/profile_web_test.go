@@ -0,0 +1,385 @@
+package user
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleProfilePage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/profile", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfilePage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Profile # Baralga"))
+	is.True(strings.Contains(htmlBody, "Change password"))
+}
+
+func TestHandleProfilePageAsGithubUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	userRepository.users[0].Origin = "github"
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: userRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/profile", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfilePage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(!strings.Contains(htmlBody, "Change password"))
+}
+
+func TestHandleProfileNameForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Ed Admin"}
+
+	r, _ := http.NewRequest("POST", "/profile/name", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Current-URL", "http://localhost:8080/reports")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfileNameForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Redirect"), "/session/refresh?redirect=%2Freports")
+	is.Equal(userRepository.users[0].Name, "Ed Admin")
+}
+
+func TestHandleProfileNameFormWithInvalidName(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Ed"}
+
+	r, _ := http.NewRequest("POST", "/profile/name", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfileNameForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(userRepository.users[0].Name, "")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Name must have 5 to 50 characters."))
+}
+
+func TestHandleProfilePasswordForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["CurrentPassword"] = []string{"adm1n"}
+	data["Password"] = []string{"myNewPassword?!"}
+	data["PasswordConfirmation"] = []string{"myNewPassword?!"}
+
+	r, _ := http.NewRequest("POST", "/profile/password", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfilePasswordForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Your password has been changed, please sign in again with your new password."))
+}
+
+func TestHandleProfilePasswordFormWithWrongCurrentPassword(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["CurrentPassword"] = []string{"wrong"}
+	data["Password"] = []string{"myNewPassword?!"}
+	data["PasswordConfirmation"] = []string{"myNewPassword?!"}
+
+	r, _ := http.NewRequest("POST", "/profile/password", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfilePasswordForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Current password is wrong."))
+}
+
+func TestHandleProfileEMailForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+	mailResource := shared.NewInMemMailResource()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			config:         &shared.Config{},
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			mailResource:   mailResource,
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"ed@baralga.com"}
+
+	r, _ := http.NewRequest("POST", "/profile/email", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfileEMailForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(mailResource.Mails), 1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "We&#39;ve sent a link to ed@baralga.com"))
+}
+
+func TestHandleProfileEMailConfirm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	emailChange := &EMailChange{
+		ConfirmationID: uuid.New(),
+		UserID:         userRepository.users[0].ID,
+		EMail:          "ed@baralga.com",
+		ExpiresAt:      time.Now().Add(EMailChangeValidity),
+	}
+	userRepository.emailChanges = append(userRepository.emailChanges, emailChange)
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/profile/email/confirm/%v", emailChange.ConfirmationID), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("confirmation-id", emailChange.ConfirmationID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleProfileEMailConfirm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+	is.Equal(httpRec.Header().Get("Location"), "/login?info=email_changed")
+	is.Equal(userRepository.users[0].EMail, "ed@baralga.com")
+}
+
+func TestHandleProfileEMailConfirmWithoutEMailChange(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	confirmationID := uuid.New()
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/profile/email/confirm/%v", confirmationID), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("confirmation-id", confirmationID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleProfileEMailConfirm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+	is.Equal(httpRec.Header().Get("Location"), "/")
+}
+
+func TestHandleProfileExport(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+			userDataExporter: func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error {
+				return nil
+			},
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/profile/export", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfileExport()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("Content-Type"), "application/zip")
+	is.True(strings.Contains(httpRec.Header().Get("Content-Disposition"), "Baralga_Data.zip"))
+}
+
+func TestHandleProfileDeleteForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			userRepository:         userRepository,
+			organizationRepository: NewInMemOrganizationRepository(),
+			teamRepository:         NewInMemTeamRepository(),
+			apiTokenRepository:     NewInMemAPITokenRepository(),
+			userDataRemover:        userDataRemoverSample(nil),
+		},
+	}
+
+	data := url.Values{}
+	data["DeletionPassword"] = []string{"adm1n"}
+
+	r, _ := http.NewRequest("POST", "/profile/delete", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfileDeleteForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Redirect"), "/logout")
+	is.Equal(len(userRepository.users), 0)
+}
+
+func TestHandleProfileDeleteFormWithWrongPassword(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &ProfileWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			userRepository:         userRepository,
+			organizationRepository: NewInMemOrganizationRepository(),
+			teamRepository:         NewInMemTeamRepository(),
+			apiTokenRepository:     NewInMemAPITokenRepository(),
+			userDataRemover:        userDataRemoverSample(nil),
+		},
+	}
+
+	data := url.Values{}
+	data["DeletionPassword"] = []string{"wrong"}
+
+	r, _ := http.NewRequest("POST", "/profile/delete", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleProfileDeleteForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.users), 1)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Password is wrong."))
+}
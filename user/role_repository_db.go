@@ -0,0 +1,177 @@
+package user
+
+import (
+	"context"
+	"strings"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbRoleRepository is a SQL database repository for custom roles
+type DbRoleRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ RoleRepository = (*DbRoleRepository)(nil)
+
+// NewDbRoleRepository creates a new SQL database repository for custom roles
+func NewDbRoleRepository(connPool *pgxpool.Pool) *DbRoleRepository {
+	return &DbRoleRepository{
+		connPool: connPool,
+	}
+}
+
+// FindRolesByOrganizationID finds the custom roles of the organization ordered by title
+func (r *DbRoleRepository) FindRolesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*OrganizationRole, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT role, title, permissions
+		 FROM organization_roles
+		 WHERE org_id = $1
+		 ORDER BY title`, organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*OrganizationRole
+	for rows.Next() {
+		var (
+			name        string
+			title       string
+			permissions string
+		)
+
+		err = rows.Scan(&name, &title, &permissions)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, &OrganizationRole{
+			OrganizationID: organizationID,
+			Name:           name,
+			Title:          title,
+			Permissions:    parsePermissions(permissions),
+		})
+	}
+
+	return roles, nil
+}
+
+func (r *DbRoleRepository) FindRoleByName(ctx context.Context, organizationID uuid.UUID, name string) (*OrganizationRole, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT title, permissions
+		 FROM organization_roles
+		 WHERE org_id = $1 AND role = $2`, organizationID, name,
+	)
+
+	var (
+		title       string
+		permissions string
+	)
+
+	err := row.Scan(&title, &permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRoleNotFound
+		}
+
+		return nil, err
+	}
+
+	return &OrganizationRole{
+		OrganizationID: organizationID,
+		Name:           name,
+		Title:          title,
+		Permissions:    parsePermissions(permissions),
+	}, nil
+}
+
+func (r *DbRoleRepository) InsertRole(ctx context.Context, role *OrganizationRole) (*OrganizationRole, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO organization_roles
+		   (org_id, role, title, permissions)
+		 VALUES
+		   ($1, $2, $3, $4)`,
+		role.OrganizationID,
+		role.Name,
+		role.Title,
+		strings.Join(role.Permissions, ","),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// UpdateRole updates title and permissions of the custom role, the name of the role is kept
+func (r *DbRoleRepository) UpdateRole(ctx context.Context, role *OrganizationRole) (*OrganizationRole, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`UPDATE organization_roles
+		 SET title = $3, permissions = $4
+		 WHERE org_id = $1 AND role = $2`,
+		role.OrganizationID,
+		role.Name,
+		role.Title,
+		strings.Join(role.Permissions, ","),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, ErrRoleNotFound
+	}
+
+	return role, nil
+}
+
+func (r *DbRoleRepository) DeleteRoleByName(ctx context.Context, organizationID uuid.UUID, name string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`DELETE
+		 FROM organization_roles
+		 WHERE org_id = $1 AND role = $2
+		 RETURNING role`,
+		organizationID, name)
+
+	var role string
+	err := row.Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// parsePermissions parses comma separated permissions, unknown permissions are skipped
+func parsePermissions(permissions string) []string {
+	var parsed []string
+	for _, permission := range strings.Split(permissions, ",") {
+		permission = strings.TrimSpace(permission)
+		if !shared.IsValidPermission(permission) {
+			continue
+		}
+		parsed = append(parsed, permission)
+	}
+
+	return parsed
+}
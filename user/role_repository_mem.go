@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type InMemRoleRepository struct {
+	roles []*OrganizationRole
+}
+
+var _ RoleRepository = (*InMemRoleRepository)(nil)
+
+func NewInMemRoleRepository() *InMemRoleRepository {
+	return &InMemRoleRepository{}
+}
+
+func (r *InMemRoleRepository) FindRolesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*OrganizationRole, error) {
+	var roles []*OrganizationRole
+	for _, role := range r.roles {
+		if role.OrganizationID == organizationID {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (r *InMemRoleRepository) FindRoleByName(ctx context.Context, organizationID uuid.UUID, name string) (*OrganizationRole, error) {
+	for _, role := range r.roles {
+		if role.Name == name && role.OrganizationID == organizationID {
+			return role, nil
+		}
+	}
+	return nil, ErrRoleNotFound
+}
+
+func (r *InMemRoleRepository) InsertRole(ctx context.Context, role *OrganizationRole) (*OrganizationRole, error) {
+	r.roles = append(r.roles, role)
+	return role, nil
+}
+
+func (r *InMemRoleRepository) UpdateRole(ctx context.Context, role *OrganizationRole) (*OrganizationRole, error) {
+	for i, existingRole := range r.roles {
+		if existingRole.Name == role.Name && existingRole.OrganizationID == role.OrganizationID {
+			r.roles[i] = role
+			return role, nil
+		}
+	}
+	return nil, ErrRoleNotFound
+}
+
+func (r *InMemRoleRepository) DeleteRoleByName(ctx context.Context, organizationID uuid.UUID, name string) error {
+	for i, role := range r.roles {
+		if role.Name == name && role.OrganizationID == organizationID {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return nil
+		}
+	}
+	return ErrRoleNotFound
+}
@@ -0,0 +1,90 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestRoleRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	roleRepository := NewDbRoleRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	role := &OrganizationRole{
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "ROLE_CONTROLLER",
+		Title:          "Controller",
+		Permissions:    []string{shared.PermissionActivitiesReadAll, shared.PermissionProjectsWrite},
+	}
+
+	t.Run("InsertRole", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := roleRepository.InsertRole(ctx, role)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		roles, err := roleRepository.FindRolesByOrganizationID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.Equal(len(roles), 1)
+		is.Equal(roles[0].Name, "ROLE_CONTROLLER")
+		is.Equal(roles[0].Permissions, []string{shared.PermissionActivitiesReadAll, shared.PermissionProjectsWrite})
+	})
+	t.Run("UpdateRole", func(t *testing.T) {
+		role.Title = "Controlling"
+		role.Permissions = nil
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := roleRepository.UpdateRole(ctx, role)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		updatedRole, err := roleRepository.FindRoleByName(context.Background(), shared.OrganizationIDSample, "ROLE_CONTROLLER")
+		is.NoErr(err)
+		is.Equal(updatedRole.Title, "Controlling")
+		is.Equal(len(updatedRole.Permissions), 0)
+	})
+	t.Run("DeleteRoleByName", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return roleRepository.DeleteRoleByName(ctx, shared.OrganizationIDSample, "ROLE_CONTROLLER")
+			},
+		)
+		is.NoErr(err)
+
+		_, err = roleRepository.FindRoleByName(context.Background(), shared.OrganizationIDSample, "ROLE_CONTROLLER")
+		is.True(errors.Is(err, ErrRoleNotFound))
+	})
+}
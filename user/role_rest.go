@@ -0,0 +1,272 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type roleModel struct {
+	Name        string     `json:"name"`
+	Title       string     `json:"title" validate:"required,max=50"`
+	Permissions []string   `json:"permissions" validate:"dive,max=50"`
+	BuiltIn     bool       `json:"builtIn"`
+	Links       *hal.Links `json:"_links"`
+}
+
+type rolesModel struct {
+	*EmbeddedRoles `json:"_embedded"`
+	Links          *hal.Links `json:"_links"`
+}
+
+// EmbeddedRoles contains embedded roles
+type EmbeddedRoles struct {
+	RoleModels []*roleModel `json:"roles"`
+}
+
+type RoleRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewRoleRestHandlers(config *shared.Config, userService *UserService) *RoleRestHandlers {
+	return &RoleRestHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *RoleRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/roles", a.HandleGetRoles())
+	r.Post("/roles", a.HandleCreateRole())
+	r.Get("/roles/{role}", a.HandleGetRole())
+	r.Patch("/roles/{role}", a.HandleUpdateRole())
+	r.Delete("/roles/{role}", a.HandleDeleteRole())
+}
+
+func (a *RoleRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetRoles reads the built-in and custom roles of the organization
+func (a *RoleRestHandlers) HandleGetRoles() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		roles, err := userService.ReadRoles(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		roleModels := make([]*roleModel, 0, len(roles))
+		for _, role := range roles {
+			roleModels = append(roleModels, mapToRoleModel(role))
+		}
+
+		rolesModel := &rolesModel{
+			EmbeddedRoles: &EmbeddedRoles{
+				RoleModels: roleModels,
+			},
+			Links: hal.NewSelfLink(r.RequestURI),
+		}
+
+		shared.RenderJSON(w, rolesModel)
+	}
+}
+
+// HandleGetRole reads a built-in or custom role of the organization
+func (a *RoleRestHandlers) HandleGetRole() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "role")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		role, err := userService.ReadRole(r.Context(), principal, name)
+		if errors.Is(err, ErrRoleNotFound) {
+			http.Error(w, problem.New(problem.Title("role not found")).JSONString(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToRoleModel(role))
+	}
+}
+
+// HandleCreateRole creates a new custom role, the name of the role is derived from the title
+func (a *RoleRestHandlers) HandleCreateRole() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var roleModel roleModel
+		err := json.NewDecoder(r.Body).Decode(&roleModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(roleModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("role not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		role, err := userService.CreateRole(r.Context(), principal, mapToRole(&roleModel))
+		if errors.Is(err, ErrInvalidRole) {
+			http.Error(w, problem.New(problem.Title("role not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			http.Error(w, problem.New(problem.Title(ErrPermissionDenied.Error())).JSONString(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		shared.RenderJSON(w, mapToRoleModel(role))
+	}
+}
+
+// HandleUpdateRole changes title and permissions of a custom role
+func (a *RoleRestHandlers) HandleUpdateRole() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "role")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var roleModel roleModel
+		err := json.NewDecoder(r.Body).Decode(&roleModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(roleModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("role not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		role := mapToRole(&roleModel)
+		role.Name = name
+
+		role, err = userService.UpdateRole(r.Context(), principal, role)
+		if errors.Is(err, ErrRoleNotFound) {
+			http.Error(w, problem.New(problem.Title("role not found")).JSONString(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidRole) {
+			http.Error(w, problem.New(problem.Title("role not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			http.Error(w, problem.New(problem.Title(ErrPermissionDenied.Error())).JSONString(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToRoleModel(role))
+	}
+}
+
+// HandleDeleteRole deletes a custom role, members with the role become users
+func (a *RoleRestHandlers) HandleDeleteRole() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "role")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		err := userService.DeleteRole(r.Context(), principal, name)
+		if errors.Is(err, ErrRoleNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+	}
+}
+
+func mapToRole(roleModel *roleModel) *OrganizationRole {
+	return &OrganizationRole{
+		Title:       roleModel.Title,
+		Permissions: roleModel.Permissions,
+	}
+}
+
+func mapToRoleModel(role *OrganizationRole) *roleModel {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	roleModel := &roleModel{
+		Name:        role.Name,
+		Title:       role.Title,
+		Permissions: permissions,
+		BuiltIn:     role.BuiltIn,
+	}
+
+	selfLink := hal.NewSelfLink(fmt.Sprintf("/api/roles/%s", roleModel.Name))
+	if role.BuiltIn {
+		roleModel.Links = hal.NewLinks(selfLink)
+	} else {
+		roleModel.Links = hal.NewLinks(
+			selfLink,
+			hal.NewLink("edit", selfLink.Href()),
+			hal.NewLink("delete", selfLink.Href()),
+		)
+	}
+
+	return roleModel
+}
@@ -0,0 +1,148 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetRoles(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &RoleRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/roles", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleGetRoles()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	rolesModel := &rolesModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(rolesModel)
+	is.NoErr(err)
+	is.Equal(len(rolesModel.RoleModels), 3)
+	is.Equal(rolesModel.RoleModels[1].Name, RoleManager)
+	is.True(rolesModel.RoleModels[1].BuiltIn)
+	is.Equal(rolesModel.RoleModels[1].Permissions, shared.RolePermissions[RoleManager])
+}
+
+func TestHandleCreateRole(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	roleRepository := NewInMemRoleRepository()
+
+	a := &RoleRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			roleRepository: roleRepository,
+		},
+	}
+
+	body := `{"title": "Controller", "permissions": ["activities:read:all"]}`
+	r, _ := http.NewRequest("POST", "/api/roles", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleCreateRole()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+
+	roleModel := &roleModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(roleModel)
+	is.NoErr(err)
+	is.Equal(roleModel.Name, "ROLE_CONTROLLER")
+	is.Equal(roleModel.Permissions, []string{shared.PermissionActivitiesReadAll})
+	is.Equal(len(roleRepository.roles), 1)
+}
+
+func TestHandleCreateRoleWithUnknownPermission(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &RoleRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	body := `{"title": "Controller", "permissions": ["activities:delete:all"]}`
+	r, _ := http.NewRequest("POST", "/api/roles", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleCreateRole()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
+
+func TestHandleCreateRoleAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &RoleRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	body := `{"title": "Controller"}`
+	r, _ := http.NewRequest("POST", "/api/roles", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleCreateRole()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleDeleteBuiltInRole(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &RoleRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: NewInMemUserRepository(),
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("DELETE", "/api/roles/ROLE_USER", nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("role", RoleUser)
+
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleDeleteRole()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
@@ -0,0 +1,484 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+// permissionTitles describe the permissions in the role form
+var permissionTitles = map[string]string{
+	shared.PermissionActivitiesReadAll:  "See activities and reports of all members",
+	shared.PermissionActivitiesWriteAll: "Edit and delete activities of all members",
+	shared.PermissionReportsReadTeam:    "See activities and reports of the led teams",
+	shared.PermissionProjectsWrite:      "Manage projects",
+	shared.PermissionHolidaysWrite:      "Manage holidays",
+	shared.PermissionUsersWrite:         "Manage users and invitations",
+	shared.PermissionTeamsWrite:         "Manage teams",
+	shared.PermissionOrganizationWrite:  "Manage organization settings and roles",
+}
+
+type roleFormModel struct {
+	CSRFToken   string
+	Name        string
+	Title       string `validate:"required,max=50"`
+	Permissions []string
+}
+
+type RoleWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewRoleWebHandlers(config *shared.Config, userService *UserService) *RoleWebHandlers {
+	return &RoleWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *RoleWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/roles", a.HandleRolesPage())
+	r.Get("/roles/new", a.HandleRoleAddPage())
+	r.Get("/roles/{role}/edit", a.HandleRoleEditPage())
+	r.Post("/roles/new", a.HandleRoleForm())
+	r.Post("/roles/{role}", a.HandleRoleForm())
+	r.Post("/roles/{role}/delete", a.HandleDeleteRole())
+}
+
+func (a *RoleWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleRolesPage shows the built-in and custom roles of the organization
+func (a *RoleWebHandlers) HandleRolesPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		roles, err := userService.ReadRoles(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Roles",
+			}
+			shared.RenderHTML(w, RolesPage(pageContext, csrf.Token(r), roles))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, RolesView(csrf.Token(r), roles))
+	}
+}
+
+// HandleRoleAddPage shows the form for a new custom role
+func (a *RoleWebHandlers) HandleRoleAddPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		formModel := roleFormModel{CSRFToken: csrf.Token(r)}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, RoleForm(formModel, nil))
+	}
+}
+
+// HandleRoleEditPage shows the form to change title and permissions of a custom role
+func (a *RoleWebHandlers) HandleRoleEditPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "role")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		role, err := userService.ReadRole(r.Context(), principal, name)
+		if errors.Is(err, ErrRoleNotFound) || (err == nil && role.BuiltIn) {
+			http.Error(w, ErrRoleNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := roleFormModel{
+			CSRFToken:   csrf.Token(r),
+			Name:        role.Name,
+			Title:       role.Title,
+			Permissions: role.Permissions,
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, RoleForm(formModel, nil))
+	}
+}
+
+// HandleRoleForm creates a new or saves an existing custom role and shows the roles again
+func (a *RoleWebHandlers) HandleRoleForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var formModel roleFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		formModel.CSRFToken = csrf.Token(r)
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			shared.RenderHTML(w, RoleForm(formModel, map[string]string{"Title": "Title must have 1 to 50 characters."}))
+			return
+		}
+
+		role := &OrganizationRole{
+			Name:        formModel.Name,
+			Title:       formModel.Title,
+			Permissions: formModel.Permissions,
+		}
+
+		if formModel.Name == "" {
+			_, err = userService.CreateRole(r.Context(), principal, role)
+		} else {
+			_, err = userService.UpdateRole(r.Context(), principal, role)
+		}
+		if errors.Is(err, ErrRoleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidRole) {
+			shared.RenderHTML(w, RoleForm(formModel, map[string]string{"Title": "Title is not valid or a role with this title already exists."}))
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			shared.RenderHTML(w, RoleForm(formModel, map[string]string{"Permissions": "You can only grant permissions you have yourself."}))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		roles, err := userService.ReadRoles(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, RolesView(csrf.Token(r), roles))
+	}
+}
+
+// HandleDeleteRole deletes a custom role and shows the remaining roles
+func (a *RoleWebHandlers) HandleDeleteRole() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "role")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionOrganizationWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		err := userService.DeleteRole(r.Context(), principal, name)
+		if errors.Is(err, ErrRoleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		roles, err := userService.ReadRoles(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, RolesView(csrf.Token(r), roles))
+	}
+}
+
+func RolesPage(pageContext *shared.PageContext, csrfToken string, roles []*OrganizationRole) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					RolesView(csrfToken, roles),
+				),
+			),
+		},
+	)
+}
+
+func RolesView(csrfToken string, roles []*OrganizationRole) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Roles"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Div(
+				Class("d-flex justify-content-end mb-3"),
+				A(
+					ghx.Get("/roles/new"),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					Class("btn btn-outline-primary btn-sm"),
+					I(Class("bi-plus me-2")),
+					g.Text("New Role"),
+				),
+			),
+			Table(
+				Class("table table-sm table-borderless align-middle"),
+				TBody(
+					g.Group(
+						g.Map(roles, func(role *OrganizationRole) g.Node {
+							return RoleRow(csrfToken, role)
+						}),
+					),
+				),
+			),
+		),
+	)
+}
+
+func RoleRow(csrfToken string, role *OrganizationRole) g.Node {
+	return Tr(
+		Td(
+			Class("w-100"),
+			Div(
+				g.Text(role.Title),
+				g.If(role.BuiltIn,
+					Span(
+						Class("badge text-bg-secondary ms-2"),
+						g.Text("Built-in"),
+					),
+				),
+			),
+			Small(
+				Class("text-muted"),
+				g.Text(fmt.Sprintf("%v permissions", len(role.Permissions))),
+			),
+		),
+		Td(
+			Class("text-nowrap"),
+			g.If(!role.BuiltIn,
+				g.Group([]g.Node{
+					A(
+						ghx.Get(fmt.Sprintf("/roles/%v/edit", role.Name)),
+						ghx.Target("#baralga__main_content_modal_content"),
+						ghx.Swap("outerHTML"),
+						Class("btn btn-outline-secondary btn-sm me-1"),
+						TitleAttr(fmt.Sprintf("Edit %v", role.Title)),
+						I(Class("bi-pen")),
+					),
+					FormEl(
+						Class("d-inline"),
+						ghx.Post(fmt.Sprintf("/roles/%v/delete", role.Name)),
+						ghx.Target("#baralga__main_content_modal_content"),
+						ghx.Swap("outerHTML"),
+						ghx.Confirm(fmt.Sprintf("Do you really want to delete the role %v? Members with the role become users.", role.Title)),
+
+						Input(
+							Type("hidden"),
+							Name("CSRFToken"),
+							Value(csrfToken),
+						),
+						Button(
+							Class("btn btn-outline-secondary btn-sm"),
+							TitleAttr(fmt.Sprintf("Delete %v", role.Title)),
+							I(Class("bi-trash2")),
+						),
+					),
+				}),
+			),
+		),
+	)
+}
+
+func RoleForm(formModel roleFormModel, fieldErrors map[string]string) g.Node {
+	action := "/roles/new"
+	title := "New Role"
+	if formModel.Name != "" {
+		action = fmt.Sprintf("/roles/%v", formModel.Name)
+		title = "Edit Role"
+	}
+
+	return FormEl(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		ghx.Post(action),
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text(title),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Input(
+				Type("hidden"),
+				Name("CSRFToken"),
+				Value(formModel.CSRFToken),
+			),
+			Input(
+				Type("hidden"),
+				Name("Name"),
+				Value(formModel.Name),
+			),
+			Div(
+				Class("form-floating mb-3"),
+				Input(
+					ID("role_Title"),
+					Required(),
+					Type("text"),
+					Name("Title"),
+					MaxLength("50"),
+					organizationControlClass("form-control", "Title", fieldErrors),
+					g.Attr("placeholder", "Title"),
+					Value(formModel.Title),
+				),
+				Label(
+					g.Attr("for", "role_Title"),
+					g.Text("Title"),
+				),
+				organizationFieldError("Title", fieldErrors),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label d-block"),
+					g.Text("Permissions"),
+				),
+				g.Group(
+					g.Map(shared.Permissions, func(permission string) g.Node {
+						checkboxID := fmt.Sprintf("role_Permissions_%v", permission)
+						return Div(
+							Class("form-check"),
+							Input(
+								ID(checkboxID),
+								Type("checkbox"),
+								Name("Permissions"),
+								Value(permission),
+								organizationControlClass("form-check-input", "Permissions", fieldErrors),
+								g.If(slices.Contains(formModel.Permissions, permission), Checked()),
+							),
+							Label(
+								Class("form-check-label"),
+								g.Attr("for", checkboxID),
+								g.Text(permissionTitles[permission]),
+							),
+						)
+					}),
+				),
+				g.If(
+					fieldErrors["Permissions"] != "",
+					Div(
+						Class("invalid-feedback d-block"),
+						g.Text(fieldErrors["Permissions"]),
+					),
+				),
+				Div(
+					Class("form-text"),
+					g.Text("Members with the role are granted changed permissions when they sign in again."),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("submit"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-save me-2")),
+				g.Text("Save"),
+			),
+			A(
+				ghx.Get("/roles"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("text-center btn btn-secondary"),
+				I(Class("bi-x me-2")),
+				g.Text("Cancel"),
+			),
+		),
+	)
+}
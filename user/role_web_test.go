@@ -0,0 +1,164 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/matryer/is"
+)
+
+func TestHandleRolesPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	roleRepository := NewInMemRoleRepository()
+	roleRepository.roles = append(roleRepository.roles, &OrganizationRole{
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "ROLE_CONTROLLER",
+		Title:          "Controller",
+		Permissions:    []string{shared.PermissionActivitiesReadAll},
+	})
+
+	a := &RoleWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			roleRepository: roleRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/roles", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleRolesPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Manager"))
+	is.True(strings.Contains(htmlBody, "Controller"))
+	is.True(strings.Contains(htmlBody, "/roles/ROLE_CONTROLLER/edit"))
+	is.True(!strings.Contains(htmlBody, "/roles/ROLE_ADMIN/edit"))
+}
+
+func TestHandleRolesPageAsManager(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &RoleWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/roles", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleManager},
+	}))
+
+	a.HandleRolesPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleRoleForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	roleRepository := NewInMemRoleRepository()
+
+	a := &RoleWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			roleRepository: roleRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Title"] = []string{"Controller"}
+	data["Permissions"] = []string{shared.PermissionActivitiesReadAll, shared.PermissionReportsReadTeam}
+
+	r, _ := http.NewRequest("POST", "/roles/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleRoleForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(strings.Contains(httpRec.Body.String(), "Controller"))
+
+	is.Equal(len(roleRepository.roles), 1)
+	is.Equal(roleRepository.roles[0].Name, "ROLE_CONTROLLER")
+	is.Equal(roleRepository.roles[0].Permissions, []string{shared.PermissionActivitiesReadAll, shared.PermissionReportsReadTeam})
+}
+
+func TestHandleRoleFormWithoutTitle(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	roleRepository := NewInMemRoleRepository()
+
+	a := &RoleWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			roleRepository: roleRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Title"] = []string{""}
+
+	r, _ := http.NewRequest("POST", "/roles/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleRoleForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(strings.Contains(httpRec.Body.String(), "Title must have 1 to 50 characters."))
+	is.Equal(len(roleRepository.roles), 0)
+}
+
+func TestHandleRoleEditPageOfBuiltInRole(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &RoleWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/roles/ROLE_ADMIN/edit", nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("role", RoleAdmin)
+
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleRoleEditPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
@@ -0,0 +1,1103 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// schemas of SCIM 2.0 (RFC 7643 and RFC 7644)
+const (
+	scimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// scimContentType is the media type of SCIM requests and responses
+const scimContentType = "application/scim+json"
+
+// scimFilterPattern matches the only filter supported, an attribute equal to a value like userName eq "john@baralga.com"
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*([a-z.]+)\s+eq\s+"([^"]*)"\s*$`)
+
+// scimMemberFilterPattern matches the path to remove a single member from a group like members[value eq "..."]
+var scimMemberFilterPattern = regexp.MustCompile(`(?i)^members\[value\s+eq\s+"([^"]*)"\]$`)
+
+type scimUserModel struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id,omitempty"`
+	UserName    string            `json:"userName"`
+	Name        *scimNameModel    `json:"name,omitempty"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Emails      []*scimEmailModel `json:"emails,omitempty"`
+	Active      *bool             `json:"active,omitempty"`
+	Meta        *scimMetaModel    `json:"meta,omitempty"`
+}
+
+type scimNameModel struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmailModel struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimGroupModel struct {
+	Schemas     []string           `json:"schemas"`
+	ID          string             `json:"id,omitempty"`
+	DisplayName string             `json:"displayName"`
+	Members     []*scimMemberModel `json:"members"`
+	Meta        *scimMetaModel     `json:"meta,omitempty"`
+}
+
+type scimMemberModel struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimMetaModel struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type scimListModel struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type scimPatchModel struct {
+	Schemas    []string              `json:"schemas"`
+	Operations []*scimPatchOperation `json:"Operations"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type scimErrorModel struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// scimRoleGroup is a group of the identity provider whose members are granted the role
+type scimRoleGroup struct {
+	DisplayName string
+	Role        string
+}
+
+type SCIMRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+	roleGroups  []*scimRoleGroup
+}
+
+func NewSCIMRestHandlers(config *shared.Config, userService *UserService) *SCIMRestHandlers {
+	return &SCIMRestHandlers{
+		config:      config,
+		userService: userService,
+		roleGroups:  parseSCIMRoleGroups(config.SCIMGroupRoles),
+	}
+}
+
+// parseSCIMRoleGroups reads the group roles like baralga-admins:ROLE_ADMIN,baralga-managers:ROLE_MANAGER,
+// the role is the id of the group so that only the first group of a role is kept
+func parseSCIMRoleGroups(groupRoles string) []*scimRoleGroup {
+	var roleGroups []*scimRoleGroup
+	for _, groupRole := range strings.Split(groupRoles, ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(groupRole), ":")
+		if !ok || group == "" || role == "" {
+			continue
+		}
+
+		if slices.ContainsFunc(roleGroups, func(roleGroup *scimRoleGroup) bool { return roleGroup.Role == role }) {
+			continue
+		}
+
+		roleGroups = append(roleGroups, &scimRoleGroup{DisplayName: group, Role: role})
+	}
+	return roleGroups
+}
+
+func (a *SCIMRestHandlers) RegisterProtected(r chi.Router) {
+}
+
+func (a *SCIMRestHandlers) RegisterScoped(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(shared.RequireScope(shared.ScopeSCIM))
+		r.Use(requireSCIMPermissions)
+
+		r.Get("/ServiceProviderConfig", a.HandleGetServiceProviderConfig())
+
+		r.Get("/Users", a.HandleGetUsers())
+		r.Post("/Users", a.HandleCreateUser())
+		r.Get("/Users/{user-id}", a.HandleGetUser())
+		r.Put("/Users/{user-id}", a.HandleReplaceUser())
+		r.Patch("/Users/{user-id}", a.HandlePatchUser())
+		r.Delete("/Users/{user-id}", a.HandleDeleteUser())
+
+		r.Get("/Groups", a.HandleGetGroups())
+		r.Post("/Groups", a.HandleCreateGroup())
+		r.Get("/Groups/{group-id}", a.HandleGetGroup())
+		r.Put("/Groups/{group-id}", a.HandleReplaceGroup())
+		r.Patch("/Groups/{group-id}", a.HandlePatchGroup())
+		r.Delete("/Groups/{group-id}", a.HandleDeleteGroup())
+	})
+}
+
+func (a *SCIMRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// requireSCIMPermissions rejects principals who may not manage the members and teams of the organization
+func requireSCIMPermissions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+		if !principal.HasPermission(shared.PermissionUsersWrite) || !principal.HasPermission(shared.PermissionTeamsWrite) {
+			renderSCIMError(w, http.StatusForbidden, "", "provisioning requires the permissions to manage users and teams")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleGetServiceProviderConfig describes the supported features of the SCIM api
+func (a *SCIMRestHandlers) HandleGetServiceProviderConfig() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serviceProviderConfig := map[string]interface{}{
+			"schemas":        []string{scimSchemaServiceProviderConfig},
+			"patch":          map[string]bool{"supported": true},
+			"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+			"filter":         map[string]interface{}{"supported": true, "maxResults": 1000},
+			"changePassword": map[string]bool{"supported": false},
+			"sort":           map[string]bool{"supported": false},
+			"etag":           map[string]bool{"supported": false},
+			"authenticationSchemes": []map[string]string{
+				{
+					"type":        "oauthbearertoken",
+					"name":        "API Token",
+					"description": "Personal api token of an admin, optionally restricted to the scope scim",
+				},
+			},
+		}
+
+		renderSCIM(w, http.StatusOK, serviceProviderConfig)
+	}
+}
+
+// HandleGetUsers reads the members of the organization, optionally filtered by userName
+func (a *SCIMRestHandlers) HandleGetUsers() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		attribute, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+		if err != nil || (attribute != "" && !strings.EqualFold(attribute, "userName")) {
+			renderSCIMError(w, http.StatusBadRequest, "invalidFilter", "only filters like userName eq \"...\" are supported")
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		resources := make([]interface{}, 0, len(users))
+		for _, user := range users {
+			if attribute != "" && !strings.EqualFold(user.Username, value) {
+				continue
+			}
+			resources = append(resources, a.mapToSCIMUserModel(user))
+		}
+
+		renderSCIM(w, http.StatusOK, newSCIMListModel(r, resources))
+	}
+}
+
+// HandleGetUser reads a member of the organization
+func (a *SCIMRestHandlers) HandleGetUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+		if err != nil {
+			renderSCIMError(w, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		user, err := userService.ReadUser(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			renderSCIMError(w, http.StatusNotFound, "", "user not found")
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		renderSCIM(w, http.StatusOK, a.mapToSCIMUserModel(user))
+	}
+}
+
+// HandleCreateUser sets up a user of the identity provider as member of the organization
+func (a *SCIMRestHandlers) HandleCreateUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var userModel scimUserModel
+		err := json.NewDecoder(r.Body).Decode(&userModel)
+		if err != nil {
+			renderSCIMError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		userName := strings.TrimSpace(userModel.UserName)
+		if userName == "" {
+			renderSCIMError(w, http.StatusBadRequest, "invalidValue", "userName is required")
+			return
+		}
+
+		user := &User{
+			Username: userName,
+			EMail:    userModel.email(),
+			Name:     userModel.fullName(),
+		}
+
+		user, err = userService.ProvisionUser(r.Context(), principal, user)
+		if err == nil && userModel.Active != nil && !*userModel.Active {
+			user, err = userService.UpdateProvisionedUser(r.Context(), principal, user.ID, user.Name, false)
+		}
+		if errors.Is(err, ErrUserExists) {
+			renderSCIMError(w, http.StatusConflict, "uniqueness", "user already exists")
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		renderSCIM(w, http.StatusCreated, a.mapToSCIMUserModel(user))
+	}
+}
+
+// HandleReplaceUser changes name and active state of a member of the organization
+func (a *SCIMRestHandlers) HandleReplaceUser() http.HandlerFunc {
+	return a.handleUpdateUser(func(user *User, r *http.Request) (string, bool, error) {
+		var userModel scimUserModel
+		err := json.NewDecoder(r.Body).Decode(&userModel)
+		if err != nil {
+			return "", false, err
+		}
+
+		if userModel.UserName != "" && !strings.EqualFold(strings.TrimSpace(userModel.UserName), user.Username) {
+			return "", false, errSCIMMutability
+		}
+
+		enabled := user.Enabled
+		if userModel.Active != nil {
+			enabled = *userModel.Active
+		}
+
+		return userModel.fullName(), enabled, nil
+	})
+}
+
+// HandlePatchUser changes name and active state of a member of the organization with patch operations,
+// changes of attributes which aren't kept are ignored
+func (a *SCIMRestHandlers) HandlePatchUser() http.HandlerFunc {
+	return a.handleUpdateUser(func(user *User, r *http.Request) (string, bool, error) {
+		var patchModel scimPatchModel
+		err := json.NewDecoder(r.Body).Decode(&patchModel)
+		if err != nil {
+			return "", false, err
+		}
+
+		changes := &scimUserChanges{}
+		for _, operation := range patchModel.Operations {
+			if strings.EqualFold(operation.Op, "remove") {
+				continue
+			}
+
+			err := changes.apply(operation.Path, operation.Value)
+			if err != nil {
+				return "", false, err
+			}
+		}
+
+		name := user.Name
+		if changes.nameChanged && changes.name.fullName() != "" {
+			name = changes.name.fullName()
+		}
+		if changes.displayName != nil && strings.TrimSpace(*changes.displayName) != "" {
+			name = *changes.displayName
+		}
+
+		enabled := user.Enabled
+		if changes.active != nil {
+			enabled = *changes.active
+		}
+
+		return name, enabled, nil
+	})
+}
+
+// errSCIMMutability is returned if an attribute which can't be changed is changed
+var errSCIMMutability = errors.New("userName can't be changed")
+
+func (a *SCIMRestHandlers) handleUpdateUser(readChanges func(user *User, r *http.Request) (string, bool, error)) http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+		if err != nil {
+			renderSCIMError(w, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		user, err := userService.ReadUser(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			renderSCIMError(w, http.StatusNotFound, "", "user not found")
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		name, enabled, err := readChanges(user, r)
+		if errors.Is(err, errSCIMMutability) {
+			renderSCIMError(w, http.StatusBadRequest, "mutability", err.Error())
+			return
+		}
+		if err != nil {
+			renderSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+
+		user, err = userService.UpdateProvisionedUser(r.Context(), principal, userID, name, enabled)
+		if errors.Is(err, ErrPermissionDenied) {
+			renderSCIMError(w, http.StatusForbidden, "", ErrPermissionDenied.Error())
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			renderSCIMError(w, http.StatusConflict, "", ErrLastAdmin.Error())
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		renderSCIM(w, http.StatusOK, a.mapToSCIMUserModel(user))
+	}
+}
+
+// HandleDeleteUser removes a member from the organization
+func (a *SCIMRestHandlers) HandleDeleteUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		userID, err := uuid.Parse(chi.URLParam(r, "user-id"))
+		if err != nil {
+			renderSCIMError(w, http.StatusNotFound, "", "user not found")
+			return
+		}
+
+		err = userService.DeleteUser(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			renderSCIMError(w, http.StatusNotFound, "", "user not found")
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			renderSCIMError(w, http.StatusForbidden, "", ErrPermissionDenied.Error())
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			renderSCIMError(w, http.StatusConflict, "", ErrLastAdmin.Error())
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleGetGroups reads the groups mapped to roles and the teams of the organization, optionally filtered by displayName
+func (a *SCIMRestHandlers) HandleGetGroups() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		attribute, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+		if err != nil || (attribute != "" && !strings.EqualFold(attribute, "displayName")) {
+			renderSCIMError(w, http.StatusBadRequest, "invalidFilter", "only filters like displayName eq \"...\" are supported")
+			return
+		}
+
+		groupModels, err := a.readGroups(r, principal)
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		resources := make([]interface{}, 0, len(groupModels))
+		for _, groupModel := range groupModels {
+			if attribute != "" && !strings.EqualFold(groupModel.DisplayName, value) {
+				continue
+			}
+			resources = append(resources, groupModel)
+		}
+
+		renderSCIM(w, http.StatusOK, newSCIMListModel(r, resources))
+	}
+}
+
+// HandleGetGroup reads a group mapped to a role or a team of the organization
+func (a *SCIMRestHandlers) HandleGetGroup() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		groupModel, err := a.readGroup(r, principal, chi.URLParam(r, "group-id"))
+		if errors.Is(err, ErrTeamNotFound) {
+			renderSCIMError(w, http.StatusNotFound, "", "group not found")
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		renderSCIM(w, http.StatusOK, groupModel)
+	}
+}
+
+// HandleCreateGroup grants the role of a mapped group to its members, other groups are set up as teams
+func (a *SCIMRestHandlers) HandleCreateGroup() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		var groupModel scimGroupModel
+		err := json.NewDecoder(r.Body).Decode(&groupModel)
+		if err != nil {
+			renderSCIMError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		memberIDs, err := parseSCIMMemberIDs(groupModel.Members)
+		if err != nil {
+			renderSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+
+		groupID := ""
+		if roleGroup := a.roleGroupByDisplayName(groupModel.DisplayName); roleGroup != nil {
+			// the role group exists already, its new members are granted the role
+			var existingGroupModel *scimGroupModel
+			existingGroupModel, err = a.readGroup(r, principal, roleGroup.Role)
+			if err == nil {
+				memberIDs = appendSCIMMemberIDs(mustParseSCIMMemberIDs(existingGroupModel.Members), memberIDs...)
+				err = userService.UpdateRoleMembers(r.Context(), principal, roleGroup.Role, memberIDs)
+			}
+			groupID = roleGroup.Role
+		} else {
+			var teams []*Team
+			teams, err = userService.ReadTeams(r.Context(), principal)
+			if err == nil && slices.ContainsFunc(teams, func(team *Team) bool { return strings.EqualFold(team.Name, strings.TrimSpace(groupModel.DisplayName)) }) {
+				renderSCIMError(w, http.StatusConflict, "uniqueness", "group already exists")
+				return
+			}
+
+			var team *Team
+			if err == nil {
+				team, err = userService.CreateTeam(r.Context(), principal, &Team{Name: groupModel.DisplayName, MemberIDs: memberIDs})
+			}
+			if team != nil {
+				groupID = team.ID.String()
+			}
+		}
+		if errors.Is(err, ErrInvalidTeam) || errors.Is(err, ErrInvalidRole) || errors.Is(err, ErrUserNotFound) {
+			renderSCIMError(w, http.StatusBadRequest, "invalidValue", "group not valid")
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		createdGroupModel, err := a.readGroup(r, principal, groupID)
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		renderSCIM(w, http.StatusCreated, createdGroupModel)
+	}
+}
+
+// HandleReplaceGroup sets the name and the members of a group
+func (a *SCIMRestHandlers) HandleReplaceGroup() http.HandlerFunc {
+	return a.handleUpdateGroup(func(groupModel *scimGroupModel, r *http.Request) (string, []uuid.UUID, error) {
+		var replacedGroupModel scimGroupModel
+		err := json.NewDecoder(r.Body).Decode(&replacedGroupModel)
+		if err != nil {
+			return "", nil, err
+		}
+
+		memberIDs, err := parseSCIMMemberIDs(replacedGroupModel.Members)
+		if err != nil {
+			return "", nil, err
+		}
+
+		displayName := replacedGroupModel.DisplayName
+		if displayName == "" {
+			displayName = groupModel.DisplayName
+		}
+
+		return displayName, memberIDs, nil
+	})
+}
+
+// HandlePatchGroup adds, removes or replaces the members of a group or changes its name with patch operations
+func (a *SCIMRestHandlers) HandlePatchGroup() http.HandlerFunc {
+	return a.handleUpdateGroup(func(groupModel *scimGroupModel, r *http.Request) (string, []uuid.UUID, error) {
+		var patchModel scimPatchModel
+		err := json.NewDecoder(r.Body).Decode(&patchModel)
+		if err != nil {
+			return "", nil, err
+		}
+
+		displayName := groupModel.DisplayName
+		memberIDs := mustParseSCIMMemberIDs(groupModel.Members)
+		for _, operation := range patchModel.Operations {
+			displayName, memberIDs, err = applySCIMGroupPatch(operation, displayName, memberIDs)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		return displayName, memberIDs, nil
+	})
+}
+
+func (a *SCIMRestHandlers) handleUpdateGroup(readChanges func(groupModel *scimGroupModel, r *http.Request) (string, []uuid.UUID, error)) http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+		groupID := chi.URLParam(r, "group-id")
+
+		groupModel, err := a.readGroup(r, principal, groupID)
+		if errors.Is(err, ErrTeamNotFound) {
+			renderSCIMError(w, http.StatusNotFound, "", "group not found")
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		displayName, memberIDs, err := readChanges(groupModel, r)
+		if err != nil {
+			renderSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+
+		if roleGroup := a.roleGroupByID(groupID); roleGroup != nil {
+			err = userService.UpdateRoleMembers(r.Context(), principal, roleGroup.Role, memberIDs)
+		} else {
+			var team *Team
+			team, err = userService.ReadTeam(r.Context(), principal, uuid.MustParse(groupID))
+			if err == nil {
+				team.Name = displayName
+				team.MemberIDs = memberIDs
+				_, err = userService.UpdateTeam(r.Context(), principal, team)
+			}
+		}
+		if errors.Is(err, ErrInvalidTeam) || errors.Is(err, ErrInvalidRole) || errors.Is(err, ErrUserNotFound) {
+			renderSCIMError(w, http.StatusBadRequest, "invalidValue", "group not valid")
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			renderSCIMError(w, http.StatusForbidden, "", ErrPermissionDenied.Error())
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			renderSCIMError(w, http.StatusConflict, "", ErrLastAdmin.Error())
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		groupModel, err = a.readGroup(r, principal, groupID)
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		renderSCIM(w, http.StatusOK, groupModel)
+	}
+}
+
+// HandleDeleteGroup deletes a team, the members of a group mapped to a role are left with the user role
+func (a *SCIMRestHandlers) HandleDeleteGroup() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+		groupID := chi.URLParam(r, "group-id")
+
+		_, err := a.readGroup(r, principal, groupID)
+		if err == nil {
+			if roleGroup := a.roleGroupByID(groupID); roleGroup != nil {
+				err = userService.UpdateRoleMembers(r.Context(), principal, roleGroup.Role, nil)
+			} else {
+				err = userService.DeleteTeam(r.Context(), principal, uuid.MustParse(groupID))
+			}
+		}
+		if errors.Is(err, ErrTeamNotFound) {
+			renderSCIMError(w, http.StatusNotFound, "", "group not found")
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			renderSCIMError(w, http.StatusForbidden, "", ErrPermissionDenied.Error())
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			renderSCIMError(w, http.StatusConflict, "", ErrLastAdmin.Error())
+			return
+		}
+		if err != nil {
+			renderSCIMProblem(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// readGroups reads the groups mapped to roles followed by the teams of the organization
+func (a *SCIMRestHandlers) readGroups(r *http.Request, principal *shared.Principal) ([]*scimGroupModel, error) {
+	users, err := a.userService.ReadUsers(r.Context(), principal)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := a.userService.ReadTeams(r.Context(), principal)
+	if err != nil {
+		return nil, err
+	}
+
+	groupModels := make([]*scimGroupModel, 0, len(a.roleGroups)+len(teams))
+	for _, roleGroup := range a.roleGroups {
+		var memberIDs []uuid.UUID
+		for _, user := range users {
+			if slices.Contains(user.Roles, roleGroup.Role) {
+				memberIDs = append(memberIDs, user.ID)
+			}
+		}
+		groupModels = append(groupModels, a.mapToSCIMGroupModel(roleGroup.Role, roleGroup.DisplayName, memberIDs, users))
+	}
+
+	for _, team := range teams {
+		groupModels = append(groupModels, a.mapToSCIMGroupModel(team.ID.String(), team.Name, team.MemberIDs, users))
+	}
+
+	return groupModels, nil
+}
+
+// readGroup reads a group mapped to a role by the role or a team by its id
+func (a *SCIMRestHandlers) readGroup(r *http.Request, principal *shared.Principal, groupID string) (*scimGroupModel, error) {
+	groupModels, err := a.readGroups(r, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, groupModel := range groupModels {
+		if groupModel.ID == groupID {
+			return groupModel, nil
+		}
+	}
+
+	return nil, ErrTeamNotFound
+}
+
+func (a *SCIMRestHandlers) roleGroupByID(groupID string) *scimRoleGroup {
+	for _, roleGroup := range a.roleGroups {
+		if roleGroup.Role == groupID {
+			return roleGroup
+		}
+	}
+	return nil
+}
+
+func (a *SCIMRestHandlers) roleGroupByDisplayName(displayName string) *scimRoleGroup {
+	for _, roleGroup := range a.roleGroups {
+		if strings.EqualFold(roleGroup.DisplayName, strings.TrimSpace(displayName)) {
+			return roleGroup
+		}
+	}
+	return nil
+}
+
+func (a *SCIMRestHandlers) mapToSCIMUserModel(user *User) *scimUserModel {
+	active := user.Enabled
+	userModel := &scimUserModel{
+		Schemas:     []string{scimSchemaUser},
+		ID:          user.ID.String(),
+		UserName:    user.Username,
+		Name:        &scimNameModel{Formatted: user.Name},
+		DisplayName: user.Name,
+		Active:      &active,
+		Meta: &scimMetaModel{
+			ResourceType: "User",
+			Location:     fmt.Sprintf("%v/scim/v2/Users/%v", a.config.Webroot, user.ID),
+		},
+	}
+
+	if user.EMail != "" {
+		userModel.Emails = []*scimEmailModel{{Value: user.EMail, Type: "work", Primary: true}}
+	}
+
+	return userModel
+}
+
+func (a *SCIMRestHandlers) mapToSCIMGroupModel(groupID, displayName string, memberIDs []uuid.UUID, users []*User) *scimGroupModel {
+	memberModels := make([]*scimMemberModel, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		memberModel := &scimMemberModel{Value: memberID.String()}
+		for _, user := range users {
+			if user.ID == memberID {
+				memberModel.Display = user.Name
+			}
+		}
+		memberModels = append(memberModels, memberModel)
+	}
+
+	return &scimGroupModel{
+		Schemas:     []string{scimSchemaGroup},
+		ID:          groupID,
+		DisplayName: displayName,
+		Members:     memberModels,
+		Meta: &scimMetaModel{
+			ResourceType: "Group",
+			Location:     fmt.Sprintf("%v/scim/v2/Groups/%v", a.config.Webroot, groupID),
+		},
+	}
+}
+
+// fullName is the display name, or the formatted name, or given and family name
+func (u *scimUserModel) fullName() string {
+	if strings.TrimSpace(u.DisplayName) != "" {
+		return strings.TrimSpace(u.DisplayName)
+	}
+	if u.Name != nil && u.Name.fullName() != "" {
+		return u.Name.fullName()
+	}
+	return strings.TrimSpace(u.UserName)
+}
+
+// email is the primary email, or the first email, or the userName
+func (u *scimUserModel) email() string {
+	for _, email := range u.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return strings.TrimSpace(u.UserName)
+}
+
+// fullName is the formatted name, or given and family name
+func (n *scimNameModel) fullName() string {
+	if strings.TrimSpace(n.Formatted) != "" {
+		return strings.TrimSpace(n.Formatted)
+	}
+	return strings.TrimSpace(n.GivenName + " " + n.FamilyName)
+}
+
+// scimUserChanges collects the changes of patch operations to the attributes of a user which are kept
+type scimUserChanges struct {
+	displayName *string
+	name        scimNameModel
+	nameChanged bool
+	active      *bool
+}
+
+// apply applies the value to the attribute of the path, or to the attributes of the value without path
+func (c *scimUserChanges) apply(path string, value json.RawMessage) error {
+	if path == "" {
+		var attributes map[string]json.RawMessage
+		err := json.Unmarshal(value, &attributes)
+		if err != nil {
+			return err
+		}
+
+		for attribute, attributeValue := range attributes {
+			err := c.apply(attribute, attributeValue)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch strings.ToLower(strings.TrimPrefix(path, scimSchemaUser+":")) {
+	case "active":
+		active, err := parseSCIMBool(value)
+		if err != nil {
+			return err
+		}
+		c.active = &active
+	case "displayname":
+		var displayName string
+		err := json.Unmarshal(value, &displayName)
+		if err != nil {
+			return err
+		}
+		c.displayName = &displayName
+	case "name":
+		c.nameChanged = true
+		return json.Unmarshal(value, &c.name)
+	case "name.formatted":
+		c.nameChanged = true
+		return json.Unmarshal(value, &c.name.Formatted)
+	case "name.givenname":
+		c.nameChanged = true
+		return json.Unmarshal(value, &c.name.GivenName)
+	case "name.familyname":
+		c.nameChanged = true
+		return json.Unmarshal(value, &c.name.FamilyName)
+	}
+
+	return nil
+}
+
+// parseSCIMBool reads a boolean, some identity providers send booleans as strings like "False"
+func parseSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	err := json.Unmarshal(value, &b)
+	if err == nil {
+		return b, nil
+	}
+
+	var s string
+	err = json.Unmarshal(value, &s)
+	if err != nil {
+		return false, err
+	}
+
+	return strconv.ParseBool(s)
+}
+
+// applySCIMGroupPatch applies the patch operation to name and members of a group
+func applySCIMGroupPatch(operation *scimPatchOperation, displayName string, memberIDs []uuid.UUID) (string, []uuid.UUID, error) {
+	op := strings.ToLower(operation.Op)
+	path := strings.ToLower(operation.Path)
+
+	if matches := scimMemberFilterPattern.FindStringSubmatch(operation.Path); op == "remove" && matches != nil {
+		memberID, err := uuid.Parse(matches[1])
+		if err != nil {
+			return "", nil, err
+		}
+		return displayName, slices.DeleteFunc(memberIDs, func(id uuid.UUID) bool { return id == memberID }), nil
+	}
+
+	if path == "" {
+		var groupModel struct {
+			DisplayName string             `json:"displayName"`
+			Members     []*scimMemberModel `json:"members"`
+		}
+		err := json.Unmarshal(operation.Value, &groupModel)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if groupModel.DisplayName != "" {
+			displayName = groupModel.DisplayName
+		}
+		if groupModel.Members == nil {
+			return displayName, memberIDs, nil
+		}
+
+		operation = &scimPatchOperation{Op: operation.Op, Path: "members"}
+		operation.Value, err = json.Marshal(groupModel.Members)
+		if err != nil {
+			return "", nil, err
+		}
+		path = "members"
+	}
+
+	switch path {
+	case "displayname":
+		if op == "remove" {
+			return "", nil, errors.New("displayName is required")
+		}
+		err := json.Unmarshal(operation.Value, &displayName)
+		return displayName, memberIDs, err
+	case "members":
+		var memberModels []*scimMemberModel
+		if len(operation.Value) > 0 {
+			err := json.Unmarshal(operation.Value, &memberModels)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		changedMemberIDs, err := parseSCIMMemberIDs(memberModels)
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch op {
+		case "add":
+			return displayName, appendSCIMMemberIDs(memberIDs, changedMemberIDs...), nil
+		case "replace":
+			return displayName, changedMemberIDs, nil
+		case "remove":
+			if len(operation.Value) == 0 {
+				return displayName, nil, nil
+			}
+			return displayName, slices.DeleteFunc(memberIDs, func(id uuid.UUID) bool { return slices.Contains(changedMemberIDs, id) }), nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("patch operation %v of %v not supported", operation.Op, operation.Path)
+}
+
+func parseSCIMMemberIDs(memberModels []*scimMemberModel) ([]uuid.UUID, error) {
+	memberIDs := make([]uuid.UUID, 0, len(memberModels))
+	for _, memberModel := range memberModels {
+		memberID, err := uuid.Parse(memberModel.Value)
+		if err != nil {
+			return nil, err
+		}
+		memberIDs = appendSCIMMemberIDs(memberIDs, memberID)
+	}
+	return memberIDs, nil
+}
+
+// mustParseSCIMMemberIDs reads the member ids of a group that was read before
+func mustParseSCIMMemberIDs(memberModels []*scimMemberModel) []uuid.UUID {
+	memberIDs, err := parseSCIMMemberIDs(memberModels)
+	if err != nil {
+		panic(err)
+	}
+	return memberIDs
+}
+
+// appendSCIMMemberIDs appends the member ids which are not contained yet
+func appendSCIMMemberIDs(memberIDs []uuid.UUID, addedMemberIDs ...uuid.UUID) []uuid.UUID {
+	for _, memberID := range addedMemberIDs {
+		if !slices.Contains(memberIDs, memberID) {
+			memberIDs = append(memberIDs, memberID)
+		}
+	}
+	return memberIDs
+}
+
+// parseSCIMFilter reads attribute and value of a filter like userName eq "john@baralga.com", both empty without filter
+func parseSCIMFilter(filter string) (string, string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+
+	matches := scimFilterPattern.FindStringSubmatch(filter)
+	if matches == nil {
+		return "", "", fmt.Errorf("filter %v not supported", filter)
+	}
+
+	return matches[1], matches[2], nil
+}
+
+// newSCIMListModel lists the page of the resources from the 1-based startIndex with up to count resources
+func newSCIMListModel(r *http.Request, resources []interface{}) *scimListModel {
+	startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 0 {
+		count = len(resources)
+	}
+
+	start := min(startIndex-1, len(resources))
+	end := min(start+count, len(resources))
+
+	return &scimListModel{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: end - start,
+		Resources:    resources[start:end],
+	}
+}
+
+func renderSCIM(w http.ResponseWriter, status int, scimModel interface{}) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(scimModel)
+	if err != nil {
+		log.Printf("could not write scim response: %s", err)
+	}
+}
+
+func renderSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	renderSCIM(w, status, &scimErrorModel{
+		Schemas:  []string{scimSchemaError},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+func renderSCIMProblem(w http.ResponseWriter, isProduction bool, err error) {
+	log.Printf("internal server error: %s", err)
+
+	detail := "internal server error"
+	if !isProduction {
+		detail = err.Error()
+	}
+	renderSCIMError(w, http.StatusInternalServerError, "", detail)
+}
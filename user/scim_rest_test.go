@@ -0,0 +1,388 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetSCIMUsers(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+	a := newSCIMRestHandlersSample(userRepository, NewInMemTeamRepository())
+
+	t.Run("all users", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleGetUsers()(httpRec, newSCIMRequest("GET", "/scim/v2/Users", "", nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(httpRec.Header().Get("Content-Type"), scimContentType)
+
+		listModel := &scimListModel{}
+		err := json.NewDecoder(httpRec.Body).Decode(listModel)
+		is.NoErr(err)
+		is.Equal(listModel.TotalResults, 2)
+		is.Equal(listModel.StartIndex, 1)
+		is.Equal(len(listModel.Resources), 2)
+	})
+
+	t.Run("users filtered by userName", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleGetUsers()(httpRec, newSCIMRequest("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "USER1@baralga.com"`), "", nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+		listModel := &struct {
+			TotalResults int              `json:"totalResults"`
+			Resources    []*scimUserModel `json:"Resources"`
+		}{}
+		err := json.NewDecoder(httpRec.Body).Decode(listModel)
+		is.NoErr(err)
+		is.Equal(listModel.TotalResults, 1)
+		is.Equal(listModel.Resources[0].UserName, "user1@baralga.com")
+		is.Equal(listModel.Resources[0].DisplayName, "Ulani User")
+		is.True(*listModel.Resources[0].Active)
+	})
+
+	t.Run("users paged", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleGetUsers()(httpRec, newSCIMRequest("GET", "/scim/v2/Users?startIndex=2&count=5", "", nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+		listModel := &scimListModel{}
+		err := json.NewDecoder(httpRec.Body).Decode(listModel)
+		is.NoErr(err)
+		is.Equal(listModel.TotalResults, 2)
+		is.Equal(listModel.StartIndex, 2)
+		is.Equal(listModel.ItemsPerPage, 1)
+	})
+
+	t.Run("unsupported filter", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+
+		a.HandleGetUsers()(httpRec, newSCIMRequest("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName sw "user"`), "", nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+		is.True(strings.Contains(httpRec.Body.String(), "invalidFilter"))
+	})
+}
+
+func TestHandleCreateSCIMUser(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+	a := newSCIMRestHandlersSample(userRepository, NewInMemTeamRepository())
+
+	body := `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "jane@baralga.com",
+		"name": {"givenName": "Jane", "familyName": "Doe"},
+		"emails": [{"value": "jane.doe@baralga.com", "type": "work", "primary": true}],
+		"active": true
+	}`
+
+	httpRec := httptest.NewRecorder()
+	a.HandleCreateUser()(httpRec, newSCIMRequest("POST", "/scim/v2/Users", body, nil))
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+	is.Equal(len(userRepository.users), userCount+1)
+
+	userModel := &scimUserModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(userModel)
+	is.NoErr(err)
+	is.Equal(userModel.UserName, "jane@baralga.com")
+	is.Equal(userModel.DisplayName, "Jane Doe")
+	is.Equal(userModel.Emails[0].Value, "jane.doe@baralga.com")
+	is.Equal(userModel.Meta.Location, "http://localhost:8080/scim/v2/Users/"+userModel.ID)
+	is.Equal(userRepository.users[userCount].Origin, SCIMOrigin)
+
+	httpRec = httptest.NewRecorder()
+	a.HandleCreateUser()(httpRec, newSCIMRequest("POST", "/scim/v2/Users", body, nil))
+	is.Equal(httpRec.Result().StatusCode, http.StatusConflict)
+	is.True(strings.Contains(httpRec.Body.String(), "uniqueness"))
+}
+
+func TestHandlePatchSCIMUser(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	member.Origin = SCIMOrigin
+	a := newSCIMRestHandlersSample(userRepository, NewInMemTeamRepository())
+
+	// identity providers like Entra ID send booleans as strings
+	body := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "replace", "value": {"displayName": "Ulani Provisioned"}},
+			{"op": "add", "path": "title", "value": "Engineer"}
+		]
+	}`
+
+	httpRec := httptest.NewRecorder()
+	a.HandlePatchUser()(httpRec, newSCIMRequest("PATCH", "/scim/v2/Users/"+member.ID.String(), body, map[string]string{"user-id": member.ID.String()}))
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(member.Name, "Ulani Provisioned")
+	is.True(!member.Enabled)
+}
+
+func TestHandleReplaceSCIMUserWithChangedUserName(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	a := newSCIMRestHandlersSample(userRepository, NewInMemTeamRepository())
+
+	body := `{"userName": "other@baralga.com", "displayName": "Ulani User", "active": true}`
+
+	httpRec := httptest.NewRecorder()
+	a.HandleReplaceUser()(httpRec, newSCIMRequest("PUT", "/scim/v2/Users/"+member.ID.String(), body, map[string]string{"user-id": member.ID.String()}))
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+	is.True(strings.Contains(httpRec.Body.String(), "mutability"))
+}
+
+func TestHandleDeleteSCIMUser(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	userCount := len(userRepository.users)
+	a := newSCIMRestHandlersSample(userRepository, NewInMemTeamRepository())
+
+	httpRec := httptest.NewRecorder()
+	a.HandleDeleteUser()(httpRec, newSCIMRequest("DELETE", "/scim/v2/Users/"+member.ID.String(), "", map[string]string{"user-id": member.ID.String()}))
+	is.Equal(httpRec.Result().StatusCode, http.StatusNoContent)
+	is.Equal(len(userRepository.users), userCount-1)
+
+	httpRec = httptest.NewRecorder()
+	a.HandleDeleteUser()(httpRec, newSCIMRequest("DELETE", "/scim/v2/Users/"+member.ID.String(), "", map[string]string{"user-id": member.ID.String()}))
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
+
+func TestHandleGetSCIMGroups(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	teamRepository := NewInMemTeamRepository()
+	teamRepository.teams = append(teamRepository.teams, &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+		MemberIDs:      []uuid.UUID{member.ID},
+	})
+	a := newSCIMRestHandlersSample(userRepository, teamRepository)
+
+	httpRec := httptest.NewRecorder()
+	a.HandleGetGroups()(httpRec, newSCIMRequest("GET", "/scim/v2/Groups", "", nil))
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	listModel := &struct {
+		TotalResults int               `json:"totalResults"`
+		Resources    []*scimGroupModel `json:"Resources"`
+	}{}
+	err := json.NewDecoder(httpRec.Body).Decode(listModel)
+	is.NoErr(err)
+	is.Equal(listModel.TotalResults, 2)
+
+	is.Equal(listModel.Resources[0].ID, RoleAdmin)
+	is.Equal(listModel.Resources[0].DisplayName, "baralga-admins")
+	is.Equal(len(listModel.Resources[0].Members), 1)
+	is.Equal(listModel.Resources[0].Members[0].Value, userRepository.users[0].ID.String())
+
+	is.Equal(listModel.Resources[1].DisplayName, "Backend")
+	is.Equal(listModel.Resources[1].Members[0].Value, member.ID.String())
+	is.Equal(listModel.Resources[1].Members[0].Display, "Ulani User")
+}
+
+func TestHandleCreateSCIMGroup(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	teamRepository := NewInMemTeamRepository()
+	a := newSCIMRestHandlersSample(userRepository, teamRepository)
+
+	t.Run("group mapped to role", func(t *testing.T) {
+		body := `{"displayName": "baralga-admins", "members": [{"value": "` + member.ID.String() + `"}]}`
+
+		httpRec := httptest.NewRecorder()
+		a.HandleCreateGroup()(httpRec, newSCIMRequest("POST", "/scim/v2/Groups", body, nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+		is.True(member.IsAdmin())
+		is.True(userRepository.users[0].IsAdmin())
+		is.Equal(len(teamRepository.teams), 0)
+	})
+
+	t.Run("group as team", func(t *testing.T) {
+		body := `{"displayName": "Backend", "members": [{"value": "` + member.ID.String() + `"}]}`
+
+		httpRec := httptest.NewRecorder()
+		a.HandleCreateGroup()(httpRec, newSCIMRequest("POST", "/scim/v2/Groups", body, nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+		is.Equal(len(teamRepository.teams), 1)
+		is.Equal(teamRepository.teams[0].MemberIDs, []uuid.UUID{member.ID})
+
+		groupModel := &scimGroupModel{}
+		err := json.NewDecoder(httpRec.Body).Decode(groupModel)
+		is.NoErr(err)
+		is.Equal(groupModel.ID, teamRepository.teams[0].ID.String())
+
+		httpRec = httptest.NewRecorder()
+		a.HandleCreateGroup()(httpRec, newSCIMRequest("POST", "/scim/v2/Groups", body, nil))
+		is.Equal(httpRec.Result().StatusCode, http.StatusConflict)
+	})
+}
+
+func TestHandlePatchSCIMGroup(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	admin := userRepository.users[0]
+	teamRepository := NewInMemTeamRepository()
+	team := &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+		MemberIDs:      []uuid.UUID{admin.ID, member.ID},
+	}
+	teamRepository.teams = append(teamRepository.teams, team)
+	a := newSCIMRestHandlersSample(userRepository, teamRepository)
+
+	t.Run("remove member of team", func(t *testing.T) {
+		body := `{"Operations": [
+			{"op": "remove", "path": "members[value eq \"` + admin.ID.String() + `\"]"},
+			{"op": "replace", "path": "displayName", "value": "Backend Team"}
+		]}`
+
+		httpRec := httptest.NewRecorder()
+		a.HandlePatchGroup()(httpRec, newSCIMRequest("PATCH", "/scim/v2/Groups/"+team.ID.String(), body, map[string]string{"group-id": team.ID.String()}))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(teamRepository.teams[0].Name, "Backend Team")
+		is.Equal(teamRepository.teams[0].MemberIDs, []uuid.UUID{member.ID})
+	})
+
+	t.Run("replace members of group mapped to role", func(t *testing.T) {
+		body := `{"Operations": [{"op": "add", "path": "members", "value": [{"value": "` + member.ID.String() + `"}]}]}`
+
+		httpRec := httptest.NewRecorder()
+		a.HandlePatchGroup()(httpRec, newSCIMRequest("PATCH", "/scim/v2/Groups/"+RoleAdmin, body, map[string]string{"group-id": RoleAdmin}))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(member.IsAdmin())
+		is.True(admin.IsAdmin())
+
+		body = `{"Operations": [{"op": "remove", "path": "members", "value": [{"value": "` + admin.ID.String() + `"}]}]}`
+
+		httpRec = httptest.NewRecorder()
+		a.HandlePatchGroup()(httpRec, newSCIMRequest("PATCH", "/scim/v2/Groups/"+RoleAdmin, body, map[string]string{"group-id": RoleAdmin}))
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(member.IsAdmin())
+		is.True(!admin.IsAdmin())
+	})
+
+	t.Run("remove last admin", func(t *testing.T) {
+		body := `{"Operations": [{"op": "remove", "path": "members"}]}`
+
+		httpRec := httptest.NewRecorder()
+		a.HandlePatchGroup()(httpRec, newSCIMRequest("PATCH", "/scim/v2/Groups/"+RoleAdmin, body, map[string]string{"group-id": RoleAdmin}))
+		is.Equal(httpRec.Result().StatusCode, http.StatusConflict)
+		is.True(member.IsAdmin())
+	})
+}
+
+func TestHandleDeleteSCIMGroup(t *testing.T) {
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	teamRepository := NewInMemTeamRepository()
+	team := &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+	}
+	teamRepository.teams = append(teamRepository.teams, team)
+	a := newSCIMRestHandlersSample(userRepository, teamRepository)
+
+	httpRec := httptest.NewRecorder()
+	a.HandleDeleteGroup()(httpRec, newSCIMRequest("DELETE", "/scim/v2/Groups/"+team.ID.String(), "", map[string]string{"group-id": team.ID.String()}))
+	is.Equal(httpRec.Result().StatusCode, http.StatusNoContent)
+	is.Equal(len(teamRepository.teams), 0)
+
+	httpRec = httptest.NewRecorder()
+	a.HandleDeleteGroup()(httpRec, newSCIMRequest("DELETE", "/scim/v2/Groups/"+team.ID.String(), "", map[string]string{"group-id": team.ID.String()}))
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
+
+func TestSCIMRequiresPermissions(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	r, _ := http.NewRequest("GET", "/scim/v2/Users", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	requireSCIMPermissions(http.NotFoundHandler()).ServeHTTP(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestParseSCIMRoleGroups(t *testing.T) {
+	is := is.New(t)
+
+	roleGroups := parseSCIMRoleGroups(" baralga-admins:ROLE_ADMIN, invalid ,baralga-managers:ROLE_MANAGER,admins:ROLE_ADMIN")
+	is.Equal(len(roleGroups), 2)
+	is.Equal(roleGroups[0].DisplayName, "baralga-admins")
+	is.Equal(roleGroups[0].Role, RoleAdmin)
+	is.Equal(roleGroups[1].DisplayName, "baralga-managers")
+
+	is.Equal(len(parseSCIMRoleGroups("")), 0)
+}
+
+func newSCIMRestHandlersSample(userRepository *InMemUserRepository, teamRepository *InMemTeamRepository) *SCIMRestHandlers {
+	var anonymizedUsernames []string
+	config := &shared.Config{
+		Webroot:        "http://localhost:8080",
+		SCIMGroupRoles: "baralga-admins:ROLE_ADMIN",
+	}
+
+	return NewSCIMRestHandlers(config, &UserService{
+		config:                  config,
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          userRepository,
+		organizationRepository:  NewInMemOrganizationRepository(),
+		teamRepository:          teamRepository,
+		roleRepository:          NewInMemRoleRepository(),
+		apiTokenRepository:      NewInMemAPITokenRepository(),
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
+		userDataRemover:         userDataRemoverSample(&anonymizedUsernames),
+	})
+}
+
+func newSCIMRequest(method, target, body string, urlParams map[string]string) *http.Request {
+	r, _ := http.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", scimContentType)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	for key, value := range urlParams {
+		rctx.URLParams.Add(key, value)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
@@ -0,0 +1,274 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbSessionRepository is a SQL database repository for sessions
+type DbSessionRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ SessionRepository = (*DbSessionRepository)(nil)
+
+// NewDbSessionRepository creates a new SQL database repository for sessions
+func NewDbSessionRepository(connPool *pgxpool.Pool) *DbSessionRepository {
+	return &DbSessionRepository{
+		connPool: connPool,
+	}
+}
+
+// FindSessionsByUserID finds the active sessions of the user, most recently refreshed first
+func (r *DbSessionRepository) FindSessionsByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*Session, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT session_id, org_id, user_agent, created_at, refreshed_at, expires_at
+		 FROM sessions
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		 ORDER BY refreshed_at DESC`, userID, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		var (
+			id             string
+			organizationID string
+			userAgent      string
+			createdAt      time.Time
+			refreshedAt    time.Time
+			expiresAt      time.Time
+		)
+
+		err = rows.Scan(&id, &organizationID, &userAgent, &createdAt, &refreshedAt, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &Session{
+			ID:             uuid.MustParse(id),
+			UserID:         userID,
+			OrganizationID: uuid.MustParse(organizationID),
+			UserAgent:      userAgent,
+			CreatedAt:      createdAt,
+			RefreshedAt:    refreshedAt,
+			ExpiresAt:      expiresAt,
+		})
+	}
+
+	return sessions, nil
+}
+
+// FindSessionByRefreshTokenHash finds the session by the hash of its current or its previous refresh token
+func (r *DbSessionRepository) FindSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*Session, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT session_id, user_id, org_id, refresh_token_hash, COALESCE(previous_refresh_token_hash, ''),
+		        user_agent, created_at, refreshed_at, expires_at, revoked_at
+		 FROM sessions
+		 WHERE refresh_token_hash = $1 OR previous_refresh_token_hash = $1`, refreshTokenHash,
+	)
+
+	var (
+		id                       string
+		userID                   string
+		organizationID           string
+		currentRefreshTokenHash  string
+		previousRefreshTokenHash string
+		userAgent                string
+		createdAt                time.Time
+		refreshedAt              time.Time
+		expiresAt                time.Time
+		revokedAt                sql.NullTime
+	)
+
+	err := row.Scan(&id, &userID, &organizationID, &currentRefreshTokenHash, &previousRefreshTokenHash,
+		&userAgent, &createdAt, &refreshedAt, &expiresAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+
+		return nil, err
+	}
+
+	return &Session{
+		ID:                       uuid.MustParse(id),
+		UserID:                   uuid.MustParse(userID),
+		OrganizationID:           uuid.MustParse(organizationID),
+		RefreshTokenHash:         currentRefreshTokenHash,
+		PreviousRefreshTokenHash: previousRefreshTokenHash,
+		UserAgent:                userAgent,
+		CreatedAt:                createdAt,
+		RefreshedAt:              refreshedAt,
+		ExpiresAt:                expiresAt,
+		RevokedAt:                revokedAt.Time,
+	}, nil
+}
+
+// FindRevokedSessionIDs finds the revoked sessions which are not yet expired
+func (r *DbSessionRepository) FindRevokedSessionIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT session_id
+		 FROM sessions
+		 WHERE revoked_at IS NOT NULL AND expires_at > $1`, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessionIDs []uuid.UUID
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		sessionIDs = append(sessionIDs, uuid.MustParse(id))
+	}
+
+	return sessionIDs, nil
+}
+
+func (r *DbSessionRepository) InsertSession(ctx context.Context, session *Session) (*Session, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO sessions
+		   (session_id, user_id, org_id, refresh_token_hash, user_agent, created_at, refreshed_at, expires_at)
+		 VALUES
+		   ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID,
+		session.UserID,
+		session.OrganizationID,
+		session.RefreshTokenHash,
+		session.UserAgent,
+		session.CreatedAt,
+		session.RefreshedAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// UpdateSession updates the refresh tokens, the organization and the expiry of an active session
+func (r *DbSessionRepository) UpdateSession(ctx context.Context, session *Session) (*Session, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`UPDATE sessions
+		 SET org_id = $2, refresh_token_hash = $3, previous_refresh_token_hash = $4,
+		     refreshed_at = $5, expires_at = $6
+		 WHERE session_id = $1 AND revoked_at IS NULL`,
+		session.ID,
+		session.OrganizationID,
+		session.RefreshTokenHash,
+		sql.NullString{String: session.PreviousRefreshTokenHash, Valid: session.PreviousRefreshTokenHash != ""},
+		session.RefreshedAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+// UpdateSessionOrganization moves an active session of the user to the organization
+func (r *DbSessionRepository) UpdateSessionOrganization(ctx context.Context, userID, sessionID, organizationID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`UPDATE sessions
+		 SET org_id = $3
+		 WHERE user_id = $1 AND session_id = $2 AND revoked_at IS NULL`,
+		userID, sessionID, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *DbSessionRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, revokedAt time.Time) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`UPDATE sessions
+		 SET revoked_at = $3
+		 WHERE user_id = $1 AND session_id = $2 AND revoked_at IS NULL`,
+		userID, sessionID, revokedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *DbSessionRepository) RevokeSessionsByUserID(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE sessions
+		 SET revoked_at = $2
+		 WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID, revokedAt,
+	)
+
+	return err
+}
+
+// DeleteExpiredSessions deletes expired sessions, revoked sessions are kept until they expire
+// so that their access tokens stay on the revocation list
+func (r *DbSessionRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`DELETE
+		 FROM sessions
+		 WHERE expires_at <= $1`,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.RowsAffected()), nil
+}
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InMemSessionRepository struct {
+	sessions []*Session
+}
+
+var _ SessionRepository = (*InMemSessionRepository)(nil)
+
+func NewInMemSessionRepository() *InMemSessionRepository {
+	return &InMemSessionRepository{}
+}
+
+func (r *InMemSessionRepository) FindSessionsByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*Session, error) {
+	var sessions []*Session
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.IsActive(now) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *InMemSessionRepository) FindSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*Session, error) {
+	for _, s := range r.sessions {
+		if s.RefreshTokenHash == refreshTokenHash || (s.PreviousRefreshTokenHash != "" && s.PreviousRefreshTokenHash == refreshTokenHash) {
+			session := *s
+			return &session, nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+func (r *InMemSessionRepository) FindRevokedSessionIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	var sessionIDs []uuid.UUID
+	for _, s := range r.sessions {
+		if !s.RevokedAt.IsZero() && now.Before(s.ExpiresAt) {
+			sessionIDs = append(sessionIDs, s.ID)
+		}
+	}
+	return sessionIDs, nil
+}
+
+func (r *InMemSessionRepository) InsertSession(ctx context.Context, session *Session) (*Session, error) {
+	r.sessions = append(r.sessions, session)
+	return session, nil
+}
+
+func (r *InMemSessionRepository) UpdateSession(ctx context.Context, session *Session) (*Session, error) {
+	for i, s := range r.sessions {
+		if s.ID == session.ID && s.RevokedAt.IsZero() {
+			r.sessions[i] = session
+			return session, nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+func (r *InMemSessionRepository) UpdateSessionOrganization(ctx context.Context, userID, sessionID, organizationID uuid.UUID) error {
+	for _, s := range r.sessions {
+		if s.ID == sessionID && s.UserID == userID && s.RevokedAt.IsZero() {
+			s.OrganizationID = organizationID
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+func (r *InMemSessionRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, revokedAt time.Time) error {
+	for _, s := range r.sessions {
+		if s.ID == sessionID && s.UserID == userID && s.RevokedAt.IsZero() {
+			s.RevokedAt = revokedAt
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+func (r *InMemSessionRepository) RevokeSessionsByUserID(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error {
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt.IsZero() {
+			s.RevokedAt = revokedAt
+		}
+	}
+	return nil
+}
+
+func (r *InMemSessionRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	var sessions []*Session
+	for _, s := range r.sessions {
+		if now.Before(s.ExpiresAt) {
+			sessions = append(sessions, s)
+		}
+	}
+	deleted := len(r.sessions) - len(sessions)
+	r.sessions = sessions
+	return deleted, nil
+}
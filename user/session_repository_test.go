@@ -0,0 +1,131 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestSessionRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	sessionRepository := NewDbSessionRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	adminID := uuid.MustParse("00000000-0000-0000-1111-000000000001")
+	now := time.Now().Truncate(time.Second)
+	session := &Session{
+		ID:               uuid.New(),
+		UserID:           adminID,
+		OrganizationID:   shared.OrganizationIDSample,
+		RefreshTokenHash: HashRefreshToken("refresh"),
+		UserAgent:        "Firefox",
+		CreatedAt:        now,
+		RefreshedAt:      now,
+		ExpiresAt:        now.Add(time.Hour),
+	}
+
+	t.Run("InsertSession", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := sessionRepository.InsertSession(ctx, session)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		sessions, err := sessionRepository.FindSessionsByUserID(context.Background(), adminID, now)
+		is.NoErr(err)
+		is.Equal(len(sessions), 1)
+		is.Equal(sessions[0].ID, session.ID)
+		is.Equal(sessions[0].UserAgent, "Firefox")
+	})
+	t.Run("UpdateSession", func(t *testing.T) {
+		session.PreviousRefreshTokenHash = session.RefreshTokenHash
+		session.RefreshTokenHash = HashRefreshToken("rotated")
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := sessionRepository.UpdateSession(ctx, session)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		rotatedSession, err := sessionRepository.FindSessionByRefreshTokenHash(context.Background(), HashRefreshToken("rotated"))
+		is.NoErr(err)
+		is.Equal(rotatedSession.ID, session.ID)
+
+		previousSession, err := sessionRepository.FindSessionByRefreshTokenHash(context.Background(), HashRefreshToken("refresh"))
+		is.NoErr(err)
+		is.Equal(previousSession.PreviousRefreshTokenHash, HashRefreshToken("refresh"))
+	})
+	t.Run("RevokeSession", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return sessionRepository.RevokeSession(ctx, adminID, session.ID, now)
+			},
+		)
+		is.NoErr(err)
+
+		sessions, err := sessionRepository.FindSessionsByUserID(context.Background(), adminID, now)
+		is.NoErr(err)
+		is.Equal(len(sessions), 0)
+
+		sessionIDs, err := sessionRepository.FindRevokedSessionIDs(context.Background(), now)
+		is.NoErr(err)
+		is.Equal(sessionIDs, []uuid.UUID{session.ID})
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := sessionRepository.UpdateSession(ctx, session)
+				return err
+			},
+		)
+		is.True(errors.Is(err, ErrSessionNotFound))
+	})
+	t.Run("DeleteExpiredSessions", func(t *testing.T) {
+		var deleted int
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				var err error
+				deleted, err = sessionRepository.DeleteExpiredSessions(ctx, now.Add(2*time.Hour))
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(deleted, 1)
+
+		_, err = sessionRepository.FindSessionByRefreshTokenHash(context.Background(), HashRefreshToken("rotated"))
+		is.True(errors.Is(err, ErrSessionNotFound))
+	})
+}
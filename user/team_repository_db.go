@@ -0,0 +1,297 @@
+package user
+
+import (
+	"context"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbTeamRepository is a SQL database repository for teams
+type DbTeamRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ TeamRepository = (*DbTeamRepository)(nil)
+
+// NewDbTeamRepository creates a new SQL database repository for teams
+func NewDbTeamRepository(connPool *pgxpool.Pool) *DbTeamRepository {
+	return &DbTeamRepository{
+		connPool: connPool,
+	}
+}
+
+// FindTeamsByOrganizationID finds all teams of the organization with their members ordered by name
+func (r *DbTeamRepository) FindTeamsByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*Team, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT team_id, name, lead_user_id
+		 FROM teams
+		 WHERE org_id = $1
+		 ORDER BY name`, organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []*Team
+	teamsByID := make(map[uuid.UUID]*Team)
+	for rows.Next() {
+		var (
+			id         string
+			name       string
+			leadUserID *string
+		)
+
+		err = rows.Scan(&id, &name, &leadUserID)
+		if err != nil {
+			return nil, err
+		}
+
+		team := &Team{
+			ID:             uuid.MustParse(id),
+			OrganizationID: organizationID,
+			Name:           name,
+		}
+		if leadUserID != nil {
+			team.LeadUserID = uuid.MustParse(*leadUserID)
+		}
+		teams = append(teams, team)
+		teamsByID[team.ID] = team
+	}
+
+	memberRows, err := r.connPool.Query(
+		ctx,
+		`SELECT m.team_id, m.user_id
+		 FROM team_members m
+		 JOIN teams t ON t.team_id = m.team_id
+		 WHERE t.org_id = $1`, organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var (
+			teamID string
+			userID string
+		)
+
+		err = memberRows.Scan(&teamID, &userID)
+		if err != nil {
+			return nil, err
+		}
+
+		team, ok := teamsByID[uuid.MustParse(teamID)]
+		if !ok {
+			continue
+		}
+		team.MemberIDs = append(team.MemberIDs, uuid.MustParse(userID))
+	}
+
+	return teams, nil
+}
+
+func (r *DbTeamRepository) FindTeamByID(ctx context.Context, organizationID, teamID uuid.UUID) (*Team, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT name, lead_user_id
+		 FROM teams
+		 WHERE team_id = $1 AND org_id = $2`, teamID, organizationID,
+	)
+
+	var (
+		name       string
+		leadUserID *string
+	)
+
+	err := row.Scan(&name, &leadUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTeamNotFound
+		}
+
+		return nil, err
+	}
+
+	team := &Team{
+		ID:             teamID,
+		OrganizationID: organizationID,
+		Name:           name,
+	}
+	if leadUserID != nil {
+		team.LeadUserID = uuid.MustParse(*leadUserID)
+	}
+
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT user_id
+		 FROM team_members
+		 WHERE team_id = $1`, teamID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+
+		err = rows.Scan(&userID)
+		if err != nil {
+			return nil, err
+		}
+
+		team.MemberIDs = append(team.MemberIDs, uuid.MustParse(userID))
+	}
+
+	return team, nil
+}
+
+func (r *DbTeamRepository) InsertTeam(ctx context.Context, team *Team) (*Team, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO teams
+		   (team_id, org_id, name, lead_user_id)
+		 VALUES
+		   ($1, $2, $3, $4)`,
+		team.ID,
+		team.OrganizationID,
+		team.Name,
+		leadUserIDParam(team),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.insertTeamMembers(ctx, tx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+// UpdateTeam updates name and lead of the team and replaces its members
+func (r *DbTeamRepository) UpdateTeam(ctx context.Context, team *Team) (*Team, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	result, err := tx.Exec(
+		ctx,
+		`UPDATE teams
+		 SET name = $3, lead_user_id = $4
+		 WHERE team_id = $1 AND org_id = $2`,
+		team.ID,
+		team.OrganizationID,
+		team.Name,
+		leadUserIDParam(team),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, ErrTeamNotFound
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM team_members
+		 WHERE team_id = $1`,
+		team.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.insertTeamMembers(ctx, tx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (r *DbTeamRepository) DeleteTeamByID(ctx context.Context, organizationID, teamID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(ctx,
+		`DELETE
+         FROM teams
+	     WHERE team_id = $1 AND org_id = $2
+		 RETURNING team_id`,
+		teamID, organizationID)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTeamNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// RemoveUserFromTeams removes the user as member and lead from all teams of the organization
+func (r *DbTeamRepository) RemoveUserFromTeams(ctx context.Context, organizationID, userID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM team_members
+		 WHERE user_id = $2 AND team_id IN (SELECT team_id FROM teams WHERE org_id = $1)`,
+		organizationID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE teams
+		 SET lead_user_id = NULL
+		 WHERE org_id = $1 AND lead_user_id = $2`,
+		organizationID,
+		userID,
+	)
+	return err
+}
+
+func (r *DbTeamRepository) insertTeamMembers(ctx context.Context, tx pgx.Tx, team *Team) error {
+	for _, memberID := range team.MemberIDs {
+		_, err := tx.Exec(
+			ctx,
+			`INSERT INTO team_members
+			   (team_id, user_id)
+			 VALUES
+			   ($1, $2)`,
+			team.ID,
+			memberID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leadUserIDParam is the lead of the team or NULL if the team has no lead
+func leadUserIDParam(team *Team) *uuid.UUID {
+	if team.LeadUserID == uuid.Nil {
+		return nil
+	}
+
+	return &team.LeadUserID
+}
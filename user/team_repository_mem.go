@@ -0,0 +1,76 @@
+package user
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+type InMemTeamRepository struct {
+	teams []*Team
+}
+
+var _ TeamRepository = (*InMemTeamRepository)(nil)
+
+func NewInMemTeamRepository() *InMemTeamRepository {
+	return &InMemTeamRepository{}
+}
+
+func (r *InMemTeamRepository) FindTeamsByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*Team, error) {
+	var teams []*Team
+	for _, t := range r.teams {
+		if t.OrganizationID == organizationID {
+			teams = append(teams, t)
+		}
+	}
+	return teams, nil
+}
+
+func (r *InMemTeamRepository) FindTeamByID(ctx context.Context, organizationID, teamID uuid.UUID) (*Team, error) {
+	for _, t := range r.teams {
+		if t.ID == teamID && t.OrganizationID == organizationID {
+			return t, nil
+		}
+	}
+	return nil, ErrTeamNotFound
+}
+
+func (r *InMemTeamRepository) InsertTeam(ctx context.Context, team *Team) (*Team, error) {
+	r.teams = append(r.teams, team)
+	return team, nil
+}
+
+func (r *InMemTeamRepository) UpdateTeam(ctx context.Context, team *Team) (*Team, error) {
+	for i, t := range r.teams {
+		if t.ID == team.ID && t.OrganizationID == team.OrganizationID {
+			r.teams[i] = team
+			return team, nil
+		}
+	}
+	return nil, ErrTeamNotFound
+}
+
+func (r *InMemTeamRepository) DeleteTeamByID(ctx context.Context, organizationID, teamID uuid.UUID) error {
+	for i, t := range r.teams {
+		if t.ID == teamID && t.OrganizationID == organizationID {
+			r.teams = append(r.teams[:i], r.teams[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTeamNotFound
+}
+
+func (r *InMemTeamRepository) RemoveUserFromTeams(ctx context.Context, organizationID, userID uuid.UUID) error {
+	for _, t := range r.teams {
+		if t.OrganizationID != organizationID {
+			continue
+		}
+
+		t.MemberIDs = slices.DeleteFunc(t.MemberIDs, func(memberID uuid.UUID) bool { return memberID == userID })
+		if t.LeadUserID == userID {
+			t.LeadUserID = uuid.Nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestTeamRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	teamRepository := NewDbTeamRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	adminID := uuid.MustParse("00000000-0000-0000-1111-000000000001")
+	team := &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+		LeadUserID:     adminID,
+		MemberIDs:      []uuid.UUID{adminID},
+	}
+
+	t.Run("InsertTeam", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := teamRepository.InsertTeam(ctx, team)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		teams, err := teamRepository.FindTeamsByOrganizationID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.Equal(len(teams), 1)
+		is.Equal(teams[0].Name, "Backend")
+		is.Equal(teams[0].LeadUserID, adminID)
+		is.Equal(teams[0].MemberIDs, []uuid.UUID{adminID})
+	})
+	t.Run("UpdateTeam", func(t *testing.T) {
+		team.Name = "Frontend"
+		team.LeadUserID = uuid.Nil
+		team.MemberIDs = nil
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := teamRepository.UpdateTeam(ctx, team)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		updatedTeam, err := teamRepository.FindTeamByID(context.Background(), shared.OrganizationIDSample, team.ID)
+		is.NoErr(err)
+		is.Equal(updatedTeam.Name, "Frontend")
+		is.Equal(updatedTeam.LeadUserID, uuid.Nil)
+		is.Equal(len(updatedTeam.MemberIDs), 0)
+	})
+	t.Run("RemoveUserFromTeams", func(t *testing.T) {
+		team.LeadUserID = adminID
+		team.MemberIDs = []uuid.UUID{adminID}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := teamRepository.UpdateTeam(ctx, team)
+				if err != nil {
+					return err
+				}
+
+				return teamRepository.RemoveUserFromTeams(ctx, shared.OrganizationIDSample, adminID)
+			},
+		)
+		is.NoErr(err)
+
+		updatedTeam, err := teamRepository.FindTeamByID(context.Background(), shared.OrganizationIDSample, team.ID)
+		is.NoErr(err)
+		is.Equal(updatedTeam.LeadUserID, uuid.Nil)
+		is.Equal(len(updatedTeam.MemberIDs), 0)
+	})
+	t.Run("DeleteTeamByID", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return teamRepository.DeleteTeamByID(ctx, shared.OrganizationIDSample, team.ID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = teamRepository.FindTeamByID(context.Background(), shared.OrganizationIDSample, team.ID)
+		is.True(errors.Is(err, ErrTeamNotFound))
+	})
+}
@@ -0,0 +1,290 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type teamModel struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	LeadUserID string     `json:"leadUserId,omitempty" validate:"omitempty,uuid"`
+	MemberIDs  []string   `json:"memberIds" validate:"dive,uuid"`
+	Links      *hal.Links `json:"_links"`
+}
+
+type teamsModel struct {
+	*EmbeddedTeams `json:"_embedded"`
+	Links          *hal.Links `json:"_links"`
+}
+
+// EmbeddedTeams contains embedded teams
+type EmbeddedTeams struct {
+	TeamModels []*teamModel `json:"teams"`
+}
+
+type TeamRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewTeamRestHandlers(config *shared.Config, userService *UserService) *TeamRestHandlers {
+	return &TeamRestHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *TeamRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/teams", a.HandleGetTeams())
+	r.Post("/teams", a.HandleCreateTeam())
+	r.Get("/teams/{team-id}", a.HandleGetTeam())
+	r.Patch("/teams/{team-id}", a.HandleUpdateTeam())
+	r.Delete("/teams/{team-id}", a.HandleDeleteTeam())
+}
+
+func (a *TeamRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetTeams reads the teams of the organization
+func (a *TeamRestHandlers) HandleGetTeams() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		teams, err := userService.ReadTeams(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		teamModels := make([]*teamModel, 0, len(teams))
+		for _, team := range teams {
+			teamModels = append(teamModels, mapToTeamModel(team))
+		}
+
+		teamsModel := &teamsModel{
+			EmbeddedTeams: &EmbeddedTeams{
+				TeamModels: teamModels,
+			},
+			Links: hal.NewSelfLink(r.RequestURI),
+		}
+
+		shared.RenderJSON(w, teamsModel)
+	}
+}
+
+// HandleGetTeam reads a team of the organization
+func (a *TeamRestHandlers) HandleGetTeam() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamIDParam := chi.URLParam(r, "team-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		teamID, err := uuid.Parse(teamIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		team, err := userService.ReadTeam(r.Context(), principal, teamID)
+		if errors.Is(err, ErrTeamNotFound) {
+			http.Error(w, problem.New(problem.Title("team not found")).JSONString(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToTeamModel(team))
+	}
+}
+
+// HandleCreateTeam creates a new team
+func (a *TeamRestHandlers) HandleCreateTeam() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var teamModel teamModel
+		err := json.NewDecoder(r.Body).Decode(&teamModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(teamModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("team not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		team, err := userService.CreateTeam(r.Context(), principal, mapToTeam(&teamModel))
+		if errors.Is(err, ErrInvalidTeam) {
+			http.Error(w, problem.New(problem.Title("team not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		shared.RenderJSON(w, mapToTeamModel(team))
+	}
+}
+
+// HandleUpdateTeam changes name, lead and members of a team
+func (a *TeamRestHandlers) HandleUpdateTeam() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamIDParam := chi.URLParam(r, "team-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		teamID, err := uuid.Parse(teamIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		var teamModel teamModel
+		err = json.NewDecoder(r.Body).Decode(&teamModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(teamModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("team not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		team := mapToTeam(&teamModel)
+		team.ID = teamID
+
+		team, err = userService.UpdateTeam(r.Context(), principal, team)
+		if errors.Is(err, ErrTeamNotFound) {
+			http.Error(w, problem.New(problem.Title("team not found")).JSONString(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidTeam) {
+			http.Error(w, problem.New(problem.Title("team not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToTeamModel(team))
+	}
+}
+
+// HandleDeleteTeam deletes a team, the members stay in the organization
+func (a *TeamRestHandlers) HandleDeleteTeam() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamIDParam := chi.URLParam(r, "team-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		teamID, err := uuid.Parse(teamIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		err = userService.DeleteTeam(r.Context(), principal, teamID)
+		if errors.Is(err, ErrTeamNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+	}
+}
+
+// mapToTeam maps a validated team model, lead and member ids are valid uuids
+func mapToTeam(teamModel *teamModel) *Team {
+	team := &Team{
+		Name: teamModel.Name,
+	}
+
+	if teamModel.LeadUserID != "" {
+		team.LeadUserID = uuid.MustParse(teamModel.LeadUserID)
+	}
+
+	for _, memberID := range teamModel.MemberIDs {
+		team.MemberIDs = append(team.MemberIDs, uuid.MustParse(memberID))
+	}
+
+	return team
+}
+
+func mapToTeamModel(team *Team) *teamModel {
+	memberIDs := make([]string, 0, len(team.MemberIDs))
+	for _, memberID := range team.MemberIDs {
+		memberIDs = append(memberIDs, memberID.String())
+	}
+
+	teamModel := &teamModel{
+		ID:        team.ID.String(),
+		Name:      team.Name,
+		MemberIDs: memberIDs,
+	}
+	if team.LeadUserID != uuid.Nil {
+		teamModel.LeadUserID = team.LeadUserID.String()
+	}
+
+	teamModel.Links = hal.NewLinks(
+		hal.NewSelfLink(fmt.Sprintf("/api/teams/%s", teamModel.ID)),
+		hal.NewLink("edit", fmt.Sprintf("/api/teams/%s", teamModel.ID)),
+		hal.NewLink("delete", fmt.Sprintf("/api/teams/%s", teamModel.ID)),
+	)
+
+	return teamModel
+}
@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetTeams(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	teamRepository := NewInMemTeamRepository()
+	teamRepository.teams = append(teamRepository.teams, &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+		MemberIDs:      []uuid.UUID{uuid.MustParse("00000000-0000-0000-1111-000000000001")},
+	})
+
+	a := &TeamRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			teamRepository: teamRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/teams", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleGetTeams()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	teamsModel := &teamsModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(teamsModel)
+	is.NoErr(err)
+	is.Equal(len(teamsModel.TeamModels), 1)
+	is.Equal(teamsModel.TeamModels[0].Name, "Backend")
+	is.Equal(teamsModel.TeamModels[0].LeadUserID, "")
+	is.Equal(teamsModel.TeamModels[0].MemberIDs, []string{"00000000-0000-0000-1111-000000000001"})
+}
+
+func TestHandleGetTeamsAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &TeamRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			teamRepository:     NewInMemTeamRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+			roleRepository:     NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/teams", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleGetTeams()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleCreateTeam(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	teamRepository := NewInMemTeamRepository()
+
+	a := &TeamRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+			teamRepository: teamRepository,
+		},
+	}
+
+	body := fmt.Sprintf(`{"name": "Backend", "leadUserId": "00000000-0000-0000-1111-000000000001", "memberIds": ["%v"]}`, member.ID)
+	r, _ := http.NewRequest("POST", "/api/teams", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleCreateTeam()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusCreated)
+
+	teamModel := &teamModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(teamModel)
+	is.NoErr(err)
+	is.Equal(teamModel.Name, "Backend")
+	is.Equal(teamModel.LeadUserID, "00000000-0000-0000-1111-000000000001")
+	is.Equal(teamModel.MemberIDs, []string{member.ID.String()})
+	is.Equal(len(teamRepository.teams), 1)
+}
+
+func TestHandleCreateTeamWithInvalidMemberID(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &TeamRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			teamRepository:     NewInMemTeamRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+			roleRepository:     NewInMemRoleRepository(),
+		},
+	}
+
+	body := `{"name": "Backend", "memberIds": ["not-a-uuid"]}`
+	r, _ := http.NewRequest("POST", "/api/teams", strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleCreateTeam()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+}
+
+func TestHandleUpdateTeamNotFound(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &TeamRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:     shared.NewInMemRepositoryTxer(),
+			userRepository:     NewInMemUserRepository(),
+			teamRepository:     NewInMemTeamRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+			roleRepository:     NewInMemRoleRepository(),
+		},
+	}
+
+	teamID := uuid.New()
+	r, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/teams/%v", teamID), strings.NewReader(`{"name": "Backend"}`))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("team-id", teamID.String())
+
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleUpdateTeam()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusNotFound)
+}
@@ -0,0 +1,604 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type teamFormModel struct {
+	CSRFToken  string
+	ID         string
+	Name       string `validate:"required,max=100"`
+	LeadUserID string
+	MemberIDs  []string
+}
+
+type TeamWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewTeamWebHandlers(config *shared.Config, userService *UserService) *TeamWebHandlers {
+	return &TeamWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *TeamWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/teams", a.HandleTeamsPage())
+	r.Get("/teams/new", a.HandleTeamAddPage())
+	r.Get("/teams/{team-id}/edit", a.HandleTeamEditPage())
+	r.Post("/teams/new", a.HandleTeamForm())
+	r.Post("/teams/{team-id}", a.HandleTeamForm())
+	r.Post("/teams/{team-id}/delete", a.HandleDeleteTeam())
+}
+
+func (a *TeamWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleTeamsPage shows the teams of the organization to members who manage teams
+func (a *TeamWebHandlers) HandleTeamsPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		teams, err := userService.ReadTeams(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Teams",
+			}
+			shared.RenderHTML(w, TeamsPage(pageContext, csrf.Token(r), teams, users))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, TeamsView(csrf.Token(r), teams, users))
+	}
+}
+
+// HandleTeamAddPage shows the form for a new team
+func (a *TeamWebHandlers) HandleTeamAddPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := teamFormModel{CSRFToken: csrf.Token(r)}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, TeamForm(formModel, users, nil))
+	}
+}
+
+// HandleTeamEditPage shows the form to change name, lead and members of a team
+func (a *TeamWebHandlers) HandleTeamEditPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamIDParam := chi.URLParam(r, "team-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		teamID, err := uuid.Parse(teamIDParam)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		team, err := userService.ReadTeam(r.Context(), principal, teamID)
+		if errors.Is(err, ErrTeamNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		formModel := mapTeamToForm(team)
+		formModel.CSRFToken = csrf.Token(r)
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, TeamForm(formModel, users, nil))
+	}
+}
+
+// HandleTeamForm creates a new or saves an existing team and shows the teams again
+func (a *TeamWebHandlers) HandleTeamForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var formModel teamFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		formModel.CSRFToken = csrf.Token(r)
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil {
+			shared.RenderHTML(w, TeamForm(formModel, users, map[string]string{"Name": "Name must have 1 to 100 characters."}))
+			return
+		}
+
+		team, err := mapFormToTeam(formModel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if formModel.ID == "" {
+			_, err = userService.CreateTeam(r.Context(), principal, team)
+		} else {
+			_, err = userService.UpdateTeam(r.Context(), principal, team)
+		}
+		if errors.Is(err, ErrTeamNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidTeam) {
+			shared.RenderHTML(w, TeamForm(formModel, users, map[string]string{"Name": "Name, lead or members are not valid."}))
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		teams, err := userService.ReadTeams(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, TeamsView(csrf.Token(r), teams, users))
+	}
+}
+
+// HandleDeleteTeam deletes a team and shows the remaining teams
+func (a *TeamWebHandlers) HandleDeleteTeam() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		teamIDParam := chi.URLParam(r, "team-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionTeamsWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		teamID, err := uuid.Parse(teamIDParam)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.DeleteTeam(r.Context(), principal, teamID)
+		if errors.Is(err, ErrTeamNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		teams, err := userService.ReadTeams(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, TeamsView(csrf.Token(r), teams, users))
+	}
+}
+
+func mapTeamToForm(team *Team) teamFormModel {
+	memberIDs := make([]string, 0, len(team.MemberIDs))
+	for _, memberID := range team.MemberIDs {
+		memberIDs = append(memberIDs, memberID.String())
+	}
+
+	formModel := teamFormModel{
+		ID:        team.ID.String(),
+		Name:      team.Name,
+		MemberIDs: memberIDs,
+	}
+	if team.LeadUserID != uuid.Nil {
+		formModel.LeadUserID = team.LeadUserID.String()
+	}
+
+	return formModel
+}
+
+func mapFormToTeam(formModel teamFormModel) (*Team, error) {
+	team := &Team{
+		Name: formModel.Name,
+	}
+
+	if formModel.ID != "" {
+		teamID, err := uuid.Parse(formModel.ID)
+		if err != nil {
+			return nil, err
+		}
+		team.ID = teamID
+	}
+
+	if formModel.LeadUserID != "" {
+		leadUserID, err := uuid.Parse(formModel.LeadUserID)
+		if err != nil {
+			return nil, err
+		}
+		team.LeadUserID = leadUserID
+	}
+
+	for _, memberIDParam := range formModel.MemberIDs {
+		memberID, err := uuid.Parse(memberIDParam)
+		if err != nil {
+			return nil, err
+		}
+		team.MemberIDs = append(team.MemberIDs, memberID)
+	}
+
+	return team, nil
+}
+
+func TeamsPage(pageContext *shared.PageContext, csrfToken string, teams []*Team, users []*User) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					TeamsView(csrfToken, teams, users),
+				),
+			),
+		},
+	)
+}
+
+func TeamsView(csrfToken string, teams []*Team, users []*User) g.Node {
+	namesByID := make(map[uuid.UUID]string)
+	for _, user := range users {
+		namesByID[user.ID] = userDisplayName(user)
+	}
+
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Teams"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Div(
+				Class("d-flex justify-content-end mb-3"),
+				A(
+					ghx.Get("/teams/new"),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					Class("btn btn-outline-primary btn-sm"),
+					I(Class("bi-plus me-2")),
+					g.Text("New Team"),
+				),
+			),
+			g.If(
+				len(teams) == 0,
+				Div(
+					Class("alert alert-info"),
+					Role("alert"),
+					g.Text("No teams yet. Teams group members, the team lead may see the activities of the members."),
+				),
+			),
+			Table(
+				Class("table table-sm table-borderless align-middle"),
+				TBody(
+					g.Group(
+						g.Map(teams, func(team *Team) g.Node {
+							return TeamRow(csrfToken, team, namesByID)
+						}),
+					),
+				),
+			),
+		),
+	)
+}
+
+func TeamRow(csrfToken string, team *Team, namesByID map[uuid.UUID]string) g.Node {
+	members := 0
+	for _, memberID := range team.MemberIDs {
+		if _, ok := namesByID[memberID]; ok {
+			members++
+		}
+	}
+
+	return Tr(
+		Td(
+			Class("w-100"),
+			Div(
+				g.Text(team.Name),
+				Span(
+					Class("badge text-bg-secondary ms-2"),
+					TitleAttr("Members"),
+					g.Text(fmt.Sprintf("%v", members)),
+				),
+			),
+			g.If(namesByID[team.LeadUserID] != "",
+				Small(
+					Class("text-muted"),
+					g.Text(fmt.Sprintf("Lead: %v", namesByID[team.LeadUserID])),
+				),
+			),
+		),
+		Td(
+			Class("text-nowrap"),
+			A(
+				ghx.Get(fmt.Sprintf("/teams/%v/edit", team.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("btn btn-outline-secondary btn-sm me-1"),
+				TitleAttr(fmt.Sprintf("Edit %v", team.Name)),
+				I(Class("bi-pen")),
+			),
+			FormEl(
+				Class("d-inline"),
+				ghx.Post(fmt.Sprintf("/teams/%v/delete", team.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm(fmt.Sprintf("Do you really want to delete the team %v?", team.Name)),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr(fmt.Sprintf("Delete %v", team.Name)),
+					I(Class("bi-trash2")),
+				),
+			),
+		),
+	)
+}
+
+func TeamForm(formModel teamFormModel, users []*User, fieldErrors map[string]string) g.Node {
+	action := "/teams/new"
+	title := "New Team"
+	if formModel.ID != "" {
+		action = fmt.Sprintf("/teams/%v", formModel.ID)
+		title = "Edit Team"
+	}
+
+	return FormEl(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		ghx.Post(action),
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text(title),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Input(
+				Type("hidden"),
+				Name("CSRFToken"),
+				Value(formModel.CSRFToken),
+			),
+			Input(
+				Type("hidden"),
+				Name("ID"),
+				Value(formModel.ID),
+			),
+			Div(
+				Class("form-floating mb-3"),
+				Input(
+					ID("team_Name"),
+					Required(),
+					Type("text"),
+					Name("Name"),
+					MaxLength("100"),
+					organizationControlClass("form-control", "Name", fieldErrors),
+					g.Attr("placeholder", "Name"),
+					Value(formModel.Name),
+				),
+				Label(
+					g.Attr("for", "team_Name"),
+					g.Text("Name"),
+				),
+				organizationFieldError("Name", fieldErrors),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "team_LeadUserID"),
+					g.Text("Team Lead"),
+				),
+				Select(
+					ID("team_LeadUserID"),
+					Name("LeadUserID"),
+					Class("form-select"),
+					Option(
+						Value(""),
+						g.Text("No team lead"),
+					),
+					g.Group(
+						g.Map(users, func(user *User) g.Node {
+							return Option(
+								Value(user.ID.String()),
+								g.Text(userDisplayName(user)),
+								g.If(formModel.LeadUserID == user.ID.String(), Selected()),
+							)
+						}),
+					),
+				),
+				Div(
+					Class("form-text"),
+					g.Text("The team lead may see but not edit the activities of the members."),
+				),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label d-block"),
+					g.Text("Members"),
+				),
+				g.Group(
+					g.Map(users, func(user *User) g.Node {
+						checkboxID := fmt.Sprintf("team_MemberIDs_%v", user.ID)
+						return Div(
+							Class("form-check"),
+							Input(
+								ID(checkboxID),
+								Type("checkbox"),
+								Name("MemberIDs"),
+								Value(user.ID.String()),
+								Class("form-check-input"),
+								g.If(slices.Contains(formModel.MemberIDs, user.ID.String()), Checked()),
+							),
+							Label(
+								Class("form-check-label"),
+								g.Attr("for", checkboxID),
+								g.Text(userDisplayName(user)),
+							),
+						)
+					}),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("submit"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-save me-2")),
+				g.Text("Save"),
+			),
+			A(
+				ghx.Get("/teams"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				Class("text-center btn btn-secondary"),
+				I(Class("bi-x me-2")),
+				g.Text("Cancel"),
+			),
+		),
+	)
+}
+
+// userDisplayName is the name of the user or the username if the user has no name
+func userDisplayName(user *User) string {
+	if user.Name == "" {
+		return user.Username
+	}
+	return user.Name
+}
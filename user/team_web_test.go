@@ -0,0 +1,191 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestHandleTeamsPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	teamRepository := NewInMemTeamRepository()
+	teamRepository.teams = append(teamRepository.teams, &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+		LeadUserID:     uuid.MustParse("00000000-0000-0000-1111-000000000001"),
+		MemberIDs:      []uuid.UUID{uuid.MustParse("00000000-0000-0000-1111-000000000001")},
+	})
+
+	a := &TeamWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:          NewInMemUserRepository(),
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			teamRepository:          teamRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/teams", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleTeamsPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Backend"))
+	is.True(strings.Contains(htmlBody, "Lead: admin@baralga.com"))
+}
+
+func TestHandleTeamsPageAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &TeamWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:     NewInMemUserRepository(),
+			teamRepository:     NewInMemTeamRepository(),
+			apiTokenRepository: NewInMemAPITokenRepository(),
+			roleRepository:     NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/teams", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleTeamsPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleTeamForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	teamRepository := NewInMemTeamRepository()
+
+	a := &TeamWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          NewInMemUserRepository(),
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			teamRepository:          teamRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Frontend"}
+	data["LeadUserID"] = []string{"00000000-0000-0000-1111-000000000001"}
+	data["MemberIDs"] = []string{"00000000-0000-0000-1111-000000000001"}
+
+	r, _ := http.NewRequest("POST", "/teams/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleTeamForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(strings.Contains(httpRec.Body.String(), "Frontend"))
+
+	is.Equal(len(teamRepository.teams), 1)
+	team := teamRepository.teams[0]
+	is.Equal(team.Name, "Frontend")
+	is.Equal(team.LeadUserID, uuid.MustParse("00000000-0000-0000-1111-000000000001"))
+	is.Equal(len(team.MemberIDs), 1)
+}
+
+func TestHandleTeamFormWithUnknownMember(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	teamRepository := NewInMemTeamRepository()
+
+	a := &TeamWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          NewInMemUserRepository(),
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			teamRepository:          teamRepository,
+		},
+	}
+
+	data := url.Values{}
+	data["Name"] = []string{"Frontend"}
+	data["MemberIDs"] = []string{uuid.New().String()}
+
+	r, _ := http.NewRequest("POST", "/teams/new", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleTeamForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(strings.Contains(httpRec.Body.String(), "Name, lead or members are not valid."))
+	is.Equal(len(teamRepository.teams), 0)
+}
+
+func TestHandleDeleteTeam(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	teamID := uuid.New()
+	teamRepository := NewInMemTeamRepository()
+	teamRepository.teams = append(teamRepository.teams, &Team{
+		ID:             teamID,
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+	})
+
+	a := &TeamWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          NewInMemUserRepository(),
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			teamRepository:          teamRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/teams/%v/delete", teamID), nil)
+	r.Header.Add("HX-Request", "true")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("team-id", teamID.String())
+
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleDeleteTeam()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(teamRepository.teams), 0)
+}
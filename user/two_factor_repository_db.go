@@ -0,0 +1,133 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// DbTwoFactorRepository is a SQL database repository for second factors
+type DbTwoFactorRepository struct {
+	connPool *pgxpool.Pool
+}
+
+var _ TwoFactorRepository = (*DbTwoFactorRepository)(nil)
+
+// NewDbTwoFactorRepository creates a new SQL database repository for second factors
+func NewDbTwoFactorRepository(connPool *pgxpool.Pool) *DbTwoFactorRepository {
+	return &DbTwoFactorRepository{
+		connPool: connPool,
+	}
+}
+
+func (r *DbTwoFactorRepository) FindTwoFactorByUserID(ctx context.Context, userID uuid.UUID) (*TwoFactor, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT secret, recovery_codes, last_used_step, created_at, confirmed_at
+		 FROM two_factors
+		 WHERE user_id = $1`, userID,
+	)
+
+	var (
+		secret        string
+		recoveryCodes string
+		lastUsedStep  int64
+		createdAt     time.Time
+		confirmedAt   sql.NullTime
+	)
+
+	err := row.Scan(&secret, &recoveryCodes, &lastUsedStep, &createdAt, &confirmedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTwoFactorNotFound
+		}
+
+		return nil, err
+	}
+
+	var recoveryCodeHashes []string
+	if recoveryCodes != "" {
+		recoveryCodeHashes = strings.Split(recoveryCodes, ",")
+	}
+
+	return &TwoFactor{
+		UserID:             userID,
+		Secret:             secret,
+		RecoveryCodeHashes: recoveryCodeHashes,
+		LastUsedStep:       lastUsedStep,
+		CreatedAt:          createdAt,
+		ConfirmedAt:        confirmedAt.Time,
+	}, nil
+}
+
+func (r *DbTwoFactorRepository) InsertTwoFactor(ctx context.Context, twoFactor *TwoFactor) (*TwoFactor, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO two_factors
+		   (user_id, secret, recovery_codes, last_used_step, created_at, confirmed_at)
+		 VALUES
+		   ($1, $2, $3, $4, $5, $6)`,
+		twoFactor.UserID,
+		twoFactor.Secret,
+		strings.Join(twoFactor.RecoveryCodeHashes, ","),
+		twoFactor.LastUsedStep,
+		twoFactor.CreatedAt,
+		sql.NullTime{Time: twoFactor.ConfirmedAt, Valid: !twoFactor.ConfirmedAt.IsZero()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+func (r *DbTwoFactorRepository) UpdateTwoFactor(ctx context.Context, twoFactor *TwoFactor) (*TwoFactor, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(
+		ctx,
+		`UPDATE two_factors
+		 SET recovery_codes = $2, last_used_step = $3, confirmed_at = $4
+		 WHERE user_id = $1
+		 RETURNING user_id`,
+		twoFactor.UserID,
+		strings.Join(twoFactor.RecoveryCodeHashes, ","),
+		twoFactor.LastUsedStep,
+		sql.NullTime{Time: twoFactor.ConfirmedAt, Valid: !twoFactor.ConfirmedAt.IsZero()},
+	)
+
+	var id string
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTwoFactorNotFound
+		}
+
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+func (r *DbTwoFactorRepository) DeleteTwoFactorByUserID(ctx context.Context, userID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE
+		 FROM two_factors
+		 WHERE user_id = $1`,
+		userID,
+	)
+
+	return err
+}
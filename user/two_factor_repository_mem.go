@@ -0,0 +1,52 @@
+package user
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type InMemTwoFactorRepository struct {
+	twoFactors []*TwoFactor
+}
+
+var _ TwoFactorRepository = (*InMemTwoFactorRepository)(nil)
+
+func NewInMemTwoFactorRepository() *InMemTwoFactorRepository {
+	return &InMemTwoFactorRepository{}
+}
+
+func (r *InMemTwoFactorRepository) FindTwoFactorByUserID(ctx context.Context, userID uuid.UUID) (*TwoFactor, error) {
+	for _, t := range r.twoFactors {
+		if t.UserID == userID {
+			twoFactor := *t
+			return &twoFactor, nil
+		}
+	}
+	return nil, ErrTwoFactorNotFound
+}
+
+func (r *InMemTwoFactorRepository) InsertTwoFactor(ctx context.Context, twoFactor *TwoFactor) (*TwoFactor, error) {
+	r.twoFactors = append(r.twoFactors, twoFactor)
+	return twoFactor, nil
+}
+
+func (r *InMemTwoFactorRepository) UpdateTwoFactor(ctx context.Context, twoFactor *TwoFactor) (*TwoFactor, error) {
+	for i, t := range r.twoFactors {
+		if t.UserID == twoFactor.UserID {
+			r.twoFactors[i] = twoFactor
+			return twoFactor, nil
+		}
+	}
+	return nil, ErrTwoFactorNotFound
+}
+
+func (r *InMemTwoFactorRepository) DeleteTwoFactorByUserID(ctx context.Context, userID uuid.UUID) error {
+	for i, t := range r.twoFactors {
+		if t.UserID == userID {
+			r.twoFactors = append(r.twoFactors[:i], r.twoFactors[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestTwoFactorRepository(t *testing.T) {
+	// skip in short mode
+	if testing.Short() {
+		return
+	}
+
+	is := is.New(t)
+
+	// Setup database
+	ctx := context.Background()
+	cleanupFunc, connPool, err := shared.SetupTestDatabase(ctx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer func() {
+		err := cleanupFunc()
+		if err != nil {
+			t.Log(err)
+		}
+	}()
+
+	twoFactorRepository := NewDbTwoFactorRepository(connPool)
+	repositoryTxer := shared.NewDbRepositoryTxer(connPool)
+
+	adminID := uuid.MustParse("00000000-0000-0000-1111-000000000001")
+	twoFactor := &TwoFactor{
+		UserID:             adminID,
+		Secret:             "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		RecoveryCodeHashes: []string{hashSecret("abcde-fghij"), hashSecret("klmno-pqrst")},
+		CreatedAt:          time.Now(),
+	}
+
+	t.Run("InsertTwoFactor", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := twoFactorRepository.InsertTwoFactor(ctx, twoFactor)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		foundTwoFactor, err := twoFactorRepository.FindTwoFactorByUserID(context.Background(), adminID)
+		is.NoErr(err)
+		is.Equal(foundTwoFactor.Secret, twoFactor.Secret)
+		is.Equal(len(foundTwoFactor.RecoveryCodeHashes), 2)
+		is.True(!foundTwoFactor.IsConfirmed())
+	})
+	t.Run("UpdateTwoFactor", func(t *testing.T) {
+		twoFactor.ConfirmedAt = time.Now()
+		twoFactor.LastUsedStep = 42
+		twoFactor.RecoveryCodeHashes = twoFactor.RecoveryCodeHashes[1:]
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := twoFactorRepository.UpdateTwoFactor(ctx, twoFactor)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		foundTwoFactor, err := twoFactorRepository.FindTwoFactorByUserID(context.Background(), adminID)
+		is.NoErr(err)
+		is.True(foundTwoFactor.IsConfirmed())
+		is.Equal(foundTwoFactor.LastUsedStep, int64(42))
+		is.Equal(foundTwoFactor.RecoveryCodeHashes, []string{hashSecret("klmno-pqrst")})
+	})
+	t.Run("DeleteTwoFactorByUserID", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return twoFactorRepository.DeleteTwoFactorByUserID(ctx, adminID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = twoFactorRepository.FindTwoFactorByUserID(context.Background(), adminID)
+		is.True(errors.Is(err, ErrTwoFactorNotFound))
+	})
+}
@@ -0,0 +1,412 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/baralga/shared/qr"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type TwoFactorWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewTwoFactorWebHandlers(config *shared.Config, userService *UserService) *TwoFactorWebHandlers {
+	return &TwoFactorWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *TwoFactorWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/profile/two-factor", a.HandleTwoFactorPage())
+	r.Post("/profile/two-factor/enroll", a.HandleTwoFactorEnrollment())
+	r.Post("/profile/two-factor/confirm", a.HandleTwoFactorConfirmation())
+	r.Post("/profile/two-factor/disable", a.HandleTwoFactorDisable())
+}
+
+func (a *TwoFactorWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleTwoFactorPage shows whether the signed in user signs in with a second factor
+func (a *TwoFactorWebHandlers) HandleTwoFactorPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		twoFactor, err := userService.ReadTwoFactor(r.Context(), principal)
+		if err != nil && !errors.Is(err, ErrTwoFactorNotFound) {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Two-Factor Authentication",
+			}
+			shared.RenderHTML(w, TwoFactorPage(pageContext, csrf.Token(r), twoFactor))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, TwoFactorView(principal, csrf.Token(r), twoFactor, ""))
+	}
+}
+
+// HandleTwoFactorEnrollment creates a new secret and shows it as QR code for the authenticator app
+func (a *TwoFactorWebHandlers) HandleTwoFactorEnrollment() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		profile, err := userService.ReadProfile(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		twoFactor, err := userService.StartTwoFactorEnrollment(r.Context(), principal)
+		if errors.Is(err, ErrTwoFactorEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, TwoFactorEnrollmentView(csrf.Token(r), twoFactor, profile.TwoFactorAccount(), ""))
+	}
+}
+
+// HandleTwoFactorConfirmation enables the second factor with a first one time password and shows the recovery codes once
+func (a *TwoFactorWebHandlers) HandleTwoFactorConfirmation() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		recoveryCodes, err := userService.ConfirmTwoFactor(r.Context(), principal, r.PostForm.Get("Code"))
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			profile, err := userService.ReadProfile(r.Context(), principal)
+			if err != nil {
+				shared.RenderProblemHTML(w, isProduction, err)
+				return
+			}
+
+			twoFactor, err := userService.ReadTwoFactor(r.Context(), principal)
+			if err != nil {
+				shared.RenderProblemHTML(w, isProduction, err)
+				return
+			}
+
+			shared.RenderHTML(w, TwoFactorEnrollmentView(csrf.Token(r), twoFactor, profile.TwoFactorAccount(), "The code is not valid, please try again."))
+			return
+		}
+		if errors.Is(err, ErrTwoFactorNotFound) || errors.Is(err, ErrTwoFactorEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, RecoveryCodesView(recoveryCodes))
+	}
+}
+
+// HandleTwoFactorDisable removes the second factor after checking a current one time password or a recovery code
+func (a *TwoFactorWebHandlers) HandleTwoFactorDisable() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = userService.DisableTwoFactor(r.Context(), principal, r.PostForm.Get("Code"))
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			twoFactor, err := userService.ReadTwoFactor(r.Context(), principal)
+			if err != nil {
+				shared.RenderProblemHTML(w, isProduction, err)
+				return
+			}
+
+			shared.RenderHTML(w, TwoFactorView(principal, csrf.Token(r), twoFactor, "The code is not valid, please try again."))
+			return
+		}
+		if err != nil && !errors.Is(err, ErrTwoFactorNotFound) {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, TwoFactorView(principal, csrf.Token(r), nil, ""))
+	}
+}
+
+func TwoFactorPage(pageContext *shared.PageContext, csrfToken string, twoFactor *TwoFactor) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					TwoFactorView(pageContext.Principal, csrfToken, twoFactor, ""),
+				),
+			),
+		},
+	)
+}
+
+// TwoFactorView shows whether the second factor is enabled and offers to set it up or to disable it
+func TwoFactorView(principal *shared.Principal, csrfToken string, twoFactor *TwoFactor, errorMessage string) g.Node {
+	enabled := twoFactor != nil && twoFactor.IsConfirmed()
+
+	var enabledSince string
+	if enabled {
+		enabledSince = twoFactor.ConfirmedAt.In(principal.Location()).Format("02.01.2006")
+	}
+
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		twoFactorModalHeader(),
+		Div(
+			Class("modal-body"),
+			twoFactorErrorMessage(errorMessage),
+			g.If(!enabled,
+				g.Group([]g.Node{
+					P(
+						g.Text("Protect your account with one time passwords of an authenticator app like Google Authenticator, Authy or 1Password in addition to your password."),
+					),
+					FormEl(
+						ghx.Post("/profile/two-factor/enroll"),
+						ghx.Target("#baralga__main_content_modal_content"),
+						ghx.Swap("outerHTML"),
+
+						Input(
+							Type("hidden"),
+							Name("CSRFToken"),
+							Value(csrfToken),
+						),
+						Button(
+							Type("submit"),
+							Class("btn btn-primary btn-sm"),
+							I(Class("bi-shield-lock me-2")),
+							g.Text("Set up two-factor authentication"),
+						),
+					),
+				}),
+			),
+			g.If(enabled,
+				g.Group([]g.Node{
+					P(
+						I(Class("bi-shield-check text-success me-2")),
+						g.Textf("Two-factor authentication is enabled since %v.", enabledSince),
+					),
+					FormEl(
+						ghx.Post("/profile/two-factor/disable"),
+						ghx.Target("#baralga__main_content_modal_content"),
+						ghx.Swap("outerHTML"),
+						ghx.Confirm("Do you really want to disable two-factor authentication?"),
+
+						Input(
+							Type("hidden"),
+							Name("CSRFToken"),
+							Value(csrfToken),
+						),
+						twoFactorCodeInput("two_factor_DisableCode", "Code from your app or recovery code"),
+						Button(
+							Type("submit"),
+							Class("btn btn-outline-danger btn-sm"),
+							I(Class("bi-shield-x me-2")),
+							g.Text("Disable two-factor authentication"),
+						),
+					),
+				}),
+			),
+		),
+	)
+}
+
+// TwoFactorEnrollmentView shows the secret of the enrollment and asks for a first one time password
+func TwoFactorEnrollmentView(csrfToken string, twoFactor *TwoFactor, account, errorMessage string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		twoFactorModalHeader(),
+		Div(
+			Class("modal-body"),
+			twoFactorErrorMessage(errorMessage),
+			TwoFactorSecret(twoFactor, account),
+			FormEl(
+				ghx.Post("/profile/two-factor/confirm"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				twoFactorCodeInput("two_factor_Code", "Code from your app"),
+				Button(
+					Type("submit"),
+					Class("btn btn-primary btn-sm"),
+					I(Class("bi-shield-check me-2")),
+					g.Text("Enable two-factor authentication"),
+				),
+			),
+		),
+	)
+}
+
+// RecoveryCodesView shows the recovery codes of the confirmed second factor once
+func RecoveryCodesView(recoveryCodes []string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		twoFactorModalHeader(),
+		Div(
+			Class("modal-body"),
+			P(
+				I(Class("bi-shield-check text-success me-2")),
+				g.Text("Two-factor authentication is enabled."),
+			),
+			RecoveryCodeList(recoveryCodes),
+		),
+		Div(
+			Class("modal-footer"),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-check me-2")),
+				g.Text("Done"),
+			),
+		),
+	)
+}
+
+// TwoFactorSecret shows the secret as QR code to scan with the authenticator app,
+// and as text to enter manually
+func TwoFactorSecret(twoFactor *TwoFactor, account string) g.Node {
+	// the key is still shown if the account is too long for a QR code
+	var svg string
+	if code, err := qr.Encode(twoFactor.URI(account)); err == nil {
+		svg = code.SVG()
+	}
+
+	return Div(
+		Class("text-center mb-3"),
+		P(
+			g.Text("Scan the QR code with your authenticator app, or enter the key manually."),
+		),
+		g.If(svg != "",
+			Div(
+				Class("mx-auto mb-2"),
+				StyleAttr("max-width: 220px;"),
+				g.Raw(svg),
+			),
+		),
+		Code(
+			ID("two_factor_Secret"),
+			Class("user-select-all"),
+			g.Text(twoFactor.Secret),
+		),
+	)
+}
+
+// RecoveryCodeList shows the recovery codes, each of them can be used once instead of the authenticator app
+func RecoveryCodeList(recoveryCodes []string) g.Node {
+	return Div(
+		Class("alert alert-warning"),
+		P(
+			g.Text("Save these recovery codes in a safe place, they will not be shown again. Each code signs you in once if you lose your authenticator app."),
+		),
+		Ul(
+			Class("list-unstyled font-monospace mb-0"),
+			g.Group(
+				g.Map(recoveryCodes, func(recoveryCode string) g.Node {
+					return Li(g.Text(recoveryCode))
+				}),
+			),
+		),
+	)
+}
+
+func twoFactorModalHeader() g.Node {
+	return Div(
+		Class("modal-header"),
+		H2(
+			Class("modal-title"),
+			g.Text("Two-Factor Authentication"),
+		),
+		Button(
+			Type("type"),
+			Class("btn-close"),
+			g.Attr("data-bs-dismiss", "modal"),
+		),
+	)
+}
+
+func twoFactorErrorMessage(errorMessage string) g.Node {
+	return g.If(
+		errorMessage != "",
+		Div(
+			Class("alert alert-danger text-center"),
+			Role("alert"),
+			Span(g.Text(errorMessage)),
+		),
+	)
+}
+
+func twoFactorCodeInput(id, label string) g.Node {
+	return Div(
+		Class("form-floating mb-3"),
+		Input(
+			ID(id),
+			Type("text"),
+			Name("Code"),
+			Class("form-control"),
+			g.Attr("autocomplete", "one-time-code"),
+			g.Attr("placeholder", "123456"),
+			Required(),
+		),
+		Label(
+			g.Attr("for", id),
+			g.Text(label),
+		),
+	)
+}
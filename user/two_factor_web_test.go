@@ -0,0 +1,98 @@
+package user
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/baralga/shared"
+	"github.com/matryer/is"
+)
+
+func TestHandleTwoFactorPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &TwoFactorWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:      NewInMemUserRepository(),
+			twoFactorRepository: NewInMemTwoFactorRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/profile/two-factor", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}))
+
+	a.HandleTwoFactorPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+	is.True(strings.Contains(httpRec.Body.String(), "Set up two-factor authentication"))
+}
+
+func TestHandleTwoFactorEnrollment(t *testing.T) {
+	is := is.New(t)
+
+	twoFactorRepository := NewInMemTwoFactorRepository()
+	a := &TwoFactorWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:      shared.NewInMemRepositoryTxer(),
+			userRepository:      NewInMemUserRepository(),
+			twoFactorRepository: twoFactorRepository,
+		},
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	t.Run("enroll", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/profile/two-factor/enroll", nil)
+		r.Header.Add("HX-Request", "true")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleTwoFactorEnrollment()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.Equal(len(twoFactorRepository.twoFactors), 1)
+
+		htmlBody := httpRec.Body.String()
+		is.True(strings.Contains(htmlBody, "<svg"))
+		is.True(strings.Contains(htmlBody, twoFactorRepository.twoFactors[0].Secret))
+	})
+	t.Run("confirm with invalid code", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		data := url.Values{}
+		data["Code"] = []string{"abcdef"}
+
+		r, _ := http.NewRequest("POST", "/profile/two-factor/confirm", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleTwoFactorConfirmation()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(strings.Contains(httpRec.Body.String(), "The code is not valid, please try again."))
+		is.True(!twoFactorRepository.twoFactors[0].IsConfirmed())
+	})
+	t.Run("confirm", func(t *testing.T) {
+		httpRec := httptest.NewRecorder()
+		data := url.Values{}
+		data["Code"] = []string{currentTOTP(twoFactorRepository.twoFactors[0])}
+
+		r, _ := http.NewRequest("POST", "/profile/two-factor/confirm", strings.NewReader(data.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), principal))
+
+		a.HandleTwoFactorConfirmation()(httpRec, r)
+		is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+		is.True(strings.Contains(httpRec.Body.String(), "recovery codes"))
+		is.True(twoFactorRepository.twoFactors[0].IsConfirmed())
+	})
+}
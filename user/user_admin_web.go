@@ -0,0 +1,421 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/pkg/errors"
+	g "maragu.dev/gomponents"
+	ghx "maragu.dev/gomponents-htmx"
+	. "maragu.dev/gomponents/html" //nolint:all
+)
+
+type UserAdminWebHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewUserAdminWebHandlers(config *shared.Config, userService *UserService) *UserAdminWebHandlers {
+	return &UserAdminWebHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *UserAdminWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/users", a.HandleUsersPage())
+	r.Post("/users/{user-id}/role", a.HandleUserRoleForm())
+	r.Post("/users/{user-id}/enabled", a.HandleUserEnabledForm())
+	r.Post("/users/{user-id}/delete", a.HandleDeleteUser())
+	r.Post("/users/{user-id}/two-factor/reset", a.HandleResetTwoFactor())
+	r.Post("/users/{user-id}/unlock", a.HandleUnlockUser())
+}
+
+func (a *UserAdminWebHandlers) RegisterOpen(r chi.Router) {
+}
+
+func (a *UserAdminWebHandlers) HandleUsersPage() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		roles, err := userService.ReadRoles(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Users",
+			}
+			shared.RenderHTML(w, UsersPage(pageContext, csrf.Token(r), users, roles))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, UsersView(principal, csrf.Token(r), users, roles, ""))
+	}
+}
+
+// HandleUserRoleForm changes the role of a member
+func (a *UserAdminWebHandlers) HandleUserRoleForm() http.HandlerFunc {
+	userService := a.userService
+	return a.handleUserChange(func(r *http.Request, principal *shared.Principal, userID uuid.UUID) error {
+		_, err := userService.UpdateUserRole(r.Context(), principal, userID, r.PostForm.Get("Role"))
+		return err
+	})
+}
+
+// HandleUserEnabledForm enables or disables a member
+func (a *UserAdminWebHandlers) HandleUserEnabledForm() http.HandlerFunc {
+	userService := a.userService
+	return a.handleUserChange(func(r *http.Request, principal *shared.Principal, userID uuid.UUID) error {
+		enabled, err := strconv.ParseBool(r.PostForm.Get("Enabled"))
+		if err != nil {
+			return err
+		}
+
+		_, err = userService.UpdateUserEnabled(r.Context(), principal, userID, enabled)
+		return err
+	})
+}
+
+// HandleDeleteUser removes a member from the organization
+func (a *UserAdminWebHandlers) HandleDeleteUser() http.HandlerFunc {
+	userService := a.userService
+	return a.handleUserChange(func(r *http.Request, principal *shared.Principal, userID uuid.UUID) error {
+		return userService.DeleteUser(r.Context(), principal, userID)
+	})
+}
+
+// HandleResetTwoFactor removes the second factor of a member who lost the authenticator app and the recovery codes
+func (a *UserAdminWebHandlers) HandleResetTwoFactor() http.HandlerFunc {
+	userService := a.userService
+	return a.handleUserChange(func(r *http.Request, principal *shared.Principal, userID uuid.UUID) error {
+		return userService.ResetTwoFactor(r.Context(), principal, userID)
+	})
+}
+
+// HandleUnlockUser unlocks a member locked after too many failed sign ins
+func (a *UserAdminWebHandlers) HandleUnlockUser() http.HandlerFunc {
+	userService := a.userService
+	return a.handleUserChange(func(r *http.Request, principal *shared.Principal, userID uuid.UUID) error {
+		return userService.UnlockUser(r.Context(), principal, userID)
+	})
+}
+
+// handleUserChange applies a change to a member and renders the members again
+func (a *UserAdminWebHandlers) handleUserChange(change func(r *http.Request, principal *shared.Principal, userID uuid.UUID) error) http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDParam := chi.URLParam(r, "user-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		errorMessage := ""
+		err = change(r, principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidRole) || errors.Is(err, strconv.ErrSyntax) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			errorMessage = "The organization needs at least one enabled admin."
+		} else if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		roles, err := userService.ReadRoles(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, UsersView(principal, csrf.Token(r), users, roles, errorMessage))
+	}
+}
+
+func UsersPage(pageContext *shared.PageContext, csrfToken string, users []*User, roles []*OrganizationRole) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					UsersView(pageContext.Principal, csrfToken, users, roles, ""),
+				),
+			),
+		},
+	)
+}
+
+func UsersView(principal *shared.Principal, csrfToken string, users []*User, roles []*OrganizationRole, errorMessage string) g.Node {
+	return Div(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Users"),
+			),
+			Button(
+				Type("type"),
+				Class("btn-close"),
+				g.Attr("data-bs-dismiss", "modal"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Div(
+				Class("d-flex justify-content-end mb-3"),
+				A(
+					ghx.Get("/invitations"),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+					Class("btn btn-outline-primary btn-sm"),
+					I(Class("bi-envelope-plus me-2")),
+					g.Text("Invite"),
+				),
+			),
+			g.If(
+				errorMessage != "",
+				Div(
+					Class("alert alert-danger text-center"),
+					Role("alert"),
+					Span(g.Text(errorMessage)),
+				),
+			),
+			Table(
+				Class("table table-sm table-borderless align-middle"),
+				TBody(
+					g.Group(
+						g.Map(users, func(user *User) g.Node {
+							return UserRow(principal, csrfToken, user, roles)
+						}),
+					),
+				),
+			),
+		),
+	)
+}
+
+func UserRow(principal *shared.Principal, csrfToken string, user *User, roles []*OrganizationRole) g.Node {
+	name := user.Name
+	if name == "" {
+		name = user.Username
+	}
+
+	nameClass := "w-100"
+	if !user.Enabled {
+		nameClass = "w-100 text-muted"
+	}
+
+	return Tr(
+		Td(
+			Class(nameClass),
+			Div(
+				g.Text(name),
+				g.If(user.Username == principal.Username,
+					Span(
+						Class("badge text-bg-secondary ms-2"),
+						g.Text("You"),
+					),
+				),
+				g.If(!user.Enabled,
+					Span(
+						Class("badge text-bg-warning ms-2"),
+						g.Text("Disabled"),
+					),
+				),
+				g.If(user.Locked,
+					Span(
+						Class("badge text-bg-danger ms-2"),
+						g.Text("Locked"),
+					),
+				),
+			),
+			Small(
+				Class("text-muted"),
+				g.Text(user.EMail),
+			),
+		),
+		Td(
+			FormEl(
+				ghx.Post(fmt.Sprintf("/users/%v/role", user.ID)),
+				ghx.Trigger("change"),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Select(
+					Name("Role"),
+					Class("form-select form-select-sm"),
+					TitleAttr("Role"),
+					g.Group(
+						g.Map(roles, func(role *OrganizationRole) g.Node {
+							return Option(
+								Value(role.Name),
+								g.Text(role.Title),
+								g.If(slices.Contains(user.Roles, role.Name), Selected()),
+							)
+						}),
+					),
+				),
+			),
+		),
+		Td(
+			Class("text-nowrap"),
+			FormEl(
+				Class("d-inline"),
+				ghx.Post(fmt.Sprintf("/users/%v/enabled", user.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Input(
+					Type("hidden"),
+					Name("Enabled"),
+					Value(strconv.FormatBool(!user.Enabled)),
+				),
+				g.If(user.Enabled,
+					Button(
+						Class("btn btn-outline-secondary btn-sm me-1"),
+						TitleAttr(fmt.Sprintf("Disable %v", name)),
+						I(Class("bi-person-slash")),
+					),
+				),
+				g.If(!user.Enabled,
+					Button(
+						Class("btn btn-outline-secondary btn-sm me-1"),
+						TitleAttr(fmt.Sprintf("Enable %v", name)),
+						I(Class("bi-person-check")),
+					),
+				),
+			),
+			g.If(user.Locked,
+				FormEl(
+					Class("d-inline"),
+					ghx.Post(fmt.Sprintf("/users/%v/unlock", user.ID)),
+					ghx.Target("#baralga__main_content_modal_content"),
+					ghx.Swap("outerHTML"),
+
+					Input(
+						Type("hidden"),
+						Name("CSRFToken"),
+						Value(csrfToken),
+					),
+					Button(
+						Class("btn btn-outline-secondary btn-sm me-1"),
+						TitleAttr(fmt.Sprintf("Unlock %v", name)),
+						I(Class("bi-unlock")),
+					),
+				),
+			),
+			FormEl(
+				Class("d-inline"),
+				ghx.Post(fmt.Sprintf("/users/%v/two-factor/reset", user.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm(fmt.Sprintf("Do you really want to reset the two-factor authentication of %v?", name)),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Class("btn btn-outline-secondary btn-sm me-1"),
+					TitleAttr(fmt.Sprintf("Reset two-factor authentication of %v", name)),
+					I(Class("bi-shield-x")),
+				),
+			),
+			FormEl(
+				Class("d-inline"),
+				ghx.Post(fmt.Sprintf("/users/%v/delete", user.ID)),
+				ghx.Target("#baralga__main_content_modal_content"),
+				ghx.Swap("outerHTML"),
+				ghx.Confirm(fmt.Sprintf("Do you really want to remove %v from the organization?", name)),
+
+				Input(
+					Type("hidden"),
+					Name("CSRFToken"),
+					Value(csrfToken),
+				),
+				Button(
+					Class("btn btn-outline-secondary btn-sm"),
+					TitleAttr(fmt.Sprintf("Remove %v", name)),
+					I(Class("bi-trash2")),
+				),
+			),
+		),
+	)
+}
@@ -0,0 +1,232 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/matryer/is"
+)
+
+func TestHandleUsersPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+
+	a := &UserAdminWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			roleRepository:          NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/users", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleUsersPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Trigger"), "baralga__main_content_modal-show")
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Ulani User"))
+	is.True(strings.Contains(htmlBody, "admin@baralga.com"))
+}
+
+func TestHandleUsersPageAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &UserAdminWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+			roleRepository: NewInMemRoleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/users", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleUsersPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleUserRoleForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserAdminWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			roleRepository:          NewInMemRoleRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["Role"] = []string{RoleAdmin}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/users/%v/role", member.ID), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUserRoleForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(member.IsAdmin())
+}
+
+func TestHandleUserEnabledFormForLastAdmin(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+
+	a := &UserAdminWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			roleRepository:          NewInMemRoleRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["Enabled"] = []string{"false"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/users/%v/enabled", admin.ID), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", admin.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUserEnabledForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.True(admin.Enabled)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "The organization needs at least one enabled admin."))
+}
+
+func TestHandleDeleteUserForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	userCount := len(userRepository.users)
+
+	a := &UserAdminWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			roleRepository:          NewInMemRoleRepository(),
+			organizationRepository:  NewInMemOrganizationRepository(),
+			teamRepository:          NewInMemTeamRepository(),
+			apiTokenRepository:      NewInMemAPITokenRepository(),
+			userDataRemover:         userDataRemoverSample(nil),
+		},
+	}
+
+	data := url.Values{}
+	data["CSRFToken"] = []string{"token"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/users/%v/delete", member.ID), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleDeleteUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.users), userCount-1)
+	is.True(!strings.Contains(httpRec.Body.String(), "Ulani User"))
+}
+
+func TestHandleUnlockUserForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	loginThrottleRepository := NewInMemLoginThrottleRepository()
+	loginThrottleRepository.loginThrottles = append(loginThrottleRepository.loginThrottles, &LoginThrottle{
+		Key:           AccountLoginThrottleKey(member.Username),
+		Failures:      LoginFailuresBeforeLockout,
+		LastFailureAt: time.Now(),
+		LockedUntil:   time.Now().Add(LoginLockoutDuration),
+	})
+
+	a := &UserAdminWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: loginThrottleRepository,
+			roleRepository:          NewInMemRoleRepository(),
+		},
+	}
+
+	data := url.Values{}
+	data["CSRFToken"] = []string{"token"}
+
+	r, _ := http.NewRequest("POST", fmt.Sprintf("/users/%v/unlock", member.ID), strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUnlockUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(loginThrottleRepository.loginThrottles), 0)
+	is.True(!strings.Contains(httpRec.Body.String(), "Locked"))
+}
@@ -2,12 +2,179 @@ package user
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/baralga/shared"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned if a user with the email already exists
+	ErrUserExists = errors.New("user already exists")
+	// ErrInvitationNotFound is returned for unknown, revoked or expired invitations
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrLastAdmin is returned if a change would leave the organization without an enabled admin
+	ErrLastAdmin = errors.New("organization needs at least one admin")
+	// ErrInvalidRole is returned for unknown roles or if title or permissions of a custom role are not valid
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrRoleNotFound is returned for unknown custom roles, built-in roles can't be changed
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrPermissionDenied is returned if a role grants permissions the principal doesn't hold itself,
+	// or if a principal who is not admin changes an admin
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrAPITokenNotFound is returned for unknown, revoked or expired api tokens
+	ErrAPITokenNotFound = errors.New("api token not found")
+	// ErrInvalidAPIToken is returned if name, scopes or expiry of a new api token are not valid
+	ErrInvalidAPIToken = errors.New("invalid api token")
+	// ErrSessionNotFound is returned for unknown, revoked or expired sessions
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrTwoFactorNotFound is returned if the user has not enrolled a second factor
+	ErrTwoFactorNotFound = errors.New("two factor not found")
+	// ErrTwoFactorEnabled is returned on enrollment if the user already confirmed a second factor
+	ErrTwoFactorEnabled = errors.New("two factor already enabled")
+	// ErrInvalidTwoFactorCode is returned for wrong, reused or expired one time passwords and recovery codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two factor code")
+	// ErrAccountLocked is returned on sign in if the account is locked after too many failed sign ins
+	ErrAccountLocked = errors.New("account locked")
+	// ErrLoginThrottled is returned on sign in if the account or ip address has to wait after recent failed sign ins
+	ErrLoginThrottled = errors.New("login throttled")
+	// ErrPasswordResetNotFound is returned for unknown, used or expired password resets
+	ErrPasswordResetNotFound = errors.New("password reset not found")
+	// ErrLoginLinkNotFound is returned for unknown, used or expired login links
+	ErrLoginLinkNotFound = errors.New("login link not found")
+	// ErrLoginLinkThrottled is returned if too many login links were requested recently for the email or from the ip address
+	ErrLoginLinkThrottled = errors.New("login link throttled")
+	// ErrPasswordInvalid is returned if the current password of the user doesn't match
+	ErrPasswordInvalid = errors.New("password invalid")
+	// ErrEMailChangeNotFound is returned for unknown or already confirmed email changes
+	ErrEMailChangeNotFound = errors.New("email change not found")
+	// ErrEMailChangeExpired is returned for email changes which are no longer valid
+	ErrEMailChangeExpired   = errors.New("email change expired")
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrInvalidOrganization is returned if title or settings of an organization are not valid
+	ErrInvalidOrganization = errors.New("invalid organization")
+	// ErrConfirmationNotFound is returned for unknown, used or expired signup confirmations
+	ErrConfirmationNotFound = errors.New("confirmation not found")
+	// ErrConfirmationExpired is returned for signup confirmations which are no longer valid
+	ErrConfirmationExpired = errors.New("confirmation expired")
+	// ErrUserNotConfirmed is returned if a user signs in before the email is confirmed
+	ErrUserNotConfirmed = errors.New("user not confirmed")
+	// ErrMembershipNotFound is returned if the user is no enabled member of the organization
+	ErrMembershipNotFound = errors.New("membership not found")
+	// ErrTeamNotFound is returned for unknown teams or teams of other organizations
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrInvalidTeam is returned if name, lead or members of a team are not valid
+	ErrInvalidTeam = errors.New("invalid team")
+)
+
+const (
+	RoleUser    = "ROLE_USER"
+	RoleManager = "ROLE_MANAGER"
+	RoleAdmin   = "ROLE_ADMIN"
+)
+
+const (
+	// UserDeletionPolicyAnonymize keeps the activities of deleted users without their username
+	UserDeletionPolicyAnonymize = "anonymize"
+	// UserDeletionPolicyDelete deletes the activities of deleted users
+	UserDeletionPolicyDelete = "delete"
+)
+
+// SCIMOrigin is the origin of users provisioned by an identity provider with SCIM
+const SCIMOrigin = "scim"
+
+// DefaultTimeZone is the time zone of new organizations
+const DefaultTimeZone = "Europe/Berlin"
+
+// DefaultWeekStart is the first day of the week of new organizations
+const DefaultWeekStart = time.Monday
+
+// DefaultDateFormat is the date format of new organizations
+const DefaultDateFormat = shared.DateFormatGerman
+
+// DefaultWorkingDays are the working days of new organizations
+var DefaultWorkingDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// TwoFactorIssuer names the account of a second factor in authenticator apps
+const TwoFactorIssuer = "Baralga"
+
+const (
+	// TOTPPeriod is how long a one time password of the second factor is valid
+	TOTPPeriod = 30 * time.Second
+	// TOTPDigits is the length of a one time password of the second factor
+	TOTPDigits = 6
+	// RecoveryCodeCount is the number of recovery codes of a second factor
+	RecoveryCodeCount = 10
+)
+
+const (
+	// LoginFailuresBeforeBackoff is how many failed sign ins of an account are allowed before further sign ins are delayed
+	LoginFailuresBeforeBackoff = 3
+	// LoginIPFailuresBeforeBackoff is how many failed sign ins from an ip address are allowed before further sign ins
+	// are delayed, more than for accounts as many users may share an ip address
+	LoginIPFailuresBeforeBackoff = 20
+	// LoginBackoff is the delay after the first delayed failure, doubled with every further failure
+	LoginBackoff = time.Second
+	// LoginMaxBackoff is the longest delay between failed sign ins
+	LoginMaxBackoff = 15 * time.Minute
+	// LoginFailuresBeforeLockout is how many failed sign ins lock an account
+	LoginFailuresBeforeLockout = 10
+	// LoginLockoutDuration is how long an account stays locked unless an admin unlocks it
+	LoginLockoutDuration = 30 * time.Minute
+	// LoginFailureRetention is how long failed sign ins are counted after the last one
+	LoginFailureRetention = 24 * time.Hour
+)
+
+// InvitationValidity is how long an invitation can be accepted
+const InvitationValidity = 7 * 24 * time.Hour
+
+// ConfirmationValidity is how long a signup confirmation link can be used
+const ConfirmationValidity = 48 * time.Hour
+
+// EMailChangeValidity is how long an email change confirmation link can be used
+const EMailChangeValidity = 48 * time.Hour
+
+// UnconfirmedUserRetention is how long unconfirmed users are kept after their last confirmation link was sent
+const UnconfirmedUserRetention = 14 * 24 * time.Hour
+
+// PasswordResetValidity is how long a password reset link can be used
+const PasswordResetValidity = time.Hour
+
+// LoginLinkValidity is how long a login link sent by email can be used
+const LoginLinkValidity = 15 * time.Minute
+
+const (
+	// LoginLinksBeforeBackoff is how many login links can be requested for an email before further requests are delayed
+	LoginLinksBeforeBackoff = 3
+	// LoginLinkIPRequestsBeforeBackoff is how many login links can be requested from an ip address before further
+	// requests are delayed, more than for an email as many users may share an ip address
+	LoginLinkIPRequestsBeforeBackoff = 10
+)
+
+// APITokenPrefix starts every personal api token to tell it apart from a JWT
+const APITokenPrefix = "bpat_"
+
+// APITokenLastUsedInterval is how often the last use of an api token is recorded at most
+const APITokenLastUsedInterval = time.Minute
+
+// RefreshTokenReuseInterval is how long the previous refresh token of a session is still accepted after
+// a rotation, e.g. for parallel requests. Later use of the previous token revokes the session.
+const RefreshTokenReuseInterval = 30 * time.Second
 
 type User struct {
 	ID             uuid.UUID
@@ -16,22 +183,633 @@ type User struct {
 	EMail          string
 	Password       string
 	Origin         string
+	OrganizationID uuid.UUID // organization of the membership or the default organization of the user
+	TimeZone       string    // time zone of user or default of organization
+	Enabled        bool
+	Roles          []string // roles in the organization, only read for members of the organization
+	Locked         bool     // locked after too many failed sign ins, only read for the users of an organization
+}
+
+// IsAdmin checks if the user has the admin role
+func (u *User) IsAdmin() bool {
+	return slices.Contains(u.Roles, RoleAdmin)
+}
+
+// HasLocalPassword checks if the user signs in with a password instead of GitHub, Google, OpenID Connect, LDAP or SCIM
+func (u *User) HasLocalPassword() bool {
+	return u.Origin == "" || u.Origin == "baralga"
+}
+
+// TwoFactorAccount names the user in authenticator apps, users of GitHub or Google may have no email
+func (u *User) TwoFactorAccount() string {
+	if u.EMail != "" {
+		return u.EMail
+	}
+	return u.Name
+}
+
+// AnonymizedUsername is the username of the activities of the user after the user is deleted
+func (u *User) AnonymizedUsername() string {
+	return "deleted-" + u.ID.String()[:8]
+}
+
+// IsBuiltInRole checks if the role is one of the roles every organization has
+func IsBuiltInRole(role string) bool {
+	return role == RoleUser || role == RoleManager || role == RoleAdmin
+}
+
+// OrganizationRole is a role of an organization which grants permissions to the members with the role
+type OrganizationRole struct {
 	OrganizationID uuid.UUID
+	Name           string // name of the role like ROLE_CONTROLLER, derived from the title for custom roles
+	Title          string
+	Permissions    []string
+	BuiltIn        bool
+}
+
+// BuiltInRoles are the roles every organization has, their permissions can't be changed
+func BuiltInRoles(organizationID uuid.UUID) []*OrganizationRole {
+	return []*OrganizationRole{
+		builtInRole(organizationID, RoleUser, "User"),
+		builtInRole(organizationID, RoleManager, "Manager"),
+		builtInRole(organizationID, RoleAdmin, "Admin"),
+	}
+}
+
+func builtInRole(organizationID uuid.UUID, name, title string) *OrganizationRole {
+	return &OrganizationRole{
+		OrganizationID: organizationID,
+		Name:           name,
+		Title:          title,
+		Permissions:    slices.Clone(shared.RolePermissions[name]),
+		BuiltIn:        true,
+	}
+}
+
+// RoleName derives the name of a custom role from its title, e.g. ROLE_TEAM_CONTROLLER for Team Controller
+func RoleName(title string) string {
+	var name strings.Builder
+	name.WriteString("ROLE_")
+
+	separate := false
+	for _, r := range strings.ToUpper(strings.TrimSpace(title)) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			if separate {
+				name.WriteRune('_')
+			}
+			name.WriteRune(r)
+			separate = false
+			continue
+		}
+		separate = name.Len() > len("ROLE_")
+	}
+
+	return name.String()
+}
+
+// IsValid checks title and permissions of a custom role
+func (r *OrganizationRole) IsValid() bool {
+	title := strings.TrimSpace(r.Title)
+	if title == "" || len(title) > 50 || r.Name == "ROLE_" || len(r.Name) > 50 || IsBuiltInRole(r.Name) {
+		return false
+	}
+
+	for _, permission := range r.Permissions {
+		if !shared.IsValidPermission(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermission checks if the role grants the permission
+func (r *OrganizationRole) HasPermission(permission string) bool {
+	return slices.Contains(r.Permissions, permission)
 }
 
 type Organization struct {
-	ID    uuid.UUID
-	Title string
+	ID                 uuid.UUID
+	Title              string
+	TimeZone           string
+	UserDeletionPolicy string
+	WeekStart          time.Weekday
+	WorkingDays        []time.Weekday
+	DateFormat         string
+	// AdminTwoFactorRequired requires admins to sign in with a second factor
+	AdminTwoFactorRequired bool
+}
+
+// Settings returns the settings of the organization that apply to all its members
+func (o *Organization) Settings() *shared.OrganizationSettings {
+	return &shared.OrganizationSettings{
+		WeekStart:   o.WeekStart,
+		WorkingDays: slices.Clone(o.WorkingDays),
+		TimeZone:    o.TimeZone,
+		DateFormat:  o.DateFormat,
+	}
+}
+
+// IsValid checks if title and settings of the organization can be saved
+func (o *Organization) IsValid() bool {
+	if len(o.Title) < 3 || len(o.Title) > 100 {
+		return false
+	}
+
+	if !IsValidTimeZone(o.TimeZone) || !shared.IsValidDateFormat(o.DateFormat) || !IsValidUserDeletionPolicy(o.UserDeletionPolicy) {
+		return false
+	}
+
+	if !isValidWeekday(o.WeekStart) || len(o.WorkingDays) == 0 {
+		return false
+	}
+
+	for _, workingDay := range o.WorkingDays {
+		if !isValidWeekday(workingDay) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Membership makes a user member of an organization with roles in that organization
+type Membership struct {
+	OrganizationID    uuid.UUID
+	OrganizationTitle string
+	Enabled           bool
+}
+
+// Invitation invites a user by email to join an existing organization
+type Invitation struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	EMail          string
+	CreatedBy      string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// IsExpired checks if the invitation can no longer be accepted
+func (i *Invitation) IsExpired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+// Confirmation confirms the email of a signed up user
+type Confirmation struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired checks if the confirmation can no longer be used
+func (c *Confirmation) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// PasswordReset allows a user to set a new password once
+type PasswordReset struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired checks if the password reset can no longer be used
+func (p *PasswordReset) IsExpired(now time.Time) bool {
+	return !now.Before(p.ExpiresAt)
+}
+
+// LoginLink signs in a user once without a password
+type LoginLink struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired checks if the login link can no longer be used
+func (l *LoginLink) IsExpired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// EMailChange changes the email of a user once the new email is confirmed
+type EMailChange struct {
+	ConfirmationID uuid.UUID
+	UserID         uuid.UUID
+	EMail          string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// IsExpired checks if the email change can no longer be confirmed
+func (e *EMailChange) IsExpired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// Team groups members of an organization, the team lead may see the activities of the members
+type Team struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+	LeadUserID     uuid.UUID // uuid.Nil if the team has no lead
+	MemberIDs      []uuid.UUID
+}
+
+// IsValid checks if name and members of the team can be saved
+func (t *Team) IsValid() bool {
+	name := strings.TrimSpace(t.Name)
+	return name != "" && len(name) <= 100
+}
+
+// HasLead checks if the user is lead of the team
+func (t *Team) HasLead(userID uuid.UUID) bool {
+	return t.LeadUserID != uuid.Nil && t.LeadUserID == userID
+}
+
+// HasMember checks if the user is member of the team
+func (t *Team) HasMember(userID uuid.UUID) bool {
+	return slices.Contains(t.MemberIDs, userID)
+}
+
+// APIToken is a personal access token of a member for the REST API, only the hash of the token is kept
+type APIToken struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Name           string
+	TokenHash      string
+	Scopes         []string // no scopes grant the full access of the owner
+	CreatedAt      time.Time
+	ExpiresAt      time.Time // zero if the token doesn't expire
+	LastUsedAt     time.Time // zero if the token was never used
+}
+
+// IsExpired checks if the api token can no longer be used
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// IsValid checks name, scopes and expiry of a new api token
+func (t *APIToken) IsValid(now time.Time) bool {
+	name := strings.TrimSpace(t.Name)
+	if name == "" || len(name) > 100 || t.IsExpired(now) {
+		return false
+	}
+
+	for _, scope := range t.Scopes {
+		if !shared.IsValidScope(scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewAPITokenSecret generates the secret of a new api token which is shown to the user only once
+func NewAPITokenSecret() (string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+
+	return APITokenPrefix + secret, nil
+}
+
+// HashAPIToken hashes the secret of an api token, the secret has enough entropy for a plain SHA-256
+func HashAPIToken(secret string) string {
+	return hashSecret(secret)
+}
+
+// IsAPIToken checks if the bearer token is a personal api token instead of a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// Session is a sign in of a user on a device, the session is kept alive by rotating refresh tokens
+// of which only the hashes are kept
+type Session struct {
+	ID                       uuid.UUID
+	UserID                   uuid.UUID
+	OrganizationID           uuid.UUID
+	RefreshTokenHash         string
+	PreviousRefreshTokenHash string // hash of the refresh token before the last rotation
+	UserAgent                string
+	CreatedAt                time.Time
+	RefreshedAt              time.Time
+	ExpiresAt                time.Time
+	RevokedAt                time.Time // zero if the session is not revoked
+}
+
+// IsActive checks if the session is neither revoked nor expired
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
+
+// NewRefreshToken generates a new refresh token of a session
+func NewRefreshToken() (string, error) {
+	return newSecret()
+}
+
+// HashRefreshToken hashes a refresh token, the token has enough entropy for a plain SHA-256
+func HashRefreshToken(refreshToken string) string {
+	return hashSecret(refreshToken)
+}
+
+// LoginThrottle counts the recent failed sign ins of an account or from an ip address
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time // zero if the account is not locked
+}
+
+// AccountLoginThrottleKey is the key of the failed sign ins of an account
+func AccountLoginThrottleKey(username string) string {
+	return "account:" + strings.ToLower(username)
+}
+
+// IPLoginThrottleKey is the key of the failed sign ins from an ip address
+func IPLoginThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginLinkThrottleKey is the key of the login links requested for an email
+func LoginLinkThrottleKey(email string) string {
+	return "login-link:" + strings.ToLower(email)
+}
+
+// IPLoginLinkThrottleKey is the key of the login links requested from an ip address
+func IPLoginLinkThrottleKey(ip string) string {
+	return "login-link-ip:" + ip
+}
+
+// IsLocked checks if the account is locked after too many failed sign ins
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return now.Before(t.LockedUntil)
+}
+
+// RetryAt is the earliest time of the next sign in, the delay doubles with every failure after the allowed failures
+func (t *LoginThrottle) RetryAt(failuresBeforeBackoff int) time.Time {
+	if t.Failures < failuresBeforeBackoff {
+		return time.Time{}
+	}
+
+	backoff := LoginMaxBackoff
+	if doublings := t.Failures - failuresBeforeBackoff; doublings < 20 {
+		backoff = min(LoginBackoff<<doublings, LoginMaxBackoff)
+	}
+
+	return t.LastFailureAt.Add(backoff)
+}
+
+// TwoFactor is the second factor of a user with time based one time passwords (TOTP) of an authenticator app,
+// recovery codes replace the app once each and only their hashes are kept
+type TwoFactor struct {
+	UserID             uuid.UUID
+	Secret             string // base32 encoded as shown to authenticator apps
+	RecoveryCodeHashes []string
+	LastUsedStep       int64 // time step of the last one time password, which can't be used again
+	CreatedAt          time.Time
+	ConfirmedAt        time.Time // zero until the user confirmed the enrollment with a first one time password
+}
+
+// IsConfirmed checks if the second factor is required on sign in
+func (t *TwoFactor) IsConfirmed() bool {
+	return !t.ConfirmedAt.IsZero()
+}
+
+// URI is the otpauth URI of the secret which authenticator apps scan as QR code
+func (t *TwoFactor) URI(account string) string {
+	label := url.PathEscape(TwoFactorIssuer + ":" + account)
+	return fmt.Sprintf("otpauth://totp/%v?secret=%v&issuer=%v", label, t.Secret, url.QueryEscape(TwoFactorIssuer))
+}
+
+// Verify checks a one time password of the current or an adjacent time step or a recovery code,
+// a used one time password or recovery code can't be used again
+func (t *TwoFactor) Verify(code string, now time.Time) bool {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) == TOTPDigits {
+		return t.verifyTOTP(code, now)
+	}
+
+	hash := hashSecret(strings.ToLower(code))
+	index := slices.Index(t.RecoveryCodeHashes, hash)
+	if index < 0 {
+		return false
+	}
+
+	t.RecoveryCodeHashes = slices.Delete(slices.Clone(t.RecoveryCodeHashes), index, index+1)
+	return true
+}
+
+func (t *TwoFactor) verifyTOTP(code string, now time.Time) bool {
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(t.Secret)
+	if err != nil {
+		return false
+	}
+
+	step := now.Unix() / int64(TOTPPeriod/time.Second)
+	for _, s := range []int64{step - 1, step, step + 1} {
+		if s <= t.LastUsedStep {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(totp(secret, s)), []byte(code)) == 1 {
+			t.LastUsedStep = s
+			return true
+		}
+	}
+
+	return false
+}
+
+// totp computes the one time password of the time step as specified in RFC 6238 with HMAC-SHA1
+func totp(secret []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// NewTwoFactorSecret generates the secret of a new second factor
+func NewTwoFactorSecret() (string, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// NewRecoveryCodes generates recovery codes like 7hk2m-q4xzp, which are shown to the user only once, and their hashes
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		random := make([]byte, 7)
+		_, err := rand.Read(random)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(random))[:10]
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashSecret(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
 }
 
 type UserRepository interface {
 	ConfirmUser(ctx context.Context, userID uuid.UUID) error
-	FindUserIDByConfirmationID(ctx context.Context, confirmationID string) (uuid.UUID, error)
+	FindConfirmationByID(ctx context.Context, confirmationID uuid.UUID) (*Confirmation, error)
 	InsertUserWithConfirmationID(ctx context.Context, user *User, confirmationID uuid.UUID) (*User, error)
+	InsertConfirmation(ctx context.Context, userID, confirmationID uuid.UUID) error
+	FindUnconfirmedUserByUsername(ctx context.Context, username string) (*User, error)
+	FindDisabledUserByUsername(ctx context.Context, username string) (*User, error)
+	FindUnconfirmedUsers(ctx context.Context, confirmationCreatedBefore time.Time) ([]*User, error)
+	InsertUserWithRole(ctx context.Context, user *User, role string) (*User, error)
 	FindUserByUsername(ctx context.Context, username string) (*User, error)
 	FindRolesByUserID(ctx context.Context, organizationID, userID uuid.UUID) ([]string, error)
+	UpdateUserTimeZone(ctx context.Context, userID uuid.UUID, timeZone string) error
+	FindUsersByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*User, error)
+	FindUserByID(ctx context.Context, organizationID, userID uuid.UUID) (*User, error)
+	UpdateUserRole(ctx context.Context, organizationID, userID uuid.UUID, role string) error
+	UpdateUserEnabled(ctx context.Context, organizationID, userID uuid.UUID, enabled bool) error
+	DeleteUserByID(ctx context.Context, organizationID, userID uuid.UUID) error
+	UpdateUserPassword(ctx context.Context, userID uuid.UUID, password string) error
+	InsertPasswordReset(ctx context.Context, passwordReset *PasswordReset) (*PasswordReset, error)
+	FindPasswordResetByID(ctx context.Context, passwordResetID uuid.UUID) (*PasswordReset, error)
+	DeletePasswordResetsByUserID(ctx context.Context, userID uuid.UUID) error
+	InsertLoginLink(ctx context.Context, loginLink *LoginLink) (*LoginLink, error)
+	DeleteLoginLinkByID(ctx context.Context, loginLinkID uuid.UUID) (*LoginLink, error)
+	UpdateUserName(ctx context.Context, userID uuid.UUID, name string) error
+	InsertEMailChange(ctx context.Context, emailChange *EMailChange) (*EMailChange, error)
+	FindEMailChangeByConfirmationID(ctx context.Context, confirmationID uuid.UUID) (*EMailChange, error)
+	ConfirmEMailChange(ctx context.Context, emailChange *EMailChange) error
+	InsertMembership(ctx context.Context, organizationID, userID uuid.UUID, role string) error
+	FindMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]*Membership, error)
 }
 
 type OrganizationRepository interface {
 	InsertOrganization(ctx context.Context, organization *Organization) (*Organization, error)
-}
\ No newline at end of file
+	FindOrganizationByID(ctx context.Context, organizationID uuid.UUID) (*Organization, error)
+	UpdateOrganization(ctx context.Context, organization *Organization) (*Organization, error)
+	DeleteOrganizationByID(ctx context.Context, organizationID uuid.UUID) error
+}
+
+type TeamRepository interface {
+	FindTeamsByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*Team, error)
+	FindTeamByID(ctx context.Context, organizationID, teamID uuid.UUID) (*Team, error)
+	InsertTeam(ctx context.Context, team *Team) (*Team, error)
+	UpdateTeam(ctx context.Context, team *Team) (*Team, error)
+	DeleteTeamByID(ctx context.Context, organizationID, teamID uuid.UUID) error
+	RemoveUserFromTeams(ctx context.Context, organizationID, userID uuid.UUID) error
+}
+
+type RoleRepository interface {
+	FindRolesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*OrganizationRole, error)
+	FindRoleByName(ctx context.Context, organizationID uuid.UUID, name string) (*OrganizationRole, error)
+	InsertRole(ctx context.Context, role *OrganizationRole) (*OrganizationRole, error)
+	UpdateRole(ctx context.Context, role *OrganizationRole) (*OrganizationRole, error)
+	DeleteRoleByName(ctx context.Context, organizationID uuid.UUID, name string) error
+}
+
+type SessionRepository interface {
+	FindSessionsByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*Session, error)
+	FindSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*Session, error)
+	FindRevokedSessionIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	InsertSession(ctx context.Context, session *Session) (*Session, error)
+	UpdateSession(ctx context.Context, session *Session) (*Session, error)
+	UpdateSessionOrganization(ctx context.Context, userID, sessionID, organizationID uuid.UUID) error
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, revokedAt time.Time) error
+	RevokeSessionsByUserID(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error)
+}
+
+type TwoFactorRepository interface {
+	FindTwoFactorByUserID(ctx context.Context, userID uuid.UUID) (*TwoFactor, error)
+	InsertTwoFactor(ctx context.Context, twoFactor *TwoFactor) (*TwoFactor, error)
+	UpdateTwoFactor(ctx context.Context, twoFactor *TwoFactor) (*TwoFactor, error)
+	DeleteTwoFactorByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+type LoginThrottleRepository interface {
+	FindLoginThrottles(ctx context.Context, keys []string) ([]*LoginThrottle, error)
+	IncrementLoginFailures(ctx context.Context, key string, failedAt, countedSince time.Time) (*LoginThrottle, error)
+	LockLoginThrottle(ctx context.Context, key string, lockedUntil time.Time) error
+	DeleteLoginThrottle(ctx context.Context, key string) error
+	DeleteStaleLoginThrottles(ctx context.Context, lastFailureBefore time.Time) (int, error)
+}
+
+type APITokenRepository interface {
+	FindAPITokensByUserID(ctx context.Context, organizationID, userID uuid.UUID) ([]*APIToken, error)
+	FindAPITokenByHash(ctx context.Context, tokenHash string) (*APIToken, error)
+	InsertAPIToken(ctx context.Context, apiToken *APIToken) (*APIToken, error)
+	UpdateAPITokenLastUsed(ctx context.Context, apiTokenID uuid.UUID, lastUsedAt time.Time) error
+	DeleteAPITokenByID(ctx context.Context, organizationID, userID, apiTokenID uuid.UUID) error
+	DeleteAPITokensByUserID(ctx context.Context, organizationID, userID uuid.UUID) error
+}
+
+type InvitationRepository interface {
+	InsertInvitation(ctx context.Context, invitation *Invitation) (*Invitation, error)
+	FindInvitationByID(ctx context.Context, invitationID uuid.UUID) (*Invitation, error)
+	FindPendingInvitations(ctx context.Context, organizationID uuid.UUID, now time.Time) ([]*Invitation, error)
+	DeleteInvitationByID(ctx context.Context, organizationID, invitationID uuid.UUID) error
+}
+
+// IsValidTimeZone checks if the time zone is a known IANA time zone
+func IsValidTimeZone(timeZone string) bool {
+	if timeZone == "" || timeZone == "Local" {
+		return false
+	}
+
+	_, err := time.LoadLocation(timeZone)
+	return err == nil
+}
+
+// IsValidUserDeletionPolicy checks if the policy is anonymize or delete
+func IsValidUserDeletionPolicy(userDeletionPolicy string) bool {
+	return userDeletionPolicy == UserDeletionPolicyAnonymize || userDeletionPolicy == UserDeletionPolicyDelete
+}
+
+// ParseWeekday parses the english name of a weekday like Monday
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String(), name) {
+			return weekday, true
+		}
+	}
+
+	return time.Sunday, false
+}
+
+func isValidWeekday(weekday time.Weekday) bool {
+	return weekday >= time.Sunday && weekday <= time.Saturday
+}
@@ -0,0 +1,118 @@
+package user
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// twoFactorSecretSample is the secret of the test vectors of RFC 6238
+const twoFactorSecretSample = "12345678901234567890"
+
+func TestTOTP(t *testing.T) {
+	is := is.New(t)
+
+	secret := []byte(twoFactorSecretSample)
+	is.Equal(totp(secret, 59/30), "287082")
+	is.Equal(totp(secret, 1111111109/30), "081804")
+	is.Equal(totp(secret, 1234567890/30), "005924")
+	is.Equal(totp(secret, 2000000000/30), "279037")
+}
+
+func TestTwoFactorVerify(t *testing.T) {
+	is := is.New(t)
+
+	twoFactor := &TwoFactor{
+		Secret: base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(twoFactorSecretSample)),
+	}
+	now := time.Unix(1111111109, 0)
+
+	t.Run("code of previous time step", func(t *testing.T) {
+		is.True(twoFactor.Verify(totp([]byte(twoFactorSecretSample), 1111111109/30-1), now))
+	})
+	t.Run("code of current time step", func(t *testing.T) {
+		is.True(twoFactor.Verify("081 804", now))
+		is.Equal(twoFactor.LastUsedStep, int64(1111111109/30))
+	})
+	t.Run("used code", func(t *testing.T) {
+		is.True(!twoFactor.Verify("081804", now))
+	})
+	t.Run("code of expired time step", func(t *testing.T) {
+		is.True(!twoFactor.Verify(totp([]byte(twoFactorSecretSample), 1111111109/30-2), now.Add(time.Hour)))
+	})
+	t.Run("wrong code", func(t *testing.T) {
+		is.True(!twoFactor.Verify("123456", now.Add(time.Hour)))
+	})
+}
+
+func TestTwoFactorVerifyRecoveryCode(t *testing.T) {
+	is := is.New(t)
+
+	recoveryCodes, recoveryCodeHashes, err := NewRecoveryCodes()
+	is.NoErr(err)
+	is.Equal(len(recoveryCodes), RecoveryCodeCount)
+	is.Equal(len(recoveryCodes[0]), 11)
+
+	twoFactor := &TwoFactor{
+		RecoveryCodeHashes: recoveryCodeHashes,
+	}
+
+	is.True(twoFactor.Verify(recoveryCodes[3], time.Now()))
+	is.Equal(len(twoFactor.RecoveryCodeHashes), RecoveryCodeCount-1)
+	is.Equal(len(recoveryCodeHashes), RecoveryCodeCount)
+
+	is.True(!twoFactor.Verify(recoveryCodes[3], time.Now()))
+	is.True(twoFactor.Verify(" "+recoveryCodes[4]+" ", time.Now()))
+	is.True(!twoFactor.Verify("abcde-fghij", time.Now()))
+}
+
+func TestTwoFactorURI(t *testing.T) {
+	is := is.New(t)
+
+	twoFactor := &TwoFactor{
+		Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+	}
+
+	is.Equal(twoFactor.URI("admin@baralga.com"), "otpauth://totp/Baralga:admin@baralga.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Baralga")
+}
+
+func TestLoginThrottleRetryAt(t *testing.T) {
+	is := is.New(t)
+
+	lastFailureAt := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	loginThrottle := &LoginThrottle{
+		Key:           AccountLoginThrottleKey("Admin@Baralga.com"),
+		LastFailureAt: lastFailureAt,
+	}
+	is.Equal(loginThrottle.Key, "account:admin@baralga.com")
+
+	loginThrottle.Failures = LoginFailuresBeforeBackoff - 1
+	is.True(loginThrottle.RetryAt(LoginFailuresBeforeBackoff).IsZero())
+
+	loginThrottle.Failures = LoginFailuresBeforeBackoff
+	is.Equal(loginThrottle.RetryAt(LoginFailuresBeforeBackoff), lastFailureAt.Add(LoginBackoff))
+
+	loginThrottle.Failures = LoginFailuresBeforeBackoff + 3
+	is.Equal(loginThrottle.RetryAt(LoginFailuresBeforeBackoff), lastFailureAt.Add(8*LoginBackoff))
+
+	loginThrottle.Failures = LoginFailuresBeforeBackoff + 100
+	is.Equal(loginThrottle.RetryAt(LoginFailuresBeforeBackoff), lastFailureAt.Add(LoginMaxBackoff))
+}
+
+func TestLoginThrottleIsLocked(t *testing.T) {
+	is := is.New(t)
+
+	now := time.Now()
+
+	is.True(!(&LoginThrottle{}).IsLocked(now))
+	is.True((&LoginThrottle{LockedUntil: now.Add(time.Minute)}).IsLocked(now))
+	is.True(!(&LoginThrottle{LockedUntil: now.Add(-time.Minute)}).IsLocked(now))
+}
+
+// currentTOTP is the one time password the authenticator app shows right now
+func currentTOTP(twoFactor *TwoFactor) string {
+	secret, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(twoFactor.Secret)
+	return totp(secret, time.Now().Unix()/int64(TOTPPeriod/time.Second))
+}
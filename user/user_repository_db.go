@@ -39,14 +39,7 @@ func (r *DbUserRepository) insertConfirmation(ctx context.Context, tx pgx.Tx, us
 	return confirmationID, err
 }
 
-func (r *DbUserRepository) InsertUserWithConfirmationID(ctx context.Context, user *User, confirmationID uuid.UUID) (*User, error) {
-	tx := shared.MustTxFromContext(ctx)
-
-	enabled := 0
-	if confirmationID == uuid.Nil {
-		enabled = 1
-	}
-
+func (r *DbUserRepository) insertUser(ctx context.Context, tx pgx.Tx, user *User, enabled int, role string) error {
 	_, err := tx.Exec(
 		ctx,
 		`INSERT INTO users 
@@ -63,7 +56,25 @@ func (r *DbUserRepository) InsertUserWithConfirmationID(ctx context.Context, use
 		user.Origin,
 	)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	return r.insertMembership(ctx, tx, user.OrganizationID, user.ID, role)
+}
+
+func (r *DbUserRepository) insertMembership(ctx context.Context, tx pgx.Tx, organizationID, userID uuid.UUID, role string) error {
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO organization_members 
+		   (user_id, org_id, enabled, created_at) 
+		 VALUES 
+		   ($1, $2, 1, $3)`,
+		userID,
+		organizationID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
 	}
 
 	_, err = tx.Exec(
@@ -71,10 +82,23 @@ func (r *DbUserRepository) InsertUserWithConfirmationID(ctx context.Context, use
 		`INSERT INTO roles 
 		   (user_id, role, org_id) 
 		 VALUES 
-		   ($1, 'ROLE_ADMIN', $2)`,
-		user.ID,
-		user.OrganizationID,
+		   ($1, $2, $3)`,
+		userID,
+		role,
+		organizationID,
 	)
+	return err
+}
+
+func (r *DbUserRepository) InsertUserWithConfirmationID(ctx context.Context, user *User, confirmationID uuid.UUID) (*User, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	enabled := 0
+	if confirmationID == uuid.Nil {
+		enabled = 1
+	}
+
+	err := r.insertUser(ctx, tx, user, enabled, "ROLE_ADMIN")
 	if err != nil {
 		return nil, err
 	}
@@ -91,28 +115,183 @@ func (r *DbUserRepository) InsertUserWithConfirmationID(ctx context.Context, use
 	return user, nil
 }
 
-func (r *DbUserRepository) FindUserIDByConfirmationID(ctx context.Context, confirmationID string) (uuid.UUID, error) {
+// InsertUserWithRole inserts an enabled user with the role in the organization of the user
+func (r *DbUserRepository) InsertUserWithRole(ctx context.Context, user *User, role string) (*User, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	err := r.insertUser(ctx, tx, user, 1, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// FindConfirmationByID finds a signup confirmation, expired ones included
+func (r *DbUserRepository) FindConfirmationByID(ctx context.Context, confirmationID uuid.UUID) (*Confirmation, error) {
 	row := r.connPool.QueryRow(
 		ctx,
-		`SELECT user_id 
+		`SELECT user_id, created_at 
 		 FROM user_confirmations 
-		 WHERE user_confirmation_id = $1`, confirmationID,
+		 WHERE user_confirmation_id = $1 AND email IS NULL`, confirmationID,
+	)
+
+	var (
+		userID    string
+		createdAt time.Time
+	)
+
+	err := row.Scan(&userID, &createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrConfirmationNotFound
+		}
+
+		return nil, err
+	}
+
+	confirmation := &Confirmation{
+		ID:        confirmationID,
+		UserID:    uuid.MustParse(userID),
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(ConfirmationValidity),
+	}
+	return confirmation, nil
+}
+
+// InsertConfirmation inserts a new signup confirmation which replaces the older ones of the user
+func (r *DbUserRepository) InsertConfirmation(ctx context.Context, userID, confirmationID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM user_confirmations 
+		 WHERE user_id = $1 AND email IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.insertConfirmation(ctx, tx, &User{ID: userID}, confirmationID)
+	return err
+}
+
+// FindUnconfirmedUserByUsername finds a signed up user who has not yet confirmed the email
+func (r *DbUserRepository) FindUnconfirmedUserByUsername(ctx context.Context, username string) (*User, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT u.user_id, u.name, COALESCE(u.email, ''), u.password, u.org_id 
+		 FROM users u 
+		 WHERE u.username = $1 AND u.enabled = 0 
+		   AND EXISTS (SELECT 1 FROM user_confirmations c WHERE c.user_id = u.user_id AND c.email IS NULL)`, username,
 	)
 
 	var (
-		userID string
+		id             string
+		name           string
+		email          string
+		password       string
+		organizationID string
 	)
 
-	err := row.Scan(&userID)
+	err := row.Scan(&id, &name, &email, &password, &organizationID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.Nil, ErrUserNotFound
+			return nil, ErrUserNotFound
 		}
 
-		return uuid.Nil, err
+		return nil, err
 	}
 
-	return uuid.MustParse(userID), nil
+	user := &User{
+		ID:             uuid.MustParse(id),
+		Name:           name,
+		Username:       username,
+		EMail:          email,
+		Password:       password,
+		OrganizationID: uuid.MustParse(organizationID),
+	}
+	return user, nil
+}
+
+// FindDisabledUserByUsername finds a user who is disabled by an admin or has not yet confirmed the email
+func (r *DbUserRepository) FindDisabledUserByUsername(ctx context.Context, username string) (*User, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT u.user_id, u.name, COALESCE(u.email, ''), COALESCE(u.origin, ''), u.org_id 
+		 FROM users u 
+		 WHERE u.username = $1 AND u.enabled = 0`, username,
+	)
+
+	var (
+		id             string
+		name           string
+		email          string
+		origin         string
+		organizationID string
+	)
+
+	err := row.Scan(&id, &name, &email, &origin, &organizationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	user := &User{
+		ID:             uuid.MustParse(id),
+		Name:           name,
+		Username:       username,
+		EMail:          email,
+		Origin:         origin,
+		OrganizationID: uuid.MustParse(organizationID),
+	}
+	return user, nil
+}
+
+// FindUnconfirmedUsers finds the unconfirmed users whose last confirmation was created before the given time
+func (r *DbUserRepository) FindUnconfirmedUsers(ctx context.Context, confirmationCreatedBefore time.Time) ([]*User, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT u.user_id, u.username, COALESCE(u.email, ''), u.org_id 
+		 FROM users u 
+		 JOIN user_confirmations c ON c.user_id = u.user_id AND c.email IS NULL 
+		 WHERE u.enabled = 0 
+		 GROUP BY u.user_id, u.username, u.email, u.org_id 
+		 HAVING MAX(c.created_at) < $1`, confirmationCreatedBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		var (
+			id             string
+			username       string
+			email          string
+			organizationID string
+		)
+
+		err = rows.Scan(&id, &username, &email, &organizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		user := &User{
+			ID:             uuid.MustParse(id),
+			Username:       username,
+			EMail:          email,
+			OrganizationID: uuid.MustParse(organizationID),
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
 }
 
 func (r *DbUserRepository) ConfirmUser(ctx context.Context, userID uuid.UUID) error {
@@ -145,19 +324,23 @@ func (r *DbUserRepository) ConfirmUser(ctx context.Context, userID uuid.UUID) er
 func (r *DbUserRepository) FindUserByUsername(ctx context.Context, username string) (*User, error) {
 	row := r.connPool.QueryRow(
 		ctx,
-		`SELECT user_id, name, password, org_id 
-		 FROM users 
-		 WHERE username = $1 AND enabled = 1`, username,
+		`SELECT u.user_id, u.name, COALESCE(u.email, ''), u.password, COALESCE(u.origin, ''), u.org_id, COALESCE(u.time_zone, o.time_zone) 
+		 FROM users u 
+		 JOIN organizations o ON u.org_id = o.org_id 
+		 WHERE u.username = $1 AND u.enabled = 1`, username,
 	)
 
 	var (
 		id             string
 		name           string
+		email          string
 		password       string
+		origin         string
 		organizationID string
+		timeZone       string
 	)
 
-	err := row.Scan(&id, &name, &password, &organizationID)
+	err := row.Scan(&id, &name, &email, &password, &origin, &organizationID, &timeZone)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
@@ -170,12 +353,30 @@ func (r *DbUserRepository) FindUserByUsername(ctx context.Context, username stri
 		ID:             uuid.MustParse(id),
 		Name:           name,
 		Username:       username,
+		EMail:          email,
 		Password:       password,
+		Origin:         origin,
 		OrganizationID: uuid.MustParse(organizationID),
+		TimeZone:       timeZone,
+		Enabled:        true,
 	}
 	return user, nil
 }
 
+func (r *DbUserRepository) UpdateUserTimeZone(ctx context.Context, userID uuid.UUID, timeZone string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		 SET time_zone = $2 
+		 WHERE user_id = $1`,
+		userID,
+		timeZone,
+	)
+	return err
+}
+
 func (r *DbUserRepository) FindRolesByUserID(ctx context.Context, organizationID, userID uuid.UUID) ([]string, error) {
 	rows, err := r.connPool.Query(
 		ctx,
@@ -202,3 +403,499 @@ func (r *DbUserRepository) FindRolesByUserID(ctx context.Context, organizationID
 
 	return roles, nil
 }
+
+// FindUsersByOrganizationID finds all enabled and disabled members of the organization with their roles
+func (r *DbUserRepository) FindUsersByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*User, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT u.user_id, COALESCE(u.name, ''), u.username, COALESCE(u.email, ''), COALESCE(u.origin, ''), u.enabled * m.enabled, COALESCE(u.time_zone, o.time_zone) 
+		 FROM organization_members m 
+		 JOIN users u ON m.user_id = u.user_id 
+		 JOIN organizations o ON m.org_id = o.org_id 
+		 WHERE m.org_id = $1
+		 ORDER BY u.name, u.username`, organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	usersByID := make(map[uuid.UUID]*User)
+	for rows.Next() {
+		var (
+			id       string
+			name     string
+			username string
+			email    string
+			origin   string
+			enabled  int
+			timeZone string
+		)
+
+		err = rows.Scan(&id, &name, &username, &email, &origin, &enabled, &timeZone)
+		if err != nil {
+			return nil, err
+		}
+
+		user := &User{
+			ID:             uuid.MustParse(id),
+			Name:           name,
+			Username:       username,
+			EMail:          email,
+			Origin:         origin,
+			OrganizationID: organizationID,
+			TimeZone:       timeZone,
+			Enabled:        enabled == 1,
+		}
+		users = append(users, user)
+		usersByID[user.ID] = user
+	}
+	rows.Close()
+
+	roleRows, err := r.connPool.Query(
+		ctx,
+		`SELECT user_id, role 
+		 FROM roles 
+		 WHERE org_id = $1
+		 ORDER BY role`, organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer roleRows.Close()
+
+	for roleRows.Next() {
+		var (
+			userID string
+			role   string
+		)
+
+		err = roleRows.Scan(&userID, &role)
+		if err != nil {
+			return nil, err
+		}
+
+		if user, ok := usersByID[uuid.MustParse(userID)]; ok {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+
+	return users, nil
+}
+
+// FindUserByID finds an enabled or disabled user of the organization with the roles
+func (r *DbUserRepository) FindUserByID(ctx context.Context, organizationID, userID uuid.UUID) (*User, error) {
+	users, err := r.FindUsersByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+
+	return nil, ErrUserNotFound
+}
+
+// UpdateUserRole replaces the roles of the user in the organization with the role
+func (r *DbUserRepository) UpdateUserRole(ctx context.Context, organizationID, userID uuid.UUID, role string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM roles 
+		 WHERE user_id = $1 AND org_id = $2`,
+		userID,
+		organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO roles 
+		   (user_id, role, org_id) 
+		 VALUES 
+		   ($1, $2, $3)`,
+		userID,
+		role,
+		organizationID,
+	)
+	return err
+}
+
+func (r *DbUserRepository) UpdateUserEnabled(ctx context.Context, organizationID, userID uuid.UUID, enabled bool) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	enabledValue := 0
+	if enabled {
+		enabledValue = 1
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		`UPDATE organization_members
+		 SET enabled = $3 
+		 WHERE user_id = $1 AND org_id = $2`,
+		userID,
+		organizationID,
+		enabledValue,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// DeleteUserByID removes the user from the organization. The user is deleted
+// once the user is no longer member of any organization.
+func (r *DbUserRepository) DeleteUserByID(ctx context.Context, organizationID, userID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM roles 
+		 WHERE user_id = $1 AND org_id = $2`,
+		userID,
+		organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	row := tx.QueryRow(ctx,
+		`DELETE 
+         FROM organization_members 
+	     WHERE user_id = $1 AND org_id = $2
+		 RETURNING user_id`,
+		userID, organizationID)
+
+	var id string
+	err = row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+
+		return err
+	}
+
+	// Move the user to the oldest remaining membership
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE users
+		 SET org_id = (SELECT m.org_id FROM organization_members m WHERE m.user_id = $1 ORDER BY m.created_at LIMIT 1) 
+		 WHERE user_id = $1 AND org_id = $2 
+		   AND EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = $1)`,
+		userID,
+		organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM user_confirmations 
+		 WHERE user_id = $1 
+		   AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = $1)`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM users 
+		 WHERE user_id = $1 
+		   AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.user_id = $1)`,
+		userID,
+	)
+	return err
+}
+
+func (r *DbUserRepository) UpdateUserPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		 SET password = $2 
+		 WHERE user_id = $1`,
+		userID,
+		password,
+	)
+	return err
+}
+
+func (r *DbUserRepository) InsertPasswordReset(ctx context.Context, passwordReset *PasswordReset) (*PasswordReset, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO user_password_resets 
+		   (user_password_reset_id, user_id, created_at, expires_at) 
+		 VALUES 
+		   ($1, $2, $3, $4)`,
+		passwordReset.ID,
+		passwordReset.UserID,
+		passwordReset.CreatedAt,
+		passwordReset.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return passwordReset, nil
+}
+
+func (r *DbUserRepository) FindPasswordResetByID(ctx context.Context, passwordResetID uuid.UUID) (*PasswordReset, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT user_id, created_at, expires_at 
+		 FROM user_password_resets 
+		 WHERE user_password_reset_id = $1`, passwordResetID,
+	)
+
+	var (
+		userID    string
+		createdAt time.Time
+		expiresAt time.Time
+	)
+
+	err := row.Scan(&userID, &createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPasswordResetNotFound
+		}
+
+		return nil, err
+	}
+
+	passwordReset := &PasswordReset{
+		ID:        passwordResetID,
+		UserID:    uuid.MustParse(userID),
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+	return passwordReset, nil
+}
+
+func (r *DbUserRepository) DeletePasswordResetsByUserID(ctx context.Context, userID uuid.UUID) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM user_password_resets 
+		 WHERE user_id = $1`,
+		userID,
+	)
+	return err
+}
+
+func (r *DbUserRepository) InsertLoginLink(ctx context.Context, loginLink *LoginLink) (*LoginLink, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO user_login_links 
+		   (user_login_link_id, user_id, created_at, expires_at) 
+		 VALUES 
+		   ($1, $2, $3, $4)`,
+		loginLink.ID,
+		loginLink.UserID,
+		loginLink.CreatedAt,
+		loginLink.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return loginLink, nil
+}
+
+// DeleteLoginLinkByID deletes the login link and returns it, so that only one of concurrent requests can use it
+func (r *DbUserRepository) DeleteLoginLinkByID(ctx context.Context, loginLinkID uuid.UUID) (*LoginLink, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	row := tx.QueryRow(
+		ctx,
+		`DELETE FROM user_login_links 
+		 WHERE user_login_link_id = $1
+		 RETURNING user_id, created_at, expires_at`,
+		loginLinkID,
+	)
+
+	var (
+		userID    string
+		createdAt time.Time
+		expiresAt time.Time
+	)
+
+	err := row.Scan(&userID, &createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLoginLinkNotFound
+		}
+
+		return nil, err
+	}
+
+	loginLink := &LoginLink{
+		ID:        loginLinkID,
+		UserID:    uuid.MustParse(userID),
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}
+	return loginLink, nil
+}
+
+func (r *DbUserRepository) UpdateUserName(ctx context.Context, userID uuid.UUID, name string) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		 SET name = $2 
+		 WHERE user_id = $1`,
+		userID,
+		name,
+	)
+	return err
+}
+
+// InsertEMailChange inserts a confirmation with the new email of the user
+func (r *DbUserRepository) InsertEMailChange(ctx context.Context, emailChange *EMailChange) (*EMailChange, error) {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO user_confirmations 
+		   (user_confirmation_id, user_id, created_at, email) 
+		 VALUES 
+		   ($1, $2, $3, $4)`,
+		emailChange.ConfirmationID,
+		emailChange.UserID,
+		emailChange.CreatedAt,
+		emailChange.EMail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return emailChange, nil
+}
+
+// FindEMailChangeByConfirmationID finds a pending email change, expired ones included
+func (r *DbUserRepository) FindEMailChangeByConfirmationID(ctx context.Context, confirmationID uuid.UUID) (*EMailChange, error) {
+	row := r.connPool.QueryRow(
+		ctx,
+		`SELECT user_id, email, created_at 
+		 FROM user_confirmations 
+		 WHERE user_confirmation_id = $1 AND email IS NOT NULL`, confirmationID,
+	)
+
+	var (
+		userID    string
+		email     string
+		createdAt time.Time
+	)
+
+	err := row.Scan(&userID, &email, &createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEMailChangeNotFound
+		}
+
+		return nil, err
+	}
+
+	emailChange := &EMailChange{
+		ConfirmationID: confirmationID,
+		UserID:         uuid.MustParse(userID),
+		EMail:          email,
+		CreatedAt:      createdAt,
+		ExpiresAt:      createdAt.Add(EMailChangeValidity),
+	}
+	return emailChange, nil
+}
+
+// ConfirmEMailChange sets the new email of the user and removes all pending email changes of the user
+func (r *DbUserRepository) ConfirmEMailChange(ctx context.Context, emailChange *EMailChange) error {
+	tx := shared.MustTxFromContext(ctx)
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM user_confirmations 
+		 WHERE user_id = $1 AND email IS NOT NULL`,
+		emailChange.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE users
+		 SET email = $2 
+		 WHERE user_id = $1`,
+		emailChange.UserID,
+		emailChange.EMail,
+	)
+	return err
+}
+
+// InsertMembership makes an existing user an enabled member of the organization with the role
+func (r *DbUserRepository) InsertMembership(ctx context.Context, organizationID, userID uuid.UUID, role string) error {
+	tx := shared.MustTxFromContext(ctx)
+	return r.insertMembership(ctx, tx, organizationID, userID, role)
+}
+
+// FindMembershipsByUserID finds the enabled and disabled memberships of the user ordered by organization
+func (r *DbUserRepository) FindMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]*Membership, error) {
+	rows, err := r.connPool.Query(
+		ctx,
+		`SELECT m.org_id, COALESCE(o.title, ''), m.enabled 
+		 FROM organization_members m 
+		 JOIN organizations o ON m.org_id = o.org_id 
+		 WHERE m.user_id = $1
+		 ORDER BY o.title, m.created_at`, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []*Membership
+	for rows.Next() {
+		var (
+			organizationID string
+			title          string
+			enabled        int
+		)
+
+		err = rows.Scan(&organizationID, &title, &enabled)
+		if err != nil {
+			return nil, err
+		}
+
+		membership := &Membership{
+			OrganizationID:    uuid.MustParse(organizationID),
+			OrganizationTitle: title,
+			Enabled:           enabled == 1,
+		}
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, nil
+}
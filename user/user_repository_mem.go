@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/google/uuid"
@@ -9,7 +10,11 @@ import (
 )
 
 type InMemUserRepository struct {
-	users []*User
+	users          []*User
+	confirmations  []*Confirmation
+	passwordResets []*PasswordReset
+	loginLinks     []*LoginLink
+	emailChanges   []*EMailChange
 }
 
 var _ UserRepository = (*InMemUserRepository)(nil)
@@ -23,6 +28,17 @@ func NewInMemUserRepository() *InMemUserRepository {
 				EMail:          "admin@baralga.com",
 				Password:       "$2a$10$NuzYobDOSTCx/EKBClGwGe0A9c8/yC7D4IP75hwz1jn.RCBfdEtb2",
 				OrganizationID: shared.OrganizationIDSample,
+				TimeZone:       DefaultTimeZone,
+				Enabled:        true,
+				Roles:          []string{RoleAdmin},
+			},
+		},
+		confirmations: []*Confirmation{
+			{
+				ID:        shared.ConfirmationIdSample,
+				UserID:    uuid.MustParse("00000000-0000-0000-1111-000000000001"),
+				CreatedAt: time.Now(),
+				ExpiresAt: time.Now().Add(ConfirmationValidity),
 			},
 		},
 	}
@@ -30,13 +46,26 @@ func NewInMemUserRepository() *InMemUserRepository {
 
 func (r *InMemUserRepository) FindUserByUsername(ctx context.Context, username string) (*User, error) {
 	for _, a := range r.users {
-		if a.Username == username {
+		if a.Username == username && a.Enabled {
 			return a, nil
 		}
 	}
 	return nil, ErrUserNotFound
 }
 
+func (r *InMemUserRepository) isUnconfirmed(user *User) bool {
+	if user.Enabled {
+		return false
+	}
+
+	for _, c := range r.confirmations {
+		if c.UserID == user.ID {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *InMemUserRepository) FindRolesByUserID(ctx context.Context, organizationID, userID uuid.UUID) ([]string, error) {
 	return []string{"ROLE_ADMIN"}, nil
 }
@@ -46,16 +75,278 @@ func (r *InMemUserRepository) InsertUserWithConfirmationID(ctx context.Context,
 		return nil, errors.New("error for tests")
 	}
 	r.users = append(r.users, user)
+
+	if confirmationID == uuid.Nil {
+		return user, nil
+	}
+
+	return user, r.InsertConfirmation(ctx, user.ID, confirmationID)
+}
+
+func (r *InMemUserRepository) InsertConfirmation(ctx context.Context, userID, confirmationID uuid.UUID) error {
+	confirmations := make([]*Confirmation, 0, len(r.confirmations)+1)
+	for _, c := range r.confirmations {
+		if c.UserID != userID {
+			confirmations = append(confirmations, c)
+		}
+	}
+
+	now := time.Now()
+	r.confirmations = append(confirmations, &Confirmation{
+		ID:        confirmationID,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ConfirmationValidity),
+	})
+	return nil
+}
+
+func (r *InMemUserRepository) FindUnconfirmedUserByUsername(ctx context.Context, username string) (*User, error) {
+	for _, u := range r.users {
+		if u.Username == username && r.isUnconfirmed(u) {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *InMemUserRepository) FindDisabledUserByUsername(ctx context.Context, username string) (*User, error) {
+	for _, u := range r.users {
+		if u.Username == username && !u.Enabled {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *InMemUserRepository) FindUnconfirmedUsers(ctx context.Context, confirmationCreatedBefore time.Time) ([]*User, error) {
+	var users []*User
+	for _, u := range r.users {
+		if !r.isUnconfirmed(u) {
+			continue
+		}
+
+		stale := true
+		for _, c := range r.confirmations {
+			if c.UserID == u.ID && !c.CreatedAt.Before(confirmationCreatedBefore) {
+				stale = false
+			}
+		}
+
+		if stale {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (r *InMemUserRepository) InsertUserWithRole(ctx context.Context, user *User, role string) (*User, error) {
+	user.Enabled = true
+	user.Roles = []string{role}
+	r.users = append(r.users, user)
 	return user, nil
 }
 
-func (r *InMemUserRepository) FindUserIDByConfirmationID(ctx context.Context, confirmationID string) (uuid.UUID, error) {
-	if confirmationID == shared.ConfirmationIdSample.String() {
-		return r.users[0].ID, nil
+func (r *InMemUserRepository) FindConfirmationByID(ctx context.Context, confirmationID uuid.UUID) (*Confirmation, error) {
+	for _, c := range r.confirmations {
+		if c.ID == confirmationID {
+			return c, nil
+		}
 	}
-	return uuid.Nil, ErrUserNotFound
+	return nil, ErrConfirmationNotFound
 }
 
 func (r *InMemUserRepository) ConfirmUser(ctx context.Context, userID uuid.UUID) error {
+	confirmations := make([]*Confirmation, 0, len(r.confirmations))
+	for _, c := range r.confirmations {
+		if c.UserID != userID {
+			confirmations = append(confirmations, c)
+		}
+	}
+	r.confirmations = confirmations
+
+	for _, u := range r.users {
+		if u.ID == userID {
+			u.Enabled = true
+		}
+	}
+	return nil
+}
+
+func (r *InMemUserRepository) UpdateUserTimeZone(ctx context.Context, userID uuid.UUID, timeZone string) error {
+	for _, u := range r.users {
+		if u.ID == userID {
+			u.TimeZone = timeZone
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (r *InMemUserRepository) FindUsersByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*User, error) {
+	var users []*User
+	for _, u := range r.users {
+		if u.OrganizationID == organizationID {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (r *InMemUserRepository) FindUserByID(ctx context.Context, organizationID, userID uuid.UUID) (*User, error) {
+	for _, u := range r.users {
+		if u.ID == userID && u.OrganizationID == organizationID {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *InMemUserRepository) UpdateUserRole(ctx context.Context, organizationID, userID uuid.UUID, role string) error {
+	u, err := r.FindUserByID(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	u.Roles = []string{role}
+	return nil
+}
+
+func (r *InMemUserRepository) UpdateUserEnabled(ctx context.Context, organizationID, userID uuid.UUID, enabled bool) error {
+	u, err := r.FindUserByID(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	u.Enabled = enabled
 	return nil
 }
+
+func (r *InMemUserRepository) DeleteUserByID(ctx context.Context, organizationID, userID uuid.UUID) error {
+	for i, u := range r.users {
+		if u.ID == userID && u.OrganizationID == organizationID {
+			r.users = append(r.users[:i], r.users[i+1:]...)
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (r *InMemUserRepository) UpdateUserPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	for _, u := range r.users {
+		if u.ID == userID {
+			u.Password = password
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (r *InMemUserRepository) InsertPasswordReset(ctx context.Context, passwordReset *PasswordReset) (*PasswordReset, error) {
+	r.passwordResets = append(r.passwordResets, passwordReset)
+	return passwordReset, nil
+}
+
+func (r *InMemUserRepository) FindPasswordResetByID(ctx context.Context, passwordResetID uuid.UUID) (*PasswordReset, error) {
+	for _, p := range r.passwordResets {
+		if p.ID == passwordResetID {
+			return p, nil
+		}
+	}
+	return nil, ErrPasswordResetNotFound
+}
+
+func (r *InMemUserRepository) DeletePasswordResetsByUserID(ctx context.Context, userID uuid.UUID) error {
+	var passwordResets []*PasswordReset
+	for _, p := range r.passwordResets {
+		if p.UserID != userID {
+			passwordResets = append(passwordResets, p)
+		}
+	}
+	r.passwordResets = passwordResets
+	return nil
+}
+
+func (r *InMemUserRepository) InsertLoginLink(ctx context.Context, loginLink *LoginLink) (*LoginLink, error) {
+	r.loginLinks = append(r.loginLinks, loginLink)
+	return loginLink, nil
+}
+
+func (r *InMemUserRepository) DeleteLoginLinkByID(ctx context.Context, loginLinkID uuid.UUID) (*LoginLink, error) {
+	for i, l := range r.loginLinks {
+		if l.ID == loginLinkID {
+			r.loginLinks = append(r.loginLinks[:i], r.loginLinks[i+1:]...)
+			return l, nil
+		}
+	}
+	return nil, ErrLoginLinkNotFound
+}
+
+func (r *InMemUserRepository) UpdateUserName(ctx context.Context, userID uuid.UUID, name string) error {
+	for _, u := range r.users {
+		if u.ID == userID {
+			u.Name = name
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (r *InMemUserRepository) InsertEMailChange(ctx context.Context, emailChange *EMailChange) (*EMailChange, error) {
+	r.emailChanges = append(r.emailChanges, emailChange)
+	return emailChange, nil
+}
+
+func (r *InMemUserRepository) FindEMailChangeByConfirmationID(ctx context.Context, confirmationID uuid.UUID) (*EMailChange, error) {
+	for _, e := range r.emailChanges {
+		if e.ConfirmationID == confirmationID {
+			return e, nil
+		}
+	}
+	return nil, ErrEMailChangeNotFound
+}
+
+func (r *InMemUserRepository) ConfirmEMailChange(ctx context.Context, emailChange *EMailChange) error {
+	var emailChanges []*EMailChange
+	for _, e := range r.emailChanges {
+		if e.UserID != emailChange.UserID {
+			emailChanges = append(emailChanges, e)
+		}
+	}
+	r.emailChanges = emailChanges
+
+	for _, u := range r.users {
+		if u.ID == emailChange.UserID {
+			u.EMail = emailChange.EMail
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+// InsertMembership adds a copy of the user as member of the organization
+func (r *InMemUserRepository) InsertMembership(ctx context.Context, organizationID, userID uuid.UUID, role string) error {
+	for _, u := range r.users {
+		if u.ID == userID {
+			member := *u
+			member.OrganizationID = organizationID
+			member.Enabled = true
+			member.Roles = []string{role}
+			r.users = append(r.users, &member)
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (r *InMemUserRepository) FindMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]*Membership, error) {
+	var memberships []*Membership
+	for _, u := range r.users {
+		if u.ID == userID {
+			membership := &Membership{
+				OrganizationID: u.OrganizationID,
+				Enabled:        u.Enabled,
+			}
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/google/uuid"
@@ -43,6 +44,29 @@ func TestUserRepository(t *testing.T) {
 
 		is.NoErr(err)
 		is.Equal(adminUser.Username, "admin@baralga.com")
+		is.Equal(adminUser.TimeZone, DefaultTimeZone)
+	})
+
+	t.Run("UpdateUserTimeZone", func(t *testing.T) {
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return userRepository.UpdateUserTimeZone(
+					ctx,
+					shared.UserIDAdminSample,
+					"America/New_York",
+				)
+			},
+		)
+		is.NoErr(err)
+
+		adminUser, err := userRepository.FindUserByUsername(
+			context.Background(),
+			"admin@baralga.com",
+		)
+
+		is.NoErr(err)
+		is.Equal(adminUser.TimeZone, "America/New_York")
 	})
 
 	t.Run("FindNotExistingUserByUsername", func(t *testing.T) {
@@ -101,12 +125,28 @@ func TestUserRepository(t *testing.T) {
 		)
 		is.NoErr(err)
 
-		userIdByConfId, err := userRepository.FindUserIDByConfirmationID(
+		confirmation, err := userRepository.FindConfirmationByID(
 			context.Background(),
-			confirmationID.String(),
+			confirmationID,
 		)
 		is.NoErr(err)
-		is.Equal(user.ID, userIdByConfId)
+		is.Equal(user.ID, confirmation.UserID)
+		is.True(!confirmation.IsExpired(time.Now()))
+
+		unconfirmedUser, err := userRepository.FindUnconfirmedUserByUsername(context.Background(), user.Username)
+		is.NoErr(err)
+		is.Equal(unconfirmedUser.ID, user.ID)
+
+		unconfirmedUsers, err := userRepository.FindUnconfirmedUsers(context.Background(), time.Now().Add(time.Minute))
+		is.NoErr(err)
+		is.Equal(len(unconfirmedUsers), 1)
+
+		unconfirmedUsers, err = userRepository.FindUnconfirmedUsers(context.Background(), time.Now().Add(-time.Minute))
+		is.NoErr(err)
+		is.Equal(len(unconfirmedUsers), 0)
+
+		_, err = userRepository.FindUserByUsername(context.Background(), user.Username)
+		is.True(errors.Is(err, ErrUserNotFound))
 
 		err = repositoryTxer.InTx(
 			context.Background(),
@@ -145,10 +185,297 @@ func TestUserRepository(t *testing.T) {
 		)
 		is.NoErr(err)
 
-		_, err = userRepository.FindUserIDByConfirmationID(
+		_, err = userRepository.FindConfirmationByID(
+			context.Background(),
+			confirmationID,
+		)
+		is.True(errors.Is(err, ErrConfirmationNotFound))
+	})
+
+	t.Run("InsertUserWithRole", func(t *testing.T) {
+		user := &User{
+			ID:             uuid.New(),
+			Name:           "Ivy Invited",
+			Username:       "ivy.invited@baralga.com",
+			EMail:          "ivy.invited@baralga.com",
+			OrganizationID: shared.OrganizationIDSample,
+			Origin:         "baralga",
+		}
+
+		err := repositoryTxer.InTx(
 			context.Background(),
-			confirmationID.String(),
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertUserWithRole(ctx, user, "ROLE_USER")
+				return err
+			},
 		)
+		is.NoErr(err)
+
+		invitedUser, err := userRepository.FindUserByUsername(context.Background(), "ivy.invited@baralga.com")
+		is.NoErr(err)
+		is.Equal(invitedUser.OrganizationID, shared.OrganizationIDSample)
+
+		roles, err := userRepository.FindRolesByUserID(context.Background(), shared.OrganizationIDSample, user.ID)
+		is.NoErr(err)
+		is.Equal(roles, []string{"ROLE_USER"})
+	})
+
+	t.Run("FindUsersByOrganizationID", func(t *testing.T) {
+		users, err := userRepository.FindUsersByOrganizationID(context.Background(), shared.OrganizationIDSample)
+		is.NoErr(err)
+		is.True(len(users) >= 3)
+
+		adminUser, err := userRepository.FindUserByID(context.Background(), shared.OrganizationIDSample, shared.UserIDAdminSample)
+		is.NoErr(err)
+		is.True(adminUser.Enabled)
+		is.True(adminUser.IsAdmin())
+	})
+
+	t.Run("UpdateUserRoleAndEnabled", func(t *testing.T) {
+		user := &User{
+			ID:             uuid.New(),
+			Name:           "Rita Role",
+			Username:       "rita.role@baralga.com",
+			EMail:          "rita.role@baralga.com",
+			OrganizationID: shared.OrganizationIDSample,
+			Origin:         "baralga",
+		}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertUserWithRole(ctx, user, RoleUser)
+				return err
+			},
+			func(ctx context.Context) error {
+				return userRepository.UpdateUserRole(ctx, shared.OrganizationIDSample, user.ID, RoleAdmin)
+			},
+			func(ctx context.Context) error {
+				return userRepository.UpdateUserEnabled(ctx, shared.OrganizationIDSample, user.ID, false)
+			},
+		)
+		is.NoErr(err)
+
+		updatedUser, err := userRepository.FindUserByID(context.Background(), shared.OrganizationIDSample, user.ID)
+		is.NoErr(err)
+		is.Equal(updatedUser.Roles, []string{RoleAdmin})
+		is.True(!updatedUser.Enabled)
+
+		_, err = userRepository.FindUserByUsername(context.Background(), "rita.role@baralga.com")
+		is.True(errors.Is(err, ErrUserNotFound))
+	})
+
+	t.Run("DeleteUserByID", func(t *testing.T) {
+		user := &User{
+			ID:             uuid.New(),
+			Name:           "Remy Removed",
+			Username:       "remy.removed@baralga.com",
+			EMail:          "remy.removed@baralga.com",
+			OrganizationID: shared.OrganizationIDSample,
+			Origin:         "baralga",
+		}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertUserWithConfirmationID(ctx, user, uuid.New())
+				return err
+			},
+			func(ctx context.Context) error {
+				return userRepository.DeleteUserByID(ctx, shared.OrganizationIDSample, user.ID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = userRepository.FindUserByID(context.Background(), shared.OrganizationIDSample, user.ID)
+		is.True(errors.Is(err, ErrUserNotFound))
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return userRepository.DeleteUserByID(ctx, shared.OrganizationIDSample, user.ID)
+			},
+		)
+		is.True(errors.Is(err, ErrUserNotFound))
+	})
+
+	t.Run("PasswordReset", func(t *testing.T) {
+		passwordReset := &PasswordReset{
+			ID:        uuid.New(),
+			UserID:    shared.UserIDAdminSample,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(PasswordResetValidity),
+		}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertPasswordReset(ctx, passwordReset)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		foundPasswordReset, err := userRepository.FindPasswordResetByID(context.Background(), passwordReset.ID)
+		is.NoErr(err)
+		is.Equal(foundPasswordReset.UserID, shared.UserIDAdminSample)
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return userRepository.UpdateUserPassword(ctx, shared.UserIDAdminSample, "$2a$10$NuzYobDOSTCx/EKBClGwGe0A9c8/yC7D4IP75hwz1jn.RCBfdEtb2")
+			},
+			func(ctx context.Context) error {
+				return userRepository.DeletePasswordResetsByUserID(ctx, shared.UserIDAdminSample)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = userRepository.FindPasswordResetByID(context.Background(), passwordReset.ID)
+		is.True(errors.Is(err, ErrPasswordResetNotFound))
+	})
+
+	t.Run("LoginLink", func(t *testing.T) {
+		loginLink := &LoginLink{
+			ID:        uuid.New(),
+			UserID:    shared.UserIDAdminSample,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(LoginLinkValidity),
+		}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertLoginLink(ctx, loginLink)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		var deletedLoginLink *LoginLink
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				var err error
+				deletedLoginLink, err = userRepository.DeleteLoginLinkByID(ctx, loginLink.ID)
+				return err
+			},
+		)
+		is.NoErr(err)
+		is.Equal(deletedLoginLink.UserID, shared.UserIDAdminSample)
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := userRepository.DeleteLoginLinkByID(ctx, loginLink.ID)
+				return err
+			},
+		)
+		is.True(errors.Is(err, ErrLoginLinkNotFound))
+	})
+
+	t.Run("EMailChange", func(t *testing.T) {
+		emailChange := &EMailChange{
+			ConfirmationID: uuid.New(),
+			UserID:         shared.UserIDAdminSample,
+			EMail:          "ed@baralga.com",
+			CreatedAt:      time.Now(),
+		}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertEMailChange(ctx, emailChange)
+				return err
+			},
+		)
+		is.NoErr(err)
+
+		_, err = userRepository.FindConfirmationByID(context.Background(), emailChange.ConfirmationID)
+		is.True(errors.Is(err, ErrConfirmationNotFound))
+
+		foundEMailChange, err := userRepository.FindEMailChangeByConfirmationID(context.Background(), emailChange.ConfirmationID)
+		is.NoErr(err)
+		is.Equal(foundEMailChange.EMail, "ed@baralga.com")
+		is.True(!foundEMailChange.IsExpired(time.Now()))
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return userRepository.ConfirmEMailChange(ctx, foundEMailChange)
+			},
+		)
+		is.NoErr(err)
+
+		user, err := userRepository.FindUserByUsername(context.Background(), "admin@baralga.com")
+		is.NoErr(err)
+		is.Equal(user.EMail, "ed@baralga.com")
+
+		_, err = userRepository.FindEMailChangeByConfirmationID(context.Background(), emailChange.ConfirmationID)
+		is.True(errors.Is(err, ErrEMailChangeNotFound))
+	})
+
+	t.Run("Membership", func(t *testing.T) {
+		organizationRepository := NewDbOrganizationRepository(connPool)
+		organization := &Organization{
+			ID:       uuid.New(),
+			Title:    "Other Organization",
+			TimeZone: DefaultTimeZone,
+		}
+		user := &User{
+			ID:             uuid.New(),
+			Name:           "Mia Member",
+			Username:       "mia.member@baralga.com",
+			EMail:          "mia.member@baralga.com",
+			OrganizationID: shared.OrganizationIDSample,
+			Origin:         "baralga",
+		}
+
+		err := repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				_, err := organizationRepository.InsertOrganization(ctx, organization)
+				return err
+			},
+			func(ctx context.Context) error {
+				_, err := userRepository.InsertUserWithRole(ctx, user, RoleUser)
+				return err
+			},
+			func(ctx context.Context) error {
+				return userRepository.InsertMembership(ctx, organization.ID, user.ID, RoleAdmin)
+			},
+		)
+		is.NoErr(err)
+
+		memberships, err := userRepository.FindMembershipsByUserID(context.Background(), user.ID)
+		is.NoErr(err)
+		is.Equal(len(memberships), 2)
+
+		member, err := userRepository.FindUserByID(context.Background(), organization.ID, user.ID)
+		is.NoErr(err)
+		is.Equal(member.Roles, []string{RoleAdmin})
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return userRepository.DeleteUserByID(ctx, shared.OrganizationIDSample, user.ID)
+			},
+		)
+		is.NoErr(err)
+
+		remainingUser, err := userRepository.FindUserByUsername(context.Background(), user.Username)
+		is.NoErr(err)
+		is.Equal(remainingUser.OrganizationID, organization.ID)
+
+		err = repositoryTxer.InTx(
+			context.Background(),
+			func(ctx context.Context) error {
+				return userRepository.DeleteUserByID(ctx, organization.ID, user.ID)
+			},
+		)
+		is.NoErr(err)
+
+		_, err = userRepository.FindUserByUsername(context.Background(), user.Username)
 		is.True(errors.Is(err, ErrUserNotFound))
 	})
 }
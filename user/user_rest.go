@@ -0,0 +1,328 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/baralga/shared"
+	"github.com/baralga/shared/hal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"schneider.vip/problem"
+)
+
+type userModel struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Username string     `json:"username"`
+	EMail    string     `json:"email"`
+	Roles    []string   `json:"roles"`
+	Enabled  bool       `json:"enabled"`
+	Locked   bool       `json:"locked"`
+	Links    *hal.Links `json:"_links"`
+}
+
+type usersModel struct {
+	*EmbeddedUsers `json:"_embedded"`
+	Links          *hal.Links `json:"_links"`
+}
+
+// EmbeddedUsers contains embedded users
+type EmbeddedUsers struct {
+	UserModels []*userModel `json:"users"`
+}
+
+// userUpdateModel changes the role or enables or disables a user, unset fields are left unchanged
+type userUpdateModel struct {
+	Role    *string `json:"role" validate:"omitempty,max=50"`
+	Enabled *bool   `json:"enabled"`
+}
+
+type UserRestHandlers struct {
+	config      *shared.Config
+	userService *UserService
+}
+
+func NewUserRestHandlers(config *shared.Config, userService *UserService) *UserRestHandlers {
+	return &UserRestHandlers{
+		config:      config,
+		userService: userService,
+	}
+}
+
+func (a *UserRestHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/users", a.HandleGetUsers())
+	r.Get("/users/{user-id}", a.HandleGetUser())
+	r.Patch("/users/{user-id}", a.HandleUpdateUser())
+	r.Delete("/users/{user-id}", a.HandleDeleteUser())
+	r.Delete("/users/{user-id}/two-factor", a.HandleResetTwoFactor())
+	r.Delete("/users/{user-id}/lock", a.HandleUnlockUser())
+}
+
+func (a *UserRestHandlers) RegisterOpen(r chi.Router) {
+}
+
+// HandleGetUsers reads the members of the organization
+func (a *UserRestHandlers) HandleGetUsers() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		users, err := userService.ReadUsers(r.Context(), principal)
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		userModels := make([]*userModel, 0, len(users))
+		for _, user := range users {
+			userModels = append(userModels, mapToUserModel(user))
+		}
+
+		usersModel := &usersModel{
+			EmbeddedUsers: &EmbeddedUsers{
+				UserModels: userModels,
+			},
+			Links: hal.NewSelfLink(r.RequestURI),
+		}
+
+		shared.RenderJSON(w, usersModel)
+	}
+}
+
+// HandleGetUser reads a member of the organization
+func (a *UserRestHandlers) HandleGetUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDParam := chi.URLParam(r, "user-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		user, err := userService.ReadUser(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			http.Error(w, problem.New(problem.Title("user not found")).JSONString(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToUserModel(user))
+	}
+}
+
+// HandleUpdateUser changes the role of a member or enables or disables it
+func (a *UserRestHandlers) HandleUpdateUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDParam := chi.URLParam(r, "user-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		var updateModel userUpdateModel
+		err = json.NewDecoder(r.Body).Decode(&updateModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		err = validator.Struct(updateModel)
+		if err != nil {
+			http.Error(w, problem.New(problem.Title("user not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+
+		user, err := userService.ReadUser(r.Context(), principal, userID)
+		if updateModel.Role != nil && err == nil {
+			user, err = userService.UpdateUserRole(r.Context(), principal, userID, *updateModel.Role)
+		}
+		if updateModel.Enabled != nil && err == nil {
+			user, err = userService.UpdateUserEnabled(r.Context(), principal, userID, *updateModel.Enabled)
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			http.Error(w, problem.New(problem.Title("user not found")).JSONString(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrInvalidRole) {
+			http.Error(w, problem.New(problem.Title("user not valid")).JSONString(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			http.Error(w, problem.New(problem.Title(ErrPermissionDenied.Error())).JSONString(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			http.Error(w, problem.New(problem.Title(ErrLastAdmin.Error())).JSONString(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+
+		shared.RenderJSON(w, mapToUserModel(user))
+	}
+}
+
+// HandleDeleteUser removes a member from the organization
+func (a *UserRestHandlers) HandleDeleteUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDParam := chi.URLParam(r, "user-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		err = userService.DeleteUser(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, ErrLastAdmin) {
+			http.Error(w, problem.New(problem.Title(ErrLastAdmin.Error())).JSONString(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+	}
+}
+
+// HandleResetTwoFactor removes the second factor of a member who lost the authenticator app and the recovery codes
+func (a *UserRestHandlers) HandleResetTwoFactor() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDParam := chi.URLParam(r, "user-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		err = userService.ResetTwoFactor(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrPermissionDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+	}
+}
+
+// HandleUnlockUser unlocks a member locked after too many failed sign ins
+func (a *UserRestHandlers) HandleUnlockUser() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIDParam := chi.URLParam(r, "user-id")
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		if !principal.HasPermission(shared.PermissionUsersWrite) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			http.Error(w, problem.New(problem.Wrap(err)).JSONString(), http.StatusNotAcceptable)
+			return
+		}
+
+		err = userService.UnlockUser(r.Context(), principal, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			shared.RenderProblemJSON(w, isProduction, err)
+			return
+		}
+	}
+}
+
+func mapToUserModel(user *User) *userModel {
+	roles := user.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	userModel := &userModel{
+		ID:       user.ID.String(),
+		Name:     user.Name,
+		Username: user.Username,
+		EMail:    user.EMail,
+		Roles:    roles,
+		Enabled:  user.Enabled,
+		Locked:   user.Locked,
+	}
+
+	userModel.Links = hal.NewLinks(
+		hal.NewSelfLink(fmt.Sprintf("/api/users/%s", userModel.ID)),
+		hal.NewLink("edit", fmt.Sprintf("/api/users/%s", userModel.ID)),
+		hal.NewLink("delete", fmt.Sprintf("/api/users/%s", userModel.ID)),
+	)
+
+	return userModel
+}
@@ -0,0 +1,351 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baralga/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/matryer/is"
+)
+
+func TestHandleGetUsers(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/users", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	a.HandleGetUsers()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	usersModel := &usersModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(usersModel)
+	is.NoErr(err)
+	is.Equal(len(usersModel.UserModels), 2)
+	is.Equal(usersModel.UserModels[1].Username, "user1@baralga.com")
+	is.Equal(usersModel.UserModels[1].Roles, []string{RoleUser})
+	is.True(usersModel.UserModels[1].Enabled)
+}
+
+func TestHandleGetUsersAsUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository: NewInMemUserRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", "/api/users", nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleUser},
+	}))
+
+	a.HandleGetUsers()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+}
+
+func TestHandleGetUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+		},
+	}
+
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/api/users/%v", member.ID), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleGetUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	userModel := &userModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(userModel)
+	is.NoErr(err)
+	is.Equal(userModel.Name, "Ulani User")
+}
+
+func TestHandleUpdateUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+		},
+	}
+
+	body := `{"role": "ROLE_ADMIN", "enabled": false}`
+	r, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/users/%v", member.ID), strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUpdateUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	userModel := &userModel{}
+	err := json.NewDecoder(httpRec.Body).Decode(userModel)
+	is.NoErr(err)
+	is.Equal(userModel.Roles, []string{RoleAdmin})
+	is.True(!userModel.Enabled)
+}
+
+func TestHandleUpdateUserToRoleBeyondOwnPermissions(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+		},
+	}
+
+	body := `{"role": "ROLE_ADMIN"}`
+	r, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/users/%v", member.ID), strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       member.Username,
+		Roles:          []string{"ROLE_USER_ADMINISTRATOR"},
+		Permissions:    []string{shared.PermissionUsersWrite},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUpdateUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusForbidden)
+	is.Equal(member.Roles, []string{RoleUser})
+}
+
+func TestHandleUpdateUserWithInvalidRole(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+			roleRepository:          NewInMemRoleRepository(),
+		},
+	}
+
+	body := `{"role": "ROLE_ROOT"}`
+	r, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/users/%v", member.ID), strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUpdateUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusBadRequest)
+	is.Equal(member.Roles, []string{RoleUser})
+}
+
+func TestHandleUpdateLastAdmin(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: NewInMemLoginThrottleRepository(),
+		},
+	}
+
+	body := `{"role": "ROLE_USER"}`
+	r, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/users/%v", admin.ID), strings.NewReader(body))
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", admin.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUpdateUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusConflict)
+	is.True(admin.IsAdmin())
+}
+
+func TestHandleDeleteUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	userCount := len(userRepository.users)
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:         shared.NewInMemRepositoryTxer(),
+			userRepository:         userRepository,
+			organizationRepository: NewInMemOrganizationRepository(),
+			teamRepository:         NewInMemTeamRepository(),
+			apiTokenRepository:     NewInMemAPITokenRepository(),
+			userDataRemover:        userDataRemoverSample(nil),
+		},
+	}
+
+	r, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/users/%v", member.ID), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleDeleteUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(userRepository.users), userCount-1)
+}
+
+func TestHandleResetTwoFactor(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	twoFactorRepository := NewInMemTwoFactorRepository()
+	twoFactorRepository.twoFactors = append(twoFactorRepository.twoFactors, &TwoFactor{
+		UserID:      member.ID,
+		Secret:      "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		CreatedAt:   time.Now(),
+		ConfirmedAt: time.Now(),
+	})
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:      shared.NewInMemRepositoryTxer(),
+			userRepository:      userRepository,
+			twoFactorRepository: twoFactorRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/users/%v/two-factor", member.ID), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleResetTwoFactor()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(twoFactorRepository.twoFactors), 0)
+}
+
+func TestHandleUnlockUser(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	loginThrottleRepository := NewInMemLoginThrottleRepository()
+	loginThrottleRepository.loginThrottles = append(loginThrottleRepository.loginThrottles, &LoginThrottle{
+		Key:           AccountLoginThrottleKey(member.Username),
+		Failures:      LoginFailuresBeforeLockout,
+		LastFailureAt: time.Now(),
+		LockedUntil:   time.Now().Add(LoginLockoutDuration),
+	})
+
+	a := &UserRestHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer:          shared.NewInMemRepositoryTxer(),
+			userRepository:          userRepository,
+			loginThrottleRepository: loginThrottleRepository,
+		},
+	}
+
+	r, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/users/%v/lock", member.ID), nil)
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("user-id", member.ID.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleUnlockUser()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(len(loginThrottleRepository.loginThrottles), 0)
+}
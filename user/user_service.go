@@ -1,11 +1,19 @@
 package user
 
 import (
+	"archive/zip"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,12 +23,23 @@ type UserService struct {
 	mailResource            shared.MailResource
 	userRepository          UserRepository
 	organizationRepository  OrganizationRepository
+	invitationRepository    InvitationRepository
+	teamRepository          TeamRepository
+	roleRepository          RoleRepository
+	apiTokenRepository      APITokenRepository
+	twoFactorRepository     TwoFactorRepository
+	loginThrottleRepository LoginThrottleRepository
 	organizationInitializer func(ctxWithTx context.Context, organizationID uuid.UUID) error
+	userDataExporter        func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error
+	userDataRemover         func(ctxWithTx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error
+	sessionsRevoker         func(ctx context.Context, userID uuid.UUID) error
 }
 
 func NewInMemUserService() *UserService {
 	return &UserService{
-		userRepository: NewInMemUserRepository(),
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          NewInMemUserRepository(),
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
 	}
 }
 
@@ -30,7 +49,15 @@ func NewUserService(
 	mailResource shared.MailResource,
 	userRepository UserRepository,
 	organizationRepository OrganizationRepository,
+	invitationRepository InvitationRepository,
+	teamRepository TeamRepository,
+	roleRepository RoleRepository,
+	apiTokenRepository APITokenRepository,
+	twoFactorRepository TwoFactorRepository,
+	loginThrottleRepository LoginThrottleRepository,
 	organizationInitializer func(ctxWithTx context.Context, organizationID uuid.UUID) error,
+	userDataExporter func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error,
+	userDataRemover func(ctxWithTx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error,
 ) *UserService {
 	return &UserService{
 		config:                  config,
@@ -38,7 +65,15 @@ func NewUserService(
 		mailResource:            mailResource,
 		userRepository:          userRepository,
 		organizationRepository:  organizationRepository,
+		invitationRepository:    invitationRepository,
+		teamRepository:          teamRepository,
+		roleRepository:          roleRepository,
+		apiTokenRepository:      apiTokenRepository,
+		twoFactorRepository:     twoFactorRepository,
+		loginThrottleRepository: loginThrottleRepository,
 		organizationInitializer: organizationInitializer,
+		userDataExporter:        userDataExporter,
+		userDataRemover:         userDataRemover,
 	}
 }
 
@@ -51,6 +86,141 @@ func (a *UserService) ConfirmUser(ctx context.Context, userID uuid.UUID) error {
 	)
 }
 
+// ConfirmSignup confirms the email of a signed up user, expired confirmations can no longer be used
+func (a *UserService) ConfirmSignup(ctx context.Context, confirmationID uuid.UUID) error {
+	confirmation, err := a.userRepository.FindConfirmationByID(ctx, confirmationID)
+	if err != nil {
+		return err
+	}
+
+	if confirmation.IsExpired(time.Now()) {
+		return ErrConfirmationExpired
+	}
+
+	return a.ConfirmUser(ctx, confirmation.UserID)
+}
+
+// ResendConfirmation sends a new confirmation link to the unconfirmed user with the email.
+// Unknown or already confirmed emails are ignored so that no accounts can be probed.
+func (a *UserService) ResendConfirmation(ctx context.Context, email string) error {
+	user, err := a.userRepository.FindUnconfirmedUserByUsername(ctx, strings.TrimSpace(email))
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.EMail == "" {
+		return nil
+	}
+
+	confirmationID := uuid.New()
+	subject, body := a.confirmationMail(confirmationID)
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.InsertConfirmation(ctx, user.ID, confirmationID)
+		},
+		// Send email confirmation link
+		func(ctx context.Context) error {
+			return a.mailResource.SendMail(user.EMail, subject, body)
+		},
+	)
+}
+
+// DeleteStaleUnconfirmedUsers deletes the users who didn't confirm their email within the retention
+// together with their organizations, unless other members remain. It returns the number of deleted users.
+func (a *UserService) DeleteStaleUnconfirmedUsers(ctx context.Context, now time.Time) (int, error) {
+	users, err := a.userRepository.FindUnconfirmedUsers(ctx, now.Add(-UnconfirmedUserRetention))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, user := range users {
+		err = a.deleteUnconfirmedUser(ctx, user)
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func (a *UserService) deleteUnconfirmedUser(ctx context.Context, user *User) error {
+	memberships, err := a.userRepository.FindMembershipsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	var txFuncs []func(ctx context.Context) error
+	for _, membership := range memberships {
+		organizationID := membership.OrganizationID
+
+		members, err := a.userRepository.FindUsersByOrganizationID(ctx, organizationID)
+		if err != nil {
+			return err
+		}
+
+		txFuncs = append(
+			txFuncs,
+			func(ctx context.Context) error {
+				return a.userRepository.DeleteUserByID(ctx, organizationID, user.ID)
+			},
+		)
+
+		if len(members) > 1 {
+			continue
+		}
+
+		txFuncs = append(
+			txFuncs,
+			func(ctx context.Context) error {
+				return a.organizationRepository.DeleteOrganizationByID(ctx, organizationID)
+			},
+		)
+	}
+
+	return a.repositoryTxer.InTx(ctx, txFuncs...)
+}
+
+// RunUnconfirmedUserCleanup deletes stale unconfirmed users in the given interval until the context is done
+func (a *UserService) RunUnconfirmedUserCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := a.DeleteStaleUnconfirmedUsers(ctx, now)
+			if err != nil {
+				log.Printf("deleting stale unconfirmed users failed: %s", err)
+				continue
+			}
+
+			if deleted > 0 {
+				log.Printf("deleted %v stale unconfirmed users", deleted)
+			}
+		}
+	}
+}
+
+// confirmationMail is the mail with the link to confirm the email of a signed up user
+func (a *UserService) confirmationMail(confirmationID uuid.UUID) (string, string) {
+	subject := "Confirm your Email address"
+	body := fmt.Sprintf(
+		`Confirm your Email address at %v/signup/confirm/%v within the next 48 hours to activate your account.`,
+		a.config.Webroot,
+		confirmationID,
+	)
+	return subject, body
+}
+
 func (a *UserService) EncryptPassword(password string) string {
 	encryptedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), 10)
 	return string(encryptedPassword)
@@ -59,8 +229,12 @@ func (a *UserService) EncryptPassword(password string) string {
 func (a *UserService) SetUpNewUser(ctx context.Context, user *User, confirmationID uuid.UUID) error {
 	// Create Organization
 	organization := &Organization{
-		ID:    uuid.New(),
-		Title: user.Name,
+		ID:          uuid.New(),
+		Title:       user.Name,
+		TimeZone:    DefaultTimeZone,
+		WeekStart:   DefaultWeekStart,
+		WorkingDays: slices.Clone(DefaultWorkingDays),
+		DateFormat:  DefaultDateFormat,
 	}
 
 	// Create User
@@ -68,12 +242,7 @@ func (a *UserService) SetUpNewUser(ctx context.Context, user *User, confirmation
 	user.OrganizationID = organization.ID
 
 	// Send email confirmation link
-	subject := "Confirm your Email address"
-	body := fmt.Sprintf(
-		`Confirm your Email address at %v/signup/confirm/%v to activate your account.`,
-		a.config.Webroot,
-		confirmationID,
-	)
+	subject, body := a.confirmationMail(confirmationID)
 
 	return a.repositoryTxer.InTx(
 		ctx,
@@ -105,3 +274,1828 @@ func (a *UserService) SetUpNewUser(ctx context.Context, user *User, confirmation
 		},
 	)
 }
+
+// SetUpMember sets up the user as enabled member with the role of an existing organization,
+// e.g. users of an identity provider who join the organization of their company
+func (a *UserService) SetUpMember(ctx context.Context, user *User, organizationID uuid.UUID, role string) error {
+	_, err := a.organizationRepository.FindOrganizationByID(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	err = a.validateMemberRole(ctx, organizationID, role)
+	if err != nil {
+		return err
+	}
+
+	user.ID = uuid.New()
+	user.OrganizationID = organizationID
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.userRepository.InsertUserWithRole(ctx, user, role)
+			return err
+		},
+	)
+}
+
+// UpdateTimeZone sets the time zone of the user
+func (a *UserService) UpdateTimeZone(ctx context.Context, username, timeZone string) error {
+	user, err := a.userRepository.FindUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserTimeZone(ctx, user.ID, timeZone)
+		},
+	)
+}
+
+// InviteUser invites a user by email to join the organization of the principal
+func (a *UserService) InviteUser(ctx context.Context, principal *shared.Principal, email string) (*Invitation, error) {
+	email = strings.TrimSpace(email)
+
+	// Existing users can be invited unless they're already member of the organization
+	existingUser, err := a.userRepository.FindUserByUsername(ctx, email)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+	if existingUser != nil {
+		_, err = a.userRepository.FindUserByID(ctx, principal.OrganizationID, existingUser.ID)
+		if err == nil {
+			return nil, ErrUserExists
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	pendingInvitations, err := a.invitationRepository.FindPendingInvitations(ctx, principal.OrganizationID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		ID:             uuid.New(),
+		OrganizationID: principal.OrganizationID,
+		EMail:          email,
+		CreatedBy:      principal.Username,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(InvitationValidity),
+	}
+
+	inviter := principal.Name
+	if inviter == "" {
+		inviter = principal.Username
+	}
+
+	// Send invitation link
+	subject := "You're invited to Baralga"
+	body := fmt.Sprintf(
+		`%v invited you to track your time with Baralga. Join at %v/signup/invitation/%v until %v.`,
+		inviter,
+		a.config.Webroot,
+		invitation.ID,
+		invitation.ExpiresAt.In(principal.Location()).Format("02.01.2006 15:04"),
+	)
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		// Replace earlier invitations of the same email
+		func(ctx context.Context) error {
+			for _, pendingInvitation := range pendingInvitations {
+				if !strings.EqualFold(pendingInvitation.EMail, email) {
+					continue
+				}
+
+				err := a.invitationRepository.DeleteInvitationByID(ctx, principal.OrganizationID, pendingInvitation.ID)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(ctx context.Context) error {
+			_, err := a.invitationRepository.InsertInvitation(ctx, invitation)
+			return err
+		},
+		// Send invitation link
+		func(ctx context.Context) error {
+			return a.mailResource.SendMail(invitation.EMail, subject, body)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// ReadPendingInvitations reads the invitations of the organization of the principal which can still be accepted
+func (a *UserService) ReadPendingInvitations(ctx context.Context, principal *shared.Principal) ([]*Invitation, error) {
+	return a.invitationRepository.FindPendingInvitations(ctx, principal.OrganizationID, time.Now())
+}
+
+// RevokeInvitation revokes a pending invitation of the organization of the principal
+func (a *UserService) RevokeInvitation(ctx context.Context, principal *shared.Principal, invitationID uuid.UUID) error {
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.invitationRepository.DeleteInvitationByID(ctx, principal.OrganizationID, invitationID)
+		},
+	)
+}
+
+// ReadInvitation reads an invitation which can still be accepted
+func (a *UserService) ReadInvitation(ctx context.Context, invitationID uuid.UUID) (*Invitation, error) {
+	invitation, err := a.invitationRepository.FindInvitationByID(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.IsExpired(time.Now()) {
+		return nil, ErrInvitationNotFound
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation sets up the user as member of the organization of the invitation.
+// The email of the user is the invited one, so it needs no further confirmation.
+func (a *UserService) AcceptInvitation(ctx context.Context, invitationID uuid.UUID, user *User) error {
+	invitation, err := a.ReadInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.userRepository.FindUserByUsername(ctx, invitation.EMail)
+	if err == nil {
+		return ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	user.ID = uuid.New()
+	user.Username = invitation.EMail
+	user.EMail = invitation.EMail
+	user.OrganizationID = invitation.OrganizationID
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.userRepository.InsertUserWithRole(ctx, user, "ROLE_USER")
+			return err
+		},
+		func(ctx context.Context) error {
+			return a.invitationRepository.DeleteInvitationByID(ctx, invitation.OrganizationID, invitation.ID)
+		},
+	)
+}
+
+// IsInvitedUserRegistered checks if the invited email already belongs to a user,
+// who then joins the organization instead of signing up
+func (a *UserService) IsInvitedUserRegistered(ctx context.Context, invitation *Invitation) (bool, error) {
+	_, err := a.userRepository.FindUserByUsername(ctx, invitation.EMail)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// JoinOrganization makes the existing user with the invited email a member of the organization of the invitation
+func (a *UserService) JoinOrganization(ctx context.Context, invitationID uuid.UUID) error {
+	invitation, err := a.ReadInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.userRepository.FindUserByUsername(ctx, invitation.EMail)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.userRepository.FindUserByID(ctx, invitation.OrganizationID, user.ID)
+	if err == nil {
+		return ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.InsertMembership(ctx, invitation.OrganizationID, user.ID, RoleUser)
+		},
+		func(ctx context.Context) error {
+			return a.invitationRepository.DeleteInvitationByID(ctx, invitation.OrganizationID, invitation.ID)
+		},
+	)
+}
+
+// ReadMemberships reads the organizations the principal is an enabled member of
+func (a *UserService) ReadMemberships(ctx context.Context, principal *shared.Principal) ([]*Membership, error) {
+	user, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := a.userRepository.FindMembershipsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabledMemberships := make([]*Membership, 0, len(memberships))
+	for _, membership := range memberships {
+		if membership.Enabled {
+			enabledMemberships = append(enabledMemberships, membership)
+		}
+	}
+
+	return enabledMemberships, nil
+}
+
+// ReadUsers reads all members of the organization of the principal
+func (a *UserService) ReadUsers(ctx context.Context, principal *shared.Principal) ([]*User, error) {
+	users, err := a.userRepository.FindUsersByOrganizationID(ctx, principal.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.readLocked(ctx, users...)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// ReadUser reads a member of the organization of the principal
+func (a *UserService) ReadUser(ctx context.Context, principal *shared.Principal, userID uuid.UUID) (*User, error) {
+	user, err := a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.readLocked(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// readLocked marks the users whose accounts are locked after too many failed sign ins
+func (a *UserService) readLocked(ctx context.Context, users ...*User) error {
+	keys := make([]string, 0, len(users))
+	for _, user := range users {
+		keys = append(keys, AccountLoginThrottleKey(user.Username))
+	}
+
+	loginThrottles, err := a.loginThrottleRepository.FindLoginThrottles(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	locked := make(map[string]bool, len(loginThrottles))
+	for _, loginThrottle := range loginThrottles {
+		locked[loginThrottle.Key] = loginThrottle.IsLocked(now)
+	}
+
+	for _, user := range users {
+		user.Locked = locked[AccountLoginThrottleKey(user.Username)]
+	}
+
+	return nil
+}
+
+// ReadOrganization reads the organization of the principal with its settings
+func (a *UserService) ReadOrganization(ctx context.Context, principal *shared.Principal) (*Organization, error) {
+	return a.organizationRepository.FindOrganizationByID(ctx, principal.OrganizationID)
+}
+
+// UpdateOrganization sets title and settings of the organization of the principal
+func (a *UserService) UpdateOrganization(ctx context.Context, principal *shared.Principal, organization *Organization) (*Organization, error) {
+	organization.ID = principal.OrganizationID
+	if !organization.IsValid() {
+		return nil, ErrInvalidOrganization
+	}
+
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.organizationRepository.UpdateOrganization(ctx, organization)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.organizationRepository.FindOrganizationByID(ctx, principal.OrganizationID)
+}
+
+// OrganizationSettingsReader reads the settings of an organization, e.g. for tracking
+func (a *UserService) OrganizationSettingsReader() func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error) {
+	return func(ctx context.Context, organizationID uuid.UUID) (*shared.OrganizationSettings, error) {
+		organization, err := a.organizationRepository.FindOrganizationByID(ctx, organizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		return organization.Settings(), nil
+	}
+}
+
+// ReadTeams reads all teams of the organization of the principal
+func (a *UserService) ReadTeams(ctx context.Context, principal *shared.Principal) ([]*Team, error) {
+	return a.teamRepository.FindTeamsByOrganizationID(ctx, principal.OrganizationID)
+}
+
+// ReadTeam reads a team of the organization of the principal
+func (a *UserService) ReadTeam(ctx context.Context, principal *shared.Principal, teamID uuid.UUID) (*Team, error) {
+	return a.teamRepository.FindTeamByID(ctx, principal.OrganizationID, teamID)
+}
+
+// CreateTeam creates a new team in the organization of the principal
+func (a *UserService) CreateTeam(ctx context.Context, principal *shared.Principal, team *Team) (*Team, error) {
+	team.ID = uuid.New()
+	team.OrganizationID = principal.OrganizationID
+
+	err := a.validateTeam(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.teamRepository.InsertTeam(ctx, team)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.teamRepository.FindTeamByID(ctx, principal.OrganizationID, team.ID)
+}
+
+// UpdateTeam changes name, lead and members of a team of the organization of the principal
+func (a *UserService) UpdateTeam(ctx context.Context, principal *shared.Principal, team *Team) (*Team, error) {
+	team.OrganizationID = principal.OrganizationID
+
+	err := a.validateTeam(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.teamRepository.UpdateTeam(ctx, team)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.teamRepository.FindTeamByID(ctx, principal.OrganizationID, team.ID)
+}
+
+// DeleteTeam deletes a team of the organization of the principal, the members stay in the organization
+func (a *UserService) DeleteTeam(ctx context.Context, principal *shared.Principal, teamID uuid.UUID) error {
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.teamRepository.DeleteTeamByID(ctx, principal.OrganizationID, teamID)
+		},
+	)
+}
+
+// ReadRoles reads the built-in roles and the custom roles of the organization of the principal
+func (a *UserService) ReadRoles(ctx context.Context, principal *shared.Principal) ([]*OrganizationRole, error) {
+	customRoles, err := a.roleRepository.FindRolesByOrganizationID(ctx, principal.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(BuiltInRoles(principal.OrganizationID), customRoles...), nil
+}
+
+// ReadRole reads a built-in or custom role of the organization of the principal
+func (a *UserService) ReadRole(ctx context.Context, principal *shared.Principal, name string) (*OrganizationRole, error) {
+	for _, role := range BuiltInRoles(principal.OrganizationID) {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+
+	return a.roleRepository.FindRoleByName(ctx, principal.OrganizationID, name)
+}
+
+// CreateRole creates a custom role in the organization of the principal, the name is derived from the title.
+// The principal can only create roles with permissions it holds itself.
+func (a *UserService) CreateRole(ctx context.Context, principal *shared.Principal, role *OrganizationRole) (*OrganizationRole, error) {
+	role.OrganizationID = principal.OrganizationID
+	role.Title = strings.TrimSpace(role.Title)
+	role.Name = RoleName(role.Title)
+	role.BuiltIn = false
+
+	err := validateRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureGrantablePermissions(principal, role.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.roleRepository.FindRoleByName(ctx, role.OrganizationID, role.Name)
+	if err == nil {
+		return nil, ErrInvalidRole
+	}
+	if !errors.Is(err, ErrRoleNotFound) {
+		return nil, err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.roleRepository.InsertRole(ctx, role)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.roleRepository.FindRoleByName(ctx, role.OrganizationID, role.Name)
+}
+
+// UpdateRole changes title and permissions of a custom role of the organization of the principal,
+// members with the role are granted the changed permissions when they sign in again.
+// The principal can only grant permissions it holds itself.
+func (a *UserService) UpdateRole(ctx context.Context, principal *shared.Principal, role *OrganizationRole) (*OrganizationRole, error) {
+	if IsBuiltInRole(role.Name) {
+		return nil, ErrRoleNotFound
+	}
+
+	role.OrganizationID = principal.OrganizationID
+	role.Title = strings.TrimSpace(role.Title)
+	role.BuiltIn = false
+
+	err := validateRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureGrantablePermissions(principal, role.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.roleRepository.UpdateRole(ctx, role)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.roleRepository.FindRoleByName(ctx, role.OrganizationID, role.Name)
+}
+
+// DeleteRole deletes a custom role of the organization of the principal, members with the role become users
+func (a *UserService) DeleteRole(ctx context.Context, principal *shared.Principal, name string) error {
+	if IsBuiltInRole(name) {
+		return ErrRoleNotFound
+	}
+
+	users, err := a.userRepository.FindUsersByOrganizationID(ctx, principal.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			for _, user := range users {
+				if !slices.Contains(user.Roles, name) {
+					continue
+				}
+
+				err := a.userRepository.UpdateUserRole(ctx, principal.OrganizationID, user.ID, RoleUser)
+				if err != nil {
+					return err
+				}
+			}
+
+			return a.roleRepository.DeleteRoleByName(ctx, principal.OrganizationID, name)
+		},
+	)
+}
+
+// validateRole checks title and permissions of a custom role and removes duplicate permissions
+func validateRole(role *OrganizationRole) error {
+	if !role.IsValid() {
+		return ErrInvalidRole
+	}
+
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range shared.Permissions {
+		if role.HasPermission(permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	role.Permissions = permissions
+
+	return nil
+}
+
+// validateTeam checks the name of the team and that lead and members are members of the organization
+func (a *UserService) validateTeam(ctx context.Context, team *Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if !team.IsValid() {
+		return ErrInvalidTeam
+	}
+
+	users, err := a.userRepository.FindUsersByOrganizationID(ctx, team.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	isMember := func(userID uuid.UUID) bool {
+		return slices.ContainsFunc(users, func(user *User) bool { return user.ID == userID })
+	}
+
+	if team.LeadUserID != uuid.Nil && !isMember(team.LeadUserID) {
+		return ErrInvalidTeam
+	}
+
+	memberIDs := make([]uuid.UUID, 0, len(team.MemberIDs))
+	for _, memberID := range team.MemberIDs {
+		if !isMember(memberID) {
+			return ErrInvalidTeam
+		}
+		if !slices.Contains(memberIDs, memberID) {
+			memberIDs = append(memberIDs, memberID)
+		}
+	}
+	team.MemberIDs = memberIDs
+
+	return nil
+}
+
+// SetSessionsRevoker sets the revoker which signs a user out on all devices, e.g. after the password changed
+func (a *UserService) SetSessionsRevoker(sessionsRevoker func(ctx context.Context, userID uuid.UUID) error) {
+	a.sessionsRevoker = sessionsRevoker
+}
+
+// revokeSessions signs the user out on all devices
+func (a *UserService) revokeSessions(ctx context.Context, userID uuid.UUID) error {
+	if a.sessionsRevoker == nil {
+		return nil
+	}
+
+	return a.sessionsRevoker(ctx, userID)
+}
+
+// TeamsReader reads the teams whose activities the principal may see, e.g. for tracking.
+// Admins see all teams of the organization, team leads the teams they lead.
+func (a *UserService) TeamsReader() func(ctx context.Context, principal *shared.Principal) ([]*shared.TeamMembers, error) {
+	return func(ctx context.Context, principal *shared.Principal) ([]*shared.TeamMembers, error) {
+		teams, err := a.teamRepository.FindTeamsByOrganizationID(ctx, principal.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(teams) == 0 {
+			return nil, nil
+		}
+
+		users, err := a.userRepository.FindUsersByOrganizationID(ctx, principal.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		usernamesByID := make(map[uuid.UUID]string)
+		principalUserID := uuid.Nil
+		for _, user := range users {
+			usernamesByID[user.ID] = user.Username
+			if user.Username == principal.Username {
+				principalUserID = user.ID
+			}
+		}
+
+		var teamMembers []*shared.TeamMembers
+		for _, team := range teams {
+			if !principal.HasPermission(shared.PermissionActivitiesReadAll) &&
+				!(principal.HasPermission(shared.PermissionReportsReadTeam) && team.HasLead(principalUserID)) {
+				continue
+			}
+
+			usernames := make([]string, 0, len(team.MemberIDs))
+			for _, memberID := range team.MemberIDs {
+				username, ok := usernamesByID[memberID]
+				if !ok {
+					continue
+				}
+				usernames = append(usernames, username)
+			}
+
+			teamMembers = append(teamMembers, &shared.TeamMembers{
+				TeamID:    team.ID,
+				Name:      team.Name,
+				Usernames: usernames,
+			})
+		}
+
+		return teamMembers, nil
+	}
+}
+
+// UpdateUserRole sets the role of a member of the organization of the principal,
+// the principal can only grant roles whose permissions it holds itself and only admins change the role of admins
+func (a *UserService) UpdateUserRole(ctx context.Context, principal *shared.Principal, userID uuid.UUID, role string) (*User, error) {
+	err := a.validateMemberRole(ctx, principal.OrganizationID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.ensureGrantableRole(ctx, principal, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.ensureChangeableMember(ctx, principal, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != RoleAdmin {
+		err := a.ensureRemainingAdmin(ctx, principal.OrganizationID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserRole(ctx, principal.OrganizationID, userID, role)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+}
+
+// UpdateMappedRole sets the role of the principal in its organization as mapped from the groups
+// of an identity provider. The last admin of the organization keeps the admin role.
+func (a *UserService) UpdateMappedRole(ctx context.Context, principal *shared.Principal, role string) error {
+	if slices.Equal(principal.Roles, []string{role}) {
+		return nil
+	}
+
+	err := a.validateMemberRole(ctx, principal.OrganizationID, role)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.userRepository.FindUserByUsername(ctx, principal.Username)
+	if err != nil {
+		return err
+	}
+
+	if role != RoleAdmin {
+		err := a.ensureRemainingAdmin(ctx, principal.OrganizationID, user.ID)
+		if errors.Is(err, ErrLastAdmin) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserRole(ctx, principal.OrganizationID, user.ID, role)
+		},
+	)
+}
+
+// ProvisionUser sets up the user of an identity provider as enabled member of the organization of the principal.
+// Existing users, also of other organizations, are not linked to the organization by their username,
+// so that an identity provider can't take over the accounts of other organizations.
+func (a *UserService) ProvisionUser(ctx context.Context, principal *shared.Principal, user *User) (*User, error) {
+	_, err := a.userRepository.FindUserByUsername(ctx, user.Username)
+	if err == nil {
+		return nil, ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	_, err = a.userRepository.FindUnconfirmedUserByUsername(ctx, user.Username)
+	if err == nil {
+		return nil, ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	user.Origin = SCIMOrigin
+	err = a.SetUpMember(ctx, user, principal.OrganizationID, RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.ReadUser(ctx, principal, user.ID)
+}
+
+// UpdateProvisionedUser sets the name of a member of the organization of the principal and enables or disables it
+// as provisioned by an identity provider. The name is shared by all organizations of the user, so it's only
+// changed for users the identity provider of the organization set up.
+func (a *UserService) UpdateProvisionedUser(ctx context.Context, principal *shared.Principal, userID uuid.UUID, name string, enabled bool) (*User, error) {
+	user, err := a.ReadUser(ctx, principal, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name != "" && name != user.Name {
+		provisioned, err := a.isProvisionedBy(ctx, user, principal.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		if provisioned {
+			err = a.repositoryTxer.InTx(
+				ctx,
+				func(ctx context.Context) error {
+					return a.userRepository.UpdateUserName(ctx, userID, name)
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if enabled != user.Enabled {
+		_, err = a.UpdateUserEnabled(ctx, principal, userID, enabled)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.ReadUser(ctx, principal, userID)
+}
+
+// isProvisionedBy checks if the user was set up by the identity provider of the organization
+func (a *UserService) isProvisionedBy(ctx context.Context, user *User, organizationID uuid.UUID) (bool, error) {
+	if user.Origin != SCIMOrigin {
+		return false, nil
+	}
+
+	defaultUser, err := a.userRepository.FindUserByUsername(ctx, user.Username)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return defaultUser.OrganizationID == organizationID, nil
+}
+
+// UpdateRoleMembers grants the role to the members of the organization of the principal and revokes it from all
+// other members, who are left with the user role. The role is granted first so that admins can be replaced.
+func (a *UserService) UpdateRoleMembers(ctx context.Context, principal *shared.Principal, role string, userIDs []uuid.UUID) error {
+	err := a.validateMemberRole(ctx, principal.OrganizationID, role)
+	if err != nil {
+		return err
+	}
+
+	users, err := a.userRepository.FindUsersByOrganizationID(ctx, principal.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if !slices.ContainsFunc(users, func(user *User) bool { return user.ID == userID }) {
+			return ErrUserNotFound
+		}
+	}
+
+	for _, user := range users {
+		if slices.Contains(userIDs, user.ID) && !slices.Contains(user.Roles, role) {
+			_, err := a.UpdateUserRole(ctx, principal, user.ID, role)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, user := range users {
+		if !slices.Contains(userIDs, user.ID) && slices.Contains(user.Roles, role) && role != RoleUser {
+			_, err := a.UpdateUserRole(ctx, principal, user.ID, RoleUser)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ensureGrantableRole checks that the principal holds every permission of the role, so that members can't
+// grant themselves or others more permissions than they have. Only admins grant the admin role.
+func (a *UserService) ensureGrantableRole(ctx context.Context, principal *shared.Principal, role string) error {
+	if role == RoleAdmin && !slices.Contains(principal.Roles, RoleAdmin) {
+		return ErrPermissionDenied
+	}
+
+	permissions := shared.RolePermissions[role]
+	if !IsBuiltInRole(role) {
+		customRole, err := a.roleRepository.FindRoleByName(ctx, principal.OrganizationID, role)
+		if err != nil {
+			return err
+		}
+		permissions = customRole.Permissions
+	}
+
+	return ensureGrantablePermissions(principal, permissions)
+}
+
+// ensureGrantablePermissions checks that the principal holds every permission it grants
+func ensureGrantablePermissions(principal *shared.Principal, permissions []string) error {
+	for _, permission := range permissions {
+		if !principal.HasPermission(permission) {
+			return ErrPermissionDenied
+		}
+	}
+
+	return nil
+}
+
+// ensureChangeableMember checks that the principal may change the member of its organization,
+// only admins change admins so that members managing users can't take over the organization
+func (a *UserService) ensureChangeableMember(ctx context.Context, principal *shared.Principal, userID uuid.UUID) error {
+	user, err := a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsAdmin() && !slices.Contains(principal.Roles, RoleAdmin) {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// validateMemberRole checks that the role is a built-in role or a custom role of the organization
+func (a *UserService) validateMemberRole(ctx context.Context, organizationID uuid.UUID, role string) error {
+	if IsBuiltInRole(role) {
+		return nil
+	}
+
+	_, err := a.roleRepository.FindRoleByName(ctx, organizationID, role)
+	if errors.Is(err, ErrRoleNotFound) {
+		return ErrInvalidRole
+	}
+	return err
+}
+
+// UpdateUserEnabled enables or disables a member of the organization of the principal,
+// disabled users can no longer sign in and are signed out on all devices. Only admins enable or disable admins.
+func (a *UserService) UpdateUserEnabled(ctx context.Context, principal *shared.Principal, userID uuid.UUID, enabled bool) (*User, error) {
+	err := a.ensureChangeableMember(ctx, principal, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		err := a.ensureRemainingAdmin(ctx, principal.OrganizationID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserEnabled(ctx, principal.OrganizationID, userID, enabled)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		err = a.revokeSessions(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+}
+
+// DeleteUser removes a member from the organization of the principal, only admins remove admins.
+// The activities of the member are anonymized or deleted according to the user deletion policy.
+func (a *UserService) DeleteUser(ctx context.Context, principal *shared.Principal, userID uuid.UUID) error {
+	err := a.ensureChangeableMember(ctx, principal, userID)
+	if err != nil {
+		return err
+	}
+
+	err = a.ensureRemainingAdmin(ctx, principal.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	return a.deleteMember(ctx, user)
+}
+
+// deleteMember removes the user from the organization of the user and anonymizes or deletes
+// the data of the user according to the user deletion policy of the organization
+func (a *UserService) deleteMember(ctx context.Context, user *User) error {
+	txFuncs, err := a.memberRemoval(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(ctx, txFuncs...)
+}
+
+// memberRemoval prepares the transaction functions to remove the user from the organization of the user
+func (a *UserService) memberRemoval(ctx context.Context, user *User) ([]func(ctx context.Context) error, error) {
+	organization, err := a.organizationRepository.FindOrganizationByID(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	anonymizedUsername := user.AnonymizedUsername()
+	if organization.UserDeletionPolicy == UserDeletionPolicyDelete {
+		anonymizedUsername = ""
+	}
+
+	return []func(ctx context.Context) error{
+		func(ctx context.Context) error {
+			return a.userDataRemover(ctx, user.OrganizationID, user.Username, anonymizedUsername)
+		},
+		func(ctx context.Context) error {
+			return a.teamRepository.RemoveUserFromTeams(ctx, user.OrganizationID, user.ID)
+		},
+		func(ctx context.Context) error {
+			return a.apiTokenRepository.DeleteAPITokensByUserID(ctx, user.OrganizationID, user.ID)
+		},
+		func(ctx context.Context) error {
+			return a.userRepository.DeleteUserByID(ctx, user.OrganizationID, user.ID)
+		},
+	}, nil
+}
+
+// ensureRemainingAdmin checks that the organization keeps an enabled admin
+// if the user loses the admin role, is disabled or removed
+func (a *UserService) ensureRemainingAdmin(ctx context.Context, organizationID, userID uuid.UUID) error {
+	users, err := a.userRepository.FindUsersByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	var changedUser *User
+	remainingAdmins := 0
+	for _, user := range users {
+		if user.ID == userID {
+			changedUser = user
+			continue
+		}
+
+		if user.Enabled && user.IsAdmin() {
+			remainingAdmins++
+		}
+	}
+
+	if changedUser == nil {
+		return ErrUserNotFound
+	}
+
+	if changedUser.Enabled && changedUser.IsAdmin() && remainingAdmins == 0 {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
+
+// RequestPasswordReset sends a link to set a new password to the user with the email.
+// Unknown emails and users without a local password are ignored so that no accounts can be probed.
+func (a *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := a.userRepository.FindUserByUsername(ctx, strings.TrimSpace(email))
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// users of GitHub, Google, OpenID Connect, LDAP or SCIM have no local password to reset
+	if user.EMail == "" || !user.HasLocalPassword() {
+		return nil
+	}
+
+	now := time.Now()
+	passwordReset := &PasswordReset{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(PasswordResetValidity),
+	}
+
+	// Send password reset link
+	subject := "Reset your password"
+	body := fmt.Sprintf(
+		`Set a new password at %v/password/reset/%v within the next hour. If you didn't request a new password, just ignore this email.`,
+		a.config.Webroot,
+		passwordReset.ID,
+	)
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.userRepository.InsertPasswordReset(ctx, passwordReset)
+			return err
+		},
+		// Send password reset link
+		func(ctx context.Context) error {
+			return a.mailResource.SendMail(user.EMail, subject, body)
+		},
+	)
+}
+
+// ReadPasswordReset reads a password reset which can still be used
+func (a *UserService) ReadPasswordReset(ctx context.Context, passwordResetID uuid.UUID) (*PasswordReset, error) {
+	passwordReset, err := a.userRepository.FindPasswordResetByID(ctx, passwordResetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if passwordReset.IsExpired(time.Now()) {
+		return nil, ErrPasswordResetNotFound
+	}
+
+	return passwordReset, nil
+}
+
+// ResetPassword sets the new password of the user of the password reset, invalidates
+// all password resets of the user and signs the user out on all devices
+func (a *UserService) ResetPassword(ctx context.Context, passwordResetID uuid.UUID, password string) error {
+	passwordReset, err := a.ReadPasswordReset(ctx, passwordResetID)
+	if err != nil {
+		return err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserPassword(ctx, passwordReset.UserID, a.EncryptPassword(password))
+		},
+		func(ctx context.Context) error {
+			return a.userRepository.DeletePasswordResetsByUserID(ctx, passwordReset.UserID)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return a.revokeSessions(ctx, passwordReset.UserID)
+}
+
+// SendLoginLink sends the link to sign in once without a password to the user,
+// the token of the link is signed by the caller and carries the id of the login link
+func (a *UserService) SendLoginLink(ctx context.Context, user *User, loginLink *LoginLink, token string) error {
+	subject := "Sign in to Baralga"
+	body := fmt.Sprintf(
+		`Sign in at %v/login/link/%v within the next %v minutes. The link can be used only once. If you didn't request a login link, just ignore this email.`,
+		a.config.Webroot,
+		token,
+		int(LoginLinkValidity.Minutes()),
+	)
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.userRepository.InsertLoginLink(ctx, loginLink)
+			return err
+		},
+		// Send login link
+		func(ctx context.Context) error {
+			return a.mailResource.SendMail(user.EMail, subject, body)
+		},
+	)
+}
+
+// ThrottleLoginLinkRequest counts a requested login link for the email from the ip address, further requests
+// are delayed like failed sign ins. All emails are counted, known or not, so that no accounts can be probed.
+func (a *UserService) ThrottleLoginLinkRequest(ctx context.Context, email, ip string) error {
+	emailKey := LoginLinkThrottleKey(email)
+	ipKey := IPLoginLinkThrottleKey(ip)
+	loginThrottles, err := a.loginThrottleRepository.FindLoginThrottles(ctx, []string{emailKey, ipKey})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, loginThrottle := range loginThrottles {
+		requestsBeforeBackoff := LoginLinkIPRequestsBeforeBackoff
+		if loginThrottle.Key == emailKey {
+			requestsBeforeBackoff = LoginLinksBeforeBackoff
+		}
+
+		if now.Before(loginThrottle.RetryAt(requestsBeforeBackoff)) {
+			return ErrLoginLinkThrottled
+		}
+	}
+
+	countedSince := now.Add(-LoginFailureRetention)
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.loginThrottleRepository.IncrementLoginFailures(ctx, emailKey, now, countedSince)
+			return err
+		},
+		func(ctx context.Context) error {
+			if ip == "" {
+				return nil
+			}
+
+			_, err := a.loginThrottleRepository.IncrementLoginFailures(ctx, ipKey, now, countedSince)
+			return err
+		},
+	)
+}
+
+// UseLoginLink invalidates the login link and returns it if it could still be used
+func (a *UserService) UseLoginLink(ctx context.Context, loginLinkID uuid.UUID) (*LoginLink, error) {
+	var loginLink *LoginLink
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			var err error
+			loginLink, err = a.userRepository.DeleteLoginLinkByID(ctx, loginLinkID)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if loginLink.IsExpired(time.Now()) {
+		return nil, ErrLoginLinkNotFound
+	}
+
+	return loginLink, nil
+}
+
+// ReadProfile reads the signed in user
+func (a *UserService) ReadProfile(ctx context.Context, principal *shared.Principal) (*User, error) {
+	return a.userRepository.FindUserByUsername(ctx, principal.Username)
+}
+
+// ReadAPITokens reads the personal api tokens of the signed in user in the current organization
+func (a *UserService) ReadAPITokens(ctx context.Context, principal *shared.Principal) ([]*APIToken, error) {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.apiTokenRepository.FindAPITokensByUserID(ctx, principal.OrganizationID, user.ID)
+}
+
+// CreateAPIToken creates a personal api token of the signed in user in the current organization, restricted
+// to the scopes if any. The returned secret is not stored and can't be read again.
+func (a *UserService) CreateAPIToken(ctx context.Context, principal *shared.Principal, name string, expiresAt time.Time, scopes []string) (*APIToken, string, error) {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := NewAPITokenSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiToken := &APIToken{
+		ID:             uuid.New(),
+		OrganizationID: principal.OrganizationID,
+		UserID:         user.ID,
+		Name:           strings.TrimSpace(name),
+		TokenHash:      HashAPIToken(secret),
+		Scopes:         scopes,
+		CreatedAt:      time.Now(),
+		ExpiresAt:      expiresAt,
+	}
+
+	if !apiToken.IsValid(apiToken.CreatedAt) {
+		return nil, "", ErrInvalidAPIToken
+	}
+
+	// keep the scopes unique and in the order of all scopes
+	apiToken.Scopes = nil
+	for _, scope := range shared.Scopes {
+		if slices.Contains(scopes, scope) {
+			apiToken.Scopes = append(apiToken.Scopes, scope)
+		}
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.apiTokenRepository.InsertAPIToken(ctx, apiToken)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return apiToken, secret, nil
+}
+
+// DeleteAPIToken revokes a personal api token of the signed in user
+func (a *UserService) DeleteAPIToken(ctx context.Context, principal *shared.Principal, apiTokenID uuid.UUID) error {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.apiTokenRepository.DeleteAPITokenByID(ctx, principal.OrganizationID, user.ID, apiTokenID)
+		},
+	)
+}
+
+// ReadTwoFactor reads the second factor of the signed in user
+func (a *UserService) ReadTwoFactor(ctx context.Context, principal *shared.Principal) (*TwoFactor, error) {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.twoFactorRepository.FindTwoFactorByUserID(ctx, user.ID)
+}
+
+// StartTwoFactorEnrollment creates a new secret for the authenticator app of the signed in user, an
+// unconfirmed enrollment is replaced. The second factor is required only after it has been confirmed.
+func (a *UserService) StartTwoFactorEnrollment(ctx context.Context, principal *shared.Principal) (*TwoFactor, error) {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	existingTwoFactor, err := a.twoFactorRepository.FindTwoFactorByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, ErrTwoFactorNotFound) {
+		return nil, err
+	}
+	if existingTwoFactor != nil && existingTwoFactor.IsConfirmed() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := NewTwoFactorSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor := &TwoFactor{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.twoFactorRepository.DeleteTwoFactorByUserID(ctx, user.ID)
+		},
+		func(ctx context.Context) error {
+			_, err := a.twoFactorRepository.InsertTwoFactor(ctx, twoFactor)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+// ConfirmTwoFactor enables the enrolled second factor of the signed in user with a first one time password
+// of the authenticator app. The returned recovery codes are not stored and can't be read again.
+func (a *UserService) ConfirmTwoFactor(ctx context.Context, principal *shared.Principal, code string) ([]string, error) {
+	twoFactor, err := a.ReadTwoFactor(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor.IsConfirmed() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	now := time.Now()
+	if !twoFactor.Verify(code, now) {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	twoFactor.RecoveryCodeHashes = recoveryCodeHashes
+	twoFactor.ConfirmedAt = now
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.twoFactorRepository.UpdateTwoFactor(ctx, twoFactor)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactor removes the second factor of the signed in user after checking
+// a current one time password or a recovery code
+func (a *UserService) DisableTwoFactor(ctx context.Context, principal *shared.Principal, code string) error {
+	twoFactor, err := a.ReadTwoFactor(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	if twoFactor.IsConfirmed() && !twoFactor.Verify(code, time.Now()) {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.twoFactorRepository.DeleteTwoFactorByUserID(ctx, twoFactor.UserID)
+		},
+	)
+}
+
+// ResetTwoFactor removes the second factor of a member of the organization of the principal,
+// e.g. if the member lost the authenticator app and the recovery codes. Only admins reset admins.
+func (a *UserService) ResetTwoFactor(ctx context.Context, principal *shared.Principal, userID uuid.UUID) error {
+	err := a.ensureChangeableMember(ctx, principal, userID)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.twoFactorRepository.DeleteTwoFactorByUserID(ctx, user.ID)
+		},
+	)
+}
+
+// CheckLoginThrottle checks if the user may try to sign in from the ip address, or has to wait after recent
+// failed sign ins of the account or from the ip address. It must be checked before the password
+// so that the password can't be guessed while the sign in is throttled.
+func (a *UserService) CheckLoginThrottle(ctx context.Context, username, ip string) error {
+	accountKey := AccountLoginThrottleKey(username)
+	loginThrottles, err := a.loginThrottleRepository.FindLoginThrottles(ctx, []string{accountKey, IPLoginThrottleKey(ip)})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, loginThrottle := range loginThrottles {
+		failuresBeforeBackoff := LoginIPFailuresBeforeBackoff
+		if loginThrottle.Key == accountKey {
+			if loginThrottle.IsLocked(now) {
+				return ErrAccountLocked
+			}
+			failuresBeforeBackoff = LoginFailuresBeforeBackoff
+		}
+
+		if now.Before(loginThrottle.RetryAt(failuresBeforeBackoff)) {
+			return ErrLoginThrottled
+		}
+	}
+
+	return nil
+}
+
+// RecordLoginFailure counts a failed sign in of the account from the ip address and locks the account
+// after too many failures. The owner of a locked account is notified by email.
+func (a *UserService) RecordLoginFailure(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	countedSince := now.Add(-LoginFailureRetention)
+	accountKey := AccountLoginThrottleKey(username)
+
+	var accountThrottle *LoginThrottle
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			var err error
+			accountThrottle, err = a.loginThrottleRepository.IncrementLoginFailures(ctx, accountKey, now, countedSince)
+			return err
+		},
+		func(ctx context.Context) error {
+			if ip == "" {
+				return nil
+			}
+
+			_, err := a.loginThrottleRepository.IncrementLoginFailures(ctx, IPLoginThrottleKey(ip), now, countedSince)
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if accountThrottle.Failures < LoginFailuresBeforeLockout || accountThrottle.IsLocked(now) {
+		return nil
+	}
+
+	lockedUntil := now.Add(LoginLockoutDuration)
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.loginThrottleRepository.LockLoginThrottle(ctx, accountKey, lockedUntil)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = a.notifyAccountLocked(ctx, username, lockedUntil)
+	if err != nil {
+		log.Printf("notifying about locked account failed: %s", err)
+	}
+
+	return nil
+}
+
+// notifyAccountLocked tells the owner of the account that it was locked, unknown accounts are ignored
+func (a *UserService) notifyAccountLocked(ctx context.Context, username string, lockedUntil time.Time) error {
+	user, err := a.userRepository.FindUserByUsername(ctx, username)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.EMail == "" {
+		return nil
+	}
+
+	subject := "Your account was locked"
+	body := fmt.Sprintf(
+		`Your account was locked after %v failed sign ins until %v. If you didn't try to sign in, someone may be guessing your password. You can reset your password at %v/password/forgot or ask an admin of your organization to unlock your account.`,
+		LoginFailuresBeforeLockout,
+		lockedUntil.UTC().Format("2006-01-02 15:04 MST"),
+		a.config.Webroot,
+	)
+
+	return a.mailResource.SendMail(user.EMail, subject, body)
+}
+
+// ResetLoginFailures forgets the failed sign ins of the account after a successful sign in
+func (a *UserService) ResetLoginFailures(ctx context.Context, username string) error {
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.loginThrottleRepository.DeleteLoginThrottle(ctx, AccountLoginThrottleKey(username))
+		},
+	)
+}
+
+// UnlockUser unlocks a member of the organization of the principal locked after too many failed sign ins
+func (a *UserService) UnlockUser(ctx context.Context, principal *shared.Principal, userID uuid.UUID) error {
+	user, err := a.userRepository.FindUserByID(ctx, principal.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	return a.ResetLoginFailures(ctx, user.Username)
+}
+
+// DeleteStaleLoginThrottles deletes the failed sign ins which are no longer counted.
+// It returns the number of deleted accounts and ip addresses.
+func (a *UserService) DeleteStaleLoginThrottles(ctx context.Context, now time.Time) (int, error) {
+	deleted := 0
+	err := a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			var err error
+			deleted, err = a.loginThrottleRepository.DeleteStaleLoginThrottles(ctx, now.Add(-LoginFailureRetention))
+			return err
+		},
+	)
+
+	return deleted, err
+}
+
+// RunLoginThrottleCleanup deletes stale failed sign ins in the given interval until the context is done
+func (a *UserService) RunLoginThrottleCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			_, err := a.DeleteStaleLoginThrottles(ctx, now)
+			if err != nil {
+				log.Printf("deleting stale failed sign ins failed: %s", err)
+			}
+		}
+	}
+}
+
+// UpdateName sets the display name of the signed in user
+func (a *UserService) UpdateName(ctx context.Context, principal *shared.Principal, name string) (*User, error) {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserName(ctx, user.ID, name)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Name = name
+	return user, nil
+}
+
+// ChangePassword sets the new password of the signed in user if the current password matches,
+// the user is signed out on all devices
+func (a *UserService) ChangePassword(ctx context.Context, principal *shared.Principal, currentPassword, password string) error {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasLocalPassword() {
+		return ErrPasswordInvalid
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword))
+	if err != nil {
+		return ErrPasswordInvalid
+	}
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.UpdateUserPassword(ctx, user.ID, a.EncryptPassword(password))
+		},
+		func(ctx context.Context) error {
+			return a.userRepository.DeletePasswordResetsByUserID(ctx, user.ID)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return a.revokeSessions(ctx, user.ID)
+}
+
+// RequestEMailChange sends a confirmation link to the new email of the signed in user.
+// The email is changed once the link is confirmed, the username stays the same.
+func (a *UserService) RequestEMailChange(ctx context.Context, principal *shared.Principal, email string) (*EMailChange, error) {
+	email = strings.TrimSpace(email)
+
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.userRepository.FindUserByUsername(ctx, email)
+	if err == nil {
+		return nil, ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	emailChange := &EMailChange{
+		ConfirmationID: uuid.New(),
+		UserID:         user.ID,
+		EMail:          email,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(EMailChangeValidity),
+	}
+
+	// Send email confirmation link
+	subject := "Confirm your new Email address"
+	body := fmt.Sprintf(
+		`Confirm your new Email address at %v/profile/email/confirm/%v within the next 48 hours to use it for your Baralga account.`,
+		a.config.Webroot,
+		emailChange.ConfirmationID,
+	)
+
+	err = a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			_, err := a.userRepository.InsertEMailChange(ctx, emailChange)
+			return err
+		},
+		// Send email confirmation link
+		func(ctx context.Context) error {
+			return a.mailResource.SendMail(emailChange.EMail, subject, body)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return emailChange, nil
+}
+
+// ConfirmEMailChange sets the new email of the user of the email change
+func (a *UserService) ConfirmEMailChange(ctx context.Context, confirmationID uuid.UUID) error {
+	emailChange, err := a.userRepository.FindEMailChangeByConfirmationID(ctx, confirmationID)
+	if err != nil {
+		return err
+	}
+
+	if emailChange.IsExpired(time.Now()) {
+		return ErrEMailChangeExpired
+	}
+
+	return a.repositoryTxer.InTx(
+		ctx,
+		func(ctx context.Context) error {
+			return a.userRepository.ConfirmEMailChange(ctx, emailChange)
+		},
+	)
+}
+
+// userDataModel is the profile of the user in the data export
+type userDataModel struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Username       string   `json:"username"`
+	EMail          string   `json:"email"`
+	Origin         string   `json:"origin"`
+	OrganizationID string   `json:"organizationId"`
+	TimeZone       string   `json:"timeZone"`
+	Roles          []string `json:"roles"`
+}
+
+// ExportUserData writes all data of the signed in user in the organization of the principal as zip archive,
+// the profile and the data of the user written by the user data exporter
+func (a *UserService) ExportUserData(ctx context.Context, principal *shared.Principal, w io.Writer) error {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	roles, err := a.userRepository.FindRolesByUserID(ctx, principal.OrganizationID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	zipWriter := zip.NewWriter(w)
+
+	profileWriter, err := zipWriter.Create("profile.json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(profileWriter)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(&userDataModel{
+		ID:             user.ID.String(),
+		Name:           user.Name,
+		Username:       user.Username,
+		EMail:          user.EMail,
+		Origin:         user.Origin,
+		OrganizationID: principal.OrganizationID.String(),
+		TimeZone:       user.TimeZone,
+		Roles:          roles,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = a.userDataExporter(ctx, principal.OrganizationID, user.Username, zipWriter)
+	if err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+// DeleteAccount deletes the signed in user after checking the password of users signing in with a password.
+// The user is removed from all organizations. Organizations where the user is the only member are deleted
+// as a whole. In all others the activities of the user are anonymized or deleted according to the user deletion policy.
+func (a *UserService) DeleteAccount(ctx context.Context, principal *shared.Principal, password string) error {
+	user, err := a.ReadProfile(ctx, principal)
+	if err != nil {
+		return err
+	}
+
+	if user.HasLocalPassword() {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+		if err != nil {
+			return ErrPasswordInvalid
+		}
+	}
+
+	memberships, err := a.userRepository.FindMembershipsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	var txFuncs []func(ctx context.Context) error
+	for _, membership := range memberships {
+		organizationID := membership.OrganizationID
+
+		members, err := a.userRepository.FindUsersByOrganizationID(ctx, organizationID)
+		if err != nil {
+			return err
+		}
+
+		if len(members) > 1 {
+			err = a.ensureRemainingAdmin(ctx, organizationID, user.ID)
+			if err != nil {
+				return err
+			}
+
+			member := *user
+			member.OrganizationID = organizationID
+			removalFuncs, err := a.memberRemoval(ctx, &member)
+			if err != nil {
+				return err
+			}
+
+			txFuncs = append(txFuncs, removalFuncs...)
+			continue
+		}
+
+		txFuncs = append(
+			txFuncs,
+			func(ctx context.Context) error {
+				return a.userDataRemover(ctx, organizationID, user.Username, "")
+			},
+			func(ctx context.Context) error {
+				return a.userRepository.DeleteUserByID(ctx, organizationID, user.ID)
+			},
+			func(ctx context.Context) error {
+				return a.organizationRepository.DeleteOrganizationByID(ctx, organizationID)
+			},
+		)
+	}
+
+	return a.repositoryTxer.InTx(ctx, txFuncs...)
+}
@@ -1,12 +1,20 @@
 package user
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/google/uuid"
 	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestSetUpNewUser(t *testing.T) {
@@ -85,3 +93,2279 @@ func TestSetUpNewUserWithUserRepositoryError(t *testing.T) {
 	is.True(err != nil)
 	is.Equal(len(mailResource.Mails), mailCount)
 }
+
+func TestInviteUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &UserService{
+		config:               &shared.Config{Webroot: "http://localhost:8080"},
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		mailResource:         mailResource,
+		userRepository:       NewInMemUserRepository(),
+		invitationRepository: invitationRepository,
+	}
+
+	principal := &shared.Principal{
+		Name:           "Admin",
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ADMIN"},
+	}
+
+	// Act
+	invitation, err := a.InviteUser(context.Background(), principal, "colleague@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(invitation.OrganizationID, shared.OrganizationIDSample)
+	is.Equal(invitation.CreatedBy, "admin@baralga.com")
+	is.Equal(len(invitationRepository.invitations), 2)
+	is.Equal(len(mailResource.Mails), 1)
+	is.True(strings.Contains(mailResource.Mails[0], fmt.Sprintf("http://localhost:8080/signup/invitation/%v", invitation.ID)))
+}
+
+func TestInviteUserReplacesPendingInvitation(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &UserService{
+		config:               &shared.Config{},
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		mailResource:         shared.NewInMemMailResource(),
+		userRepository:       NewInMemUserRepository(),
+		invitationRepository: invitationRepository,
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	invitation, err := a.InviteUser(context.Background(), principal, "invited@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(invitationRepository.invitations), 1)
+	is.Equal(invitationRepository.invitations[0].ID, invitation.ID)
+}
+
+func TestInviteExistingUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+
+	a := &UserService{
+		config:               &shared.Config{},
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		mailResource:         mailResource,
+		userRepository:       NewInMemUserRepository(),
+		invitationRepository: NewInMemInvitationRepository(),
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	_, err := a.InviteUser(context.Background(), principal, "admin@baralga.com")
+
+	// Assert
+	is.True(errors.Is(err, ErrUserExists))
+	is.Equal(len(mailResource.Mails), 0)
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &UserService{
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		invitationRepository: invitationRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.RevokeInvitation(context.Background(), principal, shared.InvitationIDSample)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(invitationRepository.invitations), 0)
+
+	_, err = a.ReadInvitation(context.Background(), shared.InvitationIDSample)
+	is.True(errors.Is(err, ErrInvitationNotFound))
+}
+
+func TestRevokeInvitationOfOtherOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &UserService{
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		invitationRepository: invitationRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: uuid.New(),
+	}
+
+	// Act
+	err := a.RevokeInvitation(context.Background(), principal, shared.InvitationIDSample)
+
+	// Assert
+	is.True(errors.Is(err, ErrInvitationNotFound))
+	is.Equal(len(invitationRepository.invitations), 1)
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+	invitationRepository := NewInMemInvitationRepository()
+
+	a := &UserService{
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		userRepository:       userRepository,
+		invitationRepository: invitationRepository,
+	}
+
+	user := &User{
+		Name:     "Ivy Invited",
+		Password: "myPassword?!§!",
+	}
+
+	// Act
+	err := a.AcceptInvitation(context.Background(), shared.InvitationIDSample, user)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.users), userCount+1)
+	is.Equal(user.Username, "invited@baralga.com")
+	is.Equal(user.EMail, "invited@baralga.com")
+	is.Equal(user.OrganizationID, shared.OrganizationIDSample)
+	is.Equal(len(invitationRepository.invitations), 0)
+}
+
+func TestAcceptExpiredInvitation(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+	invitationRepository := NewInMemInvitationRepository()
+	invitationRepository.invitations[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	a := &UserService{
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		userRepository:       userRepository,
+		invitationRepository: invitationRepository,
+	}
+
+	// Act
+	err := a.AcceptInvitation(context.Background(), shared.InvitationIDSample, &User{Name: "Ivy Invited"})
+
+	// Assert
+	is.True(errors.Is(err, ErrInvitationNotFound))
+	is.Equal(len(userRepository.users), userCount)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		roleRepository: NewInMemRoleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	user, err := a.UpdateUserRole(context.Background(), principal, member.ID, RoleAdmin)
+
+	// Assert
+	is.NoErr(err)
+	is.True(user.IsAdmin())
+
+	_, err = a.UpdateUserRole(context.Background(), principal, member.ID, "ROLE_ROOT")
+	is.True(errors.Is(err, ErrInvalidRole))
+}
+
+func TestUpdateUserRoleOfLastAdmin(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	_, err := a.UpdateUserRole(context.Background(), principal, userRepository.users[0].ID, RoleUser)
+
+	// Assert
+	is.True(errors.Is(err, ErrLastAdmin))
+	is.True(userRepository.users[0].IsAdmin())
+}
+
+func TestUpdateUserEnabled(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	var revokedUserIDs []uuid.UUID
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		sessionsRevoker: func(ctx context.Context, userID uuid.UUID) error {
+			revokedUserIDs = append(revokedUserIDs, userID)
+			return nil
+		},
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	user, err := a.UpdateUserEnabled(context.Background(), principal, member.ID, false)
+
+	// Assert
+	is.NoErr(err)
+	is.True(!user.Enabled)
+	is.Equal(revokedUserIDs, []uuid.UUID{member.ID})
+
+	_, err = a.UpdateUserEnabled(context.Background(), principal, userRepository.users[0].ID, false)
+	is.True(errors.Is(err, ErrLastAdmin))
+	is.True(userRepository.users[0].Enabled)
+	is.Equal(len(revokedUserIDs), 1)
+
+	_, err = a.UpdateUserEnabled(context.Background(), principal, member.ID, true)
+	is.NoErr(err)
+	is.Equal(len(revokedUserIDs), 1)
+}
+
+func TestDeleteUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	userCount := len(userRepository.users)
+	var anonymizedUsernames []string
+
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: NewInMemOrganizationRepository(),
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(&anonymizedUsernames),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	err := a.DeleteUser(context.Background(), principal, member.ID)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.users), userCount-1)
+	is.Equal(anonymizedUsernames, []string{member.AnonymizedUsername()})
+
+	err = a.DeleteUser(context.Background(), principal, userRepository.users[0].ID)
+	is.True(errors.Is(err, ErrLastAdmin))
+
+	err = a.DeleteUser(context.Background(), principal, uuid.New())
+	is.True(errors.Is(err, ErrUserNotFound))
+}
+
+func TestDeleteAdminWithOtherAdmin(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	member.Roles = []string{RoleAdmin}
+
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: NewInMemOrganizationRepository(),
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(nil),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	err := a.DeleteUser(context.Background(), principal, userRepository.users[0].ID)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(userRepository.users[0].ID, member.ID)
+}
+
+// addMemberSample adds an enabled user with role ROLE_USER to the sample organization
+func addMemberSample(userRepository *InMemUserRepository) *User {
+	member := &User{
+		ID:             uuid.New(),
+		Name:           "Ulani User",
+		Username:       "user1@baralga.com",
+		EMail:          "user1@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+		TimeZone:       DefaultTimeZone,
+		Enabled:        true,
+		Roles:          []string{RoleUser},
+	}
+	userRepository.users = append(userRepository.users, member)
+	return member
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	userRepository := NewInMemUserRepository()
+
+	a := &UserService{
+		config:         &shared.Config{Webroot: "http://localhost:8080"},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: userRepository,
+	}
+
+	// Act
+	err := a.RequestPasswordReset(context.Background(), "admin@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.passwordResets), 1)
+	is.Equal(userRepository.passwordResets[0].UserID, userRepository.users[0].ID)
+	is.Equal(len(mailResource.Mails), 1)
+	is.True(strings.Contains(mailResource.Mails[0], fmt.Sprintf("http://localhost:8080/password/reset/%v", userRepository.passwordResets[0].ID)))
+}
+
+func TestRequestPasswordResetForUnknownEMail(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	userRepository := NewInMemUserRepository()
+
+	a := &UserService{
+		config:         &shared.Config{},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: userRepository,
+	}
+
+	// Act
+	err := a.RequestPasswordReset(context.Background(), "nobody@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.passwordResets), 0)
+	is.Equal(len(mailResource.Mails), 0)
+}
+
+func TestRequestPasswordResetWithoutLocalPassword(t *testing.T) {
+	is := is.New(t)
+
+	for _, origin := range []string{"github", "oidc", "ldap", SCIMOrigin} {
+		t.Run(origin, func(t *testing.T) {
+			// Arrange
+			mailResource := shared.NewInMemMailResource()
+			userRepository := NewInMemUserRepository()
+			member := addMemberSample(userRepository)
+			member.Origin = origin
+
+			a := &UserService{
+				config:         &shared.Config{},
+				repositoryTxer: shared.NewInMemRepositoryTxer(),
+				mailResource:   mailResource,
+				userRepository: userRepository,
+			}
+
+			// Act
+			err := a.RequestPasswordReset(context.Background(), member.EMail)
+
+			// Assert
+			is.NoErr(err)
+			is.Equal(len(userRepository.passwordResets), 0)
+			is.Equal(len(mailResource.Mails), 0)
+		})
+	}
+}
+
+func TestSendLoginLink(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+
+	a := &UserService{
+		config:         &shared.Config{Webroot: "http://localhost:8080"},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: userRepository,
+	}
+
+	loginLink := &LoginLink{
+		ID:        uuid.New(),
+		UserID:    admin.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(LoginLinkValidity),
+	}
+
+	// Act
+	err := a.SendLoginLink(context.Background(), admin, loginLink, "signed-token")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.loginLinks), 1)
+	is.Equal(len(mailResource.Mails), 1)
+	is.True(strings.Contains(mailResource.Mails[0], "http://localhost:8080/login/link/signed-token"))
+}
+
+func TestUseLoginLink(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	loginLink := &LoginLink{
+		ID:        uuid.New(),
+		UserID:    admin.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(LoginLinkValidity),
+	}
+	expiredLoginLink := &LoginLink{
+		ID:        uuid.New(),
+		UserID:    admin.ID,
+		CreatedAt: time.Now().Add(-2 * LoginLinkValidity),
+		ExpiresAt: time.Now().Add(-LoginLinkValidity),
+	}
+	userRepository.loginLinks = append(userRepository.loginLinks, loginLink, expiredLoginLink)
+
+	t.Run("login link used", func(t *testing.T) {
+		usedLoginLink, err := a.UseLoginLink(context.Background(), loginLink.ID)
+		is.NoErr(err)
+		is.Equal(usedLoginLink.UserID, admin.ID)
+	})
+
+	t.Run("login link used only once", func(t *testing.T) {
+		_, err := a.UseLoginLink(context.Background(), loginLink.ID)
+		is.True(errors.Is(err, ErrLoginLinkNotFound))
+	})
+
+	t.Run("expired login link", func(t *testing.T) {
+		_, err := a.UseLoginLink(context.Background(), expiredLoginLink.ID)
+		is.True(errors.Is(err, ErrLoginLinkNotFound))
+		is.Equal(len(userRepository.loginLinks), 0)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+	for range 2 {
+		userRepository.passwordResets = append(userRepository.passwordResets, &PasswordReset{
+			ID:        uuid.New(),
+			UserID:    admin.ID,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(PasswordResetValidity),
+		})
+	}
+	passwordResetID := userRepository.passwordResets[0].ID
+
+	var revokedUserIDs []uuid.UUID
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		sessionsRevoker: func(ctx context.Context, userID uuid.UUID) error {
+			revokedUserIDs = append(revokedUserIDs, userID)
+			return nil
+		},
+	}
+
+	// Act
+	err := a.ResetPassword(context.Background(), passwordResetID, "myNewPassword?!")
+
+	// Assert
+	is.NoErr(err)
+	is.NoErr(bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte("myNewPassword?!")))
+	is.Equal(len(userRepository.passwordResets), 0)
+	is.Equal(revokedUserIDs, []uuid.UUID{admin.ID})
+
+	err = a.ResetPassword(context.Background(), passwordResetID, "myOtherPassword?!")
+	is.True(errors.Is(err, ErrPasswordResetNotFound))
+}
+
+func TestResetPasswordWithExpiredPasswordReset(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+	password := admin.Password
+	passwordReset := &PasswordReset{
+		ID:        uuid.New(),
+		UserID:    admin.ID,
+		CreatedAt: time.Now().Add(-2 * PasswordResetValidity),
+		ExpiresAt: time.Now().Add(-PasswordResetValidity),
+	}
+	userRepository.passwordResets = append(userRepository.passwordResets, passwordReset)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	// Act
+	err := a.ResetPassword(context.Background(), passwordReset.ID, "myNewPassword?!")
+
+	// Assert
+	is.True(errors.Is(err, ErrPasswordResetNotFound))
+	is.Equal(admin.Password, password)
+}
+
+func TestUpdateName(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	user, err := a.UpdateName(context.Background(), principal, "Ed Admin")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(user.Name, "Ed Admin")
+	is.Equal(userRepository.users[0].Name, "Ed Admin")
+}
+
+func TestChangePassword(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+	addPasswordResetSample(userRepository)
+
+	var revokedUserIDs []uuid.UUID
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		sessionsRevoker: func(ctx context.Context, userID uuid.UUID) error {
+			revokedUserIDs = append(revokedUserIDs, userID)
+			return nil
+		},
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.ChangePassword(context.Background(), principal, "adm1n", "myNewPassword?!")
+
+	// Assert
+	is.NoErr(err)
+	is.NoErr(bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte("myNewPassword?!")))
+	is.Equal(len(userRepository.passwordResets), 0)
+	is.Equal(revokedUserIDs, []uuid.UUID{admin.ID})
+}
+
+func TestChangePasswordWithWrongCurrentPassword(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+	password := admin.Password
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.ChangePassword(context.Background(), principal, "wrong", "myNewPassword?!")
+
+	// Assert
+	is.True(errors.Is(err, ErrPasswordInvalid))
+	is.Equal(admin.Password, password)
+}
+
+func TestChangeEMail(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	userRepository := NewInMemUserRepository()
+
+	a := &UserService{
+		config:         &shared.Config{Webroot: "http://localhost:8080"},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: userRepository,
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	emailChange, err := a.RequestEMailChange(context.Background(), principal, " ed@baralga.com ")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(emailChange.EMail, "ed@baralga.com")
+	is.Equal(userRepository.users[0].EMail, "admin@baralga.com")
+	is.Equal(len(mailResource.Mails), 1)
+	is.True(strings.Contains(mailResource.Mails[0], fmt.Sprintf("http://localhost:8080/profile/email/confirm/%v", emailChange.ConfirmationID)))
+
+	err = a.ConfirmEMailChange(context.Background(), emailChange.ConfirmationID)
+	is.NoErr(err)
+	is.Equal(userRepository.users[0].EMail, "ed@baralga.com")
+	is.Equal(userRepository.users[0].Username, "admin@baralga.com")
+
+	err = a.ConfirmEMailChange(context.Background(), emailChange.ConfirmationID)
+	is.True(errors.Is(err, ErrEMailChangeNotFound))
+}
+
+func TestConfirmEMailChangeExpired(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	emailChange := &EMailChange{
+		ConfirmationID: uuid.New(),
+		UserID:         userRepository.users[0].ID,
+		EMail:          "ed@baralga.com",
+		ExpiresAt:      time.Now().Add(-time.Minute),
+	}
+	userRepository.emailChanges = append(userRepository.emailChanges, emailChange)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	// Act
+	err := a.ConfirmEMailChange(context.Background(), emailChange.ConfirmationID)
+
+	// Assert
+	is.True(errors.Is(err, ErrEMailChangeExpired))
+	is.Equal(userRepository.users[0].EMail, "admin@baralga.com")
+}
+
+func TestChangeEMailToExistingUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+
+	a := &UserService{
+		config:         &shared.Config{},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: userRepository,
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	_, err := a.RequestEMailChange(context.Background(), principal, "user1@baralga.com")
+
+	// Assert
+	is.True(errors.Is(err, ErrUserExists))
+	is.Equal(len(userRepository.emailChanges), 0)
+	is.Equal(len(mailResource.Mails), 0)
+}
+
+func userDataRemoverSample(anonymizedUsernames *[]string) func(ctx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error {
+	return func(ctx context.Context, organizationID uuid.UUID, username, anonymizedUsername string) error {
+		if anonymizedUsernames != nil {
+			*anonymizedUsernames = append(*anonymizedUsernames, anonymizedUsername)
+		}
+		return nil
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+
+	var exportedUsername string
+	a := &UserService{
+		userRepository: userRepository,
+		userDataExporter: func(ctx context.Context, organizationID uuid.UUID, username string, zipWriter *zip.Writer) error {
+			exportedUsername = username
+			_, err := zipWriter.Create("activities.json")
+			return err
+		},
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	var buffer bytes.Buffer
+	err := a.ExportUserData(context.Background(), principal, &buffer)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(exportedUsername, "admin@baralga.com")
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	is.NoErr(err)
+	is.Equal(len(zipReader.File), 2)
+	is.Equal(zipReader.File[0].Name, "profile.json")
+	is.Equal(zipReader.File[1].Name, "activities.json")
+
+	profileReader, err := zipReader.File[0].Open()
+	is.NoErr(err)
+	defer profileReader.Close()
+
+	var profile userDataModel
+	err = json.NewDecoder(profileReader).Decode(&profile)
+	is.NoErr(err)
+	is.Equal(profile.Username, "admin@baralga.com")
+	is.Equal(profile.Roles, []string{RoleAdmin})
+}
+
+func TestDeleteAccountOfOnlyMember(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	organizationRepository := NewInMemOrganizationRepository()
+
+	var anonymizedUsernames []string
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(&anonymizedUsernames),
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.DeleteAccount(context.Background(), principal, "adm1n")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.users), 0)
+	is.Equal(len(organizationRepository.organizations), 0)
+	is.Equal(anonymizedUsernames, []string{""})
+}
+
+func TestDeleteAccountOfMember(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	member.Origin = "github"
+	organizationRepository := NewInMemOrganizationRepository()
+	organizationRepository.organizations[0].UserDeletionPolicy = UserDeletionPolicyDelete
+
+	var anonymizedUsernames []string
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(&anonymizedUsernames),
+	}
+
+	principal := &shared.Principal{
+		Username:       member.Username,
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.DeleteAccount(context.Background(), principal, "")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.users), 1)
+	is.Equal(len(organizationRepository.organizations), 1)
+	is.Equal(anonymizedUsernames, []string{""})
+}
+
+func TestDeleteAccountWithWrongPassword(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: NewInMemOrganizationRepository(),
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(nil),
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.DeleteAccount(context.Background(), principal, "wrong")
+
+	// Assert
+	is.True(errors.Is(err, ErrPasswordInvalid))
+	is.Equal(len(userRepository.users), 1)
+}
+
+func TestDeleteAccountOfLastAdmin(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: NewInMemOrganizationRepository(),
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(nil),
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.DeleteAccount(context.Background(), principal, "adm1n")
+
+	// Assert
+	is.True(errors.Is(err, ErrLastAdmin))
+	is.Equal(len(userRepository.users), 2)
+}
+
+func TestInviteUserOfOtherOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+
+	a := &UserService{
+		config:               &shared.Config{},
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		mailResource:         mailResource,
+		userRepository:       NewInMemUserRepository(),
+		invitationRepository: NewInMemInvitationRepository(),
+	}
+
+	principal := &shared.Principal{
+		Username:       "other.admin@baralga.com",
+		OrganizationID: uuid.New(),
+	}
+
+	// Act
+	invitation, err := a.InviteUser(context.Background(), principal, "admin@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(invitation.OrganizationID, principal.OrganizationID)
+	is.Equal(len(mailResource.Mails), 1)
+}
+
+func TestJoinOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	invitationRepository := NewInMemInvitationRepository()
+	organizationID := uuid.New()
+	invitationRepository.invitations[0].OrganizationID = organizationID
+	invitationRepository.invitations[0].EMail = "admin@baralga.com"
+
+	a := &UserService{
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		userRepository:       userRepository,
+		invitationRepository: invitationRepository,
+	}
+
+	// Act
+	err := a.JoinOrganization(context.Background(), shared.InvitationIDSample)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(invitationRepository.invitations), 0)
+
+	member, err := userRepository.FindUserByID(context.Background(), organizationID, userRepository.users[0].ID)
+	is.NoErr(err)
+	is.Equal(member.Roles, []string{RoleUser})
+}
+
+func TestJoinOrganizationAsMember(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+	invitationRepository := NewInMemInvitationRepository()
+	invitationRepository.invitations[0].EMail = "admin@baralga.com"
+
+	a := &UserService{
+		repositoryTxer:       shared.NewInMemRepositoryTxer(),
+		userRepository:       userRepository,
+		invitationRepository: invitationRepository,
+	}
+
+	// Act
+	err := a.JoinOrganization(context.Background(), shared.InvitationIDSample)
+
+	// Assert
+	is.True(errors.Is(err, ErrUserExists))
+	is.Equal(len(userRepository.users), userCount)
+}
+
+func TestReadMemberships(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+
+	err := userRepository.InsertMembership(context.Background(), uuid.New(), admin.ID, RoleUser)
+	is.NoErr(err)
+
+	disabledOrganizationID := uuid.New()
+	err = userRepository.InsertMembership(context.Background(), disabledOrganizationID, admin.ID, RoleUser)
+	is.NoErr(err)
+	err = userRepository.UpdateUserEnabled(context.Background(), disabledOrganizationID, admin.ID, false)
+	is.NoErr(err)
+
+	a := &UserService{
+		userRepository: userRepository,
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	memberships, err := a.ReadMemberships(context.Background(), principal)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(memberships), 2)
+	is.Equal(memberships[0].OrganizationID, shared.OrganizationIDSample)
+}
+
+func TestDeleteAccountInMultipleOrganizations(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	member.Roles = []string{RoleAdmin}
+	admin := userRepository.users[0]
+
+	organizationRepository := NewInMemOrganizationRepository()
+	otherOrganization := &Organization{
+		ID:                 uuid.New(),
+		Title:              "Other Organization",
+		UserDeletionPolicy: UserDeletionPolicyAnonymize,
+	}
+	_, err := organizationRepository.InsertOrganization(context.Background(), otherOrganization)
+	is.NoErr(err)
+
+	err = userRepository.InsertMembership(context.Background(), otherOrganization.ID, admin.ID, RoleAdmin)
+	is.NoErr(err)
+
+	var anonymizedUsernames []string
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		userDataRemover:        userDataRemoverSample(&anonymizedUsernames),
+	}
+
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: otherOrganization.ID,
+	}
+
+	// Act
+	err = a.DeleteAccount(context.Background(), principal, "adm1n")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.users), 1)
+	is.Equal(userRepository.users[0].ID, member.ID)
+	is.Equal(len(organizationRepository.organizations), 1)
+	is.Equal(anonymizedUsernames, []string{admin.AnonymizedUsername(), ""})
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	organizationRepository := NewInMemOrganizationRepository()
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		organizationRepository: organizationRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	organization := &Organization{
+		Title:              "Baralga Inc.",
+		TimeZone:           "Europe/London",
+		UserDeletionPolicy: UserDeletionPolicyAnonymize,
+		WeekStart:          time.Sunday,
+		WorkingDays:        []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday},
+		DateFormat:         shared.DateFormatISO,
+	}
+
+	// Act
+	updatedOrganization, err := a.UpdateOrganization(context.Background(), principal, organization)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(updatedOrganization.ID, shared.OrganizationIDSample)
+	is.Equal(updatedOrganization.Title, "Baralga Inc.")
+
+	settings, err := a.OrganizationSettingsReader()(context.Background(), shared.OrganizationIDSample)
+	is.NoErr(err)
+	is.Equal(settings.WeekStart, time.Sunday)
+	is.True(settings.IsWorkingDay(time.Sunday))
+	is.True(!settings.IsWorkingDay(time.Friday))
+	is.Equal(settings.TimeZone, "Europe/London")
+	is.Equal(settings.DateFormat, shared.DateFormatISO)
+}
+
+func TestUpdateOrganizationWithInvalidSettings(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	organizationRepository := NewInMemOrganizationRepository()
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		organizationRepository: organizationRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	organization := &Organization{
+		Title:              "Baralga Inc.",
+		TimeZone:           DefaultTimeZone,
+		UserDeletionPolicy: UserDeletionPolicyAnonymize,
+		WeekStart:          time.Monday,
+		WorkingDays:        []time.Weekday{},
+		DateFormat:         "2006",
+	}
+
+	// Act
+	_, err := a.UpdateOrganization(context.Background(), principal, organization)
+
+	// Assert
+	is.True(errors.Is(err, ErrInvalidOrganization))
+	is.Equal(organizationRepository.organizations[0].Title, "Test Organization")
+}
+
+func TestResendConfirmation(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+
+	userRepository := NewInMemUserRepository()
+	unconfirmedUser := &User{
+		ID:             uuid.New(),
+		Username:       "newbie@baralga.com",
+		EMail:          "newbie@baralga.com",
+		OrganizationID: uuid.New(),
+	}
+	oldConfirmationID := uuid.New()
+	_, err := userRepository.InsertUserWithConfirmationID(context.Background(), unconfirmedUser, oldConfirmationID)
+	is.NoErr(err)
+
+	a := &UserService{
+		config:         &shared.Config{},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: userRepository,
+	}
+
+	// Act
+	err = a.ResendConfirmation(context.Background(), "newbie@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(mailResource.Mails), 1)
+
+	_, err = userRepository.FindConfirmationByID(context.Background(), oldConfirmationID)
+	is.True(errors.Is(err, ErrConfirmationNotFound))
+}
+
+func TestResendConfirmationForConfirmedUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+
+	a := &UserService{
+		config:         &shared.Config{},
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		mailResource:   mailResource,
+		userRepository: NewInMemUserRepository(),
+	}
+
+	// Act
+	err := a.ResendConfirmation(context.Background(), "admin@baralga.com")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(mailResource.Mails), 0)
+}
+
+func TestConfirmSignupWithExpiredConfirmation(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	userRepository.confirmations[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+	}
+
+	// Act
+	err := a.ConfirmSignup(context.Background(), shared.ConfirmationIdSample)
+
+	// Assert
+	is.True(errors.Is(err, ErrConfirmationExpired))
+}
+
+func TestDeleteStaleUnconfirmedUsers(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	userRepository := NewInMemUserRepository()
+	organizationRepository := NewInMemOrganizationRepository()
+
+	organization := &Organization{
+		ID:    uuid.New(),
+		Title: "Norah Newbie",
+	}
+	_, err := organizationRepository.InsertOrganization(context.Background(), organization)
+	is.NoErr(err)
+
+	unconfirmedUser := &User{
+		ID:             uuid.New(),
+		Username:       "newbie@baralga.com",
+		EMail:          "newbie@baralga.com",
+		OrganizationID: organization.ID,
+	}
+	_, err = userRepository.InsertUserWithConfirmationID(context.Background(), unconfirmedUser, uuid.New())
+	is.NoErr(err)
+
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+	}
+
+	// Act
+	deletedNow, err := a.DeleteStaleUnconfirmedUsers(context.Background(), time.Now())
+	is.NoErr(err)
+
+	deletedLater, err := a.DeleteStaleUnconfirmedUsers(context.Background(), time.Now().Add(UnconfirmedUserRetention+time.Hour))
+	is.NoErr(err)
+
+	// Assert
+	is.Equal(deletedNow, 0)
+	is.Equal(deletedLater, 1)
+	is.Equal(len(userRepository.users), 1)
+	is.Equal(len(organizationRepository.organizations), 1)
+}
+
+func TestCreateTeamWithMemberOfOtherOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	teamRepository := NewInMemTeamRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: NewInMemUserRepository(),
+		teamRepository: teamRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	_, err := a.CreateTeam(context.Background(), principal, &Team{
+		Name:      "Backend",
+		MemberIDs: []uuid.UUID{uuid.New()},
+	})
+
+	// Assert
+	is.True(errors.Is(err, ErrInvalidTeam))
+	is.Equal(len(teamRepository.teams), 0)
+}
+
+func TestTeamsReader(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	teamRepository := NewInMemTeamRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		teamRepository: teamRepository,
+	}
+
+	admin := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "admin@baralga.com",
+		Roles:          []string{RoleAdmin},
+	}
+
+	_, err := a.CreateTeam(context.Background(), admin, &Team{
+		Name:       "Backend",
+		LeadUserID: member.ID,
+		MemberIDs:  []uuid.UUID{userRepository.users[0].ID, member.ID},
+	})
+	is.NoErr(err)
+
+	_, err = a.CreateTeam(context.Background(), admin, &Team{
+		Name: "Frontend",
+	})
+	is.NoErr(err)
+
+	teamsReader := a.TeamsReader()
+
+	// Act
+	adminTeams, err := teamsReader(context.Background(), admin)
+	is.NoErr(err)
+
+	leadTeams, err := teamsReader(context.Background(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       member.Username,
+		Roles:          []string{RoleUser},
+	})
+	is.NoErr(err)
+
+	otherTeams, err := teamsReader(context.Background(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "other@baralga.com",
+		Roles:          []string{RoleUser},
+	})
+	is.NoErr(err)
+
+	// Assert
+	is.Equal(len(adminTeams), 2)
+	is.Equal(len(leadTeams), 1)
+	is.Equal(leadTeams[0].Name, "Backend")
+	is.Equal(leadTeams[0].Usernames, []string{"admin@baralga.com", member.Username})
+	is.Equal(len(otherTeams), 0)
+}
+
+func TestCreateRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	roleRepository := NewInMemRoleRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: NewInMemUserRepository(),
+		roleRepository: roleRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	role, err := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title:       " Team Controller ",
+		Permissions: []string{shared.PermissionProjectsWrite, shared.PermissionActivitiesReadAll, shared.PermissionProjectsWrite},
+	})
+	is.NoErr(err)
+
+	_, duplicateErr := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title: "Team-Controller",
+	})
+
+	_, invalidErr := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title:       "Root",
+		Permissions: []string{"everything"},
+	})
+
+	// Assert
+	is.Equal(role.Name, "ROLE_TEAM_CONTROLLER")
+	is.Equal(role.Title, "Team Controller")
+	is.Equal(role.Permissions, []string{shared.PermissionActivitiesReadAll, shared.PermissionProjectsWrite})
+	is.True(errors.Is(duplicateErr, ErrInvalidRole))
+	is.True(errors.Is(invalidErr, ErrInvalidRole))
+	is.Equal(len(roleRepository.roles), 1)
+}
+
+func TestCreateRoleWithTitleOfBuiltInRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: NewInMemUserRepository(),
+		roleRepository: NewInMemRoleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	_, err := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title: "Admin",
+	})
+
+	// Assert
+	is.True(errors.Is(err, ErrInvalidRole))
+}
+
+func TestUpdateBuiltInRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: NewInMemUserRepository(),
+		roleRepository: NewInMemRoleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	_, err := a.UpdateRole(context.Background(), principal, &OrganizationRole{
+		Name:        RoleUser,
+		Title:       "User",
+		Permissions: shared.Permissions,
+	})
+
+	// Assert
+	is.True(errors.Is(err, ErrRoleNotFound))
+}
+
+func TestUpdateUserRoleToCustomRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		roleRepository: NewInMemRoleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	_, err := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title:       "Controller",
+		Permissions: []string{shared.PermissionActivitiesReadAll},
+	})
+	is.NoErr(err)
+
+	// Act
+	user, err := a.UpdateUserRole(context.Background(), principal, member.ID, "ROLE_CONTROLLER")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(user.Roles, []string{"ROLE_CONTROLLER"})
+}
+
+func TestUpdateUserRoleBeyondOwnPermissions(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		roleRepository: NewInMemRoleRepository(),
+	}
+
+	admin := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	_, err := a.CreateRole(context.Background(), admin, &OrganizationRole{
+		Title:       "Controller",
+		Permissions: []string{shared.PermissionActivitiesReadAll, shared.PermissionUsersWrite},
+	})
+	is.NoErr(err)
+
+	// the user administrator may manage users but holds no other permissions
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       member.Username,
+		Roles:          []string{"ROLE_USER_ADMINISTRATOR"},
+		Permissions:    []string{shared.PermissionUsersWrite, shared.PermissionReportsReadTeam},
+	}
+
+	// Act
+	_, errAdmin := a.UpdateUserRole(context.Background(), principal, member.ID, RoleAdmin)
+	_, errCustomRole := a.UpdateUserRole(context.Background(), principal, member.ID, "ROLE_CONTROLLER")
+	_, errUser := a.UpdateUserRole(context.Background(), principal, member.ID, RoleUser)
+
+	// Assert
+	is.True(errors.Is(errAdmin, ErrPermissionDenied))
+	is.True(errors.Is(errCustomRole, ErrPermissionDenied))
+	is.NoErr(errUser)
+	is.Equal(member.Roles, []string{RoleUser})
+}
+
+func TestCreateRoleBeyondOwnPermissions(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	roleRepository := NewInMemRoleRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		roleRepository: roleRepository,
+	}
+
+	// the organization administrator may manage roles but holds no permissions on users or activities
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ORGANIZATION_ADMINISTRATOR"},
+		Permissions:    []string{shared.PermissionOrganizationWrite},
+	}
+
+	// Act
+	_, err := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title:       "Controller",
+		Permissions: []string{shared.PermissionOrganizationWrite, shared.PermissionUsersWrite},
+	})
+
+	// Assert
+	is.True(errors.Is(err, ErrPermissionDenied))
+	_, err = roleRepository.FindRoleByName(context.Background(), shared.OrganizationIDSample, "ROLE_CONTROLLER")
+	is.True(errors.Is(err, ErrRoleNotFound))
+
+	_, err = a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title:       "Controller",
+		Permissions: []string{shared.PermissionOrganizationWrite},
+	})
+	is.NoErr(err)
+}
+
+func TestUpdateRoleBeyondOwnPermissions(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	roleRepository := NewInMemRoleRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		roleRepository: roleRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{"ROLE_ORGANIZATION_ADMINISTRATOR"},
+		Permissions:    []string{shared.PermissionOrganizationWrite},
+	}
+
+	role, err := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title:       "Organization Administrator",
+		Permissions: []string{shared.PermissionOrganizationWrite},
+	})
+	is.NoErr(err)
+
+	// Act
+	_, err = a.UpdateRole(context.Background(), principal, &OrganizationRole{
+		Name:        role.Name,
+		Title:       role.Title,
+		Permissions: []string{shared.PermissionOrganizationWrite, shared.PermissionActivitiesWriteAll},
+	})
+
+	// Assert
+	is.True(errors.Is(err, ErrPermissionDenied))
+
+	role, err = roleRepository.FindRoleByName(context.Background(), shared.OrganizationIDSample, role.Name)
+	is.NoErr(err)
+	is.Equal(role.Permissions, []string{shared.PermissionOrganizationWrite})
+}
+
+func TestChangeAdminWithoutAdminRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	admin := userRepository.users[0]
+	otherAdmin := addMemberSample(userRepository)
+	otherAdmin.Roles = []string{RoleAdmin}
+	member := addMemberSample(userRepository)
+	twoFactorRepository := NewInMemTwoFactorRepository()
+	twoFactorRepository.twoFactors = append(twoFactorRepository.twoFactors, &TwoFactor{
+		UserID:      admin.ID,
+		Secret:      "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		CreatedAt:   time.Now(),
+		ConfirmedAt: time.Now(),
+	})
+
+	a := &UserService{
+		repositoryTxer:         shared.NewInMemRepositoryTxer(),
+		userRepository:         userRepository,
+		organizationRepository: NewInMemOrganizationRepository(),
+		teamRepository:         NewInMemTeamRepository(),
+		apiTokenRepository:     NewInMemAPITokenRepository(),
+		twoFactorRepository:    twoFactorRepository,
+		userDataRemover:        userDataRemoverSample(nil),
+	}
+
+	// the user administrator may manage users but is no admin
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       member.Username,
+		Roles:          []string{"ROLE_USER_ADMINISTRATOR"},
+		Permissions:    []string{shared.PermissionUsersWrite},
+	}
+
+	t.Run("disable admin", func(t *testing.T) {
+		_, err := a.UpdateUserEnabled(context.Background(), principal, admin.ID, false)
+		is.True(errors.Is(err, ErrPermissionDenied))
+		is.True(admin.Enabled)
+	})
+
+	t.Run("change role of admin", func(t *testing.T) {
+		_, err := a.UpdateUserRole(context.Background(), principal, admin.ID, RoleUser)
+		is.True(errors.Is(err, ErrPermissionDenied))
+		is.Equal(admin.Roles, []string{RoleAdmin})
+	})
+
+	t.Run("reset second factor of admin", func(t *testing.T) {
+		err := a.ResetTwoFactor(context.Background(), principal, admin.ID)
+		is.True(errors.Is(err, ErrPermissionDenied))
+		is.Equal(len(twoFactorRepository.twoFactors), 1)
+	})
+
+	t.Run("delete admin", func(t *testing.T) {
+		err := a.DeleteUser(context.Background(), principal, admin.ID)
+		is.True(errors.Is(err, ErrPermissionDenied))
+		is.Equal(len(userRepository.users), 3)
+	})
+
+	t.Run("disable member", func(t *testing.T) {
+		_, err := a.UpdateUserEnabled(context.Background(), principal, member.ID, false)
+		is.NoErr(err)
+		is.True(!member.Enabled)
+	})
+}
+
+func TestDeleteRole(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	roleRepository := NewInMemRoleRepository()
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		roleRepository: roleRepository,
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	_, err := a.CreateRole(context.Background(), principal, &OrganizationRole{
+		Title: "Controller",
+	})
+	is.NoErr(err)
+
+	_, err = a.UpdateUserRole(context.Background(), principal, member.ID, "ROLE_CONTROLLER")
+	is.NoErr(err)
+
+	// Act
+	err = a.DeleteRole(context.Background(), principal, "ROLE_CONTROLLER")
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(roleRepository.roles), 0)
+	is.Equal(member.Roles, []string{RoleUser})
+	is.True(errors.Is(a.DeleteRole(context.Background(), principal, RoleAdmin), ErrRoleNotFound))
+}
+
+func TestTeamsReaderOfManager(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	teamRepository := NewInMemTeamRepository()
+	teamRepository.teams = append(teamRepository.teams, &Team{
+		ID:             uuid.New(),
+		OrganizationID: shared.OrganizationIDSample,
+		Name:           "Backend",
+		MemberIDs:      []uuid.UUID{member.ID},
+	})
+
+	a := &UserService{
+		userRepository: userRepository,
+		teamRepository: teamRepository,
+	}
+
+	// Act
+	teams, err := a.TeamsReader()(context.Background(), &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Username:       "manager@baralga.com",
+		Roles:          []string{RoleManager},
+	})
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(teams), 1)
+	is.Equal(teams[0].Usernames, []string{member.Username})
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	apiTokenRepository := NewInMemAPITokenRepository()
+
+	a := &UserService{
+		repositoryTxer:     shared.NewInMemRepositoryTxer(),
+		userRepository:     NewInMemUserRepository(),
+		apiTokenRepository: apiTokenRepository,
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	apiToken, secret, err := a.CreateAPIToken(context.Background(), principal, " CI ", time.Time{}, nil)
+
+	// Assert
+	is.NoErr(err)
+	is.True(IsAPIToken(secret))
+	is.Equal(apiToken.Name, "CI")
+	is.Equal(apiToken.TokenHash, HashAPIToken(secret))
+	is.Equal(apiToken.UserID, uuid.MustParse("00000000-0000-0000-1111-000000000001"))
+
+	apiTokens, err := a.ReadAPITokens(context.Background(), principal)
+	is.NoErr(err)
+	is.Equal(len(apiTokens), 1)
+}
+
+func TestCreateAPITokenWithExpiryInPast(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &UserService{
+		repositoryTxer:     shared.NewInMemRepositoryTxer(),
+		userRepository:     NewInMemUserRepository(),
+		apiTokenRepository: NewInMemAPITokenRepository(),
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	_, _, err := a.CreateAPIToken(context.Background(), principal, "CI", time.Now().Add(-time.Hour), nil)
+
+	// Assert
+	is.True(errors.Is(err, ErrInvalidAPIToken))
+}
+
+func TestDeleteAPITokenOfOtherUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	addMemberSample(userRepository)
+	apiTokenRepository := NewInMemAPITokenRepository()
+
+	a := &UserService{
+		repositoryTxer:     shared.NewInMemRepositoryTxer(),
+		userRepository:     userRepository,
+		apiTokenRepository: apiTokenRepository,
+	}
+	adminPrincipal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+	memberPrincipal := &shared.Principal{
+		Username:       "user1@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	apiToken, _, err := a.CreateAPIToken(context.Background(), adminPrincipal, "CI", time.Time{}, nil)
+	is.NoErr(err)
+
+	// Act
+	err = a.DeleteAPIToken(context.Background(), memberPrincipal, apiToken.ID)
+
+	// Assert
+	is.True(errors.Is(err, ErrAPITokenNotFound))
+	is.Equal(len(apiTokenRepository.apiTokens), 1)
+
+	err = a.DeleteAPIToken(context.Background(), adminPrincipal, apiToken.ID)
+	is.NoErr(err)
+	is.Equal(len(apiTokenRepository.apiTokens), 0)
+}
+
+func TestCreateAPITokenWithScopes(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &UserService{
+		repositoryTxer:     shared.NewInMemRepositoryTxer(),
+		userRepository:     NewInMemUserRepository(),
+		apiTokenRepository: NewInMemAPITokenRepository(),
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	apiToken, _, err := a.CreateAPIToken(context.Background(), principal, "Dashboard", time.Time{}, []string{shared.ScopeReportsRead, shared.ScopeActivitiesRead, shared.ScopeReportsRead})
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(apiToken.Scopes, []string{shared.ScopeActivitiesRead, shared.ScopeReportsRead})
+
+	_, _, err = a.CreateAPIToken(context.Background(), principal, "Dashboard", time.Time{}, []string{"activities:delete"})
+	is.True(errors.Is(err, ErrInvalidAPIToken))
+}
+
+func TestConfirmTwoFactor(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	twoFactorRepository := NewInMemTwoFactorRepository()
+
+	a := &UserService{
+		repositoryTxer:      shared.NewInMemRepositoryTxer(),
+		userRepository:      NewInMemUserRepository(),
+		twoFactorRepository: twoFactorRepository,
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	twoFactor, err := a.StartTwoFactorEnrollment(context.Background(), principal)
+	is.NoErr(err)
+	is.True(!twoFactor.IsConfirmed())
+
+	// Act
+	recoveryCodes, err := a.ConfirmTwoFactor(context.Background(), principal, currentTOTP(twoFactor))
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(recoveryCodes), RecoveryCodeCount)
+
+	confirmedTwoFactor, err := a.ReadTwoFactor(context.Background(), principal)
+	is.NoErr(err)
+	is.True(confirmedTwoFactor.IsConfirmed())
+	is.Equal(len(confirmedTwoFactor.RecoveryCodeHashes), RecoveryCodeCount)
+
+	_, err = a.StartTwoFactorEnrollment(context.Background(), principal)
+	is.True(errors.Is(err, ErrTwoFactorEnabled))
+}
+
+func TestConfirmTwoFactorWithInvalidCode(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+
+	a := &UserService{
+		repositoryTxer:      shared.NewInMemRepositoryTxer(),
+		userRepository:      NewInMemUserRepository(),
+		twoFactorRepository: NewInMemTwoFactorRepository(),
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	firstTwoFactor, err := a.StartTwoFactorEnrollment(context.Background(), principal)
+	is.NoErr(err)
+
+	// an unconfirmed enrollment is replaced
+	twoFactor, err := a.StartTwoFactorEnrollment(context.Background(), principal)
+	is.NoErr(err)
+	is.True(twoFactor.Secret != firstTwoFactor.Secret)
+
+	// Act
+	_, err = a.ConfirmTwoFactor(context.Background(), principal, currentTOTP(firstTwoFactor))
+
+	// Assert
+	is.True(errors.Is(err, ErrInvalidTwoFactorCode))
+
+	unconfirmedTwoFactor, err := a.ReadTwoFactor(context.Background(), principal)
+	is.NoErr(err)
+	is.True(!unconfirmedTwoFactor.IsConfirmed())
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	twoFactorRepository := NewInMemTwoFactorRepository()
+
+	a := &UserService{
+		repositoryTxer:      shared.NewInMemRepositoryTxer(),
+		userRepository:      NewInMemUserRepository(),
+		twoFactorRepository: twoFactorRepository,
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	twoFactor, err := a.StartTwoFactorEnrollment(context.Background(), principal)
+	is.NoErr(err)
+	recoveryCodes, err := a.ConfirmTwoFactor(context.Background(), principal, currentTOTP(twoFactor))
+	is.NoErr(err)
+
+	// Act
+	err = a.DisableTwoFactor(context.Background(), principal, "abcde-fghij")
+	is.True(errors.Is(err, ErrInvalidTwoFactorCode))
+
+	err = a.DisableTwoFactor(context.Background(), principal, recoveryCodes[0])
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(twoFactorRepository.twoFactors), 0)
+}
+
+func TestResetTwoFactor(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	twoFactorRepository := NewInMemTwoFactorRepository()
+	twoFactorRepository.twoFactors = append(twoFactorRepository.twoFactors, &TwoFactor{
+		UserID:      member.ID,
+		Secret:      "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		CreatedAt:   time.Now(),
+		ConfirmedAt: time.Now(),
+	})
+
+	a := &UserService{
+		repositoryTxer:      shared.NewInMemRepositoryTxer(),
+		userRepository:      userRepository,
+		twoFactorRepository: twoFactorRepository,
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	err := a.ResetTwoFactor(context.Background(), &shared.Principal{OrganizationID: uuid.New()}, member.ID)
+	is.True(errors.Is(err, ErrUserNotFound))
+
+	err = a.ResetTwoFactor(context.Background(), principal, member.ID)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(twoFactorRepository.twoFactors), 0)
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+	loginThrottleRepository := NewInMemLoginThrottleRepository()
+
+	a := &UserService{
+		config:                  &shared.Config{},
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		mailResource:            mailResource,
+		userRepository:          NewInMemUserRepository(),
+		loginThrottleRepository: loginThrottleRepository,
+	}
+
+	// Act
+	for i := 0; i < LoginFailuresBeforeLockout-1; i++ {
+		err := a.RecordLoginFailure(context.Background(), "admin@baralga.com", "10.0.0.1")
+		is.NoErr(err)
+	}
+
+	// Assert
+	err := a.CheckLoginThrottle(context.Background(), "admin@baralga.com", "10.0.0.2")
+	is.True(errors.Is(err, ErrLoginThrottled))
+	is.Equal(len(mailResource.Mails), 0)
+
+	err = a.RecordLoginFailure(context.Background(), "admin@baralga.com", "10.0.0.1")
+	is.NoErr(err)
+
+	err = a.CheckLoginThrottle(context.Background(), "admin@baralga.com", "10.0.0.2")
+	is.True(errors.Is(err, ErrAccountLocked))
+	is.Equal(len(mailResource.Mails), 1)
+	is.True(strings.Contains(mailResource.Mails[0], "admin@baralga.com"))
+
+	loginThrottles, err := loginThrottleRepository.FindLoginThrottles(context.Background(), []string{IPLoginThrottleKey("10.0.0.1")})
+	is.NoErr(err)
+	is.Equal(len(loginThrottles), 1)
+	is.Equal(loginThrottles[0].Failures, LoginFailuresBeforeLockout)
+	is.True(!loginThrottles[0].IsLocked(time.Now()))
+}
+
+func TestRecordLoginFailureOfUnknownUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	mailResource := shared.NewInMemMailResource()
+
+	a := &UserService{
+		config:                  &shared.Config{},
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		mailResource:            mailResource,
+		userRepository:          NewInMemUserRepository(),
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
+	}
+
+	// Act
+	for i := 0; i < LoginFailuresBeforeLockout; i++ {
+		err := a.RecordLoginFailure(context.Background(), "nobody@baralga.com", "")
+		is.NoErr(err)
+	}
+
+	// Assert
+	err := a.CheckLoginThrottle(context.Background(), "nobody@baralga.com", "")
+	is.True(errors.Is(err, ErrAccountLocked))
+	is.Equal(len(mailResource.Mails), 0)
+}
+
+func TestCheckLoginThrottle(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	loginThrottleRepository := NewInMemLoginThrottleRepository()
+	loginThrottleRepository.loginThrottles = append(
+		loginThrottleRepository.loginThrottles,
+		&LoginThrottle{
+			Key:           AccountLoginThrottleKey("admin@baralga.com"),
+			Failures:      LoginFailuresBeforeBackoff,
+			LastFailureAt: time.Now().Add(-time.Minute),
+		},
+		&LoginThrottle{
+			Key:           IPLoginThrottleKey("10.0.0.1"),
+			Failures:      LoginIPFailuresBeforeBackoff,
+			LastFailureAt: time.Now(),
+		},
+	)
+
+	a := &UserService{
+		loginThrottleRepository: loginThrottleRepository,
+	}
+
+	// Act and Assert
+	err := a.CheckLoginThrottle(context.Background(), "admin@baralga.com", "10.0.0.2")
+	is.NoErr(err)
+
+	err = a.CheckLoginThrottle(context.Background(), "user1@baralga.com", "10.0.0.1")
+	is.True(errors.Is(err, ErrLoginThrottled))
+}
+
+func TestUnlockUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	loginThrottleRepository := NewInMemLoginThrottleRepository()
+	loginThrottleRepository.loginThrottles = append(loginThrottleRepository.loginThrottles, &LoginThrottle{
+		Key:           AccountLoginThrottleKey(member.Username),
+		Failures:      LoginFailuresBeforeLockout,
+		LastFailureAt: time.Now(),
+		LockedUntil:   time.Now().Add(LoginLockoutDuration),
+	})
+
+	a := &UserService{
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          userRepository,
+		loginThrottleRepository: loginThrottleRepository,
+	}
+	principal := &shared.Principal{
+		Username:       "admin@baralga.com",
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	lockedUser, err := a.ReadUser(context.Background(), principal, member.ID)
+	is.NoErr(err)
+	is.True(lockedUser.Locked)
+
+	// Act
+	err = a.UnlockUser(context.Background(), &shared.Principal{OrganizationID: uuid.New()}, member.ID)
+	is.True(errors.Is(err, ErrUserNotFound))
+
+	err = a.UnlockUser(context.Background(), principal, member.ID)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(loginThrottleRepository.loginThrottles), 0)
+
+	unlockedUser, err := a.ReadUser(context.Background(), principal, member.ID)
+	is.NoErr(err)
+	is.True(!unlockedUser.Locked)
+}
+
+func TestDeleteStaleLoginThrottles(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	now := time.Now()
+	loginThrottleRepository := NewInMemLoginThrottleRepository()
+	loginThrottleRepository.loginThrottles = append(
+		loginThrottleRepository.loginThrottles,
+		&LoginThrottle{
+			Key:           IPLoginThrottleKey("10.0.0.1"),
+			Failures:      1,
+			LastFailureAt: now.Add(-LoginFailureRetention - time.Hour),
+		},
+		&LoginThrottle{
+			Key:           IPLoginThrottleKey("10.0.0.2"),
+			Failures:      1,
+			LastFailureAt: now.Add(-time.Hour),
+		},
+	)
+
+	a := &UserService{
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		loginThrottleRepository: loginThrottleRepository,
+	}
+
+	// Act
+	deleted, err := a.DeleteStaleLoginThrottles(context.Background(), now)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(deleted, 1)
+	is.Equal(len(loginThrottleRepository.loginThrottles), 1)
+	is.Equal(loginThrottleRepository.loginThrottles[0].Key, IPLoginThrottleKey("10.0.0.2"))
+}
+
+func TestProvisionUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	userCount := len(userRepository.users)
+
+	a := &UserService{
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          userRepository,
+		organizationRepository:  NewInMemOrganizationRepository(),
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+	}
+
+	// Act
+	user, err := a.ProvisionUser(context.Background(), principal, &User{
+		Username: "jane@baralga.com",
+		EMail:    "jane@baralga.com",
+		Name:     "Jane Doe",
+	})
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(len(userRepository.users), userCount+1)
+	is.Equal(user.Name, "Jane Doe")
+	is.Equal(user.Origin, SCIMOrigin)
+	is.Equal(user.Roles, []string{RoleUser})
+	is.True(user.Enabled)
+
+	_, err = a.ProvisionUser(context.Background(), principal, &User{Username: "jane@baralga.com"})
+	is.True(errors.Is(err, ErrUserExists))
+
+	// users of other organizations aren't linked by their username
+	otherPrincipal := &shared.Principal{
+		OrganizationID: uuid.New(),
+	}
+	_, err = a.ProvisionUser(context.Background(), otherPrincipal, &User{Username: "jane@baralga.com"})
+	is.True(errors.Is(err, ErrUserExists))
+	is.Equal(len(userRepository.users), userCount+1)
+}
+
+func TestUpdateProvisionedUser(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	member.Origin = SCIMOrigin
+
+	a := &UserService{
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          userRepository,
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	user, err := a.UpdateProvisionedUser(context.Background(), principal, member.ID, "Ulani Provisioned", false)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(user.Name, "Ulani Provisioned")
+	is.True(!user.Enabled)
+
+	_, err = a.UpdateProvisionedUser(context.Background(), principal, userRepository.users[0].ID, "", false)
+	is.True(errors.Is(err, ErrLastAdmin))
+}
+
+func TestUpdateProvisionedUserOfOtherOrganization(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	member.Origin = SCIMOrigin
+	admin := userRepository.users[0]
+
+	otherOrganizationID := uuid.New()
+	err := userRepository.InsertMembership(context.Background(), otherOrganizationID, member.ID, RoleUser)
+	is.NoErr(err)
+
+	a := &UserService{
+		repositoryTxer:          shared.NewInMemRepositoryTxer(),
+		userRepository:          userRepository,
+		loginThrottleRepository: NewInMemLoginThrottleRepository(),
+	}
+
+	otherPrincipal := &shared.Principal{
+		OrganizationID: otherOrganizationID,
+	}
+
+	// Act
+	user, err := a.UpdateProvisionedUser(context.Background(), otherPrincipal, member.ID, "Taken Over", false)
+
+	// Assert
+	is.NoErr(err)
+	is.Equal(user.Name, "Ulani User")
+	is.Equal(member.Name, "Ulani User")
+	is.True(!user.Enabled)
+	is.True(member.Enabled)
+
+	// users who weren't set up by the identity provider keep their name
+	_, err = a.UpdateProvisionedUser(context.Background(), &shared.Principal{OrganizationID: shared.OrganizationIDSample}, admin.ID, "Taken Over", true)
+	is.NoErr(err)
+	is.True(admin.Name != "Taken Over")
+}
+
+func TestUpdateRoleMembers(t *testing.T) {
+	// Arrange
+	is := is.New(t)
+	userRepository := NewInMemUserRepository()
+	member := addMemberSample(userRepository)
+	admin := userRepository.users[0]
+
+	a := &UserService{
+		repositoryTxer: shared.NewInMemRepositoryTxer(),
+		userRepository: userRepository,
+		roleRepository: NewInMemRoleRepository(),
+	}
+
+	principal := &shared.Principal{
+		OrganizationID: shared.OrganizationIDSample,
+		Roles:          []string{RoleAdmin},
+	}
+
+	// Act
+	err := a.UpdateRoleMembers(context.Background(), principal, RoleAdmin, []uuid.UUID{member.ID})
+
+	// Assert
+	is.NoErr(err)
+	is.True(member.IsAdmin())
+	is.True(!admin.IsAdmin())
+
+	err = a.UpdateRoleMembers(context.Background(), principal, RoleAdmin, nil)
+	is.True(errors.Is(err, ErrLastAdmin))
+
+	err = a.UpdateRoleMembers(context.Background(), principal, RoleAdmin, []uuid.UUID{uuid.New()})
+	is.True(errors.Is(err, ErrUserNotFound))
+
+	err = a.UpdateRoleMembers(context.Background(), principal, "ROLE_ROOT", nil)
+	is.True(errors.Is(err, ErrInvalidRole))
+}
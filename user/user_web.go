@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 
 	"github.com/baralga/shared"
+	"github.com/baralga/shared/hx"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -25,6 +28,47 @@ type signupFormModel struct {
 	AcceptConditions bool
 }
 
+type confirmationResendFormModel struct {
+	CSRFToken string
+	EMail     string `validate:"required,email"`
+}
+
+type timeZoneFormModel struct {
+	CSRFToken string
+	TimeZone  string `validate:"required,max=100"`
+}
+
+// timeZones are the time zones offered for selection
+var timeZones = []string{
+	"UTC",
+	"Europe/London",
+	"Europe/Lisbon",
+	"Europe/Berlin",
+	"Europe/Paris",
+	"Europe/Madrid",
+	"Europe/Rome",
+	"Europe/Vienna",
+	"Europe/Zurich",
+	"Europe/Amsterdam",
+	"Europe/Warsaw",
+	"Europe/Helsinki",
+	"Europe/Athens",
+	"Europe/Istanbul",
+	"Europe/Moscow",
+	"America/New_York",
+	"America/Chicago",
+	"America/Denver",
+	"America/Los_Angeles",
+	"America/Sao_Paulo",
+	"Asia/Dubai",
+	"Asia/Kolkata",
+	"Asia/Singapore",
+	"Asia/Shanghai",
+	"Asia/Tokyo",
+	"Australia/Sydney",
+	"Pacific/Auckland",
+}
+
 type UserWebHandlers struct {
 	config         *shared.Config
 	userService    *UserService
@@ -40,6 +84,8 @@ func NewUserWeb(config *shared.Config, userService *UserService, userRepository
 }
 
 func (a *UserWebHandlers) RegisterProtected(r chi.Router) {
+	r.Get("/settings/time-zone", a.HandleTimeZonePage())
+	r.Post("/settings/time-zone", a.HandleTimeZoneForm())
 }
 
 func (a *UserWebHandlers) RegisterOpen(r chi.Router) {
@@ -47,6 +93,8 @@ func (a *UserWebHandlers) RegisterOpen(r chi.Router) {
 	r.Post("/signup", a.HandleSignUpForm())
 	r.Post("/signup/validate", a.HandleSignUpFormValidate())
 	r.Get("/signup/confirm/{confirmation-id}", a.HandleSignUpConfirm())
+	r.Get("/signup/resend", a.HandleConfirmationResendPage())
+	r.Post("/signup/resend", a.HandleConfirmationResendForm())
 }
 
 func (a *UserWebHandlers) signupFormValidator(incomplete bool) func(ctx context.Context, formModel signupFormModel) (map[string]string, error) {
@@ -72,6 +120,11 @@ func (a *UserWebHandlers) signupFormValidator(incomplete bool) func(ctx context.
 			if !errors.Is(err, ErrUserNotFound) {
 				fieldErrors["EMail"] = "Email not available."
 			}
+
+			_, err = userRepository.FindUnconfirmedUserByUsername(ctx, formModel.EMail)
+			if !errors.Is(err, ErrUserNotFound) {
+				fieldErrors["EMail"] = "Email not confirmed yet, request a new confirmation link to activate it."
+			}
 		}
 
 		if len(fieldErrors) > 0 {
@@ -84,27 +137,80 @@ func (a *UserWebHandlers) signupFormValidator(incomplete bool) func(ctx context.
 
 func (a *UserWebHandlers) HandleSignUpConfirm() http.HandlerFunc {
 	userService := a.userService
-	userRepository := a.userRepository
 	return func(w http.ResponseWriter, r *http.Request) {
-		confirmationIDParam := chi.URLParam(r, "confirmation-id")
-
-		userID, err := userRepository.FindUserIDByConfirmationID(r.Context(), confirmationIDParam)
-		if errors.Is(err, ErrUserNotFound) {
+		confirmationID, err := uuid.Parse(chi.URLParam(r, "confirmation-id"))
+		if err != nil {
 			http.Redirect(w, r, "/signup", http.StatusFound)
 			return
 		}
+
+		err = userService.ConfirmSignup(r.Context(), confirmationID)
+		if errors.Is(err, ErrConfirmationExpired) {
+			http.Redirect(w, r, "/signup/resend?info=expired", http.StatusFound)
+			return
+		}
 		if err != nil {
 			http.Redirect(w, r, "/signup", http.StatusFound)
 			return
 		}
 
-		err = userService.ConfirmUser(r.Context(), userID)
+		http.Redirect(w, r, "/login?info=confirm_successfull", http.StatusFound)
+	}
+}
+
+func (a *UserWebHandlers) HandleConfirmationResendPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formModel := confirmationResendFormModel{}
+		formModel.CSRFToken = csrf.Token(r)
+
+		infoMessage := ""
+		if r.URL.Query().Get("info") == "expired" {
+			infoMessage = "Your confirmation link has expired. Request a new one to activate your account."
+		}
+
+		shared.RenderHTML(w, ConfirmationResendPage(r.URL.Path, ConfirmationResendForm(formModel, infoMessage, nil)))
+	}
+}
+
+// HandleConfirmationResendForm sends a new confirmation link to an unconfirmed email
+func (a *UserWebHandlers) HandleConfirmationResendForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			formModel := confirmationResendFormModel{}
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, ConfirmationResendForm(formModel, "", nil))
+			return
+		}
+
+		var formModel confirmationResendFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
 		if err != nil {
-			http.Redirect(w, r, "/signup", http.StatusFound)
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, ConfirmationResendForm(formModel, "", nil))
 			return
 		}
 
-		http.Redirect(w, r, "/login?info=confirm_successfull", http.StatusFound)
+		err = validator.Struct(formModel)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			fieldErrors := map[string]string{
+				"EMail": "Invalid email.",
+			}
+			shared.RenderHTML(w, ConfirmationResendForm(formModel, "", fieldErrors))
+			return
+		}
+
+		err = userService.ResendConfirmation(r.Context(), formModel.EMail)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		shared.RenderHTML(w, ConfirmationResendSuccess(formModel))
 	}
 }
 
@@ -187,6 +293,200 @@ func (a *UserWebHandlers) HandleSignUpForm() http.HandlerFunc {
 	}
 }
 
+func (a *UserWebHandlers) HandleTimeZonePage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		formModel := timeZoneFormModel{
+			TimeZone: principal.Location().String(),
+		}
+		formModel.CSRFToken = csrf.Token(r)
+
+		if !hx.IsHXRequest(r) {
+			pageContext := &shared.PageContext{
+				Principal:   principal,
+				CurrentPath: r.URL.Path,
+				Title:       "Time Zone",
+			}
+			shared.RenderHTML(w, TimeZonePage(pageContext, formModel))
+			return
+		}
+
+		w.Header().Set("HX-Trigger", "baralga__main_content_modal-show")
+		shared.RenderHTML(w, TimeZoneForm(formModel, nil))
+	}
+}
+
+func (a *UserWebHandlers) HandleTimeZoneForm() http.HandlerFunc {
+	isProduction := a.config.IsProduction()
+	validator := validator.New()
+	userService := a.userService
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := shared.MustPrincipalFromContext(r.Context())
+
+		err := r.ParseForm()
+		if err != nil {
+			formModel := timeZoneFormModel{}
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, TimeZoneForm(formModel, nil))
+			return
+		}
+
+		var formModel timeZoneFormModel
+		err = schema.NewDecoder().Decode(&formModel, r.PostForm)
+		if err != nil {
+			formModel.CSRFToken = csrf.Token(r)
+			shared.RenderHTML(w, TimeZoneForm(formModel, nil))
+			return
+		}
+
+		err = validator.Struct(formModel)
+		if err != nil || !IsValidTimeZone(formModel.TimeZone) {
+			formModel.CSRFToken = csrf.Token(r)
+			fieldErrors := map[string]string{
+				"TimeZone": "Unknown time zone.",
+			}
+			shared.RenderHTML(w, TimeZoneForm(formModel, fieldErrors))
+			return
+		}
+
+		err = userService.UpdateTimeZone(r.Context(), principal.Username, formModel.TimeZone)
+		if err != nil {
+			shared.RenderProblemHTML(w, isProduction, err)
+			return
+		}
+
+		// refresh session so that the new time zone becomes part of the principal
+		refreshURI := fmt.Sprintf("/session/refresh?redirect=%v", url.QueryEscape(currentRequestURI(r)))
+		if !hx.IsHXRequest(r) {
+			http.Redirect(w, r, refreshURI, http.StatusFound)
+			return
+		}
+
+		w.Header().Set("HX-Redirect", refreshURI)
+	}
+}
+
+// currentRequestURI is the uri of the page the htmx request was sent from
+func currentRequestURI(r *http.Request) string {
+	currentURL, err := url.Parse(r.Header.Get("HX-Current-URL"))
+	if err != nil || currentURL.Path == "" {
+		return "/"
+	}
+
+	return currentURL.RequestURI()
+}
+
+func TimeZonePage(pageContext *shared.PageContext, formModel timeZoneFormModel) g.Node {
+	return shared.Page(
+		pageContext.Title,
+		pageContext.CurrentPath,
+		[]g.Node{
+			shared.Navbar(pageContext),
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("mt-4 mb-4"),
+					),
+					TimeZoneForm(formModel, nil),
+				),
+			),
+		},
+	)
+}
+
+func TimeZoneForm(formModel timeZoneFormModel, fieldErrors map[string]string) g.Node {
+	options := timeZones
+	if formModel.TimeZone != "" && !slices.Contains(options, formModel.TimeZone) {
+		options = append([]string{formModel.TimeZone}, options...)
+	}
+
+	return FormEl(
+		ID("baralga__main_content_modal_content"),
+		Class("modal-content"),
+		ghx.Post("/settings/time-zone"),
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Div(
+			Class("modal-header"),
+			H2(
+				Class("modal-title"),
+				g.Text("Time Zone"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("btn-close"),
+			),
+		),
+		Div(
+			Class("modal-body"),
+			Input(
+				Type("hidden"),
+				Name("CSRFToken"),
+				Value(formModel.CSRFToken),
+			),
+			Div(
+				Class("mb-3"),
+				Label(
+					Class("form-label"),
+					g.Attr("for", "TimeZone"),
+					g.Text("Time Zone"),
+				),
+				Select(
+					ID("TimeZone"),
+					Name("TimeZone"),
+					g.If(
+						fieldErrors["TimeZone"] != "",
+						Class("form-select is-invalid"),
+					),
+					g.If(
+						fieldErrors["TimeZone"] == "",
+						Class("form-select"),
+					),
+					g.Group(
+						g.Map(options, func(timeZone string) g.Node {
+							return Option(
+								Value(timeZone),
+								g.Text(timeZone),
+								g.If(formModel.TimeZone == timeZone, Selected()),
+							)
+						}),
+					),
+				),
+				g.If(
+					fieldErrors["TimeZone"] != "",
+					Div(
+						Class("invalid-feedback"),
+						g.Text(fieldErrors["TimeZone"]),
+					),
+				),
+				Div(
+					Class("form-text"),
+					g.Text("Times of your activities are shown and entered in this time zone."),
+				),
+			),
+		),
+		Div(
+			Class("modal-footer"),
+			Button(
+				Type("submit"),
+				Class("text-center btn btn-primary"),
+				I(Class("bi-save me-2")),
+				g.Text("Save"),
+			),
+			A(
+				g.Attr("data-bs-dismiss", "modal"),
+				Class("text-center btn btn-secondary"),
+				I(Class("bi-x me-2")),
+				g.Text("Cancel"),
+			),
+		),
+	)
+}
+
 func (a *UserWebHandlers) SignUpPage(currentPath string, formModel signupFormModel) g.Node {
 	return shared.Page(
 		"Sign Up",
@@ -387,3 +687,122 @@ func mapSignUpFormToUser(formModel signupFormModel, encryptedPassword string) Us
 		Origin:   "baralga",
 	}
 }
+
+func ConfirmationResendPage(currentPath string, content g.Node) g.Node {
+	return shared.Page(
+		"Confirm Email",
+		currentPath,
+		[]g.Node{
+			Section(
+				Class("full-center"),
+				Div(
+					Class("container"),
+					Div(
+						Class("d-flex justify-content-center align-items-center mt-2 mb-3"),
+						Img(
+							Alt("Baralga"),
+							Class("img-responsive"),
+							Src("/assets/baralga_192.png"),
+						),
+						Div(
+							Class("ms-4"),
+							H2(
+								g.Text("Baralga"),
+								Small(
+									Class("text-muted"),
+									StyleAttr("display: block; font-size: 70%;"),
+									g.Text("project time tracking"),
+								),
+							),
+						),
+					),
+					content,
+				),
+			),
+		},
+	)
+}
+
+func ConfirmationResendSuccess(formModel confirmationResendFormModel) g.Node {
+	return Div(
+		Class("alert alert-success"),
+		Role("alert"),
+		g.Textf("If there is an unconfirmed account for %s, we've sent a new confirmation link. The link can be used within the next 48 hours.", formModel.EMail),
+	)
+}
+
+func ConfirmationResendForm(formModel confirmationResendFormModel, infoMessage string, fieldErrors map[string]string) g.Node {
+	return FormEl(
+		ID("confirmation_resend_form"),
+		ghx.Post("/signup/resend"),
+
+		ghx.Target("this"),
+		ghx.Swap("outerHTML"),
+
+		Input(
+			Type("hidden"),
+			Name("CSRFToken"),
+			Value(formModel.CSRFToken),
+		),
+		g.If(
+			infoMessage != "",
+			Div(
+				Class("alert alert-info text-center"),
+				Role("alert"),
+				Span(g.Text(infoMessage)),
+			),
+		),
+		P(
+			g.Text("Enter the email you signed up with and we'll send you a new link to confirm it."),
+		),
+		Div(
+			Class("form-floating mb-3"),
+			Input(
+				ID("email"),
+				Required(),
+				Type("email"),
+				Name("EMail"),
+				g.If(
+					fieldErrors["EMail"] != "",
+					Class("form-control is-invalid"),
+				),
+				g.If(
+					fieldErrors["EMail"] == "",
+					Class("form-control"),
+				),
+				g.Attr("placeholder", "john.doe@mail.com"),
+				Value(formModel.EMail),
+			),
+			Label(
+				g.Attr("for", "email"),
+				g.Text("E-Mail"),
+			),
+			g.If(
+				fieldErrors["EMail"] != "",
+				Div(
+					Class("invalid-feedback"),
+					g.Text(fieldErrors["EMail"]),
+				),
+			),
+		),
+		Div(
+			Class("container-fluid text-center"),
+			Button(
+				Type("submit"),
+				Class("btn btn-primary w-100"),
+				g.Text("Send link"),
+			),
+		),
+		Div(
+			Class("row justify-content-around mt-2"),
+			Div(
+				Class("col-4 text-center"),
+				A(
+					Href("/login"),
+					Class("link-secondary"),
+					g.Text("Back to sign in"),
+				),
+			),
+		),
+	)
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/baralga/shared"
 	"github.com/go-chi/chi/v5"
@@ -198,3 +199,171 @@ func TestHandleSignUpConfirmWithoutConfirmation(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(l.String(), "/signup")
 }
+
+func TestHandleSignUpConfirmWithExpiredConfirmation(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	userRepository.confirmations[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	a := &UserWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+		userRepository: userRepository,
+	}
+
+	r, _ := http.NewRequest("GET", fmt.Sprintf("/signup/confirm/%v", shared.ConfirmationIdSample), nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("confirmation-id", shared.ConfirmationIdSample.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	a.HandleSignUpConfirm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusFound)
+
+	l, err := httpRec.Result().Location()
+	is.NoErr(err)
+	is.Equal(l.String(), "/signup/resend?info=expired")
+}
+
+func TestHandleConfirmationResendPage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &UserWebHandlers{
+		config: &shared.Config{},
+	}
+
+	r, _ := http.NewRequest("GET", "/signup/resend?info=expired", nil)
+
+	a.HandleConfirmationResendPage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Confirm Email # Baralga"))
+	is.True(strings.Contains(htmlBody, "Your confirmation link has expired."))
+}
+
+func TestHandleConfirmationResendForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+	unconfirmedUser := &User{
+		ID:             uuid.New(),
+		Username:       "newbie@baralga.com",
+		EMail:          "newbie@baralga.com",
+		OrganizationID: uuid.New(),
+	}
+	_, err := userRepository.InsertUserWithConfirmationID(context.Background(), unconfirmedUser, uuid.New())
+	is.NoErr(err)
+
+	mailResource := shared.NewInMemMailResource()
+
+	a := &UserWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			config:         &shared.Config{},
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			mailResource:   mailResource,
+			userRepository: userRepository,
+		},
+		userRepository: userRepository,
+	}
+
+	data := url.Values{}
+	data["EMail"] = []string{"newbie@baralga.com"}
+
+	r, _ := http.NewRequest("POST", "/signup/resend", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	a.HandleConfirmationResendForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "a new confirmation link"))
+	is.Equal(len(mailResource.Mails), 1)
+}
+
+func TestHandleTimeZonePage(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	a := &UserWebHandlers{
+		config: &shared.Config{},
+	}
+
+	r, _ := http.NewRequest("GET", "/settings/time-zone", nil)
+	r.Header.Add("HX-Request", "true")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{TimeZone: "America/New_York"}))
+
+	a.HandleTimeZonePage()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "<option value=\"America/New_York\" selected>"))
+}
+
+func TestHandleTimeZoneForm(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &UserWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+		userRepository: userRepository,
+	}
+
+	data := url.Values{}
+	data["TimeZone"] = []string{"America/New_York"}
+
+	r, _ := http.NewRequest("POST", "/settings/time-zone", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Add("HX-Request", "true")
+	r.Header.Add("HX-Current-URL", "http://localhost:8080/reports?t=week")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{Username: "admin@baralga.com"}))
+
+	a.HandleTimeZoneForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+	is.Equal(httpRec.Header().Get("HX-Redirect"), "/session/refresh?redirect=%2Freports%3Ft%3Dweek")
+	is.Equal(userRepository.users[0].TimeZone, "America/New_York")
+}
+
+func TestHandleTimeZoneFormWithInvalidTimeZone(t *testing.T) {
+	is := is.New(t)
+	httpRec := httptest.NewRecorder()
+
+	userRepository := NewInMemUserRepository()
+
+	a := &UserWebHandlers{
+		config: &shared.Config{},
+		userService: &UserService{
+			repositoryTxer: shared.NewInMemRepositoryTxer(),
+			userRepository: userRepository,
+		},
+		userRepository: userRepository,
+	}
+
+	data := url.Values{}
+	data["TimeZone"] = []string{"Mars/Olympus_Mons"}
+
+	r, _ := http.NewRequest("POST", "/settings/time-zone", strings.NewReader(data.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(shared.ToContextWithPrincipal(r.Context(), &shared.Principal{Username: "admin@baralga.com"}))
+
+	a.HandleTimeZoneForm()(httpRec, r)
+	is.Equal(httpRec.Result().StatusCode, http.StatusOK)
+
+	htmlBody := httpRec.Body.String()
+	is.True(strings.Contains(htmlBody, "Unknown time zone."))
+	is.Equal(userRepository.users[0].TimeZone, DefaultTimeZone)
+}